@@ -0,0 +1,493 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etcdFlagKind describes how etcd parses value of a flag.
+type etcdFlagKind int
+
+const (
+	etcdFlagString etcdFlagKind = iota
+	etcdFlagBool
+	etcdFlagInt
+	etcdFlagUint
+	etcdFlagDuration
+	etcdFlagEnum
+)
+
+// etcdFlag is the metadata of a single etcd command line flag.
+type etcdFlag struct {
+	kind etcdFlagKind
+	// values lists accepted values of etcdFlagEnum flags.
+	values []string
+}
+
+// etcdFlagSets contains flags accepted by every supported etcd minor version.
+// Metadata is embedded into the binary, so options can be validated at admission without pulling images.
+var etcdFlagSets = map[string]map[string]etcdFlag{
+	"3.5": etcd35Flags,
+	"3.6": etcd36Flags,
+}
+
+// etcd35Flags is the flag set of etcd v3.5.x, generated from etcdmain/config.go of v3.5.13.
+var etcd35Flags = map[string]etcdFlag{
+	"advertise-client-urls":        {kind: etcdFlagString},
+	"auth-token":                   {kind: etcdFlagString},
+	"auth-token-ttl":               {kind: etcdFlagUint},
+	"auto-compaction-mode":         {kind: etcdFlagEnum, values: []string{"periodic", "revision"}},
+	"auto-compaction-retention":    {kind: etcdFlagString},
+	"auto-tls":                     {kind: etcdFlagBool},
+	"backend-batch-interval":       {kind: etcdFlagDuration},
+	"backend-batch-limit":          {kind: etcdFlagInt},
+	"backend-bbolt-freelist-type":  {kind: etcdFlagEnum, values: []string{"map", "array"}},
+	"bcrypt-cost":                  {kind: etcdFlagUint},
+	"cert-file":                    {kind: etcdFlagString},
+	"cipher-suites":                {kind: etcdFlagString},
+	"client-cert-allowed-hostname": {kind: etcdFlagString},
+	"client-cert-auth":             {kind: etcdFlagBool},
+	"client-cert-file":             {kind: etcdFlagString},
+	"client-crl-file":              {kind: etcdFlagString},
+	"client-key-file":              {kind: etcdFlagString},
+	"config-file":                  {kind: etcdFlagString},
+	"cors":                         {kind: etcdFlagString},
+	"data-dir":                     {kind: etcdFlagString},
+	"discovery":                    {kind: etcdFlagString},
+	"discovery-fallback":           {kind: etcdFlagEnum, values: []string{"proxy", "exit"}},
+	"discovery-proxy":              {kind: etcdFlagString},
+	"discovery-srv":                {kind: etcdFlagString},
+	"discovery-srv-name":           {kind: etcdFlagString},
+	"election-timeout":             {kind: etcdFlagUint},
+	"enable-grpc-gateway":          {kind: etcdFlagBool},
+	"enable-log-rotation":          {kind: etcdFlagBool},
+	"enable-pprof":                 {kind: etcdFlagBool},
+	"enable-v2":                    {kind: etcdFlagBool},
+	"experimental-bootstrap-defrag-threshold-megabytes": {kind: etcdFlagUint},
+	"experimental-compact-hash-check-enabled":           {kind: etcdFlagBool},
+	"experimental-compact-hash-check-time":              {kind: etcdFlagDuration},
+	"experimental-compaction-batch-limit":               {kind: etcdFlagInt},
+	"experimental-corrupt-check-time":                   {kind: etcdFlagDuration},
+	"experimental-distributed-tracing-address":          {kind: etcdFlagString},
+	"experimental-distributed-tracing-instance-id":      {kind: etcdFlagString},
+	"experimental-distributed-tracing-sampling-rate":    {kind: etcdFlagInt},
+	"experimental-distributed-tracing-service-name":     {kind: etcdFlagString},
+	"experimental-downgrade-check-time":                 {kind: etcdFlagDuration},
+	"experimental-enable-distributed-tracing":           {kind: etcdFlagBool},
+	"experimental-enable-lease-checkpoint":              {kind: etcdFlagBool},
+	"experimental-enable-lease-checkpoint-persist":      {kind: etcdFlagBool},
+	"experimental-enable-v2v3":                          {kind: etcdFlagString},
+	"experimental-initial-corrupt-check":                {kind: etcdFlagBool},
+	"experimental-memory-mlock":                         {kind: etcdFlagBool},
+	"experimental-peer-skip-client-san-verification":    {kind: etcdFlagBool},
+	"experimental-txn-mode-write-with-shared-buffer":    {kind: etcdFlagBool},
+	"experimental-warning-apply-duration":               {kind: etcdFlagDuration},
+	"experimental-watch-progress-notify-interval":       {kind: etcdFlagDuration},
+	"force-new-cluster":                                 {kind: etcdFlagBool},
+	"grpc-keepalive-interval":                           {kind: etcdFlagDuration},
+	"grpc-keepalive-min-time":                           {kind: etcdFlagDuration},
+	"grpc-keepalive-timeout":                            {kind: etcdFlagDuration},
+	"heartbeat-interval":                                {kind: etcdFlagUint},
+	"host-whitelist":                                    {kind: etcdFlagString},
+	"initial-advertise-peer-urls":                       {kind: etcdFlagString},
+	"initial-cluster":                                   {kind: etcdFlagString},
+	"initial-cluster-state":                             {kind: etcdFlagEnum, values: []string{"new", "existing"}},
+	"initial-cluster-token":                             {kind: etcdFlagString},
+	"initial-election-tick-advance":                     {kind: etcdFlagBool},
+	"key-file":                                          {kind: etcdFlagString},
+	"listen-client-http-urls":                           {kind: etcdFlagString},
+	"listen-client-urls":                                {kind: etcdFlagString},
+	"listen-metrics-urls":                               {kind: etcdFlagString},
+	"listen-peer-urls":                                  {kind: etcdFlagString},
+	"log-level":                                         {kind: etcdFlagEnum, values: []string{"debug", "info", "warn", "error", "panic", "fatal"}},
+	"log-outputs":                                       {kind: etcdFlagString},
+	"log-rotation-config-json":                          {kind: etcdFlagString},
+	"logger":                                            {kind: etcdFlagEnum, values: []string{"zap"}},
+	"max-concurrent-streams":                            {kind: etcdFlagUint},
+	"max-request-bytes":                                 {kind: etcdFlagUint},
+	"max-snapshots":                                     {kind: etcdFlagUint},
+	"max-txn-ops":                                       {kind: etcdFlagUint},
+	"max-wals":                                          {kind: etcdFlagUint},
+	"metrics":                                           {kind: etcdFlagEnum, values: []string{"basic", "extensive"}},
+	"name":                                              {kind: etcdFlagString},
+	"peer-auto-tls":                                     {kind: etcdFlagBool},
+	"peer-cert-allowed-cn":                              {kind: etcdFlagString},
+	"peer-cert-allowed-hostname":                        {kind: etcdFlagString},
+	"peer-cert-file":                                    {kind: etcdFlagString},
+	"peer-client-cert-auth":                             {kind: etcdFlagBool},
+	"peer-client-cert-file":                             {kind: etcdFlagString},
+	"peer-client-key-file":                              {kind: etcdFlagString},
+	"peer-crl-file":                                     {kind: etcdFlagString},
+	"peer-key-file":                                     {kind: etcdFlagString},
+	"peer-trusted-ca-file":                              {kind: etcdFlagString},
+	"pre-vote":                                          {kind: etcdFlagBool},
+	"proxy":                                             {kind: etcdFlagEnum, values: []string{"off", "readonly", "on"}},
+	"proxy-dial-timeout":                                {kind: etcdFlagUint},
+	"proxy-failure-wait":                                {kind: etcdFlagUint},
+	"proxy-read-timeout":                                {kind: etcdFlagUint},
+	"proxy-refresh-interval":                            {kind: etcdFlagUint},
+	"proxy-write-timeout":                               {kind: etcdFlagUint},
+	"quota-backend-bytes":                               {kind: etcdFlagInt},
+	"raft-read-timeout":                                 {kind: etcdFlagDuration},
+	"raft-write-timeout":                                {kind: etcdFlagDuration},
+	"self-signed-cert-validity":                         {kind: etcdFlagUint},
+	"snapshot-count":                                    {kind: etcdFlagUint},
+	"socket-reuse-address":                              {kind: etcdFlagBool},
+	"socket-reuse-port":                                 {kind: etcdFlagBool},
+	"strict-reconfig-check":                             {kind: etcdFlagBool},
+	"tls-max-version":                                   {kind: etcdFlagEnum, values: []string{"TLS1.2", "TLS1.3"}},
+	"tls-min-version":                                   {kind: etcdFlagEnum, values: []string{"TLS1.2", "TLS1.3"}},
+	"trusted-ca-file":                                   {kind: etcdFlagString},
+	"unsafe-no-fsync":                                   {kind: etcdFlagBool},
+	"v2-deprecation":                                    {kind: etcdFlagEnum, values: []string{"not-yet", "write-only", "write-only-drop-data", "gone"}},
+	"wal-dir":                                           {kind: etcdFlagString},
+}
+
+// etcd36Flags is the flag set of etcd v3.6.x. Compared to v3.5 the v2 store, v2 proxy and v2 discovery flags
+// are removed, v3 discovery and feature gates are added and experimental flags got stable names.
+// Deprecated experimental flags are still accepted.
+var etcd36Flags = newEtcd36Flags()
+
+func newEtcd36Flags() map[string]etcdFlag {
+	flags := maps.Clone(etcd35Flags)
+	for _, name := range []string{
+		"enable-v2",
+		"experimental-enable-v2v3",
+		"discovery-fallback",
+		"discovery-proxy",
+		"proxy",
+		"proxy-dial-timeout",
+		"proxy-failure-wait",
+		"proxy-read-timeout",
+		"proxy-refresh-interval",
+		"proxy-write-timeout",
+	} {
+		delete(flags, name)
+	}
+	for name, flag := range map[string]etcdFlag{
+		"bootstrap-defrag-threshold-megabytes":        {kind: etcdFlagUint},
+		"compact-hash-check-time":                     {kind: etcdFlagDuration},
+		"compaction-batch-limit":                      {kind: etcdFlagInt},
+		"compaction-sleep-interval":                   {kind: etcdFlagDuration},
+		"corrupt-check-time":                          {kind: etcdFlagDuration},
+		"discovery-cacert":                            {kind: etcdFlagString},
+		"discovery-cert":                              {kind: etcdFlagString},
+		"discovery-dial-timeout":                      {kind: etcdFlagDuration},
+		"discovery-endpoints":                         {kind: etcdFlagString},
+		"discovery-insecure-skip-tls-verify":          {kind: etcdFlagBool},
+		"discovery-insecure-transport":                {kind: etcdFlagBool},
+		"discovery-keepalive-time":                    {kind: etcdFlagDuration},
+		"discovery-keepalive-timeout":                 {kind: etcdFlagDuration},
+		"discovery-key":                               {kind: etcdFlagString},
+		"discovery-password":                          {kind: etcdFlagString},
+		"discovery-request-timeout":                   {kind: etcdFlagDuration},
+		"discovery-token":                             {kind: etcdFlagString},
+		"discovery-user":                              {kind: etcdFlagString},
+		"distributed-tracing-address":                 {kind: etcdFlagString},
+		"distributed-tracing-instance-id":             {kind: etcdFlagString},
+		"distributed-tracing-sampling-rate":           {kind: etcdFlagInt},
+		"distributed-tracing-service-name":            {kind: etcdFlagString},
+		"downgrade-check-time":                        {kind: etcdFlagDuration},
+		"enable-distributed-tracing":                  {kind: etcdFlagBool},
+		"experimental-compaction-sleep-interval":      {kind: etcdFlagDuration},
+		"experimental-max-learners":                   {kind: etcdFlagInt},
+		"experimental-set-member-localaddr":           {kind: etcdFlagBool},
+		"experimental-snapshot-catchup-entries":       {kind: etcdFlagUint},
+		"experimental-stop-grpc-service-on-defrag":    {kind: etcdFlagBool},
+		"experimental-warning-unary-request-duration": {kind: etcdFlagDuration},
+		"feature-gates":                               {kind: etcdFlagString},
+		"log-format":                                  {kind: etcdFlagEnum, values: []string{"json", "console"}},
+		"max-learners":                                {kind: etcdFlagInt},
+		"memory-mlock":                                {kind: etcdFlagBool},
+		"peer-skip-client-san-verification":           {kind: etcdFlagBool},
+		"snapshot-catchup-entries":                    {kind: etcdFlagUint},
+		"v2-deprecation":                              {kind: etcdFlagEnum, values: []string{"write-only", "write-only-drop-data", "gone"}},
+		"warning-apply-duration":                      {kind: etcdFlagDuration},
+		"warning-unary-request-duration":              {kind: etcdFlagDuration},
+		"watch-progress-notify-interval":              {kind: etcdFlagDuration},
+	} {
+		flags[name] = flag
+	}
+	return flags
+}
+
+// etcdFlagRenames maps deprecated flags to the flags replacing them in etcd minor versions.
+var etcdFlagRenames = map[string]map[string]string{
+	"3.6": {
+		"experimental-bootstrap-defrag-threshold-megabytes": "bootstrap-defrag-threshold-megabytes",
+		"experimental-compact-hash-check-time":              "compact-hash-check-time",
+		"experimental-compaction-batch-limit":               "compaction-batch-limit",
+		"experimental-compaction-sleep-interval":            "compaction-sleep-interval",
+		"experimental-corrupt-check-time":                   "corrupt-check-time",
+		"experimental-distributed-tracing-address":          "distributed-tracing-address",
+		"experimental-distributed-tracing-instance-id":      "distributed-tracing-instance-id",
+		"experimental-distributed-tracing-sampling-rate":    "distributed-tracing-sampling-rate",
+		"experimental-distributed-tracing-service-name":     "distributed-tracing-service-name",
+		"experimental-downgrade-check-time":                 "downgrade-check-time",
+		"experimental-enable-distributed-tracing":           "enable-distributed-tracing",
+		"experimental-max-learners":                         "max-learners",
+		"experimental-memory-mlock":                         "memory-mlock",
+		"experimental-peer-skip-client-san-verification":    "peer-skip-client-san-verification",
+		"experimental-snapshot-catchup-entries":             "snapshot-catchup-entries",
+		"experimental-warning-apply-duration":               "warning-apply-duration",
+		"experimental-warning-unary-request-duration":       "warning-unary-request-duration",
+		"experimental-watch-progress-notify-interval":       "watch-progress-notify-interval",
+	},
+}
+
+// etcdFeatureGateFlags maps deprecated boolean flags to the feature gates replacing them in etcd minor versions.
+var etcdFeatureGateFlags = map[string]map[string]string{
+	"3.6": {
+		"experimental-compact-hash-check-enabled":        "CompactHashCheck",
+		"experimental-enable-lease-checkpoint":           "LeaseCheckpoint",
+		"experimental-enable-lease-checkpoint-persist":   "LeaseCheckpointPersist",
+		"experimental-initial-corrupt-check":             "InitialCorruptCheck",
+		"experimental-set-member-localaddr":              "SetMemberLocalAddr",
+		"experimental-stop-grpc-service-on-defrag":       "StopGRPCServiceOnDefrag",
+		"experimental-txn-mode-write-with-shared-buffer": "TxnModeWriteWithSharedBuffer",
+	},
+}
+
+// deprecatedEtcdFlagReplacement returns the flag or feature gate replacing deprecated flag in etcd minor version.
+func deprecatedEtcdFlagReplacement(version, name string) (string, bool) {
+	if replacement, ok := etcdFlagRenames[version][name]; ok {
+		return "--" + replacement, true
+	}
+	if gate, ok := etcdFeatureGateFlags[version][name]; ok {
+		return "--feature-gates=" + gate, true
+	}
+	return "", false
+}
+
+// experimentalFlagPrefix is prepended to names of spec.experimentalOptions.
+const experimentalFlagPrefix = "experimental-"
+
+// specOptions returns spec.options merged with prefixed spec.experimentalOptions. Experimental options are admitted
+// only if they are enabled in the operator, which drops them from clusters it renders otherwise.
+func (r *EtcdCluster) specOptions() map[string]string {
+	if len(r.Spec.ExperimentalOptions) == 0 {
+		return r.Spec.Options
+	}
+	options := make(map[string]string, len(r.Spec.Options)+len(r.Spec.ExperimentalOptions))
+	maps.Copy(options, r.Spec.Options)
+	for name, value := range r.Spec.ExperimentalOptions {
+		options[experimentalFlagPrefix+name] = value
+	}
+	return options
+}
+
+// EtcdFlagName returns name under which etcd version of the cluster accepts the flag,
+// e.g. experimental flags got stable names in etcd 3.6.
+func (r *EtcdCluster) EtcdFlagName(name string) string {
+	version, ok := etcdMinorVersion(r.EtcdImage())
+	if !ok {
+		return name
+	}
+	if replacement, renamed := etcdFlagRenames[version][name]; renamed {
+		return replacement
+	}
+	return name
+}
+
+// EtcdOptions returns spec.options and spec.experimentalOptions converted for the etcd version
+// used by the cluster. Deprecated flags are renamed and flags replaced by feature gates are merged into
+// feature-gates option. Options explicitly set with the new name take precedence over deprecated ones.
+func (r *EtcdCluster) EtcdOptions() map[string]string {
+	specOptions := r.specOptions()
+	version, ok := etcdMinorVersion(r.EtcdImage())
+	if !ok || (len(etcdFlagRenames[version]) == 0 && len(etcdFeatureGateFlags[version]) == 0) {
+		return specOptions
+	}
+
+	options := make(map[string]string, len(specOptions))
+	var gates []string
+	for name, value := range specOptions {
+		if replacement, renamed := etcdFlagRenames[version][name]; renamed {
+			if _, explicit := specOptions[replacement]; !explicit {
+				options[replacement] = value
+			}
+			continue
+		}
+		if gate, replaced := etcdFeatureGateFlags[version][name]; replaced {
+			if value == "" {
+				value = "true"
+			}
+			gates = append(gates, gate+"="+value)
+			continue
+		}
+		options[name] = value
+	}
+	if len(gates) > 0 {
+		slices.Sort(gates)
+		if explicit := options["feature-gates"]; explicit != "" {
+			// explicitly set gates come last, so they override converted flags
+			gates = append(gates, explicit)
+		}
+		options["feature-gates"] = strings.Join(gates, ",")
+	}
+	return options
+}
+
+var etcdMinorVersionRe = regexp.MustCompile(`^\d+\.\d+$`)
+
+var etcdImageVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)(\.\d+)?([-+].*)?$`)
+
+// etcdMinorVersion extracts etcd minor version (e.g. "3.5") from the image tag.
+// Returns false if image is pinned by digest only or tag is not a semantic version.
+func etcdMinorVersion(image string) (string, bool) {
+	m := matchEtcdImageVersion(image)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "." + m[2], true
+}
+
+// etcdImageVersion extracts etcd version (e.g. "3.5.12") from the image tag.
+// Returns false if image is pinned by digest only or tag is not a semantic version.
+func etcdImageVersion(image string) (string, bool) {
+	m := matchEtcdImageVersion(image)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "." + m[2] + m[3], true
+}
+
+func matchEtcdImageVersion(image string) []string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i:], "/") {
+		return nil
+	}
+	return etcdImageVersionRe.FindStringSubmatch(image[i+1:])
+}
+
+// etcdFlagKinds maps names of flag kinds accepted by SetEtcdFlag.
+var etcdFlagKinds = map[string]etcdFlagKind{
+	"string":   etcdFlagString,
+	"bool":     etcdFlagBool,
+	"int":      etcdFlagInt,
+	"uint":     etcdFlagUint,
+	"duration": etcdFlagDuration,
+	"enum":     etcdFlagEnum,
+}
+
+// SetEtcdFlag adds or replaces metadata of the flag of the etcd minor version, e.g. from overrides of disconnected
+// environments running etcd releases newer than the operator. Flag set of a version unknown to the operator starts
+// as a copy of the newest known one. Kind is one of string, bool, int, uint, duration or enum, values list values
+// accepted by enum flags. It must be called before the webhook serves requests.
+func SetEtcdFlag(version, name, kind string, values []string) error {
+	if !etcdMinorVersionRe.MatchString(version) {
+		return fmt.Errorf("version %q is not an etcd minor version like 3.6", version)
+	}
+	flagKind, ok := etcdFlagKinds[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q of flag %s", kind, name)
+	}
+	if flagKind == etcdFlagEnum && len(values) == 0 {
+		return fmt.Errorf("enum flag %s requires values", name)
+	}
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid flag name %q", name)
+	}
+	flags, ok := etcdFlagSets[version]
+	if !ok {
+		flags = maps.Clone(etcdFlagSets[newestEtcdFlagSet()])
+		etcdFlagSets[version] = flags
+	}
+	flags[name] = etcdFlag{kind: flagKind, values: slices.Clone(values)}
+	return nil
+}
+
+// newestEtcdFlagSet returns the newest minor version with known flags.
+func newestEtcdFlagSet() string {
+	newest, newestMinor := "", -1
+	for version := range etcdFlagSets {
+		_, minor, _ := strings.Cut(version, ".")
+		if n, err := strconv.Atoi(minor); err == nil && n > newestMinor {
+			newest, newestMinor = version, n
+		}
+	}
+	return newest
+}
+
+// validateEtcdFlag checks that value is accepted by the flag. Empty value means the flag is passed without value.
+func validateEtcdFlag(flag etcdFlag, value string) error {
+	if value == "" {
+		if flag.kind != etcdFlagBool {
+			return fmt.Errorf("flag requires a value")
+		}
+		return nil
+	}
+	var err error
+	switch flag.kind {
+	case etcdFlagBool:
+		_, err = strconv.ParseBool(value)
+	case etcdFlagInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case etcdFlagUint:
+		_, err = strconv.ParseUint(value, 10, 64)
+	case etcdFlagDuration:
+		_, err = time.ParseDuration(value)
+	case etcdFlagEnum:
+		if !slices.Contains(flag.values, value) {
+			err = fmt.Errorf("supported values: %s", strings.Join(flag.values, ", "))
+		}
+	}
+	return err
+}
+
+// closestEtcdFlag returns the flag with the smallest edit distance to name, if the distance is small enough to be a typo.
+func closestEtcdFlag(flags map[string]etcdFlag, name string) (string, bool) {
+	const maxTypoDistance = 2
+	closest, distance := "", maxTypoDistance+1
+	for candidate := range flags {
+		d := levenshtein(name, candidate)
+		if d < distance || d == distance && candidate < closest {
+			closest, distance = candidate, d
+		}
+	}
+	return closest, distance <= maxTypoDistance
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -17,12 +17,34 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-const DefaultEtcdImage = "quay.io/coreos/etcd:v3.5.12"
+// DefaultEtcdImage is the etcd image of clusters without etcd image in the pod template.
+// It is embedded into the binary and may be overridden at startup, e.g. by a mirror in disconnected environments.
+var DefaultEtcdImage = "quay.io/coreos/etcd:v3.5.12"
+
+const (
+	// DefaultDataMountPath is the mount path of the data volume of clusters without spec.storage.mountPath.
+	DefaultDataMountPath = "/var/run/etcd"
+	// DefaultDataDir is the etcd data directory in the data volume of clusters without spec.storage.dataDir.
+	DefaultDataDir = "default.etcd"
+	// DefaultHostPath is the node directory keeping data of members of clusters without spec.storage.hostPath.path.
+	DefaultHostPath = "/var/lib/etcd-operator/$(NAMESPACE)/$(CLUSTER)"
+	// DefaultEtcdUser is the user and group id of etcd of clusters with spec.storage.volumePermissions.
+	DefaultEtcdUser = 1000
+)
 
 // EtcdClusterSpec defines the desired state of EtcdCluster
 type EtcdClusterSpec struct {
@@ -31,41 +53,530 @@ type EtcdClusterSpec struct {
 	// +kubebuilder:default:=3
 	// +kubebuilder:validation:Minimum:=0
 	Replicas *int32 `json:"replicas,omitempty"`
+	// Mode selects between a regular cluster and a Standalone single-member etcd for development and testing.
+	// Standalone clusters run one member: replicas are set to 1, no PodDisruptionBudget is created, probes and
+	// timings are tuned for fast startup in place of the profile, and checks protecting the quorum of other
+	// members are skipped. Without configured storage they keep data in emptyDir, which is lost with the pod.
+	// The mode can not be changed once the cluster is created.
+	// +optional
+	// +kubebuilder:validation:Enum=Clustered;Standalone
+	Mode ClusterMode `json:"mode,omitempty"`
+	// Profile selects defaults of probe timings, heartbeat interval, election timeout and snapshot count
+	// tuned for the storage tier of members. Values set explicitly in options or the pod template take precedence.
+	// +optional
+	// +kubebuilder:validation:Enum=Fast;Standard;SlowStorage
+	Profile Profile `json:"profile,omitempty"`
 	// Options are the extra arguments to pass to the etcd container.
+	// Flag names and values are validated against the flag set of etcd version used by the cluster.
+	// Flags deprecated in that version are passed under the names of flags or feature gates replacing them.
 	// +optional
-	// +kubebuilder:example:={enable-v2: "false", debug: "true"}
+	// +kubebuilder:example:={enable-v2: "false", log-level: "debug"}
 	Options map[string]string `json:"options,omitempty"`
+	// ExperimentalOptions are experimental etcd flags passed with the --experimental- prefix, e.g.
+	// enable-distributed-tracing: "true". They are accepted only if the operator runs with the
+	// --enable-experimental-options flag, since experimental etcd features may change or be removed without notice.
+	// +optional
+	ExperimentalOptions map[string]string `json:"experimentalOptions,omitempty"`
 	// PodTemplate defines the desired state of PodSpec for etcd members. If not specified, default values will be used.
 	PodTemplate PodTemplate `json:"podTemplate,omitempty"`
 	// Service defines the desired state of Service for etcd members. If not specified, default values will be used.
+	// The client service only routes to ready members unless spec.publishNotReadyAddresses is set, its sessionAffinity
+	// and internalTrafficPolicy are set here as well.
 	// +optional
 	ServiceTemplate *EmbeddedService `json:"serviceTemplate,omitempty"`
 	// HeadlessService defines the desired state of HeadlessService for etcd members. If not specified, default values will be used.
+	// The headless service always publishes addresses of not ready members, members discover their peers through it.
 	// +optional
 	HeadlessServiceTemplate *EmbeddedMetadataResource `json:"headlessServiceTemplate,omitempty"`
 	// PodDisruptionBudgetTemplate describes PDB resource to create for etcd cluster members. Nil to disable.
 	// +optional
 	PodDisruptionBudgetTemplate *EmbeddedPodDisruptionBudget `json:"podDisruptionBudgetTemplate,omitempty"`
-	Storage                     StorageSpec                  `json:"storage"`
+	// Storage describes volumes keeping data of members, a volume claim of 4Gi per member if not configured.
+	// Standalone clusters keep data in emptyDir if not configured.
+	// +optional
+	Storage StorageSpec `json:"storage"`
 	// Security describes security settings of etcd (authentication, certificates, rbac)
 	// +optional
 	Security *SecuritySpec `json:"security,omitempty"`
+	// RaftSnapshots tunes how often etcd snapshots its state to disk and how many snapshot and WAL files are retained.
+	// +optional
+	RaftSnapshots *RaftSnapshotsSpec `json:"raftSnapshots,omitempty"`
+	// RequestLimits raises limits of etcd requests for applications issuing large transactions or values,
+	// e.g. bulk loaders of configuration. Limits members run with are reported in status.requestLimits.
+	// +optional
+	RequestLimits *RequestLimitsSpec `json:"requestLimits,omitempty"`
+	// AutoDefrag compacts the keyspace and defragments members one at a time once a sharp drop of the key count
+	// without a matching drop of the database size is detected, which is typical after large deletions.
+	// Reclaimed space is reported in events of the cluster.
+	// +optional
+	AutoDefrag *AutoDefragSpec `json:"autoDefrag,omitempty"`
+	// Tracing exports OpenTelemetry traces of etcd requests to a collector.
+	// +optional
+	Tracing *TracingSpec `json:"tracing,omitempty"`
+	// Logging configures shipping of etcd logs, for teams without a cluster-wide log agent.
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// MemberManagement selects whether members are run by a StatefulSet or the operator manages
+	// member Pods and PersistentVolumeClaims directly. Pods mode is experimental: it recreates outdated members
+	// one at a time and does not support automatic upgrade rollback. The field can't be changed after creation.
+	// +optional
+	// +kubebuilder:validation:Enum=StatefulSet;Pods
+	MemberManagement MemberManagementMode `json:"memberManagement,omitempty"`
+	// Bootstrap selects how members discover each other when the cluster is bootstrapped. InitialCluster passes
+	// the list of all members to etcd, DiscoverySRV makes etcd look up SRV records of the headless service instead.
+	// Peer certificates must include the headless service domain, e.g. <name>-headless.<namespace>.svc,
+	// in the DiscoverySRV mode, as etcd verifies it.
+	// +optional
+	// +kubebuilder:validation:Enum=InitialCluster;DiscoverySRV
+	Bootstrap BootstrapMode `json:"bootstrap,omitempty"`
+	// ClientSRVRecords names the client port of the headless service etcd-client, or etcd-client-ssl with TLS,
+	// so DNS SRV records of members are published for clients discovering them by SRV lookups, e.g.
+	// etcdctl --discovery-srv. The domain to look up is reported in status.endpoints.srvDomain.
+	// +optional
+	ClientSRVRecords bool `json:"clientSRVRecords,omitempty"`
+	// LeaderService makes the operator maintain the <name>-leader service selecting the current leader member only,
+	// for clients which send writes to the leader directly instead of having them forwarded by another member.
+	// The leader is observed on each reconciliation, at least every 30s while the service is enabled, so
+	// writes may reach a follower shortly after a leader change.
+	// +optional
+	LeaderService bool `json:"leaderService,omitempty"`
+	// OperatorConnection selects how the operator connects to members for health checks and maintenance,
+	// since direct pod connectivity is not guaranteed in all network topologies. Members are reached by their
+	// pod DNS names by default.
+	// +optional
+	OperatorConnection *OperatorConnectionSpec `json:"operatorConnection,omitempty"`
+	// AvailabilityPolicy controls when the cluster is reported Ready.
+	// +optional
+	AvailabilityPolicy *AvailabilityPolicySpec `json:"availabilityPolicy,omitempty"`
+	// ManagementPolicy selects whether the operator manages the cluster or only observes an etcd cluster deployed
+	// outside of it. Observed clusters get health checks and status reporting for spec.observedEndpoints,
+	// no workloads are created or changed. The field can't be changed after creation.
+	// +optional
+	// +kubebuilder:validation:Enum=Manage;Observe
+	ManagementPolicy ManagementPolicy `json:"managementPolicy,omitempty"`
+	// ObservedEndpoints are client URLs of the cluster observed with the Observe management policy.
+	// TLS settings of spec.security are used to connect to them.
+	// +optional
+	ObservedEndpoints []string `json:"observedEndpoints,omitempty"`
+	// ReconcilePeriod is the interval of periodic health resync of the cluster, e.g. 30s for critical clusters.
+	// It is bounded by the --min-reconcile-period and --max-reconcile-period flags of the operator.
+	// If not set, the cluster is reconciled on changes only.
+	// +optional
+	ReconcilePeriod *metav1.Duration `json:"reconcilePeriod,omitempty"`
+	// MemberReplacementGracePeriod is how long a member stays unhealthy before it is replaced automatically,
+	// so members are not replaced during node reboots and upgrades. Defaults to 5m, zero replaces members at once.
+	// +optional
+	MemberReplacementGracePeriod *metav1.Duration `json:"memberReplacementGracePeriod,omitempty"`
+	// BootstrapTimeout is how long members may take to establish the first quorum of a new cluster, e.g. while
+	// volume claims wait for a missing storage class or pods for nodes matching their affinity. Once it passes,
+	// the cluster is reported by the BootstrapFailed condition and its objects are no longer updated until
+	// the bootstrap is retried by the etcd.aenix.io/retry-bootstrap annotation. Defaults to 30m, zero disables it.
+	// +optional
+	BootstrapTimeout *metav1.Duration `json:"bootstrapTimeout,omitempty"`
+	// Notifications configures webhooks notified about critical events of the cluster,
+	// for teams not routing Kubernetes Events into alerting.
+	// +optional
+	Notifications *NotificationsSpec `json:"notifications,omitempty"`
+}
+
+// NotificationsSpec configures sinks of critical cluster events.
+type NotificationsSpec struct {
+	// Webhooks receive a POST request once an event occurs. The event is sent again only after it was resolved
+	// and occurred anew.
+	// +listType=map
+	// +listMapKey=name
+	Webhooks []NotificationWebhook `json:"webhooks"`
+}
+
+// NotificationWebhook is an HTTP endpoint notified about cluster events.
+type NotificationWebhook struct {
+	// Name identifies the webhook in logs.
+	Name string `json:"name"`
+	// URLSecretRef selects a key of a Secret in the cluster namespace holding the webhook URL.
+	// URLs of chat integrations embed credentials, so they are not kept in the spec.
+	URLSecretRef corev1.SecretKeySelector `json:"urlSecretRef"`
+	// Format of the request body: Generic JSON document describing the event or Slack incoming webhook message.
+	// +optional
+	// +kubebuilder:default:=Generic
+	// +kubebuilder:validation:Enum=Generic;Slack
+	Format NotificationFormat `json:"format,omitempty"`
+	// Events the webhook is notified about, all events if empty.
+	// +optional
+	Events []NotificationEvent `json:"events,omitempty"`
+}
+
+// NotificationFormat is the request body format of a notification webhook.
+type NotificationFormat string
+
+const (
+	NotificationFormatGeneric NotificationFormat = "Generic"
+	NotificationFormatSlack   NotificationFormat = "Slack"
+)
+
+// NotificationEvent is a critical cluster event sent to notification sinks.
+// +kubebuilder:validation:Enum=QuorumLost;UpgradeFailed;BackupFailed;DataCorruption
+type NotificationEvent string
+
+const (
+	// NotificationEventQuorumLost occurs when less than a quorum of members of an initialized cluster is ready.
+	NotificationEventQuorumLost NotificationEvent = "QuorumLost"
+	// NotificationEventUpgradeFailed occurs when a failed upgrade is rolled back.
+	NotificationEventUpgradeFailed NotificationEvent = "UpgradeFailed"
+	// NotificationEventBackupFailed occurs when a cluster in a namespace requiring backups has no backup configured.
+	NotificationEventBackupFailed NotificationEvent = "BackupFailed"
+	// NotificationEventDataCorruption occurs when a member raises the etcd CORRUPT alarm.
+	NotificationEventDataCorruption NotificationEvent = "DataCorruption"
+)
+
+// Subscribes returns true if the webhook is notified about the event.
+func (r *NotificationWebhook) Subscribes(event NotificationEvent) bool {
+	return len(r.Events) == 0 || slices.Contains(r.Events, event)
+}
+
+// OperatorConnectionSpec defines endpoints the operator connects to.
+type OperatorConnectionSpec struct {
+	// Mode is PodDNS to connect to each member by its DNS name under the headless Service, HeadlessService or
+	// ClientService to connect through the respective Service, or Endpoints to connect to the endpoints listed
+	// in the endpoints field, e.g. of a mesh gateway. Only members answering on Service or listed endpoints
+	// report their version and status in other modes than PodDNS.
+	// +optional
+	// +kubebuilder:default:=PodDNS
+	// +kubebuilder:validation:Enum=PodDNS;HeadlessService;ClientService;Endpoints
+	Mode OperatorConnectionMode `json:"mode,omitempty"`
+	// Endpoints are client URLs the operator connects to in the Endpoints mode.
+	// +optional
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// OperatorConnectionMode is the way the operator reaches members.
+type OperatorConnectionMode string
+
+const (
+	OperatorConnectionPodDNS          OperatorConnectionMode = "PodDNS"
+	OperatorConnectionHeadlessService OperatorConnectionMode = "HeadlessService"
+	OperatorConnectionClientService   OperatorConnectionMode = "ClientService"
+	OperatorConnectionEndpoints       OperatorConnectionMode = "Endpoints"
+)
+
+// GetOperatorConnectionMode returns the way the operator reaches members, PodDNS if not set.
+func (r *EtcdCluster) GetOperatorConnectionMode() OperatorConnectionMode {
+	if r.Spec.OperatorConnection == nil || r.Spec.OperatorConnection.Mode == "" {
+		return OperatorConnectionPodDNS
+	}
+	return r.Spec.OperatorConnection.Mode
+}
+
+// Profile is a preset of timings tuned for the storage tier of members.
+type Profile string
+
+const (
+	// ProfileFast suits local NVMe disks: members are probed and elected faster and snapshot more often.
+	ProfileFast Profile = "Fast"
+	// ProfileStandard keeps etcd defaults, it is the default.
+	ProfileStandard Profile = "Standard"
+	// ProfileSlowStorage suits network attached or shared disks: members tolerate slow fsync before they are
+	// restarted or lose leadership and snapshot less often.
+	ProfileSlowStorage Profile = "SlowStorage"
+)
+
+// GetProfile returns the profile of the cluster, Standard if it is not set.
+func (r *EtcdCluster) GetProfile() Profile {
+	if r.Spec.Profile == "" {
+		return ProfileStandard
+	}
+	return r.Spec.Profile
+}
+
+// ManagementPolicy defines what the operator does with the cluster.
+type ManagementPolicy string
+
+const (
+	// ManagementPolicyManage makes the operator create and maintain the cluster, it is the default.
+	ManagementPolicyManage ManagementPolicy = "Manage"
+	// ManagementPolicyObserve makes the operator only report health of an existing cluster.
+	ManagementPolicyObserve ManagementPolicy = "Observe"
+)
+
+// ClusterMode defines whether the cluster is replicated.
+type ClusterMode string
+
+const (
+	// ClusterModeClustered runs a regular replicated cluster, it is the default.
+	ClusterModeClustered ClusterMode = "Clustered"
+	// ClusterModeStandalone runs a single member for development and testing.
+	ClusterModeStandalone ClusterMode = "Standalone"
+)
+
+// MemberManagementMode is the way member pods of the cluster are managed.
+type MemberManagementMode string
+
+const (
+	// MemberManagementStatefulSet runs members by a StatefulSet, it is the default.
+	MemberManagementStatefulSet MemberManagementMode = "StatefulSet"
+	// MemberManagementPods makes the operator create and replace member Pods and their PVCs itself.
+	MemberManagementPods MemberManagementMode = "Pods"
+)
+
+// DefaultArchitectures are CPU architectures official etcd images are published for.
+var DefaultArchitectures = []string{"amd64", "arm64", "ppc64le", "s390x"}
+
+// GetHostPath returns the node directory keeping data of members of the cluster with hostPath storage.
+func (r *EtcdCluster) GetHostPath() string {
+	hostPath := DefaultHostPath
+	if r.Spec.Storage.HostPath != nil && r.Spec.Storage.HostPath.Path != "" {
+		hostPath = r.Spec.Storage.HostPath.Path
+	}
+	return strings.NewReplacer("$(NAMESPACE)", r.Namespace, "$(CLUSTER)", r.Name).Replace(hostPath)
+}
+
+// GetArchitectures returns CPU architectures member pods may run on.
+func (r *EtcdCluster) GetArchitectures() []string {
+	if len(r.Spec.PodTemplate.Architectures) > 0 {
+		return r.Spec.PodTemplate.Architectures
+	}
+	return DefaultArchitectures
+}
+
+// BootstrapMode is the way members discover each other when the cluster is bootstrapped.
+type BootstrapMode string
+
+const (
+	// BootstrapInitialCluster passes the list of all members in --initial-cluster, it is the default.
+	BootstrapInitialCluster BootstrapMode = "InitialCluster"
+	// BootstrapDiscoverySRV passes the headless service domain in --discovery-srv.
+	BootstrapDiscoverySRV BootstrapMode = "DiscoverySRV"
+)
+
+// UsesDiscoverySRV returns true if members are bootstrapped by DNS SRV discovery.
+func (r *EtcdCluster) UsesDiscoverySRV() bool {
+	return r.Spec.Bootstrap == BootstrapDiscoverySRV
+}
+
+// ManagesMemberPods returns true if the operator manages member pods directly instead of a StatefulSet.
+func (r *EtcdCluster) ManagesMemberPods() bool {
+	return r.Spec.MemberManagement == MemberManagementPods
+}
+
+// IsStandalone returns true if the cluster runs a single member for development and testing.
+func (r *EtcdCluster) IsStandalone() bool {
+	return r.Spec.Mode == ClusterModeStandalone
+}
+
+// IsObserved returns true if the operator only observes the cluster.
+func (r *EtcdCluster) IsObserved() bool {
+	return r.Spec.ManagementPolicy == ManagementPolicyObserve
 }
 
 const (
-	EtcdConditionInitialized = "Initialized"
-	EtcdConditionReady       = "Ready"
+	EtcdConditionInitialized   = "Initialized"
+	EtcdConditionReady         = "Ready"
+	EtcdConditionMemberFailure = "MemberFailure"
+	EtcdConditionUpgradeFailed = "UpgradeFailed"
+	// EtcdConditionBackupNotConfigured is set on clusters in namespaces requiring backups, see
+	// the --backup-required-namespace-selector flag of the operator.
+	EtcdConditionBackupNotConfigured = "BackupNotConfigured"
+	// EtcdConditionEphemeralStorage warns that cluster data is kept in emptyDir and does not survive deletion of
+	// member pods. The cluster is reported Degraded while it is True.
+	EtcdConditionEphemeralStorage = "EphemeralStorage"
+	// EtcdConditionImageRejected is set if the operator verifies image signatures, see
+	// the --image-verification-public-key flag of the operator. Rejected images are not rolled out.
+	EtcdConditionImageRejected = "ImageRejected"
+	// EtcdConditionAlarmNoSpace mirrors the NOSPACE alarm raised by members whose database reached its quota.
+	EtcdConditionAlarmNoSpace = "AlarmNoSpace"
+	// EtcdConditionAlarmCorrupt mirrors the CORRUPT alarm raised by members whose data is inconsistent.
+	EtcdConditionAlarmCorrupt = "AlarmCorrupt"
+	// EtcdConditionOperationQueued is set while an operation waits for the operation held in status.currentOperation.
+	EtcdConditionOperationQueued = "OperationQueued"
+	// EtcdConditionConfigDrift is set when members run with etcd settings differing from the spec,
+	// e.g. their pods were not rolled after a change.
+	EtcdConditionConfigDrift = "ConfigDrift"
+	// EtcdConditionScaleDownDeferred is set while removal of members is deferred, because it is unsafe now.
+	EtcdConditionScaleDownDeferred = "ScaleDownDeferred"
+	// EtcdConditionClockSkew is set when clocks of members differ by more than etcd tolerates, which breaks
+	// lease expiration and is hard to spot otherwise.
+	EtcdConditionClockSkew = "ClockSkew"
+	// EtcdConditionVolumeExpansion is set while member volume claims are smaller than the spec requests.
+	EtcdConditionVolumeExpansion = "VolumeExpansion"
+	// EtcdConditionNoCompatibleNodes is set when no schedulable node runs Linux on an architecture of the etcd image,
+	// so member pods can't be scheduled.
+	EtcdConditionNoCompatibleNodes = "NoCompatibleNodes"
+	// EtcdConditionProgressing is True while the spec of the cluster is being rolled out to members and False
+	// once all members run the spec recorded in status.observedGeneration.
+	EtcdConditionProgressing = "Progressing"
+	// EtcdConditionDegraded is True while the cluster fails to serve as specified, its reason names the failing
+	// condition, e.g. MemberFailure, and False otherwise. Unlike Ready, it is not set during initial bootstrap
+	// and rollouts, so tools like Argo CD can tell waiting for the cluster from failed clusters.
+	EtcdConditionDegraded = "Degraded"
+	// EtcdConditionStorageMigration is set while member data is migrated from emptyDir to volume claims,
+	// its reason is the current step, see status.storageMigration.
+	EtcdConditionStorageMigration = "StorageMigration"
+	// EtcdConditionCertificateSANsMissing is set when the server certificate does not cover addresses the client
+	// service is exposed on outside of the cluster, e.g. load balancer hostnames, so external clients fail to verify it.
+	EtcdConditionCertificateSANsMissing = "CertificateSANsMissing"
+	// EtcdConditionForeignMemberData is set when members report a cluster ID other than status.clusterID, so their
+	// data directories belong to another etcd cluster, e.g. after restoring or adopting volumes by mistake.
+	EtcdConditionForeignMemberData = "ForeignMemberData"
+	// EtcdConditionBootstrapFailed is set when members did not establish the first quorum within
+	// spec.bootstrapTimeout. Objects of the cluster are not updated while it is True.
+	EtcdConditionBootstrapFailed = "BootstrapFailed"
+	// EtcdConditionAuthDrift is set when the cluster has etcd users or roles no EtcdUser or EtcdRole declares,
+	// see spec.security.auth.pruneUnmanaged. It is checked only for clusters some EtcdUser or EtcdRole refers to.
+	EtcdConditionAuthDrift = "AuthDrift"
 )
 
+// BackupConfiguredAnnotation marks clusters backed up by tooling outside of the operator, so they are not reported
+// by the BackupNotConfigured condition.
+const BackupConfiguredAnnotation = "etcd.aenix.io/backup-configured"
+
+// ImportedFromAnnotation records client endpoints of the cluster an EtcdCluster was generated from
+// by the import command of the operator.
+const ImportedFromAnnotation = "etcd.aenix.io/imported-from"
+
+// MigratedFromAnnotation records API version of the CoreOS etcd operator EtcdCluster an EtcdCluster was converted from
+// by the migrate command of the operator.
+const MigratedFromAnnotation = "etcd.aenix.io/migrated-from"
+
+// StorageMigrationAnnotation set to "true" allows to replace spec.storage.emptyDir by spec.storage.volumeClaimTemplate.
+// The operator snapshots the cluster, restores the snapshot on the volume of the first member, recreates
+// the StatefulSet with volume claims and joins other members one at a time, see status.storageMigration.
+// The cluster is unavailable from recreation until the first member starts and writes made after the snapshot are lost.
+const StorageMigrationAnnotation = "etcd.aenix.io/migrate-storage"
+
+// ClusterIDAnnotation and MemberIDAnnotation are set on volume claims of members to hex encoded IDs of the etcd
+// cluster and the member their data belongs to, so data of another cluster is not joined to the cluster.
+const (
+	ClusterIDAnnotation = "etcd.aenix.io/cluster-id"
+	MemberIDAnnotation  = "etcd.aenix.io/member-id"
+)
+
+// IsStorageMigrationRequested returns true if the storage migration annotation is set.
+func (r *EtcdCluster) IsStorageMigrationRequested() bool {
+	return r.Annotations[StorageMigrationAnnotation] == "true"
+}
+
+// CordonedMembersAnnotation lists comma separated ordinals of cordoned members, e.g. "0,2". The operator skips
+// cordoned members in automated actions, such as defragmentation, upgrade rollback and member replacement,
+// and tolerates their unhealthiness, so they can be investigated during node maintenance.
+const CordonedMembersAnnotation = "etcd.aenix.io/cordoned-members"
+
+// DebugShellAnnotation requests a break-glass debug shell for the duration it is set to, e.g. "30m". The operator runs
+// a pod with etcdctl configured by the admin access secret and certificates of the cluster mounted, listed in
+// status.debugShell, so it is used with kubectl exec instead of etcd containers. Once the duration passes,
+// the pod is deleted and the annotation removed. Starting and closing the shell is recorded in events of the cluster.
+const DebugShellAnnotation = "etcd.aenix.io/debug-shell"
+
+// RotateInternalCAAnnotation requests rotation of the internal CA whenever it is set to a value not rotated to yet,
+// e.g. the current date. The CA is rotated without downtime: members are rolled to trust both the current and a new
+// CA, certificates are issued by the new CA, then members are rolled to trust only the new CA. The CA is rotated
+// without the annotation a year before it expires.
+const RotateInternalCAAnnotation = "etcd.aenix.io/rotate-internal-ca"
+
+// RotateJWTKeyAnnotation requests rotation of the key pair JSON Web Tokens are signed with whenever it is set
+// to a value not rotated to yet, e.g. the current date. The key is replaced only while all members are healthy
+// and run with the current key, then members are restarted one at a time. Tokens signed by the previous key
+// are rejected, clients authenticate again.
+const RotateJWTKeyAnnotation = "etcd.aenix.io/rotate-jwt-key"
+
+// RetryBootstrapAnnotation set to "true" retries the bootstrap of a cluster that never established its first quorum.
+// The operator deletes the StatefulSet, member pods, their volume claims and the cluster state ConfigMap, waits for
+// them to be gone, removes the annotation and bootstraps the cluster from scratch with the current spec.
+// The annotation is removed without deleting anything from clusters that have established a quorum.
+const RetryBootstrapAnnotation = "etcd.aenix.io/retry-bootstrap"
+
+// MaxDebugShellTTL limits the duration of the debug shell.
+const MaxDebugShellTTL = 24 * time.Hour
+
+// DebugShellTTL returns the duration of the debug shell requested by the debug shell annotation,
+// zero if no shell is requested.
+func (r *EtcdCluster) DebugShellTTL() (time.Duration, error) {
+	value := strings.TrimSpace(r.Annotations[DebugShellAnnotation])
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	if ttl <= 0 || ttl > MaxDebugShellTTL {
+		return 0, fmt.Errorf("duration must be positive and at most %s", MaxDebugShellTTL)
+	}
+	return ttl, nil
+}
+
+// CordonedMembers returns ordinals listed in the cordoned members annotation.
+func (r *EtcdCluster) CordonedMembers() ([]int, error) {
+	value := strings.TrimSpace(r.Annotations[CordonedMembersAnnotation])
+	if value == "" {
+		return nil, nil
+	}
+	var ordinals []int
+	for _, item := range strings.Split(value, ",") {
+		ordinal, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || ordinal < 0 {
+			return nil, fmt.Errorf("invalid member ordinal %q", item)
+		}
+		ordinals = append(ordinals, ordinal)
+	}
+	return ordinals, nil
+}
+
+// IsMemberCordoned returns true if the member with the ordinal is cordoned. Malformed annotations cordon no members.
+func (r *EtcdCluster) IsMemberCordoned(ordinal int) bool {
+	ordinals, err := r.CordonedMembers()
+	return err == nil && slices.Contains(ordinals, ordinal)
+}
+
 type EtcdCondType string
 type EtcdCondMessage string
 
 const (
-	EtcdCondTypeInitStarted           EtcdCondType = "InitializationStarted"
-	EtcdCondTypeInitComplete          EtcdCondType = "InitializationComplete"
-	EtcdCondTypeWaitingForFirstQuorum EtcdCondType = "WaitingForFirstQuorum"
-	EtcdCondTypeStatefulSetReady      EtcdCondType = "StatefulSetReady"
-	EtcdCondTypeStatefulSetNotReady   EtcdCondType = "StatefulSetNotReady"
+	EtcdCondTypeInitStarted            EtcdCondType = "InitializationStarted"
+	EtcdCondTypeInitComplete           EtcdCondType = "InitializationComplete"
+	EtcdCondTypeWaitingForFirstQuorum  EtcdCondType = "WaitingForFirstQuorum"
+	EtcdCondTypeStatefulSetReady       EtcdCondType = "StatefulSetReady"
+	EtcdCondTypeStatefulSetNotReady    EtcdCondType = "StatefulSetNotReady"
+	EtcdCondTypeMembersHealthy         EtcdCondType = "MembersHealthy"
+	EtcdCondTypeMemberCrashLoopBackOff EtcdCondType = "CrashLoopBackOff"
+	EtcdCondTypeMemberOOMKilled        EtcdCondType = "OOMKilled"
+	EtcdCondTypeMemberEvicted          EtcdCondType = "Evicted"
+	EtcdCondTypeUpgradeRolledBack      EtcdCondType = "UpgradeRolledBack"
+	EtcdCondTypeUpgradeSpecChanged     EtcdCondType = "SpecChanged"
+	EtcdCondTypeNoBackupSchedule       EtcdCondType = "NoBackupSchedule"
+	EtcdCondTypeBackupConfigured       EtcdCondType = "BackupConfigured"
+	EtcdCondTypeMemoryStorage          EtcdCondType = "MemoryStorage"
+	EtcdCondTypeEmptyDirStorage        EtcdCondType = "EmptyDirStorage"
+	EtcdCondTypeSignatureVerified      EtcdCondType = "SignatureVerified"
+	EtcdCondTypeSignatureNotVerified   EtcdCondType = "SignatureNotVerified"
+	EtcdCondTypeQuorumObserved         EtcdCondType = "QuorumObserved"
+	EtcdCondTypeQuorumNotObserved      EtcdCondType = "QuorumNotObserved"
+	EtcdCondTypeObserved               EtcdCondType = "Observed"
+	EtcdCondTypeStatusCheckFailed      EtcdCondType = "StatusCheckFailed"
+	EtcdCondTypeLeaseCheckFailed       EtcdCondType = "LeaseCheckFailed"
+	EtcdCondTypeWatchCheckFailed       EtcdCondType = "WatchCheckFailed"
+	EtcdCondTypeAlarmActive            EtcdCondType = "AlarmActive"
+	EtcdCondTypeNoAlarm                EtcdCondType = "NoAlarm"
+	EtcdCondTypeWaitingForOperation    EtcdCondType = "WaitingForOperation"
+	EtcdCondTypeConfigDrifted          EtcdCondType = "ConfigDrifted"
+	EtcdCondTypeLearnerCatchingUp      EtcdCondType = "LearnerCatchingUp"
+	EtcdCondTypeMaintenanceInProgress  EtcdCondType = "MaintenanceInProgress"
+	EtcdCondTypeMembersUnverified      EtcdCondType = "MembersUnverified"
+	EtcdCondTypeClockSkewDetected      EtcdCondType = "ClockSkewDetected"
+	EtcdCondTypeVolumeResizing         EtcdCondType = "Resizing"
+	EtcdCondTypeResizePending          EtcdCondType = "FileSystemResizePending"
+	EtcdCondTypeExpansionNotSupported  EtcdCondType = "ExpansionNotSupported"
+	EtcdCondTypeIncompatibleNodes      EtcdCondType = "IncompatibleNodes"
+	EtcdCondTypeRolloutInProgress      EtcdCondType = "RolloutInProgress"
+	EtcdCondTypeRolloutComplete        EtcdCondType = "RolloutComplete"
+	EtcdCondTypeNotDegraded            EtcdCondType = "AsExpected"
+	EtcdCondTypeTakingSnapshot         EtcdCondType = "TakingSnapshot"
+	EtcdCondTypeSnapshotFailed         EtcdCondType = "SnapshotFailed"
+	EtcdCondTypeRecreatingMembers      EtcdCondType = "RecreatingMembers"
+	EtcdCondTypeJoiningMembers         EtcdCondType = "JoiningMembers"
+	EtcdCondTypeExternalSANsMissing    EtcdCondType = "ExternalAddressesNotCovered"
+	EtcdCondTypeClusterIDMismatch      EtcdCondType = "ClusterIDMismatch"
+	EtcdCondTypeBootstrapTimedOut      EtcdCondType = "BootstrapTimedOut"
+	EtcdCondTypeRetryingBootstrap      EtcdCondType = "RetryingBootstrap"
+	EtcdCondTypeUnmanagedAuth          EtcdCondType = "UnmanagedUsersOrRoles"
 )
 
 const (
@@ -74,15 +585,268 @@ const (
 	EtcdReadyCondNegMessage          EtcdCondMessage = "Cluster StatefulSet is not Ready"
 	EtcdReadyCondPosMessage          EtcdCondMessage = "Cluster StatefulSet is Ready"
 	EtcdReadyCondNegWaitingForQuorum EtcdCondMessage = "Waiting for first quorum to be established"
+	EtcdMemberFailureCondNegMessage  EtcdCondMessage = "No failing member pods detected"
+	EtcdUpgradeFailedCondNegMessage  EtcdCondMessage = "Cluster spec was changed after rollback of failed upgrade"
+	EtcdBackupCondPosMessage         EtcdCondMessage = "Cluster in namespace requiring backups has no backup configured"
+	EtcdBackupCondNegMessage         EtcdCondMessage = "Cluster backup is configured"
+	EtcdEphemeralStorageCondMessage  EtcdCondMessage = "Cluster data is kept in memory and is lost when member pods are deleted or their nodes restart, use it for throwaway clusters only"
+	EtcdEmptyDirStorageCondMessage   EtcdCondMessage = "Cluster data is kept in emptyDir and is lost when member pods are deleted or rescheduled, use volumeClaimTemplate for persistent clusters"
+	EtcdImageRejectedCondNegMessage  EtcdCondMessage = "Etcd image signature is verified"
+	EtcdInitCondObservedMessage      EtcdCondMessage = "Cluster is observed, no resources are managed"
+	EtcdAlarmCondNegMessage          EtcdCondMessage = "No member raised the alarm"
+	EtcdProgressingCondPosMessage    EtcdCondMessage = "Cluster spec is being rolled out to members"
+	EtcdProgressingCondNegMessage    EtcdCondMessage = "Cluster spec is rolled out to all members"
+	EtcdDegradedCondNegMessage       EtcdCondMessage = "Cluster serves as specified"
 )
 
 // EtcdClusterStatus defines the observed state of EtcdCluster
 type EtcdClusterStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the cluster the status was computed for. Conditions are up-to-date
+	// with the spec only if it equals metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CurrentVersion is the lowest etcd version running on cluster members.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// TargetVersion is the etcd version of the image defined in the cluster spec.
+	// +optional
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// Members describes observed state of cluster members.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Members []MemberStatus `json:"members,omitempty"`
+	// MemberIdentities map member pods to etcd members they run. Unlike members, they are kept while pods
+	// do not exist, e.g. while they are recreated or the statefulset is, so a recreated pod is recognized as
+	// the member it ran before. Identities of members beyond spec.replicas are forgotten once their pods are gone.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	MemberIdentities []MemberIdentity `json:"memberIdentities,omitempty"`
+	// ClusterID is the hex encoded etcd cluster ID, recorded once a quorum of members reports it. Members
+	// reporting another ID keep data of another cluster, they are not reported ready and not joined to the cluster.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+	// AuthEnabled is true while authentication of etcd is enabled by the operator with the root user
+	// of the root credentials secret, see spec.security.auth.
+	// +optional
+	AuthEnabled bool `json:"authEnabled,omitempty"`
+	// RequestLimits are the lowest request limits members run with, so requests within them are accepted
+	// by every member. Members started without the flags are accounted with defaults of etcd.
+	// +optional
+	RequestLimits *RequestLimitsStatus `json:"requestLimits,omitempty"`
+	// Alarms lists alarms currently raised by cluster members. It is kept unchanged while the cluster is unreachable.
+	// +optional
+	Alarms []AlarmStatus `json:"alarms,omitempty"`
+	// AdminAccess describes how to access the cluster with etcdctl.
+	// +optional
+	AdminAccess *AdminAccessStatus `json:"adminAccess,omitempty"`
+	// CurrentOperation is the operation holding the operation lock. Other requested operations are queued
+	// until it completes, so they are not interleaved.
+	// +optional
+	CurrentOperation *OperationStatus `json:"currentOperation,omitempty"`
+	// LastReconcile summarizes what the operator did during the last reconciliation.
+	// +optional
+	LastReconcile *ReconcileSummary `json:"lastReconcile,omitempty"`
+	// AppliedGenerations is the history of spec generations applied to member objects, the oldest first.
+	// The operator only appends entries and keeps the latest 10, so reviews can see when replicas, image
+	// or storage were changed without external audit logs.
+	// +optional
+	AppliedGenerations []AppliedGeneration `json:"appliedGenerations,omitempty"`
+	// DebugShell is the debug shell requested by the etcd.aenix.io/debug-shell annotation.
+	// +optional
+	DebugShell *DebugShellStatus `json:"debugShell,omitempty"`
+	// Endpoints are DNS names clients connect to.
+	// +optional
+	Endpoints *EndpointsStatus `json:"endpoints,omitempty"`
+	// StorageMigration is the migration of member data from emptyDir to volume claims requested by
+	// the etcd.aenix.io/migrate-storage annotation.
+	// +optional
+	StorageMigration *StorageMigrationStatus `json:"storageMigration,omitempty"`
+	// StorageCapacity is the largest capacity provisioned for data volume claims of members. Volume claims can not
+	// shrink, so the storage request of spec.storage.volumeClaimTemplate can not be decreased below it.
+	// +optional
+	StorageCapacity *resource.Quantity `json:"storageCapacity,omitempty"`
+}
+
+// RequestLimitsStatus describes request limits of running members.
+type RequestLimitsStatus struct {
+	// MaxTxnOps is the maximum number of operations in a single transaction.
+	MaxTxnOps int32 `json:"maxTxnOps"`
+	// MaxRequestBytes is the maximum size of a client request in bytes.
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+}
+
+// StorageMigrationPhase is a step of the migration of member data from emptyDir to volume claims.
+// +kubebuilder:validation:Enum=Snapshot;Recreate;Join
+type StorageMigrationPhase string
+
+const (
+	// StorageMigrationSnapshot takes a snapshot of the cluster and restores it on the volume of the first member.
+	StorageMigrationSnapshot StorageMigrationPhase = "Snapshot"
+	// StorageMigrationRecreate replaces the StatefulSet and its emptyDir members by members with volume claims.
+	StorageMigrationRecreate StorageMigrationPhase = "Recreate"
+	// StorageMigrationJoin adds other members to the restored first member one at a time.
+	StorageMigrationJoin StorageMigrationPhase = "Join"
+)
+
+// StorageMigrationStatus describes the running storage migration.
+type StorageMigrationStatus struct {
+	// Phase is the current step of the migration.
+	Phase StorageMigrationPhase `json:"phase"`
+	// StartTime is the time the migration started at.
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// EndpointsStatus lists client URLs of the cluster resolved by in-cluster DNS.
+type EndpointsStatus struct {
+	// Service is the client URL of the client service, which balances connections across members.
+	Service string `json:"service,omitempty"`
+	// Members are client URLs of individual members resolved by the headless service, e.g. for etcdctl --endpoints.
+	// +optional
+	Members []string `json:"members,omitempty"`
+	// SRVDomain is the domain of DNS SRV records of members, e.g. for etcdctl --discovery-srv.
+	// It is only set if spec.clientSRVRecords is enabled.
+	// +optional
+	SRVDomain string `json:"srvDomain,omitempty"`
+	// Leader is the client URL of the leader service. It is only set if spec.leaderService is enabled.
+	// +optional
+	Leader string `json:"leader,omitempty"`
+}
+
+// AppliedGeneration records a spec generation applied by the operator.
+type AppliedGeneration struct {
+	// Generation is metadata.generation of the applied spec.
+	Generation int64 `json:"generation"`
+	// Time is when the generation was applied.
+	Time metav1.Time `json:"time"`
+	// Replicas is the number of members of the generation.
+	Replicas int32 `json:"replicas"`
+	// Image is the etcd image of the generation.
+	Image string `json:"image"`
+	// Storage describes data volumes of the generation, e.g. "volumeClaim 4Gi" or "emptyDir".
+	Storage string `json:"storage"`
+	// Changes summarizes the difference from the previous entry, e.g. "replicas 3 -> 5".
+	Changes string `json:"changes"`
+}
+
+// ReconcileSummary is a compact decision log of a reconciliation, so the last actions of the operator
+// can be seen without its logs.
+type ReconcileSummary struct {
+	// Time is when the reconciliation started.
+	Time metav1.Time `json:"time"`
+	// Duration is how long the reconciliation took.
+	Duration metav1.Duration `json:"duration"`
+	// Actions lists actions taken, such as upgrade rollback or member replacement.
+	// +optional
+	Actions []string `json:"actions,omitempty"`
+	// Skipped lists actions the operator decided not to take and why.
+	// +optional
+	Skipped []string `json:"skipped,omitempty"`
+	// Error is the error the reconciliation failed with.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// OperationType is a rollout of the cluster serialized by the operation lock.
+// +kubebuilder:validation:Enum=Upgrade;Scale
+type OperationType string
+
+const (
+	// OperationUpgrade rolls out a changed etcd image.
+	OperationUpgrade OperationType = "Upgrade"
+	// OperationScale changes the number of members.
+	OperationScale OperationType = "Scale"
+)
+
+// OperationStatus describes the operation holding the operation lock.
+type OperationStatus struct {
+	// Type of the operation.
+	Type OperationType `json:"type"`
+	// StartedAt is the time the operation acquired the lock.
+	StartedAt metav1.Time `json:"startedAt"`
+}
+
+// DebugShellStatus describes the running debug shell.
+type DebugShellStatus struct {
+	// PodName is the name of the pod to exec into.
+	PodName string `json:"podName"`
+	// ExpiresAt is the time the pod is deleted at.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// AdminAccessStatus describes etcdctl access to the cluster for humans.
+type AdminAccessStatus struct {
+	// SecretName is the name of the secret with ETCDCTL_* environment variables and the etcdctl.env file,
+	// which can be sourced by shell. Certificate paths match mounts of the debug pod.
+	SecretName string `json:"secretName"`
+	// DebugPod is an example pod manifest with etcdctl configured by the secret, use it with kubectl exec.
+	// +optional
+	DebugPod string `json:"debugPod,omitempty"`
+}
+
+// AlarmType is the type of alarm raised by etcd member.
+// +kubebuilder:validation:Enum=NOSPACE;CORRUPT
+type AlarmType string
+
+const (
+	// AlarmTypeNoSpace is raised when the database of the member reached its quota, the cluster accepts
+	// only reads and deletions until space is reclaimed and the alarm is disarmed.
+	AlarmTypeNoSpace AlarmType = "NOSPACE"
+	// AlarmTypeCorrupt is raised when data of the member is inconsistent with other members.
+	AlarmTypeCorrupt AlarmType = "CORRUPT"
+)
+
+// AlarmStatus describes alarm raised by etcd member.
+type AlarmStatus struct {
+	// Type of the alarm.
+	Type AlarmType `json:"type"`
+	// MemberID is the hex encoded ID of the member which raised the alarm.
+	MemberID string `json:"memberID"`
+	// Member is the name of the member, empty if the member is not known.
+	// +optional
+	Member string `json:"member,omitempty"`
+}
+
+// MemberStatus describes observed state of etcd member.
+type MemberStatus struct {
+	// Name of the member pod, or etcd member name of observed clusters.
+	Name string `json:"name"`
+	// Version of etcd running on the member. Empty if the member could not be reached.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// ID is the hex encoded etcd member ID. Empty if the member could not be reached.
+	// +optional
+	ID string `json:"id,omitempty"`
+	// ClusterID is the hex encoded ID of the etcd cluster the data of the member belongs to.
+	// Empty if the member could not be reached.
+	// +optional
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// MemberIdentity maps the member pod to the etcd member it runs.
+type MemberIdentity struct {
+	// Name of the member pod, which is the name of the etcd member as well.
+	Name string `json:"name"`
+	// Ordinal of the member pod.
+	Ordinal int32 `json:"ordinal"`
+	// ID is the hex encoded etcd member ID.
+	ID string `json:"id"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName={ec,etcd},categories=all
+// +kubebuilder:selectablefield:JSONPath=`.spec.profile`
+// +kubebuilder:selectablefield:JSONPath=`.spec.managementPolicy`
+// +kubebuilder:selectablefield:JSONPath=`.status.currentVersion`
+// +kubebuilder:selectablefield:JSONPath=`.status.targetVersion`
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.currentVersion"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".status.targetVersion"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // EtcdCluster is the Schema for the etcdclusters API
 type EtcdCluster struct {
@@ -98,6 +862,40 @@ func (r *EtcdCluster) CalculateQuorumSize() int {
 	return int(*r.Spec.Replicas)/2 + 1
 }
 
+// HasMemberReadinessGate returns true if readiness of member pods is gated by their etcd health.
+func (r *EtcdCluster) HasMemberReadinessGate() bool {
+	return r.Spec.AvailabilityPolicy != nil && r.Spec.AvailabilityPolicy.MemberReadinessGate
+}
+
+// MinHealthyMembers returns the number of ready members required for the Ready condition out of the given
+// number of members. All members are required by default or if the policy can't be parsed.
+func (r *EtcdCluster) MinHealthyMembers(members int) int {
+	if r.Spec.AvailabilityPolicy == nil || r.Spec.AvailabilityPolicy.MinHealthyMembers == nil {
+		return members
+	}
+	minHealthy, err := intstr.GetScaledValueFromIntOrPercent(r.Spec.AvailabilityPolicy.MinHealthyMembers, members, true)
+	if err != nil {
+		return members
+	}
+	return min(minHealthy, members)
+}
+
+// TargetVersion returns etcd version of the image defined in the spec or empty string if it is not a semantic version.
+func (r *EtcdCluster) TargetVersion() string {
+	version, _ := etcdImageVersion(r.EtcdImage())
+	return version
+}
+
+// EtcdImage returns image of etcd container defined in pod template or default etcd image if not overridden.
+func (r *EtcdCluster) EtcdImage() string {
+	for _, c := range r.Spec.PodTemplate.Spec.Containers {
+		if c.Name == "etcd" && c.Image != "" {
+			return c.Image
+		}
+	}
+	return DefaultEtcdImage
+}
+
 // +kubebuilder:object:root=true
 
 // EtcdClusterList contains a list of EtcdCluster
@@ -149,34 +947,463 @@ type PodTemplate struct {
 	// +optional
 	EmbeddedObjectMetadata `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
 
+	// CommandPrefix is prepended to the etcd command, e.g. ["dumb-init", "--"] or a script bootstrapping the environment.
+	// The wrapper must execute the command passed in its arguments, so etcd flags are still generated by the operator.
+	// +optional
+	CommandPrefix []string `json:"commandPrefix,omitempty"`
+
+	// Architectures are CPU architectures the etcd image is built for, e.g. ["amd64"] for a custom image.
+	// Members are only scheduled to Linux nodes of these architectures. Defaults to architectures official
+	// etcd images are published for: amd64, arm64, ppc64le and s390x.
+	// +optional
+	// +listType=set
+	Architectures []string `json:"architectures,omitempty"`
+
 	// Spec follows the structure of a regular Pod spec. Overrides defined here will be strategically merged with the default pod spec, generated by the operator.
 	// +optional
 	// +kubebuilder:pruning:PreserveUnknownFields
 	Spec corev1.PodSpec `json:"spec,omitempty"`
 }
 
+// RaftSnapshotsSpec defines snapshotting and WAL retention of etcd members.
+// Write-heavy clusters may trade memory for recovery speed: higher snapshot count keeps more raft entries in memory,
+// so lagging members catch up from the log instead of receiving a whole snapshot from the leader.
+type RaftSnapshotsSpec struct {
+	// SnapshotCount is the number of committed transactions that triggers a snapshot to disk, passed as --snapshot-count.
+	// Defaults to 10000, lowered down to 1000 for etcd containers with memory limit below 625Mi.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	SnapshotCount *int64 `json:"snapshotCount,omitempty"`
+	// MaxSnapshots is the maximum number of snapshot files to retain, 0 is unlimited. Passed as --max-snapshots,
+	// etcd retains 5 snapshot files if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxSnapshots *int32 `json:"maxSnapshots,omitempty"`
+	// MaxWALs is the maximum number of WAL files to retain, 0 is unlimited. Passed as --max-wals,
+	// etcd retains 5 WAL files if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxWALs *int32 `json:"maxWALs,omitempty"`
+}
+
+// RequestLimitsSpec defines limits of client requests accepted by etcd members.
+// Larger requests increase latency of other requests, since raft replicates them as a whole.
+type RequestLimitsSpec struct {
+	// MaxTxnOps is the maximum number of operations in a single transaction, passed as --max-txn-ops.
+	// etcd accepts 128 operations if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	MaxTxnOps *int32 `json:"maxTxnOps,omitempty"`
+	// MaxRequestBytes is the maximum size of a client request, passed as --max-request-bytes.
+	// etcd accepts requests up to 1.5MiB if not set and recommends at most 10MiB.
+	// +optional
+	MaxRequestBytes *resource.Quantity `json:"maxRequestBytes,omitempty"`
+}
+
+// AvailabilityPolicySpec controls meaning of the Ready condition.
+type AvailabilityPolicySpec struct {
+	// MinHealthyMembers is the number or percentage of replicas, rounded up, that must be ready for the cluster
+	// to be Ready, e.g. "51%" for a quorum. Defaults to all replicas, so Ready means fully redundant.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MinHealthyMembers *intstr.IntOrString `json:"minHealthyMembers,omitempty"`
+	// MemberReadinessGate adds a readiness gate to member pods, which the operator sets only once the member
+	// answers the etcd API with a known leader and no errors. Rolling updates then wait for etcd health of each
+	// member, not just for its probes. Pods are not ready while the operator is not running.
+	// +optional
+	MemberReadinessGate bool `json:"memberReadinessGate,omitempty"`
+}
+
+// AutoDefragSpec configures detection of space left behind by large deletions.
+type AutoDefragSpec struct {
+	// KeyDropPercent is the drop of the key count, relative to the highest count observed since the last
+	// defragmentation, that triggers compaction and defragmentation if the database size did not shrink accordingly.
+	// +optional
+	// +kubebuilder:default:=50
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	KeyDropPercent *int32 `json:"keyDropPercent,omitempty"`
+}
+
+// TracingSpec configures distributed tracing of etcd. Flags are passed under experimental names to etcd 3.5
+// and under stable names to newer versions.
+type TracingSpec struct {
+	// Endpoint is the host:port of the OpenTelemetry collector gRPC receiver, passed as --distributed-tracing-address.
+	// +kubebuilder:validation:MinLength:=1
+	Endpoint string `json:"endpoint"`
+	// SamplingRatePerMillion is the number of sampled spans per million, passed as --distributed-tracing-sampling-rate.
+	// +optional
+	// +kubebuilder:default:=1000
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=1000000
+	SamplingRatePerMillion *int32 `json:"samplingRatePerMillion,omitempty"`
+	// ServiceName is the service name of etcd spans, defaults to etcd. Spans of each member carry its pod name
+	// as the instance ID.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+// LoggingSpec configures shipping of etcd logs.
+type LoggingSpec struct {
+	// Shipper runs a Fluent Bit sidecar in each member pod, which tails etcd logs and ships them
+	// with cluster, namespace and member fields attached.
+	// +optional
+	Shipper *LogShipperSpec `json:"shipper,omitempty"`
+}
+
+// LogShipperSpec configures the log shipping sidecar. Etcd writes logs into a rotated file read by the sidecar,
+// which prints them to its stdout as well, so they are still available with kubectl logs.
+type LogShipperSpec struct {
+	// Image of Fluent Bit, defaults to cr.fluentbit.io/fluent/fluent-bit:3.0.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Outputs are Fluent Bit [OUTPUT] sections appended to the generated configuration, matching the etcd tag,
+	// e.g. to ship logs to Loki or Elasticsearch. Environment variables of the sidecar may be referenced as ${VAR}.
+	// +kubebuilder:validation:MinLength:=1
+	Outputs string `json:"outputs"`
+	// Env of the sidecar, e.g. credentials of the outputs taken from secrets.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Resources of the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
 // StorageSpec defines the configured storage for a etcd members.
 // If neither `emptyDir` nor `volumeClaimTemplate` is specified, then by default an [EmptyDir](https://kubernetes.io/docs/concepts/storage/volumes/#emptydir) will be used.
+// EmptyDir with `medium: Memory` keeps data in tmpfs, which gives very fast clusters for integration testing.
+// Its `sizeLimit` is required, as the data counts against memory of members and is lost when pods are deleted.
 // +k8s:openapi-gen=true
 type StorageSpec struct {
 	// EmptyDirVolumeSource to be used by the StatefulSets. If specified, used in place of any volumeClaimTemplate. More
 	// info: https://kubernetes.io/docs/concepts/storage/volumes/#emptydir
 	// +optional
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// Ephemeral acknowledges that cluster data kept in emptyDir is lost when member pods are deleted or their
+	// nodes restart. It is required with emptyDir, so quorum data does not end up on throwaway storage by accident.
+	// +optional
+	Ephemeral bool `json:"ephemeral,omitempty"`
 	// A PVC spec to be used by the StatefulSets.
+	// Increasing the storage request expands claims of existing members if their StorageClass allows volume
+	// expansion, members are restarted one at a time if their file systems can only be resized offline.
+	// +optional
+	VolumeClaimTemplate EmbeddedPersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	// HostPath keeps data of members in directories of the nodes they run on, for single-node edge clusters
+	// without volume provisioners. If specified, used in place of any volumeClaimTemplate. Data stays on the node
+	// when a member is scheduled elsewhere or the cluster is deleted, pin members to nodes with the pod template.
+	// +optional
+	HostPath *HostPathStorageSpec `json:"hostPath,omitempty"`
+	// MountPath of the data volume in the etcd container. Defaults to /var/run/etcd.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+	// SubPath of the data volume mounted instead of its root, e.g. to take over volumes of an existing
+	// deployment keeping data in a subdirectory. It can not be changed on existing clusters.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+	// DataDir is the etcd data directory relative to the mounted volume, "." for the volume itself.
+	// Defaults to default.etcd. It can not be changed on existing clusters.
+	// +optional
+	DataDir string `json:"dataDir,omitempty"`
+	// Members pins data volumes of individual members, e.g. to local PersistentVolumes. Claims of listed members
+	// are created from volumeClaimTemplate by the operator before their pods. If the node of a bound member volume
+	// is deleted, the member is removed from etcd and recreated with a new volume.
+	// +optional
+	// +listType=map
+	// +listMapKey=ordinal
+	Members []MemberVolume `json:"members,omitempty"`
+	// Encryption encrypts data volumes of members at rest.
+	// +optional
+	Encryption *StorageEncryptionSpec `json:"encryption,omitempty"`
+	// WALVolume keeps the write-ahead log of members on a separate volume, e.g. on a faster disk. When it is
+	// added to an existing cluster, members are restarted one at a time and their log is moved to the new volume.
+	// +optional
+	WALVolume *WALVolumeSpec `json:"walVolume,omitempty"`
+	// RetentionPolicy selects whether volume claims of members are deleted when the cluster is deleted or scaled
+	// down, Retain or Delete. Claims are retained by default. It is passed to the StatefulSet and also enforced
+	// by the operator, so it applies to the Pods member management mode and to clusters without the StatefulSet
+	// feature as well.
+	// +optional
+	RetentionPolicy *appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy `json:"retentionPolicy,omitempty"`
+	// VolumePermissions runs etcd as a non-root user and makes data volumes of members writable by it, either
+	// by fsGroup of member pods or by an init container changing ownership of the volumes. The init container is
+	// needed for storage ignoring fsGroup, e.g. NFS, hostPath and some CSI drivers.
+	// +optional
+	VolumePermissions *VolumePermissionsSpec `json:"volumePermissions,omitempty"`
+}
+
+// VolumePermissionsMode is the way ownership of data volumes is fixed for the etcd user.
+type VolumePermissionsMode string
+
+const (
+	// VolumePermissionsFSGroup sets fsGroup of member pods, so the kubelet changes group ownership of volumes.
+	VolumePermissionsFSGroup VolumePermissionsMode = "FSGroup"
+	// VolumePermissionsInitContainer changes ownership of volumes by an init container running as root.
+	VolumePermissionsInitContainer VolumePermissionsMode = "InitContainer"
+)
+
+// VolumePermissionsSpec defines the user etcd runs as and how its data volumes are made writable by the user.
+type VolumePermissionsSpec struct {
+	// Mode is FSGroup or InitContainer. Defaults to FSGroup.
+	// +optional
+	// +kubebuilder:validation:Enum=FSGroup;InitContainer
+	Mode VolumePermissionsMode `json:"mode,omitempty"`
+	// RunAsUser is the user id of etcd. Defaults to 1000.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// RunAsGroup is the group id of etcd and fsGroup of member pods. Defaults to 1000.
+	// +optional
+	// +kubebuilder:validation:Minimum:=1
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// Image of the init container. It must provide chown. Defaults to busybox.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// GetMode returns the volume permissions mode, FSGroup by default.
+func (v *VolumePermissionsSpec) GetMode() VolumePermissionsMode {
+	if v.Mode == "" {
+		return VolumePermissionsFSGroup
+	}
+	return v.Mode
+}
+
+// GetRunAsUser returns the user id of etcd.
+func (v *VolumePermissionsSpec) GetRunAsUser() int64 {
+	if v.RunAsUser == nil {
+		return DefaultEtcdUser
+	}
+	return *v.RunAsUser
+}
+
+// GetRunAsGroup returns the group id of etcd.
+func (v *VolumePermissionsSpec) GetRunAsGroup() int64 {
+	if v.RunAsGroup == nil {
+		return DefaultEtcdUser
+	}
+	return *v.RunAsGroup
+}
+
+// HostPathStorageSpec defines node directories keeping data of members.
+type HostPathStorageSpec struct {
+	// Path is the node directory keeping data of all members of the cluster, each member keeps its data in
+	// a subdirectory named after its pod. $(NAMESPACE) and $(CLUSTER) are replaced by the namespace and the name
+	// of the cluster. Defaults to /var/lib/etcd-operator/$(NAMESPACE)/$(CLUSTER). It can not be changed on
+	// existing clusters.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// WALVolumeSpec defines the volume of the etcd write-ahead log passed to etcd as --wal-dir.
+type WALVolumeSpec struct {
+	// EmptyDirVolumeSource of the log. It is only allowed with emptyDir data storage, as members can not recover
+	// persisted data without the log. If specified, used in place of volumeClaimTemplate.
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// A PVC spec of the log volume. The claim is named wal unless the template is named.
 	// +optional
 	VolumeClaimTemplate EmbeddedPersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
 }
 
+// StorageEncryptionSpec selects how data volumes of members are encrypted at rest.
+type StorageEncryptionSpec struct {
+	// StorageClassName is the name of a StorageClass provisioning encrypted volumes, e.g. with keys of a cloud KMS.
+	// It is used for member claims instead of storageClassName of volumeClaimTemplate.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// DMCrypt encrypts volumes of storage without encryption by LUKS inside member pods. Member claims are
+	// requested as raw block devices and a privileged sidecar opens and mounts them before etcd starts.
+	// It requires native sidecar containers, Kubernetes 1.29 or newer.
+	// +optional
+	DMCrypt *DMCryptSpec `json:"dmCrypt,omitempty"`
+}
+
+// DMCryptSpec configures LUKS encryption of member volumes.
+type DMCryptSpec struct {
+	// KeySecret is the Secret key holding the passphrase of volumes. Keys kept in a KMS can be synced into
+	// the Secret, e.g. by external-secrets. Changing the passphrase of existing volumes is not supported.
+	KeySecret corev1.SecretKeySelector `json:"keySecret"`
+	// Image of the sidecar. It must provide sh, cryptsetup, blkid, mkfs.ext4, mount and mountpoint.
+	Image string `json:"image"`
+}
+
+// MemberVolume pre-binds the data volume of a member to a PersistentVolume or a node.
+type MemberVolume struct {
+	// Ordinal is the index of the member, e.g. 0 for the first member.
+	// +kubebuilder:validation:Minimum:=0
+	Ordinal int32 `json:"ordinal"`
+	// VolumeName binds the member claim to the PersistentVolume.
+	// +optional
+	VolumeName string `json:"volumeName,omitempty"`
+	// Selector limits PersistentVolumes the member claim can be bound to.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// NodeName requests provisioning of the member volume on the node by local volume provisioners
+	// of StorageClasses with WaitForFirstConsumer binding mode. The member pod follows its volume.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// IsDMCryptEncrypted returns true if data volumes of members are encrypted by LUKS inside member pods.
+func (s *StorageSpec) IsDMCryptEncrypted() bool {
+	return s.Encryption != nil && s.Encryption.DMCrypt != nil
+}
+
+// GetMemberVolume returns pre-binding of the member data volume or nil if the member is not listed.
+func (s *StorageSpec) GetMemberVolume(ordinal int32) *MemberVolume {
+	for i := range s.Members {
+		if s.Members[i].Ordinal == ordinal {
+			return &s.Members[i]
+		}
+	}
+	return nil
+}
+
+// GetMountPath returns the mount path of the data volume in the etcd container.
+func (s *StorageSpec) GetMountPath() string {
+	if s.MountPath == "" {
+		return DefaultDataMountPath
+	}
+	return s.MountPath
+}
+
+// GetDataDir returns the etcd data directory relative to the mounted data volume.
+func (s *StorageSpec) GetDataDir() string {
+	if s.DataDir == "" {
+		return DefaultDataDir
+	}
+	return s.DataDir
+}
+
+// GetDataDirPath returns the path of the etcd data directory in the etcd container.
+func (s *StorageSpec) GetDataDirPath() string {
+	return path.Join(s.GetMountPath(), s.GetDataDir())
+}
+
+// HasVolumeClaims returns true if data volumes of members are claimed from volumeClaimTemplate.
+func (s *StorageSpec) HasVolumeClaims() bool {
+	return s.EmptyDir == nil && s.HostPath == nil
+}
+
+// DeletesClaimsWhenDeleted returns true if volume claims of members are deleted together with the cluster.
+func (s *StorageSpec) DeletesClaimsWhenDeleted() bool {
+	return s.RetentionPolicy != nil && s.RetentionPolicy.WhenDeleted == appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+// DeletesClaimsWhenScaled returns true if volume claims of members removed by scale-down are deleted.
+func (s *StorageSpec) DeletesClaimsWhenScaled() bool {
+	return s.RetentionPolicy != nil && s.RetentionPolicy.WhenScaled == appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+// IsMemory returns true if members keep data in tmpfs emptyDir, which is only suitable for ephemeral test clusters.
+func (s *StorageSpec) IsMemory() bool {
+	return s.EmptyDir != nil && s.EmptyDir.Medium == corev1.StorageMediumMemory
+}
+
 // SecuritySpec defines security settings for etcd.
 // +k8s:openapi-gen=true
 type SecuritySpec struct {
 	// Section for user-managed tls certificates
 	// +optional
 	TLS TLSSpec `json:"tls,omitempty"`
+	// CAPublication enables publication of the CA certificate, which signed the etcd server certificate, for clients.
+	// +optional
+	CAPublication *CAPublicationSpec `json:"caPublication,omitempty"`
+	// Auth configures authentication of etcd users.
+	// +optional
+	Auth *AuthSpec `json:"auth,omitempty"`
+}
+
+// AuthSpec describes authentication of etcd users.
+type AuthSpec struct {
+	// Enabled makes the operator generate a password of the etcd root user into the <name>-root-credentials secret
+	// with username and password fields, add the root user and enable authentication once the cluster is ready.
+	// The operator uses the root user in all requests to etcd afterwards. Authentication is disabled again once
+	// it is unset, the secret is kept until the cluster is deleted.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// PruneUnmanaged makes the operator delete etcd users and roles of the cluster which no EtcdUser or EtcdRole
+	// declares, except the root user and role. Otherwise they are only reported by the AuthDrift condition.
+	// Nothing is pruned while the cluster has no EtcdUsers or EtcdRoles.
+	// +optional
+	PruneUnmanaged bool `json:"pruneUnmanaged,omitempty"`
+	// JWT makes etcd issue JSON Web Tokens instead of simple tokens, which are valid only on the member that issued
+	// them and are lost once it restarts. Tokens are signed by a key pair the operator generates into the
+	// <name>-jwt-key secret, see the etcd.aenix.io/rotate-jwt-key annotation to rotate it.
+	// +optional
+	JWT *JWTSpec `json:"jwt,omitempty"`
+}
+
+// JWTSpec describes JSON Web Tokens etcd issues to authenticated users.
+type JWTSpec struct {
+	// SignMethod is the algorithm tokens are signed with. An RSA key is generated for RS methods
+	// and an ECDSA key on the curve of the method for ES methods. Changing it replaces the key.
+	// +optional
+	// +kubebuilder:validation:Enum=RS256;RS384;RS512;ES256;ES384;ES512
+	// +kubebuilder:default:=RS256
+	SignMethod string `json:"signMethod,omitempty"`
+	// TTL is how long issued tokens are valid. Defaults to 5 minutes.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+const (
+	// DefaultJWTSignMethod is the algorithm tokens are signed with unless spec.security.auth.jwt.signMethod is set.
+	DefaultJWTSignMethod = "RS256"
+	// DefaultJWTTTL is how long tokens are valid unless spec.security.auth.jwt.ttl is set.
+	DefaultJWTTTL = 5 * time.Minute
+)
+
+// GetSignMethod returns the algorithm tokens are signed with.
+func (s *JWTSpec) GetSignMethod() string {
+	if s.SignMethod == "" {
+		return DefaultJWTSignMethod
+	}
+	return s.SignMethod
+}
+
+// GetTTL returns how long issued tokens are valid.
+func (s *JWTSpec) GetTTL() time.Duration {
+	if s.TTL == nil || s.TTL.Duration <= 0 {
+		return DefaultJWTTTL
+	}
+	return s.TTL.Duration
+}
+
+// CAPublicationSpec describes where the CA certificate of etcd server is published.
+// The certificate is taken from the ca.crt field of the server certificate secret.
+type CAPublicationSpec struct {
+	// Name of ConfigMap with CA certificate in ca.crt field. Defaults to <cluster namespace>-<cluster name>-ca,
+	// so clusters of the same name in different namespaces can publish into the same namespace.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Namespaces to publish ConfigMap with CA certificate into. ConfigMap is always published into the namespace of the cluster.
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+	// TrustManager enables distribution of CA certificate with trust-manager Bundle.
+	// +optional
+	TrustManager *TrustManagerSpec `json:"trustManager,omitempty"`
+}
+
+// TrustManagerSpec describes trust-manager Bundle distributing CA certificate.
+type TrustManagerSpec struct {
+	// Trust namespace of trust-manager. Bundle sources are published into this namespace.
+	// +optional
+	// +kubebuilder:default:=cert-manager
+	TrustNamespace string `json:"trustNamespace,omitempty"`
+	// Selects namespaces to create Bundle target ConfigMaps in. All namespaces are selected if empty.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 // TLSSpec defines user-managed certificates names.
+// Members are restarted one at a time when peer, server or trusted CA secrets change, as etcd does not reload
+// trusted CAs. Only CA certificates of secrets issued by certManager or internalCA restart members, renewed
+// certificates are loaded by etcd without restart. Restarts start only while all members are healthy and each
+// of them waits for the previous member to be ready. To rotate a CA of user-managed certificates without downtime,
+// put both the old and the new CA into trusted CA secrets, wait for members to restart, replace certificates by
+// ones issued by the new CA, then remove the old CA.
 type TLSSpec struct {
 	// Trusted CA certificate secret to secure peer-to-peer communication between etcd nodes. It is expected to have tls.crt field in the secret.
 	// +optional
@@ -188,12 +1415,116 @@ type TLSSpec struct {
 	// It is expected to have tls.crt and tls.key fields in the secret.
 	// +optional
 	ServerSecret string `json:"serverSecret,omitempty"`
-	// Trusted CA for client certificates that are provided by client to etcd. It is expected to have tls.crt field in the secret.
+	// Trusted CA for client certificates that are provided by client to etcd. It is expected to have ca.crt field in the secret.
+	// Members require client certificates signed by it (--client-cert-auth) once it is set together with clientSecret.
 	// +optional
 	ClientTrustedCASecret string `json:"clientTrustedCASecret,omitempty"`
 	// Client certificate for etcd-operator to do maintenance. It is expected to have tls.crt and tls.key fields in the secret.
+	// The operator presents it in health checks and membership operations, so it must be signed by a CA of
+	// clientTrustedCASecret. It requires serverSecret, as etcd verifies client certificates on TLS connections only.
 	// +optional
 	ClientSecret string `json:"clientSecret,omitempty"`
+	// Certificate revocation list for client certificates. It is expected to have ca.crl field in the secret.
+	// etcd reads the list on every client connection, so revoked certificates are rejected without restart of members.
+	// +optional
+	ClientCRLSecret string `json:"clientCRLSecret,omitempty"`
+	// CertManager requests server, peer and client certificates from cert-manager instead of providing them.
+	// Certificates are issued into serverSecret, peerSecret and clientSecret, which default to <name>-server-tls,
+	// <name>-peer-tls and <name>-client-tls. Trusted CA secrets default to the certificate secrets, as cert-manager
+	// stores the CA of the issuer in their ca.crt field.
+	// +optional
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+	// InternalCA makes the operator generate a CA and issue certificates signed by it: a peer certificate
+	// of every member, a server certificate covering members and the client service, and a client certificate
+	// of the operator. Certificates are issued into <name>-peer-tls, <name>-server-tls and <name>-client-tls
+	// secrets and renewed before they expire. It can only be set when the cluster is created, members with
+	// self-signed peer certificates can not talk to members verifying peers by the CA.
+	// +optional
+	InternalCA *InternalCASpec `json:"internalCA,omitempty"`
+}
+
+// InternalCASpec describes certificates issued by the CA generated by the operator.
+type InternalCASpec struct {
+	// Duration of issued certificates. The CA itself is valid for ten years and rotated a year before it expires,
+	// see the etcd.aenix.io/rotate-internal-ca annotation. Defaults to 90 days.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry certificates are renewed. Defaults to a third of the duration.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+const (
+	// DefaultInternalCADuration is the default duration of certificates issued by the internal CA.
+	DefaultInternalCADuration = 90 * 24 * time.Hour
+	// InternalCARootDuration is how long the internal CA is valid.
+	InternalCARootDuration = 10 * 365 * 24 * time.Hour
+	// InternalCARotateBefore is how long before expiry the internal CA is rotated.
+	InternalCARotateBefore = 365 * 24 * time.Hour
+)
+
+// GetDuration returns duration of issued certificates.
+func (s *InternalCASpec) GetDuration() time.Duration {
+	if s.Duration == nil || s.Duration.Duration <= 0 {
+		return DefaultInternalCADuration
+	}
+	return s.Duration.Duration
+}
+
+// GetRenewBefore returns how long before expiry certificates are renewed.
+func (s *InternalCASpec) GetRenewBefore() time.Duration {
+	if s.RenewBefore == nil || s.RenewBefore.Duration <= 0 {
+		return s.GetDuration() / 3
+	}
+	return s.RenewBefore.Duration
+}
+
+// UsesInternalCA returns true if certificates of the cluster are issued by the CA generated by the operator.
+func (r *EtcdCluster) UsesInternalCA() bool {
+	return r.Spec.Security != nil && r.Spec.Security.TLS.InternalCA != nil
+}
+
+// IsAuthEnabled returns true if authentication of etcd users is requested by spec.security.auth.
+func (r *EtcdCluster) IsAuthEnabled() bool {
+	return r.Spec.Security != nil && r.Spec.Security.Auth != nil && r.Spec.Security.Auth.Enabled
+}
+
+// UsesJWT returns true if etcd issues JSON Web Tokens signed by the key pair generated by the operator.
+func (r *EtcdCluster) UsesJWT() bool {
+	return r.Spec.Security != nil && r.Spec.Security.Auth != nil && r.Spec.Security.Auth.JWT != nil
+}
+
+// PrunesUnmanagedAuth returns true if etcd users and roles no EtcdUser or EtcdRole declares are deleted,
+// see spec.security.auth.pruneUnmanaged.
+func (r *EtcdCluster) PrunesUnmanagedAuth() bool {
+	return r.Spec.Security != nil && r.Spec.Security.Auth != nil && r.Spec.Security.Auth.PruneUnmanaged
+}
+
+// CertManagerSpec describes how certificates of the cluster are issued by cert-manager.
+type CertManagerSpec struct {
+	// IssuerRef is the cert-manager issuer signing certificates of the cluster.
+	IssuerRef CertManagerIssuerRef `json:"issuerRef"`
+	// Duration of issued certificates. Defaults to the cert-manager default of 90 days.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry certificates are renewed. Defaults to a third of the duration.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager Issuer, ClusterIssuer or an external issuer.
+type CertManagerIssuerRef struct {
+	// Name of the issuer.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind of the issuer.
+	// +optional
+	// +kubebuilder:default:=Issuer
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer.
+	// +optional
+	// +kubebuilder:default:=cert-manager.io
+	Group string `json:"group,omitempty"`
 }
 
 // EmbeddedPersistentVolumeClaim is an embedded version of k8s.io/api/core/v1.PersistentVolumeClaim.
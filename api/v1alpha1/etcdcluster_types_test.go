@@ -1,8 +1,12 @@
 package v1alpha1
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
 )
 
@@ -20,3 +24,98 @@ var _ = Context("CalculateQuorumSize", func() {
 		Expect(etcdCluster.CalculateQuorumSize()).To(Equal(3))
 	})
 })
+
+var _ = Context("MinHealthyMembers", func() {
+	It("should require all members by default", func() {
+		etcdCluster := EtcdCluster{}
+		Expect(etcdCluster.MinHealthyMembers(3)).To(Equal(3))
+	})
+	It("should round percentages up and cap at the member count", func() {
+		etcdCluster := EtcdCluster{
+			Spec: EtcdClusterSpec{AvailabilityPolicy: &AvailabilityPolicySpec{
+				MinHealthyMembers: ptr.To(intstr.FromString("51%")),
+			}},
+		}
+		Expect(etcdCluster.MinHealthyMembers(5)).To(Equal(3))
+		etcdCluster.Spec.AvailabilityPolicy.MinHealthyMembers = ptr.To(intstr.FromInt32(7))
+		Expect(etcdCluster.MinHealthyMembers(5)).To(Equal(5))
+	})
+})
+
+var _ = Context("CordonedMembers", func() {
+	It("should parse ordinals of cordoned members", func() {
+		etcdCluster := EtcdCluster{}
+		etcdCluster.Annotations = map[string]string{CordonedMembersAnnotation: "0, 2"}
+		Expect(etcdCluster.CordonedMembers()).To(Equal([]int{0, 2}))
+		Expect(etcdCluster.IsMemberCordoned(2)).To(BeTrue())
+		Expect(etcdCluster.IsMemberCordoned(1)).To(BeFalse())
+	})
+	It("should cordon no members if the annotation is malformed", func() {
+		etcdCluster := EtcdCluster{}
+		etcdCluster.Annotations = map[string]string{CordonedMembersAnnotation: "0,test-1"}
+		_, err := etcdCluster.CordonedMembers()
+		Expect(err).To(HaveOccurred())
+		Expect(etcdCluster.IsMemberCordoned(0)).To(BeFalse())
+	})
+})
+
+var _ = Context("DebugShellTTL", func() {
+	It("should return duration of the requested debug shell", func() {
+		etcdCluster := EtcdCluster{}
+		Expect(etcdCluster.DebugShellTTL()).To(BeZero())
+		etcdCluster.Annotations = map[string]string{DebugShellAnnotation: "30m"}
+		Expect(etcdCluster.DebugShellTTL()).To(Equal(30 * time.Minute))
+	})
+	It("should reject malformed or too long durations", func() {
+		etcdCluster := EtcdCluster{}
+		for _, ttl := range []string{"yes", "-1h", "0s", "25h"} {
+			etcdCluster.Annotations = map[string]string{DebugShellAnnotation: ttl}
+			_, err := etcdCluster.DebugShellTTL()
+			Expect(err).To(HaveOccurred(), ttl)
+		}
+	})
+})
+
+var _ = Context("GetDataDirPath", func() {
+	It("should default the data dir path", func() {
+		storage := StorageSpec{}
+		Expect(storage.GetDataDirPath()).To(Equal("/var/run/etcd/default.etcd"))
+	})
+	It("should join the mount path and the data dir", func() {
+		storage := StorageSpec{MountPath: "/var/lib/etcd", DataDir: "."}
+		Expect(storage.GetDataDirPath()).To(Equal("/var/lib/etcd"))
+		storage.DataDir = "data"
+		Expect(storage.GetDataDirPath()).To(Equal("/var/lib/etcd/data"))
+	})
+})
+
+var _ = Context("TargetVersion", func() {
+	It("should return version of the default image", func() {
+		etcdCluster := EtcdCluster{}
+		Expect(etcdCluster.TargetVersion()).To(Equal("3.5.12"))
+	})
+	It("should return version of the overridden image", func() {
+		etcdCluster := EtcdCluster{
+			Spec: EtcdClusterSpec{
+				PodTemplate: PodTemplate{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "etcd", Image: "registry.k8s.io/etcd:3.5.13-0"}},
+					},
+				},
+			},
+		}
+		Expect(etcdCluster.TargetVersion()).To(Equal("3.5.13"))
+	})
+	It("should return empty version for image pinned by digest", func() {
+		etcdCluster := EtcdCluster{
+			Spec: EtcdClusterSpec{
+				PodTemplate: PodTemplate{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "etcd", Image: "quay.io/coreos/etcd@sha256:abcd"}},
+					},
+				},
+			},
+		}
+		Expect(etcdCluster.TargetVersion()).To(BeEmpty())
+	})
+})
@@ -17,31 +17,51 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// maxDNSNdots is the maximum ndots value accepted by the resolver.
+const maxDNSNdots = 15
+
+// imageDigestRe matches digests of image references pinned by content.
+var imageDigestRe = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
 // log is for logging in this package.
 var etcdclusterlog = logf.Log.WithName("etcdcluster-resource")
 
 // SetupWebhookWithManager will setup the manager to manage the webhooks
-func (r *EtcdCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+func (r *EtcdCluster) SetupWebhookWithManager(mgr ctrl.Manager, validator *EtcdClusterValidator) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithDefaulter(&namespaceDefaulter{client: mgr.GetClient()}).
+		WithValidator(validator).
 		Complete()
 }
 
@@ -52,7 +72,10 @@ var _ webhook.Defaulter = &EtcdCluster{}
 // Default implements webhook.Defaulter so a webhook will be registered for the type
 func (r *EtcdCluster) Default() {
 	etcdclusterlog.Info("default", "name", r.Name)
-	if r.Spec.Storage.EmptyDir == nil {
+	if r.IsStandalone() {
+		r.defaultStandalone()
+	}
+	if r.Spec.Storage.HasVolumeClaims() {
 		if len(r.Spec.Storage.VolumeClaimTemplate.Spec.AccessModes) == 0 {
 			r.Spec.Storage.VolumeClaimTemplate.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
 		}
@@ -63,14 +86,86 @@ func (r *EtcdCluster) Default() {
 			}
 		}
 	}
+	if r.Spec.Security != nil && (r.Spec.Security.TLS.CertManager != nil || r.Spec.Security.TLS.InternalCA != nil) {
+		r.defaultIssuedSecrets()
+	}
+}
+
+// defaultStandalone limits standalone clusters to a single member and keeps their data in emptyDir,
+// unless storage is configured.
+func (r *EtcdCluster) defaultStandalone() {
+	if r.Spec.Replicas == nil || *r.Spec.Replicas > 1 {
+		r.Spec.Replicas = ptr.To(int32(1))
+	}
+	if equality.Semantic.DeepEqual(r.Spec.Storage, StorageSpec{}) {
+		r.Spec.Storage = StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}, Ephemeral: true}
+	}
+}
+
+// defaultIssuedSecrets names secrets of certificates issued by cert-manager or the internal CA. Certificate secrets
+// are trusted CA secrets too, the CA of the issuer is kept in their ca.crt field.
+func (r *EtcdCluster) defaultIssuedSecrets() {
+	tls := &r.Spec.Security.TLS
+	if tls.ServerSecret == "" {
+		tls.ServerSecret = r.Name + "-server-tls"
+	}
+	if tls.PeerSecret == "" {
+		tls.PeerSecret = r.Name + "-peer-tls"
+	}
+	if tls.PeerTrustedCASecret == "" {
+		tls.PeerTrustedCASecret = tls.PeerSecret
+	}
+	if tls.ClientSecret == "" {
+		tls.ClientSecret = r.Name + "-client-tls"
+	}
+	if tls.ClientTrustedCASecret == "" {
+		tls.ClientTrustedCASecret = tls.ClientSecret
+	}
 }
 
 // +kubebuilder:webhook:path=/validate-etcd-aenix-io-v1alpha1-etcdcluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=etcd.aenix.io,resources=etcdclusters,verbs=create;update,versions=v1alpha1,name=vetcdcluster.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &EtcdCluster{}
+// EtcdClusterValidator validates EtcdClusters on admission with settings of the operator.
+// +kubebuilder:object:generate=false
+type EtcdClusterValidator struct {
+	// ExperimentalOptionsEnabled admits spec.experimentalOptions, see the --enable-experimental-options flag.
+	ExperimentalOptionsEnabled bool
+}
+
+var _ webhook.CustomValidator = &EtcdClusterValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *EtcdClusterValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	cluster, ok := obj.(*EtcdCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an EtcdCluster but got %T", obj)
+	}
+	return cluster.validateCreate(v.ExperimentalOptionsEnabled)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *EtcdClusterValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldCluster, ok := oldObj.(*EtcdCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an EtcdCluster but got %T", oldObj)
+	}
+	cluster, ok := newObj.(*EtcdCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an EtcdCluster but got %T", newObj)
+	}
+	return cluster.validateUpdate(oldCluster, v.ExperimentalOptionsEnabled)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *EtcdClusterValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	if cluster, ok := obj.(*EtcdCluster); ok {
+		etcdclusterlog.Info("validate delete", "name", cluster.Name)
+	}
+	return nil, nil
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *EtcdCluster) ValidateCreate() (admission.Warnings, error) {
+// validateCreate validates a new cluster, experimentalOptions admits spec.experimentalOptions.
+func (r *EtcdCluster) validateCreate(experimentalOptions bool) (admission.Warnings, error) {
 	etcdclusterlog.Info("validate create", "name", r.Name)
 
 	var allErrors field.ErrorList
@@ -85,6 +180,16 @@ func (r *EtcdCluster) ValidateCreate() (admission.Warnings, error) {
 		allErrors = append(allErrors, securityErr...)
 	}
 
+	dnsErr := r.validateDNS()
+	if dnsErr != nil {
+		allErrors = append(allErrors, dnsErr...)
+	}
+
+	if serviceErr := r.validateServiceTemplate(); serviceErr != nil {
+		allErrors = append(allErrors, serviceErr...)
+	}
+	warnings = append(warnings, r.validateIsolation()...)
+
 	if errOptions := validateOptions(r); errOptions != nil {
 		allErrors = append(allErrors, field.Invalid(
 			field.NewPath("spec", "options"),
@@ -92,6 +197,108 @@ func (r *EtcdCluster) ValidateCreate() (admission.Warnings, error) {
 			errOptions.Error()))
 	}
 
+	flagWarnings, flagErr := r.validateOptionsFlagSet()
+	if flagErr != nil {
+		allErrors = append(allErrors, flagErr...)
+	}
+	warnings = append(warnings, flagWarnings...)
+
+	experimentalWarnings, experimentalErr := r.validateExperimentalOptions(experimentalOptions)
+	if experimentalErr != nil {
+		allErrors = append(allErrors, experimentalErr...)
+	}
+	warnings = append(warnings, experimentalWarnings...)
+
+	if raftSnapshotsErr := r.validateRaftSnapshots(); raftSnapshotsErr != nil {
+		allErrors = append(allErrors, raftSnapshotsErr...)
+	}
+
+	requestLimitsWarnings, requestLimitsErr := r.validateRequestLimits()
+	if requestLimitsErr != nil {
+		allErrors = append(allErrors, requestLimitsErr...)
+	}
+	warnings = append(warnings, requestLimitsWarnings...)
+
+	authWarnings, authErr := r.validateAuth()
+	allErrors = append(allErrors, authErr...)
+	warnings = append(warnings, authWarnings...)
+
+	if tracingErr := r.validateTracing(); tracingErr != nil {
+		allErrors = append(allErrors, tracingErr...)
+	}
+
+	if loggingErr := r.validateLogging(); loggingErr != nil {
+		allErrors = append(allErrors, loggingErr...)
+	}
+
+	if observeErr := r.validateManagementPolicy(); observeErr != nil {
+		allErrors = append(allErrors, observeErr...)
+	}
+
+	modeWarnings, modeErr := r.validateMode(nil)
+	allErrors = append(allErrors, modeErr...)
+	warnings = append(warnings, modeWarnings...)
+
+	if connectionErr := r.validateOperatorConnection(); connectionErr != nil {
+		allErrors = append(allErrors, connectionErr...)
+	}
+
+	if bootstrapErr := r.validateBootstrap(); bootstrapErr != nil {
+		allErrors = append(allErrors, bootstrapErr...)
+	}
+
+	if platformErr := r.validatePlatform(); platformErr != nil {
+		allErrors = append(allErrors, platformErr...)
+	}
+
+	if imageErr := r.validateImageDigests(); imageErr != nil {
+		allErrors = append(allErrors, imageErr...)
+	}
+
+	if prefixErr := r.validateCommandPrefix(); prefixErr != nil {
+		allErrors = append(allErrors, prefixErr...)
+	}
+
+	if availabilityErr := r.validateAvailabilityPolicy(); availabilityErr != nil {
+		allErrors = append(allErrors, availabilityErr...)
+	}
+
+	if cordonErr := r.validateCordonedMembers(); cordonErr != nil {
+		allErrors = append(allErrors, cordonErr...)
+	}
+
+	if debugShellErr := r.validateDebugShell(); debugShellErr != nil {
+		allErrors = append(allErrors, debugShellErr...)
+	}
+
+	if r.Spec.ReconcilePeriod != nil && r.Spec.ReconcilePeriod.Duration <= 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "reconcilePeriod"),
+			r.Spec.ReconcilePeriod.Duration.String(),
+			"must be positive"))
+	}
+
+	if r.Spec.MemberReplacementGracePeriod != nil && r.Spec.MemberReplacementGracePeriod.Duration < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "memberReplacementGracePeriod"),
+			r.Spec.MemberReplacementGracePeriod.Duration.String(),
+			"must not be negative"))
+	}
+
+	if r.Spec.BootstrapTimeout != nil && r.Spec.BootstrapTimeout.Duration < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "bootstrapTimeout"),
+			r.Spec.BootstrapTimeout.Duration.String(),
+			"must not be negative"))
+	}
+
+	storageWarnings, storageErr := r.validateStorage()
+	if storageErr != nil {
+		allErrors = append(allErrors, storageErr...)
+	}
+	warnings = append(warnings, storageWarnings...)
+	allErrors = append(allErrors, r.validateEphemeralStorage(nil)...)
+
 	if len(allErrors) > 0 {
 		err := errors.NewInvalid(
 			schema.GroupKind{Group: GroupVersion.Group, Kind: "EtcdCluster"},
@@ -102,24 +309,81 @@ func (r *EtcdCluster) ValidateCreate() (admission.Warnings, error) {
 	return warnings, nil
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *EtcdCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+// validateUpdate validates changes of the cluster, experimentalOptions admits spec.experimentalOptions.
+func (r *EtcdCluster) validateUpdate(oldCluster *EtcdCluster, experimentalOptions bool) (admission.Warnings, error) {
 	etcdclusterlog.Info("validate update", "name", r.Name)
 	var warnings admission.Warnings
-	oldCluster := old.(*EtcdCluster)
 	if *oldCluster.Spec.Replicas != *r.Spec.Replicas {
 		warnings = append(warnings, "cluster resize is not currently supported")
 	}
 
 	var allErrors field.ErrorList
-	if oldCluster.Spec.Storage.EmptyDir == nil && r.Spec.Storage.EmptyDir != nil ||
-		oldCluster.Spec.Storage.EmptyDir != nil && r.Spec.Storage.EmptyDir == nil {
+	if oldCluster.Spec.Storage.EmptyDir == nil && r.Spec.Storage.EmptyDir != nil {
 		allErrors = append(allErrors, field.Invalid(
 			field.NewPath("spec", "storage", "emptyDir"),
 			r.Spec.Storage.EmptyDir,
 			"field is immutable"),
 		)
 	}
+	allErrors = append(allErrors, r.validateEphemeralStorage(oldCluster)...)
+	if oldCluster.Spec.Storage.EmptyDir != nil && r.Spec.Storage.EmptyDir == nil {
+		migrationWarnings, migrationErr := r.validateStorageMigration()
+		allErrors = append(allErrors, migrationErr...)
+		warnings = append(warnings, migrationWarnings...)
+	}
+	if (oldCluster.Spec.Storage.HostPath == nil) != (r.Spec.Storage.HostPath == nil) ||
+		r.Spec.Storage.HostPath != nil && oldCluster.GetHostPath() != r.GetHostPath() {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "hostPath"),
+			r.Spec.Storage.HostPath,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.Spec.Storage.IsDMCryptEncrypted() != r.Spec.Storage.IsDMCryptEncrypted() {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "encryption", "dmCrypt"),
+			r.Spec.Storage.Encryption,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.Spec.Storage.SubPath != r.Spec.Storage.SubPath {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "subPath"),
+			r.Spec.Storage.SubPath,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.Spec.Storage.GetDataDir() != r.Spec.Storage.GetDataDir() {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "dataDir"),
+			r.Spec.Storage.DataDir,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.Spec.Storage.WALVolume != nil {
+		allErrors = append(allErrors, r.validateWALVolumeUpdate(oldCluster.Spec.Storage.WALVolume)...)
+	}
+	if oldCluster.IsObserved() != r.IsObserved() {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "managementPolicy"),
+			r.Spec.ManagementPolicy,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.ManagesMemberPods() != r.ManagesMemberPods() {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "memberManagement"),
+			r.Spec.MemberManagement,
+			"field is immutable"),
+		)
+	}
+	if oldCluster.UsesInternalCA() != r.UsesInternalCA() {
+		allErrors = append(allErrors, field.Forbidden(
+			field.NewPath("spec", "security", "tls", "internalCA"),
+			"can only be set when the cluster is created"),
+		)
+	}
+	allErrors = append(allErrors, r.validateStorageDecrease(oldCluster)...)
 
 	pdbWarnings, pdbErr := r.validatePdb()
 	if pdbErr != nil {
@@ -134,6 +398,16 @@ func (r *EtcdCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, er
 		allErrors = append(allErrors, securityErr...)
 	}
 
+	dnsErr := r.validateDNS()
+	if dnsErr != nil {
+		allErrors = append(allErrors, dnsErr...)
+	}
+
+	if serviceErr := r.validateServiceTemplate(); serviceErr != nil {
+		allErrors = append(allErrors, serviceErr...)
+	}
+	warnings = append(warnings, r.validateIsolation()...)
+
 	if errOptions := validateOptions(r); errOptions != nil {
 		allErrors = append(allErrors, field.Invalid(
 			field.NewPath("spec", "options"),
@@ -141,6 +415,107 @@ func (r *EtcdCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, er
 			errOptions.Error()))
 	}
 
+	flagWarnings, flagErr := r.validateOptionsFlagSet()
+	if flagErr != nil {
+		allErrors = append(allErrors, flagErr...)
+	}
+	warnings = append(warnings, flagWarnings...)
+
+	experimentalWarnings, experimentalErr := r.validateExperimentalOptions(experimentalOptions)
+	if experimentalErr != nil {
+		allErrors = append(allErrors, experimentalErr...)
+	}
+	warnings = append(warnings, experimentalWarnings...)
+
+	if raftSnapshotsErr := r.validateRaftSnapshots(); raftSnapshotsErr != nil {
+		allErrors = append(allErrors, raftSnapshotsErr...)
+	}
+
+	requestLimitsWarnings, requestLimitsErr := r.validateRequestLimits()
+	if requestLimitsErr != nil {
+		allErrors = append(allErrors, requestLimitsErr...)
+	}
+	warnings = append(warnings, requestLimitsWarnings...)
+
+	authWarnings, authErr := r.validateAuth()
+	allErrors = append(allErrors, authErr...)
+	warnings = append(warnings, authWarnings...)
+
+	if tracingErr := r.validateTracing(); tracingErr != nil {
+		allErrors = append(allErrors, tracingErr...)
+	}
+
+	if loggingErr := r.validateLogging(); loggingErr != nil {
+		allErrors = append(allErrors, loggingErr...)
+	}
+
+	if observeErr := r.validateManagementPolicy(); observeErr != nil {
+		allErrors = append(allErrors, observeErr...)
+	}
+
+	modeWarnings, modeErr := r.validateMode(oldCluster)
+	allErrors = append(allErrors, modeErr...)
+	warnings = append(warnings, modeWarnings...)
+
+	if connectionErr := r.validateOperatorConnection(); connectionErr != nil {
+		allErrors = append(allErrors, connectionErr...)
+	}
+
+	if bootstrapErr := r.validateBootstrap(); bootstrapErr != nil {
+		allErrors = append(allErrors, bootstrapErr...)
+	}
+
+	if platformErr := r.validatePlatform(); platformErr != nil {
+		allErrors = append(allErrors, platformErr...)
+	}
+
+	if imageErr := r.validateImageDigests(); imageErr != nil {
+		allErrors = append(allErrors, imageErr...)
+	}
+
+	if prefixErr := r.validateCommandPrefix(); prefixErr != nil {
+		allErrors = append(allErrors, prefixErr...)
+	}
+
+	if availabilityErr := r.validateAvailabilityPolicy(); availabilityErr != nil {
+		allErrors = append(allErrors, availabilityErr...)
+	}
+
+	if cordonErr := r.validateCordonedMembers(); cordonErr != nil {
+		allErrors = append(allErrors, cordonErr...)
+	}
+
+	if debugShellErr := r.validateDebugShell(); debugShellErr != nil {
+		allErrors = append(allErrors, debugShellErr...)
+	}
+
+	if r.Spec.ReconcilePeriod != nil && r.Spec.ReconcilePeriod.Duration <= 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "reconcilePeriod"),
+			r.Spec.ReconcilePeriod.Duration.String(),
+			"must be positive"))
+	}
+
+	if r.Spec.MemberReplacementGracePeriod != nil && r.Spec.MemberReplacementGracePeriod.Duration < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "memberReplacementGracePeriod"),
+			r.Spec.MemberReplacementGracePeriod.Duration.String(),
+			"must not be negative"))
+	}
+
+	if r.Spec.BootstrapTimeout != nil && r.Spec.BootstrapTimeout.Duration < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "bootstrapTimeout"),
+			r.Spec.BootstrapTimeout.Duration.String(),
+			"must not be negative"))
+	}
+
+	storageWarnings, storageErr := r.validateStorage()
+	if storageErr != nil {
+		allErrors = append(allErrors, storageErr...)
+	}
+	warnings = append(warnings, storageWarnings...)
+
 	if len(allErrors) > 0 {
 		err := errors.NewInvalid(
 			schema.GroupKind{Group: GroupVersion.Group, Kind: "EtcdCluster"},
@@ -151,15 +526,10 @@ func (r *EtcdCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, er
 	return warnings, nil
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *EtcdCluster) ValidateDelete() (admission.Warnings, error) {
-	etcdclusterlog.Info("validate delete", "name", r.Name)
-	return nil, nil
-}
-
 // validatePdb validates PDB fields
 func (r *EtcdCluster) validatePdb() (admission.Warnings, field.ErrorList) {
-	if r.Spec.PodDisruptionBudgetTemplate == nil {
+	// standalone clusters have no PodDisruptionBudget, the template is reported ignored by validateMode
+	if r.Spec.PodDisruptionBudgetTemplate == nil || r.IsStandalone() {
 		return nil, nil
 	}
 	pdb := r.Spec.PodDisruptionBudgetTemplate
@@ -281,6 +651,203 @@ func (r *EtcdCluster) validateSecurity() field.ErrorList {
 		)
 	}
 
+	// etcd verifies client certificates only on TLS connections, so the operator would not present its certificate
+	if security.TLS.ClientSecret != "" && security.TLS.ServerSecret == "" {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "security", "tls", "clientSecret"),
+			security.TLS.ClientSecret,
+			"spec.security.tls.serverSecret must be filled to authenticate clients by certificates"),
+		)
+	}
+
+	if security.TLS.ClientCRLSecret != "" && security.TLS.ClientTrustedCASecret == "" {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "security", "tls", "clientCRLSecret"),
+			security.TLS.ClientCRLSecret,
+			"spec.security.tls.clientTrustedCASecret must be filled to revoke client certificates"),
+		)
+	}
+
+	if security.CAPublication != nil {
+		allErrors = append(allErrors, validateCAPublication(security, field.NewPath("spec", "security", "caPublication"))...)
+	}
+
+	if security.TLS.CertManager != nil {
+		allErrors = append(allErrors,
+			validateCertManager(security.TLS.CertManager, field.NewPath("spec", "security", "tls", "certManager"))...)
+	}
+
+	if security.TLS.InternalCA != nil {
+		allErrors = append(allErrors, r.validateInternalCA(field.NewPath("spec", "security", "tls"))...)
+	}
+
+	if len(allErrors) > 0 {
+		return allErrors
+	}
+
+	return nil
+}
+
+// validateCertManager validates durations of certificates issued by cert-manager
+func validateCertManager(certManager *CertManagerSpec, path *field.Path) field.ErrorList {
+	var allErrors field.ErrorList
+	if certManager.Duration != nil && certManager.Duration.Duration < time.Hour {
+		allErrors = append(allErrors, field.Invalid(
+			path.Child("duration"),
+			certManager.Duration.Duration.String(),
+			"must be at least 1h"),
+		)
+	}
+	if certManager.RenewBefore != nil && certManager.RenewBefore.Duration <= 0 {
+		allErrors = append(allErrors, field.Invalid(
+			path.Child("renewBefore"),
+			certManager.RenewBefore.Duration.String(),
+			"must be positive"),
+		)
+	}
+	if certManager.Duration != nil && certManager.RenewBefore != nil &&
+		certManager.RenewBefore.Duration >= certManager.Duration.Duration {
+		allErrors = append(allErrors, field.Invalid(
+			path.Child("renewBefore"),
+			certManager.RenewBefore.Duration.String(),
+			"must be shorter than duration"),
+		)
+	}
+	return allErrors
+}
+
+// validateInternalCA rejects secrets of certificates issued by the internal CA named otherwise than the operator
+// names them, so secrets provided by users are not overwritten
+func (r *EtcdCluster) validateInternalCA(path *field.Path) field.ErrorList {
+	var allErrors field.ErrorList
+	tls := r.Spec.Security.TLS
+	if tls.CertManager != nil {
+		allErrors = append(allErrors, field.Forbidden(
+			path.Child("internalCA"),
+			"can not be set together with spec.security.tls.certManager"),
+		)
+	}
+	issued := []struct {
+		name, value, expected string
+	}{
+		{"serverSecret", tls.ServerSecret, r.Name + "-server-tls"},
+		{"peerSecret", tls.PeerSecret, r.Name + "-peer-tls"},
+		{"peerTrustedCASecret", tls.PeerTrustedCASecret, r.Name + "-peer-tls"},
+		{"clientSecret", tls.ClientSecret, r.Name + "-client-tls"},
+		{"clientTrustedCASecret", tls.ClientTrustedCASecret, r.Name + "-client-tls"},
+	}
+	for _, secret := range issued {
+		if secret.value != "" && secret.value != secret.expected {
+			allErrors = append(allErrors, field.Invalid(
+				path.Child(secret.name),
+				secret.value,
+				fmt.Sprintf("must be empty or %s, certificates of the internal CA are issued into it", secret.expected)),
+			)
+		}
+	}
+	internalCA := tls.InternalCA
+	validDuration := internalCA.Duration == nil || internalCA.Duration.Duration >= time.Hour
+	if !validDuration {
+		allErrors = append(allErrors, field.Invalid(
+			path.Child("internalCA", "duration"),
+			internalCA.Duration.Duration.String(),
+			"must be at least 1h"),
+		)
+	}
+	if validDuration && internalCA.RenewBefore != nil &&
+		(internalCA.RenewBefore.Duration <= 0 || internalCA.RenewBefore.Duration >= internalCA.GetDuration()) {
+		allErrors = append(allErrors, field.Invalid(
+			path.Child("internalCA", "renewBefore"),
+			internalCA.RenewBefore.Duration.String(),
+			"must be positive and shorter than duration"),
+		)
+	}
+	return allErrors
+}
+
+// validateCAPublication validates CA publication settings
+func validateCAPublication(security *SecuritySpec, path *field.Path) field.ErrorList {
+	var allErrors field.ErrorList
+	publication := security.CAPublication
+
+	if security.TLS.ServerSecret == "" {
+		allErrors = append(allErrors, field.Invalid(
+			path,
+			publication,
+			"spec.security.tls.serverSecret must be filled to publish CA certificate"),
+		)
+	}
+	if publication.ConfigMapName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(publication.ConfigMapName) {
+			allErrors = append(allErrors, field.Invalid(path.Child("configMapName"), publication.ConfigMapName, msg))
+		}
+	}
+	for i, namespace := range publication.Namespaces {
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			allErrors = append(allErrors, field.Invalid(path.Child("namespaces").Index(i), namespace, msg))
+		}
+	}
+	if publication.TrustManager != nil && publication.TrustManager.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(publication.TrustManager.NamespaceSelector); err != nil {
+			allErrors = append(allErrors, field.Invalid(
+				path.Child("trustManager", "namespaceSelector"),
+				publication.TrustManager.NamespaceSelector,
+				err.Error()),
+			)
+		}
+	}
+
+	return allErrors
+}
+
+// validatePlatform rejects node selectors of the pod template requiring nodes the etcd image does not run on
+func (r *EtcdCluster) validatePlatform() field.ErrorList {
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "podTemplate", "spec", "nodeSelector")
+	nodeSelector := r.Spec.PodTemplate.Spec.NodeSelector
+	if os, found := nodeSelector[corev1.LabelOSStable]; found && os != "linux" {
+		allErrors = append(allErrors, field.Invalid(path.Key(corev1.LabelOSStable), os, "etcd runs on linux nodes only"))
+	}
+	if arch, found := nodeSelector[corev1.LabelArchStable]; found && !slices.Contains(r.GetArchitectures(), arch) {
+		allErrors = append(allErrors, field.Invalid(path.Key(corev1.LabelArchStable), arch,
+			fmt.Sprintf("etcd image is built for %s, see spec.podTemplate.architectures", strings.Join(r.GetArchitectures(), ", "))))
+	}
+	return allErrors
+}
+
+// validateDNS validates dnsPolicy and dnsConfig of the pod template
+func (r *EtcdCluster) validateDNS() field.ErrorList {
+	var allErrors field.ErrorList
+	podSpec := r.Spec.PodTemplate.Spec
+
+	if podSpec.DNSPolicy == corev1.DNSNone && (podSpec.DNSConfig == nil || len(podSpec.DNSConfig.Nameservers) == 0) {
+		allErrors = append(allErrors, field.Required(
+			field.NewPath("spec", "podTemplate", "spec", "dnsConfig", "nameservers"),
+			"at least one nameserver must be provided when dnsPolicy is None"),
+		)
+	}
+
+	if podSpec.DNSConfig != nil {
+		for i, option := range podSpec.DNSConfig.Options {
+			if option.Name != "ndots" {
+				continue
+			}
+			ndots := -1
+			if option.Value != nil {
+				if value, err := strconv.Atoi(*option.Value); err == nil {
+					ndots = value
+				}
+			}
+			if ndots < 0 || ndots > maxDNSNdots {
+				allErrors = append(allErrors, field.Invalid(
+					field.NewPath("spec", "podTemplate", "spec", "dnsConfig", "options").Index(i).Child("value"),
+					option.Value,
+					fmt.Sprintf("ndots must be an integer between 0 and %d", maxDNSNdots)),
+				)
+			}
+		}
+	}
+
 	if len(allErrors) > 0 {
 		return allErrors
 	}
@@ -288,6 +855,853 @@ func (r *EtcdCluster) validateSecurity() field.ErrorList {
 	return nil
 }
 
+// validateServiceTemplate validates traffic settings of the client service. Settings the API server would reject
+// are caught here, as otherwise they only surface as reconciliation errors.
+func (r *EtcdCluster) validateServiceTemplate() field.ErrorList {
+	if r.Spec.ServiceTemplate == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	spec := r.Spec.ServiceTemplate.Spec
+	path := field.NewPath("spec", "serviceTemplate", "spec")
+
+	switch spec.SessionAffinity {
+	case "", corev1.ServiceAffinityNone, corev1.ServiceAffinityClientIP:
+	default:
+		allErrors = append(allErrors, field.NotSupported(path.Child("sessionAffinity"), spec.SessionAffinity,
+			[]string{string(corev1.ServiceAffinityNone), string(corev1.ServiceAffinityClientIP)}))
+	}
+	if spec.SessionAffinityConfig != nil && spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		allErrors = append(allErrors, field.Forbidden(path.Child("sessionAffinityConfig"),
+			"sessionAffinityConfig requires ClientIP session affinity"))
+	}
+	if spec.InternalTrafficPolicy != nil {
+		switch *spec.InternalTrafficPolicy {
+		case corev1.ServiceInternalTrafficPolicyCluster, corev1.ServiceInternalTrafficPolicyLocal:
+		default:
+			allErrors = append(allErrors, field.NotSupported(path.Child("internalTrafficPolicy"), *spec.InternalTrafficPolicy,
+				[]string{string(corev1.ServiceInternalTrafficPolicyCluster), string(corev1.ServiceInternalTrafficPolicyLocal)}))
+		}
+	}
+
+	if len(allErrors) > 0 {
+		return allErrors
+	}
+	return nil
+}
+
+// clusterSelectorLabels are labels the operator selects members of a cluster by, their values are set by the operator.
+var clusterSelectorLabels = []string{"app.kubernetes.io/name", "app.kubernetes.io/instance", "app.kubernetes.io/managed-by"}
+
+// validateIsolation warns about templates setting labels members of the cluster are selected by, as they are
+// replaced by the operator, and about names of clusters colliding with names of objects of other clusters
+func (r *EtcdCluster) validateIsolation() admission.Warnings {
+	var warnings admission.Warnings
+	type templateLabels struct {
+		path   string
+		labels map[string]string
+	}
+	templates := []templateLabels{{"spec.podTemplate.metadata.labels", r.Spec.PodTemplate.Labels}}
+	if r.Spec.ServiceTemplate != nil {
+		templates = append(templates,
+			templateLabels{"spec.serviceTemplate.metadata.labels", r.Spec.ServiceTemplate.Labels},
+			templateLabels{"spec.serviceTemplate.spec.selector", r.Spec.ServiceTemplate.Spec.Selector})
+	}
+	if r.Spec.HeadlessServiceTemplate != nil {
+		templates = append(templates,
+			templateLabels{"spec.headlessServiceTemplate.metadata.labels", r.Spec.HeadlessServiceTemplate.Labels})
+	}
+	for _, template := range templates {
+		for _, key := range clusterSelectorLabels {
+			if _, ok := template.labels[key]; ok {
+				warnings = append(warnings, fmt.Sprintf("%s[%s] is replaced by the operator, so the cluster can not "+
+					"select members of other clusters", template.path, key))
+			}
+		}
+	}
+	if name, ok := strings.CutSuffix(r.Name, "-headless"); ok && name != "" {
+		warnings = append(warnings, fmt.Sprintf("client Service of the cluster collides with headless Service "+
+			"of cluster %s, only the cluster created first can be reconciled in the namespace", name))
+	}
+	return warnings
+}
+
+// validateOptionsFlagSet checks names and values of spec.options against the flag set of etcd version used by the cluster
+func (r *EtcdCluster) validateOptionsFlagSet() (admission.Warnings, field.ErrorList) {
+	if len(r.Spec.Options) == 0 {
+		return nil, nil
+	}
+
+	image := r.EtcdImage()
+	version, ok := etcdMinorVersion(image)
+	if !ok {
+		return admission.Warnings{fmt.Sprintf("cannot determine etcd version of image %s, spec.options are not validated", image)}, nil
+	}
+	flags, ok := etcdFlagSets[version]
+	if !ok {
+		return admission.Warnings{fmt.Sprintf("etcd version %s is not known to the operator, spec.options are not validated", version)}, nil
+	}
+
+	var warnings admission.Warnings
+	var allErrors field.ErrorList
+	names := make([]string, 0, len(r.Spec.Options))
+	for name := range r.Spec.Options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := r.Spec.Options[name]
+		if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+			// reported by validateOptions
+			continue
+		}
+		flag, exists := flags[name]
+		if !exists {
+			msg := fmt.Sprintf("unknown flag for etcd %s", version)
+			if closest, found := closestEtcdFlag(flags, name); found {
+				msg = fmt.Sprintf("%s, did you mean %q?", msg, closest)
+			}
+			allErrors = append(allErrors, field.Invalid(field.NewPath("spec", "options").Key(name), value, msg))
+			continue
+		}
+		if err := validateEtcdFlag(flag, value); err != nil {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(name),
+				value,
+				fmt.Sprintf("invalid value for etcd %s: %s", version, err.Error())),
+			)
+			continue
+		}
+		if replacement, deprecated := deprecatedEtcdFlagReplacement(version, name); deprecated {
+			warnings = append(warnings, fmt.Sprintf("spec.options[%s] is deprecated in etcd %s, it is passed as %s", name, version, replacement))
+		}
+	}
+
+	if len(allErrors) > 0 {
+		return nil, allErrors
+	}
+
+	return warnings, nil
+}
+
+// validateExperimentalOptions admits spec.experimentalOptions only if they are enabled in the operator.
+// Names are validated against the flag set of the etcd version with the experimental- prefix added.
+func (r *EtcdCluster) validateExperimentalOptions(enabled bool) (admission.Warnings, field.ErrorList) {
+	if len(r.Spec.ExperimentalOptions) == 0 {
+		return nil, nil
+	}
+	path := field.NewPath("spec", "experimentalOptions")
+	if !enabled {
+		return nil, field.ErrorList{field.Forbidden(path,
+			"experimental options are disabled in the operator, enable them with --enable-experimental-options")}
+	}
+
+	warnings := admission.Warnings{
+		"spec.experimentalOptions enable experimental etcd features, which may change or be removed without notice",
+	}
+	version, known := etcdMinorVersion(r.EtcdImage())
+	flags := etcdFlagSets[version]
+	var allErrors field.ErrorList
+	names := make([]string, 0, len(r.Spec.ExperimentalOptions))
+	for name := range r.Spec.ExperimentalOptions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := r.Spec.ExperimentalOptions[name]
+		flagName := experimentalFlagPrefix + name
+		if strings.HasPrefix(name, experimentalFlagPrefix) {
+			allErrors = append(allErrors, field.Invalid(path.Key(name), value,
+				fmt.Sprintf("the %s prefix is added by the operator, use %q", experimentalFlagPrefix,
+					strings.TrimPrefix(name, experimentalFlagPrefix))))
+			continue
+		}
+		if _, duplicate := r.Spec.Options[flagName]; duplicate {
+			allErrors = append(allErrors, field.Duplicate(path.Key(name), flagName))
+			continue
+		}
+		if !known || flags == nil {
+			// reported by validateOptionsFlagSet if spec.options are set
+			continue
+		}
+		flag, exists := flags[flagName]
+		if !exists {
+			allErrors = append(allErrors, field.Invalid(path.Key(name), value,
+				fmt.Sprintf("unknown experimental flag for etcd %s", version)))
+			continue
+		}
+		if err := validateEtcdFlag(flag, value); err != nil {
+			allErrors = append(allErrors, field.Invalid(path.Key(name), value,
+				fmt.Sprintf("invalid value for etcd %s: %s", version, err.Error())))
+			continue
+		}
+		if replacement, deprecated := deprecatedEtcdFlagReplacement(version, flagName); deprecated {
+			warnings = append(warnings, fmt.Sprintf(
+				"spec.experimentalOptions[%s] is deprecated in etcd %s, it is passed as %s", name, version, replacement))
+		}
+	}
+	return warnings, allErrors
+}
+
+// validateImageDigests checks digests of images pinned in spec.podTemplate, so malformed references
+// are rejected instead of leaving member pods in ErrImagePull
+func (r *EtcdCluster) validateImageDigests() field.ErrorList {
+	var allErrors field.ErrorList
+	check := func(path *field.Path, containers []corev1.Container) {
+		for i, c := range containers {
+			_, digest, pinned := strings.Cut(c.Image, "@")
+			if pinned && !imageDigestRe.MatchString(digest) {
+				allErrors = append(allErrors, field.Invalid(path.Index(i).Child("image"), c.Image,
+					"digest must be sha256 or sha512 followed by the lowercase hex encoded hash"))
+			}
+		}
+	}
+	spec := field.NewPath("spec", "podTemplate", "spec")
+	check(spec.Child("initContainers"), r.Spec.PodTemplate.Spec.InitContainers)
+	check(spec.Child("containers"), r.Spec.PodTemplate.Spec.Containers)
+	return allErrors
+}
+
+// validateCommandPrefix forbids empty prefix entries and prefix of etcd container whose command is overridden
+func (r *EtcdCluster) validateCommandPrefix() field.ErrorList {
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "podTemplate", "commandPrefix")
+	for i, arg := range r.Spec.PodTemplate.CommandPrefix {
+		if arg == "" {
+			allErrors = append(allErrors, field.Invalid(path.Index(i), arg, "must not be empty"))
+		}
+	}
+	if len(r.Spec.PodTemplate.CommandPrefix) == 0 {
+		return allErrors
+	}
+	for i, c := range r.Spec.PodTemplate.Spec.Containers {
+		if c.Name == "etcd" && len(c.Command) > 0 {
+			allErrors = append(allErrors, field.Forbidden(
+				field.NewPath("spec", "podTemplate", "spec", "containers").Index(i).Child("command"),
+				"etcd command can not be overridden together with spec.podTemplate.commandPrefix"))
+		}
+	}
+	return allErrors
+}
+
+// validateAvailabilityPolicy requires minHealthyMembers to be at least a quorum, so Ready is never reported
+// for a cluster unable to serve requests
+func (r *EtcdCluster) validateAvailabilityPolicy() field.ErrorList {
+	if r.Spec.AvailabilityPolicy == nil || r.Spec.AvailabilityPolicy.MinHealthyMembers == nil || r.Spec.Replicas == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "availabilityPolicy", "minHealthyMembers")
+	value := r.Spec.AvailabilityPolicy.MinHealthyMembers
+	replicas := int(*r.Spec.Replicas)
+	minHealthy, err := intstr.GetScaledValueFromIntOrPercent(value, replicas, true)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, value.String(), "must be an integer or a percentage, e.g. 51%")}
+	}
+	if value.Type == intstr.Int && minHealthy > replicas {
+		return field.ErrorList{field.Invalid(path, value.String(),
+			fmt.Sprintf("must not exceed the number of replicas (%d)", replicas))}
+	}
+	if replicas > 0 && minHealthy < r.CalculateQuorumSize() {
+		return field.ErrorList{field.Invalid(path, value.String(),
+			fmt.Sprintf("resolves to %d members, below the quorum of %d", minHealthy, r.CalculateQuorumSize()))}
+	}
+	return nil
+}
+
+// validateCordonedMembers requires the cordoned members annotation to list member ordinals
+func (r *EtcdCluster) validateCordonedMembers() field.ErrorList {
+	if _, err := r.CordonedMembers(); err != nil {
+		return field.ErrorList{field.Invalid(
+			field.NewPath("metadata", "annotations").Key(CordonedMembersAnnotation),
+			r.Annotations[CordonedMembersAnnotation],
+			err.Error(),
+		)}
+	}
+	return nil
+}
+
+func (r *EtcdCluster) validateDebugShell() field.ErrorList {
+	if _, err := r.DebugShellTTL(); err != nil {
+		return field.ErrorList{field.Invalid(
+			field.NewPath("metadata", "annotations").Key(DebugShellAnnotation),
+			r.Annotations[DebugShellAnnotation],
+			err.Error(),
+		)}
+	}
+	return nil
+}
+
+// validateStorage requires size limit of memory storage and warns about its data loss,
+// member volumes must be pinned to something and only with PVC storage
+func (r *EtcdCluster) validateStorage() (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	var allErrors field.ErrorList
+	membersPath := field.NewPath("spec", "storage", "members")
+	if len(r.Spec.Storage.Members) > 0 && !r.Spec.Storage.HasVolumeClaims() {
+		allErrors = append(allErrors, field.Forbidden(membersPath, "member volumes can only be pinned with volumeClaimTemplate storage"))
+	}
+	for i, member := range r.Spec.Storage.Members {
+		if member.VolumeName == "" && member.Selector == nil && member.NodeName == "" {
+			allErrors = append(allErrors, field.Required(membersPath.Index(i), "one of volumeName, selector or nodeName is required"))
+		}
+		if r.Spec.Replicas != nil && member.Ordinal >= *r.Spec.Replicas {
+			warnings = append(warnings, fmt.Sprintf("spec.storage.members[%d] has ordinal %d, but cluster has only %d replicas",
+				i, member.Ordinal, *r.Spec.Replicas))
+		}
+	}
+
+	allErrors = append(allErrors, r.validateVolumeClaimTemplate()...)
+	hostPathWarnings, hostPathErr := r.validateHostPath()
+	warnings = append(warnings, hostPathWarnings...)
+	allErrors = append(allErrors, hostPathErr...)
+	allErrors = append(allErrors, r.validateStorageEncryption()...)
+	allErrors = append(allErrors, r.validateWALVolume()...)
+	allErrors = append(allErrors, r.validateRetentionPolicy()...)
+	allErrors = append(allErrors, r.validateDataDir()...)
+	permissionsWarnings, permissionsErr := r.validateVolumePermissions()
+	warnings = append(warnings, permissionsWarnings...)
+	allErrors = append(allErrors, permissionsErr...)
+
+	if !r.Spec.Storage.IsMemory() {
+		return warnings, allErrors
+	}
+	if r.Spec.Storage.EmptyDir.SizeLimit == nil || r.Spec.Storage.EmptyDir.SizeLimit.IsZero() {
+		return warnings, append(allErrors, field.Required(
+			field.NewPath("spec", "storage", "emptyDir", "sizeLimit"),
+			"size limit is required for Memory medium, as data counts against memory of members",
+		))
+	}
+	return append(warnings,
+		"spec.storage.emptyDir.medium is Memory, cluster data is lost when member pods are deleted or their nodes restart",
+	), allErrors
+}
+
+// validateHostPath checks the node directory of hostPath storage and warns that data is bound to nodes
+func (r *EtcdCluster) validateHostPath() (admission.Warnings, field.ErrorList) {
+	if r.Spec.Storage.HostPath == nil {
+		return nil, nil
+	}
+	var allErrors field.ErrorList
+	storagePath := field.NewPath("spec", "storage")
+	if r.Spec.Storage.EmptyDir != nil {
+		allErrors = append(allErrors, field.Forbidden(storagePath.Child("hostPath"), "only one of emptyDir and hostPath can be set"))
+	}
+	if hostPath := r.GetHostPath(); !path.IsAbs(hostPath) || path.Clean(hostPath) != hostPath || hostPath == "/" {
+		allErrors = append(allErrors, field.Invalid(storagePath.Child("hostPath", "path"), r.Spec.Storage.HostPath.Path,
+			"must be a clean absolute path of a directory other than /"))
+	}
+	if r.Spec.Storage.WALVolume != nil {
+		allErrors = append(allErrors, field.Forbidden(storagePath.Child("walVolume"), "write-ahead log volume is not supported with hostPath storage"))
+	}
+	warnings := admission.Warnings{
+		"spec.storage.hostPath keeps cluster data on nodes, a member scheduled to another node starts without its data " +
+			"and data is not removed when the cluster is deleted; use it for single-node clusters without volume provisioners only",
+	}
+	if r.Spec.Replicas != nil && *r.Spec.Replicas > 1 && len(r.Spec.PodTemplate.Spec.NodeSelector) == 0 &&
+		(r.Spec.PodTemplate.Spec.Affinity == nil || r.Spec.PodTemplate.Spec.Affinity.NodeAffinity == nil) {
+		warnings = append(warnings, "members with spec.storage.hostPath are not pinned to nodes, "+
+			"set spec.podTemplate.spec.nodeSelector or node affinity so they are not scheduled to nodes without their data")
+	}
+	return warnings, allErrors
+}
+
+// validateVolumeClaimTemplate checks storage class, access modes and capacity of the data volume claim template
+func (r *EtcdCluster) validateVolumeClaimTemplate() field.ErrorList {
+	if !r.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "storage", "volumeClaimTemplate", "spec")
+	claimSpec := r.Spec.Storage.VolumeClaimTemplate.Spec
+
+	// empty storage class name requests a volume without class and is valid
+	if name := claimSpec.StorageClassName; name != nil && *name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(*name) {
+			allErrors = append(allErrors, field.Invalid(path.Child("storageClassName"), *name, msg))
+		}
+	}
+
+	// every member writes to its own volume, read-only volumes are useless for etcd
+	supportedAccessModes := []corev1.PersistentVolumeAccessMode{
+		corev1.ReadWriteOnce, corev1.ReadWriteOncePod, corev1.ReadWriteMany,
+	}
+	for i, mode := range claimSpec.AccessModes {
+		if !slices.Contains(supportedAccessModes, mode) {
+			allErrors = append(allErrors, field.NotSupported(path.Child("accessModes").Index(i), mode,
+				[]string{string(corev1.ReadWriteOnce), string(corev1.ReadWriteOncePod), string(corev1.ReadWriteMany)}))
+		}
+	}
+	if slices.Contains(claimSpec.AccessModes, corev1.ReadWriteOncePod) && len(claimSpec.AccessModes) > 1 {
+		allErrors = append(allErrors, field.Forbidden(path.Child("accessModes"),
+			"ReadWriteOncePod can not be combined with other access modes"))
+	}
+
+	requestsPath := path.Child("resources", "requests", "storage")
+	request, requested := claimSpec.Resources.Requests[corev1.ResourceStorage]
+	if requested && request.Sign() <= 0 {
+		allErrors = append(allErrors, field.Invalid(requestsPath, request.String(), "must be greater than zero"))
+	}
+	if limit, found := claimSpec.Resources.Limits[corev1.ResourceStorage]; found && requested && limit.Cmp(request) < 0 {
+		allErrors = append(allErrors, field.Invalid(path.Child("resources", "limits", "storage"), limit.String(),
+			fmt.Sprintf("must not be less than the requested %s", request.String())))
+	}
+	return allErrors
+}
+
+// storageShrinkProcedure describes how to move data to smaller volumes, which can't be shrunk in place.
+const storageShrinkProcedure = "storage can not be shrunk in place; to reduce it, save a snapshot with etcdctl snapshot save, " +
+	"create a new EtcdCluster with the smaller size, restore the snapshot into it and move clients to the new cluster"
+
+// validateStorageDecrease rejects decrease of the volume claim storage request and of the emptyDir size limit.
+// The storage request can not be decreased below the capacity already provisioned for volume claims of members either.
+func (r *EtcdCluster) validateStorageDecrease(oldCluster *EtcdCluster) field.ErrorList {
+	var allErrors field.ErrorList
+	oldClaim := oldCluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests
+	newClaim := r.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests
+	oldSize, oldFound := oldClaim[corev1.ResourceStorage]
+	newSize, newFound := newClaim[corev1.ResourceStorage]
+	if oldFound && newFound && newSize.Cmp(oldSize) < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "volumeClaimTemplate", "spec", "resources", "requests", "storage"),
+			newSize.String(),
+			fmt.Sprintf("can not be decreased from %s, %s", oldSize.String(), storageShrinkProcedure)))
+	} else if capacity := oldCluster.Status.StorageCapacity; newFound && capacity != nil && newSize.Cmp(*capacity) < 0 {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "storage", "volumeClaimTemplate", "spec", "resources", "requests", "storage"),
+			newSize.String(),
+			fmt.Sprintf("can not be decreased below %s provisioned for volume claims of members, %s",
+				capacity.String(), storageShrinkProcedure)))
+	}
+	if oldCluster.Spec.Storage.EmptyDir != nil && r.Spec.Storage.EmptyDir != nil {
+		oldLimit, newLimit := oldCluster.Spec.Storage.EmptyDir.SizeLimit, r.Spec.Storage.EmptyDir.SizeLimit
+		if oldLimit != nil && newLimit != nil && newLimit.Cmp(*oldLimit) < 0 {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "storage", "emptyDir", "sizeLimit"),
+				newLimit.String(),
+				fmt.Sprintf("can not be decreased from %s, %s", oldLimit.String(), storageShrinkProcedure)))
+		}
+	}
+	return allErrors
+}
+
+// validateStorageEncryption checks that encryption settings do not contradict the volume claim template
+func (r *EtcdCluster) validateStorageEncryption() field.ErrorList {
+	encryption := r.Spec.Storage.Encryption
+	if encryption == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "storage", "encryption")
+	if !r.Spec.Storage.HasVolumeClaims() {
+		return append(allErrors, field.Forbidden(path, "encryption requires volumeClaimTemplate storage"))
+	}
+	claimSpec := r.Spec.Storage.VolumeClaimTemplate.Spec
+	if encryption.StorageClassName != "" && claimSpec.StorageClassName != nil &&
+		*claimSpec.StorageClassName != encryption.StorageClassName {
+		allErrors = append(allErrors, field.Invalid(path.Child("storageClassName"), encryption.StorageClassName,
+			"conflicts with spec.storage.volumeClaimTemplate.spec.storageClassName"))
+	}
+	if dmCrypt := encryption.DMCrypt; dmCrypt != nil {
+		dmCryptPath := path.Child("dmCrypt")
+		if dmCrypt.Image == "" {
+			allErrors = append(allErrors, field.Required(dmCryptPath.Child("image"), "image with cryptsetup is required"))
+		}
+		if dmCrypt.KeySecret.Name == "" || dmCrypt.KeySecret.Key == "" {
+			allErrors = append(allErrors, field.Required(dmCryptPath.Child("keySecret"), "name and key of the passphrase are required"))
+		}
+		if claimSpec.VolumeMode != nil && *claimSpec.VolumeMode != corev1.PersistentVolumeBlock {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "storage", "volumeClaimTemplate", "spec", "volumeMode"),
+				*claimSpec.VolumeMode, "dmCrypt encryption requires Block volume mode"))
+		}
+	}
+	return allErrors
+}
+
+// validateDataDir checks that the data dir stays inside the data volume
+func (r *EtcdCluster) validateDataDir() field.ErrorList {
+	var allErrors field.ErrorList
+	storagePath := field.NewPath("spec", "storage")
+	storage := r.Spec.Storage
+	if storage.MountPath != "" && (!path.IsAbs(storage.MountPath) || path.Clean(storage.MountPath) == "/") {
+		allErrors = append(allErrors, field.Invalid(storagePath.Child("mountPath"), storage.MountPath,
+			"must be an absolute path other than /"))
+	}
+	if storage.SubPath != "" && !isLocalPath(storage.SubPath) {
+		allErrors = append(allErrors, field.Invalid(storagePath.Child("subPath"), storage.SubPath,
+			"must be a relative path inside the volume"))
+	}
+	if storage.SubPath != "" && storage.IsDMCryptEncrypted() {
+		allErrors = append(allErrors, field.Forbidden(storagePath.Child("subPath"), "is not supported with dmCrypt encryption"))
+	}
+	if storage.DataDir != "" && !isLocalPath(storage.DataDir) {
+		allErrors = append(allErrors, field.Invalid(storagePath.Child("dataDir"), storage.DataDir,
+			"must be a relative path inside the volume"))
+	}
+	return allErrors
+}
+
+// isLocalPath returns true if the relative path does not escape the directory it is relative to
+func isLocalPath(p string) bool {
+	return !path.IsAbs(p) && path.Clean(p) != ".." && !strings.HasPrefix(path.Clean(p), "../")
+}
+
+// validateRetentionPolicy checks retention policy types of member volume claims
+func (r *EtcdCluster) validateRetentionPolicy() field.ErrorList {
+	policy := r.Spec.Storage.RetentionPolicy
+	if policy == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "storage", "retentionPolicy")
+	if !r.Spec.Storage.HasVolumeClaims() {
+		allErrors = append(allErrors, field.Forbidden(path, "retention policy requires volumeClaimTemplate storage"))
+	}
+	supported := []appsv1.PersistentVolumeClaimRetentionPolicyType{
+		appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+		appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+	}
+	if policy.WhenDeleted != "" && !slices.Contains(supported, policy.WhenDeleted) {
+		allErrors = append(allErrors, field.NotSupported(path.Child("whenDeleted"), policy.WhenDeleted, supported))
+	}
+	if policy.WhenScaled != "" && !slices.Contains(supported, policy.WhenScaled) {
+		allErrors = append(allErrors, field.NotSupported(path.Child("whenScaled"), policy.WhenScaled, supported))
+	}
+	return allErrors
+}
+
+// validateWALVolume checks that the write-ahead log is not kept in emptyDir while data is persisted
+func (r *EtcdCluster) validateWALVolume() field.ErrorList {
+	wal := r.Spec.Storage.WALVolume
+	if wal == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "storage", "walVolume")
+	if wal.EmptyDir != nil && r.Spec.Storage.EmptyDir == nil {
+		allErrors = append(allErrors, field.Forbidden(path.Child("emptyDir"),
+			"write-ahead log can only be kept in emptyDir with emptyDir data storage, members can not recover data without it"))
+	}
+	if _, ok := r.Spec.Options["wal-dir"]; ok {
+		allErrors = append(allErrors, field.Forbidden(field.NewPath("spec", "options").Key("wal-dir"),
+			"wal-dir is set by spec.storage.walVolume"))
+	}
+	return allErrors
+}
+
+// validateEphemeralStorage requires explicit opt-in into emptyDir storage. Clusters which already kept data
+// in emptyDir without it can still be updated, so the operator does not get stuck updating them.
+func (r *EtcdCluster) validateEphemeralStorage(oldCluster *EtcdCluster) field.ErrorList {
+	if r.Spec.Storage.EmptyDir == nil || r.Spec.Storage.Ephemeral || r.IsStandalone() {
+		return nil
+	}
+	if oldCluster != nil && oldCluster.Spec.Storage.EmptyDir != nil && !oldCluster.Spec.Storage.Ephemeral {
+		return nil
+	}
+	return field.ErrorList{field.Required(field.NewPath("spec", "storage", "ephemeral"),
+		"emptyDir storage loses cluster data when member pods are deleted, set ephemeral to true to use it")}
+}
+
+// validateVolumePermissions forbids to set the etcd user in the pod template as well and warns about fsGroup
+// of storage ignoring it
+func (r *EtcdCluster) validateVolumePermissions() (admission.Warnings, field.ErrorList) {
+	permissions := r.Spec.Storage.VolumePermissions
+	if permissions == nil {
+		return nil, nil
+	}
+	var allErrors field.ErrorList
+	if securityContext := r.Spec.PodTemplate.Spec.SecurityContext; securityContext != nil &&
+		(securityContext.RunAsUser != nil || securityContext.RunAsGroup != nil || securityContext.FSGroup != nil) {
+		allErrors = append(allErrors, field.Forbidden(field.NewPath("spec", "podTemplate", "spec", "securityContext"),
+			"runAsUser, runAsGroup and fsGroup are set by spec.storage.volumePermissions"))
+	}
+	if permissions.GetMode() == VolumePermissionsFSGroup && r.Spec.Storage.HostPath != nil {
+		return admission.Warnings{"fsGroup is not applied to spec.storage.hostPath volumes, " +
+			"set spec.storage.volumePermissions.mode to InitContainer so etcd can write its data"}, allErrors
+	}
+	return nil, allErrors
+}
+
+// validateStorageMigration allows to replace emptyDir by volume claims only if the migration is requested
+// by annotation and the StatefulSet can be recreated with members restored from a snapshot
+func (r *EtcdCluster) validateStorageMigration() (admission.Warnings, field.ErrorList) {
+	if !r.IsStorageMigrationRequested() {
+		return nil, field.ErrorList{field.Invalid(
+			field.NewPath("spec", "storage", "emptyDir"),
+			r.Spec.Storage.EmptyDir,
+			fmt.Sprintf("field is immutable, unless the %s annotation is set to \"true\" to migrate data to volume claims",
+				StorageMigrationAnnotation)),
+		}
+	}
+	var allErrors field.ErrorList
+	if r.Spec.Storage.WALVolume != nil {
+		allErrors = append(allErrors, field.Forbidden(field.NewPath("spec", "storage", "walVolume"),
+			"can not be set while data is migrated from emptyDir, add it once the migration completes"))
+	}
+	if r.ManagesMemberPods() {
+		allErrors = append(allErrors, field.Forbidden(field.NewPath("spec", "memberManagement"),
+			"data can only be migrated from emptyDir with the StatefulSet member management"))
+	}
+	return admission.Warnings{
+		"data is migrated from emptyDir to volume claims: all members are recreated, the cluster is unavailable " +
+			"until the first member is restored from a snapshot and writes made after the snapshot are lost",
+	}, allErrors
+}
+
+// validateWALVolumeUpdate forbids removing the write-ahead log volume and changing its kind, as the log
+// of members can only be moved to a new volume, not back
+func (r *EtcdCluster) validateWALVolumeUpdate(oldWAL *WALVolumeSpec) field.ErrorList {
+	path := field.NewPath("spec", "storage", "walVolume")
+	if r.Spec.Storage.WALVolume == nil {
+		return field.ErrorList{field.Forbidden(path, "can not be removed, as members would lose their write-ahead log")}
+	}
+	if (oldWAL.EmptyDir == nil) != (r.Spec.Storage.WALVolume.EmptyDir == nil) {
+		return field.ErrorList{field.Invalid(path.Child("emptyDir"), r.Spec.Storage.WALVolume.EmptyDir, "field is immutable")}
+	}
+	return nil
+}
+
+// validateRaftSnapshots forbids setting the same etcd flag in spec.raftSnapshots and spec.options
+func (r *EtcdCluster) validateRaftSnapshots() field.ErrorList {
+	if r.Spec.RaftSnapshots == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "raftSnapshots")
+	fields := []struct {
+		name string
+		flag string
+		set  bool
+	}{
+		{name: "snapshotCount", flag: "snapshot-count", set: r.Spec.RaftSnapshots.SnapshotCount != nil},
+		{name: "maxSnapshots", flag: "max-snapshots", set: r.Spec.RaftSnapshots.MaxSnapshots != nil},
+		{name: "maxWALs", flag: "max-wals", set: r.Spec.RaftSnapshots.MaxWALs != nil},
+	}
+	for _, f := range fields {
+		if _, exists := r.Spec.Options[f.flag]; f.set && exists {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(f.flag),
+				r.Spec.Options[f.flag],
+				fmt.Sprintf("conflicts with %s", path.Child(f.name).String())),
+			)
+		}
+	}
+	return allErrors
+}
+
+// recommendedMaxRequestBytes is the largest request size recommended by etcd.
+const recommendedMaxRequestBytes = 10 * 1024 * 1024
+
+// validateRequestLimits checks the request size limit and forbids setting the same etcd flag
+// in spec.requestLimits and spec.options.
+func (r *EtcdCluster) validateRequestLimits() (admission.Warnings, field.ErrorList) {
+	if r.Spec.RequestLimits == nil {
+		return nil, nil
+	}
+	var warnings admission.Warnings
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "requestLimits")
+	if maxRequestBytes := r.Spec.RequestLimits.MaxRequestBytes; maxRequestBytes != nil {
+		value := maxRequestBytes.Value()
+		switch {
+		case value <= 0 || value > math.MaxInt32:
+			allErrors = append(allErrors, field.Invalid(path.Child("maxRequestBytes"), maxRequestBytes.String(),
+				"must be positive and less than 2Gi"))
+		case value > recommendedMaxRequestBytes:
+			warnings = append(warnings, fmt.Sprintf(
+				"%s exceeds 10Mi recommended by etcd, large requests delay other requests and raise memory usage of members",
+				path.Child("maxRequestBytes").String()))
+		}
+	}
+	fields := []struct {
+		name string
+		flag string
+		set  bool
+	}{
+		{name: "maxTxnOps", flag: "max-txn-ops", set: r.Spec.RequestLimits.MaxTxnOps != nil},
+		{name: "maxRequestBytes", flag: "max-request-bytes", set: r.Spec.RequestLimits.MaxRequestBytes != nil},
+	}
+	for _, f := range fields {
+		if _, exists := r.Spec.Options[f.flag]; f.set && exists {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(f.flag),
+				r.Spec.Options[f.flag],
+				fmt.Sprintf("conflicts with %s", path.Child(f.name).String())),
+			)
+		}
+	}
+	return warnings, allErrors
+}
+
+// validateAuth checks the TTL of JSON Web Tokens and forbids setting the auth-token flag in spec.options
+// together with spec.security.auth.jwt.
+func (r *EtcdCluster) validateAuth() (admission.Warnings, field.ErrorList) {
+	if !r.UsesJWT() {
+		return nil, nil
+	}
+	var warnings admission.Warnings
+	var allErrors field.ErrorList
+	path := field.NewPath("spec", "security", "auth", "jwt")
+	jwt := r.Spec.Security.Auth.JWT
+	if jwt.TTL != nil && jwt.TTL.Duration <= 0 {
+		allErrors = append(allErrors, field.Invalid(path.Child("ttl"), jwt.TTL.Duration.String(), "must be positive"))
+	}
+	if value, exists := r.Spec.Options["auth-token"]; exists {
+		allErrors = append(allErrors, field.Invalid(
+			field.NewPath("spec", "options").Key("auth-token"),
+			value,
+			fmt.Sprintf("conflicts with %s", path.String())),
+		)
+	}
+	if !r.Spec.Security.Auth.Enabled {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s has no effect until spec.security.auth.enabled is set, etcd issues tokens only with authentication enabled",
+			path.String()))
+	}
+	return warnings, allErrors
+}
+
+// tracingFlags lists etcd 3.5 names of flags passed for spec.tracing.
+var tracingFlags = []string{
+	"experimental-enable-distributed-tracing",
+	"experimental-distributed-tracing-address",
+	"experimental-distributed-tracing-instance-id",
+	"experimental-distributed-tracing-sampling-rate",
+	"experimental-distributed-tracing-service-name",
+}
+
+// validateMode limits standalone clusters to a single managed member and forbids to change the mode.
+func (r *EtcdCluster) validateMode(oldCluster *EtcdCluster) (admission.Warnings, field.ErrorList) {
+	path := field.NewPath("spec", "mode")
+	if oldCluster != nil && oldCluster.IsStandalone() != r.IsStandalone() {
+		return nil, field.ErrorList{field.Invalid(path, r.Spec.Mode, "field is immutable")}
+	}
+	if !r.IsStandalone() {
+		return nil, nil
+	}
+	var warnings admission.Warnings
+	var allErrors field.ErrorList
+	if r.IsObserved() {
+		allErrors = append(allErrors, field.Forbidden(path, "observed clusters can not be standalone"))
+	}
+	if r.Spec.Replicas != nil && *r.Spec.Replicas > 1 {
+		allErrors = append(allErrors, field.Invalid(field.NewPath("spec", "replicas"), *r.Spec.Replicas,
+			"standalone clusters run a single member"))
+	}
+	if r.Spec.PodDisruptionBudgetTemplate != nil {
+		warnings = append(warnings, "spec.podDisruptionBudgetTemplate is ignored, standalone clusters have no PodDisruptionBudget")
+	}
+	return warnings, allErrors
+}
+
+// validateManagementPolicy requires endpoints of observed clusters and rejects them for managed ones.
+func (r *EtcdCluster) validateManagementPolicy() field.ErrorList {
+	path := field.NewPath("spec", "observedEndpoints")
+	if !r.IsObserved() {
+		if len(r.Spec.ObservedEndpoints) > 0 {
+			return field.ErrorList{field.Forbidden(path, "observed endpoints are used only with the Observe management policy")}
+		}
+		return nil
+	}
+	if len(r.Spec.ObservedEndpoints) == 0 {
+		return field.ErrorList{field.Required(path, "observed clusters require client endpoints")}
+	}
+	return validateClientURLs(path, r.Spec.ObservedEndpoints)
+}
+
+// validateOperatorConnection requires endpoints in the Endpoints connection mode and rejects them in other modes.
+func (r *EtcdCluster) validateOperatorConnection() field.ErrorList {
+	if r.Spec.OperatorConnection == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "operatorConnection", "endpoints")
+	endpoints := r.Spec.OperatorConnection.Endpoints
+	if r.GetOperatorConnectionMode() != OperatorConnectionEndpoints {
+		if len(endpoints) > 0 {
+			return field.ErrorList{field.Forbidden(path, "endpoints are used only in the Endpoints mode")}
+		}
+		return nil
+	}
+	if len(endpoints) == 0 {
+		return field.ErrorList{field.Required(path, "the Endpoints mode requires client endpoints")}
+	}
+	return validateClientURLs(path, endpoints)
+}
+
+// discoveryFlags are etcd flags conflicting with the DiscoverySRV bootstrap mode.
+var discoveryFlags = []string{"initial-cluster", "discovery", "discovery-srv", "discovery-srv-name"}
+
+// validateBootstrap rejects options conflicting with the discovery flags passed by the operator.
+func (r *EtcdCluster) validateBootstrap() field.ErrorList {
+	if !r.UsesDiscoverySRV() {
+		return nil
+	}
+	var allErrors field.ErrorList
+	for _, name := range discoveryFlags {
+		if value, exists := r.Spec.Options[name]; exists {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(name),
+				value,
+				"conflicts with DiscoverySRV bootstrap mode"),
+			)
+		}
+	}
+	return allErrors
+}
+
+// validateClientURLs checks that endpoints are http or https URLs.
+func validateClientURLs(path *field.Path, endpoints []string) field.ErrorList {
+	var allErrors field.ErrorList
+	for i, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrors = append(allErrors, field.Invalid(path.Index(i), endpoint, "must be an http or https URL"))
+		}
+	}
+	return allErrors
+}
+
+// validateTracing rejects options conflicting with spec.tracing.
+func (r *EtcdCluster) validateTracing() field.ErrorList {
+	if r.Spec.Tracing == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	options := r.EtcdOptions()
+	for _, flag := range tracingFlags {
+		name := r.EtcdFlagName(flag)
+		if _, exists := options[name]; exists {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(name),
+				options[name],
+				"conflicts with spec.tracing"),
+			)
+		}
+	}
+	return allErrors
+}
+
+// logShipperFlags lists flags passed to etcd for spec.logging.shipper.
+var logShipperFlags = []string{"log-outputs", "enable-log-rotation", "log-rotation-config-json"}
+
+// validateLogging rejects options redirecting etcd logs away from the file tailed by the log shipper.
+func (r *EtcdCluster) validateLogging() field.ErrorList {
+	if r.Spec.Logging == nil || r.Spec.Logging.Shipper == nil {
+		return nil
+	}
+	var allErrors field.ErrorList
+	for _, name := range logShipperFlags {
+		if value, exists := r.Spec.Options[name]; exists {
+			allErrors = append(allErrors, field.Invalid(
+				field.NewPath("spec", "options").Key(name),
+				value,
+				"conflicts with spec.logging.shipper"),
+			)
+		}
+	}
+	return allErrors
+}
+
 func validateOptions(cluster *EtcdCluster) error {
 	if len(cluster.Spec.Options) == 0 {
 		return nil
@@ -17,14 +17,22 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 var _ = Describe("EtcdCluster Webhook", func() {
@@ -75,6 +83,98 @@ var _ = Describe("EtcdCluster Webhook", func() {
 				Expect(*storage).To(Equal(resource.MustParse("10Gi")))
 			}
 		})
+
+		It("Should default standalone clusters to a single ephemeral member", func() {
+			etcdCluster := &EtcdCluster{Spec: EtcdClusterSpec{Mode: ClusterModeStandalone, Replicas: ptr.To(int32(3))}}
+			etcdCluster.Default()
+			Expect(*etcdCluster.Spec.Replicas).To(Equal(int32(1)))
+			Expect(etcdCluster.Spec.Storage.EmptyDir).NotTo(BeNil())
+			Expect(etcdCluster.Spec.Storage.Ephemeral).To(BeTrue())
+
+			etcdCluster = &EtcdCluster{Spec: EtcdClusterSpec{
+				Mode:     ClusterModeStandalone,
+				Replicas: ptr.To(int32(0)),
+				Storage:  StorageSpec{VolumeClaimTemplate: EmbeddedPersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: ptr.To("local-path")}}},
+			}}
+			etcdCluster.Default()
+			Expect(*etcdCluster.Spec.Replicas).To(Equal(int32(0)))
+			Expect(etcdCluster.Spec.Storage.EmptyDir).To(BeNil())
+		})
+
+		It("Should name secrets of certificates issued by cert-manager", func() {
+			etcdCluster := &EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: EtcdClusterSpec{
+					Security: &SecuritySpec{TLS: TLSSpec{
+						PeerSecret:  "custom-peer",
+						CertManager: &CertManagerSpec{IssuerRef: CertManagerIssuerRef{Name: "ca"}},
+					}},
+				},
+			}
+			etcdCluster.Default()
+			Expect(etcdCluster.Spec.Security.TLS).To(Equal(TLSSpec{
+				ServerSecret:          "test-server-tls",
+				PeerSecret:            "custom-peer",
+				PeerTrustedCASecret:   "custom-peer",
+				ClientSecret:          "test-client-tls",
+				ClientTrustedCASecret: "test-client-tls",
+				CertManager:           &CertManagerSpec{IssuerRef: CertManagerIssuerRef{Name: "ca"}},
+			}))
+		})
+	})
+
+	Context("When defaulting storage from namespace annotations", func() {
+		annotations := map[string]string{
+			DefaultStorageClassAnnotation: "fast-ssd",
+			DefaultStorageSizeAnnotation:  "20Gi",
+		}
+
+		It("Should fill in storage class and size of the namespace", func() {
+			etcdCluster := &EtcdCluster{}
+			Expect(etcdCluster.ApplyNamespaceDefaults(annotations)).To(Succeed())
+			claimSpec := etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec
+			Expect(claimSpec.StorageClassName).To(Equal(ptr.To("fast-ssd")))
+			Expect(*claimSpec.Resources.Requests.Storage()).To(Equal(resource.MustParse("20Gi")))
+		})
+
+		It("Should keep storage set in the spec", func() {
+			etcdCluster := &EtcdCluster{}
+			etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec.StorageClassName = ptr.To("local-path")
+			etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests = corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("10Gi"),
+			}
+			Expect(etcdCluster.ApplyNamespaceDefaults(annotations)).To(Succeed())
+			claimSpec := etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec
+			Expect(claimSpec.StorageClassName).To(Equal(ptr.To("local-path")))
+			Expect(*claimSpec.Resources.Requests.Storage()).To(Equal(resource.MustParse("10Gi")))
+		})
+
+		It("Should reject invalid size of the namespace", func() {
+			etcdCluster := &EtcdCluster{}
+			Expect(etcdCluster.ApplyNamespaceDefaults(map[string]string{DefaultStorageSizeAnnotation: "lots"})).
+				To(MatchError(ContainSubstring(DefaultStorageSizeAnnotation)))
+		})
+
+		It("Should apply namespace defaults on creation only", func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-", Annotations: annotations}}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+			defaulter := &namespaceDefaulter{client: k8sClient}
+			request := func(operation admissionv1.Operation) context.Context {
+				return admission.NewContextWithRequest(ctx, admission.Request{
+					AdmissionRequest: admissionv1.AdmissionRequest{Operation: operation},
+				})
+			}
+
+			etcdCluster := &EtcdCluster{ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name}}
+			Expect(defaulter.Default(request(admissionv1.Update), etcdCluster)).To(Succeed())
+			Expect(etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec.StorageClassName).To(BeNil())
+
+			etcdCluster = &EtcdCluster{ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name}}
+			Expect(defaulter.Default(request(admissionv1.Create), etcdCluster)).To(Succeed())
+			Expect(etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec.StorageClassName).To(Equal(ptr.To("fast-ssd")))
+			Expect(etcdCluster.Spec.Storage.VolumeClaimTemplate.Spec.AccessModes).NotTo(BeEmpty())
+		})
 	})
 
 	Context("When creating EtcdCluster under Validating Webhook", func() {
@@ -84,10 +184,58 @@ var _ = Describe("EtcdCluster Webhook", func() {
 					Replicas: ptr.To(int32(1)),
 				},
 			}
-			w, err := etcdCluster.ValidateCreate()
+			w, err := etcdCluster.validateCreate(false)
 			Expect(err).To(Succeed())
 			Expect(w).To(BeEmpty())
 		})
+
+		It("Should require opt-in into emptyDir storage", func() {
+			etcdCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+			}
+			_, err := etcdCluster.validateCreate(false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring("spec.storage.ephemeral: Required value"))
+			}
+			// clusters created before the opt-in can still be updated
+			Expect(etcdCluster.validateEphemeralStorage(etcdCluster.DeepCopy())).To(BeEmpty())
+
+			etcdCluster.Spec.Storage.Ephemeral = true
+			_, err = etcdCluster.validateCreate(false)
+			Expect(err).To(Succeed())
+		})
+	})
+
+	Context("When validating isolation of clusters in the namespace", func() {
+		It("Should warn about templates setting selector labels", func() {
+			etcdCluster := &EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "etcd"},
+				Spec: EtcdClusterSpec{
+					PodTemplate: PodTemplate{
+						EmbeddedObjectMetadata: EmbeddedObjectMetadata{
+							Labels: map[string]string{"app.kubernetes.io/instance": "etcd-2", "tier": "etcd"},
+						},
+					},
+					ServiceTemplate: &EmbeddedService{
+						Spec: corev1.ServiceSpec{Selector: map[string]string{"app.kubernetes.io/name": "etcd"}},
+					},
+				},
+			}
+			Expect(etcdCluster.validateIsolation()).To(ConsistOf(
+				ContainSubstring("spec.podTemplate.metadata.labels[app.kubernetes.io/instance]"),
+				ContainSubstring("spec.serviceTemplate.spec.selector[app.kubernetes.io/name]"),
+			))
+		})
+		It("Should warn about names colliding with headless services", func() {
+			etcdCluster := &EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "etcd-headless"}}
+			Expect(etcdCluster.validateIsolation()).To(ConsistOf(ContainSubstring("headless Service of cluster etcd")))
+			etcdCluster.Name = "etcd-2"
+			Expect(etcdCluster.validateIsolation()).To(BeEmpty())
+		})
 	})
 
 	Context("When updating EtcdCluster under Validating Webhook", func() {
@@ -104,29 +252,169 @@ var _ = Describe("EtcdCluster Webhook", func() {
 					Storage:  StorageSpec{EmptyDir: nil},
 				},
 			}
-			_, err := etcdCluster.ValidateUpdate(oldCluster)
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
 			if Expect(err).To(HaveOccurred()) {
 				statusErr := err.(*errors.StatusError)
 				Expect(statusErr.ErrStatus.Message).To(ContainSubstring("field is immutable"))
 			}
 		})
 
-		It("Should allow changing emptydir size", func() {
+		It("Should allow migrating emptyDir to volume claims only if requested", func() {
+			etcdCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+				},
+			}
+			oldCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+			}
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring(
+					"field is immutable, unless the etcd.aenix.io/migrate-storage annotation is set"))
+			}
+
+			etcdCluster.Annotations = map[string]string{StorageMigrationAnnotation: "true"}
+			warnings, err := etcdCluster.validateUpdate(oldCluster, false)
+			Expect(err).To(Succeed())
+			Expect(warnings).To(ContainElement(ContainSubstring("writes made after the snapshot are lost")))
+
+			etcdCluster.Spec.Storage.WALVolume = &WALVolumeSpec{}
+			_, err = etcdCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring("spec.storage.walVolume: Forbidden"))
+			}
+		})
+
+		It("Should reject changing member management mode", func() {
 			etcdCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas:         ptr.To(int32(1)),
+					MemberManagement: MemberManagementPods,
+				},
+			}
+			oldCluster := &EtcdCluster{
 				Spec: EtcdClusterSpec{
 					Replicas: ptr.To(int32(1)),
-					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(resource.MustParse("4Gi"))}},
+				},
+			}
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring("spec.memberManagement: Invalid value"))
+			}
+		})
+
+		It("Should reject changing data dir, but not its mount path", func() {
+			etcdCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{MountPath: "/var/lib/etcd", DataDir: "data"},
 				},
 			}
 			oldCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{DataDir: "default.etcd"},
+				},
+			}
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring("spec.storage.dataDir: Invalid value"))
+				Expect(statusErr.ErrStatus.Message).NotTo(ContainSubstring("mountPath"))
+			}
+
+			etcdCluster.Spec.Storage.DataDir = ""
+			_, err = etcdCluster.validateUpdate(oldCluster, false)
+			Expect(err).To(Succeed())
+		})
+
+		It("Should allow increasing emptydir size", func() {
+			etcdCluster := &EtcdCluster{
 				Spec: EtcdClusterSpec{
 					Replicas: ptr.To(int32(1)),
 					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(resource.MustParse("10Gi"))}},
 				},
 			}
-			_, err := etcdCluster.ValidateUpdate(oldCluster)
+			oldCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(resource.MustParse("4Gi"))}},
+				},
+			}
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
 			Expect(err).To(Succeed())
 		})
+
+		It("Should reject decreasing emptydir size", func() {
+			etcdCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(resource.MustParse("4Gi"))}},
+				},
+			}
+			oldCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(resource.MustParse("10Gi"))}},
+				},
+			}
+			_, err := etcdCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				statusErr := err.(*errors.StatusError)
+				Expect(statusErr.ErrStatus.Message).To(ContainSubstring(
+					"spec.storage.emptyDir.sizeLimit: Invalid value: \"4Gi\": can not be decreased from 10Gi"))
+			}
+		})
+
+		It("Should reject decreasing volume claim size", func() {
+			claim := func(size string) StorageSpec {
+				return StorageSpec{VolumeClaimTemplate: EmbeddedPersistentVolumeClaim{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+						},
+					},
+				}}
+			}
+			etcdCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(1)), Storage: claim("5Gi")}}
+			oldCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(1)), Storage: claim("8Gi")}}
+			errs := etcdCluster.validateStorageDecrease(oldCluster)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("spec.storage.volumeClaimTemplate.spec.resources.requests.storage"))
+			Expect(errs[0].Detail).To(ContainSubstring("restore the snapshot into it"))
+
+			Expect(oldCluster.validateStorageDecrease(etcdCluster)).To(BeEmpty())
+		})
+
+		It("Should reject volume claim size below the provisioned capacity", func() {
+			claim := func(size string) StorageSpec {
+				return StorageSpec{VolumeClaimTemplate: EmbeddedPersistentVolumeClaim{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+						},
+					},
+				}}
+			}
+			oldCluster := &EtcdCluster{
+				Spec:   EtcdClusterSpec{Replicas: ptr.To(int32(1)), Storage: claim("4Gi")},
+				Status: EtcdClusterStatus{StorageCapacity: ptr.To(resource.MustParse("10Gi"))},
+			}
+			etcdCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(1)), Storage: claim("8Gi")}}
+			errs := etcdCluster.validateStorageDecrease(oldCluster)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Detail).To(ContainSubstring("can not be decreased below 10Gi provisioned"))
+
+			etcdCluster.Spec.Storage = claim("10Gi")
+			Expect(etcdCluster.validateStorageDecrease(oldCluster)).To(BeEmpty())
+		})
 	})
 
 	Context("Validate Security", func() {
@@ -178,6 +466,25 @@ var _ = Describe("EtcdCluster Webhook", func() {
 			}
 		})
 
+		It("Should reject client certificates without server certificate", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.TLS = TLSSpec{
+				ClientSecret:          "test-client-cert",
+				ClientTrustedCASecret: "test-client-ca-cert",
+			}
+			err := localCluster.validateSecurity()
+			if Expect(err).NotTo(BeNil()) {
+				expectedFieldErr := field.Invalid(
+					field.NewPath("spec", "security", "tls", "clientSecret"),
+					"test-client-cert",
+					"spec.security.tls.serverSecret must be filled to authenticate clients by certificates",
+				)
+				if Expect(err).To(HaveLen(1)) {
+					Expect(*(err[0])).To(Equal(*expectedFieldErr))
+				}
+			}
+		})
+
 		It("Should reject if only one client secret is defined", func() {
 			localCluster := etcdCluster.DeepCopy()
 			localCluster.Spec.Security.TLS = TLSSpec{
@@ -213,6 +520,118 @@ var _ = Describe("EtcdCluster Webhook", func() {
 				}
 			}
 		})
+
+		It("Should reject client CRL without client CA", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.TLS = TLSSpec{
+				ClientCRLSecret: "test-client-crl",
+			}
+			err := localCluster.validateSecurity()
+			if Expect(err).NotTo(BeNil()) {
+				expectedFieldErr := field.Invalid(
+					field.NewPath("spec", "security", "tls", "clientCRLSecret"),
+					"test-client-crl",
+					"spec.security.tls.clientTrustedCASecret must be filled to revoke client certificates",
+				)
+				if Expect(err).To(HaveLen(1)) {
+					Expect(*(err[0])).To(Equal(*expectedFieldErr))
+				}
+			}
+		})
+
+		It("Should reject CA publication without server secret", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.CAPublication = &CAPublicationSpec{}
+			err := localCluster.validateSecurity()
+			if Expect(err).NotTo(BeNil()) {
+				expectedFieldErr := field.Invalid(
+					field.NewPath("spec", "security", "caPublication"),
+					localCluster.Spec.Security.CAPublication,
+					"spec.security.tls.serverSecret must be filled to publish CA certificate",
+				)
+				if Expect(err).To(HaveLen(1)) {
+					Expect(*(err[0])).To(Equal(*expectedFieldErr))
+				}
+			}
+		})
+
+		It("Should reject CA publication into invalid namespace", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.TLS = TLSSpec{ServerSecret: "test-server-cert"}
+			localCluster.Spec.Security.CAPublication = &CAPublicationSpec{
+				Namespaces: []string{"clients", "Invalid_Namespace"},
+			}
+			err := localCluster.validateSecurity()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.security.caPublication.namespaces[1]"))
+			}
+		})
+
+		It("Should admit CA publication with server secret", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.TLS = TLSSpec{ServerSecret: "test-server-cert"}
+			localCluster.Spec.Security.CAPublication = &CAPublicationSpec{
+				Namespaces:   []string{"clients"},
+				TrustManager: &TrustManagerSpec{TrustNamespace: "cert-manager"},
+			}
+			err := localCluster.validateSecurity()
+			Expect(err).To(BeNil())
+		})
+
+		It("Should reject secrets of the internal CA named otherwise", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Name = "test"
+			localCluster.Spec.Security.TLS = TLSSpec{
+				ServerSecret: "test-server-tls",
+				PeerSecret:   "custom-peer",
+				InternalCA:   &InternalCASpec{},
+			}
+			err := localCluster.validateSecurity()
+			// peer secrets must be filled together
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[1].Field).To(Equal("spec.security.tls.peerSecret"))
+			}
+
+			localCluster.Spec.Security.TLS.PeerSecret = ""
+			localCluster.Default()
+			Expect(localCluster.Spec.Security.TLS.PeerTrustedCASecret).To(Equal("test-peer-tls"))
+			Expect(localCluster.Spec.Security.TLS.ClientTrustedCASecret).To(Equal("test-client-tls"))
+			Expect(localCluster.validateSecurity()).To(BeNil())
+
+			localCluster.Spec.Security.TLS.CertManager = &CertManagerSpec{IssuerRef: CertManagerIssuerRef{Name: "ca"}}
+			err = localCluster.validateSecurity()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+
+		It("Should reject enabling the internal CA of existing cluster", func() {
+			oldCluster := etcdCluster.DeepCopy()
+			oldCluster.Name = "test"
+			localCluster := oldCluster.DeepCopy()
+			localCluster.Spec.Security.TLS.InternalCA = &InternalCASpec{}
+			localCluster.Default()
+			_, err := localCluster.validateUpdate(oldCluster, false)
+			if Expect(err).To(HaveOccurred()) {
+				Expect(err.Error()).To(ContainSubstring("spec.security.tls.internalCA: Forbidden: can only be set when the cluster is created"))
+			}
+		})
+
+		It("Should reject cert-manager renewal not shorter than the certificate duration", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.TLS = TLSSpec{CertManager: &CertManagerSpec{
+				IssuerRef:   CertManagerIssuerRef{Name: "ca"},
+				Duration:    &metav1.Duration{Duration: 24 * time.Hour},
+				RenewBefore: &metav1.Duration{Duration: 24 * time.Hour},
+			}}
+			err := localCluster.validateSecurity()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.security.tls.certManager.renewBefore"))
+			}
+
+			localCluster.Spec.Security.TLS.CertManager.RenewBefore.Duration = 8 * time.Hour
+			Expect(localCluster.validateSecurity()).To(BeNil())
+		})
 	})
 
 	Context("Validate PDB", func() {
@@ -333,4 +752,810 @@ var _ = Describe("EtcdCluster Webhook", func() {
 			Expect(err).To(BeNil())
 		})
 	})
+
+	Context("Validate DNS", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+			},
+		}
+		It("Should admit custom dnsConfig with ndots", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.PodTemplate.Spec.DNSConfig = &corev1.PodDNSConfig{
+				Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("1")}},
+			}
+			Expect(localCluster.validateDNS()).To(BeNil())
+		})
+		It("Should reject dnsPolicy None without nameservers", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.PodTemplate.Spec.DNSPolicy = corev1.DNSNone
+			err := localCluster.validateDNS()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeRequired))
+			}
+		})
+		It("Should reject invalid ndots value", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.PodTemplate.Spec.DNSConfig = &corev1.PodDNSConfig{
+				Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("many")}},
+			}
+			err := localCluster.validateDNS()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.podTemplate.spec.dnsConfig.options[0].value"))
+			}
+		})
+	})
+
+	Context("Validate service template", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+			},
+		}
+		It("Should admit client service traffic settings", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.ServiceTemplate = &EmbeddedService{Spec: corev1.ServiceSpec{
+				PublishNotReadyAddresses: true,
+				SessionAffinity:          corev1.ServiceAffinityClientIP,
+				SessionAffinityConfig: &corev1.SessionAffinityConfig{
+					ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: ptr.To(int32(600))},
+				},
+				InternalTrafficPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyLocal),
+			}}
+			Expect(localCluster.validateServiceTemplate()).To(BeNil())
+		})
+		It("Should reject unknown session affinity and traffic policy", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.ServiceTemplate = &EmbeddedService{Spec: corev1.ServiceSpec{
+				SessionAffinity:       "Cookie",
+				InternalTrafficPolicy: ptr.To(corev1.ServiceInternalTrafficPolicy("Node")),
+			}}
+			err := localCluster.validateServiceTemplate()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Field).To(Equal("spec.serviceTemplate.spec.sessionAffinity"))
+				Expect(err[1].Field).To(Equal("spec.serviceTemplate.spec.internalTrafficPolicy"))
+			}
+		})
+		It("Should reject session affinity config without ClientIP affinity", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.ServiceTemplate = &EmbeddedService{Spec: corev1.ServiceSpec{
+				SessionAffinityConfig: &corev1.SessionAffinityConfig{},
+			}}
+			err := localCluster.validateServiceTemplate()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+	})
+
+	Context("Validate storage", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				Storage: StorageSpec{
+					EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+				},
+			},
+		}
+		It("Should reject memory storage without size limit", func() {
+			localCluster := etcdCluster.DeepCopy()
+			_, err := localCluster.validateStorage()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeRequired))
+				Expect(err[0].Field).To(Equal("spec.storage.emptyDir.sizeLimit"))
+			}
+		})
+		It("Should warn about memory storage", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Storage.EmptyDir.SizeLimit = ptr.To(resource.MustParse("512Mi"))
+			w, err := localCluster.validateStorage()
+			Expect(err).To(BeNil())
+			Expect(w).To(HaveLen(1))
+		})
+		It("Should warn about host path storage and validate its path", func() {
+			localCluster := &EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "edge"},
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage:  StorageSpec{HostPath: &HostPathStorageSpec{}},
+				},
+			}
+			Expect(localCluster.GetHostPath()).To(Equal("/var/lib/etcd-operator/edge/test"))
+			w, err := localCluster.validateStorage()
+			Expect(err).To(BeEmpty())
+			Expect(w).To(HaveLen(1))
+
+			localCluster.Spec.Replicas = ptr.To(int32(3))
+			w, _ = localCluster.validateStorage()
+			Expect(w).To(HaveLen(2))
+
+			localCluster.Spec.Storage.HostPath.Path = "data/$(CLUSTER)"
+			_, err = localCluster.validateStorage()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.storage.hostPath.path"))
+			}
+		})
+		It("Should validate the retention policy of member claims", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{Storage: StorageSpec{
+				RetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+					WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+					WhenScaled:  "Archive",
+				},
+			}}}
+			err := localCluster.validateRetentionPolicy()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeNotSupported))
+				Expect(err[0].Field).To(Equal("spec.storage.retentionPolicy.whenScaled"))
+			}
+
+			localCluster.Spec.Storage.RetentionPolicy.WhenScaled = ""
+			localCluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{}
+			err = localCluster.validateRetentionPolicy()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+		It("Should validate storage class, access modes and capacity of the claim", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Storage: StorageSpec{
+						VolumeClaimTemplate: EmbeddedPersistentVolumeClaim{
+							Spec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: ptr.To("Fast_SSD"),
+								AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOncePod, corev1.ReadOnlyMany},
+								Resources: corev1.VolumeResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+									Limits:   corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+								},
+							},
+						},
+					},
+				},
+			}
+			_, err := localCluster.validateStorage()
+			Expect(err).To(HaveLen(4))
+			Expect(err[0].Field).To(Equal("spec.storage.volumeClaimTemplate.spec.storageClassName"))
+			Expect(err[1].Field).To(Equal("spec.storage.volumeClaimTemplate.spec.accessModes[1]"))
+			Expect(err[2].Type).To(Equal(field.ErrorTypeForbidden))
+			Expect(err[3].Field).To(Equal("spec.storage.volumeClaimTemplate.spec.resources.limits.storage"))
+
+			localCluster.Spec.Storage.VolumeClaimTemplate.Spec = corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To(""),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOncePod},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			}
+			_, err = localCluster.validateStorage()
+			Expect(err).To(BeEmpty())
+		})
+		It("Should validate member volumes", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Storage: StorageSpec{
+						Members: []MemberVolume{
+							{Ordinal: 0, NodeName: "node-a"},
+							{Ordinal: 1},
+							{Ordinal: 3, VolumeName: "local-pv-3"},
+						},
+					},
+				},
+			}
+			w, err := localCluster.validateStorage()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.storage.members[1]"))
+			}
+			Expect(w).To(ConsistOf(ContainSubstring("has ordinal 3")))
+		})
+		It("Should validate storage encryption", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Storage: StorageSpec{
+						VolumeClaimTemplate: EmbeddedPersistentVolumeClaim{
+							Spec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: ptr.To("standard"),
+								VolumeMode:       ptr.To(corev1.PersistentVolumeFilesystem),
+							},
+						},
+						Encryption: &StorageEncryptionSpec{
+							StorageClassName: "encrypted",
+							DMCrypt:          &DMCryptSpec{Image: "cryptsetup:latest"},
+						},
+					},
+				},
+			}
+			_, err := localCluster.validateStorage()
+			Expect(err).To(ConsistOf(
+				HaveField("Field", "spec.storage.encryption.storageClassName"),
+				HaveField("Field", "spec.storage.encryption.dmCrypt.keySecret"),
+				HaveField("Field", "spec.storage.volumeClaimTemplate.spec.volumeMode"),
+			))
+		})
+		It("Should keep data dir inside the volume", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Storage: StorageSpec{MountPath: "etcd", SubPath: "../data", DataDir: "/member"},
+				},
+			}
+			Expect(localCluster.validateDataDir()).To(ConsistOf(
+				HaveField("Field", "spec.storage.mountPath"),
+				HaveField("Field", "spec.storage.subPath"),
+				HaveField("Field", "spec.storage.dataDir"),
+			))
+			localCluster.Spec.Storage = StorageSpec{MountPath: "/bitnami/etcd", SubPath: "data", DataDir: "."}
+			Expect(localCluster.validateDataDir()).To(BeEmpty())
+		})
+		It("Should validate write-ahead log volume", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Options:  map[string]string{"wal-dir": "/var/lib/wal"},
+					Storage: StorageSpec{
+						WALVolume: &WALVolumeSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					},
+				},
+			}
+			_, err := localCluster.validateStorage()
+			Expect(err).To(ConsistOf(
+				HaveField("Field", "spec.storage.walVolume.emptyDir"),
+				HaveField("Field", "spec.options[wal-dir]"),
+			))
+		})
+		It("Should validate volume permissions", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Storage: StorageSpec{
+						HostPath:          &HostPathStorageSpec{},
+						VolumePermissions: &VolumePermissionsSpec{},
+					},
+					PodTemplate: PodTemplate{
+						Spec: corev1.PodSpec{SecurityContext: &corev1.PodSecurityContext{RunAsUser: ptr.To(int64(1001))}},
+					},
+				},
+			}
+			warnings, err := localCluster.validateVolumePermissions()
+			Expect(warnings).To(ConsistOf(ContainSubstring("InitContainer")))
+			Expect(err).To(ConsistOf(HaveField("Field", "spec.podTemplate.spec.securityContext")))
+			localCluster.Spec.Storage.VolumePermissions.Mode = VolumePermissionsInitContainer
+			localCluster.Spec.PodTemplate.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)}
+			warnings, err = localCluster.validateVolumePermissions()
+			Expect(warnings).To(BeEmpty())
+			Expect(err).To(BeEmpty())
+		})
+		It("Should allow adding but not removing write-ahead log volume", func() {
+			oldWAL := &WALVolumeSpec{}
+			localCluster := &EtcdCluster{}
+			Expect(localCluster.validateWALVolumeUpdate(oldWAL)).To(ConsistOf(
+				HaveField("Type", field.ErrorTypeForbidden),
+			))
+			localCluster.Spec.Storage.WALVolume = &WALVolumeSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+			Expect(localCluster.validateWALVolumeUpdate(oldWAL)).To(ConsistOf(
+				HaveField("Field", "spec.storage.walVolume.emptyDir"),
+			))
+			localCluster.Spec.Storage.WALVolume = &WALVolumeSpec{}
+			Expect(localCluster.validateWALVolumeUpdate(oldWAL)).To(BeEmpty())
+		})
+	})
+
+	Context("Validate image digests", func() {
+		digest := "sha256:" + strings.Repeat("ab", 32)
+		It("Should admit images pinned by digest", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "quay.io/coreos/etcd@" + digest},
+				{Name: "sidecar", Image: "busybox:1.36@" + digest},
+			}
+			Expect(localCluster.validateImageDigests()).To(BeNil())
+		})
+		It("Should reject malformed digest", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "quay.io/coreos/etcd@sha256:abc"},
+			}
+			err := localCluster.validateImageDigests()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.podTemplate.spec.containers[0].image"))
+			}
+		})
+	})
+
+	Context("Validate command prefix", func() {
+		It("Should admit command prefix", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.PodTemplate.CommandPrefix = []string{"dumb-init", "--"}
+			Expect(localCluster.validateCommandPrefix()).To(BeNil())
+		})
+		It("Should reject empty entries and overridden etcd command", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.PodTemplate.CommandPrefix = []string{"dumb-init", ""}
+			localCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Command: []string{"/usr/local/bin/etcd"}},
+			}
+			err := localCluster.validateCommandPrefix()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Field).To(Equal("spec.podTemplate.commandPrefix[1]"))
+				Expect(err[1].Field).To(Equal("spec.podTemplate.spec.containers[0].command"))
+			}
+		})
+	})
+
+	Context("Validate availability policy", func() {
+		It("Should admit quorum and percentage values", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(5))}}
+			for _, value := range []intstr.IntOrString{intstr.FromInt32(3), intstr.FromString("60%")} {
+				localCluster.Spec.AvailabilityPolicy = &AvailabilityPolicySpec{MinHealthyMembers: ptr.To(value)}
+				Expect(localCluster.validateAvailabilityPolicy()).To(BeNil())
+			}
+		})
+		It("Should reject values below quorum, above replicas or malformed", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(5))}}
+			for _, value := range []intstr.IntOrString{intstr.FromInt32(2), intstr.FromInt32(6), intstr.FromString("40%"), intstr.FromString("most")} {
+				localCluster.Spec.AvailabilityPolicy = &AvailabilityPolicySpec{MinHealthyMembers: ptr.To(value)}
+				err := localCluster.validateAvailabilityPolicy()
+				if Expect(err).To(HaveLen(1)) {
+					Expect(err[0].Field).To(Equal("spec.availabilityPolicy.minHealthyMembers"))
+				}
+			}
+		})
+	})
+
+	Context("Validate cordoned members", func() {
+		It("Should admit member ordinals", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Annotations = map[string]string{CordonedMembersAnnotation: "0,2"}
+			Expect(localCluster.validateCordonedMembers()).To(BeNil())
+		})
+		It("Should reject malformed ordinals", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Annotations = map[string]string{CordonedMembersAnnotation: "-1"}
+			err := localCluster.validateCordonedMembers()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("metadata.annotations[etcd.aenix.io/cordoned-members]"))
+			}
+		})
+	})
+
+	Context("Validate debug shell", func() {
+		It("Should admit durations up to the limit", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Annotations = map[string]string{DebugShellAnnotation: "30m"}
+			Expect(localCluster.validateDebugShell()).To(BeNil())
+		})
+		It("Should reject malformed and too long durations", func() {
+			for _, value := range []string{"forever", "-1m", "48h"} {
+				localCluster := &EtcdCluster{}
+				localCluster.Annotations = map[string]string{DebugShellAnnotation: value}
+				err := localCluster.validateDebugShell()
+				if Expect(err).To(HaveLen(1)) {
+					Expect(err[0].Field).To(Equal("metadata.annotations[etcd.aenix.io/debug-shell]"))
+				}
+			}
+		})
+	})
+
+	Context("Validate raft snapshots", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				RaftSnapshots: &RaftSnapshotsSpec{
+					SnapshotCount: ptr.To(int64(50000)),
+				},
+			},
+		}
+		It("Should admit raft snapshot settings", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"max-wals": "10"}
+			Expect(localCluster.validateRaftSnapshots()).To(BeNil())
+		})
+		It("Should reject the same flag in options", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"snapshot-count": "10000"}
+			err := localCluster.validateRaftSnapshots()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.options[snapshot-count]"))
+				Expect(err[0].Detail).To(Equal("conflicts with spec.raftSnapshots.snapshotCount"))
+			}
+		})
+	})
+
+	Context("Validate request limits", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				RequestLimits: &RequestLimitsSpec{
+					MaxTxnOps:       ptr.To(int32(1024)),
+					MaxRequestBytes: ptr.To(resource.MustParse("4Mi")),
+				},
+			},
+		}
+		It("Should admit request limits", func() {
+			warnings, err := etcdCluster.DeepCopy().validateRequestLimits()
+			Expect(warnings).To(BeEmpty())
+			Expect(err).To(BeNil())
+		})
+		It("Should warn about requests larger than recommended by etcd", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.RequestLimits.MaxRequestBytes = ptr.To(resource.MustParse("32Mi"))
+			warnings, err := localCluster.validateRequestLimits()
+			Expect(warnings).To(HaveLen(1))
+			Expect(err).To(BeNil())
+		})
+		It("Should reject non-positive request size", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.RequestLimits.MaxRequestBytes = ptr.To(resource.MustParse("0"))
+			_, err := localCluster.validateRequestLimits()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.requestLimits.maxRequestBytes"))
+			}
+		})
+		It("Should reject the same flag in options", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"max-txn-ops": "256"}
+			_, err := localCluster.validateRequestLimits()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.options[max-txn-ops]"))
+				Expect(err[0].Detail).To(Equal("conflicts with spec.requestLimits.maxTxnOps"))
+			}
+		})
+	})
+
+	Context("Validate auth", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Security: &SecuritySpec{Auth: &AuthSpec{
+					Enabled: true,
+					JWT:     &JWTSpec{SignMethod: "ES256", TTL: &metav1.Duration{Duration: 10 * time.Minute}},
+				}},
+			},
+		}
+		It("Should admit JWT settings", func() {
+			warnings, err := etcdCluster.DeepCopy().validateAuth()
+			Expect(warnings).To(BeEmpty())
+			Expect(err).To(BeNil())
+		})
+		It("Should warn about JWT without authentication", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Security.Auth.Enabled = false
+			warnings, err := localCluster.validateAuth()
+			Expect(warnings).To(HaveLen(1))
+			Expect(err).To(BeNil())
+		})
+		It("Should reject the auth-token flag in options", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"auth-token": "simple"}
+			localCluster.Spec.Security.Auth.JWT.TTL.Duration = 0
+			_, err := localCluster.validateAuth()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Field).To(Equal("spec.security.auth.jwt.ttl"))
+				Expect(err[1].Field).To(Equal("spec.options[auth-token]"))
+				Expect(err[1].Detail).To(Equal("conflicts with spec.security.auth.jwt"))
+			}
+		})
+	})
+
+	Context("Validate mode", func() {
+		It("Should admit a single standalone member", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{
+				Mode:                        ClusterModeStandalone,
+				Replicas:                    ptr.To(int32(1)),
+				PodDisruptionBudgetTemplate: &EmbeddedPodDisruptionBudget{},
+				Storage:                     StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			}}
+			warnings, err := localCluster.validateMode(nil)
+			Expect(err).To(BeEmpty())
+			Expect(warnings).To(HaveLen(1))
+			Expect(localCluster.validateEphemeralStorage(nil)).To(BeEmpty())
+			pdbWarnings, pdbErr := localCluster.validatePdb()
+			Expect(pdbWarnings).To(BeEmpty())
+			Expect(pdbErr).To(BeEmpty())
+		})
+		It("Should reject several standalone members", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{Mode: ClusterModeStandalone, Replicas: ptr.To(int32(3))}}
+			_, err := localCluster.validateMode(nil)
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.replicas"))
+			}
+		})
+		It("Should forbid to change the mode", func() {
+			oldCluster := &EtcdCluster{Spec: EtcdClusterSpec{Replicas: ptr.To(int32(1))}}
+			localCluster := oldCluster.DeepCopy()
+			localCluster.Spec.Mode = ClusterModeStandalone
+			_, err := localCluster.validateMode(oldCluster)
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.mode"))
+			}
+			oldCluster.Spec.Mode = ClusterModeClustered
+			localCluster.Spec.Mode = ""
+			_, err = localCluster.validateMode(oldCluster)
+			Expect(err).To(BeEmpty())
+		})
+	})
+
+	Context("Validate management policy", func() {
+		It("Should require http endpoints of observed clusters", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{ManagementPolicy: ManagementPolicyObserve}}
+			err := localCluster.validateManagementPolicy()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeRequired))
+			}
+			localCluster.Spec.ObservedEndpoints = []string{"https://10.0.0.1:2379", "10.0.0.2:2379"}
+			err = localCluster.validateManagementPolicy()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.observedEndpoints[1]"))
+			}
+		})
+		It("Should reject observed endpoints of managed clusters", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{ObservedEndpoints: []string{"https://10.0.0.1:2379"}}}
+			err := localCluster.validateManagementPolicy()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+	})
+
+	Context("Validate operator connection", func() {
+		It("Should require endpoints only in the Endpoints mode", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{
+				OperatorConnection: &OperatorConnectionSpec{Mode: OperatorConnectionEndpoints},
+			}}
+			err := localCluster.validateOperatorConnection()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeRequired))
+			}
+			localCluster.Spec.OperatorConnection.Endpoints = []string{"https://gateway.mesh:2379"}
+			Expect(localCluster.validateOperatorConnection()).To(BeEmpty())
+
+			localCluster.Spec.OperatorConnection.Mode = OperatorConnectionClientService
+			err = localCluster.validateOperatorConnection()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+	})
+
+	Context("Validate bootstrap", func() {
+		It("Should reject discovery options in the DiscoverySRV mode", func() {
+			localCluster := &EtcdCluster{Spec: EtcdClusterSpec{
+				Options: map[string]string{"initial-cluster": "etcd-0=https://etcd-0:2380", "discovery-srv": "example.com"},
+			}}
+			Expect(localCluster.validateBootstrap()).To(BeEmpty())
+
+			localCluster.Spec.Bootstrap = BootstrapDiscoverySRV
+			err := localCluster.validateBootstrap()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Field).To(Equal("spec.options[initial-cluster]"))
+				Expect(err[1].Field).To(Equal("spec.options[discovery-srv]"))
+			}
+		})
+	})
+
+	Context("Validate platform", func() {
+		It("Should reject node selectors of platforms the etcd image does not run on", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.PodTemplate.Spec.NodeSelector = map[string]string{
+				corev1.LabelOSStable:   "linux",
+				corev1.LabelArchStable: "arm64",
+			}
+			Expect(localCluster.validatePlatform()).To(BeEmpty())
+
+			localCluster.Spec.PodTemplate.Architectures = []string{"amd64"}
+			localCluster.Spec.PodTemplate.Spec.NodeSelector[corev1.LabelOSStable] = "windows"
+			err := localCluster.validatePlatform()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Field).To(Equal("spec.podTemplate.spec.nodeSelector[kubernetes.io/os]"))
+				Expect(err[1].Field).To(Equal("spec.podTemplate.spec.nodeSelector[kubernetes.io/arch]"))
+			}
+		})
+	})
+
+	Context("Validate tracing", func() {
+		It("Should reject options conflicting with tracing", func() {
+			localCluster := &EtcdCluster{
+				Spec: EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Options:  map[string]string{"experimental-distributed-tracing-address": "collector:4317"},
+					Tracing:  &TracingSpec{Endpoint: "otel-collector:4317"},
+				},
+			}
+			err := localCluster.validateTracing()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.options[experimental-distributed-tracing-address]"))
+				Expect(err[0].Detail).To(Equal("conflicts with spec.tracing"))
+			}
+			localCluster.Spec.Options = map[string]string{"log-level": "debug"}
+			Expect(localCluster.validateTracing()).To(BeEmpty())
+		})
+	})
+
+	Context("Validate logging", func() {
+		It("Should reject options conflicting with the log shipper", func() {
+			localCluster := &EtcdCluster{}
+			localCluster.Spec.Logging = &LoggingSpec{Shipper: &LogShipperSpec{Outputs: "[OUTPUT]\n    Name null"}}
+			Expect(localCluster.validateLogging()).To(BeNil())
+
+			localCluster.Spec.Options = map[string]string{"log-outputs": "stderr"}
+			err := localCluster.validateLogging()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.options[log-outputs]"))
+			}
+		})
+	})
+
+	Context("Validate options against etcd flag set", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+			},
+		}
+		It("Should admit known flags with valid values", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"snapshot-count": "10000",
+				"enable-pprof":   "",
+				"log-level":      "debug",
+			}
+			w, err := localCluster.validateOptionsFlagSet()
+			Expect(err).To(BeNil())
+			Expect(w).To(BeEmpty())
+		})
+		It("Should reject typo in flag name and suggest correct flag", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"snapshot-cuont": "10000"}
+			_, err := localCluster.validateOptionsFlagSet()
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Field).To(Equal("spec.options[snapshot-cuont]"))
+				Expect(err[0].Detail).To(ContainSubstring(`did you mean "snapshot-count"?`))
+			}
+		})
+		It("Should reject invalid flag values", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"snapshot-count":     "many",
+				"heartbeat-interval": "",
+				"log-level":          "verbose",
+			}
+			_, err := localCluster.validateOptionsFlagSet()
+			Expect(err).To(HaveLen(3))
+		})
+		It("Should warn if etcd version cannot be determined", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"snapshot-cuont": "10000"}
+			localCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "registry.local/etcd@sha256:0123456789abcdef"},
+			}
+			w, err := localCluster.validateOptionsFlagSet()
+			Expect(err).To(BeNil())
+			Expect(w).To(HaveLen(1))
+		})
+		It("Should parse minor version from image tag", func() {
+			version, ok := etcdMinorVersion("quay.io/coreos/etcd:v3.5.12")
+			Expect(ok).To(BeTrue())
+			Expect(version).To(Equal("3.5"))
+			_, ok = etcdMinorVersion("localhost:5000/etcd")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Validate options against etcd 3.6 flag set", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				PodTemplate: PodTemplate{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "etcd", Image: "gcr.io/etcd-development/etcd:v3.6.0"}},
+					},
+				},
+			},
+		}
+		It("Should reject flags removed in etcd 3.6", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"enable-v2":      "true",
+				"v2-deprecation": "not-yet",
+			}
+			_, err := localCluster.validateOptionsFlagSet()
+			if Expect(err).To(HaveLen(2)) {
+				Expect(err[0].Detail).To(Equal("unknown flag for etcd 3.6"))
+			}
+		})
+		It("Should admit flags added in etcd 3.6 and reject them for etcd 3.5", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"discovery-endpoints": "https://discovery:2379",
+				"feature-gates":       "InitialCorruptCheck=true",
+			}
+			w, err := localCluster.validateOptionsFlagSet()
+			Expect(err).To(BeNil())
+			Expect(w).To(BeEmpty())
+
+			localCluster.Spec.PodTemplate.Spec.Containers = nil
+			_, err = localCluster.validateOptionsFlagSet()
+			Expect(err).To(HaveLen(2))
+		})
+		It("Should warn about deprecated flags", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"experimental-corrupt-check-time":    "1m",
+				"experimental-initial-corrupt-check": "true",
+			}
+			w, err := localCluster.validateOptionsFlagSet()
+			Expect(err).To(BeNil())
+			Expect(w).To(Equal(admission.Warnings{
+				"spec.options[experimental-corrupt-check-time] is deprecated in etcd 3.6, it is passed as --corrupt-check-time",
+				"spec.options[experimental-initial-corrupt-check] is deprecated in etcd 3.6, it is passed as --feature-gates=InitialCorruptCheck",
+			}))
+		})
+		It("Should convert deprecated flags for etcd 3.6", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{
+				"experimental-corrupt-check-time":    "1m",
+				"experimental-initial-corrupt-check": "",
+				"feature-gates":                      "StopGRPCServiceOnDefrag=true",
+				"log-level":                          "debug",
+			}
+			Expect(localCluster.EtcdOptions()).To(Equal(map[string]string{
+				"corrupt-check-time": "1m",
+				"feature-gates":      "InitialCorruptCheck=true,StopGRPCServiceOnDefrag=true",
+				"log-level":          "debug",
+			}))
+
+			localCluster.Spec.PodTemplate.Spec.Containers = nil
+			Expect(localCluster.EtcdOptions()).To(Equal(localCluster.Spec.Options))
+		})
+	})
+
+	Context("Validate experimental options", func() {
+		etcdCluster := &EtcdCluster{
+			Spec: EtcdClusterSpec{
+				Replicas:            ptr.To(int32(3)),
+				ExperimentalOptions: map[string]string{"enable-distributed-tracing": "true"},
+			},
+		}
+		It("Should reject experimental options if they are disabled", func() {
+			_, err := etcdCluster.DeepCopy().validateExperimentalOptions(false)
+			if Expect(err).To(HaveLen(1)) {
+				Expect(err[0].Type).To(Equal(field.ErrorTypeForbidden))
+			}
+		})
+		It("Should reject experimental options on admission unless the validator enables them", func() {
+			_, err := (&EtcdClusterValidator{}).ValidateCreate(context.Background(), etcdCluster.DeepCopy())
+			Expect(err).To(HaveOccurred())
+			validator := &EtcdClusterValidator{ExperimentalOptionsEnabled: true}
+			_, err = validator.ValidateCreate(context.Background(), etcdCluster.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("Should admit known experimental flags with a warning", func() {
+			localCluster := etcdCluster.DeepCopy()
+			w, err := localCluster.validateExperimentalOptions(true)
+			Expect(err).To(BeNil())
+			Expect(w).To(HaveLen(1))
+			Expect(localCluster.EtcdOptions()).To(Equal(map[string]string{
+				"experimental-enable-distributed-tracing": "true",
+			}))
+		})
+		It("Should reject unknown, prefixed and duplicate experimental flags", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.Options = map[string]string{"experimental-enable-distributed-tracing": "true"}
+			localCluster.Spec.ExperimentalOptions = map[string]string{
+				"enable-distributed-tracing":  "true",
+				"experimental-memory-mlock":   "true",
+				"enable-distributed-tracking": "true",
+			}
+			_, err := localCluster.validateExperimentalOptions(true)
+			Expect(err).To(HaveLen(3))
+		})
+		It("Should pass experimental flags under stable names to etcd 3.6", func() {
+			localCluster := etcdCluster.DeepCopy()
+			localCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "gcr.io/etcd-development/etcd:v3.6.0"},
+			}
+			w, err := localCluster.validateExperimentalOptions(true)
+			Expect(err).To(BeNil())
+			Expect(w).To(HaveLen(2))
+			Expect(localCluster.EtcdOptions()).To(Equal(map[string]string{"enable-distributed-tracing": "true"}))
+		})
+	})
 })
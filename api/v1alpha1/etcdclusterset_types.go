@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterSetLabel is set on EtcdClusters created by an EtcdClusterSet to the name of the set.
+const ClusterSetLabel = "etcd.aenix.io/cluster-set"
+
+// ClusterSetTemplateHashAnnotation holds the hash of the EtcdClusterSet template the cluster was last updated from.
+const ClusterSetTemplateHashAnnotation = "etcd.aenix.io/cluster-set-template-hash"
+
+const (
+	EtcdCondTypeAllClustersReady   EtcdCondType = "AllClustersReady"
+	EtcdCondTypeClustersNotReady   EtcdCondType = "ClustersNotReady"
+	EtcdCondTypeInvalidSelector    EtcdCondType = "InvalidNamespaceSelector"
+	EtcdCondTypeNoNamespaceMatched EtcdCondType = "NoNamespaceMatched"
+)
+
+// EtcdClusterSetSpec defines the desired state of EtcdClusterSet
+type EtcdClusterSetSpec struct {
+	// NamespaceSelector selects namespaces to create an EtcdCluster in, one cluster per namespace.
+	// Clusters in namespaces which are no longer selected are deleted.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// Template describes the EtcdClusters created by the set.
+	Template EtcdClusterTemplate `json:"template"`
+}
+
+// EtcdClusterTemplate describes EtcdClusters created by an EtcdClusterSet.
+type EtcdClusterTemplate struct {
+	// Metadata of the clusters. Clusters are named after the set unless a name is set here.
+	// +optional
+	EmbeddedObjectMetadata `json:"metadata,omitempty"`
+
+	// Spec of the clusters. Namespace storage defaults are applied to it as to any other EtcdCluster.
+	Spec EtcdClusterSpec `json:"spec"`
+}
+
+// EtcdClusterSetStatus defines the observed state of EtcdClusterSet
+type EtcdClusterSetStatus struct {
+	// ObservedGeneration is the generation of the set the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Clusters is the number of clusters in selected namespaces.
+	// +optional
+	Clusters int32 `json:"clusters"`
+
+	// ReadyClusters is the number of clusters with Ready condition.
+	// +optional
+	ReadyClusters int32 `json:"readyClusters"`
+
+	// ClusterStatuses reports state of the cluster in every selected namespace.
+	// +optional
+	// +listType=map
+	// +listMapKey=namespace
+	ClusterStatuses []EtcdClusterSetClusterStatus `json:"clusterStatuses,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// EtcdClusterSetClusterStatus is the state of a single EtcdCluster of the set.
+type EtcdClusterSetClusterStatus struct {
+	// Namespace of the cluster.
+	Namespace string `json:"namespace"`
+
+	// Name of the cluster.
+	Name string `json:"name"`
+
+	// Ready is true if the cluster has Ready condition.
+	Ready bool `json:"ready"`
+
+	// CurrentVersion is the etcd version the cluster runs.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// Message tells why the cluster is not ready or couldn't be created.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName={ecs},categories=all
+// +kubebuilder:printcolumn:name="Clusters",type="integer",JSONPath=".status.clusters"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyClusters"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// EtcdClusterSet stamps out identical EtcdClusters across selected namespaces and aggregates their statuses.
+type EtcdClusterSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdClusterSetSpec   `json:"spec,omitempty"`
+	Status EtcdClusterSetStatus `json:"status,omitempty"`
+}
+
+// ClusterName returns name of EtcdClusters created by the set.
+func (r *EtcdClusterSet) ClusterName() string {
+	if r.Spec.Template.Name != "" {
+		return r.Spec.Template.Name
+	}
+	return r.Name
+}
+
+// +kubebuilder:object:root=true
+
+// EtcdClusterSetList contains a list of EtcdClusterSet
+type EtcdClusterSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdClusterSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdClusterSet{}, &EtcdClusterSetList{})
+}
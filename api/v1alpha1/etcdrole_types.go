@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EtcdRoleFinalizer keeps an EtcdRole until its role is deleted from etcd.
+const EtcdRoleFinalizer = "etcd.aenix.io/etcd-role"
+
+// RootRole is the etcd role of the root user, it is managed by the operator and can not be defined by EtcdRoles.
+const RootRole = "root"
+
+// EtcdConditionDrifted is True when the last periodic check of an applied EtcdRole or EtcdUser found its etcd role
+// or user changed outside of the operator and restored it.
+const EtcdConditionDrifted = "Drifted"
+
+const (
+	EtcdCondTypeRoleApplied        EtcdCondType = "RoleApplied"
+	EtcdCondTypeRoleRejected       EtcdCondType = "RoleRejected"
+	EtcdCondTypeClusterNotFound    EtcdCondType = "ClusterNotFound"
+	EtcdCondTypeClusterUnreachable EtcdCondType = "ClusterUnreachable"
+	EtcdCondTypeNoDrift            EtcdCondType = "NoDrift"
+	EtcdCondTypePermissionsDrifted EtcdCondType = "PermissionsDrifted"
+)
+
+// PermissionType is the access an etcd role grants to keys.
+type PermissionType string
+
+const (
+	// PermissionRead allows to read and watch keys.
+	PermissionRead PermissionType = "Read"
+	// PermissionWrite allows to write and delete keys.
+	PermissionWrite PermissionType = "Write"
+	// PermissionReadWrite allows both.
+	PermissionReadWrite PermissionType = "ReadWrite"
+)
+
+// EtcdRoleSpec defines the desired state of EtcdRole
+type EtcdRoleSpec struct {
+	// ClusterRef is the EtcdCluster in the namespace of the role the role is created in.
+	ClusterRef corev1.LocalObjectReference `json:"clusterRef"`
+
+	// RoleName is the name of the etcd role. Defaults to the name of the EtcdRole. The root role is managed
+	// by the operator and is rejected.
+	// +optional
+	RoleName string `json:"roleName,omitempty"`
+
+	// Permissions granted by the role. Permissions of the etcd role which are not listed are revoked.
+	// +optional
+	Permissions []EtcdPermission `json:"permissions,omitempty"`
+}
+
+// EtcdPermission grants access to a key, a prefix of keys or a range of keys.
+type EtcdPermission struct {
+	// Type of the access.
+	// +kubebuilder:validation:Enum=Read;Write;ReadWrite
+	Type PermissionType `json:"type"`
+
+	// Key is the key the permission applies to, or the first key of the range or the prefix.
+	Key string `json:"key"`
+
+	// Prefix grants access to all keys starting with the key. An empty key with prefix grants access to all keys.
+	// +optional
+	Prefix bool `json:"prefix,omitempty"`
+
+	// RangeEnd grants access to keys from the key up to, but not including, the range end.
+	// It can not be set together with prefix.
+	// +optional
+	RangeEnd string `json:"rangeEnd,omitempty"`
+}
+
+// EtcdRoleStatus defines the observed state of EtcdRole
+type EtcdRoleStatus struct {
+	// ObservedGeneration is the generation of the role the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Cluster is the name of the EtcdCluster the role was created in.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// RoleName is the name of the etcd role created in the cluster. The role is deleted from the cluster
+	// if the spec refers to another cluster or role, or the EtcdRole is deleted.
+	// +optional
+	RoleName string `json:"roleName,omitempty"`
+
+	// Conditions report whether the role is applied to the cluster. The Ready condition is false with
+	// the RoleRejected reason if the cluster rejects the definition of the role. The Drifted condition reports
+	// permissions of the etcd role restored after they were changed outside of the operator.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName={er},categories=all
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// EtcdRole describes permissions on keys of an EtcdCluster, which are reconciled into an etcd role.
+type EtcdRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdRoleSpec   `json:"spec,omitempty"`
+	Status EtcdRoleStatus `json:"status,omitempty"`
+}
+
+// EtcdRoleName returns name of the etcd role.
+func (r *EtcdRole) EtcdRoleName() string {
+	if r.Spec.RoleName != "" {
+		return r.Spec.RoleName
+	}
+	return r.Name
+}
+
+// +kubebuilder:object:root=true
+
+// EtcdRoleList contains a list of EtcdRole
+type EtcdRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdRole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdRole{}, &EtcdRoleList{})
+}
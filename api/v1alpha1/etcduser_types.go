@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EtcdUserFinalizer keeps an EtcdUser until its user is deleted from etcd.
+const EtcdUserFinalizer = "etcd.aenix.io/etcd-user"
+
+const (
+	EtcdCondTypeUserApplied            EtcdCondType = "UserApplied"
+	EtcdCondTypeUserRejected           EtcdCondType = "UserRejected"
+	EtcdCondTypePasswordSecretNotFound EtcdCondType = "PasswordSecretNotFound"
+	EtcdCondTypeRoleNotFound           EtcdCondType = "RoleNotFound"
+	EtcdCondTypeUserDrifted            EtcdCondType = "UserDrifted"
+)
+
+// EtcdUserSpec defines the desired state of EtcdUser
+type EtcdUserSpec struct {
+	// ClusterRef is the EtcdCluster in the namespace of the user the user is created in.
+	ClusterRef corev1.LocalObjectReference `json:"clusterRef"`
+
+	// UserName is the name of the etcd user. Defaults to the name of the EtcdUser. The root user is managed
+	// by the operator and is rejected.
+	// +optional
+	UserName string `json:"userName,omitempty"`
+
+	// PasswordSecretRef is the secret in the namespace of the user holding the password in the password field,
+	// as secrets of the kubernetes.io/basic-auth type do. Changes of the secret are pushed to etcd.
+	PasswordSecretRef corev1.LocalObjectReference `json:"passwordSecretRef"`
+
+	// Roles granted to the user. Roles of the etcd user which are not listed are revoked, the root role
+	// is rejected.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+}
+
+// EtcdUserStatus defines the observed state of EtcdUser
+type EtcdUserStatus struct {
+	// ObservedGeneration is the generation of the user the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Cluster is the name of the EtcdCluster the user was created in.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// UserName is the name of the etcd user created in the cluster. The user is deleted from the cluster
+	// if the spec refers to another cluster or user, or the EtcdUser is deleted.
+	// +optional
+	UserName string `json:"userName,omitempty"`
+
+	// PasswordSecretVersion is the resource version of the password secret the password of the etcd user
+	// was last set from.
+	// +optional
+	PasswordSecretVersion string `json:"passwordSecretVersion,omitempty"`
+
+	// LastRotationTime is the time the password of the etcd user was last changed after the password secret
+	// was updated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// Conditions report whether the user is applied to the cluster. The Ready condition is false with
+	// the UserRejected reason if the cluster rejects the definition of the user. The Drifted condition reports
+	// the etcd user or its roles restored after they were changed outside of the operator.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName={eu},categories=all
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Rotated",type="date",JSONPath=".status.lastRotationTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// EtcdUser describes an etcd user of an EtcdCluster with its password kept in a secret and the roles granted to it.
+type EtcdUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdUserSpec   `json:"spec,omitempty"`
+	Status EtcdUserStatus `json:"status,omitempty"`
+}
+
+// EtcdUserName returns name of the etcd user.
+func (u *EtcdUser) EtcdUserName() string {
+	if u.Spec.UserName != "" {
+		return u.Spec.UserName
+	}
+	return u.Name
+}
+
+// +kubebuilder:object:root=true
+
+// EtcdUserList contains a list of EtcdUser
+type EtcdUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdUser{}, &EtcdUserList{})
+}
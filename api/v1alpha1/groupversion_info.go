@@ -14,9 +14,6 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// Package v1alpha1 contains API Schema definitions for the etcd.aenix.io v1alpha1 API group
-// +kubebuilder:object:generate=true
-// +groupName=etcd.aenix.io
 package v1alpha1
 
 import (
@@ -28,6 +25,9 @@ var (
 	// GroupVersion is group version used to register these objects
 	GroupVersion = schema.GroupVersion{Group: "etcd.aenix.io", Version: "v1alpha1"}
 
+	// SchemeGroupVersion is an alias of GroupVersion used by generated apply configurations
+	SchemeGroupVersion = GroupVersion
+
 	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
 	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
 
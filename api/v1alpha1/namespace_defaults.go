@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DefaultStorageClassAnnotation on a namespace sets storageClassName of volume claim templates of clusters
+	// created in the namespace without one.
+	DefaultStorageClassAnnotation = "etcd.aenix.io/default-storage-class"
+	// DefaultStorageSizeAnnotation on a namespace sets the storage request of volume claim templates of clusters
+	// created in the namespace without one, instead of the operator default.
+	DefaultStorageSizeAnnotation = "etcd.aenix.io/default-storage-size"
+)
+
+// namespaceDefaulter applies storage defaults set by annotations of the cluster namespace, then operator defaults.
+// Namespace defaults apply on creation only, as the volume claim template of existing clusters can't be changed.
+type namespaceDefaulter struct {
+	client client.Reader
+}
+
+var _ webhook.CustomDefaulter = &namespaceDefaulter{}
+
+// Default implements webhook.CustomDefaulter.
+func (d *namespaceDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	cluster, ok := obj.(*EtcdCluster)
+	if !ok {
+		return fmt.Errorf("expected EtcdCluster, got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err == nil && req.Operation == admissionv1.Create {
+		namespace := &corev1.Namespace{}
+		if err := d.client.Get(ctx, client.ObjectKey{Name: cluster.Namespace}, namespace); err != nil {
+			return fmt.Errorf("cannot get namespace %s: %w", cluster.Namespace, err)
+		}
+		if err := cluster.ApplyNamespaceDefaults(namespace.Annotations); err != nil {
+			return err
+		}
+	}
+	cluster.Default()
+	return nil
+}
+
+// ApplyNamespaceDefaults sets storage class and size of the volume claim template from annotations of the
+// namespace, if they are not set in the spec.
+func (r *EtcdCluster) ApplyNamespaceDefaults(annotations map[string]string) error {
+	if !r.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	claimSpec := &r.Spec.Storage.VolumeClaimTemplate.Spec
+	if class, ok := annotations[DefaultStorageClassAnnotation]; ok && class != "" && claimSpec.StorageClassName == nil {
+		claimSpec.StorageClassName = &class
+	}
+	size, ok := annotations[DefaultStorageSizeAnnotation]
+	if !ok || size == "" {
+		return nil
+	}
+	if storage := claimSpec.Resources.Requests.Storage(); storage != nil && !storage.IsZero() {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil || quantity.Sign() <= 0 {
+		return fmt.Errorf("namespace annotation %s has invalid size %q", DefaultStorageSizeAnnotation, size)
+	}
+	if claimSpec.Resources.Requests == nil {
+		claimSpec.Resources.Requests = corev1.ResourceList{}
+	}
+	claimSpec.Resources.Requests[corev1.ResourceStorage] = quantity
+	return nil
+}
@@ -113,7 +113,7 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	err = (&EtcdCluster{}).SetupWebhookWithManager(mgr)
+	err = (&EtcdCluster{}).SetupWebhookWithManager(mgr, &EtcdClusterValidator{})
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:webhook
@@ -21,12 +21,217 @@ limitations under the License.
 package v1alpha1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdminAccessStatus) DeepCopyInto(out *AdminAccessStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdminAccessStatus.
+func (in *AdminAccessStatus) DeepCopy() *AdminAccessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdminAccessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlarmStatus) DeepCopyInto(out *AlarmStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlarmStatus.
+func (in *AlarmStatus) DeepCopy() *AlarmStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AlarmStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedGeneration) DeepCopyInto(out *AppliedGeneration) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedGeneration.
+func (in *AppliedGeneration) DeepCopy() *AppliedGeneration {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedGeneration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
+	*out = *in
+	if in.JWT != nil {
+		in, out := &in.JWT, &out.JWT
+		*out = new(JWTSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSpec.
+func (in *AuthSpec) DeepCopy() *AuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoDefragSpec) DeepCopyInto(out *AutoDefragSpec) {
+	*out = *in
+	if in.KeyDropPercent != nil {
+		in, out := &in.KeyDropPercent, &out.KeyDropPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoDefragSpec.
+func (in *AutoDefragSpec) DeepCopy() *AutoDefragSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoDefragSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AvailabilityPolicySpec) DeepCopyInto(out *AvailabilityPolicySpec) {
+	*out = *in
+	if in.MinHealthyMembers != nil {
+		in, out := &in.MinHealthyMembers, &out.MinHealthyMembers
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AvailabilityPolicySpec.
+func (in *AvailabilityPolicySpec) DeepCopy() *AvailabilityPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AvailabilityPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CAPublicationSpec) DeepCopyInto(out *CAPublicationSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrustManager != nil {
+		in, out := &in.TrustManager, &out.TrustManager
+		*out = new(TrustManagerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CAPublicationSpec.
+func (in *CAPublicationSpec) DeepCopy() *CAPublicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CAPublicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerSpec) DeepCopyInto(out *CertManagerSpec) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerSpec.
+func (in *CertManagerSpec) DeepCopy() *CertManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DMCryptSpec) DeepCopyInto(out *DMCryptSpec) {
+	*out = *in
+	in.KeySecret.DeepCopyInto(&out.KeySecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DMCryptSpec.
+func (in *DMCryptSpec) DeepCopy() *DMCryptSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DMCryptSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DebugShellStatus) DeepCopyInto(out *DebugShellStatus) {
+	*out = *in
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DebugShellStatus.
+func (in *DebugShellStatus) DeepCopy() *DebugShellStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DebugShellStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmbeddedMetadataResource) DeepCopyInto(out *EmbeddedMetadataResource) {
 	*out = *in
@@ -125,6 +330,26 @@ func (in *EmbeddedService) DeepCopy() *EmbeddedService {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointsStatus) DeepCopyInto(out *EndpointsStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointsStatus.
+func (in *EndpointsStatus) DeepCopy() *EndpointsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdCluster) DeepCopyInto(out *EtcdCluster) {
 	*out = *in
@@ -185,143 +410,1048 @@ func (in *EtcdClusterList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdClusterSpec) DeepCopyInto(out *EtcdClusterSpec) {
+func (in *EtcdClusterSet) DeepCopyInto(out *EtcdClusterSet) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	if in.Options != nil {
-		in, out := &in.Options, &out.Options
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
-	if in.ServiceTemplate != nil {
-		in, out := &in.ServiceTemplate, &out.ServiceTemplate
-		*out = new(EmbeddedService)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.HeadlessServiceTemplate != nil {
-		in, out := &in.HeadlessServiceTemplate, &out.HeadlessServiceTemplate
-		*out = new(EmbeddedMetadataResource)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.PodDisruptionBudgetTemplate != nil {
-		in, out := &in.PodDisruptionBudgetTemplate, &out.PodDisruptionBudgetTemplate
-		*out = new(EmbeddedPodDisruptionBudget)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Storage.DeepCopyInto(&out.Storage)
-	if in.Security != nil {
-		in, out := &in.Security, &out.Security
-		*out = new(SecuritySpec)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSpec.
-func (in *EtcdClusterSpec) DeepCopy() *EtcdClusterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSet.
+func (in *EtcdClusterSet) DeepCopy() *EtcdClusterSet {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdClusterSpec)
+	out := new(EtcdClusterSet)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdClusterSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *EtcdClusterStatus) DeepCopyInto(out *EtcdClusterStatus) {
+func (in *EtcdClusterSetClusterStatus) DeepCopyInto(out *EtcdClusterSetClusterStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterStatus.
-func (in *EtcdClusterStatus) DeepCopy() *EtcdClusterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSetClusterStatus.
+func (in *EtcdClusterSetClusterStatus) DeepCopy() *EtcdClusterSetClusterStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(EtcdClusterStatus)
+	out := new(EtcdClusterSetClusterStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodDisruptionBudgetSpec) DeepCopyInto(out *PodDisruptionBudgetSpec) {
+func (in *EtcdClusterSetList) DeepCopyInto(out *EtcdClusterSetList) {
 	*out = *in
-	if in.MinAvailable != nil {
-		in, out := &in.MinAvailable, &out.MinAvailable
-		*out = new(intstr.IntOrString)
-		**out = **in
-	}
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
-		*out = new(intstr.IntOrString)
-		**out = **in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdClusterSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetSpec.
-func (in *PodDisruptionBudgetSpec) DeepCopy() *PodDisruptionBudgetSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSetList.
+func (in *EtcdClusterSetList) DeepCopy() *EtcdClusterSetList {
 	if in == nil {
 		return nil
 	}
-	out := new(PodDisruptionBudgetSpec)
+	out := new(EtcdClusterSetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdClusterSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodTemplate) DeepCopyInto(out *PodTemplate) {
+func (in *EtcdClusterSetSpec) DeepCopyInto(out *EtcdClusterSetSpec) {
 	*out = *in
-	in.EmbeddedObjectMetadata.DeepCopyInto(&out.EmbeddedObjectMetadata)
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplate.
-func (in *PodTemplate) DeepCopy() *PodTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSetSpec.
+func (in *EtcdClusterSetSpec) DeepCopy() *EtcdClusterSetSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PodTemplate)
+	out := new(EtcdClusterSetSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
+func (in *EtcdClusterSetStatus) DeepCopyInto(out *EtcdClusterSetStatus) {
 	*out = *in
-	out.TLS = in.TLS
+	if in.ClusterStatuses != nil {
+		in, out := &in.ClusterStatuses, &out.ClusterStatuses
+		*out = make([]EtcdClusterSetClusterStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuritySpec.
-func (in *SecuritySpec) DeepCopy() *SecuritySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSetStatus.
+func (in *EtcdClusterSetStatus) DeepCopy() *EtcdClusterSetStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(SecuritySpec)
+	out := new(EtcdClusterSetStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
+func (in *EtcdClusterSpec) DeepCopyInto(out *EtcdClusterSpec) {
 	*out = *in
-	if in.EmptyDir != nil {
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExperimentalOptions != nil {
+		in, out := &in.ExperimentalOptions, &out.ExperimentalOptions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.PodTemplate.DeepCopyInto(&out.PodTemplate)
+	if in.ServiceTemplate != nil {
+		in, out := &in.ServiceTemplate, &out.ServiceTemplate
+		*out = new(EmbeddedService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeadlessServiceTemplate != nil {
+		in, out := &in.HeadlessServiceTemplate, &out.HeadlessServiceTemplate
+		*out = new(EmbeddedMetadataResource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudgetTemplate != nil {
+		in, out := &in.PodDisruptionBudgetTemplate, &out.PodDisruptionBudgetTemplate
+		*out = new(EmbeddedPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.Security != nil {
+		in, out := &in.Security, &out.Security
+		*out = new(SecuritySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RaftSnapshots != nil {
+		in, out := &in.RaftSnapshots, &out.RaftSnapshots
+		*out = new(RaftSnapshotsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestLimits != nil {
+		in, out := &in.RequestLimits, &out.RequestLimits
+		*out = new(RequestLimitsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoDefrag != nil {
+		in, out := &in.AutoDefrag, &out.AutoDefrag
+		*out = new(AutoDefragSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(TracingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OperatorConnection != nil {
+		in, out := &in.OperatorConnection, &out.OperatorConnection
+		*out = new(OperatorConnectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AvailabilityPolicy != nil {
+		in, out := &in.AvailabilityPolicy, &out.AvailabilityPolicy
+		*out = new(AvailabilityPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObservedEndpoints != nil {
+		in, out := &in.ObservedEndpoints, &out.ObservedEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReconcilePeriod != nil {
+		in, out := &in.ReconcilePeriod, &out.ReconcilePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MemberReplacementGracePeriod != nil {
+		in, out := &in.MemberReplacementGracePeriod, &out.MemberReplacementGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.BootstrapTimeout != nil {
+		in, out := &in.BootstrapTimeout, &out.BootstrapTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(NotificationsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterSpec.
+func (in *EtcdClusterSpec) DeepCopy() *EtcdClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdClusterStatus) DeepCopyInto(out *EtcdClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]MemberStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.MemberIdentities != nil {
+		in, out := &in.MemberIdentities, &out.MemberIdentities
+		*out = make([]MemberIdentity, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestLimits != nil {
+		in, out := &in.RequestLimits, &out.RequestLimits
+		*out = new(RequestLimitsStatus)
+		**out = **in
+	}
+	if in.Alarms != nil {
+		in, out := &in.Alarms, &out.Alarms
+		*out = make([]AlarmStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdminAccess != nil {
+		in, out := &in.AdminAccess, &out.AdminAccess
+		*out = new(AdminAccessStatus)
+		**out = **in
+	}
+	if in.CurrentOperation != nil {
+		in, out := &in.CurrentOperation, &out.CurrentOperation
+		*out = new(OperationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastReconcile != nil {
+		in, out := &in.LastReconcile, &out.LastReconcile
+		*out = new(ReconcileSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AppliedGenerations != nil {
+		in, out := &in.AppliedGenerations, &out.AppliedGenerations
+		*out = make([]AppliedGeneration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DebugShell != nil {
+		in, out := &in.DebugShell, &out.DebugShell
+		*out = new(DebugShellStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = new(EndpointsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageMigration != nil {
+		in, out := &in.StorageMigration, &out.StorageMigration
+		*out = new(StorageMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageCapacity != nil {
+		in, out := &in.StorageCapacity, &out.StorageCapacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterStatus.
+func (in *EtcdClusterStatus) DeepCopy() *EtcdClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdClusterTemplate) DeepCopyInto(out *EtcdClusterTemplate) {
+	*out = *in
+	in.EmbeddedObjectMetadata.DeepCopyInto(&out.EmbeddedObjectMetadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdClusterTemplate.
+func (in *EtcdClusterTemplate) DeepCopy() *EtcdClusterTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdClusterTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdPermission) DeepCopyInto(out *EtcdPermission) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdPermission.
+func (in *EtcdPermission) DeepCopy() *EtcdPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRole) DeepCopyInto(out *EtcdRole) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRole.
+func (in *EtcdRole) DeepCopy() *EtcdRole {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRole) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRoleList) DeepCopyInto(out *EtcdRoleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRoleList.
+func (in *EtcdRoleList) DeepCopy() *EtcdRoleList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRoleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRoleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRoleSpec) DeepCopyInto(out *EtcdRoleSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = make([]EtcdPermission, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRoleSpec.
+func (in *EtcdRoleSpec) DeepCopy() *EtcdRoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRoleStatus) DeepCopyInto(out *EtcdRoleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRoleStatus.
+func (in *EtcdRoleStatus) DeepCopy() *EtcdRoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdUser) DeepCopyInto(out *EtcdUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdUser.
+func (in *EtcdUser) DeepCopy() *EtcdUser {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdUserList) DeepCopyInto(out *EtcdUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdUserList.
+func (in *EtcdUserList) DeepCopy() *EtcdUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdUserSpec) DeepCopyInto(out *EtcdUserSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	out.PasswordSecretRef = in.PasswordSecretRef
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdUserSpec.
+func (in *EtcdUserSpec) DeepCopy() *EtcdUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdUserStatus) DeepCopyInto(out *EtcdUserStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdUserStatus.
+func (in *EtcdUserStatus) DeepCopy() *EtcdUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostPathStorageSpec) DeepCopyInto(out *HostPathStorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostPathStorageSpec.
+func (in *HostPathStorageSpec) DeepCopy() *HostPathStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostPathStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InternalCASpec) DeepCopyInto(out *InternalCASpec) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternalCASpec.
+func (in *InternalCASpec) DeepCopy() *InternalCASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InternalCASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWTSpec) DeepCopyInto(out *JWTSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWTSpec.
+func (in *JWTSpec) DeepCopy() *JWTSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JWTSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogShipperSpec) DeepCopyInto(out *LogShipperSpec) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogShipperSpec.
+func (in *LogShipperSpec) DeepCopy() *LogShipperSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogShipperSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggingSpec) DeepCopyInto(out *LoggingSpec) {
+	*out = *in
+	if in.Shipper != nil {
+		in, out := &in.Shipper, &out.Shipper
+		*out = new(LogShipperSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingSpec.
+func (in *LoggingSpec) DeepCopy() *LoggingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberIdentity) DeepCopyInto(out *MemberIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberIdentity.
+func (in *MemberIdentity) DeepCopy() *MemberIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberStatus) DeepCopyInto(out *MemberStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStatus.
+func (in *MemberStatus) DeepCopy() *MemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberVolume) DeepCopyInto(out *MemberVolume) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberVolume.
+func (in *MemberVolume) DeepCopy() *MemberVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationWebhook) DeepCopyInto(out *NotificationWebhook) {
+	*out = *in
+	in.URLSecretRef.DeepCopyInto(&out.URLSecretRef)
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]NotificationEvent, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationWebhook.
+func (in *NotificationWebhook) DeepCopy() *NotificationWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationsSpec) DeepCopyInto(out *NotificationsSpec) {
+	*out = *in
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]NotificationWebhook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationsSpec.
+func (in *NotificationsSpec) DeepCopy() *NotificationsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationStatus) DeepCopyInto(out *OperationStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationStatus.
+func (in *OperationStatus) DeepCopy() *OperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConnectionSpec) DeepCopyInto(out *OperatorConnectionSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConnectionSpec.
+func (in *OperatorConnectionSpec) DeepCopy() *OperatorConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetSpec) DeepCopyInto(out *PodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetSpec.
+func (in *PodDisruptionBudgetSpec) DeepCopy() *PodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplate) DeepCopyInto(out *PodTemplate) {
+	*out = *in
+	in.EmbeddedObjectMetadata.DeepCopyInto(&out.EmbeddedObjectMetadata)
+	if in.CommandPrefix != nil {
+		in, out := &in.CommandPrefix, &out.CommandPrefix
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplate.
+func (in *PodTemplate) DeepCopy() *PodTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RaftSnapshotsSpec) DeepCopyInto(out *RaftSnapshotsSpec) {
+	*out = *in
+	if in.SnapshotCount != nil {
+		in, out := &in.SnapshotCount, &out.SnapshotCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxSnapshots != nil {
+		in, out := &in.MaxSnapshots, &out.MaxSnapshots
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxWALs != nil {
+		in, out := &in.MaxWALs, &out.MaxWALs
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RaftSnapshotsSpec.
+func (in *RaftSnapshotsSpec) DeepCopy() *RaftSnapshotsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RaftSnapshotsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileSummary) DeepCopyInto(out *ReconcileSummary) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Duration = in.Duration
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Skipped != nil {
+		in, out := &in.Skipped, &out.Skipped
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileSummary.
+func (in *ReconcileSummary) DeepCopy() *ReconcileSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestLimitsSpec) DeepCopyInto(out *RequestLimitsSpec) {
+	*out = *in
+	if in.MaxTxnOps != nil {
+		in, out := &in.MaxTxnOps, &out.MaxTxnOps
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRequestBytes != nil {
+		in, out := &in.MaxRequestBytes, &out.MaxRequestBytes
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestLimitsSpec.
+func (in *RequestLimitsSpec) DeepCopy() *RequestLimitsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestLimitsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestLimitsStatus) DeepCopyInto(out *RequestLimitsStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestLimitsStatus.
+func (in *RequestLimitsStatus) DeepCopy() *RequestLimitsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestLimitsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecuritySpec) DeepCopyInto(out *SecuritySpec) {
+	*out = *in
+	in.TLS.DeepCopyInto(&out.TLS)
+	if in.CAPublication != nil {
+		in, out := &in.CAPublication, &out.CAPublication
+		*out = new(CAPublicationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(AuthSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecuritySpec.
+func (in *SecuritySpec) DeepCopy() *SecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageEncryptionSpec) DeepCopyInto(out *StorageEncryptionSpec) {
+	*out = *in
+	if in.DMCrypt != nil {
+		in, out := &in.DMCrypt, &out.DMCrypt
+		*out = new(DMCryptSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageEncryptionSpec.
+func (in *StorageEncryptionSpec) DeepCopy() *StorageEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationStatus) DeepCopyInto(out *StorageMigrationStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationStatus.
+func (in *StorageMigrationStatus) DeepCopy() *StorageMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
+	*out = *in
+	if in.EmptyDir != nil {
 		in, out := &in.EmptyDir, &out.EmptyDir
 		*out = new(corev1.EmptyDirVolumeSource)
 		(*in).DeepCopyInto(*out)
 	}
 	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+	if in.HostPath != nil {
+		in, out := &in.HostPath, &out.HostPath
+		*out = new(HostPathStorageSpec)
+		**out = **in
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]MemberVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(StorageEncryptionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WALVolume != nil {
+		in, out := &in.WALVolume, &out.WALVolume
+		*out = new(WALVolumeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetentionPolicy != nil {
+		in, out := &in.RetentionPolicy, &out.RetentionPolicy
+		*out = new(appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy)
+		**out = **in
+	}
+	if in.VolumePermissions != nil {
+		in, out := &in.VolumePermissions, &out.VolumePermissions
+		*out = new(VolumePermissionsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
@@ -337,6 +1467,16 @@ func (in *StorageSpec) DeepCopy() *StorageSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
 	*out = *in
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InternalCA != nil {
+		in, out := &in.InternalCA, &out.InternalCA
+		*out = new(InternalCASpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
@@ -348,3 +1488,89 @@ func (in *TLSSpec) DeepCopy() *TLSSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingSpec) DeepCopyInto(out *TracingSpec) {
+	*out = *in
+	if in.SamplingRatePerMillion != nil {
+		in, out := &in.SamplingRatePerMillion, &out.SamplingRatePerMillion
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingSpec.
+func (in *TracingSpec) DeepCopy() *TracingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustManagerSpec) DeepCopyInto(out *TrustManagerSpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustManagerSpec.
+func (in *TrustManagerSpec) DeepCopy() *TrustManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumePermissionsSpec) DeepCopyInto(out *VolumePermissionsSpec) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumePermissionsSpec.
+func (in *VolumePermissionsSpec) DeepCopy() *VolumePermissionsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumePermissionsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WALVolumeSpec) DeepCopyInto(out *WALVolumeSpec) {
+	*out = *in
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(corev1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WALVolumeSpec.
+func (in *WALVolumeSpec) DeepCopy() *WALVolumeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WALVolumeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
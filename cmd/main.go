@@ -20,22 +20,31 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
 	"github.com/aenix-io/etcd-operator/internal/controller"
+	"github.com/aenix-io/etcd-operator/internal/defaults"
+	"github.com/aenix-io/etcd-operator/internal/images"
+	"github.com/aenix-io/etcd-operator/internal/importer"
+	"github.com/aenix-io/etcd-operator/internal/maintenance"
+	"github.com/aenix-io/etcd-operator/internal/migrate"
+	"github.com/aenix-io/etcd-operator/internal/notify"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,11 +61,30 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(importer.Main(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate.Main(ctrl.SetupSignalHandler(), os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	maintenanceLimits := maintenance.DefaultLimits()
+	var defragInterval time.Duration
+	var maxParallelSnapshots int
+	var upgradeTimeout time.Duration
+	var backupRequiredNamespaces string
+	imageMirrors := images.Mirrors{}
+	var imageVerificationKey string
+	var minReconcilePeriod, maxReconcilePeriod time.Duration
+	var experimentalOptions bool
+	var defaultsFile string
+	var offline bool
+	var priorityWorkers int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -66,6 +94,39 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&defragInterval, "maintenance-defrag-interval",
+		maintenanceLimits.MinInterval[maintenance.OperationDefragment],
+		"Minimal interval between two defragmentations of the same etcd member.")
+	flag.IntVar(&maxParallelSnapshots, "maintenance-max-parallel-snapshots",
+		maintenanceLimits.MaxParallel[maintenance.OperationSnapshot],
+		"Maximal number of etcd snapshots taken at the same time across all clusters. Zero means no limit.")
+	flag.DurationVar(&upgradeTimeout, "upgrade-rollback-timeout", 0,
+		"Time an updated etcd member may stay not ready before the upgrade is rolled back, e.g. 10m. "+
+			"Zero, the default, disables rollback.")
+	flag.StringVar(&backupRequiredNamespaces, "backup-required-namespace-selector", "",
+		"Label selector of namespaces, e.g. environment=production, whose EtcdClusters are reported "+
+			"by the BackupNotConfigured condition and metric if they have no backup configured.")
+	flag.Var(imageMirrors, "image-mirror",
+		"Registry or repository prefix rewrite source=target applied to images of etcd pods, "+
+			"e.g. quay.io=registry.example.com/quay. May be repeated or comma separated.")
+	flag.StringVar(&imageVerificationKey, "image-verification-public-key", "",
+		"Path to PEM encoded cosign public key. If set, etcd images whose signatures are not made by the key "+
+			"are not rolled out and clusters report them in the ImageRejected condition.")
+	flag.BoolVar(&experimentalOptions, "enable-experimental-options", false,
+		"Admit spec.experimentalOptions of EtcdClusters and pass them to etcd with the --experimental- prefix.")
+	flag.DurationVar(&minReconcilePeriod, "min-reconcile-period", 10*time.Second,
+		"Shortest spec.reconcilePeriod of EtcdClusters, shorter periods are raised to it.")
+	flag.DurationVar(&maxReconcilePeriod, "max-reconcile-period", time.Hour,
+		"Longest spec.reconcilePeriod of EtcdClusters, longer periods are lowered to it. Zero means no limit.")
+	flag.StringVar(&defaultsFile, "defaults-file", "",
+		"Path to YAML file overriding defaulting data embedded in the operator: etcdImage and etcdFlags "+
+			"describing flags of etcd minor versions validated in spec.options.")
+	flag.BoolVar(&offline, "offline", false,
+		"Disable all outbound calls of the operator outside of the Kubernetes API and etcd members, "+
+			"for disconnected environments. Image signature verification and notification webhooks are refused.")
+	flag.IntVar(&priorityWorkers, "priority-reconcile-workers", 0,
+		"Number of workers reconciling degraded EtcdClusters and EtcdClusters without quorum from a queue "+
+			"of their own, ahead of routine resyncs of healthy clusters. Zero, the default, disables the priority queue.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -73,6 +134,35 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	maintenanceLimits.MinInterval[maintenance.OperationDefragment] = defragInterval
+	maintenanceLimits.MaxParallel[maintenance.OperationSnapshot] = maxParallelSnapshots
+	backupRequiredSelector, err := labels.Parse(backupRequiredNamespaces)
+	if err != nil {
+		setupLog.Error(err, "invalid backup required namespace selector")
+		os.Exit(1)
+	}
+	if defaultsFile != "" {
+		if err := defaults.Load(defaultsFile); err != nil {
+			setupLog.Error(err, "cannot load defaults")
+			os.Exit(1)
+		}
+	}
+	if offline && imageVerificationKey != "" {
+		setupLog.Error(nil, "image signature verification looks up signatures in registries and can't be used offline")
+		os.Exit(1)
+	}
+	var imageVerifier images.Verifier
+	if imageVerificationKey != "" {
+		publicKey, err := os.ReadFile(imageVerificationKey)
+		if err != nil {
+			setupLog.Error(err, "cannot read image verification public key")
+			os.Exit(1)
+		}
+		if imageVerifier, err = images.NewCosignVerifier(publicKey, nil); err != nil {
+			setupLog.Error(err, "invalid image verification public key")
+			os.Exit(1)
+		}
+	}
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -122,15 +212,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	// notifications are sent to webhooks outside of the cluster
+	var notifications *notify.Tracker
+	if !offline {
+		notifications = notify.NewTracker()
+	}
+
 	if err = (&controller.EtcdClusterReconciler{
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		Maintenance:              maintenance.NewLimiter(maintenanceLimits),
+		UpgradeTimeout:           upgradeTimeout,
+		BackupRequiredNamespaces: backupRequiredSelector,
+		ImageMirrors:             imageMirrors,
+		ImageVerifier:            imageVerifier,
+		MinReconcilePeriod:       minReconcilePeriod,
+		MaxReconcilePeriod:       maxReconcilePeriod,
+		Notifications:            notifications,
+		Bloat:                    maintenance.NewBloatDetector(),
+		Recorder:                 mgr.GetEventRecorderFor("etcd-operator"),
+		ExperimentalOptions:      experimentalOptions,
+		PriorityWorkers:          priorityWorkers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdCluster")
+		os.Exit(1)
+	}
+	if err = (&controller.EtcdClusterSetReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "EtcdCluster")
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdClusterSet")
+		os.Exit(1)
+	}
+	if err = (&controller.EtcdRoleReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdRole")
+		os.Exit(1)
+	}
+	if err = (&controller.EtcdUserReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdUser")
 		os.Exit(1)
 	}
+	metrics.Registry.MustRegister(controller.NewFleetCollector(mgr.GetClient()))
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err = (&etcdaenixiov1alpha1.EtcdCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		if err = (&etcdaenixiov1alpha1.EtcdCluster{}).SetupWebhookWithManager(mgr, &etcdaenixiov1alpha1.EtcdClusterValidator{
+			ExperimentalOptionsEnabled: experimentalOptions,
+		}); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "EtcdCluster")
 			os.Exit(1)
 		}
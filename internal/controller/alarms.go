@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// alarmConditions maps mirrored alarm types to their conditions.
+var alarmConditions = []struct {
+	alarm     etcdaenixiov1alpha1.AlarmType
+	condition etcdaenixiov1alpha1.EtcdCondType
+}{
+	{etcdaenixiov1alpha1.AlarmTypeNoSpace, etcdaenixiov1alpha1.EtcdConditionAlarmNoSpace},
+	{etcdaenixiov1alpha1.AlarmTypeCorrupt, etcdaenixiov1alpha1.EtcdConditionAlarmCorrupt},
+}
+
+// updateAlarmStatus mirrors alarms raised by members into cluster status and alarm conditions.
+// Status is left unchanged if the cluster can not be reached.
+func (r *EtcdClusterReconciler) updateAlarmStatus(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) {
+	logger := log.FromContext(ctx)
+	if len(getMemberEndpoints(cluster, pods, nil)) == 0 {
+		return
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		logger.Error(err, "cannot create etcd client")
+		return
+	}
+
+	alarms, err := listAlarms(ctx, cli)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd alarms", "error", err.Error())
+		return
+	}
+	setAlarmStatus(cluster, alarms)
+}
+
+// listAlarms returns NOSPACE and CORRUPT alarms raised by members sorted by type and member.
+func listAlarms(ctx context.Context, cli *clientv3.Client) ([]etcdaenixiov1alpha1.AlarmStatus, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	resp, err := cli.AlarmList(reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list alarms: %w", err)
+	}
+	if len(resp.Alarms) == 0 {
+		return nil, nil
+	}
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list members: %w", err)
+	}
+	return getAlarmStatuses(resp.Alarms, members.Members), nil
+}
+
+// getAlarmStatuses converts alarms to status, members are named after the member list.
+func getAlarmStatuses(alarms []*etcdserverpb.AlarmMember, members []*etcdserverpb.Member) []etcdaenixiov1alpha1.AlarmStatus {
+	names := make(map[uint64]string, len(members))
+	for _, member := range members {
+		names[member.ID] = member.Name
+	}
+	var statuses []etcdaenixiov1alpha1.AlarmStatus
+	for _, alarm := range alarms {
+		var alarmType etcdaenixiov1alpha1.AlarmType
+		switch alarm.Alarm {
+		case etcdserverpb.AlarmType_NOSPACE:
+			alarmType = etcdaenixiov1alpha1.AlarmTypeNoSpace
+		case etcdserverpb.AlarmType_CORRUPT:
+			alarmType = etcdaenixiov1alpha1.AlarmTypeCorrupt
+		default:
+			continue
+		}
+		statuses = append(statuses, etcdaenixiov1alpha1.AlarmStatus{
+			Type:     alarmType,
+			MemberID: strconv.FormatUint(alarm.MemberID, 16),
+			Member:   names[alarm.MemberID],
+		})
+	}
+	slices.SortFunc(statuses, func(a, b etcdaenixiov1alpha1.AlarmStatus) int {
+		return cmp.Or(strings.Compare(string(a.Type), string(b.Type)), strings.Compare(a.MemberID, b.MemberID))
+	})
+	return statuses
+}
+
+// setAlarmStatus sets status.alarms and the condition of every mirrored alarm type.
+func setAlarmStatus(cluster *etcdaenixiov1alpha1.EtcdCluster, alarms []etcdaenixiov1alpha1.AlarmStatus) {
+	cluster.Status.Alarms = alarms
+	for _, ac := range alarmConditions {
+		members := alarmMembers(alarms, ac.alarm)
+		if len(members) == 0 {
+			factory.SetCondition(cluster, factory.NewCondition(ac.condition).
+				WithStatus(false).
+				WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeNoAlarm)).
+				WithMessage(string(etcdaenixiov1alpha1.EtcdAlarmCondNegMessage)).
+				Complete())
+			continue
+		}
+		factory.SetCondition(cluster, factory.NewCondition(ac.condition).
+			WithStatus(true).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeAlarmActive)).
+			WithMessage(fmt.Sprintf("members %s raised the %s alarm", strings.Join(members, ", "), ac.alarm)).
+			Complete())
+	}
+}
+
+// alarmMembers returns names of members which raised the alarm, unknown members are identified by their ID.
+func alarmMembers(alarms []etcdaenixiov1alpha1.AlarmStatus, alarmType etcdaenixiov1alpha1.AlarmType) []string {
+	var members []string
+	for _, alarm := range alarms {
+		if alarm.Type != alarmType {
+			continue
+		}
+		if alarm.Member != "" {
+			members = append(members, alarm.Member)
+		} else {
+			members = append(members, alarm.MemberID)
+		}
+	}
+	return members
+}
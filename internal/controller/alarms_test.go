@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Alarms", func() {
+	It("should mirror NOSPACE and CORRUPT alarms of known and unknown members", func() {
+		alarms := getAlarmStatuses([]*etcdserverpb.AlarmMember{
+			{MemberID: 0x2b, Alarm: etcdserverpb.AlarmType_NOSPACE},
+			{MemberID: 0x1a, Alarm: etcdserverpb.AlarmType_NOSPACE},
+			{MemberID: 0x4d, Alarm: etcdserverpb.AlarmType_CORRUPT},
+			{MemberID: 0x1a, Alarm: etcdserverpb.AlarmType_NONE},
+		}, []*etcdserverpb.Member{{ID: 0x1a, Name: "test-0"}, {ID: 0x2b, Name: "test-1"}})
+		Expect(alarms).To(Equal([]etcdaenixiov1alpha1.AlarmStatus{
+			{Type: etcdaenixiov1alpha1.AlarmTypeCorrupt, MemberID: "4d"},
+			{Type: etcdaenixiov1alpha1.AlarmTypeNoSpace, MemberID: "1a", Member: "test-0"},
+			{Type: etcdaenixiov1alpha1.AlarmTypeNoSpace, MemberID: "2b", Member: "test-1"},
+		}))
+
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setAlarmStatus(cluster, alarms)
+		Expect(cluster.Status.Alarms).To(Equal(alarms))
+		noSpace := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionAlarmNoSpace)
+		Expect(noSpace.Status).To(Equal(metav1.ConditionTrue))
+		Expect(noSpace.Message).To(Equal("members test-0, test-1 raised the NOSPACE alarm"))
+		message, corrupted := getDataCorruption(cluster)
+		Expect(corrupted).To(BeTrue())
+		Expect(message).To(Equal("members 4d raised the CORRUPT alarm"))
+
+		By("clearing disarmed alarms", func() {
+			setAlarmStatus(cluster, nil)
+			Expect(cluster.Status.Alarms).To(BeEmpty())
+			corrupt := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionAlarmCorrupt)
+			Expect(corrupt.Status).To(Equal(metav1.ConditionFalse))
+			Expect(corrupt.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeNoAlarm)))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// maxAppliedGenerations is the number of entries kept in status.appliedGenerations.
+const maxAppliedGenerations = 10
+
+// recordAppliedGeneration appends the generation of the cluster to status.appliedGenerations once it is applied
+// to member objects. Generations already recorded are left as they are, the oldest entries are dropped.
+func recordAppliedGeneration(cluster *etcdaenixiov1alpha1.EtcdCluster, now time.Time) {
+	history := cluster.Status.AppliedGenerations
+	if len(history) > 0 && history[len(history)-1].Generation >= cluster.Generation {
+		return
+	}
+	entry := etcdaenixiov1alpha1.AppliedGeneration{
+		Generation: cluster.Generation,
+		Time:       metav1.NewTime(now),
+		Replicas:   ptr.Deref(cluster.Spec.Replicas, 0),
+		Image:      cluster.EtcdImage(),
+		Storage:    describeStorage(&cluster.Spec.Storage),
+		Changes:    "first recorded generation",
+	}
+	if len(history) > 0 {
+		entry.Changes = describeAppliedChanges(&history[len(history)-1], &entry)
+	}
+	history = append(history, entry)
+	cluster.Status.AppliedGenerations = history[max(len(history)-maxAppliedGenerations, 0):]
+}
+
+// describeStorage summarizes data volumes of members.
+func describeStorage(storage *etcdaenixiov1alpha1.StorageSpec) string {
+	switch {
+	case storage.EmptyDir != nil:
+		description := "emptyDir"
+		if storage.IsMemory() {
+			description += " Memory"
+		}
+		if storage.EmptyDir.SizeLimit != nil {
+			description += " " + storage.EmptyDir.SizeLimit.String()
+		}
+		return description
+	case storage.HostPath != nil:
+		return "hostPath"
+	}
+	claim := storage.VolumeClaimTemplate.Spec
+	description := "volumeClaim " + claim.Resources.Requests.Storage().String()
+	if claim.StorageClassName != nil {
+		description += " " + *claim.StorageClassName
+	}
+	return description
+}
+
+// describeAppliedChanges summarizes differences of recorded fields between two applied generations.
+func describeAppliedChanges(previous, current *etcdaenixiov1alpha1.AppliedGeneration) string {
+	var changes []string
+	if previous.Replicas != current.Replicas {
+		changes = append(changes, fmt.Sprintf("replicas %d -> %d", previous.Replicas, current.Replicas))
+	}
+	if previous.Image != current.Image {
+		changes = append(changes, fmt.Sprintf("image %s -> %s", previous.Image, current.Image))
+	}
+	if previous.Storage != current.Storage {
+		changes = append(changes, fmt.Sprintf("storage %s -> %s", previous.Storage, current.Storage))
+	}
+	if len(changes) == 0 {
+		return "other spec fields"
+	}
+	return strings.Join(changes, "; ")
+}
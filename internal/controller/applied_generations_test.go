@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Applied generations", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Generation: 1},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				Storage: etcdaenixiov1alpha1.StorageSpec{
+					VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							Resources: corev1.VolumeResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("4Gi")},
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("should summarize changes of recorded fields", func() {
+		recordAppliedGeneration(cluster, now)
+		Expect(cluster.Status.AppliedGenerations).To(Equal([]etcdaenixiov1alpha1.AppliedGeneration{{
+			Generation: 1,
+			Time:       metav1.NewTime(now),
+			Replicas:   3,
+			Image:      etcdaenixiov1alpha1.DefaultEtcdImage,
+			Storage:    "volumeClaim 4Gi",
+			Changes:    "first recorded generation",
+		}}))
+
+		recordAppliedGeneration(cluster, now.Add(time.Minute))
+		Expect(cluster.Status.AppliedGenerations).To(HaveLen(1))
+
+		cluster.Generation = 2
+		cluster.Spec.Replicas = ptr.To(int32(5))
+		cluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("8Gi")
+		recordAppliedGeneration(cluster, now.Add(time.Minute))
+		Expect(cluster.Status.AppliedGenerations[1].Changes).To(Equal("replicas 3 -> 5; storage volumeClaim 4Gi -> volumeClaim 8Gi"))
+
+		cluster.Generation = 3
+		recordAppliedGeneration(cluster, now.Add(2*time.Minute))
+		Expect(cluster.Status.AppliedGenerations[2].Changes).To(Equal("other spec fields"))
+	})
+
+	It("should keep the latest entries only", func() {
+		for generation := range int64(maxAppliedGenerations + 2) {
+			cluster.Generation = generation + 1
+			recordAppliedGeneration(cluster, now)
+		}
+		history := cluster.Status.AppliedGenerations
+		Expect(history).To(HaveLen(maxAppliedGenerations))
+		Expect(history[0].Generation).To(Equal(int64(3)))
+		Expect(history[maxAppliedGenerations-1].Generation).To(Equal(int64(maxAppliedGenerations + 2)))
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	eventReasonAuthEnabled  = "AuthEnabled"
+	eventReasonAuthDisabled = "AuthDisabled"
+	// rootRole is the etcd role granting all permissions, etcd requires the root user to have it.
+	rootRole = "root"
+)
+
+// ensureRootCredentials generates the root credentials secret once authentication is requested. The password is
+// never regenerated, so a secret deleted while authentication is enabled is reported instead of locking
+// the operator out of etcd.
+func (r *EtcdClusterReconciler) ensureRootCredentials(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) error {
+	if !cluster.IsAuthEnabled() {
+		return nil
+	}
+	name := factory.GetRootCredentialsSecretName(cluster)
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, &corev1.Secret{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("cannot get secret %s: %w", name, err)
+	}
+	if cluster.Status.AuthEnabled {
+		return fmt.Errorf("secret %s is missing, etcd authentication is enabled with the root password it held", name)
+	}
+	secret, err := factory.NewRootCredentialsSecret(cluster)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(cluster, secret, r.Scheme); err != nil {
+		return fmt.Errorf("cannot set controller reference: %w", err)
+	}
+	if err := r.Create(ctx, secret); err != nil {
+		return fmt.Errorf("cannot create secret %s: %w", name, err)
+	}
+	recordAction(ctx, "generated root credentials in secret %s", name)
+	return nil
+}
+
+// reconcileAuth enables authentication of a ready cluster requesting it: the root user with the password of
+// the root credentials secret and the root role are added, then authentication is enabled. Authentication
+// enabled by the operator is disabled again once it is no longer requested. Users added by others are kept.
+func (r *EtcdClusterReconciler) reconcileAuth(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) error {
+	enabled := cluster.IsAuthEnabled()
+	if enabled == cluster.Status.AuthEnabled {
+		return nil
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		return fmt.Errorf("cannot create etcd client: %w", err)
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+
+	if !enabled {
+		if _, err := cli.AuthDisable(reqCtx); err != nil {
+			return fmt.Errorf("cannot disable authentication: %w", err)
+		}
+		cluster.Status.AuthEnabled = false
+		log.FromContext(ctx).Info("etcd authentication disabled")
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonAuthDisabled, "Authentication of etcd users was disabled")
+		recordAction(ctx, "disabled etcd authentication")
+		return nil
+	}
+
+	status, err := cli.AuthStatus(reqCtx)
+	if err != nil {
+		return fmt.Errorf("cannot get authentication status: %w", err)
+	}
+	if !status.Enabled {
+		_, password, err := factory.GetEtcdCredentials(ctx, cluster, r.Client)
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			return fmt.Errorf("secret %s is not created yet", factory.GetRootCredentialsSecretName(cluster))
+		}
+		if _, err := cli.UserAdd(reqCtx, factory.RootUser, password); err != nil {
+			if !errors.Is(err, rpctypes.ErrUserAlreadyExist) {
+				return fmt.Errorf("cannot add root user: %w", err)
+			}
+			// the user may be left by an attempt interrupted before authentication was enabled
+			if _, err := cli.UserChangePassword(reqCtx, factory.RootUser, password); err != nil {
+				return fmt.Errorf("cannot set password of root user: %w", err)
+			}
+		}
+		if _, err := cli.RoleAdd(reqCtx, rootRole); err != nil && !errors.Is(err, rpctypes.ErrRoleAlreadyExist) {
+			return fmt.Errorf("cannot add root role: %w", err)
+		}
+		if _, err := cli.UserGrantRole(reqCtx, factory.RootUser, rootRole); err != nil {
+			return fmt.Errorf("cannot grant root role: %w", err)
+		}
+		if _, err := cli.AuthEnable(reqCtx); err != nil {
+			return fmt.Errorf("cannot enable authentication: %w", err)
+		}
+		recordAction(ctx, "enabled etcd authentication")
+	}
+	cluster.Status.AuthEnabled = true
+	log.FromContext(ctx).Info("etcd authentication enabled")
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonAuthEnabled, fmt.Sprintf(
+		"Authentication of etcd users was enabled, the root password is kept in secret %s",
+		factory.GetRootCredentialsSecretName(cluster)))
+	return nil
+}
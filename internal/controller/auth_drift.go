@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const eventReasonUnmanagedAuthPruned = "UnmanagedAuthPruned"
+
+// declaredAuth is the set of etcd users and roles EtcdUsers and EtcdRoles declare in a cluster.
+type declaredAuth struct {
+	users map[string]bool
+	roles map[string]bool
+}
+
+// updateAuthDriftCondition compares etcd users and roles of the cluster with EtcdUsers and EtcdRoles referring to it.
+// Users and roles no resource declares are reported in the AuthDrift condition, or deleted if the cluster prunes
+// them. The root user and role are never touched, and clusters no EtcdUser or EtcdRole refers to are not checked,
+// so users managed by other means are left alone.
+func (r *EtcdClusterReconciler) updateAuthDriftCondition(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) {
+	logger := log.FromContext(ctx)
+	declared, err := r.getDeclaredAuth(ctx, cluster)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd users and roles", "error", err.Error())
+		return
+	}
+	if declared == nil {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionAuthDrift)
+		return
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		logger.V(2).Info("cannot connect to check etcd users and roles", "error", err.Error())
+		return
+	}
+	users, roles, err := getUnmanagedAuth(ctx, cli, declared)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd users and roles", "error", err.Error())
+		return
+	}
+	if cluster.PrunesUnmanagedAuth() {
+		users, roles = r.pruneUnmanagedAuth(ctx, cluster, cli, users, roles)
+	}
+	if len(users) == 0 && len(roles) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionAuthDrift)
+		return
+	}
+	var unmanaged []string
+	if len(users) > 0 {
+		unmanaged = append(unmanaged, "users "+strings.Join(users, ", "))
+	}
+	if len(roles) > 0 {
+		unmanaged = append(unmanaged, "roles "+strings.Join(roles, ", "))
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionAuthDrift).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeUnmanagedAuth)).
+		WithMessage(fmt.Sprintf("Etcd %s are declared by no EtcdUser or EtcdRole", strings.Join(unmanaged, " and "))).
+		Complete())
+}
+
+// getDeclaredAuth returns users and roles EtcdUsers and EtcdRoles declare in the cluster, including users and roles
+// they still have to delete after they were renamed or moved to another cluster. It returns nil if no EtcdUser
+// or EtcdRole refers to the cluster.
+func (r *EtcdClusterReconciler) getDeclaredAuth(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (*declaredAuth, error) {
+	users := &etcdaenixiov1alpha1.EtcdUserList{}
+	if err := r.List(ctx, users, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, err
+	}
+	roles := &etcdaenixiov1alpha1.EtcdRoleList{}
+	if err := r.List(ctx, roles, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, err
+	}
+	declared := &declaredAuth{users: map[string]bool{}, roles: map[string]bool{}}
+	for i := range users.Items {
+		user := &users.Items[i]
+		if user.Spec.ClusterRef.Name == cluster.Name {
+			declared.users[user.EtcdUserName()] = true
+		}
+		if user.Status.Cluster == cluster.Name && user.Status.UserName != "" {
+			declared.users[user.Status.UserName] = true
+		}
+	}
+	for i := range roles.Items {
+		role := &roles.Items[i]
+		if role.Spec.ClusterRef.Name == cluster.Name {
+			declared.roles[role.EtcdRoleName()] = true
+		}
+		if role.Status.Cluster == cluster.Name && role.Status.RoleName != "" {
+			declared.roles[role.Status.RoleName] = true
+		}
+	}
+	if len(declared.users) == 0 && len(declared.roles) == 0 {
+		return nil, nil
+	}
+	return declared, nil
+}
+
+// getUnmanagedAuth returns users and roles of the cluster which are neither declared nor the root user and role.
+func getUnmanagedAuth(ctx context.Context, cli *clientv3.Client, declared *declaredAuth) (users, roles []string, err error) {
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	userList, err := cli.UserList(reqCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	roleList, err := cli.RoleList(reqCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return getUnmanaged(userList.Users, declared.users, factory.RootUser),
+		getUnmanaged(roleList.Roles, declared.roles, rootRole), nil
+}
+
+// getUnmanaged returns live names which are neither declared nor the root name.
+func getUnmanaged(live []string, declared map[string]bool, root string) []string {
+	var unmanaged []string
+	for _, name := range live {
+		if name != root && !declared[name] {
+			unmanaged = append(unmanaged, name)
+		}
+	}
+	return unmanaged
+}
+
+// pruneUnmanagedAuth deletes the users and roles from the cluster and returns those which could not be deleted.
+func (r *EtcdClusterReconciler) pruneUnmanagedAuth(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	cli *clientv3.Client,
+	users, roles []string,
+) (remainingUsers, remainingRoles []string) {
+	logger := log.FromContext(ctx)
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	var pruned []string
+	for _, name := range users {
+		if _, err := cli.UserDelete(reqCtx, name); err != nil && !errors.Is(err, rpctypes.ErrUserNotFound) {
+			logger.Error(err, "cannot prune etcd user", "user", name)
+			remainingUsers = append(remainingUsers, name)
+			continue
+		}
+		pruned = append(pruned, "user "+name)
+	}
+	for _, name := range roles {
+		if _, err := cli.RoleDelete(reqCtx, name); err != nil && !errors.Is(err, rpctypes.ErrRoleNotFound) {
+			logger.Error(err, "cannot prune etcd role", "role", name)
+			remainingRoles = append(remainingRoles, name)
+			continue
+		}
+		pruned = append(pruned, "role "+name)
+	}
+	if len(pruned) > 0 {
+		logger.Info("pruned etcd users and roles no EtcdUser or EtcdRole declares", "pruned", pruned)
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonUnmanagedAuthPruned,
+			fmt.Sprintf("Deleted etcd %s declared by no EtcdUser or EtcdRole", strings.Join(pruned, ", ")))
+		recordAction(ctx, "pruned etcd users and roles declared by no EtcdUser or EtcdRole")
+	}
+	return remainingUsers, remainingRoles
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Auth drift", func() {
+	It("should report users and roles which are neither declared nor root", func() {
+		declared := map[string]bool{"app": true}
+		Expect(getUnmanaged([]string{"app", "manual", "root"}, declared, "root")).To(Equal([]string{"manual"}))
+		Expect(getUnmanaged([]string{"app", "root"}, declared, "root")).To(BeEmpty())
+	})
+
+	It("should collect users and roles EtcdUsers and EtcdRoles declare in the cluster", func(ctx SpecContext) {
+		r := &EtcdClusterReconciler{Client: k8sClient}
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-auth-drift-"}}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name}}
+
+		declared, err := r.getDeclaredAuth(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(declared).To(BeNil())
+
+		user := &etcdaenixiov1alpha1.EtcdUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: ns.Name},
+			Spec: etcdaenixiov1alpha1.EtcdUserSpec{
+				ClusterRef:        corev1.LocalObjectReference{Name: "test"},
+				UserName:          "renamed",
+				PasswordSecretRef: corev1.LocalObjectReference{Name: "app-password"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		// the previous user is still to be deleted from the cluster
+		user.Status.Cluster, user.Status.UserName = "test", "app"
+		Expect(k8sClient.Status().Update(ctx, user)).To(Succeed())
+		for _, role := range []*etcdaenixiov1alpha1.EtcdRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: ns.Name},
+				Spec:       etcdaenixiov1alpha1.EtcdRoleSpec{ClusterRef: corev1.LocalObjectReference{Name: "test"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: ns.Name},
+				Spec:       etcdaenixiov1alpha1.EtcdRoleSpec{ClusterRef: corev1.LocalObjectReference{Name: "other"}},
+			},
+		} {
+			Expect(k8sClient.Create(ctx, role)).To(Succeed())
+		}
+
+		declared, err = r.getDeclaredAuth(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(declared).To(Equal(&declaredAuth{
+			users: map[string]bool{"app": true, "renamed": true},
+			roles: map[string]bool{"app": true},
+		}))
+	})
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Etcd authentication", func() {
+	var (
+		reconciler *EtcdClusterReconciler
+		config     clientv3.Config
+		cluster    *etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-auth-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		reconciler = &EtcdClusterReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			EtcdClientFactory: EtcdClientFactoryFunc(func(c clientv3.Config) (*clientv3.Client, error) {
+				config = c
+				return clientv3.NewCtxClient(ctx), nil
+			}),
+		}
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name, UID: types.UID(uuid.NewString())},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Security: &etcdaenixiov1alpha1.SecuritySpec{Auth: &etcdaenixiov1alpha1.AuthSpec{Enabled: true}},
+			},
+		}
+	})
+
+	It("should generate root credentials the operator authenticates with", func(ctx SpecContext) {
+		Expect(reconciler.ensureRootCredentials(ctx, cluster)).To(Succeed())
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: cluster.Namespace, Name: "test-root-credentials"}
+		Expect(k8sClient.Get(ctx, key, secret)).To(Succeed())
+		Expect(secret.Type).To(Equal(corev1.SecretTypeBasicAuth))
+		Expect(string(secret.Data[corev1.BasicAuthUsernameKey])).To(Equal(factory.RootUser))
+		Expect(secret.Data[corev1.BasicAuthPasswordKey]).NotTo(BeEmpty())
+
+		// the password is kept
+		Expect(reconciler.ensureRootCredentials(ctx, cluster)).To(Succeed())
+		kept := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, key, kept)).To(Succeed())
+		Expect(kept.Data).To(Equal(secret.Data))
+
+		_, err := reconciler.newEtcdClient(ctx, cluster, []string{"http://test-0.test-headless.ns.svc:2379"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Username).To(Equal(factory.RootUser))
+		Expect(config.Password).To(Equal(string(secret.Data[corev1.BasicAuthPasswordKey])))
+	})
+
+	It("should not regenerate the password while authentication is enabled", func(ctx SpecContext) {
+		cluster.Status.AuthEnabled = true
+		Expect(reconciler.ensureRootCredentials(ctx, cluster)).
+			To(MatchError(ContainSubstring("secret test-root-credentials is missing")))
+		_, err := reconciler.newEtcdClient(ctx, cluster, []string{"http://test-0.test-headless.ns.svc:2379"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// clusterBackupConfigured reports clusters in namespaces requiring backups, so unprotected clusters can be alerted on.
+var clusterBackupConfigured = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "etcd_operator_cluster_backup_configured",
+	Help: "Whether the EtcdCluster in a namespace requiring backups has a backup configured (1) or not (0).",
+}, []string{"namespace", "name"})
+
+func init() {
+	metrics.Registry.MustRegister(clusterBackupConfigured)
+}
+
+// isBackupConfigured returns true if the cluster is backed up. The operator has no backup schedule of its own yet,
+// so only clusters annotated as backed up by other tooling are considered protected.
+func isBackupConfigured(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Annotations[etcdaenixiov1alpha1.BackupConfiguredAnnotation] == "true"
+}
+
+// isBackupRequired returns true if the namespace of the cluster matches BackupRequiredNamespaces.
+func (r *EtcdClusterReconciler) isBackupRequired(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (bool, error) {
+	if r.BackupRequiredNamespaces == nil || r.BackupRequiredNamespaces.Empty() {
+		return false, nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster.Namespace}, namespace); err != nil {
+		return false, fmt.Errorf("cannot get cluster namespace: %w", err)
+	}
+	return r.BackupRequiredNamespaces.Matches(labels.Set(namespace.Labels)), nil
+}
+
+// updateBackupCondition reflects missing backup of clusters in namespaces requiring backups in the
+// BackupNotConfigured condition and metric. Clusters in other namespaces have neither.
+func (r *EtcdClusterReconciler) updateBackupCondition(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	required, err := r.isBackupRequired(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if !required {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured)
+		forgetBackupMetric(cluster.Namespace, cluster.Name)
+		return nil
+	}
+
+	configured := isBackupConfigured(cluster)
+	reason := etcdaenixiov1alpha1.EtcdCondTypeNoBackupSchedule
+	message := etcdaenixiov1alpha1.EtcdBackupCondPosMessage
+	value := float64(0)
+	if configured {
+		reason = etcdaenixiov1alpha1.EtcdCondTypeBackupConfigured
+		message = etcdaenixiov1alpha1.EtcdBackupCondNegMessage
+		value = 1
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured).
+		WithStatus(!configured).
+		WithReason(string(reason)).
+		WithMessage(string(message)).
+		Complete())
+	clusterBackupConfigured.WithLabelValues(cluster.Namespace, cluster.Name).Set(value)
+	return nil
+}
+
+func forgetBackupMetric(namespace, name string) {
+	clusterBackupConfigured.DeleteLabelValues(namespace, name)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Backup condition", func() {
+	var (
+		reconciler *EtcdClusterReconciler
+		cluster    *etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-backup-",
+				Labels:       map[string]string{"environment": "production"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		reconciler = &EtcdClusterReconciler{
+			Client:                   k8sClient,
+			Scheme:                   k8sClient.Scheme(),
+			BackupRequiredNamespaces: labels.SelectorFromSet(labels.Set{"environment": "production"}),
+		}
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name}}
+		DeferCleanup(forgetBackupMetric, ns.Name, "test")
+	})
+
+	It("should report clusters without backup in selected namespaces", func(ctx SpecContext) {
+		Expect(reconciler.updateBackupCondition(ctx, cluster)).To(Succeed())
+		condition := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeNoBackupSchedule)))
+		Expect(testutil.ToFloat64(clusterBackupConfigured.WithLabelValues(cluster.Namespace, cluster.Name))).To(BeZero())
+	})
+
+	It("should not report clusters backed up by other tooling", func(ctx SpecContext) {
+		cluster.Annotations = map[string]string{etcdaenixiov1alpha1.BackupConfiguredAnnotation: "true"}
+		Expect(reconciler.updateBackupCondition(ctx, cluster)).To(Succeed())
+		condition := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(testutil.ToFloat64(clusterBackupConfigured.WithLabelValues(cluster.Namespace, cluster.Name))).To(Equal(float64(1)))
+	})
+
+	It("should not report clusters in other namespaces", func(ctx SpecContext) {
+		Expect(reconciler.updateBackupCondition(ctx, cluster)).To(Succeed())
+		reconciler.BackupRequiredNamespaces = labels.SelectorFromSet(labels.Set{"environment": "staging"})
+		Expect(reconciler.updateBackupCondition(ctx, cluster)).To(Succeed())
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured)).To(BeNil())
+		Expect(testutil.CollectAndCount(clusterBackupConfigured)).To(BeZero())
+	})
+})
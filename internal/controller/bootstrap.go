@@ -0,0 +1,263 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	eventReasonBootstrapFailed  = "BootstrapFailed"
+	eventReasonBootstrapRetried = "BootstrapRetried"
+	// defaultBootstrapTimeout is long enough for images to be pulled and volumes to be provisioned.
+	defaultBootstrapTimeout = 30 * time.Minute
+	// bootstrapRetryPollInterval is how often deletion of member objects is checked during a retry of the bootstrap.
+	bootstrapRetryPollInterval = 5 * time.Second
+)
+
+// isBootstrapping returns true until members of the cluster establish the first quorum.
+func isBootstrapping(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+	return ready != nil && ready.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum)
+}
+
+// getBootstrapTimeout returns spec.bootstrapTimeout of the cluster, the default if it is not set.
+func getBootstrapTimeout(cluster *etcdaenixiov1alpha1.EtcdCluster) time.Duration {
+	if cluster.Spec.BootstrapTimeout == nil {
+		return defaultBootstrapTimeout
+	}
+	return cluster.Spec.BootstrapTimeout.Duration
+}
+
+// checkBootstrap reports clusters whose members did not establish the first quorum within spec.bootstrapTimeout
+// in the BootstrapFailed condition and retries their bootstrap requested by annotation. Returns true while
+// reconciliation must stop there, so objects of the failed cluster are not churned, and the duration after which
+// the bootstrap should be checked again.
+func (r *EtcdClusterReconciler) checkBootstrap(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (bool, time.Duration, error) {
+	if cluster.Annotations[etcdaenixiov1alpha1.RetryBootstrapAnnotation] == "true" {
+		return r.retryBootstrap(ctx, cluster)
+	}
+	if !isBootstrapping(cluster) {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionBootstrapFailed)
+		return false, 0, nil
+	}
+	if meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionBootstrapFailed) {
+		recordSkipped(ctx, "update of cluster objects: bootstrap failed, waiting for retry")
+		return true, 0, nil
+	}
+	timeout := getBootstrapTimeout(cluster)
+	if timeout == 0 {
+		return false, 0, nil
+	}
+	ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+	if remaining := ready.LastTransitionTime.Add(timeout).Sub(r.getClock().Now()); remaining > 0 {
+		return false, remaining, nil
+	}
+
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return false, 0, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	message := fmt.Sprintf("Members did not establish the first quorum within %s: %s; "+
+		"fix the spec and set the %s annotation to \"true\" to delete member objects and bootstrap the cluster again",
+		timeout, strings.Join(getBootstrapBlockers(pods), "; "), etcdaenixiov1alpha1.RetryBootstrapAnnotation)
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionBootstrapFailed).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeBootstrapTimedOut)).
+		WithMessage(message).
+		Complete())
+	setHealthConditions(cluster, false)
+	log.FromContext(ctx).Info("bootstrap of the cluster timed out", "timeout", timeout)
+	r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonBootstrapFailed, message)
+	recordAction(ctx, "stopped updating cluster objects: bootstrap timed out after %s", timeout)
+	return true, 0, nil
+}
+
+// getBootstrapBlockers describes why members do not run: pods not scheduled, failing or not ready.
+func getBootstrapBlockers(pods []corev1.Pod) []string {
+	failures := map[string]string{}
+	for _, failure := range getMemberFailures(pods) {
+		failures[failure.podName] = failure.message
+	}
+	var blockers []string
+	for i := range pods {
+		pod := &pods[i]
+		if message, failed := failures[pod.Name]; failed {
+			blockers = append(blockers, message)
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+				blockers = append(blockers, fmt.Sprintf("pod %s is not scheduled: %s", pod.Name, cond.Message))
+			}
+		}
+	}
+	if len(blockers) == 0 {
+		return []string{"no member pod failure is detected, see events of the StatefulSet and member pods"}
+	}
+	return blockers
+}
+
+// retryBootstrap deletes member objects of a cluster that never established the first quorum. Once they are gone,
+// the retry annotation is removed and conditions are reset, so the cluster is bootstrapped from scratch and
+// the bootstrap timeout starts over. The annotation is removed without deleting anything from other clusters.
+func (r *EtcdClusterReconciler) retryBootstrap(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (bool, time.Duration, error) {
+	if !isBootstrapping(cluster) {
+		r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonBootstrapRetried,
+			"Bootstrap is not retried, members have established a quorum and member objects keep data of the cluster")
+		return false, 0, r.removeRetryBootstrapAnnotation(ctx, cluster)
+	}
+	deleted, err := r.deleteMemberObjects(ctx, cluster)
+	if err != nil {
+		return false, 0, err
+	}
+	if !deleted {
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionBootstrapFailed).
+			WithStatus(true).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeRetryingBootstrap)).
+			WithMessage("Member objects are deleted to bootstrap the cluster again").
+			Complete())
+		return true, bootstrapRetryPollInterval, nil
+	}
+	if err := r.removeRetryBootstrapAnnotation(ctx, cluster); err != nil {
+		return false, 0, err
+	}
+	for _, condType := range []string{
+		etcdaenixiov1alpha1.EtcdConditionBootstrapFailed,
+		etcdaenixiov1alpha1.EtcdConditionInitialized,
+		etcdaenixiov1alpha1.EtcdConditionReady,
+	} {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, condType)
+	}
+	factory.FillConditions(cluster)
+	cluster.Status.ClusterID = ""
+	cluster.Status.Members = nil
+	cluster.Status.MemberIdentities = nil
+	log.FromContext(ctx).Info("member objects are deleted, bootstrapping the cluster again")
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonBootstrapRetried,
+		"Member objects were deleted, the cluster is bootstrapped again")
+	recordAction(ctx, "retried bootstrap of the cluster")
+	return false, 0, nil
+}
+
+// deleteMemberObjects deletes the StatefulSet, member pods, their volume claims and the cluster state ConfigMap.
+// Returns true once the StatefulSet, pods and claims are gone.
+func (r *EtcdClusterReconciler) deleteMemberObjects(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (bool, error) {
+	deleted := true
+	sts := &appsv1.StatefulSet{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts)
+	if client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("cannot get statefulset: %w", err)
+	}
+	if err == nil {
+		deleted = false
+		if sts.DeletionTimestamp.IsZero() {
+			if err := r.Delete(ctx, sts, client.PropagationPolicy(metav1.DeletePropagationForeground)); client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("cannot delete statefulset: %w", err)
+			}
+			recordAction(ctx, "deleted statefulset %s to retry bootstrap", sts.Name)
+		}
+	}
+
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if ordinal, err := podOrdinal(pod.Name); err != nil || factory.GetMemberPodName(cluster, ordinal) != pod.Name {
+			continue
+		}
+		deleted = false
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("cannot delete member pod %s: %w", pod.Name, err)
+		}
+		recordAction(ctx, "deleted member pod %s to retry bootstrap", pod.Name)
+	}
+
+	claims := &corev1.PersistentVolumeClaimList{}
+	err = r.List(ctx, claims,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("cannot list member PVCs: %w", err)
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if _, _, found := getClaimMember(cluster, claim.Name); !found {
+			continue
+		}
+		deleted = false
+		if !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+			return false, fmt.Errorf("cannot delete member PVC %s: %w", claim.Name, err)
+		}
+		recordAction(ctx, "deleted volume claim %s to retry bootstrap", claim.Name)
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: cluster.Namespace,
+		Name:      factory.GetClusterStateConfigMapName(cluster),
+	}}
+	if err := r.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("cannot delete cluster state configmap: %w", err)
+	}
+	return deleted, nil
+}
+
+// removeRetryBootstrapAnnotation removes the retry annotation, keeping status changes made during reconciliation.
+func (r *EtcdClusterReconciler) removeRetryBootstrapAnnotation(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) error {
+	patched := cluster.DeepCopy()
+	delete(patched.Annotations, etcdaenixiov1alpha1.RetryBootstrapAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(cluster)); err != nil {
+		return fmt.Errorf("cannot remove retry bootstrap annotation: %w", err)
+	}
+	cluster.Annotations = patched.Annotations
+	cluster.ResourceVersion = patched.ResourceVersion
+	return nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Bootstrap", func() {
+	It("should describe members blocking the bootstrap", func() {
+		pods := []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-0"},
+				Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Message: "0/3 nodes are available: 3 node(s) didn't match pod anti-affinity rules.",
+				}}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-1"},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "etcd",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: containerReasonCrashLoop}},
+				}}},
+			},
+		}
+		Expect(getBootstrapBlockers(pods)).To(Equal([]string{
+			"pod test-0 is not scheduled: 0/3 nodes are available: 3 node(s) didn't match pod anti-affinity rules.",
+			"container etcd of pod test-1 is in CrashLoopBackOff",
+		}))
+		Expect(getBootstrapBlockers(nil)).To(HaveLen(1))
+	})
+
+	Context("with a cluster", func() {
+		var (
+			reconciler *EtcdClusterReconciler
+			clock      *clocktesting.FakePassiveClock
+			cluster    *etcdaenixiov1alpha1.EtcdCluster
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-bootstrap-"}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+
+			clock = clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+			reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Clock: clock}
+			cluster = &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name},
+				Spec:       etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(1))},
+			}
+			Expect(k8sClient.Create(ctx, cluster)).Should(Succeed())
+			factory.FillConditions(cluster)
+			meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady).
+				LastTransitionTime = metav1.NewTime(clock.Now())
+		})
+
+		It("should stop updating objects once the bootstrap times out", func(ctx SpecContext) {
+			held, requeueAfter, err := reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeFalse())
+			Expect(requeueAfter).To(Equal(defaultBootstrapTimeout))
+
+			clock.SetTime(clock.Now().Add(defaultBootstrapTimeout))
+			held, _, err = reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeTrue())
+			cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBootstrapFailed)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeBootstrapTimedOut)))
+			Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)).To(BeTrue())
+
+			held, requeueAfter, err = reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeTrue())
+			Expect(requeueAfter).To(BeZero())
+		})
+
+		It("should delete member objects to retry the bootstrap", func(ctx SpecContext) {
+			clock.SetTime(clock.Now().Add(defaultBootstrapTimeout))
+			_, _, err := reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: cluster.Namespace,
+					Name:      factory.GetPVCName(cluster) + "-" + factory.GetMemberPodName(cluster, 0),
+					Labels:    factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			// update a copy, the response carries the stored status without the bootstrap conditions
+			annotated := cluster.DeepCopy()
+			annotated.Annotations = map[string]string{etcdaenixiov1alpha1.RetryBootstrapAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, annotated)).To(Succeed())
+			cluster.Annotations = annotated.Annotations
+			cluster.ResourceVersion = annotated.ResourceVersion
+
+			held, requeueAfter, err := reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeTrue())
+			Expect(requeueAfter).To(Equal(bootstrapRetryPollInterval))
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)
+			Expect(apierrors.IsNotFound(err) || err == nil && !claim.DeletionTimestamp.IsZero()).To(BeTrue())
+			if err == nil {
+				// nothing removes the protection finalizer of claims in the test environment
+				claim.Finalizers = nil
+				Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+			}
+
+			held, _, err = reconciler.checkBootstrap(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(held).To(BeFalse())
+			Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBootstrapFailed)).To(BeNil())
+			Expect(isBootstrapping(cluster)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+			Expect(cluster.Annotations).NotTo(HaveKey(etcdaenixiov1alpha1.RetryBootstrapAnnotation))
+		})
+	})
+})
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const eventReasonCertificateSANsMissing = "CertificateSANsMissing"
+
+// ensureCertificates requests certificates of the cluster from cert-manager if it issues them. The server certificate
+// covers external addresses of the client service once they are assigned.
+func (r *EtcdClusterReconciler) ensureCertificates(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) error {
+	var externalAddresses []string
+	if factory.IsCertManagerEnabled(cluster) {
+		svc := &corev1.Service{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetServiceName(cluster)}, svc)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("cannot get client service: %w", err)
+		}
+		externalAddresses = getExternalAddresses(svc)
+	}
+	return factory.CreateOrUpdateCertificates(ctx, cluster, r.Client, externalAddresses)
+}
+
+// updateCertificateSANsCondition checks that the server certificate covers external addresses of the client service.
+// Unless cert-manager issues certificates of the cluster, missing addresses are reported to be added by whoever
+// issues them. Members reload renewed certificates from the mounted secret, so they are not restarted once it
+// is updated.
+func (r *EtcdClusterReconciler) updateCertificateSANsCondition(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) error {
+	if cluster.Spec.Security == nil || cluster.Spec.Security.TLS.ServerSecret == "" {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionCertificateSANsMissing)
+		return nil
+	}
+	svc := &corev1.Service{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetServiceName(cluster)}, svc)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("cannot get client service: %w", err)
+		}
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionCertificateSANsMissing)
+		return nil
+	}
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.Security.TLS.ServerSecret}, secret)
+	if err != nil {
+		// members wait for the secret, which is reported by their pods
+		return client.IgnoreNotFound(err)
+	}
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		// members fail to start with such certificate, which is reported by their pods
+		log.FromContext(ctx).Error(err, "cannot check server certificate", "secret", secret.Name)
+		return nil
+	}
+	missing := getUncoveredAddresses(cert, getExternalAddresses(svc))
+	if len(missing) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionCertificateSANsMissing)
+		return nil
+	}
+	message := fmt.Sprintf("Server certificate in secret %s does not cover %s of service %s, "+
+		"external clients fail to verify it until the certificate is reissued with these subject alternative names",
+		secret.Name, strings.Join(missing, ", "), svc.Name)
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionCertificateSANsMissing) {
+		r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonCertificateSANsMissing, message)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionCertificateSANsMissing).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeExternalSANsMissing)).
+		WithMessage(message).
+		Complete())
+	return nil
+}
+
+// getExternalAddresses returns sorted hostnames and IPs the service is reachable on from outside of the cluster:
+// load balancer ingress and external IPs.
+func getExternalAddresses(svc *corev1.Service) []string {
+	addresses := slices.Clone(svc.Spec.ExternalIPs)
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		}
+		if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+	}
+	slices.Sort(addresses)
+	return slices.Compact(addresses)
+}
+
+// getUncoveredAddresses returns addresses the certificate is not valid for, wildcard names are respected.
+func getUncoveredAddresses(cert *x509.Certificate, addresses []string) []string {
+	var missing []string
+	for _, address := range addresses {
+		if cert.VerifyHostname(address) != nil {
+			missing = append(missing, address)
+		}
+	}
+	return missing
+}
+
+// parseCertificate returns the first certificate of the PEM encoded chain.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate in %s", corev1.TLSCertKey)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
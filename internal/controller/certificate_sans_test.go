@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Certificate SANs", func() {
+	newCertificate := func(dnsNames []string, ips []net.IP) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "etcd"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+			DNSNames:     dnsNames,
+			IPAddresses:  ips,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := x509.ParseCertificate(der)
+		Expect(err).NotTo(HaveOccurred())
+		return cert
+	}
+
+	It("should list external addresses of the service", func() {
+		svc := &corev1.Service{
+			Spec: corev1.ServiceSpec{ExternalIPs: []string{"192.0.2.10"}},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{Hostname: "etcd.example.com"},
+				{IP: "192.0.2.10"},
+				{IP: "198.51.100.7"},
+			}}},
+		}
+		Expect(getExternalAddresses(svc)).To(Equal([]string{"192.0.2.10", "198.51.100.7", "etcd.example.com"}))
+		Expect(getExternalAddresses(&corev1.Service{})).To(BeEmpty())
+	})
+
+	It("should report addresses not covered by the certificate", func() {
+		cert := newCertificate([]string{"*.lb.example.com"}, []net.IP{net.ParseIP("192.0.2.10")})
+		Expect(getUncoveredAddresses(cert, []string{"192.0.2.10", "198.51.100.7", "etcd.lb.example.com", "etcd.example.com"})).
+			To(Equal([]string{"198.51.100.7", "etcd.example.com"}))
+	})
+})
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	// clockSkewThreshold is the clock difference between members etcd warns about in its peer probes.
+	clockSkewThreshold = time.Second
+	// dateHeaderResolution is the resolution of times members report in the HTTP Date header.
+	dateHeaderResolution = time.Second
+)
+
+// memberClockOffset reports clock offsets of members relative to the operator, so skew can be alerted on.
+var memberClockOffset = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "etcd_operator_member_clock_offset_seconds",
+	Help: "Estimated clock offset of the EtcdCluster member relative to the operator, in seconds.",
+}, []string{"namespace", "name", "member"})
+
+func init() {
+	metrics.Registry.MustRegister(memberClockOffset)
+}
+
+// clockOffset is the estimated clock offset of a member relative to the operator. The real offset is within
+// the uncertainty of the estimate.
+type clockOffset struct {
+	member      string
+	offset      time.Duration
+	uncertainty time.Duration
+}
+
+// updateClockSkewCondition estimates clock offsets of ready members from the Date header of their health
+// endpoint and reflects members whose clocks certainly differ by more than etcd tolerates in the ClockSkew
+// condition. Offsets are measured only for members reachable by pod DNS names.
+func (r *EtcdClusterReconciler) updateClockSkewCondition(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) {
+	if cluster.GetOperatorConnectionMode() != etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionClockSkew)
+		return
+	}
+	logger := log.FromContext(ctx)
+	tlsConfig, err := conn.TLSConfig(ctx)
+	if err != nil {
+		logger.V(2).Info("cannot get etcd TLS config", "error", err.Error())
+		return
+	}
+	var offsets []clockOffset
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.DeletionTimestamp.IsZero() || !isPodReady(pod) {
+			continue
+		}
+		offset, err := r.getMemberClockOffset(ctx, cluster, pod.Name, tlsConfig)
+		if err != nil {
+			logger.V(2).Info("cannot get member clock offset", "member", pod.Name, "error", err.Error())
+			continue
+		}
+		memberClockOffset.WithLabelValues(cluster.Namespace, cluster.Name, pod.Name).Set(offset.offset.Seconds())
+		offsets = append(offsets, offset)
+	}
+	setClockSkewCondition(cluster, getClockSkew(offsets))
+}
+
+// getMemberClockOffset estimates the clock offset of the member from the Date header of its health endpoint,
+// assuming the response was generated halfway through the request.
+func (r *EtcdClusterReconciler) getMemberClockOffset(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	podName string,
+	tlsConfig *tls.Config,
+) (clockOffset, error) {
+	healthURL := strings.TrimSuffix(factory.GetMemberMetricsURL(cluster, podName), "/metrics") + "/health"
+	var offset clockOffset
+	sent := r.getClock().Now()
+	err := getMemberHTTP(ctx, tlsConfig, healthURL, func(resp *http.Response) error {
+		received := r.getClock().Now()
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return fmt.Errorf("cannot parse Date header: %w", err)
+		}
+		offset = estimateClockOffset(podName, sent, received, date)
+		return nil
+	})
+	return offset, err
+}
+
+// estimateClockOffset returns the offset of the member clock given the time the request was sent and
+// the response received by the operator and the time reported by the member, truncated to the resolution
+// of the Date header.
+func estimateClockOffset(member string, sent, received, reported time.Time) clockOffset {
+	roundTrip := received.Sub(sent)
+	memberTime := reported.Add(dateHeaderResolution / 2)
+	operatorTime := sent.Add(roundTrip / 2)
+	return clockOffset{
+		member:      member,
+		offset:      memberTime.Sub(operatorTime),
+		uncertainty: dateHeaderResolution/2 + roundTrip/2,
+	}
+}
+
+// getClockSkew describes the largest skew between members exceeding the threshold even within uncertainty
+// of the offsets, empty if there is none. Offsets are relative to the operator, so its own clock doesn't matter.
+func getClockSkew(offsets []clockOffset) string {
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i].offset < offsets[j].offset })
+	var skew time.Duration
+	var ahead, behind clockOffset
+	for i := range offsets {
+		for j := i + 1; j < len(offsets); j++ {
+			certain := offsets[j].offset - offsets[i].offset - offsets[i].uncertainty - offsets[j].uncertainty
+			if certain > clockSkewThreshold && certain > skew {
+				skew, ahead, behind = certain, offsets[j], offsets[i]
+			}
+		}
+	}
+	if skew == 0 {
+		return ""
+	}
+	return fmt.Sprintf("clock of member %s is ahead of member %s by %s, etcd tolerates %s",
+		ahead.member, behind.member, (ahead.offset - behind.offset).Round(time.Millisecond), clockSkewThreshold)
+}
+
+// setClockSkewCondition reflects skew between member clocks in the ClockSkew condition. Clusters have no such
+// condition while clocks of members agree.
+func setClockSkewCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, skew string) {
+	if skew == "" {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionClockSkew)
+		return
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionClockSkew).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeClockSkewDetected)).
+		WithMessage(skew).
+		Complete())
+}
+
+func forgetClockOffsetMetrics(namespace, name string) {
+	memberClockOffset.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Clock skew", func() {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	It("should estimate clock offset of members from the Date header", func() {
+		offset := estimateClockOffset("test-0", now, now.Add(200*time.Millisecond), now.Add(3*time.Second))
+		Expect(offset.offset).To(Equal(3400 * time.Millisecond))
+		Expect(offset.uncertainty).To(Equal(600 * time.Millisecond))
+	})
+
+	It("should report skew between members exceeding the uncertainty only", func() {
+		offsets := []clockOffset{
+			{member: "test-0", offset: 2 * time.Second, uncertainty: 600 * time.Millisecond},
+			{member: "test-1", offset: 0, uncertainty: 600 * time.Millisecond},
+		}
+		Expect(getClockSkew(offsets)).To(BeEmpty())
+
+		offsets = append(offsets, clockOffset{member: "test-2", offset: -2 * time.Second, uncertainty: time.Second / 2})
+		Expect(getClockSkew(offsets)).To(Equal("clock of member test-0 is ahead of member test-2 by 4s, etcd tolerates 1s"))
+	})
+
+	It("should reflect skew in the condition", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setClockSkewCondition(cluster, "clock of member test-0 is ahead of member test-2 by 4s")
+		condition := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionClockSkew)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeClockSkewDetected)))
+
+		setClockSkewCondition(cluster, "")
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionClockSkew)).To(BeNil())
+	})
+})
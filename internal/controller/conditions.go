@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// degradingConditions degrade the cluster while they are True, the first of them is reported in Degraded condition.
+var degradingConditions = []string{
+	etcdaenixiov1alpha1.EtcdConditionMemberFailure,
+	etcdaenixiov1alpha1.EtcdConditionUpgradeFailed,
+	etcdaenixiov1alpha1.EtcdConditionAlarmCorrupt,
+	etcdaenixiov1alpha1.EtcdConditionAlarmNoSpace,
+	etcdaenixiov1alpha1.EtcdConditionImageRejected,
+	etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes,
+	etcdaenixiov1alpha1.EtcdConditionForeignMemberData,
+	etcdaenixiov1alpha1.EtcdConditionBootstrapFailed,
+}
+
+// isRolloutComplete returns true if all members run the current spec and are ready.
+// In the Pods member management mode all member pods are checked to be ready instead.
+func (r *EtcdClusterReconciler) isRolloutComplete(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) (bool, error) {
+	if cluster.ManagesMemberPods() {
+		ready := 0
+		for i := range pods {
+			if !pods[i].DeletionTimestamp.IsZero() {
+				return false, nil
+			}
+			if isPodReady(&pods[i]) {
+				ready++
+			}
+		}
+		replicas := int(ptr.Deref(cluster.Spec.Replicas, 0))
+		return len(pods) == replicas && ready == replicas, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return isStatefulSetSettled(sts), nil
+}
+
+// setHealthConditions sets Progressing and Degraded conditions from the rollout state and other conditions
+// and records the generation the status was computed for. Ready condition must be set before.
+// A cluster is degraded if any of degrading conditions is True, or it is not ready although nothing is rolled out.
+func setHealthConditions(cluster *etcdaenixiov1alpha1.EtcdCluster, rolledOut bool) {
+	ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+	waitingForQuorum := ready != nil && ready.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum)
+	progressing := !rolledOut || waitingForQuorum ||
+		meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion) ||
+		meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionStorageMigration)
+	// nothing is rolled out to clusters whose bootstrap failed until it is retried
+	if meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionBootstrapFailed) {
+		progressing = false
+	}
+
+	reason := etcdaenixiov1alpha1.EtcdCondTypeRolloutComplete
+	message := string(etcdaenixiov1alpha1.EtcdProgressingCondNegMessage)
+	if progressing {
+		reason = etcdaenixiov1alpha1.EtcdCondTypeRolloutInProgress
+		message = string(etcdaenixiov1alpha1.EtcdProgressingCondPosMessage)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionProgressing).
+		WithStatus(progressing).
+		WithReason(string(reason)).
+		WithMessage(message).
+		Complete())
+
+	degraded := false
+	reason = etcdaenixiov1alpha1.EtcdCondTypeNotDegraded
+	message = string(etcdaenixiov1alpha1.EtcdDegradedCondNegMessage)
+	for _, condType := range degradingConditions {
+		if cond := factory.GetCondition(cluster, condType); cond != nil && cond.Status == metav1.ConditionTrue {
+			degraded = true
+			reason = etcdaenixiov1alpha1.EtcdCondType(condType)
+			message = cond.Message
+			break
+		}
+	}
+	if !degraded && !progressing && ready != nil && ready.Status != metav1.ConditionTrue {
+		degraded = true
+		reason = etcdaenixiov1alpha1.EtcdCondType(ready.Reason)
+		message = ready.Message
+	}
+	// ephemeral storage is a standing warning rather than a failure, it is reported only if nothing else is wrong
+	// and not at all for standalone clusters, which are meant to be thrown away
+	if cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage); !degraded &&
+		!cluster.IsStandalone() && cond != nil && cond.Status == metav1.ConditionTrue {
+		degraded = true
+		reason = etcdaenixiov1alpha1.EtcdCondType(etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)
+		message = cond.Message
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionDegraded).
+		WithStatus(degraded).
+		WithReason(string(reason)).
+		WithMessage(message).
+		Complete())
+
+	cluster.Status.ObservedGeneration = cluster.Generation
+}
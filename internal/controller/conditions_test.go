@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Health conditions", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+
+	setReady := func(ready bool, reason etcdaenixiov1alpha1.EtcdCondType) {
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).
+			WithStatus(ready).
+			WithReason(string(reason)).
+			WithMessage("test").
+			Complete())
+	}
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+		factory.FillConditions(cluster)
+	})
+
+	It("should report bootstrap as progressing, not degraded", func() {
+		setHealthConditions(cluster, true)
+		Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)).To(BeTrue())
+		Expect(cluster.Status.ObservedGeneration).To(Equal(int64(2)))
+	})
+
+	It("should report rollout as progressing, not degraded", func() {
+		setReady(false, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady)
+		setHealthConditions(cluster, false)
+		Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)).To(BeTrue())
+	})
+
+	It("should report ready cluster with rolled out spec as healthy", func() {
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		setHealthConditions(cluster, true)
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)).To(BeTrue())
+	})
+
+	It("should report unready cluster with rolled out spec as degraded", func() {
+		setReady(false, etcdaenixiov1alpha1.EtcdCondTypeLeaseCheckFailed)
+		setHealthConditions(cluster, true)
+		degraded := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeLeaseCheckFailed)))
+	})
+
+	It("should report degrading conditions even during rollout", func() {
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionAlarmNoSpace).
+			WithStatus(true).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeAlarmActive)).
+			WithMessage("database space exceeded").
+			Complete())
+		setHealthConditions(cluster, false)
+		degraded := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(etcdaenixiov1alpha1.EtcdConditionAlarmNoSpace))
+		Expect(degraded.Message).To(Equal("database space exceeded"))
+	})
+
+	It("should report ephemeral storage as degraded only if nothing else is wrong", func() {
+		cluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		setEphemeralStorageCondition(cluster)
+		setReady(false, etcdaenixiov1alpha1.EtcdCondTypeLeaseCheckFailed)
+		setHealthConditions(cluster, true)
+		degraded := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)
+		Expect(degraded.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeLeaseCheckFailed)))
+
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		setHealthConditions(cluster, true)
+		degraded = meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(etcdaenixiov1alpha1.EtcdConditionEphemeralStorage))
+	})
+
+	It("should not report ephemeral storage of standalone clusters as degraded", func() {
+		cluster.Spec.Mode = etcdaenixiov1alpha1.ClusterModeStandalone
+		cluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		setEphemeralStorageCondition(cluster)
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		setHealthConditions(cluster, true)
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDegraded)).To(BeTrue())
+	})
+})
@@ -0,0 +1,40 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// isPodCordoned returns true if the pod runs a member cordoned by the cordoned members annotation.
+func isPodCordoned(cluster *etcdaenixiov1alpha1.EtcdCluster, pod *corev1.Pod) bool {
+	ordinal, err := podOrdinal(pod.Name)
+	return err == nil && cluster.IsMemberCordoned(ordinal)
+}
+
+// withoutCordonedMembers returns pods of members which are not cordoned.
+func withoutCordonedMembers(cluster *etcdaenixiov1alpha1.EtcdCluster, pods []corev1.Pod) []corev1.Pod {
+	filtered := make([]corev1.Pod, 0, len(pods))
+	for i := range pods {
+		if !isPodCordoned(cluster, &pods[i]) {
+			filtered = append(filtered, pods[i])
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Cordoned members", func() {
+	pod := func(name string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{etcdaenixiov1alpha1.CordonedMembersAnnotation: "1"},
+		},
+		Spec: etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(3))},
+	}
+	pods := []corev1.Pod{pod("test-0", true), pod("test-1", false), pod("test-2", true)}
+
+	It("should skip cordoned members", func() {
+		Expect(isPodCordoned(cluster, &pods[1])).To(BeTrue())
+		Expect(withoutCordonedMembers(cluster, pods)).To(HaveLen(2))
+	})
+	It("should tolerate unhealthy cordoned members", func() {
+		Expect(allMembersReady(cluster, pods)).To(BeTrue())
+		Expect(allMembersReady(&etcdaenixiov1alpha1.EtcdCluster{Spec: cluster.Spec}, pods)).To(BeFalse())
+	})
+})
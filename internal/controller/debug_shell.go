@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	eventReasonDebugShellStarted = "DebugShellStarted"
+	eventReasonDebugShellExpired = "DebugShellExpired"
+	eventReasonDebugShellClosed  = "DebugShellClosed"
+)
+
+// reconcileDebugShell runs the debug shell pod requested by the debug shell annotation until it expires, then deletes
+// the pod and removes the annotation. The pod is deleted early if the annotation is removed. The duration is fixed
+// when the pod starts, so the shell is extended by requesting a new one. Returns the duration after which it expires.
+func (r *EtcdClusterReconciler) reconcileDebugShell(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (time.Duration, error) {
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetDebugShellPodName(cluster)}, pod)
+	if client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("cannot get debug shell pod: %w", err)
+	}
+	exists := err == nil
+
+	// malformed durations are rejected by the webhook, such requests are treated as withdrawn
+	ttl, _ := cluster.DebugShellTTL()
+	if ttl == 0 {
+		cluster.Status.DebugShell = nil
+		if !exists {
+			return 0, nil
+		}
+		if err := r.deleteDebugShellPod(ctx, pod); err != nil {
+			return 0, err
+		}
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonDebugShellClosed,
+			fmt.Sprintf("Debug shell pod %s was deleted, because the debug shell is no longer requested", pod.Name))
+		recordAction(ctx, "closed debug shell %s", pod.Name)
+		return 0, nil
+	}
+
+	now := r.getClock().Now()
+	if !exists {
+		pod = factory.GetDebugShellPod(ctx, cluster, now, now.Add(ttl))
+		if err := ctrl.SetControllerReference(cluster, pod, r.Scheme); err != nil {
+			return 0, fmt.Errorf("cannot set controller reference: %w", err)
+		}
+		if err := r.Create(ctx, pod); err != nil {
+			return 0, fmt.Errorf("cannot create debug shell pod: %w", err)
+		}
+		log.FromContext(ctx).Info("debug shell started", "pod", pod.Name, "ttl", ttl.String())
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonDebugShellStarted,
+			fmt.Sprintf("Debug shell pod %s was started for %s", pod.Name, ttl))
+		recordAction(ctx, "started debug shell %s for %s", pod.Name, ttl)
+	}
+
+	expiresAt, ok := factory.GetDebugShellExpiration(pod)
+	if ok && now.Before(expiresAt) {
+		cluster.Status.DebugShell = &etcdaenixiov1alpha1.DebugShellStatus{
+			PodName:   pod.Name,
+			ExpiresAt: metav1.NewTime(expiresAt),
+		}
+		return expiresAt.Sub(now), nil
+	}
+
+	if err := r.deleteDebugShellPod(ctx, pod); err != nil {
+		return 0, err
+	}
+	// the annotation is removed from a copy, so status changes made during the reconciliation are kept
+	patched := cluster.DeepCopy()
+	delete(patched.Annotations, etcdaenixiov1alpha1.DebugShellAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(cluster)); err != nil {
+		return 0, fmt.Errorf("cannot remove debug shell annotation: %w", err)
+	}
+	cluster.Annotations = patched.Annotations
+	cluster.ResourceVersion = patched.ResourceVersion
+	cluster.Status.DebugShell = nil
+	log.FromContext(ctx).Info("debug shell expired", "pod", pod.Name)
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonDebugShellExpired,
+		fmt.Sprintf("Debug shell pod %s was deleted on expiration", pod.Name))
+	recordAction(ctx, "closed expired debug shell %s", pod.Name)
+	return 0, nil
+}
+
+func (r *EtcdClusterReconciler) deleteDebugShellPod(ctx context.Context, pod *corev1.Pod) error {
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete debug shell pod: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Debug shell", func() {
+	var (
+		reconciler *EtcdClusterReconciler
+		clock      *clocktesting.FakePassiveClock
+		cluster    *etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-debug-shell-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		clock = clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+		reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Clock: clock}
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   ns.Name,
+				Annotations: map[string]string{etcdaenixiov1alpha1.DebugShellAnnotation: "1h"},
+			},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(1))},
+		}
+		Expect(k8sClient.Create(ctx, cluster)).Should(Succeed())
+	})
+
+	getPod := func(ctx SpecContext) error {
+		key := client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetDebugShellPodName(cluster)}
+		return k8sClient.Get(ctx, key, &corev1.Pod{})
+	}
+
+	It("should delete the pod and the annotation on expiration", func(ctx SpecContext) {
+		requeueAfter, err := reconciler.reconcileDebugShell(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(Equal(time.Hour))
+		Expect(getPod(ctx)).To(Succeed())
+		Expect(cluster.Status.DebugShell).NotTo(BeNil())
+		Expect(cluster.Status.DebugShell.ExpiresAt.Time).To(BeTemporally("==", clock.Now().Add(time.Hour)))
+
+		clock.SetTime(clock.Now().Add(time.Hour))
+		requeueAfter, err = reconciler.reconcileDebugShell(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		Expect(cluster.Status.DebugShell).To(BeNil())
+		Expect(apierrors.IsNotFound(getPod(ctx))).To(BeTrue())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.Annotations).NotTo(HaveKey(etcdaenixiov1alpha1.DebugShellAnnotation))
+	})
+
+	It("should delete the pod when the annotation is removed", func(ctx SpecContext) {
+		_, err := reconciler.reconcileDebugShell(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		delete(cluster.Annotations, etcdaenixiov1alpha1.DebugShellAnnotation)
+		requeueAfter, err := reconciler.reconcileDebugShell(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		Expect(cluster.Status.DebugShell).To(BeNil())
+		Expect(apierrors.IsNotFound(getPod(ctx))).To(BeTrue())
+	})
+})
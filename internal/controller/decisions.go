@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// maxDecisions limits actions and skipped actions kept in status.lastReconcile.
+	maxDecisions = 10
+	// maxDecisionErrorLength limits the error kept in status.lastReconcile.
+	maxDecisionErrorLength = 1024
+)
+
+type decisionLogKey struct{}
+
+// decisionLog collects what the operator did and decided not to do during a reconciliation,
+// it is summarized in status.lastReconcile.
+type decisionLog struct {
+	clock   clock.PassiveClock
+	start   time.Time
+	actions []string
+	skipped []string
+	err     error
+	// observed is the status of the cluster read at the start of the reconciliation.
+	observed *etcdaenixiov1alpha1.EtcdClusterStatus
+}
+
+// withDecisionLog returns context collecting decisions of a reconciliation started now.
+func withDecisionLog(ctx context.Context, clk clock.PassiveClock) context.Context {
+	return context.WithValue(ctx, decisionLogKey{}, &decisionLog{clock: clk, start: clk.Now()})
+}
+
+func decisionLogFromContext(ctx context.Context) *decisionLog {
+	decisions, _ := ctx.Value(decisionLogKey{}).(*decisionLog)
+	return decisions
+}
+
+// recordAction records an action taken by the operator, e.g. a rollback or replacement of a member.
+func recordAction(ctx context.Context, format string, args ...any) {
+	if decisions := decisionLogFromContext(ctx); decisions != nil {
+		decisions.actions = append(decisions.actions, fmt.Sprintf(format, args...))
+	}
+}
+
+// recordSkipped records an action the operator decided not to take and why.
+func recordSkipped(ctx context.Context, format string, args ...any) {
+	if decisions := decisionLogFromContext(ctx); decisions != nil {
+		decisions.skipped = append(decisions.skipped, fmt.Sprintf(format, args...))
+	}
+}
+
+// recordError records the error the reconciliation failed with.
+func recordError(ctx context.Context, err error) {
+	if decisions := decisionLogFromContext(ctx); decisions != nil {
+		decisions.err = err
+	}
+}
+
+// setLastReconcile summarizes decisions of the reconciliation in status.lastReconcile.
+func setLastReconcile(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	decisions := decisionLogFromContext(ctx)
+	if decisions == nil {
+		return
+	}
+	summary := &etcdaenixiov1alpha1.ReconcileSummary{
+		Time:     metav1.NewTime(decisions.start),
+		Duration: metav1.Duration{Duration: decisions.clock.Since(decisions.start).Round(time.Millisecond)},
+		Actions:  truncateDecisions(decisions.actions),
+		Skipped:  truncateDecisions(decisions.skipped),
+	}
+	if decisions.err != nil {
+		summary.Error = decisions.err.Error()
+		if len(summary.Error) > maxDecisionErrorLength {
+			summary.Error = summary.Error[:maxDecisionErrorLength] + "..."
+		}
+	}
+	cluster.Status.LastReconcile = summary
+}
+
+// truncateDecisions keeps the first decisions and counts the rest, so the status stays compact.
+func truncateDecisions(decisions []string) []string {
+	if len(decisions) <= maxDecisions {
+		return decisions
+	}
+	truncated := append([]string{}, decisions[:maxDecisions-1]...)
+	return append(truncated, fmt.Sprintf("and %d more", len(decisions)-maxDecisions+1))
+}
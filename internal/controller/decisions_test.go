@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Decision log", func() {
+	It("should summarize the reconciliation in status", func() {
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := clocktesting.NewFakeClock(start)
+		ctx := withDecisionLog(context.Background(), clock)
+		recordAction(ctx, "replaced member %s", "test-0")
+		recordSkipped(ctx, "rollout: etcd image signature is not verified")
+		recordError(ctx, errors.New("cannot list Cluster pods"))
+		clock.Step(1500 * time.Millisecond)
+
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setLastReconcile(ctx, cluster)
+		Expect(cluster.Status.LastReconcile).NotTo(BeNil())
+		Expect(cluster.Status.LastReconcile.Time.Time).To(Equal(start))
+		Expect(cluster.Status.LastReconcile.Duration.Duration).To(Equal(1500 * time.Millisecond))
+		Expect(cluster.Status.LastReconcile.Actions).To(Equal([]string{"replaced member test-0"}))
+		Expect(cluster.Status.LastReconcile.Skipped).To(HaveLen(1))
+		Expect(cluster.Status.LastReconcile.Error).To(Equal("cannot list Cluster pods"))
+	})
+	It("should keep the summary compact", func() {
+		ctx := withDecisionLog(context.Background(), clock.RealClock{})
+		for i := 0; i < maxDecisions+5; i++ {
+			recordAction(ctx, "action %d", i)
+		}
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setLastReconcile(ctx, cluster)
+		Expect(cluster.Status.LastReconcile.Actions).To(HaveLen(maxDecisions))
+		Expect(cluster.Status.LastReconcile.Actions[maxDecisions-1]).To(Equal(fmt.Sprintf("and %d more", 6)))
+	})
+	It("should ignore decisions outside of reconciliation", func() {
+		recordAction(context.Background(), "replaced member test-0")
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setLastReconcile(context.Background(), cluster)
+		Expect(cluster.Status.LastReconcile).To(BeNil())
+	})
+})
@@ -0,0 +1,210 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/maintenance"
+)
+
+const (
+	defaultKeyDropPercent = 50
+	// defragStageInterval separates defragmentation of consecutive members, so they catch up in between.
+	defragStageInterval = 10 * time.Second
+
+	eventReasonCompacted     = "Compacted"
+	eventReasonDefragmented  = "Defragmented"
+	eventReasonDefragFailed  = "DefragmentationFailed"
+	eventReasonCompactFailed = "CompactionFailed"
+)
+
+// reconcileAutoDefrag compacts the keyspace once large deletions are detected and then defragments members one at
+// a time, followers first. Only healthy clusters whose members are reachable by pod DNS names are maintained.
+// Cordoned members are neither defragmented nor required to be healthy.
+// Returns the duration after which the next step should be made, zero if there is nothing to do.
+func (r *EtcdClusterReconciler) reconcileAutoDefrag(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) time.Duration {
+	clusterKey := maintenance.MemberKey(cluster.Namespace, cluster.Name, "")
+	if r.Bloat == nil || r.Maintenance == nil {
+		return 0
+	}
+	if cluster.Spec.AutoDefrag == nil {
+		r.Bloat.Forget(clusterKey)
+		return 0
+	}
+	logger := log.FromContext(ctx)
+	if cluster.GetOperatorConnectionMode() != etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+		logger.V(2).Info("automatic defragmentation requires members reachable by pod DNS names")
+		return 0
+	}
+	pods = withoutCordonedMembers(cluster, pods)
+	if !allMembersReady(cluster, pods) {
+		return 0
+	}
+
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		logger.Error(err, "cannot create etcd client")
+		return 0
+	}
+
+	if member, ok := r.Bloat.Next(clusterKey); ok {
+		return r.defragmentMember(ctx, cli, cluster, member)
+	}
+
+	statuses := getMemberStatuses(ctx, cli, cluster, pods)
+	if len(statuses) != len(pods) {
+		return 0
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	count, err := cli.Get(reqCtx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly())
+	if err != nil {
+		logger.V(2).Info("cannot count keys", "error", err.Error())
+		return 0
+	}
+	sample := maintenance.SizeSample{Keys: count.Count}
+	for _, status := range statuses {
+		sample.DBSize = max(sample.DBSize, status.DbSize)
+	}
+	keyDropPercent := int(ptr.Deref(cluster.Spec.AutoDefrag.KeyDropPercent, defaultKeyDropPercent))
+	if !r.Bloat.Observe(clusterKey, sample, keyDropPercent) {
+		return 0
+	}
+
+	logger.Info("large deletion detected, compacting", "keys", sample.Keys, "db_size", sample.DBSize)
+	if _, err := cli.Compact(reqCtx, count.Header.Revision, clientv3.WithCompactPhysical()); err != nil {
+		r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonCompactFailed,
+			fmt.Sprintf("Cannot compact keyspace at revision %d: %s", count.Header.Revision, err.Error()))
+		return 0
+	}
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonCompacted,
+		fmt.Sprintf("Keyspace compacted at revision %d after the key count dropped to %d", count.Header.Revision, sample.Keys))
+	recordAction(ctx, "compacted keyspace at revision %d", count.Header.Revision)
+	r.Bloat.Schedule(clusterKey, defragOrder(statuses))
+	return defragStageInterval
+}
+
+// defragmentMember defragments the member if the maintenance limiter allows it and reports reclaimed space.
+func (r *EtcdClusterReconciler) defragmentMember(
+	ctx context.Context,
+	cli *clientv3.Client,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	member string,
+) time.Duration {
+	clusterKey := maintenance.MemberKey(cluster.Namespace, cluster.Name, "")
+	release, retryAfter, ok := r.Maintenance.TryAcquire(maintenance.OperationDefragment,
+		maintenance.MemberKey(cluster.Namespace, cluster.Name, member))
+	if !ok {
+		return retryAfter
+	}
+	defer release()
+
+	endpoint := factory.GetMemberClientEndpoint(cluster, member)
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	before, err := cli.Status(reqCtx, endpoint)
+	cancel()
+	if err != nil {
+		log.FromContext(ctx).V(2).Info("cannot get member status", "member", member, "error", err.Error())
+		return defragStageInterval
+	}
+	// defragmentation rewrites the whole database, so it is not bound by the request timeout
+	if _, err := cli.Defragment(ctx, endpoint); err != nil {
+		r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonDefragFailed,
+			fmt.Sprintf("Cannot defragment member %s: %s", member, err.Error()))
+		r.Bloat.Done(clusterKey, member)
+		return defragStageInterval
+	}
+	r.Bloat.Done(clusterKey, member)
+
+	reqCtx, cancel = context.WithTimeout(ctx, etcdRequestTimeout)
+	after, err := cli.Status(reqCtx, endpoint)
+	cancel()
+	message := fmt.Sprintf("Member %s defragmented", member)
+	if err == nil {
+		reclaimed := resource.NewQuantity(max(before.DbSize-after.DbSize, 0), resource.BinarySI)
+		message = fmt.Sprintf("Member %s defragmented, reclaimed %s", member, reclaimed.String())
+	}
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonDefragmented, message)
+	recordAction(ctx, "defragmented member %s", member)
+	if _, pending := r.Bloat.Next(clusterKey); pending {
+		return defragStageInterval
+	}
+	return 0
+}
+
+// defragOrder returns members ordered for defragmentation, followers first and the leader last,
+// so leadership changes at most once.
+func defragOrder(statuses map[string]*clientv3.StatusResponse) []string {
+	members := make([]string, 0, len(statuses))
+	for name := range statuses {
+		members = append(members, name)
+	}
+	slices.SortFunc(members, func(a, b string) int {
+		aLeader := statuses[a].Leader == statuses[a].Header.MemberId
+		bLeader := statuses[b].Leader == statuses[b].Header.MemberId
+		switch {
+		case aLeader && !bLeader:
+			return 1
+		case !aLeader && bLeader:
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	return members
+}
+
+// allMembersReady returns true if all desired members, except cordoned ones, run in ready pods.
+func allMembersReady(cluster *etcdaenixiov1alpha1.EtcdCluster, pods []corev1.Pod) bool {
+	ready := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() && isPodReady(&pods[i]) && !isPodCordoned(cluster, &pods[i]) {
+			ready++
+		}
+	}
+	desired := 0
+	for ordinal := 0; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+		if !cluster.IsMemberCordoned(ordinal) {
+			desired++
+		}
+	}
+	return ready > 0 && ready == desired
+}
+
+// recordEvent records event of the cluster if the reconciler has an event recorder.
+func (r *EtcdClusterReconciler) recordEvent(cluster *etcdaenixiov1alpha1.EtcdCluster, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(cluster, eventType, reason, message)
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ = Describe("Automatic defragmentation", func() {
+	It("should defragment followers first and the leader last", func() {
+		status := func(id, leader uint64) *clientv3.StatusResponse {
+			return &clientv3.StatusResponse{Header: &etcdserverpb.ResponseHeader{MemberId: id}, Leader: leader}
+		}
+		Expect(defragOrder(map[string]*clientv3.StatusResponse{
+			"test-0": status(1, 1),
+			"test-2": status(3, 1),
+			"test-1": status(2, 1),
+		})).To(Equal([]string{"test-1", "test-2", "test-0"}))
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	flagQuotaBackendBytes = "quota-backend-bytes"
+	// quotaBackendBytesMetric reports the backend quota the member runs with.
+	quotaBackendBytesMetric = "etcd_server_quota_backend_bytes"
+)
+
+// configDriftFlags are etcd settings compared between the spec and running members.
+var configDriftFlags = []string{
+	flagQuotaBackendBytes, "auto-compaction-mode", "auto-compaction-retention", flagMaxTxnOps, flagMaxRequestBytes,
+}
+
+// updateConfigDriftCondition compares etcd settings members were started with, and the backend quota reported
+// by their metrics, with the spec and reflects differences in the ConfigDrift condition. Members are compared on
+// every reconciliation, so the condition is also set while a change is rolled out. Metrics are read only from
+// members reachable by pod DNS names.
+func (r *EtcdClusterReconciler) updateConfigDriftCondition(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) {
+	desired := getFlagValues(factory.GetEtcdArgs(ctx, cluster), configDriftFlags)
+	podDNS := cluster.GetOperatorConnectionMode() == etcdaenixiov1alpha1.OperatorConnectionPodDNS
+	var drifts []string
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		container := slices.IndexFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == "etcd" })
+		if container == -1 {
+			continue
+		}
+		drifts = append(drifts, getConfigDrifts(pod.Name, desired,
+			getFlagValues(pod.Spec.Containers[container].Args, configDriftFlags))...)
+
+		if desired[flagQuotaBackendBytes] == "" || !isPodReady(pod) || !podDNS {
+			continue
+		}
+		quota, err := getMemberQuota(ctx, cluster, conn, pod.Name)
+		if err != nil {
+			log.FromContext(ctx).V(2).Info("cannot get member quota", "member", pod.Name, "error", err.Error())
+			continue
+		}
+		if strconv.FormatInt(quota, 10) != desired[flagQuotaBackendBytes] {
+			drifts = append(drifts, fmt.Sprintf("member %s reports %s=%d, spec requires %s",
+				pod.Name, flagQuotaBackendBytes, quota, desired[flagQuotaBackendBytes]))
+		}
+	}
+	setConfigDriftCondition(cluster, drifts)
+}
+
+// getConfigDrifts describes settings of the member differing from desired ones.
+func getConfigDrifts(member string, desired, actual map[string]string) []string {
+	var drifts []string
+	for _, name := range configDriftFlags {
+		if desired[name] != actual[name] {
+			drifts = append(drifts, fmt.Sprintf("member %s runs with %s=%q, spec requires %q",
+				member, name, actual[name], desired[name]))
+		}
+	}
+	return drifts
+}
+
+// getFlagValues returns values of the flags passed in the arguments. The last occurrence of a flag wins,
+// as in etcd, flags passed without value have empty value.
+func getFlagValues(args []string, names []string) map[string]string {
+	values := make(map[string]string, len(names))
+	for _, arg := range args {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if slices.Contains(names, name) {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// getMemberQuota returns the backend quota the member runs with, as reported by its metrics.
+func getMemberQuota(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	podName string,
+) (int64, error) {
+	tlsConfig, err := conn.TLSConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	quota, err := getMemberMetric(ctx, tlsConfig, factory.GetMemberMetricsURL(cluster, podName), quotaBackendBytesMetric)
+	return int64(quota), err
+}
+
+// setConfigDriftCondition reflects settings of members differing from the spec in the ConfigDrift condition.
+// Clusters have no such condition while members run with settings of the spec.
+func setConfigDriftCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, drifts []string) {
+	if len(drifts) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionConfigDrift)
+		return
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionConfigDrift).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeConfigDrifted)).
+		WithMessage(strings.Join(drifts, "; ")).
+		Complete())
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Config drift", func() {
+	It("should take the last occurrence of flags", func() {
+		Expect(getFlagValues([]string{
+			"--auto-compaction-retention=1h",
+			"--name=$(POD_NAME)",
+			"--auto-compaction-retention=5m",
+			"--quota-backend-bytes=1024",
+		}, configDriftFlags)).To(Equal(map[string]string{
+			"auto-compaction-retention": "5m",
+			"quota-backend-bytes":       "1024",
+		}))
+	})
+	It("should describe settings differing from the spec", func() {
+		desired := map[string]string{"auto-compaction-retention": "5m", "quota-backend-bytes": "2048"}
+		actual := map[string]string{"auto-compaction-retention": "5m", "quota-backend-bytes": "1024"}
+		Expect(getConfigDrifts("test-0", desired, desired)).To(BeEmpty())
+		Expect(getConfigDrifts("test-0", desired, actual)).To(Equal([]string{
+			`member test-0 runs with quota-backend-bytes="1024", spec requires "2048"`,
+		}))
+	})
+	It("should reflect drifts in the condition", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setConfigDriftCondition(cluster, []string{"member test-0 runs with stale settings"})
+		condition := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionConfigDrift)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("member test-0 runs with stale settings"))
+
+		setConfigDriftCondition(cluster, nil)
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionConfigDrift)).To(BeNil())
+	})
+})
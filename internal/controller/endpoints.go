@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// setEndpointsStatus publishes client URLs of the client service and of members in status.endpoints,
+// together with the domain of SRV records of members and the leader service if they are enabled.
+func setEndpointsStatus(cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	endpoints := &etcdaenixiov1alpha1.EndpointsStatus{
+		Service: factory.GetClientServiceEndpoint(cluster),
+		Members: factory.GetMemberClientEndpoints(cluster),
+	}
+	if cluster.Spec.ClientSRVRecords {
+		endpoints.SRVDomain = factory.GetDiscoverySRVDomain(cluster)
+	}
+	if cluster.Spec.LeaderService {
+		endpoints.Leader = factory.GetLeaderServiceEndpoint(cluster)
+	}
+	cluster.Status.Endpoints = endpoints
+}
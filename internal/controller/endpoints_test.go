@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Endpoints status", func() {
+	It("should publish client URLs of the service and members", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(2)),
+				Security: &etcdaenixiov1alpha1.SecuritySpec{
+					TLS: etcdaenixiov1alpha1.TLSSpec{ServerSecret: "server"},
+				},
+			},
+		}
+		setEndpointsStatus(cluster)
+		Expect(cluster.Status.Endpoints).To(Equal(&etcdaenixiov1alpha1.EndpointsStatus{
+			Service: "https://test.ns.svc:2379",
+			Members: []string{"https://test-0.test-headless.ns.svc:2379", "https://test-1.test-headless.ns.svc:2379"},
+		}))
+
+		cluster.Spec.ClientSRVRecords = true
+		setEndpointsStatus(cluster)
+		Expect(cluster.Status.Endpoints.SRVDomain).To(Equal("test-headless.ns.svc"))
+		Expect(cluster.Status.Endpoints.Leader).To(BeEmpty())
+
+		cluster.Spec.LeaderService = true
+		setEndpointsStatus(cluster)
+		Expect(cluster.Status.Endpoints.Leader).To(Equal("https://test-leader.ns.svc:2379"))
+	})
+})
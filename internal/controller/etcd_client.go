@@ -0,0 +1,304 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	etcdDialTimeout    = 5 * time.Second
+	etcdRequestTimeout = 5 * time.Second
+)
+
+// Types of etcd client errors, so network problems can be told apart from auth misconfigurations.
+const (
+	etcdClientErrorDeadlineExceeded = "deadline_exceeded"
+	etcdClientErrorUnavailable      = "unavailable"
+	etcdClientErrorPermissionDenied = "permission_denied"
+	etcdClientErrorCorrupt          = "corrupt"
+	etcdClientErrorOther            = "other"
+)
+
+var etcdClientErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "etcd_operator_etcd_client_errors_total",
+	Help: "Number of failed requests of the operator to etcd of the EtcdCluster by error type.",
+}, []string{"namespace", "name", "type"})
+
+func init() {
+	metrics.Registry.MustRegister(etcdClientErrors)
+}
+
+// EtcdClientFactory creates etcd clients, so tests can serve etcd requests without running etcd, e.g. by clients
+// of clientv3.NewCtxClient with fake KV, Cluster, Lease, Watcher and Maintenance.
+type EtcdClientFactory interface {
+	NewClient(config clientv3.Config) (*clientv3.Client, error)
+}
+
+// EtcdClientFactoryFunc is a function implementing EtcdClientFactory.
+type EtcdClientFactoryFunc func(config clientv3.Config) (*clientv3.Client, error)
+
+func (f EtcdClientFactoryFunc) NewClient(config clientv3.Config) (*clientv3.Client, error) {
+	return f(config)
+}
+
+// newEtcdClient returns etcd client connected to the given member endpoints.
+// TLS is configured from the server and client certificate secrets of the cluster, and the client authenticates
+// as the root user once authentication is requested.
+func (r *EtcdClusterReconciler) newEtcdClient(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	endpoints []string,
+) (*clientv3.Client, error) {
+	tlsConfig, err := r.getEtcdTLSConfig(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	username, password, err := factory.GetEtcdCredentials(ctx, cluster, r.Client)
+	if err != nil {
+		return nil, err
+	}
+	newClient := clientv3.New
+	if r.EtcdClientFactory != nil {
+		newClient = r.EtcdClientFactory.NewClient
+	}
+	cli, err := newClient(clientv3.Config{
+		Endpoints:   endpoints,
+		TLS:         tlsConfig,
+		Username:    username,
+		Password:    password,
+		DialTimeout: etcdDialTimeout,
+		DialOptions: []grpc.DialOption{grpc.WithChainUnaryInterceptor(countEtcdClientErrors(cluster))},
+		Context:     ctx,
+	})
+	if err != nil {
+		recordEtcdClientError(cluster, err)
+	}
+	return cli, err
+}
+
+// errNoMemberEndpoints is returned by etcdConnection while no member of the cluster is reachable.
+var errNoMemberEndpoints = errors.New("no member is reachable")
+
+// etcdConnection connects one reconciliation to members of the cluster. The client and the TLS settings are set up
+// on first use and shared by the following steps of the reconciliation, so secrets are read and members are dialed
+// once instead of in every step talking to etcd.
+type etcdConnection struct {
+	reconciler *EtcdClusterReconciler
+	cluster    *etcdaenixiov1alpha1.EtcdCluster
+	client     *clientv3.Client
+	tlsConfig  *tls.Config
+	tlsLoaded  bool
+}
+
+// newEtcdConnection returns connection to members of the cluster, which is closed when the reconciliation ends.
+func (r *EtcdClusterReconciler) newEtcdConnection(cluster *etcdaenixiov1alpha1.EtcdCluster) *etcdConnection {
+	return &etcdConnection{reconciler: r, cluster: cluster}
+}
+
+// Client returns etcd client of the reconciliation. It is connected on first use to endpoints of members
+// of the pods, see getMemberEndpoints, and errNoMemberEndpoints is returned while there are none.
+func (c *etcdConnection) Client(ctx context.Context, pods []corev1.Pod) (*clientv3.Client, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+	endpoints := getMemberEndpoints(c.cluster, pods, nil)
+	if len(endpoints) == 0 {
+		return nil, errNoMemberEndpoints
+	}
+	cli, err := c.reconciler.newEtcdClient(ctx, c.cluster, endpoints)
+	if err != nil {
+		return nil, err
+	}
+	c.client = cli
+	return cli, nil
+}
+
+// TLSConfig returns TLS settings of requests to HTTP endpoints of members, nil if etcd serves clients without TLS.
+func (c *etcdConnection) TLSConfig(ctx context.Context) (*tls.Config, error) {
+	if c.tlsLoaded {
+		return c.tlsConfig, nil
+	}
+	tlsConfig, err := c.reconciler.getEtcdTLSConfig(ctx, c.cluster)
+	if err != nil {
+		return nil, err
+	}
+	c.tlsConfig, c.tlsLoaded = tlsConfig, true
+	return tlsConfig, nil
+}
+
+// Close closes the client if it was connected.
+func (c *etcdConnection) Close() {
+	if c.client != nil {
+		_ = c.client.Close()
+		c.client = nil
+	}
+}
+
+// countEtcdClientErrors returns interceptor counting failed unary requests to etcd of the cluster by error type.
+func countEtcdClientErrors(cluster *etcdaenixiov1alpha1.EtcdCluster) grpc.UnaryClientInterceptor {
+	namespace, name := cluster.Namespace, cluster.Name
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if errorType := classifyEtcdClientError(err); errorType != "" {
+			etcdClientErrors.WithLabelValues(namespace, name, errorType).Inc()
+		}
+		return err
+	}
+}
+
+func recordEtcdClientError(cluster *etcdaenixiov1alpha1.EtcdCluster, err error) {
+	if errorType := classifyEtcdClientError(err); errorType != "" {
+		etcdClientErrors.WithLabelValues(cluster.Namespace, cluster.Name, errorType).Inc()
+	}
+}
+
+// classifyEtcdClientError returns type of the etcd client error, or an empty string if there is no error or
+// the request was canceled by the operator.
+func classifyEtcdClientError(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return etcdClientErrorDeadlineExceeded
+	}
+	var code codes.Code
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
+		code = etcdErr.Code()
+	} else if s, ok := status.FromError(err); ok {
+		code = s.Code()
+	} else {
+		return etcdClientErrorOther
+	}
+	desc := rpctypes.ErrorDesc(err)
+	switch {
+	case code == codes.Canceled:
+		return ""
+	case code == codes.DeadlineExceeded:
+		return etcdClientErrorDeadlineExceeded
+	case code == codes.PermissionDenied, code == codes.Unauthenticated,
+		desc == rpctypes.ErrorDesc(rpctypes.ErrGRPCAuthFailed), desc == rpctypes.ErrorDesc(rpctypes.ErrGRPCUserEmpty):
+		return etcdClientErrorPermissionDenied
+	case code == codes.Unavailable && strings.Contains(desc, "authentication handshake failed"):
+		// rejected client certificates are reported by gRPC as unavailable connections
+		return etcdClientErrorPermissionDenied
+	case code == codes.Unavailable:
+		return etcdClientErrorUnavailable
+	case code == codes.DataLoss:
+		return etcdClientErrorCorrupt
+	}
+	return etcdClientErrorOther
+}
+
+func forgetEtcdClientErrorMetrics(namespace, name string) {
+	etcdClientErrors.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// getMemberEndpoints returns endpoints the operator connects to. In the PodDNS connection mode these are client
+// URLs of running member pods accepted by the filter, otherwise endpoints configured by spec.operatorConnection.
+func getMemberEndpoints(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+	accept func(pod *corev1.Pod) bool,
+) []string {
+	if cluster.GetOperatorConnectionMode() != etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+		return factory.GetOperatorEndpoints(cluster)
+	}
+	endpoints := make([]string, 0, len(pods))
+	for i := range pods {
+		if pods[i].Status.PodIP != "" && pods[i].DeletionTimestamp.IsZero() && (accept == nil || accept(&pods[i])) {
+			endpoints = append(endpoints, factory.GetMemberClientEndpoint(cluster, pods[i].Name))
+		}
+	}
+	return endpoints
+}
+
+// getMemberStatuses returns status of members keyed by member name. Members are queried one by one in the PodDNS
+// connection mode, otherwise only members answering on the configured endpoints are reported.
+func getMemberStatuses(
+	ctx context.Context,
+	cli *clientv3.Client,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) map[string]*clientv3.StatusResponse {
+	logger := log.FromContext(ctx)
+	statuses := make(map[string]*clientv3.StatusResponse)
+	if cluster.GetOperatorConnectionMode() == etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+		for _, endpoint := range cli.Endpoints() {
+			for i := range pods {
+				if factory.GetMemberClientEndpoint(cluster, pods[i].Name) != endpoint {
+					continue
+				}
+				reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+				resp, err := cli.Status(reqCtx, endpoint)
+				cancel()
+				if err != nil {
+					logger.V(2).Info("cannot get member status", "member", pods[i].Name, "error", err.Error())
+					continue
+				}
+				statuses[pods[i].Name] = resp
+			}
+		}
+		return statuses
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd members", "error", err.Error())
+		return statuses
+	}
+	names := make(map[uint64]string, len(members.Members))
+	for _, member := range members.Members {
+		names[member.ID] = member.Name
+	}
+	for _, endpoint := range cli.Endpoints() {
+		resp, err := cli.Status(reqCtx, endpoint)
+		if err != nil {
+			logger.V(2).Info("cannot get member status", "endpoint", endpoint, "error", err.Error())
+			continue
+		}
+		if name := names[resp.Header.MemberId]; name != "" {
+			statuses[name] = resp
+		}
+	}
+	return statuses
+}
+
+// getEtcdTLSConfig returns nil if etcd serves clients without TLS.
+func (r *EtcdClusterReconciler) getEtcdTLSConfig(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (*tls.Config, error) {
+	return factory.GetEtcdTLSConfig(ctx, cluster, r.Client)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Etcd client", func() {
+	It("should create clients with the injected factory", func(ctx SpecContext) {
+		var config clientv3.Config
+		r := &EtcdClusterReconciler{
+			EtcdClientFactory: EtcdClientFactoryFunc(func(c clientv3.Config) (*clientv3.Client, error) {
+				config = c
+				return clientv3.NewCtxClient(ctx), nil
+			}),
+		}
+		cli, err := r.newEtcdClient(ctx, &etcdaenixiov1alpha1.EtcdCluster{}, []string{"http://test-0.test-headless.ns.svc:2379"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cli).NotTo(BeNil())
+		Expect(config.Endpoints).To(Equal([]string{"http://test-0.test-headless.ns.svc:2379"}))
+		Expect(config.TLS).To(BeNil())
+	})
+
+	It("should share one client between steps of a reconciliation", func(ctx SpecContext) {
+		dials := 0
+		r := &EtcdClusterReconciler{
+			EtcdClientFactory: EtcdClientFactoryFunc(func(c clientv3.Config) (*clientv3.Client, error) {
+				dials++
+				return clientv3.NewCtxClient(ctx), nil
+			}),
+		}
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+		conn := r.newEtcdConnection(cluster)
+		defer conn.Close()
+
+		_, err := conn.Client(ctx, nil)
+		Expect(err).To(MatchError(errNoMemberEndpoints))
+		pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}}}
+		first, err := conn.Client(ctx, pods)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := conn.Client(ctx, pods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+		Expect(dials).To(Equal(1))
+	})
+})
+
+var _ = Describe("Etcd client errors", func() {
+	DescribeTable("should classify errors",
+		func(err error, errorType string) {
+			Expect(classifyEtcdClientError(err)).To(Equal(errorType))
+		},
+		Entry("no error", nil, ""),
+		Entry("canceled request", fmt.Errorf("list members: %w", context.Canceled), ""),
+		Entry("request timeout", context.DeadlineExceeded, etcdClientErrorDeadlineExceeded),
+		Entry("server timeout", rpctypes.ErrGRPCTimeout, etcdClientErrorUnavailable),
+		Entry("deadline status", status.Error(codes.DeadlineExceeded, "context deadline exceeded"), etcdClientErrorDeadlineExceeded),
+		Entry("no leader", rpctypes.ErrNoLeader, etcdClientErrorUnavailable),
+		Entry("permission denied", rpctypes.ErrGRPCPermissionDenied, etcdClientErrorPermissionDenied),
+		Entry("invalid password", rpctypes.ErrAuthFailed, etcdClientErrorPermissionDenied),
+		Entry("rejected certificate", status.Error(codes.Unavailable,
+			"connection error: desc = \"transport: authentication handshake failed: remote error: tls: bad certificate\""),
+			etcdClientErrorPermissionDenied),
+		Entry("corrupt cluster", rpctypes.ErrGRPCCorrupt, etcdClientErrorCorrupt),
+		Entry("unknown error", errors.New("unexpected EOF"), etcdClientErrorOther),
+	)
+
+	It("should count failed requests of the cluster", func(ctx SpecContext) {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "errors", Namespace: "ns"}}
+		DeferCleanup(forgetEtcdClientErrorMetrics, cluster.Namespace, cluster.Name)
+		interceptor := countEtcdClientErrors(cluster)
+		invoke := func(err error) grpc.UnaryInvoker {
+			return func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return err }
+		}
+		Expect(interceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, invoke(nil))).To(Succeed())
+		Expect(interceptor(ctx, "/etcdserverpb.KV/Range", nil, nil, nil, invoke(rpctypes.ErrGRPCPermissionDenied))).
+			To(MatchError(rpctypes.ErrGRPCPermissionDenied))
+		Expect(testutil.ToFloat64(etcdClientErrors.WithLabelValues("ns", "errors", etcdClientErrorPermissionDenied))).
+			To(Equal(float64(1)))
+
+		forgetEtcdClientErrorMetrics(cluster.Namespace, cluster.Name)
+		Expect(etcdClientErrors.DeleteLabelValues("ns", "errors", etcdClientErrorPermissionDenied)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Member endpoints", func() {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "test-2"}},
+	}
+
+	It("should connect to running member pods by default", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+		Expect(getMemberEndpoints(cluster, pods, nil)).To(Equal([]string{
+			"http://test-0.test-headless.ns.svc:2379",
+			"http://test-1.test-headless.ns.svc:2379",
+		}))
+		Expect(getMemberEndpoints(cluster, pods, func(pod *corev1.Pod) bool { return pod.Name != "test-0" })).
+			To(Equal([]string{"http://test-1.test-headless.ns.svc:2379"}))
+	})
+
+	It("should connect to configured endpoints regardless of pods", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				OperatorConnection: &etcdaenixiov1alpha1.OperatorConnectionSpec{
+					Mode:      etcdaenixiov1alpha1.OperatorConnectionEndpoints,
+					Endpoints: []string{"https://gateway.mesh:2379"},
+				},
+			},
+		}
+		Expect(getMemberEndpoints(cluster, pods, nil)).To(Equal([]string{"https://gateway.mesh:2379"}))
+	})
+})
@@ -20,29 +20,74 @@ import (
 	"context"
 	goerrors "errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	policyv1 "k8s.io/api/policy/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
 	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/images"
+	"github.com/aenix-io/etcd-operator/internal/maintenance"
+	"github.com/aenix-io/etcd-operator/internal/notify"
 )
 
 // EtcdClusterReconciler reconciles a EtcdCluster object
 type EtcdClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Maintenance limits maintenance calls made to etcd members of all clusters.
+	Maintenance *maintenance.Limiter
+	// UpgradeTimeout is the time updated member may stay not ready before the upgrade is rolled back.
+	// Zero disables automatic rollback.
+	UpgradeTimeout time.Duration
+	// BackupRequiredNamespaces selects namespaces whose clusters are reported if they have no backup configured.
+	// Nil or empty selector disables the reporting.
+	BackupRequiredNamespaces labels.Selector
+	// ImageMirrors rewrites registries of images rendered into etcd pods.
+	ImageMirrors images.Mirrors
+	// ExperimentalOptions passes spec.experimentalOptions of clusters to etcd, they are dropped if disabled.
+	ExperimentalOptions bool
+	// MinReconcilePeriod and MaxReconcilePeriod bound spec.reconcilePeriod of clusters.
+	MinReconcilePeriod time.Duration
+	MaxReconcilePeriod time.Duration
+	// ImageVerifier refuses to roll out etcd images with unverified signatures. Nil disables the verification.
+	ImageVerifier images.Verifier
+	// Notifications tracks events sent to notification webhooks of clusters. Nil disables notifications.
+	Notifications *notify.Tracker
+	// NotificationClient sends notifications, http.DefaultClient is used if nil.
+	NotificationClient *http.Client
+	// Bloat detects space left behind by large deletions for automatic defragmentation. Nil disables it.
+	Bloat *maintenance.BloatDetector
+	// Recorder records events of clusters. Nil disables events.
+	Recorder record.EventRecorder
+	// Clock tells time to time-dependent decisions, so they can be tested deterministically. Real clock if nil.
+	Clock clock.PassiveClock
+	// EtcdClientFactory creates clients of etcd members. Clients connect with clientv3.New if nil.
+	EtcdClientFactory EtcdClientFactory
+	// PriorityWorkers reconcile unhealthy clusters from a queue of their own ahead of routine resyncs.
+	// Zero disables the priority queue.
+	PriorityWorkers int
+
+	locks clusterLocks
 }
 
 // +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdclusters,verbs=get;list;watch;create;update;patch;delete
@@ -50,39 +95,178 @@ type EtcdClusterReconciler struct {
 // +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdclusters/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;watch;delete;patch
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;create;delete;update;patch;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete;patch
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=patch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete;patch
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="storage.k8s.io",resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdusers;etcdroles,verbs=get;list;watch
 // +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;create;delete;update;patch;list;watch
+// +kubebuilder:rbac:groups="apps",resources=controllerrevisions,verbs=get;list;watch
 // +kubebuilder:rbac:groups="policy",resources=poddisruptionbudgets,verbs=get;create;delete;update;patch;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="trust.cert-manager.io",resources=bundles,verbs=get;create;delete;update
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;create;delete;update
 
 // Reconcile checks CR and current cluster state and performs actions to transform current state to desired.
 func (r *EtcdClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.V(2).Info("reconciling object", "namespaced_name", req.NamespacedName)
+	// the cluster may be taken from the regular and the priority queues at the same time
+	defer r.locks.lock(req.NamespacedName)()
+	ctx = images.WithMirrors(ctx, r.ImageMirrors)
+	ctx = factory.WithExperimentalOptions(ctx, r.ExperimentalOptions)
+	ctx = withDecisionLog(ctx, r.getClock())
 	instance := &etcdaenixiov1alpha1.EtcdCluster{}
 	err := r.Get(ctx, req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.V(2).Info("object not found", "namespaced_name", req.NamespacedName)
+			if r.Maintenance != nil {
+				r.Maintenance.Forget(maintenance.MemberKey(req.Namespace, req.Name, ""))
+			}
+			if r.Bloat != nil {
+				r.Bloat.Forget(maintenance.MemberKey(req.Namespace, req.Name, ""))
+			}
+			forgetBackupMetric(req.Namespace, req.Name)
+			forgetClockOffsetMetrics(req.Namespace, req.Name)
+			forgetEtcdClientErrorMetrics(req.Namespace, req.Name)
+			if r.Notifications != nil {
+				r.Notifications.Forget(notify.WebhookKey(req.Namespace, req.Name, ""))
+			}
 			return ctrl.Result{}, nil
 		}
 		// Error retrieving object, requeue
 		return reconcile.Result{}, err
 	}
-	// If object is being deleted, skipping reconciliation
+	observeStatus(ctx, instance)
+	// If object is being deleted, cleaning up published CA and skipping reconciliation
 	if !instance.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(instance, factory.CAPublicationFinalizer) {
+			return ctrl.Result{}, r.removeCAPublication(ctx, instance)
+		}
 		return reconcile.Result{}, nil
 	}
 
+	// observed clusters are deployed outside of the operator, only their health is reported
+	if instance.IsObserved() {
+		return r.reconcileObserved(ctx, instance)
+	}
+
+	// published CA is kept in other namespaces, so it is protected by finalizer
+	if factory.IsCAPublicationEnabled(instance) && !controllerutil.ContainsFinalizer(instance, factory.CAPublicationFinalizer) {
+		controllerutil.AddFinalizer(instance, factory.CAPublicationFinalizer)
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if !factory.IsCAPublicationEnabled(instance) && controllerutil.ContainsFinalizer(instance, factory.CAPublicationFinalizer) {
+		if err := r.removeCAPublication(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// fill conditions
 	if len(instance.Status.Conditions) == 0 {
 		factory.FillConditions(instance)
 	}
 
+	// stop updating objects of clusters failing to bootstrap until a clean retry is requested
+	bootstrapHeld, bootstrapRequeueAfter, err := r.checkBootstrap(ctx, instance)
+	if err != nil {
+		logger.Error(err, "cannot check bootstrap")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check bootstrap: %w", err))
+	}
+	if bootstrapHeld {
+		result, err := r.updateStatus(ctx, instance)
+		if err == nil && !result.Requeue {
+			result.RequeueAfter = bootstrapRequeueAfter
+		}
+		return result, err
+	}
+
+	// new spec is rolled out again after rollback of failed upgrade
+	clearUpgradeRollback(instance)
+
+	// refuse to roll out etcd image with unverified signature
+	r.verifyImage(ctx, instance)
+
+	// steps talking to etcd share one connection to members
+	conn := r.newEtcdConnection(instance)
+	defer conn.Close()
+
+	// move member data from emptyDir to volume claims
+	migrationRequeueAfter, err := r.migrateStorage(ctx, instance, conn)
+	if err != nil {
+		logger.Error(err, "cannot migrate storage")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot migrate storage: %w", err))
+	}
+
+	// serialize operations requested simultaneously
+	desired, err := r.lockOperation(ctx, instance)
+	if err != nil {
+		logger.Error(err, "cannot lock operation")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot lock operation: %w", err))
+	}
+
+	// keep members whose removal is unsafe now
+	desired, scaleDownRequeueAfter, err := r.deferUnsafeScaleDown(ctx, instance, conn, desired)
+	if err != nil {
+		logger.Error(err, "cannot check scale-down")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check scale-down: %w", err))
+	}
+
+	// issue certificates of the internal CA before members mount them
+	renewalRequeueAfter, err := r.ensureInternalCA(ctx, desired)
+	if err != nil {
+		logger.Error(err, "cannot issue certificates of the internal CA")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot issue certificates of the internal CA: %w", err))
+	}
+
+	// generate the root password before clients authenticate with it
+	if err := r.ensureRootCredentials(ctx, desired); err != nil {
+		logger.Error(err, "cannot generate root credentials")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot generate root credentials: %w", err))
+	}
+
+	// generate the key tokens are signed with before members mount it
+	jwtKeyRequeueAfter, err := r.ensureJWTKey(ctx, desired, conn)
+	if err != nil {
+		logger.Error(err, "cannot generate JWT key")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot generate JWT key: %w", err))
+	}
+
+	// restart members loading changed TLS secrets only while all of them are healthy
+	ctx, tlsRolloutRequeueAfter, err := r.holdTLSRollout(ctx, desired, conn)
+	if err != nil {
+		logger.Error(err, "cannot check rollout of TLS secrets")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check rollout of TLS secrets: %w", err))
+	}
+
 	// ensure managed resources
-	if err := r.ensureClusterObjects(ctx, instance); err != nil {
+	if err := r.ensureClusterObjects(ctx, desired); err != nil {
 		logger.Error(err, "cannot create Cluster auxiliary objects")
 		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot create Cluster auxiliary objects: %w", err))
 	}
 
+	// keep the history of applied spec generations for audits
+	recordAppliedGeneration(instance, r.getClock().Now())
+
+	// describe etcdctl access for humans
+	if err := setAdminAccessStatus(ctx, instance); err != nil {
+		logger.Error(err, "cannot describe admin access")
+	}
+
+	// run the break-glass debug shell requested by annotation
+	debugShellRequeueAfter, err := r.reconcileDebugShell(ctx, instance)
+	if err != nil {
+		logger.Error(err, "cannot reconcile debug shell")
+		return r.updateStatusOnErr(ctx, instance, err)
+	}
+
 	// set cluster initialization condition
 	factory.SetCondition(instance, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionInitialized).
 		WithStatus(true).
@@ -90,6 +274,111 @@ func (r *EtcdClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		WithMessage(string(etcdaenixiov1alpha1.EtcdInitCondPosMessage)).
 		Complete())
 
+	// warn about data kept in emptyDir
+	if setEphemeralStorageCondition(instance) {
+		condition := factory.GetCondition(instance, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)
+		r.recordEvent(instance, corev1.EventTypeWarning, condition.Reason, condition.Message)
+	}
+
+	// publish DNS names clients connect to
+	setEndpointsStatus(instance)
+
+	// report external addresses external clients can not verify the server certificate for
+	if err := r.updateCertificateSANsCondition(ctx, instance); err != nil {
+		logger.Error(err, "failed to check server certificate")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check server certificate: %w", err))
+	}
+
+	// report clusters without nodes the etcd image runs on
+	if err := r.updateNodeCompatibilityCondition(ctx, instance); err != nil {
+		logger.Error(err, "failed to check node compatibility")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check node compatibility: %w", err))
+	}
+
+	// reflect failing member pods in status
+	pods, err := r.listClusterPods(ctx, instance)
+	if err != nil {
+		logger.Error(err, "failed to list cluster pods")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot list Cluster pods: %w", err))
+	}
+	setMemberFailureCondition(instance, getMemberFailures(pods))
+
+	// reflect versions running on members
+	r.updateVersionStatus(ctx, instance, conn, pods)
+
+	// record the etcd cluster ID and detect members with data of another cluster
+	if err := r.updateMemberIdentity(ctx, instance); err != nil {
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot record member identity: %w", err))
+	}
+
+	// mirror alarms raised by members
+	r.updateAlarmStatus(ctx, instance, conn, pods)
+
+	// detect members running with stale etcd settings
+	r.updateConfigDriftCondition(ctx, instance, conn, pods)
+
+	// reflect request limits members run with
+	updateRequestLimitsStatus(instance, pods)
+
+	// detect clocks of members drifting apart
+	r.updateClockSkewCondition(ctx, instance, conn, pods)
+
+	// label member pods with their etcd member ID, role and zone
+	if err := r.updateMemberLabels(ctx, instance, conn, pods); err != nil {
+		logger.Error(err, "failed to label member pods")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot label member pods: %w", err))
+	}
+
+	// open readiness gates of members healthy according to etcd
+	readinessRequeueAfter, err := r.updateMemberReadinessGates(ctx, instance, conn, pods)
+	if err != nil {
+		logger.Error(err, "failed to update member readiness gates")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot update member readiness gates: %w", err))
+	}
+
+	// move members to peer URLs their pods advertise
+	peerURLsRequeueAfter, err := r.migratePeerURLs(ctx, instance, conn, pods)
+	if err != nil {
+		logger.Error(err, "failed to migrate member peer URLs")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot migrate member peer URLs: %w", err))
+	}
+
+	// report missing backups in namespaces requiring them
+	if err := r.updateBackupCondition(ctx, instance); err != nil {
+		logger.Error(err, "failed to check cluster backup")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check Cluster backup: %w", err))
+	}
+
+	// roll back upgrade leaving members unhealthy
+	upgradeRequeueAfter, err := r.checkUpgrade(ctx, instance, pods)
+	if err != nil {
+		logger.Error(err, "failed to check cluster upgrade")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check Cluster upgrade: %w", err))
+	}
+
+	// reclaim space left behind by large deletions
+	defragRequeueAfter := r.reconcileAutoDefrag(ctx, instance, conn, pods)
+
+	// recreate member whose volume was lost with its node
+	replaceRequeueAfter, err := r.replaceLostMember(ctx, instance, conn, pods)
+	if err != nil {
+		logger.Error(err, "failed to replace lost member")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot replace lost member: %w", err))
+	}
+
+	// grow member volumes to the requested storage
+	expansionRequeueAfter, err := r.expandVolumes(ctx, instance, pods)
+	if err != nil {
+		logger.Error(err, "failed to expand member volumes")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot expand member volumes: %w", err))
+	}
+
+	// keep or garbage-collect volumes of deleted and removed members
+	if err := r.reconcileVolumeRetention(ctx, instance, pods); err != nil {
+		logger.Error(err, "failed to apply volume retention policy")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot apply volume retention policy: %w", err))
+	}
+
 	// check sts condition
 	clusterReady, err := r.isStatefulSetReady(ctx, instance)
 	if err != nil {
@@ -97,6 +386,13 @@ func (r *EtcdClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check Cluster readiness: %w", err))
 	}
 
+	// tell rollouts from failures, so health checks of tools like Argo CD do not depend on Ready alone
+	rolledOut, err := r.isRolloutComplete(ctx, instance, pods)
+	if err != nil {
+		logger.Error(err, "failed to check cluster rollout")
+		return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot check Cluster rollout: %w", err))
+	}
+
 	// set cluster readiness condition
 	existingCondition := factory.GetCondition(instance, etcdaenixiov1alpha1.EtcdConditionReady)
 	if existingCondition != nil &&
@@ -104,24 +400,96 @@ func (r *EtcdClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		!clusterReady {
 		// if we are still "waiting for first quorum establishment" and the StatefulSet
 		// isn't ready yet, don't update the EtcdConditionReady, but circuit-break.
+		setHealthConditions(instance, rolledOut)
 		return r.updateStatus(ctx, instance)
 	}
 
 	// otherwise, EtcdConditionReady is set to true/false with the reason that the
 	// StatefulSet is or isn't ready.
 	reason := etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady
-	message := etcdaenixiov1alpha1.EtcdReadyCondNegMessage
+	message := string(etcdaenixiov1alpha1.EtcdReadyCondNegMessage)
 	if clusterReady {
 		reason = etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady
-		message = etcdaenixiov1alpha1.EtcdReadyCondPosMessage
+		message = string(etcdaenixiov1alpha1.EtcdReadyCondPosMessage)
+
+		// ready pods do not guarantee that lease and watch requests are served
+		if failure := r.checkClusterHealth(ctx, instance, conn, pods); failure != nil {
+			clusterReady = false
+			reason = failure.reason
+			message = failure.Error()
+		}
 	}
 
 	factory.SetCondition(instance, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).
 		WithStatus(clusterReady).
 		WithReason(string(reason)).
-		WithMessage(string(message)).
+		WithMessage(message).
 		Complete())
-	return r.updateStatus(ctx, instance)
+	setHealthConditions(instance, rolledOut)
+
+	// enable authentication once the cluster serves clients
+	if clusterReady {
+		if err := r.reconcileAuth(ctx, instance, conn, pods); err != nil {
+			logger.Error(err, "failed to reconcile etcd authentication")
+			return r.updateStatusOnErr(ctx, instance, fmt.Errorf("cannot reconcile etcd authentication: %w", err))
+		}
+
+		// detect etcd users and roles no EtcdUser or EtcdRole declares
+		r.updateAuthDriftCondition(ctx, instance, conn, pods)
+	}
+
+	// notify about critical events
+	r.sendNotifications(ctx, instance, pods)
+
+	result, err := r.updateStatus(ctx, instance)
+	if err == nil && !result.Requeue {
+		requeueAfter := []time.Duration{
+			upgradeRequeueAfter, defragRequeueAfter, replaceRequeueAfter, scaleDownRequeueAfter,
+			debugShellRequeueAfter, readinessRequeueAfter, peerURLsRequeueAfter, expansionRequeueAfter,
+			migrationRequeueAfter, renewalRequeueAfter, tlsRolloutRequeueAfter, bootstrapRequeueAfter,
+			jwtKeyRequeueAfter, r.getReconcilePeriod(instance),
+		}
+		if isImageRejected(instance) {
+			requeueAfter = append(requeueAfter, imageVerificationRetryInterval)
+		}
+		if instance.Spec.LeaderService {
+			requeueAfter = append(requeueAfter, leaderPollInterval)
+		}
+		result.RequeueAfter = earliestRequeue(requeueAfter...)
+	}
+	return result, err
+}
+
+// getClock returns the clock of the reconciler, the real clock if none is set.
+func (r *EtcdClusterReconciler) getClock() clock.PassiveClock {
+	if r.Clock == nil {
+		return clock.RealClock{}
+	}
+	return r.Clock
+}
+
+// getReconcilePeriod returns spec.reconcilePeriod of the cluster bounded by the operator limits.
+// Zero means the cluster is reconciled only on changes.
+func (r *EtcdClusterReconciler) getReconcilePeriod(cluster *etcdaenixiov1alpha1.EtcdCluster) time.Duration {
+	if cluster.Spec.ReconcilePeriod == nil || cluster.Spec.ReconcilePeriod.Duration <= 0 {
+		return 0
+	}
+	period := max(cluster.Spec.ReconcilePeriod.Duration, r.MinReconcilePeriod)
+	if r.MaxReconcilePeriod > 0 {
+		period = min(period, r.MaxReconcilePeriod)
+	}
+	return period
+}
+
+// earliestRequeue returns the shortest of positive durations, zero if there is none.
+func earliestRequeue(durations ...time.Duration) time.Duration {
+	var earliest time.Duration
+	for _, d := range durations {
+		if d > 0 && (earliest == 0 || d < earliest) {
+			earliest = d
+		}
+	}
+	return earliest
 }
 
 // ensureClusterObjects creates or updates all objects owned by cluster CR
@@ -130,26 +498,67 @@ func (r *EtcdClusterReconciler) ensureClusterObjects(
 	if err := factory.CreateOrUpdateClusterStateConfigMap(ctx, cluster, r.Client); err != nil {
 		return err
 	}
+	if err := factory.CreateOrUpdateLogShipperConfigMap(ctx, cluster, r.Client); err != nil {
+		return err
+	}
 	if err := factory.CreateOrUpdateHeadlessService(ctx, cluster, r.Client); err != nil {
 		return err
 	}
-	if err := factory.CreateOrUpdateStatefulSet(ctx, cluster, r.Client); err != nil {
+	if err := r.ensureCertificates(ctx, cluster); err != nil {
+		return err
+	}
+	if err := factory.CreateMemberVolumeClaims(ctx, cluster, r.Client); err != nil {
+		return err
+	}
+	if isUpgradeRolledBack(cluster) {
+		log.FromContext(ctx).V(2).Info("statefulset is kept on rolled back revision until the spec is changed")
+		recordSkipped(ctx, "rollout: kept on rolled back revision until the spec is changed")
+	} else if isStorageMigrationHoldingMembers(cluster) {
+		log.FromContext(ctx).V(2).Info("statefulset is kept until members on emptyDir are replaced")
+		recordSkipped(ctx, "rollout: statefulset is kept until members on emptyDir are replaced")
+	} else if isImageRejected(cluster) {
+		log.FromContext(ctx).V(2).Info("members are kept until the etcd image signature is verified")
+		recordSkipped(ctx, "rollout: etcd image signature is not verified")
+	} else if cluster.ManagesMemberPods() {
+		if err := factory.CreateOrUpdateMemberPods(ctx, cluster, r.Client); err != nil {
+			return err
+		}
+	} else if err := factory.CreateOrUpdateStatefulSet(ctx, cluster, r.Client); err != nil {
 		return err
 	}
 	if err := factory.CreateOrUpdateClientService(ctx, cluster, r.Client); err != nil {
 		return err
 	}
+	if err := factory.CreateOrUpdateLeaderService(ctx, cluster, r.Client); err != nil {
+		return err
+	}
 	if err := factory.CreateOrUpdatePdb(ctx, cluster, r.Client); err != nil {
 		return err
 	}
+	if err := factory.CreateOrUpdateCAPublication(ctx, cluster, r.Client); err != nil {
+		return err
+	}
+	if err := factory.CreateOrUpdateAdminAccessSecret(ctx, cluster, r.Client); err != nil {
+		return err
+	}
 	return nil
 }
 
+// removeCAPublication deletes published CA certificate and removes the finalizer protecting it
+func (r *EtcdClusterReconciler) removeCAPublication(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	if err := factory.DeleteCAPublication(ctx, cluster, r.Client); err != nil {
+		return fmt.Errorf("cannot delete published CA certificate: %w", err)
+	}
+	controllerutil.RemoveFinalizer(cluster, factory.CAPublicationFinalizer)
+	return r.Update(ctx, cluster)
+}
+
 // updateStatusOnErr wraps error and updates EtcdCluster status
 func (r *EtcdClusterReconciler) updateStatusOnErr(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster, err error) (ctrl.Result, error) {
 	// The function 'updateStatusOnErr' will always return non-nil error. Hence, the ctrl.Result will always be ignored.
 	// Therefore, the ctrl.Result returned by 'updateStatus' function can be discarded.
 	// REF: https://pkg.go.dev/sigs.k8s.io/controller-runtime/pkg/reconcile@v0.17.3#Reconciler
+	recordError(ctx, err)
 	_, statusErr := r.updateStatus(ctx, cluster)
 	if statusErr != nil {
 		return ctrl.Result{}, goerrors.Join(statusErr, err)
@@ -157,11 +566,20 @@ func (r *EtcdClusterReconciler) updateStatusOnErr(ctx context.Context, cluster *
 	return ctrl.Result{}, err
 }
 
-// updateStatus updates EtcdCluster status and returns error and requeue in case status could not be updated due to conflict
+// updateStatus updates EtcdCluster status and returns error and requeue in case status could not be updated due to conflict.
+// The update is skipped if the reconciliation did not change the status.
 func (r *EtcdClusterReconciler) updateStatus(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	setLastReconcile(ctx, cluster)
+	if !isStatusChanged(ctx, cluster) {
+		logger.V(2).Info("cluster status did not change, skipping update")
+		statusUpdates.WithLabelValues("skipped").Inc()
+		return ctrl.Result{}, nil
+	}
+	statusUpdates.WithLabelValues("written").Inc()
 	err := r.Status().Update(ctx, cluster)
 	if err == nil {
+		observeStatus(ctx, cluster)
 		return ctrl.Result{}, nil
 	}
 	if errors.IsConflict(err) {
@@ -173,22 +591,43 @@ func (r *EtcdClusterReconciler) updateStatus(ctx context.Context, cluster *etcda
 }
 
 // isStatefulSetReady gets managed StatefulSet and checks its readiness.
+// In the Pods member management mode readiness of member pods is checked instead.
 func (r *EtcdClusterReconciler) isStatefulSetReady(ctx context.Context, c *etcdaenixiov1alpha1.EtcdCluster) (bool, error) {
+	if c.ManagesMemberPods() {
+		return r.areMemberPodsReady(ctx, c)
+	}
 	sts := &appsv1.StatefulSet{}
 	err := r.Get(ctx, client.ObjectKeyFromObject(c), sts)
 	if err == nil {
-		return sts.Status.ReadyReplicas == *sts.Spec.Replicas, nil
+		return int(sts.Status.ReadyReplicas) >= c.MinHealthyMembers(int(*sts.Spec.Replicas)), nil
 	}
 	return false, client.IgnoreNotFound(err)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *EtcdClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&etcdaenixiov1alpha1.EtcdCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&etcdaenixiov1alpha1.EtcdCluster{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+		))).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
 		Owns(&corev1.Service{}).
 		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(mapPodToCluster)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToClusters)).
 		Complete(r)
+	if err != nil || r.PriorityWorkers <= 0 {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(priorityControllerName).
+		For(&etcdaenixiov1alpha1.EtcdCluster{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(isUnhealthyClusterObject),
+		)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.mapPodToUnhealthyCluster)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.PriorityWorkers}).
+		Complete(reconcile.Func(r.reconcileUnhealthy))
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package controller
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
@@ -72,7 +74,8 @@ var _ = Describe("EtcdCluster Controller", func() {
 				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
 					Replicas: ptr.To(int32(3)),
 					Storage: etcdaenixiov1alpha1.StorageSpec{
-						EmptyDir: &corev1.EmptyDirVolumeSource{},
+						EmptyDir:  &corev1.EmptyDirVolumeSource{},
+						Ephemeral: true,
 					},
 				},
 			}
@@ -115,11 +118,21 @@ var _ = Describe("EtcdCluster Controller", func() {
 				_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&etcdcluster)})
 				Expect(err).ToNot(HaveOccurred())
 				Eventually(Get(&etcdcluster)).Should(Succeed())
-				Expect(etcdcluster.Status.Conditions).To(HaveLen(2))
+				Expect(etcdcluster.Status.Conditions).To(HaveLen(6))
 				Expect(etcdcluster.Status.Conditions[0].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionInitialized))
 				Expect(etcdcluster.Status.Conditions[0].Status).To(Equal(metav1.ConditionStatus("True")))
 				Expect(etcdcluster.Status.Conditions[1].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionReady))
 				Expect(etcdcluster.Status.Conditions[1].Status).To(Equal(metav1.ConditionStatus("False")))
+				Expect(etcdcluster.Status.Conditions[2].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionEphemeralStorage))
+				Expect(etcdcluster.Status.Conditions[2].Status).To(Equal(metav1.ConditionStatus("True")))
+				Expect(etcdcluster.Status.Conditions[3].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionMemberFailure))
+				Expect(etcdcluster.Status.Conditions[3].Status).To(Equal(metav1.ConditionStatus("False")))
+				Expect(etcdcluster.Status.Conditions[4].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionProgressing))
+				Expect(etcdcluster.Status.Conditions[4].Status).To(Equal(metav1.ConditionStatus("True")))
+				Expect(etcdcluster.Status.Conditions[5].Type).To(Equal(etcdaenixiov1alpha1.EtcdConditionDegraded))
+				Expect(etcdcluster.Status.Conditions[5].Status).To(Equal(metav1.ConditionStatus("True")))
+				Expect(etcdcluster.Status.Conditions[5].Reason).To(Equal(etcdaenixiov1alpha1.EtcdConditionEphemeralStorage))
+				Expect(etcdcluster.Status.ObservedGeneration).To(Equal(etcdcluster.Generation))
 			})
 
 			By("reconciling owned ConfigMap", func() {
@@ -166,3 +179,23 @@ var _ = Describe("EtcdCluster Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("Reconcile period", func() {
+	reconciler := &EtcdClusterReconciler{MinReconcilePeriod: 10 * time.Second, MaxReconcilePeriod: time.Hour}
+
+	It("should bound reconcile period of the cluster by operator limits", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		Expect(reconciler.getReconcilePeriod(cluster)).To(BeZero())
+		cluster.Spec.ReconcilePeriod = &metav1.Duration{Duration: 30 * time.Second}
+		Expect(reconciler.getReconcilePeriod(cluster)).To(Equal(30 * time.Second))
+		cluster.Spec.ReconcilePeriod.Duration = time.Second
+		Expect(reconciler.getReconcilePeriod(cluster)).To(Equal(10 * time.Second))
+		cluster.Spec.ReconcilePeriod.Duration = 24 * time.Hour
+		Expect(reconciler.getReconcilePeriod(cluster)).To(Equal(time.Hour))
+	})
+
+	It("should requeue at the earliest positive duration", func() {
+		Expect(earliestRequeue(0, 0)).To(BeZero())
+		Expect(earliestRequeue(0, time.Minute, 30*time.Second)).To(Equal(30 * time.Second))
+	})
+})
@@ -0,0 +1,329 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// maxNotReadyClustersInMessage limits namespaces listed in the Ready condition message of a cluster set.
+const maxNotReadyClustersInMessage = 5
+
+// EtcdClusterSetReconciler reconciles a EtcdClusterSet object
+type EtcdClusterSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdclustersets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdclustersets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdclustersets/finalizers,verbs=update
+
+// Reconcile creates an EtcdCluster from the template of the set in every selected namespace, deletes clusters
+// of the set in namespaces which are no longer selected and aggregates statuses of the clusters.
+func (r *EtcdClusterSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	set := &etcdaenixiov1alpha1.EtcdClusterSet{}
+	if err := r.Get(ctx, req.NamespacedName, set); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(2).Info("object not found", "name", req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	// Clusters are garbage collected by their owner references
+	if !set.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	observed := set.Status.DeepCopy()
+
+	selector, err := metav1.LabelSelectorAsSelector(&set.Spec.NamespaceSelector)
+	if err != nil {
+		set.Status.ObservedGeneration = set.Generation
+		meta.SetStatusCondition(&set.Status.Conditions, metav1.Condition{
+			Type:               etcdaenixiov1alpha1.EtcdConditionReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             string(etcdaenixiov1alpha1.EtcdCondTypeInvalidSelector),
+			Message:            err.Error(),
+			ObservedGeneration: set.Generation,
+		})
+		return ctrl.Result{}, r.updateSetStatus(ctx, set, observed)
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot list namespaces: %w", err)
+	}
+	clusters := &etcdaenixiov1alpha1.EtcdClusterList{}
+	if err := r.List(ctx, clusters, client.MatchingLabels{etcdaenixiov1alpha1.ClusterSetLabel: set.Name}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot list clusters of the set: %w", err)
+	}
+	hash, err := hashClusterTemplate(set.Spec.Template)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	selected := make(map[string]bool, len(namespaces.Items))
+	statuses := make([]etcdaenixiov1alpha1.EtcdClusterSetClusterStatus, 0, len(namespaces.Items))
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		selected[ns.Name] = true
+		cluster, err := r.ensureSetCluster(ctx, set, ns, hash)
+		if err != nil {
+			logger.Error(err, "cannot reconcile cluster of the set", "namespace", ns.Name)
+		}
+		statuses = append(statuses, getSetClusterStatus(ns.Name, set.ClusterName(), cluster, err))
+	}
+
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if (selected[cluster.Namespace] && cluster.Name == set.ClusterName()) || !metav1.IsControlledBy(cluster, set) {
+			continue
+		}
+		if err := r.Delete(ctx, cluster); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("cannot delete cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
+		}
+		logger.Info("deleted cluster which is no longer selected by the set", "namespace", cluster.Namespace, "name", cluster.Name)
+	}
+
+	setClusterSetStatus(set, statuses)
+	return ctrl.Result{}, r.updateSetStatus(ctx, set, observed)
+}
+
+// updateSetStatus updates the status of the set unless it is equal to the observed one.
+func (r *EtcdClusterSetReconciler) updateSetStatus(
+	ctx context.Context,
+	set *etcdaenixiov1alpha1.EtcdClusterSet,
+	observed *etcdaenixiov1alpha1.EtcdClusterSetStatus,
+) error {
+	if equality.Semantic.DeepEqual(observed, &set.Status) {
+		log.FromContext(ctx).V(2).Info("cluster set status did not change, skipping update")
+		return nil
+	}
+	return r.Status().Update(ctx, set)
+}
+
+// ensureSetCluster creates the cluster of the set in the namespace or updates it if the template has changed since.
+// Clusters which exist but are not controlled by the set are left untouched.
+func (r *EtcdClusterSetReconciler) ensureSetCluster(
+	ctx context.Context,
+	set *etcdaenixiov1alpha1.EtcdClusterSet,
+	ns *corev1.Namespace,
+	hash string,
+) (*etcdaenixiov1alpha1.EtcdCluster, error) {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: ns.Name, Name: set.ClusterName()}, cluster)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	exists := err == nil
+	if exists {
+		if !metav1.IsControlledBy(cluster, set) {
+			return nil, fmt.Errorf("EtcdCluster %s already exists and is not managed by the set", cluster.Name)
+		}
+		if cluster.Annotations[etcdaenixiov1alpha1.ClusterSetTemplateHashAnnotation] == hash {
+			return cluster, nil
+		}
+	}
+
+	desired, err := r.generateSetCluster(set, ns, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := r.Create(ctx, desired); err != nil {
+			return nil, err
+		}
+		return desired, nil
+	}
+
+	if cluster.Labels == nil {
+		cluster.Labels = map[string]string{}
+	}
+	maps.Copy(cluster.Labels, desired.Labels)
+	if cluster.Annotations == nil {
+		cluster.Annotations = map[string]string{}
+	}
+	maps.Copy(cluster.Annotations, desired.Annotations)
+	cluster.Spec = desired.Spec
+	if err := r.Update(ctx, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// generateSetCluster renders the cluster of the set in the namespace. Namespace storage defaults and the webhook
+// defaults are applied up front, so updates from the template render the same spec as the initial creation.
+func (r *EtcdClusterSetReconciler) generateSetCluster(
+	set *etcdaenixiov1alpha1.EtcdClusterSet,
+	ns *corev1.Namespace,
+	hash string,
+) (*etcdaenixiov1alpha1.EtcdCluster, error) {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        set.ClusterName(),
+			Namespace:   ns.Name,
+			Labels:      maps.Clone(set.Spec.Template.Labels),
+			Annotations: maps.Clone(set.Spec.Template.Annotations),
+		},
+		Spec: *set.Spec.Template.Spec.DeepCopy(),
+	}
+	if cluster.Labels == nil {
+		cluster.Labels = map[string]string{}
+	}
+	cluster.Labels[etcdaenixiov1alpha1.ClusterSetLabel] = set.Name
+	if cluster.Annotations == nil {
+		cluster.Annotations = map[string]string{}
+	}
+	cluster.Annotations[etcdaenixiov1alpha1.ClusterSetTemplateHashAnnotation] = hash
+
+	if err := cluster.ApplyNamespaceDefaults(ns.Annotations); err != nil {
+		return nil, err
+	}
+	cluster.Default()
+	if err := controllerutil.SetControllerReference(set, cluster, r.Scheme); err != nil {
+		return nil, fmt.Errorf("cannot set controller reference: %w", err)
+	}
+	return cluster, nil
+}
+
+// getSetClusterStatus reports the cluster of the set in the namespace, err is the error of its reconciliation.
+func getSetClusterStatus(
+	namespace, name string,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	err error,
+) etcdaenixiov1alpha1.EtcdClusterSetClusterStatus {
+	status := etcdaenixiov1alpha1.EtcdClusterSetClusterStatus{Namespace: namespace, Name: name}
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	status.CurrentVersion = cluster.Status.CurrentVersion
+	ready := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+	switch {
+	case ready == nil:
+		status.Message = "cluster has not reported its status yet"
+	case ready.Status == metav1.ConditionTrue:
+		status.Ready = true
+	default:
+		status.Message = ready.Message
+	}
+	return status
+}
+
+// setClusterSetStatus aggregates statuses of clusters into the status of the set.
+func setClusterSetStatus(set *etcdaenixiov1alpha1.EtcdClusterSet, statuses []etcdaenixiov1alpha1.EtcdClusterSetClusterStatus) {
+	slices.SortFunc(statuses, func(a, b etcdaenixiov1alpha1.EtcdClusterSetClusterStatus) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	var notReady []string
+	for _, status := range statuses {
+		if !status.Ready {
+			notReady = append(notReady, status.Namespace)
+		}
+	}
+	set.Status.ObservedGeneration = set.Generation
+	set.Status.ClusterStatuses = statuses
+	set.Status.Clusters = int32(len(statuses))
+	set.Status.ReadyClusters = int32(len(statuses) - len(notReady))
+
+	condition := metav1.Condition{
+		Type:               etcdaenixiov1alpha1.EtcdConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             string(etcdaenixiov1alpha1.EtcdCondTypeAllClustersReady),
+		Message:            fmt.Sprintf("All %d clusters are ready", len(statuses)),
+		ObservedGeneration: set.Generation,
+	}
+	switch {
+	case len(statuses) == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(etcdaenixiov1alpha1.EtcdCondTypeNoNamespaceMatched)
+		condition.Message = "No namespace matches the namespace selector"
+	case len(notReady) > 0:
+		listed := notReady[:min(len(notReady), maxNotReadyClustersInMessage)]
+		message := fmt.Sprintf("%d of %d clusters are not ready: %s", len(notReady), len(statuses), strings.Join(listed, ", "))
+		if len(listed) < len(notReady) {
+			message += fmt.Sprintf(" and %d more", len(notReady)-len(listed))
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(etcdaenixiov1alpha1.EtcdCondTypeClustersNotReady)
+		condition.Message = message
+	}
+	meta.SetStatusCondition(&set.Status.Conditions, condition)
+}
+
+// hashClusterTemplate returns short hash of the cluster template, so clusters are only updated when it changes.
+func hashClusterTemplate(template etcdaenixiov1alpha1.EtcdClusterTemplate) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash cluster template: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// mapNamespaceToClusterSets enqueues all cluster sets, as a namespace may start or stop matching any of them.
+func (r *EtcdClusterSetReconciler) mapNamespaceToClusterSets(ctx context.Context, _ client.Object) []reconcile.Request {
+	sets := &etcdaenixiov1alpha1.EtcdClusterSetList{}
+	if err := r.List(ctx, sets); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list cluster sets")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(sets.Items))
+	for i := range sets.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sets.Items[i].Name}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdClusterSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&etcdaenixiov1alpha1.EtcdClusterSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&etcdaenixiov1alpha1.EtcdCluster{}).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToClusterSets),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("EtcdClusterSet status", func() {
+	It("should report a cluster which couldn't be created", func() {
+		status := getSetClusterStatus("tenant", "etcd", nil, errors.New("denied"))
+		Expect(status).To(Equal(etcdaenixiov1alpha1.EtcdClusterSetClusterStatus{
+			Namespace: "tenant",
+			Name:      "etcd",
+			Message:   "denied",
+		}))
+	})
+
+	It("should report readiness and version of a cluster", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{Status: etcdaenixiov1alpha1.EtcdClusterStatus{
+			CurrentVersion: "3.5.12",
+			Conditions: []metav1.Condition{{
+				Type:   etcdaenixiov1alpha1.EtcdConditionReady,
+				Status: metav1.ConditionTrue,
+			}},
+		}}
+		status := getSetClusterStatus("tenant", "etcd", cluster, nil)
+		Expect(status.Ready).To(BeTrue())
+		Expect(status.CurrentVersion).To(Equal("3.5.12"))
+		Expect(status.Message).To(BeEmpty())
+	})
+
+	It("should aggregate statuses of clusters", func() {
+		set := &etcdaenixiov1alpha1.EtcdClusterSet{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+		setClusterSetStatus(set, []etcdaenixiov1alpha1.EtcdClusterSetClusterStatus{
+			{Namespace: "c", Ready: true},
+			{Namespace: "b"},
+			{Namespace: "a", Ready: true},
+		})
+		Expect(set.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(set.Status.Clusters).To(Equal(int32(3)))
+		Expect(set.Status.ReadyClusters).To(Equal(int32(2)))
+		Expect(set.Status.ClusterStatuses[0].Namespace).To(Equal("a"))
+		ready := meta.FindStatusCondition(set.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeClustersNotReady)))
+		Expect(ready.Message).To(Equal("1 of 3 clusters are not ready: b"))
+	})
+
+	It("should not be ready without selected namespaces", func() {
+		set := &etcdaenixiov1alpha1.EtcdClusterSet{}
+		setClusterSetStatus(set, nil)
+		Expect(meta.IsStatusConditionFalse(set.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)).To(BeTrue())
+		Expect(set.Status.Conditions[0].Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeNoNamespaceMatched)))
+	})
+})
+
+var _ = Describe("EtcdClusterSet controller", func() {
+	var (
+		reconciler *EtcdClusterSetReconciler
+		set        *etcdaenixiov1alpha1.EtcdClusterSet
+		ns         *corev1.Namespace
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-cluster-set-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		reconciler = &EtcdClusterSetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		set = &etcdaenixiov1alpha1.EtcdClusterSet{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "test-set-"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSetSpec{
+				NamespaceSelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{corev1.LabelMetadataName: ns.Name},
+				},
+				Template: etcdaenixiov1alpha1.EtcdClusterTemplate{
+					EmbeddedObjectMetadata: etcdaenixiov1alpha1.EmbeddedObjectMetadata{Name: "etcd"},
+					Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+						Replicas: ptr.To(int32(1)),
+						Storage: etcdaenixiov1alpha1.StorageSpec{
+							EmptyDir:  &corev1.EmptyDirVolumeSource{},
+							Ephemeral: true,
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, set)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, set)
+	})
+
+	reconcileSet := func(ctx SpecContext) {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(set)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(set), set)).To(Succeed())
+	}
+
+	It("should create a cluster in selected namespaces", func(ctx SpecContext) {
+		reconcileSet(ctx)
+
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: "etcd"}, cluster)).To(Succeed())
+		Expect(cluster.Labels).To(HaveKeyWithValue(etcdaenixiov1alpha1.ClusterSetLabel, set.Name))
+		Expect(metav1.IsControlledBy(cluster, set)).To(BeTrue())
+		Expect(*cluster.Spec.Replicas).To(Equal(int32(1)))
+
+		Expect(set.Status.Clusters).To(Equal(int32(1)))
+		Expect(set.Status.ClusterStatuses).To(HaveLen(1))
+		Expect(set.Status.ClusterStatuses[0].Namespace).To(Equal(ns.Name))
+	})
+
+	It("should update clusters when the template changes", func(ctx SpecContext) {
+		reconcileSet(ctx)
+
+		set.Spec.Template.Spec.Replicas = ptr.To(int32(3))
+		Expect(k8sClient.Update(ctx, set)).To(Succeed())
+		reconcileSet(ctx)
+
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: "etcd"}, cluster)).To(Succeed())
+		Expect(*cluster.Spec.Replicas).To(Equal(int32(3)))
+	})
+
+	It("should delete the cluster when the namespace is no longer selected", func(ctx SpecContext) {
+		reconcileSet(ctx)
+
+		set.Spec.NamespaceSelector.MatchLabels[corev1.LabelMetadataName] = ns.Name + "-other"
+		Expect(k8sClient.Update(ctx, set)).To(Succeed())
+		reconcileSet(ctx)
+
+		err := k8sClient.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: "etcd"}, &etcdaenixiov1alpha1.EtcdCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(set.Status.Clusters).To(BeZero())
+	})
+
+	It("should not take over an existing cluster", func(ctx SpecContext) {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: ns.Name},
+			Spec:       etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(5))},
+		}
+		Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+		reconcileSet(ctx)
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(*cluster.Spec.Replicas).To(Equal(int32(5)))
+		Expect(set.Status.ClusterStatuses).To(HaveLen(1))
+		Expect(set.Status.ClusterStatuses[0].Message).To(ContainSubstring("not managed by the set"))
+	})
+})
@@ -0,0 +1,435 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// etcdRoleRetryInterval is how often roles are applied again while their cluster is unreachable.
+	etcdRoleRetryInterval = 30 * time.Second
+	// etcdRoleDriftInterval is how often applied roles and users are compared with their cluster.
+	etcdRoleDriftInterval = 5 * time.Minute
+)
+
+// EtcdRoleReconciler reconciles a EtcdRole object
+type EtcdRoleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// EtcdClientFactory creates clients of etcd members. Clients connect with clientv3.New if nil.
+	EtcdClientFactory EtcdClientFactory
+}
+
+// etcdPermission is a permission in the form of the etcd API.
+type etcdPermission struct {
+	key      string
+	rangeEnd string
+	permType clientv3.PermissionType
+}
+
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdroles,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdroles/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdroles/finalizers,verbs=update
+
+// Reconcile creates the etcd role of the EtcdRole in its cluster and grants it permissions of the spec, revoking
+// any others. The etcd role is deleted from the cluster once the EtcdRole is deleted or refers to another role.
+// Applied roles are checked periodically, restoring permissions changed outside of the operator.
+func (r *EtcdRoleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	role := &etcdaenixiov1alpha1.EtcdRole{}
+	if err := r.Get(ctx, req.NamespacedName, role); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.V(2).Info("object not found", "name", req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !role.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(role, etcdaenixiov1alpha1.EtcdRoleFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.deleteEtcdRole(ctx, role); err != nil {
+			return ctrl.Result{}, fmt.Errorf("cannot delete etcd role %s: %w", role.Status.RoleName, err)
+		}
+		controllerutil.RemoveFinalizer(role, etcdaenixiov1alpha1.EtcdRoleFinalizer)
+		return ctrl.Result{}, r.Update(ctx, role)
+	}
+	if !controllerutil.ContainsFinalizer(role, etcdaenixiov1alpha1.EtcdRoleFinalizer) {
+		controllerutil.AddFinalizer(role, etcdaenixiov1alpha1.EtcdRoleFinalizer)
+		if err := r.Update(ctx, role); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	observed := role.Status.DeepCopy()
+	result, err := r.applyEtcdRole(ctx, role)
+	role.Status.ObservedGeneration = role.Generation
+	if equality.Semantic.DeepEqual(observed, &role.Status) {
+		logger.V(2).Info("etcd role status did not change, skipping update")
+		return result, err
+	}
+	if statusErr := r.Status().Update(ctx, role); statusErr != nil {
+		return ctrl.Result{}, statusErr
+	}
+	return result, err
+}
+
+// applyEtcdRole applies the role to its cluster and reflects the outcome in the Ready condition. Roles the cluster
+// rejects are not retried until they are changed, roles of unreachable clusters are retried periodically.
+func (r *EtcdRoleReconciler) applyEtcdRole(ctx context.Context, role *etcdaenixiov1alpha1.EtcdRole) (ctrl.Result, error) {
+	name := role.EtcdRoleName()
+	if role.Status.RoleName != "" && (role.Status.Cluster != role.Spec.ClusterRef.Name || role.Status.RoleName != name) {
+		if err := r.deleteEtcdRole(ctx, role); err != nil {
+			setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable,
+				fmt.Sprintf("Cannot delete previous role %s of cluster %s: %v", role.Status.RoleName, role.Status.Cluster, err))
+			return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+		}
+		role.Status.Cluster, role.Status.RoleName = "", ""
+	}
+	if name == etcdaenixiov1alpha1.RootRole {
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeRoleRejected,
+			"The root role is managed by the operator")
+		return ctrl.Result{}, nil
+	}
+	permissions, err := getEtcdPermissions(role.Spec.Permissions)
+	if err != nil {
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeRoleRejected, err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: role.Namespace, Name: role.Spec.ClusterRef.Name}, cluster)
+	if apierrors.IsNotFound(err) {
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeClusterNotFound,
+			fmt.Sprintf("EtcdCluster %s not found", role.Spec.ClusterRef.Name))
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster.IsObserved() {
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeRoleRejected,
+			"Roles are not applied to observed clusters")
+		return ctrl.Result{}, nil
+	}
+
+	cli, err := newClusterClient(ctx, r.Client, r.EtcdClientFactory, cluster)
+	if err != nil {
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable, err.Error())
+		return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+	}
+	defer func() { _ = cli.Close() }()
+	// changes to a role already applied with the current spec were made outside of the operator
+	applied := isAppliedTo(role.Status.Conditions, role.Generation) &&
+		role.Status.Cluster == cluster.Name && role.Status.RoleName == name
+	// recorded up front, so a role created halfway is deleted as well
+	role.Status.Cluster, role.Status.RoleName = cluster.Name, name
+	changed, err := applyEtcdRolePermissions(ctx, cli, name, permissions)
+	if err != nil {
+		if isEtcdRejection(err) {
+			setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeRoleRejected,
+				fmt.Sprintf("Cluster rejected the role: %v", err))
+			return ctrl.Result{}, nil
+		}
+		setEtcdRoleCondition(role, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable,
+			fmt.Sprintf("Cannot apply the role: %v", err))
+		return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+	}
+	setEtcdRoleCondition(role, true, etcdaenixiov1alpha1.EtcdCondTypeRoleApplied,
+		fmt.Sprintf("Role %s is applied to cluster %s", name, cluster.Name))
+	if applied && changed {
+		log.FromContext(ctx).Info("restored etcd role changed outside of the operator", "cluster", cluster.Name, "role", name)
+		setDriftedCondition(&role.Status.Conditions, role.Generation, etcdaenixiov1alpha1.EtcdCondTypePermissionsDrifted,
+			fmt.Sprintf("Role %s was changed in cluster %s outside of the operator and was restored", name, cluster.Name))
+	} else {
+		setDriftedCondition(&role.Status.Conditions, role.Generation, etcdaenixiov1alpha1.EtcdCondTypeNoDrift,
+			fmt.Sprintf("Role %s matches the spec", name))
+	}
+	return ctrl.Result{RequeueAfter: etcdRoleDriftInterval}, nil
+}
+
+// deleteEtcdRole deletes the etcd role recorded in the status from its cluster. Roles of clusters which are gone
+// or being deleted are skipped.
+func (r *EtcdRoleReconciler) deleteEtcdRole(ctx context.Context, role *etcdaenixiov1alpha1.EtcdRole) error {
+	if role.Status.RoleName == "" {
+		return nil
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: role.Namespace, Name: role.Status.Cluster}, cluster)
+	if apierrors.IsNotFound(err) || err == nil && !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cli, err := newClusterClient(ctx, r.Client, r.EtcdClientFactory, cluster)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	if _, err := cli.RoleDelete(reqCtx, role.Status.RoleName); err != nil && !errors.Is(err, rpctypes.ErrRoleNotFound) {
+		return err
+	}
+	log.FromContext(ctx).Info("deleted etcd role", "cluster", cluster.Name, "role", role.Status.RoleName)
+	return nil
+}
+
+// newClusterClient connects to members of the cluster the same way the cluster is reconciled.
+func newClusterClient(
+	ctx context.Context,
+	c client.Client,
+	clientFactory EtcdClientFactory,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (*clientv3.Client, error) {
+	clusters := &EtcdClusterReconciler{Client: c, EtcdClientFactory: clientFactory}
+	pods, err := clusters.listClusterPods(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	endpoints := getMemberEndpoints(cluster, pods, nil)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("cluster %s has no members to connect to", cluster.Name)
+	}
+	return clusters.newEtcdClient(ctx, cluster, endpoints)
+}
+
+// applyEtcdRolePermissions creates the etcd role if it does not exist, grants it the permissions and revokes
+// permissions which are not listed. It returns true if the role was changed.
+func applyEtcdRolePermissions(
+	ctx context.Context,
+	cli *clientv3.Client,
+	name string,
+	permissions []etcdPermission,
+) (bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	var current []*authpb.Permission
+	added := false
+	resp, err := cli.RoleGet(reqCtx, name)
+	switch {
+	case errors.Is(err, rpctypes.ErrRoleNotFound):
+		if _, err := cli.RoleAdd(reqCtx, name); err != nil {
+			return false, err
+		}
+		added = true
+	case err != nil:
+		return false, err
+	default:
+		current = resp.Perm
+	}
+	grant, revoke := getPermissionChanges(permissions, current)
+	for _, p := range revoke {
+		if _, err := cli.RoleRevokePermission(reqCtx, name, p.key, p.rangeEnd); err != nil {
+			return true, err
+		}
+	}
+	for _, p := range grant {
+		if _, err := cli.RoleGrantPermission(reqCtx, name, p.key, p.rangeEnd, p.permType); err != nil {
+			return true, err
+		}
+	}
+	return added || len(grant) > 0 || len(revoke) > 0, nil
+}
+
+// getEtcdPermissions converts permissions of the spec to the etcd API form. Permissions etcd would store
+// differently than requested, e.g. with an empty or inverted range, are refused.
+func getEtcdPermissions(permissions []etcdaenixiov1alpha1.EtcdPermission) ([]etcdPermission, error) {
+	permTypes := map[etcdaenixiov1alpha1.PermissionType]clientv3.PermissionType{
+		etcdaenixiov1alpha1.PermissionRead:      clientv3.PermissionType(clientv3.PermRead),
+		etcdaenixiov1alpha1.PermissionWrite:     clientv3.PermissionType(clientv3.PermWrite),
+		etcdaenixiov1alpha1.PermissionReadWrite: clientv3.PermissionType(clientv3.PermReadWrite),
+	}
+	result := make([]etcdPermission, 0, len(permissions))
+	for i, p := range permissions {
+		permType, ok := permTypes[p.Type]
+		if !ok {
+			return nil, fmt.Errorf("permissions[%d]: unknown type %q", i, p.Type)
+		}
+		converted := etcdPermission{key: p.Key, rangeEnd: p.RangeEnd, permType: permType}
+		switch {
+		case p.Prefix && p.RangeEnd != "":
+			return nil, fmt.Errorf("permissions[%d]: prefix and rangeEnd are mutually exclusive", i)
+		case p.Prefix:
+			converted.rangeEnd = clientv3.GetPrefixRangeEnd(p.Key)
+		case p.Key == "":
+			return nil, fmt.Errorf("permissions[%d]: key is required unless prefix is set", i)
+		case p.RangeEnd != "" && p.RangeEnd <= p.Key:
+			return nil, fmt.Errorf("permissions[%d]: rangeEnd must be greater than the key", i)
+		}
+		for j, previous := range result {
+			if previous.key == converted.key && previous.rangeEnd == converted.rangeEnd {
+				return nil, fmt.Errorf("permissions[%d]: the same keys as permissions[%d]", i, j)
+			}
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+// getPermissionChanges returns permissions to grant, missing or with another type, and current permissions
+// to revoke as they are not desired.
+func getPermissionChanges(desired []etcdPermission, current []*authpb.Permission) (grant, revoke []etcdPermission) {
+	type keyRange struct{ key, rangeEnd string }
+	currentTypes := make(map[keyRange]clientv3.PermissionType, len(current))
+	for _, p := range current {
+		currentTypes[keyRange{string(p.Key), string(p.RangeEnd)}] = clientv3.PermissionType(p.PermType)
+	}
+	desiredKeys := make(map[keyRange]bool, len(desired))
+	for _, p := range desired {
+		keys := keyRange{p.key, p.rangeEnd}
+		desiredKeys[keys] = true
+		if permType, ok := currentTypes[keys]; !ok || permType != p.permType {
+			grant = append(grant, p)
+		}
+	}
+	for _, p := range current {
+		if !desiredKeys[keyRange{string(p.Key), string(p.RangeEnd)}] {
+			revoke = append(revoke, etcdPermission{key: string(p.Key), rangeEnd: string(p.RangeEnd)})
+		}
+	}
+	return grant, revoke
+}
+
+// isEtcdRejection returns true if etcd refused the request as invalid, so repeating it does not help.
+func isEtcdRejection(err error) bool {
+	var etcdErr rpctypes.EtcdError
+	if !errors.As(err, &etcdErr) {
+		return false
+	}
+	return etcdErr.Code() == codes.InvalidArgument || etcdErr.Code() == codes.FailedPrecondition
+}
+
+// setEtcdRoleCondition sets the Ready condition of the role.
+func setEtcdRoleCondition(
+	role *etcdaenixiov1alpha1.EtcdRole,
+	ready bool,
+	reason etcdaenixiov1alpha1.EtcdCondType,
+	message string,
+) {
+	status := metav1.ConditionFalse
+	if ready {
+		status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&role.Status.Conditions, metav1.Condition{
+		Type:               etcdaenixiov1alpha1.EtcdConditionReady,
+		Status:             status,
+		Reason:             string(reason),
+		Message:            message,
+		ObservedGeneration: role.Generation,
+	})
+}
+
+// isAppliedTo returns true if the Ready condition reports the resource applied with the generation of its spec.
+func isAppliedTo(conditions []metav1.Condition, generation int64) bool {
+	ready := meta.FindStatusCondition(conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+	return ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration == generation
+}
+
+// setDriftedCondition sets the Drifted condition of an EtcdRole or EtcdUser, it is False only with
+// the NoDrift reason.
+func setDriftedCondition(
+	conditions *[]metav1.Condition,
+	generation int64,
+	reason etcdaenixiov1alpha1.EtcdCondType,
+	message string,
+) {
+	status := metav1.ConditionTrue
+	if reason == etcdaenixiov1alpha1.EtcdCondTypeNoDrift {
+		status = metav1.ConditionFalse
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               etcdaenixiov1alpha1.EtcdConditionDrifted,
+		Status:             status,
+		Reason:             string(reason),
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// mapClusterToRoles enqueues roles of the cluster, as they are applied once it is ready.
+func (r *EtcdRoleReconciler) mapClusterToRoles(ctx context.Context, obj client.Object) []reconcile.Request {
+	roles := &etcdaenixiov1alpha1.EtcdRoleList{}
+	if err := r.List(ctx, roles, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list etcd roles")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range roles.Items {
+		role := &roles.Items[i]
+		if role.Spec.ClusterRef.Name == obj.GetName() || role.Status.Cluster == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(role)})
+		}
+	}
+	return requests
+}
+
+// clusterAccessChangedPredicate passes clusters whose readiness or authentication changed, besides created
+// and deleted ones.
+func clusterAccessChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCluster, ok := e.ObjectOld.(*etcdaenixiov1alpha1.EtcdCluster)
+			if !ok {
+				return false
+			}
+			newCluster, ok := e.ObjectNew.(*etcdaenixiov1alpha1.EtcdCluster)
+			if !ok {
+				return false
+			}
+			return oldCluster.Status.AuthEnabled != newCluster.Status.AuthEnabled ||
+				meta.IsStatusConditionTrue(oldCluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady) !=
+					meta.IsStatusConditionTrue(newCluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&etcdaenixiov1alpha1.EtcdRole{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&etcdaenixiov1alpha1.EtcdCluster{}, handler.EnqueueRequestsFromMapFunc(r.mapClusterToRoles),
+			builder.WithPredicates(clusterAccessChangedPredicate())).
+		Complete(r)
+}
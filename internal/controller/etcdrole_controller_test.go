@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("EtcdRole permissions", func() {
+	readWrite := clientv3.PermissionType(clientv3.PermReadWrite)
+	read := clientv3.PermissionType(clientv3.PermRead)
+
+	It("should convert prefixes and ranges", func() {
+		permissions, err := getEtcdPermissions([]etcdaenixiov1alpha1.EtcdPermission{
+			{Type: etcdaenixiov1alpha1.PermissionReadWrite, Key: "/app/", Prefix: true},
+			{Type: etcdaenixiov1alpha1.PermissionRead, Key: "/config/a", RangeEnd: "/config/z"},
+			{Type: etcdaenixiov1alpha1.PermissionWrite, Key: "/lock"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(permissions).To(Equal([]etcdPermission{
+			{key: "/app/", rangeEnd: "/app0", permType: readWrite},
+			{key: "/config/a", rangeEnd: "/config/z", permType: read},
+			{key: "/lock", permType: clientv3.PermissionType(clientv3.PermWrite)},
+		}))
+	})
+
+	It("should refuse permissions etcd would not store as requested", func() {
+		for _, p := range []etcdaenixiov1alpha1.EtcdPermission{
+			{Type: etcdaenixiov1alpha1.PermissionRead, Key: "/a", Prefix: true, RangeEnd: "/b"},
+			{Type: etcdaenixiov1alpha1.PermissionRead, Key: "/b", RangeEnd: "/a"},
+			{Type: etcdaenixiov1alpha1.PermissionRead},
+		} {
+			_, err := getEtcdPermissions([]etcdaenixiov1alpha1.EtcdPermission{p})
+			Expect(err).To(HaveOccurred(), fmt.Sprintf("%+v", p))
+		}
+		_, err := getEtcdPermissions([]etcdaenixiov1alpha1.EtcdPermission{
+			{Type: etcdaenixiov1alpha1.PermissionRead, Key: "/a"},
+			{Type: etcdaenixiov1alpha1.PermissionWrite, Key: "/a"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("the same keys as permissions[0]")))
+	})
+
+	It("should grant missing permissions and revoke unlisted ones", func() {
+		desired := []etcdPermission{
+			{key: "/app/", rangeEnd: "/app0", permType: readWrite},
+			{key: "/config", permType: readWrite},
+			{key: "/new", permType: read},
+		}
+		current := []*authpb.Permission{
+			{Key: []byte("/app/"), RangeEnd: []byte("/app0"), PermType: authpb.READWRITE},
+			{Key: []byte("/config"), PermType: authpb.READ},
+			{Key: []byte("/old"), PermType: authpb.WRITE},
+		}
+		grant, revoke := getPermissionChanges(desired, current)
+		Expect(grant).To(Equal([]etcdPermission{desired[1], desired[2]}))
+		Expect(revoke).To(Equal([]etcdPermission{{key: "/old"}}))
+	})
+
+	It("should set the Drifted condition false only without drift", func() {
+		role := &etcdaenixiov1alpha1.EtcdRole{}
+		for reason, status := range map[etcdaenixiov1alpha1.EtcdCondType]metav1.ConditionStatus{
+			etcdaenixiov1alpha1.EtcdCondTypeNoDrift:            metav1.ConditionFalse,
+			etcdaenixiov1alpha1.EtcdCondTypePermissionsDrifted: metav1.ConditionTrue,
+		} {
+			setDriftedCondition(&role.Status.Conditions, role.Generation, reason, "")
+			condition := meta.FindStatusCondition(role.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionDrifted)
+			Expect(condition.Status).To(Equal(status), string(reason))
+		}
+		Expect(role.Status.Conditions).To(HaveLen(1))
+	})
+
+	It("should not retry requests etcd refused", func() {
+		Expect(isEtcdRejection(rpctypes.ErrRoleEmpty)).To(BeTrue())
+		Expect(isEtcdRejection(fmt.Errorf("cannot grant: %w", rpctypes.ErrRoleNotGranted))).To(BeTrue())
+		Expect(isEtcdRejection(rpctypes.ErrNoLeader)).To(BeFalse())
+		Expect(isEtcdRejection(errors.New("connection refused"))).To(BeFalse())
+	})
+})
@@ -0,0 +1,358 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// EtcdUserReconciler reconciles a EtcdUser object
+type EtcdUserReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// EtcdClientFactory creates clients of etcd members. Clients connect with clientv3.New if nil.
+	EtcdClientFactory EtcdClientFactory
+}
+
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdusers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=etcd.aenix.io,resources=etcdusers/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile creates the etcd user of the EtcdUser in its cluster with the password of the password secret and grants
+// it roles of the spec, revoking any others. The password is changed in etcd whenever the secret is updated.
+// The etcd user is deleted from the cluster once the EtcdUser is deleted or refers to another user. Applied users
+// are checked periodically, restoring users and roles changed outside of the operator.
+func (r *EtcdUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	user := &etcdaenixiov1alpha1.EtcdUser{}
+	if err := r.Get(ctx, req.NamespacedName, user); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.V(2).Info("object not found", "name", req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !user.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(user, etcdaenixiov1alpha1.EtcdUserFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.deleteEtcdUser(ctx, user); err != nil {
+			return ctrl.Result{}, fmt.Errorf("cannot delete etcd user %s: %w", user.Status.UserName, err)
+		}
+		controllerutil.RemoveFinalizer(user, etcdaenixiov1alpha1.EtcdUserFinalizer)
+		return ctrl.Result{}, r.Update(ctx, user)
+	}
+	if !controllerutil.ContainsFinalizer(user, etcdaenixiov1alpha1.EtcdUserFinalizer) {
+		controllerutil.AddFinalizer(user, etcdaenixiov1alpha1.EtcdUserFinalizer)
+		if err := r.Update(ctx, user); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	observed := user.Status.DeepCopy()
+	result, err := r.applyEtcdUser(ctx, user)
+	user.Status.ObservedGeneration = user.Generation
+	if equality.Semantic.DeepEqual(observed, &user.Status) {
+		logger.V(2).Info("etcd user status did not change, skipping update")
+		return result, err
+	}
+	if statusErr := r.Status().Update(ctx, user); statusErr != nil {
+		return ctrl.Result{}, statusErr
+	}
+	return result, err
+}
+
+// applyEtcdUser applies the user to its cluster and reflects the outcome in the Ready condition. Users the cluster
+// rejects are not retried until they or their password secret are changed, users of unreachable clusters
+// or granted roles which do not exist yet are retried periodically.
+func (r *EtcdUserReconciler) applyEtcdUser(ctx context.Context, user *etcdaenixiov1alpha1.EtcdUser) (ctrl.Result, error) {
+	name := user.EtcdUserName()
+	if user.Status.UserName != "" && (user.Status.Cluster != user.Spec.ClusterRef.Name || user.Status.UserName != name) {
+		if err := r.deleteEtcdUser(ctx, user); err != nil {
+			setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable,
+				fmt.Sprintf("Cannot delete previous user %s of cluster %s: %v", user.Status.UserName, user.Status.Cluster, err))
+			return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+		}
+		user.Status.Cluster, user.Status.UserName, user.Status.PasswordSecretVersion = "", "", ""
+	}
+	if name == factory.RootUser {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeUserRejected,
+			"The root user is managed by the operator")
+		return ctrl.Result{}, nil
+	}
+	if slices.Contains(user.Spec.Roles, etcdaenixiov1alpha1.RootRole) {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeUserRejected,
+			"The root role is managed by the operator")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: user.Spec.ClusterRef.Name}, cluster)
+	if apierrors.IsNotFound(err) {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeClusterNotFound,
+			fmt.Sprintf("EtcdCluster %s not found", user.Spec.ClusterRef.Name))
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster.IsObserved() {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeUserRejected,
+			"Users are not applied to observed clusters")
+		return ctrl.Result{}, nil
+	}
+
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: user.Spec.PasswordSecretRef.Name}, secret)
+	if apierrors.IsNotFound(err) {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypePasswordSecretNotFound,
+			fmt.Sprintf("Secret %s not found", user.Spec.PasswordSecretRef.Name))
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	password := string(secret.Data[corev1.BasicAuthPasswordKey])
+	if password == "" {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeUserRejected,
+			fmt.Sprintf("Secret %s has no %s field", secret.Name, corev1.BasicAuthPasswordKey))
+		return ctrl.Result{}, nil
+	}
+
+	cli, err := newClusterClient(ctx, r.Client, r.EtcdClientFactory, cluster)
+	if err != nil {
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable, err.Error())
+		return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+	}
+	defer func() { _ = cli.Close() }()
+	// changes to a user already applied with the current spec were made outside of the operator
+	applied := isAppliedTo(user.Status.Conditions, user.Generation) &&
+		user.Status.Cluster == cluster.Name && user.Status.UserName == name
+	// recorded up front, so a user created halfway is deleted as well
+	user.Status.Cluster, user.Status.UserName = cluster.Name, name
+	rotate := user.Status.PasswordSecretVersion != "" && user.Status.PasswordSecretVersion != secret.ResourceVersion
+	rotated, changed, err := applyEtcdUserAccount(ctx, cli, name, password, rotate, user.Spec.Roles)
+	if rotated {
+		user.Status.LastRotationTime = ptr.To(metav1.Now())
+		log.FromContext(ctx).Info("changed password of etcd user", "cluster", cluster.Name, "user", name)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, rpctypes.ErrRoleNotFound):
+			setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeRoleNotFound,
+				fmt.Sprintf("Cannot grant the roles: %v", err))
+			return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+		case isEtcdRejection(err):
+			setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeUserRejected,
+				fmt.Sprintf("Cluster rejected the user: %v", err))
+			return ctrl.Result{}, nil
+		}
+		setEtcdUserCondition(user, false, etcdaenixiov1alpha1.EtcdCondTypeClusterUnreachable,
+			fmt.Sprintf("Cannot apply the user: %v", err))
+		return ctrl.Result{RequeueAfter: etcdRoleRetryInterval}, nil
+	}
+	user.Status.PasswordSecretVersion = secret.ResourceVersion
+	setEtcdUserCondition(user, true, etcdaenixiov1alpha1.EtcdCondTypeUserApplied,
+		fmt.Sprintf("User %s is applied to cluster %s", name, cluster.Name))
+	if applied && changed {
+		log.FromContext(ctx).Info("restored etcd user changed outside of the operator", "cluster", cluster.Name, "user", name)
+		setDriftedCondition(&user.Status.Conditions, user.Generation, etcdaenixiov1alpha1.EtcdCondTypeUserDrifted,
+			fmt.Sprintf("User %s was changed in cluster %s outside of the operator and was restored", name, cluster.Name))
+	} else {
+		setDriftedCondition(&user.Status.Conditions, user.Generation, etcdaenixiov1alpha1.EtcdCondTypeNoDrift,
+			fmt.Sprintf("User %s matches the spec", name))
+	}
+	return ctrl.Result{RequeueAfter: etcdRoleDriftInterval}, nil
+}
+
+// deleteEtcdUser deletes the etcd user recorded in the status from its cluster. Users of clusters which are gone
+// or being deleted are skipped.
+func (r *EtcdUserReconciler) deleteEtcdUser(ctx context.Context, user *etcdaenixiov1alpha1.EtcdUser) error {
+	if user.Status.UserName == "" {
+		return nil
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: user.Status.Cluster}, cluster)
+	if apierrors.IsNotFound(err) || err == nil && !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cli, err := newClusterClient(ctx, r.Client, r.EtcdClientFactory, cluster)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	if _, err := cli.UserDelete(reqCtx, user.Status.UserName); err != nil && !errors.Is(err, rpctypes.ErrUserNotFound) {
+		return err
+	}
+	log.FromContext(ctx).Info("deleted etcd user", "cluster", cluster.Name, "user", user.Status.UserName)
+	return nil
+}
+
+// applyEtcdUserAccount creates the etcd user with the password if it does not exist, or changes its password
+// if rotate is set, and grants the user the roles, revoking roles which are not listed. It returns whether
+// the password of an existing user was changed, and whether the user was added or its roles were changed.
+func applyEtcdUserAccount(
+	ctx context.Context,
+	cli *clientv3.Client,
+	name, password string,
+	rotate bool,
+	roles []string,
+) (rotated, changed bool, err error) {
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	var current []string
+	resp, err := cli.UserGet(reqCtx, name)
+	switch {
+	case errors.Is(err, rpctypes.ErrUserNotFound):
+		if _, err := cli.UserAdd(reqCtx, name, password); err != nil {
+			return false, false, err
+		}
+		changed = true
+	case err != nil:
+		return false, false, err
+	default:
+		current = resp.Roles
+		if rotate {
+			if _, err := cli.UserChangePassword(reqCtx, name, password); err != nil {
+				return false, false, err
+			}
+			rotated = true
+		}
+	}
+	grant, revoke := getRoleChanges(roles, current)
+	for _, role := range revoke {
+		if _, err := cli.UserRevokeRole(reqCtx, name, role); err != nil {
+			return rotated, true, err
+		}
+	}
+	for _, role := range grant {
+		if _, err := cli.UserGrantRole(reqCtx, name, role); err != nil {
+			return rotated, true, err
+		}
+	}
+	return rotated, changed || len(grant) > 0 || len(revoke) > 0, nil
+}
+
+// getRoleChanges returns roles to grant as the user does not have them yet, and current roles to revoke
+// as they are not desired.
+func getRoleChanges(desired, current []string) (grant, revoke []string) {
+	for _, role := range desired {
+		if !slices.Contains(current, role) && !slices.Contains(grant, role) {
+			grant = append(grant, role)
+		}
+	}
+	for _, role := range current {
+		if !slices.Contains(desired, role) {
+			revoke = append(revoke, role)
+		}
+	}
+	return grant, revoke
+}
+
+// setEtcdUserCondition sets the Ready condition of the user.
+func setEtcdUserCondition(
+	user *etcdaenixiov1alpha1.EtcdUser,
+	ready bool,
+	reason etcdaenixiov1alpha1.EtcdCondType,
+	message string,
+) {
+	status := metav1.ConditionFalse
+	if ready {
+		status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               etcdaenixiov1alpha1.EtcdConditionReady,
+		Status:             status,
+		Reason:             string(reason),
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	})
+}
+
+// mapClusterToUsers enqueues users of the cluster, as they are applied once it is ready.
+func (r *EtcdUserReconciler) mapClusterToUsers(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.mapUsers(ctx, obj.GetNamespace(), func(user *etcdaenixiov1alpha1.EtcdUser) bool {
+		return user.Spec.ClusterRef.Name == obj.GetName() || user.Status.Cluster == obj.GetName()
+	})
+}
+
+// mapSecretToUsers enqueues users whose password is kept in the secret, so rotated passwords are pushed to etcd.
+func (r *EtcdUserReconciler) mapSecretToUsers(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.mapUsers(ctx, obj.GetNamespace(), func(user *etcdaenixiov1alpha1.EtcdUser) bool {
+		return user.Spec.PasswordSecretRef.Name == obj.GetName()
+	})
+}
+
+func (r *EtcdUserReconciler) mapUsers(
+	ctx context.Context,
+	namespace string,
+	accept func(user *etcdaenixiov1alpha1.EtcdUser) bool,
+) []reconcile.Request {
+	users := &etcdaenixiov1alpha1.EtcdUserList{}
+	if err := r.List(ctx, users, client.InNamespace(namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list etcd users")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range users.Items {
+		if accept(&users.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&users.Items[i])})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&etcdaenixiov1alpha1.EtcdUser{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&etcdaenixiov1alpha1.EtcdCluster{}, handler.EnqueueRequestsFromMapFunc(r.mapClusterToUsers),
+			builder.WithPredicates(clusterAccessChangedPredicate())).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToUsers)).
+		Complete(r)
+}
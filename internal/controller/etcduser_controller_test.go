@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("EtcdUser", func() {
+	It("should grant missing roles and revoke unlisted ones", func() {
+		grant, revoke := getRoleChanges([]string{"app", "config", "app"}, []string{"config", "old"})
+		Expect(grant).To(Equal([]string{"app"}))
+		Expect(revoke).To(Equal([]string{"old"}))
+	})
+
+	It("should refuse to manage the root user and role", func(ctx SpecContext) {
+		r := &EtcdUserReconciler{Client: k8sClient}
+		for _, user := range []*etcdaenixiov1alpha1.EtcdUser{
+			{ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "default"}},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       etcdaenixiov1alpha1.EtcdUserSpec{Roles: []string{"app", "root"}},
+			},
+		} {
+			result, err := r.applyEtcdUser(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeZero())
+			cond := meta.FindStatusCondition(user.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeUserRejected)))
+		}
+	})
+
+	It("should enqueue users whose password is kept in the changed secret", func(ctx SpecContext) {
+		r := &EtcdUserReconciler{Client: k8sClient}
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-etcd-user-"}}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+		for _, name := range []string{"app", "backup"} {
+			user := &etcdaenixiov1alpha1.EtcdUser{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns.Name},
+				Spec: etcdaenixiov1alpha1.EtcdUserSpec{
+					ClusterRef:        corev1.LocalObjectReference{Name: "test"},
+					PasswordSecretRef: corev1.LocalObjectReference{Name: name + "-password"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		}
+
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-password", Namespace: ns.Name}}
+		Expect(r.mapSecretToUsers(ctx, secret)).To(Equal([]reconcile.Request{
+			{NamespacedName: client.ObjectKey{Namespace: ns.Name, Name: "app"}},
+		}))
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name}}
+		Expect(r.mapClusterToUsers(ctx, cluster)).To(HaveLen(2))
+	})
+})
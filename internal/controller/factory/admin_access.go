@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/images"
+)
+
+const (
+	// AdminAccessEnvFileKey is the key of the admin access secret with the environment in shell syntax.
+	AdminAccessEnvFileKey = "etcdctl.env"
+
+	// DebugShellExpiresAtAnnotation holds the time the debug shell pod expires at in RFC 3339 format.
+	DebugShellExpiresAtAnnotation = "etcd.aenix.io/expires-at"
+
+	adminCAMountPath     = "/etc/etcdctl/ca"
+	adminClientMountPath = "/etc/etcdctl/client"
+)
+
+// GetAdminAccessSecretName returns name of the secret with etcdctl environment for humans.
+func GetAdminAccessSecretName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-etcdctl"
+}
+
+// GetMemberClientEndpoints returns client URLs of all members of the cluster.
+func GetMemberClientEndpoints(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	endpoints := make([]string, 0, *cluster.Spec.Replicas)
+	for i := int32(0); i < *cluster.Spec.Replicas; i++ {
+		endpoints = append(endpoints, GetMemberClientEndpoint(cluster, fmt.Sprintf("%s-%d", cluster.Name, i)))
+	}
+	return endpoints
+}
+
+// GetAdminAccessEnv returns etcdctl environment connecting to all members of the cluster. Certificate paths
+// point to where the debug pod mounts the server CA and the client certificate.
+func GetAdminAccessEnv(cluster *etcdaenixiov1alpha1.EtcdCluster) map[string]string {
+	env := map[string]string{
+		"ETCDCTL_ENDPOINTS": strings.Join(GetMemberClientEndpoints(cluster), ","),
+	}
+	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ServerSecret != "" {
+		env["ETCDCTL_CACERT"] = path.Join(adminCAMountPath, corev1.ServiceAccountRootCAKey)
+		if cluster.Spec.Security.TLS.ClientSecret != "" {
+			env["ETCDCTL_CERT"] = path.Join(adminClientMountPath, corev1.TLSCertKey)
+			env["ETCDCTL_KEY"] = path.Join(adminClientMountPath, corev1.TLSPrivateKeyKey)
+		}
+	}
+	return env
+}
+
+// CreateOrUpdateAdminAccessSecret creates secret with etcdctl environment variables and the same environment
+// as a file which can be sourced by shell. The secret holds no key material, only paths to certificates.
+func CreateOrUpdateAdminAccessSecret(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	env := GetAdminAccessEnv(cluster)
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var envFile strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&envFile, "export %s=%q\n", name, env[name])
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      GetAdminAccessSecretName(cluster),
+			Labels:    NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+		},
+		StringData: env,
+	}
+	secret.StringData[AdminAccessEnvFileKey] = envFile.String()
+	if err := ctrl.SetControllerReference(cluster, secret, rclient.Scheme()); err != nil {
+		return fmt.Errorf("cannot set controller reference: %w", err)
+	}
+	return reconcileOwnedResource(ctx, rclient, secret)
+}
+
+// GetDebugPod returns an example pod with etcdctl configured by the admin access secret. The etcd image has
+// no shell, so the pod keeps running by watching a key and is used with kubectl exec. etcdctl authenticates
+// as the root user if authentication is enabled.
+func GetDebugPod(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.Pod {
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name + "-debug",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "etcdctl",
+				Image:   images.MirrorsFromContext(ctx).Rewrite(cluster.EtcdImage()),
+				Command: []string{"etcdctl", "watch", "/etcd-operator/debug"},
+				EnvFrom: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: GetAdminAccessSecretName(cluster)},
+					},
+				}},
+			}},
+		},
+	}
+	if cluster.IsAuthEnabled() {
+		pod.Spec.Containers[0].Env = []corev1.EnvVar{
+			{Name: "ETCDCTL_USER", Value: RootUser},
+			{Name: "ETCDCTL_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: GetRootCredentialsSecretName(cluster)},
+				Key:                  corev1.BasicAuthPasswordKey,
+			}}},
+		}
+	}
+	if cluster.Spec.Security == nil || cluster.Spec.Security.TLS.ServerSecret == "" {
+		return pod
+	}
+	mount := func(name, secretName, mountPath string) {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+		})
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+	mount("ca", cluster.Spec.Security.TLS.ServerSecret, adminCAMountPath)
+	if cluster.Spec.Security.TLS.ClientSecret != "" {
+		mount("client", cluster.Spec.Security.TLS.ClientSecret, adminClientMountPath)
+	}
+	return pod
+}
+
+// GetDebugShellPodName returns name of the pod of the debug shell requested by the debug shell annotation.
+func GetDebugShellPodName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-debug-shell"
+}
+
+// GetDebugShellPod returns the debug pod run by the operator as the debug shell expiring at the given time.
+// Kubernetes stops the pod at expiration even if the operator does not delete it. The pod is not labeled
+// with the name of etcd, so it is not selected as a member of the cluster.
+func GetDebugShellPod(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	now, expiresAt time.Time,
+) *corev1.Pod {
+	pod := GetDebugPod(ctx, cluster)
+	pod.Name = GetDebugShellPodName(cluster)
+	pod.Labels = NewLabelsBuilder().WithInstance(cluster.Name).WithManagedBy()
+	pod.Annotations = map[string]string{DebugShellExpiresAtAnnotation: expiresAt.UTC().Format(time.RFC3339)}
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	pod.Spec.ActiveDeadlineSeconds = ptr.To(int64(math.Ceil(expiresAt.Sub(now).Seconds())))
+	return pod
+}
+
+// GetDebugShellExpiration returns the time the debug shell pod expires at, false if the pod has no valid expiration.
+func GetDebugShellExpiration(pod *corev1.Pod) (time.Time, bool) {
+	expiresAt, err := time.Parse(time.RFC3339, pod.Annotations[DebugShellExpiresAtAnnotation])
+	return expiresAt, err == nil
+}
+
+// GetDebugPodManifest returns YAML manifest of the debug pod.
+func GetDebugPodManifest(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (string, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(GetDebugPod(ctx, cluster))
+	if err != nil {
+		return "", fmt.Errorf("cannot convert debug pod: %w", err)
+	}
+	unstructured.RemoveNestedField(obj, "status")
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	containers, _, _ := unstructured.NestedSlice(obj, "spec", "containers")
+	for _, container := range containers {
+		unstructured.RemoveNestedField(container.(map[string]interface{}), "resources")
+	}
+	if err := unstructured.SetNestedSlice(obj, containers, "spec", "containers"); err != nil {
+		return "", fmt.Errorf("cannot set debug pod containers: %w", err)
+	}
+	manifest, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal debug pod: %w", err)
+	}
+	return string(manifest), nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Admin access", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(2)),
+				Security: &etcdaenixiov1alpha1.SecuritySpec{
+					TLS: etcdaenixiov1alpha1.TLSSpec{ServerSecret: "server-tls", ClientSecret: "client-tls"},
+				},
+			},
+		}
+	})
+
+	It("should point etcdctl to all members and mounted certificates", func() {
+		Expect(GetAdminAccessEnv(cluster)).To(Equal(map[string]string{
+			"ETCDCTL_ENDPOINTS": "https://test-0.test-headless.default.svc:2379,https://test-1.test-headless.default.svc:2379",
+			"ETCDCTL_CACERT":    "/etc/etcdctl/ca/ca.crt",
+			"ETCDCTL_CERT":      "/etc/etcdctl/client/tls.crt",
+			"ETCDCTL_KEY":       "/etc/etcdctl/client/tls.key",
+		}))
+
+		cluster.Spec.Security = nil
+		Expect(GetAdminAccessEnv(cluster)).To(HaveLen(1))
+	})
+
+	It("should mount certificates into the debug pod", func(ctx SpecContext) {
+		pod := GetDebugPod(ctx, cluster)
+		Expect(pod.Spec.Containers).To(HaveLen(1))
+		Expect(pod.Spec.Containers[0].Image).To(Equal(etcdaenixiov1alpha1.DefaultEtcdImage))
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(HaveLen(2))
+		Expect(pod.Spec.Volumes[1].Secret.SecretName).To(Equal("client-tls"))
+
+		manifest, err := GetDebugPodManifest(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest).To(ContainSubstring("name: test-etcdctl"))
+		Expect(manifest).NotTo(ContainSubstring("creationTimestamp"))
+		Expect(manifest).NotTo(ContainSubstring("status"))
+	})
+
+	It("should authenticate etcdctl of the debug pod as the root user", func(ctx SpecContext) {
+		Expect(GetDebugPod(ctx, cluster).Spec.Containers[0].Env).To(BeEmpty())
+
+		cluster.Spec.Security.Auth = &etcdaenixiov1alpha1.AuthSpec{Enabled: true}
+		env := GetDebugPod(ctx, cluster).Spec.Containers[0].Env
+		Expect(env).To(HaveLen(2))
+		Expect(env[0]).To(Equal(corev1.EnvVar{Name: "ETCDCTL_USER", Value: "root"}))
+		Expect(env[1].ValueFrom.SecretKeyRef.Name).To(Equal("test-root-credentials"))
+		Expect(env[1].ValueFrom.SecretKeyRef.Key).To(Equal(corev1.BasicAuthPasswordKey))
+	})
+
+	It("should expire the debug shell pod", func(ctx SpecContext) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		pod := GetDebugShellPod(ctx, cluster, now, now.Add(90*time.Second+time.Millisecond))
+		Expect(pod.Name).To(Equal("test-debug-shell"))
+		Expect(pod.Labels).NotTo(HaveKey("app.kubernetes.io/name"))
+		Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(pod.Spec.ActiveDeadlineSeconds).To(Equal(ptr.To(int64(91))))
+
+		expiresAt, ok := GetDebugShellExpiration(pod)
+		Expect(ok).To(BeTrue())
+		Expect(expiresAt).To(Equal(now.Add(90 * time.Second)))
+		delete(pod.Annotations, DebugShellExpiresAtAnnotation)
+		_, ok = GetDebugShellExpiration(pod)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should create the admin access secret", func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+		cluster.Namespace = ns.Name
+		cluster.UID = types.UID(uuid.NewString())
+
+		Expect(CreateOrUpdateAdminAccessSecret(ctx, cluster, k8sClient)).To(Succeed())
+		secret := &corev1.Secret{}
+		secret.Namespace = ns.Name
+		secret.Name = GetAdminAccessSecretName(cluster)
+		Eventually(Get(secret)).Should(Succeed())
+		Expect(string(secret.Data[AdminAccessEnvFileKey])).To(ContainSubstring(
+			"export ETCDCTL_CACERT=\"/etc/etcdctl/ca/ca.crt\"\n"))
+		Expect(secret.OwnerReferences).To(HaveLen(1))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
@@ -41,6 +42,11 @@ func reconcileOwnedResource(ctx context.Context, c client.Client, resource clien
 	base := resource.DeepCopyObject().(client.Object)
 	err = c.Get(ctx, client.ObjectKeyFromObject(resource), base)
 	if err == nil {
+		// objects of clusters with colliding generated names are not taken over from each other
+		if owner, desired := metav1.GetControllerOf(base), metav1.GetControllerOf(resource); owner != nil && desired != nil &&
+			owner.UID != desired.UID {
+			return fmt.Errorf("%s %s is controlled by %s %s", gvk.Kind, resource.GetName(), owner.Kind, owner.Name)
+		}
 		logger.V(2).Info("updating owned resource")
 		resource.SetAnnotations(labels.Merge(base.GetAnnotations(), resource.GetAnnotations()))
 		resource.SetResourceVersion(base.GetResourceVersion())
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// CAPublicationFinalizer protects ConfigMaps and Bundles published outside the cluster namespace,
+	// which can not be garbage collected by owner references.
+	CAPublicationFinalizer = "etcd.aenix.io/ca-publication"
+
+	caCertKey = "ca.crt"
+)
+
+// TrustBundleGVK is the kind of trust-manager Bundle.
+var TrustBundleGVK = schema.GroupVersionKind{Group: "trust.cert-manager.io", Version: "v1alpha1", Kind: "Bundle"}
+
+// GetCAConfigMapName returns name of ConfigMaps the CA certificate is published into. The default name includes
+// the cluster namespace like the Bundle name, as ConfigMaps of clusters from several namespaces may share a namespace.
+func GetCAConfigMapName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	if name := cluster.Spec.Security.CAPublication.ConfigMapName; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s-ca", cluster.Namespace, cluster.Name)
+}
+
+// GetTrustBundleName returns name of the cluster scoped Bundle, which is also the name of target ConfigMaps.
+func GetTrustBundleName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s-%s-etcd-ca", cluster.Namespace, cluster.Name)
+}
+
+// IsCAPublicationEnabled returns true if the cluster requests publication of its CA certificate.
+func IsCAPublicationEnabled(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Spec.Security != nil && cluster.Spec.Security.CAPublication != nil
+}
+
+// CreateOrUpdateCAPublication publishes CA certificate of the etcd server into ConfigMaps of consuming namespaces
+// and optionally into trust-manager Bundle. ConfigMaps which are no longer requested are deleted.
+func CreateOrUpdateCAPublication(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	if !IsCAPublicationEnabled(cluster) {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+	publication := cluster.Spec.Security.CAPublication
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Spec.Security.TLS.ServerSecret}
+	if err := rclient.Get(ctx, secretName, secret); err != nil {
+		return fmt.Errorf("cannot get server certificate secret: %w", err)
+	}
+	caCert := secret.Data[caCertKey]
+	if len(caCert) == 0 {
+		return fmt.Errorf("server certificate secret %s has no %s field", secretName.Name, caCertKey)
+	}
+
+	namespaces := []string{cluster.Namespace}
+	namespaces = append(namespaces, publication.Namespaces...)
+	if publication.TrustManager != nil {
+		namespaces = append(namespaces, publication.TrustManager.TrustNamespace)
+	}
+
+	desired := make(map[types.NamespacedName]bool, len(namespaces))
+	var errs []error
+	for _, namespace := range namespaces {
+		key := types.NamespacedName{Namespace: namespace, Name: GetCAConfigMapName(cluster)}
+		if desired[key] {
+			continue
+		}
+		desired[key] = true
+
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Labels:    caPublicationLabels(cluster),
+			},
+			Data: map[string]string{
+				caCertKey: string(caCert),
+			},
+		}
+		if namespace == cluster.Namespace {
+			if err := ctrl.SetControllerReference(cluster, configMap, rclient.Scheme()); err != nil {
+				return fmt.Errorf("cannot set controller reference: %w", err)
+			}
+		}
+		if err := checkCAConfigMapCluster(ctx, cluster, rclient, key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		logger.V(2).Info("publishing CA certificate", "cm_name", key.Name, "cm_namespace", key.Namespace)
+		if err := reconcileOwnedResource(ctx, rclient, configMap); err != nil {
+			errs = append(errs, fmt.Errorf("cannot publish CA certificate into namespace %s: %w", namespace, err))
+		}
+	}
+
+	published, err := listPublishedCAConfigMaps(ctx, cluster, rclient)
+	if err != nil {
+		return err
+	}
+	for i := range published {
+		if !desired[client.ObjectKeyFromObject(&published[i])] {
+			if err := deleteOwnedResource(ctx, rclient, &published[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if publication.TrustManager != nil {
+		bundle, err := getTrustBundle(cluster)
+		if err != nil {
+			return err
+		}
+		if err := reconcileOwnedResource(ctx, rclient, bundle); err != nil {
+			errs = append(errs, fmt.Errorf("cannot reconcile trust-manager Bundle: %w", err))
+		}
+	} else if err := deleteTrustBundle(ctx, cluster, rclient); err != nil {
+		errs = append(errs, err)
+	}
+
+	return goerrors.Join(errs...)
+}
+
+// DeleteCAPublication deletes all ConfigMaps and Bundle published for the cluster.
+func DeleteCAPublication(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	published, err := listPublishedCAConfigMaps(ctx, cluster, rclient)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range published {
+		if err := deleteOwnedResource(ctx, rclient, &published[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := deleteTrustBundle(ctx, cluster, rclient); err != nil {
+		errs = append(errs, err)
+	}
+	return goerrors.Join(errs...)
+}
+
+// checkCAConfigMapCluster refuses to take over a ConfigMap the CA certificate of another cluster is published into.
+func checkCAConfigMapCluster(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+	key types.NamespacedName,
+) error {
+	existing := &corev1.ConfigMap{}
+	if err := rclient.Get(ctx, key, existing); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	namespace, ok := existing.Labels["etcd.aenix.io/cluster-namespace"]
+	if !ok {
+		return nil
+	}
+	if name := existing.Labels["app.kubernetes.io/instance"]; name != cluster.Name || namespace != cluster.Namespace {
+		return fmt.Errorf("ConfigMap %s in namespace %s holds CA certificate of cluster %s/%s",
+			key.Name, key.Namespace, namespace, name)
+	}
+	return nil
+}
+
+func caPublicationLabels(cluster *etcdaenixiov1alpha1.EtcdCluster) map[string]string {
+	return NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy().WithClusterNamespace(cluster.Namespace)
+}
+
+func listPublishedCAConfigMaps(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) ([]corev1.ConfigMap, error) {
+	configMaps := &corev1.ConfigMapList{}
+	if err := rclient.List(ctx, configMaps, client.MatchingLabels(caPublicationLabels(cluster))); err != nil {
+		return nil, fmt.Errorf("cannot list published CA ConfigMaps: %w", err)
+	}
+	return configMaps.Items, nil
+}
+
+func getTrustBundle(cluster *etcdaenixiov1alpha1.EtcdCluster) (*unstructured.Unstructured, error) {
+	target := map[string]interface{}{
+		"configMap": map[string]interface{}{"key": caCertKey},
+	}
+	if selector := cluster.Spec.Security.CAPublication.TrustManager.NamespaceSelector; selector != nil {
+		namespaceSelector, err := runtime.DefaultUnstructuredConverter.ToUnstructured(selector)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert namespace selector: %w", err)
+		}
+		target["namespaceSelector"] = namespaceSelector
+	}
+
+	bundle := &unstructured.Unstructured{}
+	bundle.SetGroupVersionKind(TrustBundleGVK)
+	bundle.SetName(GetTrustBundleName(cluster))
+	bundle.SetLabels(caPublicationLabels(cluster))
+	bundle.Object["spec"] = map[string]interface{}{
+		"sources": []interface{}{
+			map[string]interface{}{
+				"configMap": map[string]interface{}{"name": GetCAConfigMapName(cluster), "key": caCertKey},
+			},
+		},
+		"target": target,
+	}
+	return bundle, nil
+}
+
+// deleteTrustBundle deletes Bundle of the cluster, missing trust-manager CRD is not an error.
+func deleteTrustBundle(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster, rclient client.Client) error {
+	bundle := &unstructured.Unstructured{}
+	bundle.SetGroupVersionKind(TrustBundleGVK)
+	bundle.SetName(GetTrustBundleName(cluster))
+	if err := deleteOwnedResource(ctx, rclient, bundle); err != nil && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("cannot delete trust-manager Bundle: %w", err)
+	}
+	return nil
+}
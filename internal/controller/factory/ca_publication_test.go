@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("CreateOrUpdateCAPublication handlers", func() {
+	var ns, consumerNs *corev1.Namespace
+
+	BeforeEach(func(ctx SpecContext) {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		consumerNs = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-consumer-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, consumerNs)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, consumerNs)
+	})
+
+	Context("when publishing CA certificate", func() {
+		var (
+			etcdcluster etcdaenixiov1alpha1.EtcdCluster
+			secret      corev1.Secret
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			secret = corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-server-cert-",
+					Namespace:    ns.GetName(),
+				},
+				Data: map[string][]byte{
+					"ca.crt":  []byte("test-ca"),
+					"tls.crt": []byte("test-cert"),
+					"tls.key": []byte("test-key"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, &secret)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, &secret)
+
+			etcdcluster = etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-etcdcluster-",
+					Namespace:    ns.GetName(),
+					UID:          types.UID(uuid.NewString()),
+				},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{
+							ServerSecret: secret.GetName(),
+						},
+						CAPublication: &etcdaenixiov1alpha1.CAPublicationSpec{
+							Namespaces: []string{consumerNs.GetName()},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &etcdcluster)).Should(Succeed())
+			Eventually(Get(&etcdcluster)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, &etcdcluster)
+		})
+
+		It("should publish CA certificate into requested namespaces", func(ctx SpecContext) {
+			ownConfigMap := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns.GetName(),
+					Name:      GetCAConfigMapName(&etcdcluster),
+				},
+			}
+			consumerConfigMap := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: consumerNs.GetName(),
+					Name:      GetCAConfigMapName(&etcdcluster),
+				},
+			}
+
+			By("publishing new CA certificate", func() {
+				Expect(CreateOrUpdateCAPublication(ctx, &etcdcluster, k8sClient)).To(Succeed())
+				Eventually(Get(&ownConfigMap)).Should(Succeed())
+				Expect(ownConfigMap.Data["ca.crt"]).To(Equal("test-ca"))
+				Expect(ownConfigMap.OwnerReferences).To(HaveLen(1))
+				Eventually(Get(&consumerConfigMap)).Should(Succeed())
+				Expect(consumerConfigMap.Data["ca.crt"]).To(Equal("test-ca"))
+				Expect(consumerConfigMap.OwnerReferences).To(BeEmpty())
+			})
+
+			By("updating rotated CA certificate", func() {
+				secret.Data["ca.crt"] = []byte("test-ca-rotated")
+				Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+				Expect(CreateOrUpdateCAPublication(ctx, &etcdcluster, k8sClient)).To(Succeed())
+				Eventually(Object(&consumerConfigMap)).Should(HaveField("Data", HaveKeyWithValue("ca.crt", "test-ca-rotated")))
+			})
+
+			By("deleting CA certificate from namespaces no longer requested", func() {
+				etcdcluster.Spec.Security.CAPublication.Namespaces = nil
+				Expect(CreateOrUpdateCAPublication(ctx, &etcdcluster, k8sClient)).To(Succeed())
+				err := Get(&consumerConfigMap)()
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				Expect(Get(&ownConfigMap)()).To(Succeed())
+			})
+
+			By("deleting all published CA certificates", func() {
+				Expect(DeleteCAPublication(ctx, &etcdcluster, k8sClient)).To(Succeed())
+				err := Get(&ownConfigMap)()
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		It("should not take over CA ConfigMaps of another cluster", func(ctx SpecContext) {
+			Expect(GetCAConfigMapName(&etcdcluster)).To(Equal(ns.GetName() + "-" + etcdcluster.GetName() + "-ca"))
+			// a cluster of the same name in another namespace publishing the same ConfigMap name
+			other := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: etcdcluster.GetName(), Namespace: "other"}}
+			foreign := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: consumerNs.GetName(),
+					Name:      GetCAConfigMapName(&etcdcluster),
+					Labels:    caPublicationLabels(other),
+				},
+				Data: map[string]string{"ca.crt": "other-ca"},
+			}
+			Expect(k8sClient.Create(ctx, &foreign)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, &foreign)
+
+			Expect(CreateOrUpdateCAPublication(ctx, &etcdcluster, k8sClient)).
+				To(MatchError(ContainSubstring("holds CA certificate of cluster other/")))
+			Expect(Get(&foreign)()).To(Succeed())
+			Expect(foreign.Data["ca.crt"]).To(Equal("other-ca"))
+		})
+
+		It("should fail to publish CA certificate without ca.crt field", func(ctx SpecContext) {
+			delete(secret.Data, "ca.crt")
+			Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+			Expect(CreateOrUpdateCAPublication(ctx, &etcdcluster, k8sClient)).NotTo(Succeed())
+		})
+	})
+})
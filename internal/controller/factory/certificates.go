@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// CertificateGVK is the kind of cert-manager Certificate.
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// clientCertificateCommonName is the etcd user of the operator client certificate once authentication is enabled.
+const clientCertificateCommonName = "root"
+
+// certificateRole is a certificate of the cluster issued by cert-manager.
+type certificateRole string
+
+const (
+	serverCertificate certificateRole = "server"
+	peerCertificate   certificateRole = "peer"
+	clientCertificate certificateRole = "client"
+)
+
+var certificateRoles = []certificateRole{serverCertificate, peerCertificate, clientCertificate}
+
+// IsCertManagerEnabled returns true if certificates of the cluster are issued by cert-manager.
+func IsCertManagerEnabled(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Spec.Security != nil && cluster.Spec.Security.TLS.CertManager != nil
+}
+
+// GetCertificateName returns name of the cert-manager Certificate of the given role: server, peer or client.
+func GetCertificateName(cluster *etcdaenixiov1alpha1.EtcdCluster, role string) string {
+	return fmt.Sprintf("%s-%s", cluster.Name, role)
+}
+
+// CreateOrUpdateCertificates requests server, peer and client certificates of the cluster from cert-manager.
+// The server certificate covers the client service, members and the given external addresses of the service.
+// Certificates are deleted once the cluster no longer requests them, their secrets are kept.
+func CreateOrUpdateCertificates(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+	externalAddresses []string,
+) error {
+	if cluster.Spec.Security == nil {
+		return nil
+	}
+	if !IsCertManagerEnabled(cluster) {
+		return deleteCertificates(ctx, cluster, rclient)
+	}
+	for _, role := range certificateRoles {
+		certificate := getCertificate(cluster, role, externalAddresses)
+		if certificate == nil {
+			continue
+		}
+		if err := ctrl.SetControllerReference(cluster, certificate, rclient.Scheme()); err != nil {
+			return fmt.Errorf("cannot set controller reference: %w", err)
+		}
+		if err := reconcileOwnedResource(ctx, rclient, certificate); err != nil {
+			return fmt.Errorf("cannot reconcile %s certificate: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// getCertificate returns the Certificate of the role, nil if the cluster does not name its secret.
+func getCertificate(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	role certificateRole,
+	externalAddresses []string,
+) *unstructured.Unstructured {
+	tls := cluster.Spec.Security.TLS
+	var secretName string
+	var names, ips, usages []interface{}
+	spec := map[string]interface{}{}
+	switch role {
+	case serverCertificate:
+		secretName = tls.ServerSecret
+		dnsNames, ipAddresses := getServerCertificateAddresses(cluster, externalAddresses)
+		for _, name := range dnsNames {
+			names = append(names, name)
+		}
+		for _, ip := range ipAddresses {
+			ips = append(ips, ip.String())
+		}
+		usages = []interface{}{"server auth", "digital signature", "key encipherment"}
+	case peerCertificate:
+		secretName = tls.PeerSecret
+		names = []interface{}{"*." + getHeadlessServiceDomain(cluster)}
+		usages = []interface{}{"server auth", "client auth", "digital signature", "key encipherment"}
+	case clientCertificate:
+		secretName = tls.ClientSecret
+		spec["commonName"] = clientCertificateCommonName
+		usages = []interface{}{"client auth", "digital signature", "key encipherment"}
+	}
+	if secretName == "" {
+		return nil
+	}
+
+	certManager := tls.CertManager
+	issuerRef := map[string]interface{}{"name": certManager.IssuerRef.Name}
+	if certManager.IssuerRef.Kind != "" {
+		issuerRef["kind"] = certManager.IssuerRef.Kind
+	}
+	if certManager.IssuerRef.Group != "" {
+		issuerRef["group"] = certManager.IssuerRef.Group
+	}
+	spec["secretName"] = secretName
+	spec["issuerRef"] = issuerRef
+	spec["usages"] = usages
+	if len(names) > 0 {
+		spec["dnsNames"] = names
+	}
+	if len(ips) > 0 {
+		spec["ipAddresses"] = ips
+	}
+	if certManager.Duration != nil {
+		spec["duration"] = certManager.Duration.Duration.String()
+	}
+	if certManager.RenewBefore != nil {
+		spec["renewBefore"] = certManager.RenewBefore.Duration.String()
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	certificate.SetNamespace(cluster.Namespace)
+	certificate.SetName(GetCertificateName(cluster, string(role)))
+	certificate.SetLabels(NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy())
+	certificate.Object["spec"] = spec
+	return certificate
+}
+
+// getServerCertificateAddresses returns DNS names and IP addresses the server certificate covers: the client service,
+// members resolved by the headless service, the loopback and the given external addresses of the client service.
+func getServerCertificateAddresses(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	externalAddresses []string,
+) ([]string, []net.IP) {
+	service := GetServiceName(cluster)
+	names := []string{
+		service,
+		fmt.Sprintf("%s.%s", service, cluster.Namespace),
+		fmt.Sprintf("%s.%s.svc", service, cluster.Namespace),
+		"*." + getHeadlessServiceDomain(cluster),
+		"localhost",
+	}
+	ips := []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	for _, address := range externalAddresses {
+		if ip := net.ParseIP(address); ip != nil {
+			ips = append(ips, ip)
+		} else {
+			names = append(names, address)
+		}
+	}
+	return names, ips
+}
+
+// getHeadlessServiceDomain returns the domain members are resolved in by the headless service.
+func getHeadlessServiceDomain(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s.%s.svc", GetHeadlessServiceName(cluster), cluster.Namespace)
+}
+
+// deleteCertificates deletes Certificates of the cluster, missing cert-manager CRD is not an error.
+func deleteCertificates(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster, rclient client.Client) error {
+	var errs []error
+	for _, role := range certificateRoles {
+		certificate := &unstructured.Unstructured{}
+		certificate.SetGroupVersionKind(CertificateGVK)
+		certificate.SetNamespace(cluster.Namespace)
+		certificate.SetName(GetCertificateName(cluster, string(role)))
+		if err := deleteOwnedResource(ctx, rclient, certificate); err != nil && !meta.IsNoMatchError(err) {
+			errs = append(errs, fmt.Errorf("cannot delete %s certificate: %w", role, err))
+		}
+	}
+	return goerrors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("cert-manager certificates", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Security: &etcdaenixiov1alpha1.SecuritySpec{
+					TLS: etcdaenixiov1alpha1.TLSSpec{
+						ServerSecret: "test-server-tls",
+						PeerSecret:   "test-peer-tls",
+						CertManager: &etcdaenixiov1alpha1.CertManagerSpec{
+							IssuerRef: etcdaenixiov1alpha1.CertManagerIssuerRef{
+								Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io",
+							},
+							Duration: &metav1.Duration{Duration: 720 * time.Hour},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("should issue the server certificate for services, members and external addresses", func() {
+		certificate := getCertificate(cluster, serverCertificate, []string{"etcd.example.com", "203.0.113.10"})
+		Expect(certificate.GetName()).To(Equal("test-server"))
+		Expect(certificate.GetNamespace()).To(Equal("ns"))
+		secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+		Expect(secretName).To(Equal("test-server-tls"))
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ConsistOf(
+			"test", "test.ns", "test.ns.svc", "*.test-headless.ns.svc", "localhost", "etcd.example.com"))
+		ips, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "ipAddresses")
+		Expect(ips).To(ConsistOf("127.0.0.1", "::1", "203.0.113.10"))
+		issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+		Expect(issuerRef).To(Equal(map[string]string{"name": "ca", "kind": "ClusterIssuer", "group": "cert-manager.io"}))
+		duration, _, _ := unstructured.NestedString(certificate.Object, "spec", "duration")
+		Expect(duration).To(Equal("720h0m0s"))
+	})
+
+	It("should issue the peer certificate for client and server authentication", func() {
+		certificate := getCertificate(cluster, peerCertificate, nil)
+		usages, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "usages")
+		Expect(usages).To(ContainElements("server auth", "client auth"))
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(Equal([]string{"*.test-headless.ns.svc"}))
+	})
+
+	It("should not issue certificates without secret", func() {
+		Expect(getCertificate(cluster, clientCertificate, nil)).To(BeNil())
+
+		cluster.Spec.Security.TLS.ClientSecret = "test-client-tls"
+		certificate := getCertificate(cluster, clientCertificate, nil)
+		commonName, _, _ := unstructured.NestedString(certificate.Object, "spec", "commonName")
+		Expect(commonName).To(Equal(clientCertificateCommonName))
+		_, found, _ := unstructured.NestedFieldNoCopy(certificate.Object, "spec", "dnsNames")
+		Expect(found).To(BeFalse())
+	})
+})
@@ -75,7 +75,9 @@ func FillConditions(cluster *etcdaenixiov1alpha1.EtcdCluster) {
 }
 
 // SetCondition sets either replaces corresponding existing condition in the .status.Conditions list or appends
-// one passed as an argument. In case operation will not result into condition status change, return.
+// one passed as an argument. In case operation will not result into condition status, reason or message change,
+// only observed generation is updated, so conditions do not look stale after spec changes.
+// Transition timestamp is preserved unless condition status is changed.
 func SetCondition(
 	cluster *etcdaenixiov1alpha1.EtcdCluster,
 	condition metav1.Condition,
@@ -91,9 +93,14 @@ func SetCondition(
 	}
 	statusNotChanged := cluster.Status.Conditions[idx].Status == condition.Status
 	reasonNotChanged := cluster.Status.Conditions[idx].Reason == condition.Reason
-	if statusNotChanged && reasonNotChanged {
+	messageNotChanged := cluster.Status.Conditions[idx].Message == condition.Message
+	if statusNotChanged && reasonNotChanged && messageNotChanged {
+		cluster.Status.Conditions[idx].ObservedGeneration = condition.ObservedGeneration
 		return
 	}
+	if statusNotChanged {
+		condition.LastTransitionTime = cluster.Status.Conditions[idx].LastTransitionTime
+	}
 	cluster.Status.Conditions[idx] = condition
 }
 
@@ -64,6 +64,17 @@ var _ = Describe("Condition builder", func() {
 				Expect(etcdCluster.Status.Conditions[idx].LastTransitionTime).To(Equal(timestamp))
 			})
 
+			By("setting condition without change after spec change", func() {
+				etcdCluster.Generation++
+				SetCondition(etcdCluster, NewCondition(etcdaenixiov1alpha1.EtcdConditionInitialized).
+					WithStatus(false).
+					WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeInitStarted)).
+					WithMessage("test").
+					Complete())
+				Expect(etcdCluster.Status.Conditions[idx].ObservedGeneration).To(Equal(etcdCluster.Generation))
+				Expect(etcdCluster.Status.Conditions[idx].LastTransitionTime).To(Equal(timestamp))
+			})
+
 			By("setting condition with status changed", func() {
 				SetCondition(etcdCluster, NewCondition(etcdaenixiov1alpha1.EtcdConditionInitialized).
 					WithStatus(true).
@@ -58,10 +58,15 @@ func CreateOrUpdateClusterStateConfigMap(
 		Data: map[string]string{
 			"ETCD_INITIAL_CLUSTER_STATE": "new",
 			"ETCD_INITIAL_CLUSTER":       initialCluster,
-			"ETCD_INITIAL_CLUSTER_TOKEN": cluster.Name + "-" + cluster.Namespace,
+			"ETCD_INITIAL_CLUSTER_TOKEN": GetInitialClusterToken(cluster),
 		},
 	}
 
+	// members discover each other by SRV records, etcd rejects both bootstrap flags set
+	if cluster.UsesDiscoverySRV() {
+		delete(configMap.Data, "ETCD_INITIAL_CLUSTER")
+	}
+
 	if isEtcdClusterReady(cluster) {
 		// update cluster state to existing
 		logger.V(2).Info("updating cluster state", "cluster_name", cluster.Name)
@@ -83,3 +88,8 @@ func isEtcdClusterReady(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
 	return cond != nil && (cond.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady) ||
 		cond.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady))
 }
+
+// GetInitialClusterToken returns the token members of the cluster are bootstrapped with.
+func GetInitialClusterToken(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-" + cluster.Namespace
+}
@@ -114,6 +114,16 @@ var _ = Describe("CreateOrUpdateClusterStateConfigMap handlers", func() {
 			})
 		})
 
+		It("should not list initial members in the DiscoverySRV bootstrap mode", func(ctx SpecContext) {
+			etcdcluster.Spec.Bootstrap = etcdaenixiov1alpha1.BootstrapDiscoverySRV
+			Expect(CreateOrUpdateClusterStateConfigMap(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&configMap)).Should(HaveField("Data", SatisfyAll(
+				Not(HaveKey("ETCD_INITIAL_CLUSTER")),
+				HaveKeyWithValue("ETCD_INITIAL_CLUSTER_STATE", "new"),
+				HaveKey("ETCD_INITIAL_CLUSTER_TOKEN"),
+			)))
+		})
+
 		It("should fail to create the configmap with invalid owner reference", func(ctx SpecContext) {
 			Expect(CreateOrUpdateClusterStateConfigMap(ctx, &etcdcluster, clientWithEmptyScheme)).NotTo(Succeed())
 		})
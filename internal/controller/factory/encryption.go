@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	dmCryptContainerName  = "data-crypt"
+	dmCryptDataVolumeName = "data-decrypted"
+	dmCryptKeyVolumeName  = "data-crypt-key"
+	dmCryptDevicePath     = "/dev/etcd-data"
+	dmCryptKeyPath        = "/etc/etcd/crypt"
+	dmCryptDataMountPath  = "/var/run/etcd"
+	dmCryptKeyFile        = "key"
+)
+
+// dmCryptScript opens LUKS volume of the member, formatting it on first start, and mounts it into the emptyDir
+// shared with etcd. The mapping is closed after etcd stops, as native sidecars are stopped after main containers.
+const dmCryptScript = `set -e
+key=` + dmCryptKeyPath + `/` + dmCryptKeyFile + `
+name="etcd-${POD_NAMESPACE}-${POD_NAME}"
+if ! cryptsetup isLuks ` + dmCryptDevicePath + `; then
+  cryptsetup luksFormat --batch-mode --key-file "$key" ` + dmCryptDevicePath + `
+fi
+cryptsetup status "$name" >/dev/null 2>&1 || cryptsetup open --key-file "$key" ` + dmCryptDevicePath + ` "$name"
+blkid "/dev/mapper/$name" >/dev/null 2>&1 || mkfs.ext4 -q "/dev/mapper/$name"
+mountpoint -q ` + dmCryptDataMountPath + ` || mount "/dev/mapper/$name" ` + dmCryptDataMountPath + `
+trap 'umount ` + dmCryptDataMountPath + `; cryptsetup close "$name"; exit 0' TERM INT
+while true; do sleep 3600 & wait $!; done
+`
+
+// getDataVolumeName returns name of the volume mounted as etcd data dir.
+func getDataVolumeName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	if cluster.Spec.Storage.IsDMCryptEncrypted() {
+		return dmCryptDataVolumeName
+	}
+	return "data"
+}
+
+// generateDMCryptVolumes returns the emptyDir the decrypted volume is mounted into and the passphrase volume.
+func generateDMCryptVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
+	dmCrypt := cluster.Spec.Storage.Encryption.DMCrypt
+	return []corev1.Volume{
+		{
+			Name:         dmCryptDataVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		{
+			Name: dmCryptKeyVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: dmCrypt.KeySecret.Name,
+					Items:      []corev1.KeyToPath{{Key: dmCrypt.KeySecret.Key, Path: dmCryptKeyFile}},
+				},
+			},
+		},
+	}
+}
+
+// generateDMCryptContainer returns native sidecar holding the decrypted member volume mounted while etcd runs.
+func generateDMCryptContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Container {
+	return corev1.Container{
+		Name:          dmCryptContainerName,
+		Image:         cluster.Spec.Storage.Encryption.DMCrypt.Image,
+		Command:       []string{"/bin/sh", "-c", dmCryptScript},
+		RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+		Env: []corev1.EnvVar{
+			{
+				Name:      "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+			},
+			{
+				Name:      "POD_NAMESPACE",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+			},
+		},
+		SecurityContext: dmCryptSecurityContext(cluster),
+		VolumeDevices:   []corev1.VolumeDevice{{Name: "data", DevicePath: dmCryptDevicePath}},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:             dmCryptDataVolumeName,
+				MountPath:        dmCryptDataMountPath,
+				MountPropagation: ptr.To(corev1.MountPropagationBidirectional),
+			},
+			{Name: dmCryptKeyVolumeName, MountPath: dmCryptKeyPath, ReadOnly: true},
+		},
+		// etcd is started once the decrypted volume is mounted
+		StartupProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"mountpoint", "-q", dmCryptDataMountPath}},
+			},
+			PeriodSeconds:    2,
+			FailureThreshold: 150,
+		},
+	}
+}
+
+// dmCryptSecurityContext returns security context of the sidecar, it needs root to open and mount volumes
+// also when etcd runs as another user.
+func dmCryptSecurityContext(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.SecurityContext {
+	securityContext := &corev1.SecurityContext{Privileged: ptr.To(true)}
+	if root := rootSecurityContext(cluster); root != nil {
+		securityContext.RunAsUser = root.RunAsUser
+		securityContext.RunAsGroup = root.RunAsGroup
+	}
+	return securityContext
+}
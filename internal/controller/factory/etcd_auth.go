@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/pki"
+)
+
+// RootUser is the etcd user the operator authenticates as once authentication is enabled.
+const RootUser = "root"
+
+// GetRootCredentialsSecretName returns name of the secret with the password of the etcd root user.
+func GetRootCredentialsSecretName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-root-credentials"
+}
+
+// NewRootCredentialsSecret returns the root credentials secret with a random password.
+func NewRootCredentialsSecret(cluster *etcdaenixiov1alpha1.EtcdCluster) (*corev1.Secret, error) {
+	password := make([]byte, 24)
+	if _, err := rand.Read(password); err != nil {
+		return nil, fmt.Errorf("cannot generate root password: %w", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      GetRootCredentialsSecretName(cluster),
+			Labels:    NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte(RootUser),
+			corev1.BasicAuthPasswordKey: []byte(base64.RawURLEncoding.EncodeToString(password)),
+		},
+	}, nil
+}
+
+// GetEtcdCredentials returns username and password etcd clients authenticate with, empty if authentication is
+// neither requested nor enabled. Credentials are used as soon as authentication is requested, etcd accepts clients
+// authenticating while it is not enabled yet.
+func GetEtcdCredentials(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) (string, string, error) {
+	if !cluster.IsAuthEnabled() && !cluster.Status.AuthEnabled {
+		return "", "", nil
+	}
+	name := GetRootCredentialsSecretName(cluster)
+	secret := &corev1.Secret{}
+	err := rclient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: name}, secret)
+	if errors.IsNotFound(err) && !cluster.Status.AuthEnabled {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("cannot get root credentials secret: %w", err)
+	}
+	username, password := secret.Data[corev1.BasicAuthUsernameKey], secret.Data[corev1.BasicAuthPasswordKey]
+	if len(username) == 0 || len(password) == 0 {
+		return "", "", fmt.Errorf("root credentials secret %s has no %s or %s field",
+			name, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+	return string(username), string(password), nil
+}
+
+const (
+	// JWTPrivateKeyKey and JWTPublicKeyKey hold the PEM encoded key pair in the JWT key secret.
+	JWTPrivateKeyKey = "jwt.key"
+	JWTPublicKeyKey  = "jwt.pub"
+	// JWTKeyChecksumAnnotation holds checksum of the public key members sign tokens with, so members are restarted
+	// to load a rotated key.
+	JWTKeyChecksumAnnotation = "etcd.aenix.io/jwt-key-checksum"
+
+	jwtKeyVolumeName = "jwt-key"
+	jwtKeyMountPath  = "/etc/etcd/jwt"
+	// jwtRSAKeySize is the size of keys of RS sign methods, the hash of the method does not depend on it.
+	jwtRSAKeySize = 2048
+)
+
+// GetJWTKeySecretName returns name of the secret with the key pair JSON Web Tokens are signed with.
+func GetJWTKeySecretName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-jwt-key"
+}
+
+// NewJWTKey generates the PEM encoded private and public key of the sign method.
+func NewJWTKey(signMethod string) ([]byte, []byte, error) {
+	var key crypto.Signer
+	var err error
+	switch signMethod {
+	case "RS256", "RS384", "RS512":
+		key, err = rsa.GenerateKey(rand.Reader, jwtRSAKeySize)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	default:
+		return nil, nil, fmt.Errorf("unsupported JWT sign method %s", signMethod)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot generate JWT key: %w", err)
+	}
+	privatePEM, err := pki.EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot encode JWT public key: %w", err)
+	}
+	return privatePEM, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// IsJWTKeyValid returns true if the PEM encoded key pair can sign tokens with the sign method.
+func IsJWTKeyValid(signMethod string, privatePEM, publicPEM []byte) bool {
+	privateBlock, _ := pem.Decode(privatePEM)
+	publicBlock, _ := pem.Decode(publicPEM)
+	if privateBlock == nil || publicBlock == nil {
+		return false
+	}
+	key, err := x509.ParsePKCS8PrivateKey(privateBlock.Bytes)
+	if err != nil {
+		return false
+	}
+	public, err := x509.ParsePKIXPublicKey(publicBlock.Bytes)
+	if err != nil {
+		return false
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	if equal, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !equal.Equal(public) {
+		return false
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return strings.HasPrefix(signMethod, "RS")
+	case *ecdsa.PrivateKey:
+		curves := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}
+		return curves[signMethod] == k.Curve
+	}
+	return false
+}
+
+// GetJWTKeyChecksum returns checksum of the public key of the JWT key secret, empty if the cluster does not use JWT
+// or the secret is not created yet.
+func GetJWTKeyChecksum(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) (string, error) {
+	if !cluster.UsesJWT() {
+		return "", nil
+	}
+	secret := &corev1.Secret{}
+	err := rclient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: GetJWTKeySecretName(cluster)}, secret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get JWT key secret: %w", err)
+	}
+	sum := sha256.Sum256(secret.Data[JWTPublicKeyKey])
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// withJWTKeyChecksum annotates the pod template with checksum of the JWT key, so the template changes with it.
+func withJWTKeyChecksum(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+	template *corev1.PodTemplateSpec,
+) error {
+	checksum, err := GetJWTKeyChecksum(ctx, cluster, rclient)
+	if err != nil || checksum == "" {
+		return err
+	}
+	// annotations may be shared with spec.podTemplate of the cluster
+	annotations := maps.Clone(template.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[JWTKeyChecksumAnnotation] = checksum
+	template.Annotations = annotations
+	return nil
+}
+
+// generateAuthTokenArgs passes spec.security.auth.jwt to etcd, the flag set explicitly in spec.options takes precedence.
+func generateAuthTokenArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	if _, ok := cluster.Spec.Options["auth-token"]; ok || !cluster.UsesJWT() {
+		return nil
+	}
+	jwt := cluster.Spec.Security.Auth.JWT
+	return []string{fmt.Sprintf("--auth-token=jwt,pub-key=%s/%s,priv-key=%s/%s,sign-method=%s,ttl=%s",
+		jwtKeyMountPath, JWTPublicKeyKey, jwtKeyMountPath, JWTPrivateKeyKey, jwt.GetSignMethod(), jwt.GetTTL())}
+}
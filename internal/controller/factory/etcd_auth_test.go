@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT key", func() {
+	It("should generate keys of the sign method", func() {
+		for _, signMethod := range []string{"RS256", "ES256", "ES512"} {
+			privatePEM, publicPEM, err := NewJWTKey(signMethod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsJWTKeyValid(signMethod, privatePEM, publicPEM)).To(BeTrue(), signMethod)
+		}
+	})
+
+	It("should refuse keys of another sign method or pair", func() {
+		privatePEM, publicPEM, err := NewJWTKey("ES256")
+		Expect(err).NotTo(HaveOccurred())
+		_, otherPublicPEM, err := NewJWTKey("ES256")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IsJWTKeyValid("ES384", privatePEM, publicPEM)).To(BeFalse())
+		Expect(IsJWTKeyValid("RS256", privatePEM, publicPEM)).To(BeFalse())
+		Expect(IsJWTKeyValid("ES256", privatePEM, otherPublicPEM)).To(BeFalse())
+		Expect(IsJWTKeyValid("ES256", nil, nil)).To(BeFalse())
+	})
+})
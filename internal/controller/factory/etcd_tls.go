@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// TLSChecksumAnnotation holds checksum of TLS secrets mounted into member pods, so members are restarted
+// to load changed certificates.
+const TLSChecksumAnnotation = "etcd.aenix.io/tls-checksum"
+
+// GetEtcdTLSConfig returns TLS config of etcd clients, nil if etcd serves clients without TLS. Server certificate
+// is verified with ca.crt of the server secret, client certificate is presented if client secret is set.
+func GetEtcdTLSConfig(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) (*tls.Config, error) {
+	if cluster.Spec.Security == nil || cluster.Spec.Security.TLS.ServerSecret == "" {
+		return nil, nil
+	}
+
+	serverSecret := &corev1.Secret{}
+	err := rclient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Spec.Security.TLS.ServerSecret}, serverSecret)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get server certificate secret: %w", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(serverSecret.Data[corev1.ServiceAccountRootCAKey]) {
+		return nil, fmt.Errorf("server certificate secret %s has no valid %s field", serverSecret.Name, corev1.ServiceAccountRootCAKey)
+	}
+	tlsConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cluster.Spec.Security.TLS.ClientSecret != "" {
+		clientSecret := &corev1.Secret{}
+		err := rclient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Spec.Security.TLS.ClientSecret}, clientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get client certificate secret: %w", err)
+		}
+		cert, err := tls.X509KeyPair(clientSecret.Data[corev1.TLSCertKey], clientSecret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// GetTLSChecksum returns checksum of TLS secrets members load on start, empty if none of them exists.
+// etcd reloads key pairs on every handshake, but not trusted CAs, so only CA certificates of secrets renewed
+// by the operator or cert-manager are covered and routine renewals do not restart members.
+// Missing secrets are skipped, the checksum changes once they are created.
+func GetTLSChecksum(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) (string, error) {
+	if cluster.Spec.Security == nil {
+		return "", nil
+	}
+	tlsSpec := cluster.Spec.Security.TLS
+	// the CRL is read on every client connection
+	names := []string{tlsSpec.PeerTrustedCASecret, tlsSpec.PeerSecret, tlsSpec.ServerSecret, tlsSpec.ClientTrustedCASecret}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	issued := cluster.UsesInternalCA() || IsCertManagerEnabled(cluster)
+
+	hash := sha256.New()
+	found := false
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := rclient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: name}, secret)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot get TLS secret %s: %w", name, err)
+		}
+		found = true
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			if !issued || key == corev1.ServiceAccountRootCAKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(hash, "%s/%s:%d:", name, key, len(secret.Data[key]))
+			_, _ = hash.Write(secret.Data[key])
+		}
+	}
+	if !found {
+		return "", nil
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8]), nil
+}
+
+type heldTLSChecksumKey struct{}
+
+// WithHeldTLSChecksum returns context rendering pod templates with the given TLS checksum instead of the checksum
+// of current secrets, so members are not restarted to load changed secrets yet.
+func WithHeldTLSChecksum(ctx context.Context, checksum string) context.Context {
+	return context.WithValue(ctx, heldTLSChecksumKey{}, checksum)
+}
+
+// withTLSChecksum annotates the pod template with checksum of TLS secrets, so the template changes with them.
+func withTLSChecksum(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+	template *corev1.PodTemplateSpec,
+) error {
+	checksum, held := ctx.Value(heldTLSChecksumKey{}).(string)
+	if !held {
+		var err error
+		if checksum, err = GetTLSChecksum(ctx, cluster, rclient); err != nil {
+			return err
+		}
+	}
+	if checksum == "" {
+		return nil
+	}
+	// annotations may be shared with spec.podTemplate of the cluster
+	annotations := maps.Clone(template.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TLSChecksumAnnotation] = checksum
+	template.Annotations = annotations
+	return nil
+}
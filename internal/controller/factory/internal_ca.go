@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"crypto/x509"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/pki"
+)
+
+// GetInternalCASecretName returns name of the secret keeping the CA generated by the operator for the cluster.
+func GetInternalCASecretName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-internal-ca"
+}
+
+// GetMemberCertificateKey returns the key of the peer certificate of the member in the peer secret
+// issued by the internal CA.
+func GetMemberCertificateKey(podName string) string {
+	return podName + ".crt"
+}
+
+// GetMemberPrivateKeyKey returns the key of the peer certificate private key of the member in the peer secret
+// issued by the internal CA.
+func GetMemberPrivateKeyKey(podName string) string {
+	return podName + ".key"
+}
+
+// GetInternalCACommonName returns the common name of the CA generated by the operator for the cluster.
+func GetInternalCACommonName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Namespace + "/" + cluster.Name + " etcd CA"
+}
+
+// GetMemberPeerCertificateRequest returns the peer certificate of the member, which both serves and opens
+// connections to other members.
+func GetMemberPeerCertificateRequest(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) pki.Request {
+	return pki.Request{
+		CommonName: podName,
+		DNSNames:   []string{podName + "." + getHeadlessServiceDomain(cluster)},
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// GetServerCertificateRequest returns the server certificate shared by members, covering the client service,
+// members and the given external addresses of the client service.
+func GetServerCertificateRequest(cluster *etcdaenixiov1alpha1.EtcdCluster, externalAddresses []string) pki.Request {
+	dnsNames, ipAddresses := getServerCertificateAddresses(cluster, externalAddresses)
+	return pki.Request{
+		CommonName:  GetServiceName(cluster),
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
+		Usages:      []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+// GetClientCertificateRequest returns the client certificate of the operator, authenticated as the etcd root user.
+func GetClientCertificateRequest() pki.Request {
+	return pki.Request{
+		CommonName: clientCertificateCommonName,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
@@ -1,5 +1,16 @@
 package factory
 
+import (
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// LabelMemberRole is set on member pods to leader, voter or learner as of the last observation.
+	LabelMemberRole = "etcd.aenix.io/member-role"
+	// MemberRoleLeader is the role of the member pod which is the leader of the cluster.
+	MemberRoleLeader = "leader"
+)
+
 type LabelsBuilder map[string]string
 
 func NewLabelsBuilder() LabelsBuilder {
@@ -20,3 +31,27 @@ func (b LabelsBuilder) WithManagedBy() LabelsBuilder {
 	b["app.kubernetes.io/managed-by"] = "etcd-operator"
 	return b
 }
+
+// WithMemberRole selects members of the role as of the last observation of the operator.
+func (b LabelsBuilder) WithMemberRole(role string) LabelsBuilder {
+	b[LabelMemberRole] = role
+	return b
+}
+
+func (b LabelsBuilder) WithClusterNamespace(namespace string) LabelsBuilder {
+	b["etcd.aenix.io/cluster-namespace"] = namespace
+	return b
+}
+
+// withClusterSelector returns labels with selector labels of the cluster overriding user defined values,
+// so objects of the cluster can not be selected by other clusters in the namespace or the other way round.
+func withClusterSelector(cluster *etcdaenixiov1alpha1.EtcdCluster, labels map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+3)
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy() {
+		merged[key] = value
+	}
+	return merged
+}
@@ -26,6 +26,11 @@ var _ = Describe("Labels builder", func() {
 			builder.WithInstance("local")
 			Expect(builder["app.kubernetes.io/instance"]).To(Equal("local"))
 		})
+		It("WithClusterNamespace sets correct key and value", func() {
+			builder := NewLabelsBuilder()
+			builder.WithClusterNamespace("default")
+			Expect(builder["etcd.aenix.io/cluster-namespace"]).To(Equal("default"))
+		})
 		It("Chaining methods builds correct map", func() {
 			builder := NewLabelsBuilder()
 			builder.WithName().WithManagedBy().WithInstance("local")
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	logShipperContainerName    = "log-shipper"
+	logShipperConfigVolumeName = "log-shipper-config"
+	logShipperConfigPath       = "/etc/log-shipper"
+	logShipperConfigFile       = "fluent-bit.conf"
+	logShipperParsersFile      = "parsers.conf"
+	defaultLogShipperImage     = "cr.fluentbit.io/fluent/fluent-bit:3.0"
+	etcdLogsVolumeName         = "etcd-logs"
+	etcdLogsPath               = "/var/log/etcd"
+	etcdLogFile                = etcdLogsPath + "/etcd.log"
+	// etcdLogRotation keeps up to 400MB of logs, the logs volume is limited accordingly
+	etcdLogRotation = `{"maxsize":100,"maxage":0,"maxbackups":3,"localtime":false,"compress":false}`
+)
+
+var etcdLogsSizeLimit = resource.MustParse("512Mi")
+
+// logShipperParsers parses JSON lines written by the zap logger of etcd.
+const logShipperParsers = `[PARSER]
+    Name        etcd
+    Format      json
+    Time_Key    ts
+    Time_Format %Y-%m-%dT%H:%M:%S.%L%z
+    Time_Keep   On
+`
+
+// IsLogShipperEnabled returns true if etcd logs are shipped by the sidecar.
+func IsLogShipperEnabled(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Spec.Logging != nil && cluster.Spec.Logging.Shipper != nil
+}
+
+// GetLogShipperConfigMapName returns name of the ConfigMap with configuration of the log shipping sidecar.
+func GetLogShipperConfigMapName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-log-shipper"
+}
+
+// generateLogShipperConfig returns Fluent Bit configuration tailing the etcd log, attaching cluster and member
+// fields, printing records to stdout and shipping them to the outputs of the spec.
+func generateLogShipperConfig(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `[SERVICE]
+    Flush        1
+    Log_Level    warn
+    Parsers_File %s
+
+[INPUT]
+    Name             tail
+    Tag              etcd
+    Path             %s
+    Parser           etcd
+    DB               %s/log-shipper.db
+    Refresh_Interval 5
+    Rotate_Wait      30
+
+[FILTER]
+    Name   modify
+    Match  etcd
+    Add    cluster %s
+    Add    namespace ${POD_NAMESPACE}
+    Add    member ${POD_NAME}
+
+[OUTPUT]
+    Name   stdout
+    Match  etcd
+    Format json_lines
+
+`, logShipperConfigPath+"/"+logShipperParsersFile, etcdLogFile, etcdLogsPath, cluster.Name)
+	b.WriteString(strings.TrimSpace(cluster.Spec.Logging.Shipper.Outputs))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// CreateOrUpdateLogShipperConfigMap creates the ConfigMap with configuration of the log shipping sidecar,
+// or deletes it if logs are not shipped.
+func CreateOrUpdateLogShipperConfigMap(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      GetLogShipperConfigMapName(cluster),
+			Labels:    NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+		},
+	}
+	if !IsLogShipperEnabled(cluster) {
+		return deleteOwnedResource(ctx, rclient, configMap)
+	}
+	configMap.Data = map[string]string{
+		logShipperConfigFile:  generateLogShipperConfig(cluster),
+		logShipperParsersFile: logShipperParsers,
+	}
+	if err := ctrl.SetControllerReference(cluster, configMap, rclient.Scheme()); err != nil {
+		return fmt.Errorf("cannot set controller reference: %w", err)
+	}
+	return reconcileOwnedResource(ctx, rclient, configMap)
+}
+
+// generateLogShipperArgs makes etcd write logs into the rotated file tailed by the sidecar,
+// flags set explicitly in spec.options take precedence.
+func generateLogShipperArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	if !IsLogShipperEnabled(cluster) {
+		return nil
+	}
+	options := cluster.EtcdOptions()
+	args := []string{}
+	for _, flag := range [][2]string{
+		{"log-outputs", etcdLogFile},
+		{"enable-log-rotation", "true"},
+		{"log-rotation-config-json", etcdLogRotation},
+	} {
+		if _, ok := options[flag[0]]; !ok {
+			args = append(args, fmt.Sprintf("--%s=%s", flag[0], flag[1]))
+		}
+	}
+	return args
+}
+
+// generateLogShipperVolumes returns the volume etcd writes logs into and the sidecar configuration volume.
+func generateLogShipperVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: etcdLogsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: ptr.To(etcdLogsSizeLimit)},
+			},
+		},
+		{
+			Name: logShipperConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: GetLogShipperConfigMapName(cluster)},
+				},
+			},
+		},
+	}
+}
+
+// generateLogShipperContainer returns native sidecar shipping etcd logs, so it starts before etcd and
+// stops after it. The configuration hash restarts members once the configuration changes.
+func generateLogShipperContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Container {
+	shipper := cluster.Spec.Logging.Shipper
+	image := shipper.Image
+	if image == "" {
+		image = defaultLogShipperImage
+	}
+	hash := sha256.Sum256([]byte(generateLogShipperConfig(cluster)))
+	env := []corev1.EnvVar{
+		{
+			Name:      "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+		},
+		{
+			Name:      "POD_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+		},
+		{Name: "LOG_SHIPPER_CONFIG_HASH", Value: hex.EncodeToString(hash[:8])},
+	}
+	return corev1.Container{
+		Name:          logShipperContainerName,
+		Image:         image,
+		Command:       []string{"/fluent-bit/bin/fluent-bit", "-c", logShipperConfigPath + "/" + logShipperConfigFile},
+		RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+		Env:           append(env, shipper.Env...),
+		Resources:     shipper.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: etcdLogsVolumeName, MountPath: etcdLogsPath},
+			{Name: logShipperConfigVolumeName, MountPath: logShipperConfigPath, ReadOnly: true},
+		},
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// MemberSpecHashAnnotation holds hash of the pod template a member pod was created from.
+	// Members with outdated hash are recreated in the Pods member management mode.
+	MemberSpecHashAnnotation = "etcd.aenix.io/member-spec-hash"
+	// selectedNodeAnnotation asks volume provisioners to provision the claim on the node, the scheduler sets it
+	// on claims of StorageClasses with WaitForFirstConsumer binding mode.
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+// GetMemberPodName returns name of the member with the ordinal, the same name StatefulSet gives its pods.
+func GetMemberPodName(cluster *etcdaenixiov1alpha1.EtcdCluster, ordinal int) string {
+	return fmt.Sprintf("%s-%d", cluster.Name, ordinal)
+}
+
+// GetMemberPVCName returns name of the data volume claim of the member, the same name StatefulSet gives it.
+func GetMemberPVCName(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	return GetPVCName(cluster) + "-" + podName
+}
+
+// CreateOrUpdateMemberPods manages member pods and their PVCs in the Pods member management mode.
+// Missing members are created, members beyond spec.replicas are deleted and members created from an outdated
+// pod template are recreated one at a time, starting from the highest ordinal, while all other members are ready.
+// Cordoned members are neither recreated nor required to be ready.
+// PVCs of deleted members are retained, unless spec.storage.retentionPolicy deletes them, see the cluster controller.
+func CreateOrUpdateMemberPods(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	template, err := generatePodTemplate(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if err := withTLSChecksum(ctx, cluster, rclient, &template); err != nil {
+		return err
+	}
+	if err := withJWTKeyChecksum(ctx, cluster, rclient, &template); err != nil {
+		return err
+	}
+	hash, err := hashPodTemplate(template)
+	if err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	err = rclient.List(ctx, pods,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot list member pods: %w", err)
+	}
+	existing := make(map[string]*corev1.Pod, len(pods.Items))
+	for i := range pods.Items {
+		existing[pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	var outdated []*corev1.Pod
+	allReady := true
+	for ordinal := 0; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+		name := GetMemberPodName(cluster, ordinal)
+		pod, found := existing[name]
+		delete(existing, name)
+		if !found {
+			if err := createMember(ctx, cluster, rclient, template, hash, name); err != nil {
+				return err
+			}
+			allReady = false
+			continue
+		}
+		if cluster.IsMemberCordoned(ordinal) {
+			continue
+		}
+		if !pod.DeletionTimestamp.IsZero() || !isMemberPodReady(pod) {
+			allReady = false
+		}
+		if pod.Annotations[MemberSpecHashAnnotation] != hash {
+			outdated = append(outdated, pod)
+		}
+	}
+
+	for _, pod := range existing {
+		if err := deleteOwnedResource(ctx, rclient, pod); err != nil {
+			return fmt.Errorf("cannot delete member pod %s: %w", pod.Name, err)
+		}
+	}
+
+	if len(outdated) > 0 && allReady {
+		pod := outdated[len(outdated)-1]
+		log.FromContext(ctx).Info("recreating member with outdated spec", "pod_name", pod.Name)
+		return deleteOwnedResource(ctx, rclient, pod)
+	}
+	return nil
+}
+
+// createMember creates the member pod and its data and write-ahead log volume claims, if the claims do not exist yet.
+func createMember(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+	template corev1.PodTemplateSpec,
+	hash string,
+	name string,
+) error {
+	if cluster.Spec.Storage.HasVolumeClaims() {
+		claim := generateMemberVolumeClaim(cluster, name)
+		if err := rclient.Create(ctx, &claim); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("cannot create member PVC %s: %w", claim.Name, err)
+		}
+	}
+	if hasWALVolumeClaim(cluster) {
+		claim := generateMemberWALVolumeClaim(cluster, name)
+		if err := rclient.Create(ctx, &claim); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("cannot create member PVC %s: %w", claim.Name, err)
+		}
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   cluster.Namespace,
+			Name:        name,
+			Labels:      maps.Clone(template.Labels),
+			Annotations: labels.Merge(template.Annotations, map[string]string{MemberSpecHashAnnotation: hash}),
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	// hostname and subdomain make the member resolvable by the name used in its peer URL
+	pod.Spec.Hostname = name
+	pod.Spec.Subdomain = GetHeadlessServiceName(cluster)
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].PersistentVolumeClaim == nil {
+			continue
+		}
+		switch pod.Spec.Volumes[i].Name {
+		case "data":
+			pod.Spec.Volumes[i].PersistentVolumeClaim.ClaimName = GetMemberPVCName(cluster, name)
+		case GetWALPVCName(cluster):
+			pod.Spec.Volumes[i].PersistentVolumeClaim.ClaimName = GetMemberWALPVCName(cluster, name)
+		}
+	}
+	if err := ctrl.SetControllerReference(cluster, pod, rclient.Scheme()); err != nil {
+		return fmt.Errorf("cannot set controller reference: %w", err)
+	}
+	log.FromContext(ctx).V(2).Info("creating member pod", "pod_name", name)
+	if err := rclient.Create(ctx, pod); client.IgnoreAlreadyExists(err) != nil {
+		return fmt.Errorf("cannot create member pod %s: %w", name, err)
+	}
+	return nil
+}
+
+// CreateMemberVolumeClaims creates data volume claims of members listed in spec.storage.members pre-bound
+// to their volumes or nodes, so StatefulSet uses them instead of creating claims from the template.
+// Existing claims are not changed. Members pinned to a node or volume which is not available anymore
+// are skipped, so their claims are created from the template and the members are recreated elsewhere.
+func CreateMemberVolumeClaims(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+	for _, member := range cluster.Spec.Storage.Members {
+		if member.Ordinal >= ptr.Deref(cluster.Spec.Replicas, 0) {
+			continue
+		}
+		claim := generateMemberVolumeClaim(cluster, GetMemberPodName(cluster, int(member.Ordinal)))
+		err := rclient.Get(ctx, client.ObjectKeyFromObject(&claim), &corev1.PersistentVolumeClaim{})
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("cannot get member PVC %s: %w", claim.Name, err)
+		}
+		available, err := isMemberVolumeAvailable(ctx, rclient, claim.Name, member)
+		if err != nil {
+			return err
+		}
+		if !available {
+			logger.Info("member volume is pinned to missing node or volume, claim is created from template",
+				"pvc_name", claim.Name)
+			continue
+		}
+
+		claim.Spec.VolumeName = member.VolumeName
+		if member.Selector != nil {
+			claim.Spec.Selector = member.Selector
+		}
+		if member.NodeName != "" {
+			claim.Annotations = labels.Merge(claim.Annotations, map[string]string{selectedNodeAnnotation: member.NodeName})
+		}
+		logger.V(2).Info("creating pre-bound member PVC", "pvc_name", claim.Name)
+		if err := rclient.Create(ctx, &claim); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("cannot create member PVC %s: %w", claim.Name, err)
+		}
+	}
+	return nil
+}
+
+// isMemberVolumeAvailable checks that pinned node exists and pinned volume is not bound to another claim.
+func isMemberVolumeAvailable(
+	ctx context.Context,
+	rclient client.Client,
+	claimName string,
+	member etcdaenixiov1alpha1.MemberVolume,
+) (bool, error) {
+	if member.NodeName != "" {
+		err := rclient.Get(ctx, client.ObjectKey{Name: member.NodeName}, &corev1.Node{})
+		if err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+	}
+	if member.VolumeName != "" {
+		volume := &corev1.PersistentVolume{}
+		err := rclient.Get(ctx, client.ObjectKey{Name: member.VolumeName}, volume)
+		if err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		if ref := volume.Spec.ClaimRef; ref != nil && ref.Name != claimName {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// generateMemberVolumeClaim returns data volume claim of the member named as StatefulSet would name it.
+func generateMemberVolumeClaim(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) corev1.PersistentVolumeClaim {
+	claim := generateVolumeClaim(cluster)
+	claim.Name = GetMemberPVCName(cluster, podName)
+	claim.Namespace = cluster.Namespace
+	claim.Labels = labels.Merge(claim.Labels, map[string]string(NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()))
+	claim.Status = corev1.PersistentVolumeClaimStatus{}
+	return claim
+}
+
+func hashPodTemplate(template corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash pod template: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func isMemberPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
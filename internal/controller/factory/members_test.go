@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("CreateOrUpdateMemberPods handler", func() {
+	var (
+		ns          *corev1.Namespace
+		etcdcluster etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	listPods := func(ctx SpecContext) []corev1.Pod {
+		pods := &corev1.PodList{}
+		Expect(k8sClient.List(ctx, pods, client.InNamespace(ns.Name))).To(Succeed())
+		return pods.Items
+	}
+
+	markReady := func(ctx SpecContext) {
+		for _, pod := range listPods(ctx) {
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		etcdcluster = etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-etcdcluster-",
+				Namespace:    ns.GetName(),
+				UID:          types.UID(uuid.NewString()),
+			},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas:         ptr.To(int32(3)),
+				MemberManagement: etcdaenixiov1alpha1.MemberManagementPods,
+				Storage: etcdaenixiov1alpha1.StorageSpec{
+					VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+							Resources: corev1.VolumeResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+							},
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &etcdcluster)).Should(Succeed())
+		Eventually(Get(&etcdcluster)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, &etcdcluster)
+	})
+
+	It("should create member pods resolvable by their peer names and their PVCs", func(ctx SpecContext) {
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+
+		pods := listPods(ctx)
+		Expect(pods).To(HaveLen(3))
+		for _, pod := range pods {
+			Expect(pod.Spec.Hostname).To(Equal(pod.Name))
+			Expect(pod.Spec.Subdomain).To(Equal(GetHeadlessServiceName(&etcdcluster)))
+			Expect(pod.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName",
+				GetMemberPVCName(&etcdcluster, pod.Name))))
+			Expect(pod.Annotations).To(HaveKey(MemberSpecHashAnnotation))
+		}
+		claim := &corev1.PersistentVolumeClaim{}
+		name := GetMemberPVCName(&etcdcluster, GetMemberPodName(&etcdcluster, 2))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: ns.Name, Name: name}, claim)).To(Succeed())
+	})
+
+	It("should recreate outdated members one at a time while others are ready", func(ctx SpecContext) {
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		etcdcluster.Spec.Options = map[string]string{"log-level": "debug"}
+
+		By("keeping members until all of them are ready")
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		Expect(listPods(ctx)).To(HaveLen(3))
+
+		By("deleting the member with the highest ordinal")
+		markReady(ctx)
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(listPods(ctx)).To(HaveLen(2))
+		}).Should(Succeed())
+		Expect(listPods(ctx)).NotTo(ContainElement(HaveField("Name", GetMemberPodName(&etcdcluster, 2))))
+
+		By("recreating it from the new template")
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		pod := &corev1.Pod{}
+		key := types.NamespacedName{Namespace: ns.Name, Name: GetMemberPodName(&etcdcluster, 2)}
+		Expect(k8sClient.Get(ctx, key, pod)).To(Succeed())
+		Expect(pod.Spec.Containers[0].Args).To(ContainElement("--log-level=debug"))
+	})
+
+	It("should delete members beyond replicas and retain their PVCs", func(ctx SpecContext) {
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		etcdcluster.Spec.Replicas = ptr.To(int32(1))
+		Expect(CreateOrUpdateMemberPods(ctx, &etcdcluster, k8sClient)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(listPods(ctx)).To(ConsistOf(HaveField("Name", GetMemberPodName(&etcdcluster, 0))))
+		}).Should(Succeed())
+
+		claims := &corev1.PersistentVolumeClaimList{}
+		Expect(k8sClient.List(ctx, claims, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(claims.Items).To(HaveLen(3))
+	})
+
+	It("should pre-bind claims of pinned members to available nodes and volumes", func(ctx SpecContext) {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{GenerateName: "node-"}}
+		Expect(k8sClient.Create(ctx, node)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, node)
+		etcdcluster.Spec.Storage.Members = []etcdaenixiov1alpha1.MemberVolume{
+			{Ordinal: 0, NodeName: node.Name},
+			{Ordinal: 1, VolumeName: "missing-local-pv"},
+			{Ordinal: 5, NodeName: node.Name},
+		}
+		Expect(CreateMemberVolumeClaims(ctx, &etcdcluster, k8sClient)).To(Succeed())
+
+		claims := &corev1.PersistentVolumeClaimList{}
+		Expect(k8sClient.List(ctx, claims, client.InNamespace(ns.Name))).To(Succeed())
+		Expect(claims.Items).To(ConsistOf(And(
+			HaveField("Name", GetMemberPVCName(&etcdcluster, GetMemberPodName(&etcdcluster, 0))),
+			HaveField("Annotations", HaveKeyWithValue(selectedNodeAnnotation, node.Name)),
+		)))
+	})
+})
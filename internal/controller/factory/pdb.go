@@ -35,7 +35,7 @@ func CreateOrUpdatePdb(
 	cluster *etcdaenixiov1alpha1.EtcdCluster,
 	rclient client.Client,
 ) error {
-	if cluster.Spec.PodDisruptionBudgetTemplate == nil {
+	if cluster.Spec.PodDisruptionBudgetTemplate == nil || cluster.IsStandalone() {
 		return deleteOwnedResource(ctx, rclient, &v1.PodDisruptionBudget{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: cluster.Namespace,
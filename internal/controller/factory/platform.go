@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// IsNodeCompatible returns true if the node runs Linux on one of the architectures of the etcd image,
+// as reported by its well-known labels.
+func IsNodeCompatible(cluster *etcdaenixiov1alpha1.EtcdCluster, node *corev1.Node) bool {
+	return node.Labels[corev1.LabelOSStable] == "linux" &&
+		slices.Contains(cluster.GetArchitectures(), node.Labels[corev1.LabelArchStable])
+}
+
+// addPlatformAffinity requires nodes running Linux on one of the architectures of the etcd image.
+// Requirements are added to every node selector term of the pod, since terms are ORed.
+func addPlatformAffinity(cluster *etcdaenixiov1alpha1.EtcdCluster, podSpec *corev1.PodSpec) {
+	requirements := []corev1.NodeSelectorRequirement{
+		{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"linux"}},
+		{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: cluster.GetArchitectures()},
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for i := range selector.NodeSelectorTerms {
+		term := &selector.NodeSelectorTerms[i]
+		term.MatchExpressions = append(slices.Clone(term.MatchExpressions), requirements...)
+	}
+}
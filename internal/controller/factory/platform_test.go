@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Platform affinity", func() {
+	It("should require linux nodes of image architectures in every node selector term", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		cluster.Spec.PodTemplate.Architectures = []string{"amd64"}
+		zoneTerm := func(zone string) corev1.NodeSelectorTerm {
+			return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+				{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
+			}}
+		}
+		podSpec := &corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{zoneTerm("a"), zoneTerm("b")},
+			},
+		}}}
+
+		addPlatformAffinity(cluster, podSpec)
+		terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		for _, term := range terms {
+			Expect(term.MatchExpressions).To(HaveLen(3))
+			Expect(term.MatchExpressions).To(ContainElements(
+				corev1.NodeSelectorRequirement{Key: corev1.LabelOSStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"linux"}},
+				corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"}},
+			))
+		}
+
+		podSpec = &corev1.PodSpec{}
+		addPlatformAffinity(cluster, podSpec)
+		Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(HaveLen(1))
+	})
+
+	It("should check nodes by their well-known labels", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		node := func(os, arch string) *corev1.Node {
+			return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				corev1.LabelOSStable:   os,
+				corev1.LabelArchStable: arch,
+			}}}
+		}
+		Expect(IsNodeCompatible(cluster, node("linux", "arm64"))).To(BeTrue())
+		Expect(IsNodeCompatible(cluster, node("windows", "amd64"))).To(BeFalse())
+		Expect(IsNodeCompatible(cluster, node("linux", "riscv64"))).To(BeFalse())
+	})
+})
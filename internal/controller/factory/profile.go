@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"fmt"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// timingProfile holds defaults of a profile. Zero values keep defaults of etcd and Kubernetes.
+type timingProfile struct {
+	// heartbeatInterval and electionTimeout are in milliseconds
+	heartbeatInterval int
+	electionTimeout   int
+	snapshotCount     int64
+	// probePeriod and probeFailureThreshold apply to liveness and readiness probes
+	probePeriod           int32
+	probeFailureThreshold int32
+	// startupFailureThreshold bounds the time a member may spend replaying its WAL on start
+	startupFailureThreshold int32
+}
+
+var timingProfiles = map[etcdaenixiov1alpha1.Profile]timingProfile{
+	etcdaenixiov1alpha1.ProfileFast: {
+		heartbeatInterval: 50,
+		electionTimeout:   500,
+		snapshotCount:     5000,
+		probePeriod:       2,
+	},
+	etcdaenixiov1alpha1.ProfileStandard: {
+		snapshotCount: defaultSnapshotCount,
+		probePeriod:   5,
+	},
+	etcdaenixiov1alpha1.ProfileSlowStorage: {
+		heartbeatInterval:       250,
+		electionTimeout:         2500,
+		snapshotCount:           25000,
+		probePeriod:             10,
+		probeFailureThreshold:   6,
+		startupFailureThreshold: 60,
+	},
+}
+
+// standaloneTimingProfile makes the only member of standalone clusters elect itself and report ready quickly.
+var standaloneTimingProfile = timingProfile{
+	heartbeatInterval:       50,
+	electionTimeout:         500,
+	snapshotCount:           defaultSnapshotCount,
+	probePeriod:             1,
+	probeFailureThreshold:   5,
+	startupFailureThreshold: 60,
+}
+
+// getTimingProfile returns defaults of the cluster profile, standalone clusters ignore the profile.
+func getTimingProfile(cluster *etcdaenixiov1alpha1.EtcdCluster) timingProfile {
+	if cluster.IsStandalone() {
+		return standaloneTimingProfile
+	}
+	if profile, ok := timingProfiles[cluster.GetProfile()]; ok {
+		return profile
+	}
+	return timingProfiles[etcdaenixiov1alpha1.ProfileStandard]
+}
+
+// generateProfileArgs passes heartbeat interval and election timeout of the profile to etcd. They are passed
+// only if neither is set in spec.options, as etcd requires the election timeout to exceed heartbeats.
+func generateProfileArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	profile := getTimingProfile(cluster)
+	if profile.heartbeatInterval == 0 {
+		return nil
+	}
+	_, heartbeatSet := cluster.Spec.Options["heartbeat-interval"]
+	_, electionSet := cluster.Spec.Options["election-timeout"]
+	if heartbeatSet || electionSet {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("--heartbeat-interval=%d", profile.heartbeatInterval),
+		fmt.Sprintf("--election-timeout=%d", profile.electionTimeout),
+	}
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package factory
 
-import etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
 
 func GetPVCName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
 	if len(cluster.Spec.Storage.VolumeClaimTemplate.Name) > 0 {
@@ -25,3 +29,16 @@ func GetPVCName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
 	//nolint:goconst
 	return "data"
 }
+
+// GetMemberVolumeClaims returns volume claims of the member as the spec requires them: the data claim and
+// the write-ahead log claim, if the log is kept on a claimed volume of its own.
+func GetMemberVolumeClaims(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) []corev1.PersistentVolumeClaim {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	claims := []corev1.PersistentVolumeClaim{generateMemberVolumeClaim(cluster, podName)}
+	if hasWALVolumeClaim(cluster) {
+		claims = append(claims, generateMemberWALVolumeClaim(cluster, podName))
+	}
+	return claims
+}
@@ -20,69 +20,66 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/url"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/images"
 	"github.com/aenix-io/etcd-operator/internal/k8sutils"
 )
 
 const (
 	etcdContainerName                = "etcd"
 	defaultBackendQuotaBytesFraction = 0.95
+	defaultSnapshotCount             = 10000
+	minDefaultSnapshotCount          = 1000
+	defaultMetricsURLs               = "http://0.0.0.0:2381"
+	defaultTracingServiceName        = "etcd"
+	etcdctlProbeCertPath             = "/etc/etcd/pki/client/cert"
+	// memoryLimitQuotaFraction is the part of etcd memory limit used as default backend quota
+	memoryLimitQuotaFraction = 0.5
+	// memoryPerRaftEntry is the memory reserved for each raft entry kept between snapshots
+	memoryPerRaftEntry = 64 << 10
 )
 
+// MemberHealthyCondition is the pod readiness gate set by the operator once the member is healthy according to
+// the etcd API, added to member pods of clusters with spec.availabilityPolicy.memberReadinessGate.
+const MemberHealthyCondition corev1.PodConditionType = "etcd.aenix.io/member-healthy"
+
 func CreateOrUpdateStatefulSet(
 	ctx context.Context,
 	cluster *etcdaenixiov1alpha1.EtcdCluster,
 	rclient client.Client,
 ) error {
-	podMetadata := metav1.ObjectMeta{
-		Labels: NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+	podTemplate, err := generatePodTemplate(ctx, cluster)
+	if err != nil {
+		return err
 	}
-
-	if cluster.Spec.PodTemplate.Labels != nil {
-		for key, value := range cluster.Spec.PodTemplate.Labels {
-			podMetadata.Labels[key] = value
-		}
+	if err := withTLSChecksum(ctx, cluster, rclient, &podTemplate); err != nil {
+		return err
 	}
-
-	if cluster.Spec.PodTemplate.Annotations != nil {
-		podMetadata.Annotations = cluster.Spec.PodTemplate.Annotations
+	if err := withJWTKeyChecksum(ctx, cluster, rclient, &podTemplate); err != nil {
+		return err
 	}
-
-	volumeClaimTemplates := []corev1.PersistentVolumeClaim{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:        GetPVCName(cluster),
-				Labels:      cluster.Spec.Storage.VolumeClaimTemplate.Labels,
-				Annotations: cluster.Spec.Storage.VolumeClaimTemplate.Annotations,
-			},
-			Spec:   cluster.Spec.Storage.VolumeClaimTemplate.Spec,
-			Status: cluster.Spec.Storage.VolumeClaimTemplate.Status,
-		},
+	var volumeClaimTemplates []corev1.PersistentVolumeClaim
+	if cluster.Spec.Storage.HasVolumeClaims() {
+		volumeClaimTemplates = append(volumeClaimTemplates, generateVolumeClaim(cluster))
 	}
-
-	volumes := generateVolumes(cluster)
-
-	basePodSpec := corev1.PodSpec{
-		Containers: []corev1.Container{generateContainer(cluster)},
-		Volumes:    volumes,
-	}
-	if cluster.Spec.PodTemplate.Spec.Containers == nil {
-		cluster.Spec.PodTemplate.Spec.Containers = make([]corev1.Container, 0)
-	}
-	finalPodSpec, err := k8sutils.StrategicMerge(basePodSpec, cluster.Spec.PodTemplate.Spec)
-	if err != nil {
-		return fmt.Errorf("cannot strategic-merge base podspec with podTemplate.spec: %w", err)
+	if hasWALVolumeClaim(cluster) {
+		volumeClaimTemplates = append(volumeClaimTemplates, generateWALVolumeClaim(cluster))
 	}
 
 	statefulSet := &appsv1.StatefulSet{
@@ -98,11 +95,10 @@ func CreateOrUpdateStatefulSet(
 			Selector: &metav1.LabelSelector{
 				MatchLabels: NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: podMetadata,
-				Spec:       finalPodSpec,
-			},
+			Template:             podTemplate,
 			VolumeClaimTemplates: volumeClaimTemplates,
+			// the operator enforces the policy as well, where statefulsets do not support it
+			PersistentVolumeClaimRetentionPolicy: cluster.Spec.Storage.RetentionPolicy,
 		},
 	}
 	logger := log.FromContext(ctx)
@@ -112,9 +108,138 @@ func CreateOrUpdateStatefulSet(
 		return fmt.Errorf("cannot set controller reference: %w", err)
 	}
 
+	existing := &appsv1.StatefulSet{}
+	err = rclient.Get(ctx, client.ObjectKeyFromObject(statefulSet), existing)
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot get statefulset: %w", err)
+	}
+	if err == nil {
+		if !existing.DeletionTimestamp.IsZero() {
+			logger.V(2).Info("waiting for statefulset to be deleted", "sts_name", existing.Name)
+			return nil
+		}
+		if !slices.Equal(getClaimNames(existing.Spec.VolumeClaimTemplates), getClaimNames(volumeClaimTemplates)) ||
+			isClaimStorageChanged(existing.Spec.VolumeClaimTemplates, volumeClaimTemplates) ||
+			existing.Spec.ServiceName != statefulSet.Spec.ServiceName {
+			// volume claim templates and the service name are immutable, so the statefulset is recreated.
+			// Its pods are orphaned, adopted by the new statefulset and rolled one at a time. Existing claims
+			// are kept by statefulsets, they are expanded by the cluster controller.
+			logger.Info("recreating statefulset to change immutable fields", "sts_name", existing.Name)
+			err = rclient.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+			return client.IgnoreNotFound(err)
+		}
+	}
+
 	return reconcileOwnedResource(ctx, rclient, statefulSet)
 }
 
+// getClaimNames returns names of the volume claim templates.
+func getClaimNames(claims []corev1.PersistentVolumeClaim) []string {
+	names := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		names = append(names, claim.Name)
+	}
+	return names
+}
+
+// isClaimStorageChanged returns true if storage requested by any of the volume claim templates differs.
+// Templates are compared by position, their names are expected to be equal.
+func isClaimStorageChanged(existing, desired []corev1.PersistentVolumeClaim) bool {
+	for i := range min(len(existing), len(desired)) {
+		if existing[i].Spec.Resources.Requests.Storage().Cmp(*desired[i].Spec.Resources.Requests.Storage()) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// generatePodTemplate returns pod template of etcd members merged with spec.podTemplate of the cluster.
+func generatePodTemplate(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (corev1.PodTemplateSpec, error) {
+	cluster = withEnabledOptions(ctx, cluster)
+	podMetadata := metav1.ObjectMeta{
+		// user labels can not move members to another cluster
+		Labels: withClusterSelector(cluster, cluster.Spec.PodTemplate.Labels),
+	}
+
+	if cluster.Spec.PodTemplate.Annotations != nil {
+		podMetadata.Annotations = cluster.Spec.PodTemplate.Annotations
+	}
+
+	volumes := generateVolumes(cluster)
+
+	basePodSpec := corev1.PodSpec{
+		Containers: []corev1.Container{generateContainer(cluster)},
+		Volumes:    volumes,
+	}
+	if cluster.Spec.Storage.IsDMCryptEncrypted() {
+		basePodSpec.InitContainers = append(basePodSpec.InitContainers, generateDMCryptContainer(cluster))
+	}
+	if cluster.HasMemberReadinessGate() {
+		basePodSpec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: MemberHealthyCondition}}
+	}
+	if hasWALVolumeClaim(cluster) {
+		basePodSpec.InitContainers = append(basePodSpec.InitContainers, generateWALMigrationContainer(cluster))
+	}
+	if cluster.Spec.Storage.VolumePermissions != nil {
+		basePodSpec.SecurityContext = generatePodSecurityContext(cluster)
+		if cluster.Spec.Storage.VolumePermissions.GetMode() == etcdaenixiov1alpha1.VolumePermissionsInitContainer {
+			basePodSpec.InitContainers = append(basePodSpec.InitContainers, generateVolumePermissionsContainer(cluster))
+		}
+	}
+	if IsLogShipperEnabled(cluster) {
+		basePodSpec.InitContainers = append(basePodSpec.InitContainers, generateLogShipperContainer(cluster))
+	}
+	if cluster.Spec.PodTemplate.Spec.Containers == nil {
+		cluster.Spec.PodTemplate.Spec.Containers = make([]corev1.Container, 0)
+	}
+	finalPodSpec, err := k8sutils.StrategicMerge(basePodSpec, cluster.Spec.PodTemplate.Spec)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, fmt.Errorf("cannot strategic-merge base podspec with podTemplate.spec: %w", err)
+	}
+	// pods in host network can't resolve peer names of the headless service with default dns policy
+	if finalPodSpec.HostNetwork && finalPodSpec.DNSPolicy == "" {
+		finalPodSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+	// keep members off nodes the etcd image does not run on, e.g. Windows nodes of mixed clusters
+	addPlatformAffinity(cluster, &finalPodSpec)
+	// mirrors are applied after merge to cover images of podTemplate containers as well
+	rewriteImages(images.MirrorsFromContext(ctx), &finalPodSpec)
+
+	return corev1.PodTemplateSpec{ObjectMeta: podMetadata, Spec: finalPodSpec}, nil
+}
+
+// generateVolumeClaim returns claim of the data volume, StatefulSet creates it for each member.
+func generateVolumeClaim(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.PersistentVolumeClaim {
+	claim := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetPVCName(cluster),
+			Labels:      cluster.Spec.Storage.VolumeClaimTemplate.Labels,
+			Annotations: cluster.Spec.Storage.VolumeClaimTemplate.Annotations,
+		},
+		Spec:   cluster.Spec.Storage.VolumeClaimTemplate.Spec,
+		Status: cluster.Spec.Storage.VolumeClaimTemplate.Status,
+	}
+	if encryption := cluster.Spec.Storage.Encryption; encryption != nil {
+		if encryption.StorageClassName != "" {
+			claim.Spec.StorageClassName = ptr.To(encryption.StorageClassName)
+		}
+		if encryption.DMCrypt != nil {
+			claim.Spec.VolumeMode = ptr.To(corev1.PersistentVolumeBlock)
+		}
+	}
+	return claim
+}
+
+// rewriteImages replaces registries of all container images with operator configured mirrors.
+func rewriteImages(mirrors images.Mirrors, podSpec *corev1.PodSpec) {
+	for i := range podSpec.InitContainers {
+		podSpec.InitContainers[i].Image = mirrors.Rewrite(podSpec.InitContainers[i].Image)
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].Image = mirrors.Rewrite(podSpec.Containers[i].Image)
+	}
+}
+
 func generateVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
 	volumes := []corev1.Volume{}
 
@@ -122,6 +247,11 @@ func generateVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
 
 	if cluster.Spec.Storage.EmptyDir != nil {
 		dataVolumeSource = corev1.VolumeSource{EmptyDir: cluster.Spec.Storage.EmptyDir}
+	} else if cluster.Spec.Storage.HostPath != nil {
+		dataVolumeSource = corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{
+			Path: cluster.GetHostPath(),
+			Type: ptr.To(corev1.HostPathDirectoryOrCreate),
+		}}
 	} else {
 		dataVolumeSource = corev1.VolumeSource{
 			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
@@ -139,6 +269,18 @@ func generateVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
 		},
 	)
 
+	if cluster.Spec.Storage.IsDMCryptEncrypted() {
+		volumes = append(volumes, generateDMCryptVolumes(cluster)...)
+	}
+
+	if cluster.Spec.Storage.WALVolume != nil {
+		volumes = append(volumes, generateWALVolume(cluster))
+	}
+
+	if IsLogShipperEnabled(cluster) {
+		volumes = append(volumes, generateLogShipperVolumes(cluster)...)
+	}
+
 	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.PeerSecret != "" {
 		volumes = append(volumes,
 			[]corev1.Volume{
@@ -189,6 +331,42 @@ func generateVolumes(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Volume {
 			}...)
 	}
 
+	if usesEtcdctlProbes(cluster) {
+		volumes = append(volumes, corev1.Volume{
+			Name: "client-certificate",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: cluster.Spec.Security.TLS.ClientSecret,
+				},
+			},
+		})
+	}
+
+	if cluster.UsesJWT() {
+		volumes = append(volumes, corev1.Volume{
+			Name: jwtKeyVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: GetJWTKeySecretName(cluster),
+				},
+			},
+		})
+	}
+
+	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ClientCRLSecret != "" {
+		volumes = append(volumes,
+			[]corev1.Volume{
+				{
+					Name: "client-crl",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: cluster.Spec.Security.TLS.ClientCRLSecret,
+						},
+					},
+				},
+			}...)
+	}
+
 	return volumes
 
 }
@@ -197,11 +375,30 @@ func generateVolumeMounts(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Vol
 
 	volumeMounts := []corev1.VolumeMount{}
 
-	volumeMounts = append(volumeMounts, corev1.VolumeMount{
-		Name:      "data",
+	dataVolumeMount := corev1.VolumeMount{
+		Name:      getDataVolumeName(cluster),
 		ReadOnly:  false,
-		MountPath: "/var/run/etcd",
-	})
+		MountPath: cluster.Spec.Storage.GetMountPath(),
+		SubPath:   cluster.Spec.Storage.SubPath,
+	}
+	if cluster.Spec.Storage.HostPath != nil {
+		// members share the node directory, each of them keeps data in its own subdirectory
+		dataVolumeMount.SubPath = ""
+		dataVolumeMount.SubPathExpr = path.Join("$(POD_NAME)", cluster.Spec.Storage.SubPath)
+	}
+	if cluster.Spec.Storage.IsDMCryptEncrypted() {
+		// decrypted volume is mounted by the sidecar after the emptyDir is mounted into etcd container
+		dataVolumeMount.MountPropagation = ptr.To(corev1.MountPropagationHostToContainer)
+	}
+	volumeMounts = append(volumeMounts, dataVolumeMount)
+
+	if cluster.Spec.Storage.WALVolume != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: GetWALPVCName(cluster), MountPath: walMountPath})
+	}
+
+	if IsLogShipperEnabled(cluster) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: etcdLogsVolumeName, MountPath: etcdLogsPath})
+	}
 
 	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.PeerSecret != "" {
 		volumeMounts = append(volumeMounts, []corev1.VolumeMount{
@@ -239,6 +436,32 @@ func generateVolumeMounts(cluster *etcdaenixiov1alpha1.EtcdCluster) []corev1.Vol
 		}...)
 	}
 
+	if usesEtcdctlProbes(cluster) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "client-certificate",
+			ReadOnly:  true,
+			MountPath: etcdctlProbeCertPath,
+		})
+	}
+
+	if cluster.UsesJWT() {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      jwtKeyVolumeName,
+			ReadOnly:  true,
+			MountPath: jwtKeyMountPath,
+		})
+	}
+
+	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ClientCRLSecret != "" {
+		volumeMounts = append(volumeMounts, []corev1.VolumeMount{
+			{
+				Name:      "client-crl",
+				ReadOnly:  true,
+				MountPath: "/etc/etcd/pki/client/crl",
+			},
+		}...)
+	}
+
 	return volumeMounts
 }
 
@@ -262,6 +485,13 @@ func generateEtcdArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
 		}
 		quota := float64(size.Value()) * defaultBackendQuotaBytesFraction
 		quota = math.Floor(quota)
+		// database is memory mapped, so it must fit into the memory limit with room for the rest of etcd
+		if limit := getEtcdMemoryLimit(cluster); limit != nil {
+			limitQuota := math.Floor(float64(limit.Value()) * memoryLimitQuotaFraction)
+			if quota <= 0 || limitQuota < quota {
+				quota = limitQuota
+			}
+		}
 		if quota > 0 {
 			if cluster.Spec.Options == nil {
 				cluster.Spec.Options = make(map[string]string, 1)
@@ -270,7 +500,15 @@ func generateEtcdArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
 		}
 	}
 
-	for name, value := range cluster.Spec.Options {
+	// options are rendered for etcd version of the cluster in stable order, so pods are not restarted needlessly
+	options := cluster.EtcdOptions()
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := options[name]
 		flag := "--" + name
 		if len(value) == 0 {
 			args = append(args, flag)
@@ -291,6 +529,15 @@ func generateEtcdArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
 			"--peer-client-cert-auth",
 		}
 	}
+	if cluster.UsesInternalCA() && cluster.Spec.Security.TLS.PeerSecret != "" {
+		// every member has its own peer certificate in the secret issued by the internal CA
+		peerTlsSettings = []string{
+			"--peer-trusted-ca-file=/etc/etcd/pki/peer/ca/ca.crt",
+			"--peer-cert-file=/etc/etcd/pki/peer/cert/" + GetMemberCertificateKey("$(POD_NAME)"),
+			"--peer-key-file=/etc/etcd/pki/peer/cert/" + GetMemberPrivateKeyKey("$(POD_NAME)"),
+			"--peer-client-cert-auth",
+		}
+	}
 
 	serverTlsSettings := []string{}
 	serverProtocol := "http"
@@ -312,29 +559,151 @@ func generateEtcdArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
 		}
 	}
 
+	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ClientCRLSecret != "" {
+		clientTlsSettings = append(clientTlsSettings, "--client-crl-file=/etc/etcd/pki/client/crl/ca.crl")
+	}
+
 	autoCompactionSettings := []string{
 		"--auto-compaction-retention=5m",
-		"--snapshot-count=10000",
 	}
 
+	args = append(args, "--name=$(POD_NAME)")
+	// metrics listeners set in options are already passed
+	if _, ok := cluster.Spec.Options["listen-metrics-urls"]; !ok {
+		args = append(args, "--listen-metrics-urls="+defaultMetricsURLs)
+	}
 	args = append(args, []string{
-		"--name=$(POD_NAME)",
-		"--listen-metrics-urls=http://0.0.0.0:2381",
 		"--listen-peer-urls=https://0.0.0.0:2380",
 		fmt.Sprintf("--listen-client-urls=%s://0.0.0.0:2379", serverProtocol),
 		fmt.Sprintf("--initial-advertise-peer-urls=https://$(POD_NAME).%s.$(POD_NAMESPACE).svc:2380", GetHeadlessServiceName(cluster)),
-		"--data-dir=/var/run/etcd/default.etcd",
+		"--data-dir=" + cluster.Spec.Storage.GetDataDirPath(),
 		fmt.Sprintf("--advertise-client-urls=%s://$(POD_NAME).%s.$(POD_NAMESPACE).svc:2379", serverProtocol, GetHeadlessServiceName(cluster)),
 	}...)
+	if cluster.Spec.Storage.WALVolume != nil {
+		args = append(args, "--wal-dir="+walDir)
+	}
+	if cluster.UsesDiscoverySRV() {
+		args = append(args, "--discovery-srv="+GetDiscoverySRVDomain(cluster))
+	}
 
 	args = append(args, peerTlsSettings...)
 	args = append(args, serverTlsSettings...)
 	args = append(args, clientTlsSettings...)
 	args = append(args, autoCompactionSettings...)
+	args = append(args, generateProfileArgs(cluster)...)
+	args = append(args, generateRaftSnapshotArgs(cluster)...)
+	args = append(args, generateRequestLimitArgs(cluster)...)
+	args = append(args, generateAuthTokenArgs(cluster)...)
+	args = append(args, generateTracingArgs(cluster)...)
+	args = append(args, generateLogShipperArgs(cluster)...)
+
+	return args
+}
+
+// generateRaftSnapshotArgs passes spec.raftSnapshots to etcd, flags set explicitly in spec.options take precedence.
+func generateRaftSnapshotArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	snapshotCount := getDefaultSnapshotCount(cluster)
+	var maxSnapshots, maxWALs *int32
+	if spec := cluster.Spec.RaftSnapshots; spec != nil {
+		if spec.SnapshotCount != nil {
+			snapshotCount = *spec.SnapshotCount
+		}
+		maxSnapshots = spec.MaxSnapshots
+		maxWALs = spec.MaxWALs
+	}
+
+	args := []string{}
+	if _, ok := cluster.Spec.Options["snapshot-count"]; !ok {
+		args = append(args, fmt.Sprintf("--snapshot-count=%d", snapshotCount))
+	}
+	if _, ok := cluster.Spec.Options["max-snapshots"]; !ok && maxSnapshots != nil {
+		args = append(args, fmt.Sprintf("--max-snapshots=%d", *maxSnapshots))
+	}
+	if _, ok := cluster.Spec.Options["max-wals"]; !ok && maxWALs != nil {
+		args = append(args, fmt.Sprintf("--max-wals=%d", *maxWALs))
+	}
+	return args
+}
+
+// generateRequestLimitArgs passes spec.requestLimits to etcd, flags set explicitly in spec.options take precedence.
+func generateRequestLimitArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	spec := cluster.Spec.RequestLimits
+	if spec == nil {
+		return nil
+	}
+	args := []string{}
+	if _, ok := cluster.Spec.Options["max-txn-ops"]; !ok && spec.MaxTxnOps != nil {
+		args = append(args, fmt.Sprintf("--max-txn-ops=%d", *spec.MaxTxnOps))
+	}
+	if _, ok := cluster.Spec.Options["max-request-bytes"]; !ok && spec.MaxRequestBytes != nil {
+		args = append(args, fmt.Sprintf("--max-request-bytes=%d", spec.MaxRequestBytes.Value()))
+	}
+	return args
+}
+
+// generateTracingArgs passes spec.tracing to etcd under flag names of its version,
+// flags set explicitly in spec.options take precedence.
+func generateTracingArgs(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	tracing := cluster.Spec.Tracing
+	if tracing == nil {
+		return nil
+	}
+	serviceName := tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultTracingServiceName
+	}
+	type flagValue struct {
+		name  string
+		value string
+	}
+	flags := []flagValue{
+		{name: "experimental-enable-distributed-tracing", value: "true"},
+		{name: "experimental-distributed-tracing-address", value: tracing.Endpoint},
+		{name: "experimental-distributed-tracing-service-name", value: serviceName},
+		{name: "experimental-distributed-tracing-instance-id", value: "$(POD_NAME)"},
+	}
+	if tracing.SamplingRatePerMillion != nil {
+		flags = append(flags, flagValue{
+			name:  "experimental-distributed-tracing-sampling-rate",
+			value: strconv.Itoa(int(*tracing.SamplingRatePerMillion)),
+		})
+	}
 
+	options := cluster.EtcdOptions()
+	args := []string{}
+	for _, f := range flags {
+		name := cluster.EtcdFlagName(f.name)
+		if _, ok := options[name]; !ok {
+			args = append(args, fmt.Sprintf("--%s=%s", name, f.value))
+		}
+	}
 	return args
 }
 
+// getDefaultSnapshotCount returns snapshot count of the profile keeping raft entries within memory limit
+// of etcd container.
+func getDefaultSnapshotCount(cluster *etcdaenixiov1alpha1.EtcdCluster) int64 {
+	snapshotCount := getTimingProfile(cluster).snapshotCount
+	limit := getEtcdMemoryLimit(cluster)
+	if limit == nil {
+		return snapshotCount
+	}
+	return min(max(limit.Value()/memoryPerRaftEntry, minDefaultSnapshotCount), snapshotCount)
+}
+
+// getEtcdMemoryLimit returns memory limit of etcd container defined in pod template, nil if it is not limited.
+func getEtcdMemoryLimit(cluster *etcdaenixiov1alpha1.EtcdCluster) *resource.Quantity {
+	for _, c := range cluster.Spec.PodTemplate.Spec.Containers {
+		if c.Name != etcdContainerName {
+			continue
+		}
+		if limit, ok := c.Resources.Limits[corev1.ResourceMemory]; ok && !limit.IsZero() {
+			return &limit
+		}
+	}
+	return nil
+}
+
 func generateContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Container {
 	podEnv := []corev1.EnvVar{
 		{
@@ -358,7 +727,7 @@ func generateContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Containe
 	c := corev1.Container{}
 	c.Name = etcdContainerName
 	c.Image = etcdaenixiov1alpha1.DefaultEtcdImage
-	c.Command = generateEtcdCommand()
+	c.Command = append(slices.Clone(cluster.Spec.PodTemplate.CommandPrefix), generateEtcdCommand()...)
 	c.Args = generateEtcdArgs(cluster)
 	c.Ports = []corev1.ContainerPort{
 		{Name: "peer", ContainerPort: 2380},
@@ -374,47 +743,141 @@ func generateContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Containe
 			},
 		},
 	}
-	c.StartupProbe = getStartupProbe()
-	c.LivenessProbe = getLivenessProbe()
-	c.ReadinessProbe = getReadinessProbe()
+	c.StartupProbe = getStartupProbe(cluster)
+	c.LivenessProbe = getLivenessProbe(cluster)
+	c.ReadinessProbe = getReadinessProbe(cluster)
 	c.Env = podEnv
 	c.VolumeMounts = generateVolumeMounts(cluster)
 
 	return c
 }
 
-func getStartupProbe() *corev1.Probe {
+func getStartupProbe(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.Probe {
+	profile := getTimingProfile(cluster)
 	return &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/readyz?serializable=false",
-				Port: intstr.FromInt32(2381),
-			},
-		},
-		PeriodSeconds: 5,
+		ProbeHandler:     getProbeHandler(cluster, "/readyz?serializable=false", "endpoint", "health"),
+		PeriodSeconds:    profile.probePeriod,
+		FailureThreshold: profile.startupFailureThreshold,
 	}
 }
 
-func getReadinessProbe() *corev1.Probe {
+func getReadinessProbe(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.Probe {
+	profile := getTimingProfile(cluster)
 	return &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/readyz",
-				Port: intstr.FromInt32(2381),
-			},
-		},
-		PeriodSeconds: 5,
+		ProbeHandler:     getProbeHandler(cluster, "/readyz", "endpoint", "health"),
+		PeriodSeconds:    profile.probePeriod,
+		FailureThreshold: profile.probeFailureThreshold,
 	}
 }
 
-func getLivenessProbe() *corev1.Probe {
+func getLivenessProbe(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.Probe {
+	profile := getTimingProfile(cluster)
+	// endpoint status does not need quorum, so members are not restarted when it is lost, the same as with /livez
 	return &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
+		ProbeHandler:     getProbeHandler(cluster, "/livez", "endpoint", "status"),
+		PeriodSeconds:    profile.probePeriod,
+		FailureThreshold: profile.probeFailureThreshold,
+	}
+}
+
+// getProbeHandler returns handler requesting the path of the metrics listener. If the metrics are served only over
+// TLS requiring client certificates, which kubelet can not present, etcdctl with the given arguments is executed
+// against the client port instead.
+func getProbeHandler(cluster *etcdaenixiov1alpha1.EtcdCluster, path string, etcdctlArgs ...string) corev1.ProbeHandler {
+	metricsURL := getProbedMetricsURL(cluster)
+	if metricsURL.Scheme == "http" || !isClientCertAuthEnabled(cluster) {
+		handler := corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/livez",
-				Port: intstr.FromInt32(2381),
+				Path: path,
+				Port: intstr.Parse(metricsURL.Port()),
 			},
-		},
-		PeriodSeconds: 5,
+		}
+		if metricsURL.Scheme == "https" {
+			handler.HTTPGet.Scheme = corev1.URISchemeHTTPS
+		}
+		return handler
+	}
+
+	command := []string{
+		"etcdctl",
+		"--endpoints=https://127.0.0.1:2379",
+		// the probe checks the local member, whose certificate does not have to be issued for localhost
+		"--insecure-skip-tls-verify",
+		"--cert=" + etcdctlProbeCertPath + "/tls.crt",
+		"--key=" + etcdctlProbeCertPath + "/tls.key",
 	}
+	return corev1.ProbeHandler{
+		Exec: &corev1.ExecAction{Command: append(command, etcdctlArgs...)},
+	}
+}
+
+// getProbedMetricsURL returns the metrics listener probes are sent to, preferring listeners without TLS.
+func getProbedMetricsURL(cluster *etcdaenixiov1alpha1.EtcdCluster) *url.URL {
+	var probed *url.URL
+	for _, rawURL := range strings.Split(getMetricsURLs(cluster), ",") {
+		metricsURL, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || metricsURL.Port() == "" {
+			continue
+		}
+		if metricsURL.Scheme == "http" {
+			return metricsURL
+		}
+		if probed == nil {
+			probed = metricsURL
+		}
+	}
+	if probed == nil {
+		probed, _ = url.Parse(defaultMetricsURLs)
+	}
+	return probed
+}
+
+// GetMemberMetricsURL returns URL of the metrics of the member served by the listener probes are sent to.
+func GetMemberMetricsURL(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	metricsURL := getProbedMetricsURL(cluster)
+	return fmt.Sprintf("%s://%s.%s.%s.svc:%s/metrics",
+		metricsURL.Scheme, podName, GetHeadlessServiceName(cluster), cluster.Namespace, metricsURL.Port())
+}
+
+// GetEtcdArgs returns arguments etcd members of the cluster are started with.
+func GetEtcdArgs(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	// options are defaulted in place while arguments are generated
+	return generateEtcdArgs(withEnabledOptions(ctx, cluster).DeepCopy())
+}
+
+type experimentalOptionsKey struct{}
+
+// WithExperimentalOptions returns context rendering spec.experimentalOptions of clusters if they are enabled
+// in the operator, see the --enable-experimental-options flag.
+func WithExperimentalOptions(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, experimentalOptionsKey{}, enabled)
+}
+
+// withEnabledOptions returns a copy of the cluster without spec.experimentalOptions unless the context enables
+// them, e.g. options admitted before they were disabled in the operator are not passed to etcd.
+func withEnabledOptions(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) *etcdaenixiov1alpha1.EtcdCluster {
+	if enabled, _ := ctx.Value(experimentalOptionsKey{}).(bool); enabled || len(cluster.Spec.ExperimentalOptions) == 0 {
+		return cluster
+	}
+	cluster = cluster.DeepCopy()
+	cluster.Spec.ExperimentalOptions = nil
+	return cluster
+}
+
+// getMetricsURLs returns metrics listeners of etcd, which may be overridden in spec.options.
+func getMetricsURLs(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	if urls := cluster.Spec.Options["listen-metrics-urls"]; urls != "" {
+		return urls
+	}
+	return defaultMetricsURLs
+}
+
+// isClientCertAuthEnabled returns true if etcd requires client certificates.
+func isClientCertAuthEnabled(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ClientSecret != ""
+}
+
+// usesEtcdctlProbes returns true if probes execute etcdctl, which needs the client certificate mounted.
+func usesEtcdctlProbes(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return getProbeHandler(cluster, "").Exec != nil
 }
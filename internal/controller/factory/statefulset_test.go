@@ -17,6 +17,9 @@ limitations under the License.
 package factory
 
 import (
+	"context"
+	"strings"
+
 	"github.com/google/uuid"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -32,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/images"
 )
 
 var _ = Describe("CreateOrUpdateStatefulSet handler", func() {
@@ -365,14 +369,133 @@ var _ = Describe("CreateOrUpdateStatefulSet handler", func() {
 
 			By("Checking the emptyDir", func() {
 				Expect(statefulSet.Spec.Template.Spec.Volumes[0].VolumeSource.EmptyDir.SizeLimit.String()).To(Equal(size.String()))
+				Expect(statefulSet.Spec.VolumeClaimTemplates).To(BeEmpty())
+				Expect(IsEmptyDirPodTemplate(&statefulSet.Spec.Template)).To(BeTrue())
 			})
 		})
 
+		It("should use host network dns policy when running in host network", func(ctx SpecContext) {
+			etcdcluster.Spec.PodTemplate.Spec = corev1.PodSpec{HostNetwork: true}
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Get(&statefulSet)).Should(Succeed())
+			Expect(statefulSet.Spec.Template.Spec.DNSPolicy).To(Equal(corev1.DNSClusterFirstWithHostNet))
+		})
+
+		It("should wrap etcd command with command prefix", func(ctx SpecContext) {
+			etcdcluster.Spec.PodTemplate.CommandPrefix = []string{"dumb-init", "--"}
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Get(&statefulSet)).Should(Succeed())
+			Expect(statefulSet.Spec.Template.Spec.Containers[0].Command).To(Equal([]string{"dumb-init", "--", "etcd"}))
+		})
+
+		It("should keep user defined dns settings", func(ctx SpecContext) {
+			etcdcluster.Spec.PodTemplate.Spec = corev1.PodSpec{
+				DNSPolicy: corev1.DNSClusterFirst,
+				DNSConfig: &corev1.PodDNSConfig{
+					Options: []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("1")}},
+				},
+			}
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Get(&statefulSet)).Should(Succeed())
+			Expect(statefulSet.Spec.Template.Spec.DNSPolicy).To(Equal(corev1.DNSClusterFirst))
+			Expect(statefulSet.Spec.Template.Spec.DNSConfig).To(Equal(etcdcluster.Spec.PodTemplate.Spec.DNSConfig))
+		})
+
+		It("should rewrite images with operator configured mirrors", func(ctx SpecContext) {
+			digest := "sha256:" + strings.Repeat("ab", 32)
+			etcdcluster.Spec.PodTemplate.Spec = corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "busybox:1.36"}},
+				Containers:     []corev1.Container{{Name: "sidecar", Image: "quay.io/prometheus/node-exporter@" + digest}},
+			}
+			mirrors := images.Mirrors{
+				"quay.io":   "registry.example.com/quay",
+				"docker.io": "registry.example.com/dockerhub",
+			}
+			Expect(CreateOrUpdateStatefulSet(images.WithMirrors(ctx, mirrors), &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Get(&statefulSet)).Should(Succeed())
+			podSpec := statefulSet.Spec.Template.Spec
+			Expect(podSpec.InitContainers[0].Image).To(Equal("registry.example.com/dockerhub/library/busybox:1.36"))
+			Expect(podSpec.Containers).To(ContainElements(
+				HaveField("Image", "registry.example.com/quay/coreos/etcd:v3.5.12"),
+				HaveField("Image", "registry.example.com/quay/prometheus/node-exporter@"+digest),
+			))
+		})
+
+		It("should request encrypted volumes", func(ctx SpecContext) {
+			etcdcluster.Spec.Storage.Encryption = &etcdaenixiov1alpha1.StorageEncryptionSpec{
+				StorageClassName: "encrypted",
+				DMCrypt: &etcdaenixiov1alpha1.DMCryptSpec{
+					KeySecret: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "etcd-passphrase"},
+						Key:                  "passphrase",
+					},
+					Image: "registry.example.com/cryptsetup:2.7",
+				},
+			}
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Get(&statefulSet)).Should(Succeed())
+
+			claim := statefulSet.Spec.VolumeClaimTemplates[0]
+			Expect(claim.Spec.StorageClassName).To(Equal(ptr.To("encrypted")))
+			Expect(claim.Spec.VolumeMode).To(Equal(ptr.To(corev1.PersistentVolumeBlock)))
+			podSpec := statefulSet.Spec.Template.Spec
+			Expect(podSpec.InitContainers).To(ConsistOf(And(
+				HaveField("Name", dmCryptContainerName),
+				HaveField("RestartPolicy", ptr.To(corev1.ContainerRestartPolicyAlways)),
+				HaveField("VolumeDevices", ConsistOf(HaveField("Name", "data"))),
+			)))
+			Expect(podSpec.Containers[0].VolumeMounts).To(ContainElement(And(
+				HaveField("Name", dmCryptDataVolumeName),
+				HaveField("MountPath", "/var/run/etcd"),
+			)))
+			Expect(podSpec.Volumes).To(ContainElement(HaveField("Secret.SecretName", "etcd-passphrase")))
+		})
+
+		It("should restart members when their TLS secrets change", func(ctx SpecContext) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ns.GetName(), Name: "server-tls"},
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			etcdcluster.Spec.Security = &etcdaenixiov1alpha1.SecuritySpec{
+				TLS: etcdaenixiov1alpha1.TLSSpec{ServerSecret: secret.Name},
+			}
+			etcdcluster.Spec.PodTemplate.Annotations = map[string]string{"user": "value"}
+
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Expect(Get(&statefulSet)()).To(Succeed())
+			checksum := statefulSet.Spec.Template.Annotations[TLSChecksumAnnotation]
+			Expect(checksum).NotTo(BeEmpty())
+			Expect(statefulSet.Spec.Template.Annotations).To(HaveKeyWithValue("user", "value"))
+			Expect(etcdcluster.Spec.PodTemplate.Annotations).NotTo(HaveKey(TLSChecksumAnnotation))
+
+			secret.Data[corev1.TLSCertKey] = []byte("renewed")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&statefulSet)).Should(HaveField("Spec.Template.Annotations",
+				HaveKeyWithValue(TLSChecksumAnnotation, Not(Equal(checksum)))))
+		})
+
 		It("should fail on creating the statefulset with invalid owner reference", func(ctx SpecContext) {
 			Expect(CreateOrUpdateStatefulSet(ctx, &etcdcluster, clientWithEmptyScheme)).NotTo(Succeed())
 		})
 	})
 
+	Context("When comparing volume claim templates", func() {
+		It("should detect changed storage requests", func() {
+			claim := func(storage string) corev1.PersistentVolumeClaim {
+				return corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)},
+					},
+				}}
+			}
+			existing := []corev1.PersistentVolumeClaim{claim("4Gi"), claim("1Gi")}
+			Expect(isClaimStorageChanged(existing, []corev1.PersistentVolumeClaim{claim("4096Mi"), claim("1Gi")})).To(BeFalse())
+			Expect(isClaimStorageChanged(existing, []corev1.PersistentVolumeClaim{claim("4Gi"), claim("2Gi")})).To(BeTrue())
+		})
+	})
+
 	Context("When generating a etcd command", func() {
 		It("should correctly fill options to args", func() {
 			extraArgs := map[string]string{
@@ -392,6 +515,18 @@ var _ = Describe("CreateOrUpdateStatefulSet handler", func() {
 				"--key2=value2",
 			}))
 		})
+		It("should look up SRV records of the headless service in the DiscoverySRV bootstrap mode", func() {
+			etcdcluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Bootstrap: etcdaenixiov1alpha1.BootstrapDiscoverySRV,
+				},
+			}
+
+			Expect(generateEtcdArgs(etcdcluster)).To(ContainElement("--discovery-srv=test-headless.ns.svc"))
+			etcdcluster.Spec.Bootstrap = ""
+			Expect(generateEtcdArgs(etcdcluster)).NotTo(ContainElement(HavePrefix("--discovery-srv")))
+		})
 		It("should not override user defined quota-backend-bytes", func() {
 			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
 				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
@@ -444,6 +579,478 @@ var _ = Describe("CreateOrUpdateStatefulSet handler", func() {
 			// 2Gi * 0.95 = 2040109465,6
 			Expect(args).To(ContainElement("--quota-backend-bytes=2040109465"))
 		})
+		It("should limit default quota-backend-bytes and snapshot-count by memory limit", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						EmptyDir: &corev1.EmptyDirVolumeSource{
+							SizeLimit: ptr.To(resource.MustParse("2Gi")),
+						},
+					},
+					PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "etcd",
+								Resources: corev1.ResourceRequirements{
+									Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+								},
+							}},
+						},
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			// 256Mi * 0.5 and 256Mi / 64Ki
+			Expect(args).To(ContainElements("--quota-backend-bytes=134217728", "--snapshot-count=4096"))
+		})
+		It("should keep quota-backend-bytes of storage below memory limit", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						EmptyDir: &corev1.EmptyDirVolumeSource{
+							SizeLimit: ptr.To(resource.MustParse("2Gi")),
+						},
+					},
+					PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "etcd",
+								Resources: corev1.ResourceRequirements{
+									Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+								},
+							}},
+						},
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElements("--quota-backend-bytes=2040109465", "--snapshot-count=10000"))
+		})
+		It("should render options for etcd 3.6", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{
+						"experimental-watch-progress-notify-interval": "5s",
+						"experimental-compact-hash-check-enabled":     "true",
+						"log-level": "debug",
+					},
+					PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "etcd", Image: "gcr.io/etcd-development/etcd:v3.6.0"}},
+						},
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args[:3]).To(Equal([]string{
+				"--feature-gates=CompactHashCheck=true",
+				"--log-level=debug",
+				"--watch-progress-notify-interval=5s",
+			}))
+		})
+		It("should pass client certificate revocation list to etcd", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{
+							ClientTrustedCASecret: "client-ca-secret",
+							ClientSecret:          "client-secret",
+							ClientCRLSecret:       "client-crl-secret",
+						},
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElements("--client-cert-auth", "--client-crl-file=/etc/etcd/pki/client/crl/ca.crl"))
+			Expect(generateVolumes(etcdCluster)).To(ContainElement(corev1.Volume{
+				Name: "client-crl",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "client-crl-secret"},
+				},
+			}))
+			Expect(generateVolumeMounts(etcdCluster)).To(ContainElement(corev1.VolumeMount{
+				Name:      "client-crl",
+				ReadOnly:  true,
+				MountPath: "/etc/etcd/pki/client/crl",
+			}))
+		})
+		It("should pass peer certificates of members issued by the internal CA", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{
+							PeerTrustedCASecret: "test-peer-tls",
+							PeerSecret:          "test-peer-tls",
+							InternalCA:          &etcdaenixiov1alpha1.InternalCASpec{},
+						},
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElements(
+				"--peer-trusted-ca-file=/etc/etcd/pki/peer/ca/ca.crt",
+				"--peer-cert-file=/etc/etcd/pki/peer/cert/$(POD_NAME).crt",
+				"--peer-key-file=/etc/etcd/pki/peer/cert/$(POD_NAME).key",
+				"--peer-client-cert-auth",
+			))
+			Expect(args).NotTo(ContainElement("--peer-auto-tls"))
+		})
+		It("should pass raft snapshot settings to etcd", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					RaftSnapshots: &etcdaenixiov1alpha1.RaftSnapshotsSpec{
+						SnapshotCount: ptr.To(int64(50000)),
+						MaxWALs:       ptr.To(int32(10)),
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElements("--snapshot-count=50000", "--max-wals=10"))
+			Expect(args).NotTo(ContainElement(HavePrefix("--max-snapshots")))
+		})
+		It("should pass experimental options to etcd only if they are enabled", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					ExperimentalOptions: map[string]string{"memory-mlock": "true"},
+				},
+			}
+			Expect(generateEtcdArgs(withEnabledOptions(context.Background(), etcdCluster))).
+				NotTo(ContainElement(HavePrefix("--experimental-memory-mlock")))
+			Expect(generateEtcdArgs(withEnabledOptions(WithExperimentalOptions(context.Background(), true), etcdCluster))).
+				To(ContainElement("--experimental-memory-mlock=true"))
+			Expect(etcdCluster.Spec.ExperimentalOptions).To(HaveLen(1))
+		})
+		It("should pass request limits to etcd", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{"max-txn-ops": "512"},
+					RequestLimits: &etcdaenixiov1alpha1.RequestLimitsSpec{
+						MaxTxnOps:       ptr.To(int32(1024)),
+						MaxRequestBytes: ptr.To(resource.MustParse("4Mi")),
+					},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElement("--max-request-bytes=4194304"))
+			Expect(args).NotTo(ContainElement("--max-txn-ops=1024"))
+		})
+		It("should pass JWT settings to etcd", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Security: &etcdaenixiov1alpha1.SecuritySpec{Auth: &etcdaenixiov1alpha1.AuthSpec{
+						Enabled: true,
+						JWT:     &etcdaenixiov1alpha1.JWTSpec{SignMethod: "ES384"},
+					}},
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElement("--auth-token=jwt," +
+				"pub-key=/etc/etcd/jwt/jwt.pub,priv-key=/etc/etcd/jwt/jwt.key,sign-method=ES384,ttl=5m0s"))
+			Expect(generateVolumes(etcdCluster)).To(ContainElement(HaveField("Secret.SecretName", "test-jwt-key")))
+			Expect(generateVolumeMounts(etcdCluster)).To(ContainElement(HaveField("MountPath", "/etc/etcd/jwt")))
+		})
+		It("should not duplicate snapshot-count set in options", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{"snapshot-count": "20000"},
+				},
+			}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElement("--snapshot-count=20000"))
+			Expect(args).NotTo(ContainElement("--snapshot-count=10000"))
+		})
+		It("should tune timings of standalone clusters for fast startup", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Mode:    etcdaenixiov1alpha1.ClusterModeStandalone,
+					Profile: etcdaenixiov1alpha1.ProfileSlowStorage,
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElements("--heartbeat-interval=50", "--election-timeout=500"))
+			Expect(getStartupProbe(etcdCluster).PeriodSeconds).To(Equal(int32(1)))
+			Expect(getReadinessProbe(etcdCluster).FailureThreshold).To(Equal(int32(5)))
+		})
+		It("should apply timings of the profile", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{}
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElement("--snapshot-count=10000"))
+			Expect(args).NotTo(ContainElement(HavePrefix("--heartbeat-interval")))
+			Expect(getLivenessProbe(etcdCluster).PeriodSeconds).To(Equal(int32(5)))
+
+			etcdCluster.Spec.Profile = etcdaenixiov1alpha1.ProfileSlowStorage
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElements(
+				"--heartbeat-interval=250",
+				"--election-timeout=2500",
+				"--snapshot-count=25000",
+			))
+			Expect(getReadinessProbe(etcdCluster).FailureThreshold).To(Equal(int32(6)))
+			Expect(getStartupProbe(etcdCluster).FailureThreshold).To(Equal(int32(60)))
+
+			etcdCluster.Spec.Profile = etcdaenixiov1alpha1.ProfileFast
+			etcdCluster.Spec.Options = map[string]string{"election-timeout": "1000"}
+			args = generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElements("--election-timeout=1000", "--snapshot-count=5000"))
+			Expect(args).NotTo(ContainElement(HavePrefix("--heartbeat-interval")))
+			Expect(getLivenessProbe(etcdCluster).PeriodSeconds).To(Equal(int32(2)))
+		})
+		It("should mount data dir of existing deployments", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{MountPath: "/bitnami/etcd", SubPath: "data", DataDir: "."},
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElement("--data-dir=/bitnami/etcd"))
+			Expect(generateVolumeMounts(etcdCluster)[0]).To(Equal(corev1.VolumeMount{
+				Name:      "data",
+				MountPath: "/bitnami/etcd",
+				SubPath:   "data",
+			}))
+		})
+		It("should keep data of members in subdirectories of the host path", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "edge"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						HostPath: &etcdaenixiov1alpha1.HostPathStorageSpec{Path: "/data/$(NAMESPACE)-$(CLUSTER)"},
+					},
+				},
+			}
+			Expect(generateVolumes(etcdCluster)[0].HostPath).To(Equal(&corev1.HostPathVolumeSource{
+				Path: "/data/edge-test",
+				Type: ptr.To(corev1.HostPathDirectoryOrCreate),
+			}))
+			Expect(generateVolumeMounts(etcdCluster)[0]).To(Equal(corev1.VolumeMount{
+				Name:        "data",
+				MountPath:   etcdaenixiov1alpha1.DefaultDataMountPath,
+				SubPathExpr: "$(POD_NAME)",
+			}))
+			Expect(GetMemberVolumeClaims(etcdCluster, "test-0")).To(BeEmpty())
+		})
+		It("should keep selector labels of members of the cluster", func(ctx SpecContext) {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+						EmbeddedObjectMetadata: etcdaenixiov1alpha1.EmbeddedObjectMetadata{
+							Labels: map[string]string{"app.kubernetes.io/instance": "test-2", "tier": "etcd"},
+						},
+					},
+				},
+			}
+			template, err := generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Labels).To(Equal(map[string]string{
+				"app.kubernetes.io/name":       "etcd",
+				"app.kubernetes.io/instance":   "test",
+				"app.kubernetes.io/managed-by": "etcd-operator",
+				"tier":                         "etcd",
+			}))
+		})
+		It("should gate readiness of members by their etcd health", func(ctx SpecContext) {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{}
+			template, err := generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.ReadinessGates).To(BeEmpty())
+
+			etcdCluster.Spec.AvailabilityPolicy = &etcdaenixiov1alpha1.AvailabilityPolicySpec{MemberReadinessGate: true}
+			template, err = generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.ReadinessGates).To(ConsistOf(
+				corev1.PodReadinessGate{ConditionType: MemberHealthyCondition},
+			))
+		})
+		It("should keep write-ahead log on a separate volume", func(ctx SpecContext) {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						WALVolume: &etcdaenixiov1alpha1.WALVolumeSpec{},
+					},
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElement("--wal-dir=/var/run/etcd-wal/wal"))
+			Expect(generateVolumes(etcdCluster)).To(ContainElement(And(
+				HaveField("Name", "wal"),
+				HaveField("PersistentVolumeClaim.ClaimName", "wal"),
+			)))
+			Expect(generateVolumeMounts(etcdCluster)).To(ContainElement(
+				corev1.VolumeMount{Name: "wal", MountPath: "/var/run/etcd-wal"},
+			))
+			template, err := generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.InitContainers).To(ConsistOf(And(
+				HaveField("Name", walMigrationContainerName),
+				HaveField("VolumeMounts", HaveLen(2)),
+			)))
+
+			etcdCluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{}
+			etcdCluster.Spec.Storage.WALVolume.EmptyDir = &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}
+			Expect(generateVolumes(etcdCluster)).To(ContainElement(HaveField("EmptyDir.Medium", corev1.StorageMediumMemory)))
+			template, err = generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.InitContainers).To(BeEmpty())
+		})
+		It("should run etcd as the user owning data volumes", func(ctx SpecContext) {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						VolumePermissions: &etcdaenixiov1alpha1.VolumePermissionsSpec{},
+					},
+				},
+			}
+			template, err := generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.SecurityContext).To(Equal(&corev1.PodSecurityContext{
+				RunAsUser:           ptr.To(int64(1000)),
+				RunAsGroup:          ptr.To(int64(1000)),
+				FSGroup:             ptr.To(int64(1000)),
+				FSGroupChangePolicy: ptr.To(corev1.FSGroupChangeOnRootMismatch),
+			}))
+			Expect(template.Spec.InitContainers).To(BeEmpty())
+
+			etcdCluster.Spec.Storage.VolumePermissions = &etcdaenixiov1alpha1.VolumePermissionsSpec{
+				Mode:       etcdaenixiov1alpha1.VolumePermissionsInitContainer,
+				RunAsUser:  ptr.To(int64(1001)),
+				RunAsGroup: ptr.To(int64(1002)),
+			}
+			etcdCluster.Spec.Storage.WALVolume = &etcdaenixiov1alpha1.WALVolumeSpec{}
+			template, err = generatePodTemplate(ctx, etcdCluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Spec.SecurityContext).To(Equal(&corev1.PodSecurityContext{
+				RunAsUser:  ptr.To(int64(1001)),
+				RunAsGroup: ptr.To(int64(1002)),
+			}))
+			// ownership is fixed after the log is moved by root
+			Expect(template.Spec.InitContainers).To(HaveExactElements(
+				And(
+					HaveField("Name", walMigrationContainerName),
+					HaveField("SecurityContext.RunAsUser", ptr.To(int64(0))),
+				),
+				And(
+					HaveField("Name", volumePermissionsContainerName),
+					HaveField("Command", Equal([]string{"chown", "-R", "1001:1002", "/var/run/etcd", "/var/run/etcd-wal"})),
+					HaveField("SecurityContext.RunAsUser", ptr.To(int64(0))),
+				),
+			))
+		})
+		It("should pass tracing settings to etcd under names of its version", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Tracing: &etcdaenixiov1alpha1.TracingSpec{
+						Endpoint:               "otel-collector.monitoring:4317",
+						SamplingRatePerMillion: ptr.To(int32(100)),
+					},
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElements(
+				"--experimental-enable-distributed-tracing=true",
+				"--experimental-distributed-tracing-address=otel-collector.monitoring:4317",
+				"--experimental-distributed-tracing-service-name=etcd",
+				"--experimental-distributed-tracing-instance-id=$(POD_NAME)",
+				"--experimental-distributed-tracing-sampling-rate=100",
+			))
+
+			etcdCluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "gcr.io/etcd-development/etcd:v3.6.0"},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElements(
+				"--enable-distributed-tracing=true",
+				"--distributed-tracing-address=otel-collector.monitoring:4317",
+			))
+		})
+		It("should write etcd logs into the file shipped by the sidecar", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Logging: &etcdaenixiov1alpha1.LoggingSpec{
+						Shipper: &etcdaenixiov1alpha1.LogShipperSpec{Outputs: "[OUTPUT]\n    Name  loki\n    Match etcd"},
+					},
+				},
+			}
+			Expect(generateEtcdArgs(etcdCluster)).To(ContainElements(
+				"--log-outputs=/var/log/etcd/etcd.log",
+				"--enable-log-rotation=true",
+			))
+			config := generateLogShipperConfig(etcdCluster)
+			Expect(config).To(ContainSubstring("Add    cluster test"))
+			Expect(config).To(HaveSuffix("[OUTPUT]\n    Name  loki\n    Match etcd\n"))
+
+			shipper := generateLogShipperContainer(etcdCluster)
+			Expect(shipper.Image).To(Equal(defaultLogShipperImage))
+			Expect(shipper.RestartPolicy).To(Equal(ptr.To(corev1.ContainerRestartPolicyAlways)))
+			Expect(generateVolumeMounts(etcdCluster)).To(ContainElement(
+				corev1.VolumeMount{Name: etcdLogsVolumeName, MountPath: etcdLogsPath},
+			))
+		})
+		It("should probe plain HTTP metrics listener", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{"listen-metrics-urls": "https://0.0.0.0:2381,http://0.0.0.0:2382"},
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{
+							ClientTrustedCASecret: "client-ca-secret",
+							ClientSecret:          "client-secret",
+						},
+					},
+				},
+			}
+			Expect(getReadinessProbe(etcdCluster).HTTPGet).To(Equal(&corev1.HTTPGetAction{
+				Path: "/readyz",
+				Port: intstr.FromInt32(2382),
+			}))
+			args := generateEtcdArgs(etcdCluster)
+			Expect(args).To(ContainElement("--listen-metrics-urls=https://0.0.0.0:2381,http://0.0.0.0:2382"))
+			Expect(args).NotTo(ContainElement("--listen-metrics-urls=http://0.0.0.0:2381"))
+		})
+		It("should probe HTTPS metrics listener without client certificate authentication", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{"listen-metrics-urls": "https://0.0.0.0:2381"},
+				},
+			}
+			Expect(getLivenessProbe(etcdCluster).HTTPGet).To(Equal(&corev1.HTTPGetAction{
+				Path:   "/livez",
+				Port:   intstr.FromInt32(2381),
+				Scheme: corev1.URISchemeHTTPS,
+			}))
+		})
+		It("should probe with etcdctl if metrics require client certificates", func() {
+			etcdCluster := &etcdaenixiov1alpha1.EtcdCluster{
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Options: map[string]string{"listen-metrics-urls": "https://0.0.0.0:2381"},
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{
+							ServerSecret:          "server-secret",
+							ClientTrustedCASecret: "client-ca-secret",
+							ClientSecret:          "client-secret",
+						},
+					},
+				},
+			}
+			probe := getReadinessProbe(etcdCluster)
+			Expect(probe.HTTPGet).To(BeNil())
+			Expect(probe.Exec.Command).To(Equal([]string{
+				"etcdctl",
+				"--endpoints=https://127.0.0.1:2379",
+				"--insecure-skip-tls-verify",
+				"--cert=/etc/etcd/pki/client/cert/tls.crt",
+				"--key=/etc/etcd/pki/client/cert/tls.key",
+				"endpoint",
+				"health",
+			}))
+			Expect(generateVolumes(etcdCluster)).To(ContainElement(corev1.Volume{
+				Name: "client-certificate",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: "client-secret"},
+				},
+			}))
+			Expect(generateVolumeMounts(etcdCluster)).To(ContainElement(corev1.VolumeMount{
+				Name:      "client-certificate",
+				ReadOnly:  true,
+				MountPath: "/etc/etcd/pki/client/cert",
+			}))
+		})
 	})
 
 	/* TODO: all of the following tests validate merging logic, but all merging logic is now handled externally.
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const storageMigrationMountPath = "/migration"
+
+// GetStorageMigrationPodName returns name of the pod restoring the snapshot of the cluster during storage migration.
+func GetStorageMigrationPodName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return cluster.Name + "-storage-migration"
+}
+
+// GetStorageMigrationPod returns the pod migrating data of the cluster from emptyDir to volume claims.
+// Its init container saves a snapshot of the first member, then the snapshot is restored as a single member cluster
+// on the volume claim of the first member, so the member keeps the data when it is recreated with the claim.
+// The snapshot itself is kept in emptyDir of the pod. The pod is not labeled with the name of etcd,
+// so it is not selected as a member of the cluster.
+func GetStorageMigrationPod(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.Pod {
+	podName := GetMemberPodName(cluster, 0)
+	peerURL := GetMemberPeerURL(cluster, podName)
+	snapshotPath := path.Join(storageMigrationMountPath, "snapshot.db")
+	migrationMount := corev1.VolumeMount{Name: "migration", MountPath: storageMigrationMountPath}
+
+	pod := GetDebugPod(ctx, cluster)
+	pod.Name = GetStorageMigrationPodName(cluster)
+	pod.Labels = NewLabelsBuilder().WithInstance(cluster.Name).WithManagedBy()
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	snapshot := pod.Spec.Containers[0]
+	snapshot.Name = "snapshot"
+	snapshot.Command = []string{
+		"etcdctl",
+		"--endpoints=" + GetMemberClientEndpoint(cluster, podName),
+		"snapshot", "save", snapshotPath,
+	}
+	snapshot.VolumeMounts = append(snapshot.VolumeMounts, migrationMount)
+	pod.Spec.InitContainers = []corev1.Container{snapshot}
+
+	pod.Spec.Containers = []corev1.Container{{
+		Name:  "restore",
+		Image: snapshot.Image,
+		Command: []string{
+			"etcdutl", "snapshot", "restore", snapshotPath,
+			"--name=" + podName,
+			fmt.Sprintf("--initial-cluster=%s=%s", podName, peerURL),
+			"--initial-advertise-peer-urls=" + peerURL,
+			"--initial-cluster-token=" + GetInitialClusterToken(cluster),
+			"--data-dir=" + cluster.Spec.Storage.GetDataDirPath(),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			migrationMount,
+			{
+				Name:      "data",
+				MountPath: cluster.Spec.Storage.GetMountPath(),
+				SubPath:   cluster.Spec.Storage.SubPath,
+			},
+		},
+	}}
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name:         "migration",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: GetMemberPVCName(cluster, podName),
+			}},
+		},
+	)
+
+	// the volume is bound where the pod runs, so the pod is scheduled like members and writes data as they do
+	template := cluster.Spec.PodTemplate.Spec.DeepCopy()
+	pod.Spec.NodeSelector = template.NodeSelector
+	pod.Spec.Tolerations = template.Tolerations
+	pod.Spec.ImagePullSecrets = template.ImagePullSecrets
+	pod.Spec.SecurityContext = template.SecurityContext
+	if template.Affinity != nil && template.Affinity.NodeAffinity != nil {
+		pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: template.Affinity.NodeAffinity}
+	}
+	addPlatformAffinity(cluster, &pod.Spec)
+	return pod
+}
+
+// IsEmptyDirPodTemplate returns true if members created from the pod template keep data in emptyDir.
+func IsEmptyDirPodTemplate(template *corev1.PodTemplateSpec) bool {
+	for _, volume := range template.Spec.Volumes {
+		if volume.Name == "data" {
+			return volume.EmptyDir != nil
+		}
+	}
+	return false
+}
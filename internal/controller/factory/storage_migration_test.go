@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Storage migration pod", func() {
+	It("should restore snapshot of the first member on its volume claim", func(ctx SpecContext) {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+					Spec: corev1.PodSpec{NodeSelector: map[string]string{"disk": "ssd"}},
+				},
+			},
+		}
+		pod := GetStorageMigrationPod(ctx, cluster)
+		Expect(pod.Name).To(Equal("test-storage-migration"))
+		Expect(pod.Labels).NotTo(HaveKey("app.kubernetes.io/name"))
+		Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("disk", "ssd"))
+		Expect(pod.Spec.InitContainers).To(ConsistOf(HaveField("Command", Equal([]string{
+			"etcdctl", "--endpoints=http://test-0.test-headless.default.svc:2379",
+			"snapshot", "save", "/migration/snapshot.db",
+		}))))
+		Expect(pod.Spec.Containers).To(ConsistOf(HaveField("Command", ContainElements(
+			"etcdutl",
+			"--name=test-0",
+			"--initial-cluster=test-0=https://test-0.test-headless.default.svc:2380",
+			"--initial-cluster-token=test-default",
+			"--data-dir="+cluster.Spec.Storage.GetDataDirPath(),
+		))))
+		Expect(pod.Spec.Volumes).To(ContainElement(
+			HaveField("PersistentVolumeClaim.ClaimName", "data-test-0"),
+		))
+	})
+})
@@ -47,6 +47,61 @@ func GetHeadlessServiceName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
 	return fmt.Sprintf("%s-headless", cluster.Name)
 }
 
+// GetLeaderServiceName returns name of the service selecting the leader member.
+func GetLeaderServiceName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s-leader", cluster.Name)
+}
+
+// getClientScheme returns scheme of client URLs, https if members serve clients with TLS.
+func getClientScheme(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	if cluster.Spec.Security != nil && cluster.Spec.Security.TLS.ServerSecret != "" {
+		return "https"
+	}
+	return "http"
+}
+
+// GetMemberClientEndpoint returns client URL of the member pod resolved by the headless service.
+func GetMemberClientEndpoint(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	return fmt.Sprintf("%s://%s.%s.%s.svc:2379", getClientScheme(cluster), podName, GetHeadlessServiceName(cluster), cluster.Namespace)
+}
+
+// GetClientServiceEndpoint returns client URL of the client service.
+func GetClientServiceEndpoint(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s://%s.%s.svc:2379", getClientScheme(cluster), GetServiceName(cluster), cluster.Namespace)
+}
+
+// GetLeaderServiceEndpoint returns client URL of the leader service.
+func GetLeaderServiceEndpoint(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s://%s.%s.svc:2379", getClientScheme(cluster), GetLeaderServiceName(cluster), cluster.Namespace)
+}
+
+// GetOperatorEndpoints returns endpoints the operator connects to in connection modes other than PodDNS.
+func GetOperatorEndpoints(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	switch cluster.GetOperatorConnectionMode() {
+	case etcdaenixiov1alpha1.OperatorConnectionHeadlessService:
+		return []string{fmt.Sprintf("%s://%s.%s.svc:2379", getClientScheme(cluster), GetHeadlessServiceName(cluster), cluster.Namespace)}
+	case etcdaenixiov1alpha1.OperatorConnectionClientService:
+		return []string{GetClientServiceEndpoint(cluster)}
+	case etcdaenixiov1alpha1.OperatorConnectionEndpoints:
+		return cluster.Spec.OperatorConnection.Endpoints
+	}
+	return nil
+}
+
+// discoverySRVPeerPortName is the name of the headless service port etcd looks up SRV records of TLS peers by.
+const discoverySRVPeerPortName = "etcd-server-ssl"
+
+// GetDiscoverySRVDomain returns domain of SRV records of the headless service, which members look up in
+// the DiscoverySRV bootstrap mode and clients look up with spec.clientSRVRecords.
+func GetDiscoverySRVDomain(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	return fmt.Sprintf("%s.%s.svc", GetHeadlessServiceName(cluster), cluster.Namespace)
+}
+
+// GetMemberPeerURL returns peer URL the member pod advertises to other members.
+func GetMemberPeerURL(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	return fmt.Sprintf("https://%s.%s.%s.svc:2380", podName, GetHeadlessServiceName(cluster), cluster.Namespace)
+}
+
 func CreateOrUpdateHeadlessService(
 	ctx context.Context,
 	cluster *etcdaenixiov1alpha1.EtcdCluster,
@@ -68,12 +123,26 @@ func CreateOrUpdateHeadlessService(
 		}
 	}
 
+	// peers always use TLS, so SRV records are published under the name of TLS peers
+	peerPortName := "peer"
+	if cluster.UsesDiscoverySRV() {
+		peerPortName = discoverySRVPeerPortName
+	}
+	// clients look up SRV records named by the scheme they connect with
+	clientPortName := "client"
+	if cluster.Spec.ClientSRVRecords {
+		clientPortName = "etcd-client"
+		if getClientScheme(cluster) == "https" {
+			clientPortName = "etcd-client-ssl"
+		}
+	}
+
 	svc := &corev1.Service{
 		ObjectMeta: metadata,
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
-				{Name: "peer", TargetPort: intstr.FromInt32(2380), Port: 2380, Protocol: corev1.ProtocolTCP},
-				{Name: "client", TargetPort: intstr.FromInt32(2379), Port: 2379, Protocol: corev1.ProtocolTCP},
+				{Name: peerPortName, TargetPort: intstr.FromInt32(2380), Port: 2380, Protocol: corev1.ProtocolTCP},
+				{Name: clientPortName, TargetPort: intstr.FromInt32(2379), Port: 2379, Protocol: corev1.ProtocolTCP},
 			},
 			Type:                     corev1.ServiceTypeClusterIP,
 			ClusterIP:                "None",
@@ -82,6 +151,9 @@ func CreateOrUpdateHeadlessService(
 		},
 	}
 
+	svc.Labels = withClusterSelector(cluster, svc.Labels)
+	svc.Spec.Selector = withClusterSelector(cluster, svc.Spec.Selector)
+
 	logger.V(2).Info("cluster service spec generated", "svc_name", svc.Name, "svc_spec", svc.Spec)
 
 	if err := ctrl.SetControllerReference(cluster, svc, rclient.Scheme()); err != nil {
@@ -124,6 +196,10 @@ func CreateOrUpdateClientService(
 		}
 	}
 
+	// the template can not make the service select members of other clusters
+	svc.Labels = withClusterSelector(cluster, svc.Labels)
+	svc.Spec.Selector = withClusterSelector(cluster, svc.Spec.Selector)
+
 	logger.V(2).Info("client service spec generated", "svc_name", svc.Name, "svc_spec", svc.Spec)
 
 	if err := ctrl.SetControllerReference(cluster, &svc, rclient.Scheme()); err != nil {
@@ -132,3 +208,43 @@ func CreateOrUpdateClientService(
 
 	return reconcileOwnedResource(ctx, rclient, &svc)
 }
+
+// CreateOrUpdateLeaderService creates the service selecting the member pod labeled as the leader,
+// or deletes it if spec.leaderService is disabled.
+func CreateOrUpdateLeaderService(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	rclient client.Client,
+) error {
+	if !cluster.Spec.LeaderService {
+		return deleteOwnedResource(ctx, rclient, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cluster.Namespace,
+				Name:      GetLeaderServiceName(cluster),
+			}})
+	}
+
+	logger := log.FromContext(ctx)
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetLeaderServiceName(cluster),
+			Namespace: cluster.Namespace,
+			Labels:    withClusterSelector(cluster, nil),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "client", TargetPort: intstr.FromInt32(2379), Port: 2379, Protocol: corev1.ProtocolTCP},
+			},
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: withClusterSelector(cluster, NewLabelsBuilder().WithMemberRole(MemberRoleLeader)),
+		},
+	}
+
+	logger.V(2).Info("leader service spec generated", "svc_name", svc.Name, "svc_spec", svc.Spec)
+
+	if err := ctrl.SetControllerReference(cluster, svc, rclient.Scheme()); err != nil {
+		return fmt.Errorf("cannot set controller reference: %w", err)
+	}
+
+	return reconcileOwnedResource(ctx, rclient, svc)
+}
@@ -191,9 +191,135 @@ var _ = Describe("CreateOrUpdateService handlers", func() {
 			))
 		})
 
+		It("should publish not ready members only by the headless service", func(ctx SpecContext) {
+			etcdcluster.Spec.ServiceTemplate = &etcdaenixiov1alpha1.EmbeddedService{
+				Spec: corev1.ServiceSpec{
+					SessionAffinity:       corev1.ServiceAffinityClientIP,
+					InternalTrafficPolicy: ptr.To(corev1.ServiceInternalTrafficPolicyLocal),
+				},
+			}
+
+			Expect(CreateOrUpdateHeadlessService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Expect(CreateOrUpdateClientService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&headlessService)).Should(HaveField("Spec.PublishNotReadyAddresses", BeTrue()))
+			Eventually(Object(&clientService)).Should(SatisfyAll(
+				HaveField("Spec.PublishNotReadyAddresses", BeFalse()),
+				HaveField("Spec.SessionAffinity", Equal(corev1.ServiceAffinityClientIP)),
+				HaveField("Spec.InternalTrafficPolicy", Equal(ptr.To(corev1.ServiceInternalTrafficPolicyLocal))),
+			))
+		})
+
+		It("should publish SRV records of TLS peers in the DiscoverySRV bootstrap mode", func(ctx SpecContext) {
+			etcdcluster.Spec.Bootstrap = etcdaenixiov1alpha1.BootstrapDiscoverySRV
+
+			Expect(CreateOrUpdateHeadlessService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&headlessService)).Should(HaveField("Spec.Ports", ContainElement(SatisfyAll(
+				HaveField("Name", Equal("etcd-server-ssl")),
+				HaveField("Port", Equal(int32(2380))),
+			))))
+		})
+
+		It("should publish SRV records of member clients if requested", func(ctx SpecContext) {
+			etcdcluster.Spec.ClientSRVRecords = true
+
+			Expect(CreateOrUpdateHeadlessService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&headlessService)).Should(HaveField("Spec.Ports", ContainElement(SatisfyAll(
+				HaveField("Name", Equal("etcd-client")),
+				HaveField("Port", Equal(int32(2379))),
+			))))
+		})
+
+		It("should select only members of the cluster whatever the template sets", func(ctx SpecContext) {
+			etcdcluster.Spec.ServiceTemplate = &etcdaenixiov1alpha1.EmbeddedService{
+				EmbeddedObjectMetadata: etcdaenixiov1alpha1.EmbeddedObjectMetadata{
+					Labels: map[string]string{"app.kubernetes.io/instance": "other"},
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"app.kubernetes.io/instance": "other", "tier": "etcd"},
+				},
+			}
+
+			Expect(CreateOrUpdateClientService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(&clientService)).Should(SatisfyAll(
+				HaveField("Labels", HaveKeyWithValue("app.kubernetes.io/instance", etcdcluster.Name)),
+				HaveField("Spec.Selector", Equal(map[string]string{
+					"app.kubernetes.io/name":       "etcd",
+					"app.kubernetes.io/instance":   etcdcluster.Name,
+					"app.kubernetes.io/managed-by": "etcd-operator",
+					"tier":                         "etcd",
+				})),
+			))
+		})
+
+		It("should not take over services of other clusters with colliding names", func(ctx SpecContext) {
+			Expect(CreateOrUpdateHeadlessService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+
+			other := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      GetHeadlessServiceName(&etcdcluster),
+					Namespace: ns.GetName(),
+				},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+				},
+			}
+			Expect(k8sClient.Create(ctx, other)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, other)
+
+			Expect(CreateOrUpdateClientService(ctx, other, k8sClient)).To(MatchError(ContainSubstring(
+				"is controlled by EtcdCluster " + etcdcluster.Name)))
+			Eventually(Object(&headlessService)).Should(HaveField("Spec.ClusterIP", Equal(corev1.ClusterIPNone)))
+		})
+
+		It("should select the leader member by the leader service only while it is enabled", func(ctx SpecContext) {
+			leaderService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ns.GetName(), Name: GetLeaderServiceName(&etcdcluster)},
+			}
+			Expect(CreateOrUpdateLeaderService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Expect(apierrors.IsNotFound(Get(leaderService)())).To(BeTrue())
+
+			etcdcluster.Spec.LeaderService = true
+			Expect(CreateOrUpdateLeaderService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Eventually(Object(leaderService)).Should(SatisfyAll(
+				HaveField("Spec.Selector", HaveKeyWithValue(LabelMemberRole, MemberRoleLeader)),
+				HaveField("Spec.Selector", HaveKeyWithValue("app.kubernetes.io/instance", etcdcluster.Name)),
+				HaveField("Spec.PublishNotReadyAddresses", BeFalse()),
+			))
+
+			etcdcluster.Spec.LeaderService = false
+			Expect(CreateOrUpdateLeaderService(ctx, &etcdcluster, k8sClient)).To(Succeed())
+			Expect(apierrors.IsNotFound(Get(leaderService)())).To(BeTrue())
+		})
+
 		It("should fail on creating the client service with invalid owner reference", func(ctx SpecContext) {
 			Expect(CreateOrUpdateHeadlessService(ctx, &etcdcluster, clientWithEmptyScheme)).NotTo(Succeed())
 			Expect(CreateOrUpdateClientService(ctx, &etcdcluster, clientWithEmptyScheme)).NotTo(Succeed())
 		})
 	})
+
+	Context("when getting operator endpoints", func() {
+		It("should connect through Services or configured endpoints", func() {
+			cluster := &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					OperatorConnection: &etcdaenixiov1alpha1.OperatorConnectionSpec{
+						Mode: etcdaenixiov1alpha1.OperatorConnectionHeadlessService,
+					},
+				},
+			}
+			Expect(GetOperatorEndpoints(cluster)).To(Equal([]string{"http://test-headless.ns.svc:2379"}))
+
+			cluster.Spec.OperatorConnection.Mode = etcdaenixiov1alpha1.OperatorConnectionClientService
+			cluster.Spec.Security = &etcdaenixiov1alpha1.SecuritySpec{
+				TLS: etcdaenixiov1alpha1.TLSSpec{ServerSecret: "server-tls"},
+			}
+			Expect(GetOperatorEndpoints(cluster)).To(Equal([]string{"https://test.ns.svc:2379"}))
+
+			cluster.Spec.OperatorConnection = &etcdaenixiov1alpha1.OperatorConnectionSpec{
+				Mode:      etcdaenixiov1alpha1.OperatorConnectionEndpoints,
+				Endpoints: []string{"https://gateway.mesh:2379"},
+			}
+			Expect(GetOperatorEndpoints(cluster)).To(Equal([]string{"https://gateway.mesh:2379"}))
+		})
+	})
 })
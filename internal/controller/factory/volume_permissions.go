@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	volumePermissionsContainerName = "volume-permissions"
+	volumePermissionsImage         = "busybox:1.36"
+)
+
+// generatePodSecurityContext returns security context of member pods running etcd as the user of
+// spec.storage.volumePermissions, the kubelet changes group ownership of volumes only in the FSGroup mode.
+func generatePodSecurityContext(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.PodSecurityContext {
+	permissions := cluster.Spec.Storage.VolumePermissions
+	securityContext := &corev1.PodSecurityContext{
+		RunAsUser:  ptr.To(permissions.GetRunAsUser()),
+		RunAsGroup: ptr.To(permissions.GetRunAsGroup()),
+	}
+	if permissions.GetMode() == etcdaenixiov1alpha1.VolumePermissionsFSGroup {
+		securityContext.FSGroup = ptr.To(permissions.GetRunAsGroup())
+		// volumes of members keep the same owner, so they are not walked on every restart
+		securityContext.FSGroupChangePolicy = ptr.To(corev1.FSGroupChangeOnRootMismatch)
+	}
+	return securityContext
+}
+
+// generateVolumePermissionsContainer returns init container changing ownership of data volumes of the member
+// to the etcd user, it runs after other init containers may have written to the volumes as root.
+func generateVolumePermissionsContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Container {
+	permissions := cluster.Spec.Storage.VolumePermissions
+	image := permissions.Image
+	if image == "" {
+		image = volumePermissionsImage
+	}
+	owner := fmt.Sprintf("%d:%d", permissions.GetRunAsUser(), permissions.GetRunAsGroup())
+	command := []string{"chown", "-R", owner}
+	volumeMounts := []corev1.VolumeMount{}
+	for _, volumeMount := range generateVolumeMounts(cluster) {
+		if volumeMount.Name == getDataVolumeName(cluster) || volumeMount.Name == GetWALPVCName(cluster) {
+			command = append(command, volumeMount.MountPath)
+			volumeMounts = append(volumeMounts, volumeMount)
+		}
+	}
+	return corev1.Container{
+		Name:            volumePermissionsContainerName,
+		Image:           image,
+		Command:         command,
+		SecurityContext: rootSecurityContext(cluster),
+		VolumeMounts:    volumeMounts,
+	}
+}
+
+// rootSecurityContext returns security context of init containers which have to run as root, or nil if member
+// pods run as root anyway.
+func rootSecurityContext(cluster *etcdaenixiov1alpha1.EtcdCluster) *corev1.SecurityContext {
+	if cluster.Spec.Storage.VolumePermissions == nil {
+		return nil
+	}
+	return &corev1.SecurityContext{RunAsUser: ptr.To(int64(0)), RunAsGroup: ptr.To(int64(0))}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	walMigrationContainerName = "wal-migration"
+	walMigrationImage         = "busybox:1.36"
+	walMountPath              = "/var/run/etcd-wal"
+	// walDir is a directory inside the volume, etcd creates the log in a temporary directory and renames it
+	walDir = walMountPath + "/wal"
+)
+
+// walMigrationScript moves the log of a member from its data dir to the log volume. The log is copied next to
+// the destination first, so a member restarted during the copy starts it over.
+const walMigrationScript = `set -e
+src="$1/member/wal"
+if [ -d "$src" ]; then
+  if [ ! -d ` + walDir + ` ]; then
+    rm -rf ` + walDir + `.tmp
+    cp -a "$src" ` + walDir + `.tmp
+    mv ` + walDir + `.tmp ` + walDir + `
+  fi
+  rm -rf "$src"
+fi
+`
+
+// GetWALPVCName returns name of the write-ahead log volume claim template and of the log volume.
+func GetWALPVCName(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	if wal := cluster.Spec.Storage.WALVolume; wal != nil && len(wal.VolumeClaimTemplate.Name) > 0 {
+		return wal.VolumeClaimTemplate.Name
+	}
+	return "wal"
+}
+
+// GetMemberWALPVCName returns name of the write-ahead log volume claim of the member, the same name StatefulSet gives it.
+func GetMemberWALPVCName(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	return GetWALPVCName(cluster) + "-" + podName
+}
+
+// hasWALVolumeClaim returns true if the write-ahead log is kept on a volume claimed for each member.
+func hasWALVolumeClaim(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Spec.Storage.WALVolume != nil && cluster.Spec.Storage.WALVolume.EmptyDir == nil
+}
+
+// generateWALVolumeClaim returns claim of the write-ahead log volume, StatefulSet creates it for each member.
+func generateWALVolumeClaim(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.PersistentVolumeClaim {
+	template := cluster.Spec.Storage.WALVolume.VolumeClaimTemplate
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetWALPVCName(cluster),
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec:   template.Spec,
+		Status: template.Status,
+	}
+}
+
+// generateMemberWALVolumeClaim returns write-ahead log volume claim of the member named as StatefulSet would name it.
+func generateMemberWALVolumeClaim(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) corev1.PersistentVolumeClaim {
+	claim := generateWALVolumeClaim(cluster)
+	claim.Name = GetMemberWALPVCName(cluster, podName)
+	claim.Namespace = cluster.Namespace
+	claim.Labels = labels.Merge(claim.Labels, map[string]string(NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()))
+	claim.Status = corev1.PersistentVolumeClaimStatus{}
+	return claim
+}
+
+// generateWALVolume returns the write-ahead log volume of the pod template.
+func generateWALVolume(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Volume {
+	volume := corev1.Volume{Name: GetWALPVCName(cluster)}
+	if emptyDir := cluster.Spec.Storage.WALVolume.EmptyDir; emptyDir != nil {
+		volume.EmptyDir = emptyDir
+	} else {
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: GetWALPVCName(cluster)}
+	}
+	return volume
+}
+
+// generateWALMigrationContainer returns init container moving the log of members created before the log volume
+// was added. It does nothing on later starts.
+func generateWALMigrationContainer(cluster *etcdaenixiov1alpha1.EtcdCluster) corev1.Container {
+	dataVolumeMount := generateVolumeMounts(cluster)[0]
+	return corev1.Container{
+		Name:    walMigrationContainerName,
+		Image:   walMigrationImage,
+		Command: []string{"/bin/sh", "-c", walMigrationScript, walMigrationContainerName, cluster.Spec.Storage.GetDataDirPath()},
+		// the log written by etcd running as root can only be moved by root
+		SecurityContext: rootSecurityContext(cluster),
+		VolumeMounts: []corev1.VolumeMount{
+			dataVolumeMount,
+			{Name: GetWALPVCName(cluster), MountPath: walMountPath},
+		},
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	fleetStateReady        = "ready"
+	fleetStateNotReady     = "not_ready"
+	fleetStateInitializing = "initializing"
+
+	fleetListTimeout = 10 * time.Second
+)
+
+var (
+	fleetClustersDesc = prometheus.NewDesc(
+		"etcd_operator_clusters",
+		"Number of EtcdClusters managed by the operator by state.",
+		[]string{"state"}, nil,
+	)
+	fleetMembersDesc = prometheus.NewDesc(
+		"etcd_operator_members",
+		"Number of etcd members of all EtcdClusters managed by the operator.",
+		nil, nil,
+	)
+	fleetUnhealthyDesc = prometheus.NewDesc(
+		"etcd_operator_cluster_unhealthy",
+		"EtcdClusters which are not ready or have failing members, with the reason of the first failing condition.",
+		[]string{"namespace", "name", "reason"}, nil,
+	)
+)
+
+// unhealthyCluster is a cluster reported by the fleet overview.
+type unhealthyCluster struct {
+	namespace string
+	name      string
+	reason    string
+}
+
+// fleetSummary aggregates state of all managed clusters.
+type fleetSummary struct {
+	clusters  map[string]int
+	members   int
+	unhealthy []unhealthyCluster
+}
+
+// summarizeFleet counts clusters by the state of their Ready condition and lists unhealthy ones.
+func summarizeFleet(clusters []etcdaenixiov1alpha1.EtcdCluster) fleetSummary {
+	summary := fleetSummary{
+		clusters: map[string]int{fleetStateReady: 0, fleetStateNotReady: 0, fleetStateInitializing: 0},
+	}
+	for i := range clusters {
+		cluster := &clusters[i]
+		if cluster.Spec.Replicas != nil {
+			summary.members += int(*cluster.Spec.Replicas)
+		}
+
+		ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+		switch {
+		case ready == nil || ready.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum):
+			summary.clusters[fleetStateInitializing]++
+			continue
+		case ready.Status == metav1.ConditionTrue:
+			summary.clusters[fleetStateReady]++
+		default:
+			summary.clusters[fleetStateNotReady]++
+		}
+
+		reason := ""
+		if ready.Status != metav1.ConditionTrue {
+			reason = ready.Reason
+		}
+		for _, conditionType := range []string{
+			etcdaenixiov1alpha1.EtcdConditionMemberFailure,
+			etcdaenixiov1alpha1.EtcdConditionUpgradeFailed,
+		} {
+			if reason != "" {
+				break
+			}
+			if condition := factory.GetCondition(cluster, conditionType); condition != nil && condition.Status == metav1.ConditionTrue {
+				reason = condition.Reason
+			}
+		}
+		if reason != "" {
+			summary.unhealthy = append(summary.unhealthy, unhealthyCluster{
+				namespace: cluster.Namespace,
+				name:      cluster.Name,
+				reason:    reason,
+			})
+		}
+	}
+	return summary
+}
+
+// FleetCollector exposes an overview of all managed clusters, so dashboards do not have to scrape every resource.
+type FleetCollector struct {
+	reader client.Reader
+}
+
+var _ prometheus.Collector = &FleetCollector{}
+
+// NewFleetCollector returns collector listing clusters with the reader, usually the cache of the manager.
+func NewFleetCollector(reader client.Reader) *FleetCollector {
+	return &FleetCollector{reader: reader}
+}
+
+func (c *FleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fleetClustersDesc
+	ch <- fleetMembersDesc
+	ch <- fleetUnhealthyDesc
+}
+
+func (c *FleetCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), fleetListTimeout)
+	defer cancel()
+	clusters := &etcdaenixiov1alpha1.EtcdClusterList{}
+	if err := c.reader.List(ctx, clusters); err != nil {
+		ch <- prometheus.NewInvalidMetric(fleetClustersDesc, err)
+		return
+	}
+
+	summary := summarizeFleet(clusters.Items)
+	for state, count := range summary.clusters {
+		ch <- prometheus.MustNewConstMetric(fleetClustersDesc, prometheus.GaugeValue, float64(count), state)
+	}
+	ch <- prometheus.MustNewConstMetric(fleetMembersDesc, prometheus.GaugeValue, float64(summary.members))
+	for _, cluster := range summary.unhealthy {
+		ch <- prometheus.MustNewConstMetric(fleetUnhealthyDesc, prometheus.GaugeValue, 1,
+			cluster.namespace, cluster.name, cluster.reason)
+	}
+}
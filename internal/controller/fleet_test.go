@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Fleet overview", func() {
+	newCluster := func(name string, conditions ...metav1.Condition) etcdaenixiov1alpha1.EtcdCluster {
+		cluster := etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(3))},
+		}
+		for _, condition := range conditions {
+			factory.SetCondition(&cluster, condition)
+		}
+		return cluster
+	}
+	ready := func(status bool, reason etcdaenixiov1alpha1.EtcdCondType) metav1.Condition {
+		return factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).WithStatus(status).WithReason(string(reason)).Complete()
+	}
+
+	It("should summarize clusters by state", func() {
+		summary := summarizeFleet([]etcdaenixiov1alpha1.EtcdCluster{
+			newCluster("new"),
+			newCluster("ready", ready(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)),
+			newCluster("not-ready", ready(false, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady)),
+			newCluster("failing", ready(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady),
+				factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionMemberFailure).
+					WithStatus(true).
+					WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled)).
+					Complete()),
+		})
+		Expect(summary.clusters).To(Equal(map[string]int{
+			fleetStateReady:        2,
+			fleetStateNotReady:     1,
+			fleetStateInitializing: 1,
+		}))
+		Expect(summary.members).To(Equal(12))
+		Expect(summary.unhealthy).To(Equal([]unhealthyCluster{
+			{namespace: "default", name: "not-ready", reason: string(etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady)},
+			{namespace: "default", name: "failing", reason: string(etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled)},
+		}))
+	})
+
+	It("should collect metrics of clusters", func() {
+		collector := NewFleetCollector(k8sClient)
+		Expect(testutil.CollectAndCount(collector, "etcd_operator_clusters")).To(Equal(3))
+		Expect(testutil.CollectAndCount(collector, "etcd_operator_members")).To(Equal(1))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/pkg/etcdhealth"
+)
+
+// healthCheckReasons are condition reasons of failing health checks.
+var healthCheckReasons = map[etcdhealth.Check]etcdaenixiov1alpha1.EtcdCondType{
+	etcdhealth.CheckStatus: etcdaenixiov1alpha1.EtcdCondTypeStatusCheckFailed,
+	etcdhealth.CheckLease:  etcdaenixiov1alpha1.EtcdCondTypeLeaseCheckFailed,
+	etcdhealth.CheckWatch:  etcdaenixiov1alpha1.EtcdCondTypeWatchCheckFailed,
+}
+
+// healthCheckError is a failed health check with the condition reason describing the failing subsystem.
+type healthCheckError struct {
+	reason etcdaenixiov1alpha1.EtcdCondType
+	err    error
+}
+
+func (e *healthCheckError) Error() string {
+	return e.err.Error()
+}
+
+func (e *healthCheckError) Unwrap() error {
+	return e.err
+}
+
+// newHealthCheckError returns nil if err is nil. Errors of checks other than etcdhealth ones fail the status check.
+func newHealthCheckError(err error) *healthCheckError {
+	if err == nil {
+		return nil
+	}
+	reason := etcdaenixiov1alpha1.EtcdCondTypeStatusCheckFailed
+	var failure *etcdhealth.Error
+	if errors.As(err, &failure) {
+		reason = healthCheckReasons[failure.Check]
+	}
+	return &healthCheckError{reason: reason, err: err}
+}
+
+// checkClusterHealth checks that members respond to Status and that lease and watch requests are served.
+// Clusters without reachable members are not checked.
+func (r *EtcdClusterReconciler) checkClusterHealth(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) *healthCheckError {
+	endpoints := getMemberEndpoints(cluster, pods, nil)
+	if len(endpoints) == 0 {
+		return nil
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		return newHealthCheckError(fmt.Errorf("cannot create etcd client: %w", err))
+	}
+
+	// endpoints other than member pods are load balanced, any member answering on them is enough
+	minHealthy := 1
+	if cluster.GetOperatorConnectionMode() == etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+		minHealthy = cluster.MinHealthyMembers(len(endpoints))
+	}
+	_, err = etcdhealth.CheckCluster(ctx, cli, etcdhealth.Options{MinHealthy: minHealthy, Timeout: etcdRequestTimeout})
+	return newHealthCheckError(err)
+}
+
+// checkLeaseAndWatch grants and revokes a probe lease and opens and closes a watch on the sentinel key.
+func checkLeaseAndWatch(ctx context.Context, cli *clientv3.Client) *healthCheckError {
+	return newHealthCheckError(etcdhealth.CheckLeaseAndWatch(ctx, cli, etcdRequestTimeout))
+}
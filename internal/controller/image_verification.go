@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/images"
+)
+
+// imageVerificationRetryInterval is the time after which rejected image is verified again,
+// e.g. when the signature is pushed after the image.
+const imageVerificationRetryInterval = time.Minute
+
+// verifyImage checks signature of the etcd image pulled by members and reflects the result in the ImageRejected
+// condition. Clusters have no such condition if the operator does not verify images.
+func (r *EtcdClusterReconciler) verifyImage(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	if r.ImageVerifier == nil {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionImageRejected)
+		return
+	}
+	image := images.MirrorsFromContext(ctx).Rewrite(cluster.EtcdImage())
+	if err := r.ImageVerifier.Verify(ctx, image); err != nil {
+		log.FromContext(ctx).Error(err, "etcd image is rejected", "image", image)
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionImageRejected).
+			WithStatus(true).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeSignatureNotVerified)).
+			WithMessage(fmt.Sprintf("Image %s is not rolled out: %s", image, err)).
+			Complete())
+		return
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionImageRejected).
+		WithStatus(false).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeSignatureVerified)).
+		WithMessage(string(etcdaenixiov1alpha1.EtcdImageRejectedCondNegMessage)).
+		Complete())
+}
+
+func isImageRejected(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionImageRejected)
+	return cond != nil && cond.Status == metav1.ConditionTrue
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/images"
+)
+
+// fakeVerifier accepts images listed as trusted and records verified images.
+type fakeVerifier struct {
+	trusted  map[string]bool
+	verified []string
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, image string) error {
+	f.verified = append(f.verified, image)
+	if !f.trusted[image] {
+		return errors.New("image has no cosign signature")
+	}
+	return nil
+}
+
+var _ = Describe("Image verification", func() {
+	It("should reject etcd image pulled from mirror until its signature is verified", func(ctx SpecContext) {
+		verifier := &fakeVerifier{trusted: map[string]bool{}}
+		r := &EtcdClusterReconciler{ImageVerifier: verifier}
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		mirrored := "registry.example.com/coreos/etcd:v3.5.12"
+		mirrorCtx := images.WithMirrors(ctx, images.Mirrors{"quay.io": "registry.example.com"})
+
+		r.verifyImage(mirrorCtx, cluster)
+		Expect(verifier.verified).To(Equal([]string{mirrored}))
+		Expect(isImageRejected(cluster)).To(BeTrue())
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionImageRejected).Message).
+			To(ContainSubstring("has no cosign signature"))
+
+		verifier.trusted[mirrored] = true
+		r.verifyImage(mirrorCtx, cluster)
+		Expect(isImageRejected(cluster)).To(BeFalse())
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionImageRejected).Status).
+			To(Equal(metav1.ConditionFalse))
+
+		r.ImageVerifier = nil
+		r.verifyImage(mirrorCtx, cluster)
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionImageRejected)).To(BeNil())
+	})
+})
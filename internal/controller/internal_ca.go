@@ -0,0 +1,432 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/pki"
+)
+
+const (
+	eventReasonInternalCACreated  = "InternalCACreated"
+	eventReasonInternalCARotation = "InternalCARotation"
+)
+
+// ensureInternalCA generates the CA of the cluster and issues peer certificates of members, the server certificate
+// and the client certificate of the operator signed by it. Certificates missing, not signed by the CA, not covering
+// current addresses or due to renewal are issued again, others are kept. Members reload certificates from mounted
+// secrets, so they are not restarted. Returns the duration after which the next certificate is due to renewal.
+func (r *EtcdClusterReconciler) ensureInternalCA(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (time.Duration, error) {
+	if !cluster.UsesInternalCA() {
+		return 0, nil
+	}
+	ca, rotationRequeueAfter, err := r.ensureInternalCASecret(ctx, cluster)
+	if err != nil {
+		return 0, err
+	}
+	svc := &corev1.Service{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetServiceName(cluster)}, svc)
+	if client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("cannot get client service: %w", err)
+	}
+
+	issuer := &certificateIssuer{
+		ca:          ca.signing,
+		now:         r.getClock().Now(),
+		duration:    cluster.Spec.Security.TLS.InternalCA.GetDuration(),
+		renewBefore: cluster.Spec.Security.TLS.InternalCA.GetRenewBefore(),
+	}
+	tlsSpec := cluster.Spec.Security.TLS
+
+	if tlsSpec.PeerSecret != "" {
+		existing, err := r.getSecretData(ctx, cluster.Namespace, tlsSpec.PeerSecret)
+		if err != nil {
+			return 0, err
+		}
+		data := map[string][]byte{corev1.ServiceAccountRootCAKey: ca.trustBundle()}
+		for ordinal := 0; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+			podName := factory.GetMemberPodName(cluster, ordinal)
+			certKey, keyKey := factory.GetMemberCertificateKey(podName), factory.GetMemberPrivateKeyKey(podName)
+			request := factory.GetMemberPeerCertificateRequest(cluster, podName)
+			if data[certKey], data[keyKey], err = issuer.issue(existing[certKey], existing[keyKey], request); err != nil {
+				return 0, fmt.Errorf("cannot issue peer certificate of member %s: %w", podName, err)
+			}
+		}
+		if err := r.applyIssuedSecret(ctx, cluster, tlsSpec.PeerSecret, corev1.SecretTypeOpaque, nil, data); err != nil {
+			return 0, err
+		}
+	}
+
+	issued := []struct {
+		secret  string
+		request pki.Request
+	}{
+		{tlsSpec.ServerSecret, factory.GetServerCertificateRequest(cluster, getExternalAddresses(svc))},
+		{tlsSpec.ClientSecret, factory.GetClientCertificateRequest()},
+	}
+	for _, certificate := range issued {
+		if certificate.secret == "" {
+			continue
+		}
+		existing, err := r.getSecretData(ctx, cluster.Namespace, certificate.secret)
+		if err != nil {
+			return 0, err
+		}
+		data := map[string][]byte{corev1.ServiceAccountRootCAKey: ca.trustBundle()}
+		data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey], err = issuer.issue(
+			existing[corev1.TLSCertKey], existing[corev1.TLSPrivateKeyKey], certificate.request)
+		if err != nil {
+			return 0, fmt.Errorf("cannot issue certificate of secret %s: %w", certificate.secret, err)
+		}
+		if err := r.applyIssuedSecret(ctx, cluster, certificate.secret, corev1.SecretTypeTLS, nil, data); err != nil {
+			return 0, err
+		}
+	}
+
+	if issuer.nextRenewal.IsZero() {
+		return rotationRequeueAfter, nil
+	}
+	log.FromContext(ctx).V(2).Info("internal CA certificates are issued", "next_renewal", issuer.nextRenewal)
+	return earliestRequeue(rotationRequeueAfter, max(issuer.nextRenewal.Sub(issuer.now), time.Second)), nil
+}
+
+// Keys of the internal CA secret besides the signing CA in tls.crt and tls.key.
+const (
+	// nextCACertKey and nextCAKeyKey hold the CA members are rolled to trust before it signs certificates.
+	nextCACertKey = "next.crt"
+	nextCAKeyKey  = "next.key"
+	// previousCACertKey holds the CA retired by rotation, trusted until members load certificates of the new CA.
+	previousCACertKey = "previous.crt"
+	// internalCARotatedAtAnnotation is set on the internal CA secret to the time the previous CA was retired.
+	internalCARotatedAtAnnotation = "etcd.aenix.io/rotated-at"
+)
+
+// internalCATrustOverlap is how long the retired CA stays trusted after certificates are issued by the new CA,
+// so members load the new certificates from updated secret volumes before the retired CA is no longer trusted.
+const internalCATrustOverlap = 15 * time.Minute
+
+// internalCA is the state of the internal CA kept in its secret.
+type internalCA struct {
+	// signing CA issues certificates.
+	signing *pki.CA
+	// next CA is trusted, but does not sign certificates until members trusting it are rolled out.
+	next *pki.CA
+	// previous is the PEM encoded retired CA, trusted until the overlap after rotation passes.
+	previous  []byte
+	rotatedAt time.Time
+	// rotation is the value of the rotation annotation the CA was last rotated for.
+	rotation string
+}
+
+// trustBundle returns PEM encoded CAs members trust.
+func (ca *internalCA) trustBundle() []byte {
+	bundle := pki.EncodeCertificate(ca.signing.Certificate.Raw)
+	if ca.next != nil {
+		bundle = append(bundle, pki.EncodeCertificate(ca.next.Certificate.Raw)...)
+	}
+	return append(bundle, ca.previous...)
+}
+
+// ensureInternalCASecret loads the CA of the cluster and advances its rotation. The CA is generated if its secret
+// is missing or invalid. A year before the CA expires, or when rotation is requested by annotation, a next CA
+// is generated and added to the trust bundle; once all members are rolled out trusting it, it signs certificates
+// and the retired CA is trusted for the overlap, then dropped from the bundle, which rolls members again.
+func (r *EtcdClusterReconciler) ensureInternalCASecret(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (*internalCA, time.Duration, error) {
+	name := factory.GetInternalCASecretName(cluster)
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, 0, fmt.Errorf("cannot get secret %s: %w", name, err)
+	}
+	now := r.getClock().Now()
+	ca := loadInternalCA(secret, now)
+	requested := cluster.Annotations[etcdaenixiov1alpha1.RotateInternalCAAnnotation]
+	if ca == nil {
+		if secret.Data != nil {
+			log.FromContext(ctx).Info("internal CA is not valid, generating a new one", "secret", name)
+		}
+		signing, err := pki.NewCA(factory.GetInternalCACommonName(cluster), now, etcdaenixiov1alpha1.InternalCARootDuration)
+		if err != nil {
+			return nil, 0, err
+		}
+		// nothing trusts another CA yet, so a requested rotation is done
+		ca = &internalCA{signing: signing, rotation: requested}
+		if err := r.applyInternalCASecret(ctx, cluster, ca); err != nil {
+			return nil, 0, err
+		}
+		recordAction(ctx, "generated internal CA in secret %s", name)
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonInternalCACreated,
+			fmt.Sprintf("Generated CA signing certificates of the cluster in secret %s", name))
+		return ca, 0, nil
+	}
+
+	var requeueAfter time.Duration
+	switch {
+	case ca.next == nil && ca.previous == nil &&
+		(requested != ca.rotation || !now.Before(ca.signing.Certificate.NotAfter.Add(-etcdaenixiov1alpha1.InternalCARotateBefore))):
+		if ca.next, err = pki.NewCA(factory.GetInternalCACommonName(cluster), now,
+			etcdaenixiov1alpha1.InternalCARootDuration); err != nil {
+			return nil, 0, err
+		}
+		ca.rotation = requested
+		requeueAfter = tlsRolloutRetryInterval
+		recordAction(ctx, "started rotation of internal CA, members are rolled out to trust the next CA")
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonInternalCARotation,
+			"Started rotation of the internal CA, members are rolled out to trust both the current and the next CA")
+	case ca.next != nil:
+		// certificates of the next CA are issued only once all members trust it
+		rolledOut, err := r.isTLSRolledOut(ctx, cluster)
+		if err != nil {
+			return nil, 0, err
+		}
+		trusted, err := r.isTrustBundleIssued(ctx, cluster, ca.trustBundle())
+		if err != nil {
+			return nil, 0, err
+		}
+		if !trusted || !rolledOut {
+			recordSkipped(ctx, "rotation of internal CA: waiting for members to trust the next CA")
+			return ca, tlsRolloutRetryInterval, nil
+		}
+		ca.previous = pki.EncodeCertificate(ca.signing.Certificate.Raw)
+		ca.signing, ca.next, ca.rotatedAt = ca.next, nil, now
+		requeueAfter = internalCATrustOverlap
+		recordAction(ctx, "internal CA rotated, certificates are issued by the new CA")
+	case ca.previous != nil && ca.rotatedAt.IsZero():
+		// the overlap starts over rather than the retired CA is dropped early
+		ca.rotatedAt = now
+		requeueAfter = internalCATrustOverlap
+	case ca.previous != nil:
+		if wait := ca.rotatedAt.Add(internalCATrustOverlap).Sub(now); wait > 0 {
+			return ca, wait, nil
+		}
+		ca.previous = nil
+		recordAction(ctx, "retired the previous internal CA, members are rolled out to trust only the new CA")
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonInternalCARotation,
+			"Completed rotation of the internal CA, members are rolled out to trust only the new CA")
+	default:
+		return ca, 0, nil
+	}
+	if err := r.applyInternalCASecret(ctx, cluster, ca); err != nil {
+		return nil, 0, err
+	}
+	return ca, requeueAfter, nil
+}
+
+// loadInternalCA loads the CA state from its secret, nil if the signing CA is missing, invalid or expired.
+// The next CA is forgotten if it is invalid, so the rotation starts over.
+func loadInternalCA(secret *corev1.Secret, now time.Time) *internalCA {
+	signing, err := pki.LoadCA(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil || !now.Before(signing.Certificate.NotAfter) {
+		return nil
+	}
+	ca := &internalCA{
+		signing:  signing,
+		previous: secret.Data[previousCACertKey],
+		rotation: secret.Annotations[etcdaenixiov1alpha1.RotateInternalCAAnnotation],
+	}
+	if next, err := pki.LoadCA(secret.Data[nextCACertKey], secret.Data[nextCAKeyKey]); err == nil {
+		ca.next = next
+	}
+	if ca.previous != nil {
+		ca.rotatedAt, _ = time.Parse(time.RFC3339, secret.Annotations[internalCARotatedAtAnnotation])
+	}
+	return ca
+}
+
+// applyInternalCASecret stores the CA state in its secret.
+func (r *EtcdClusterReconciler) applyInternalCASecret(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	ca *internalCA,
+) error {
+	keyPEM, err := pki.EncodeKey(ca.signing.Key)
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:              pki.EncodeCertificate(ca.signing.Certificate.Raw),
+		corev1.TLSPrivateKeyKey:        keyPEM,
+		corev1.ServiceAccountRootCAKey: ca.trustBundle(),
+	}
+	if ca.next != nil {
+		if data[nextCAKeyKey], err = pki.EncodeKey(ca.next.Key); err != nil {
+			return err
+		}
+		data[nextCACertKey] = pki.EncodeCertificate(ca.next.Certificate.Raw)
+	}
+	annotations := map[string]string{}
+	if ca.rotation != "" {
+		annotations[etcdaenixiov1alpha1.RotateInternalCAAnnotation] = ca.rotation
+	}
+	if ca.previous != nil {
+		data[previousCACertKey] = ca.previous
+		annotations[internalCARotatedAtAnnotation] = ca.rotatedAt.UTC().Format(time.RFC3339)
+	}
+	return r.applyIssuedSecret(ctx, cluster, factory.GetInternalCASecretName(cluster), corev1.SecretTypeTLS,
+		annotations, data)
+}
+
+// isTrustBundleIssued returns true if all secrets certificates of the internal CA are issued into carry the bundle.
+func (r *EtcdClusterReconciler) isTrustBundleIssued(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	bundle []byte,
+) (bool, error) {
+	tlsSpec := cluster.Spec.Security.TLS
+	for _, name := range []string{tlsSpec.PeerSecret, tlsSpec.ServerSecret, tlsSpec.ClientSecret} {
+		if name == "" {
+			continue
+		}
+		data, err := r.getSecretData(ctx, cluster.Namespace, name)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(data[corev1.ServiceAccountRootCAKey], bundle) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getSecretData returns data of the secret, nil if it does not exist.
+func (r *EtcdClusterReconciler) getSecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get secret %s: %w", name, err)
+	}
+	if secret.Data == nil {
+		return map[string][]byte{}, nil
+	}
+	return secret.Data, nil
+}
+
+// applyIssuedSecret creates the secret controlled by the cluster or updates it if its data or annotations changed.
+// Secrets controlled by others are not taken over.
+func (r *EtcdClusterReconciler) applyIssuedSecret(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	name string,
+	secretType corev1.SecretType,
+	annotations map[string]string,
+	data map[string][]byte,
+) error {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, secret)
+	if errors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   cluster.Namespace,
+				Name:        name,
+				Labels:      factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+				Annotations: annotations,
+			},
+			Type: secretType,
+			Data: data,
+		}
+		if err := ctrl.SetControllerReference(cluster, secret, r.Scheme); err != nil {
+			return fmt.Errorf("cannot set controller reference: %w", err)
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("cannot create secret %s: %w", name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get secret %s: %w", name, err)
+	}
+	if owner := metav1.GetControllerOf(secret); owner == nil || owner.UID != cluster.UID {
+		return fmt.Errorf("secret %s is not controlled by the cluster, "+
+			"certificates of the internal CA are not issued into it", name)
+	}
+	if maps.EqualFunc(secret.Data, data, bytes.Equal) && maps.Equal(secret.Annotations, annotations) {
+		return nil
+	}
+	secret.Data = data
+	secret.Annotations = annotations
+	if err := r.Update(ctx, secret); err != nil {
+		return fmt.Errorf("cannot update secret %s: %w", name, err)
+	}
+	recordAction(ctx, "issued certificates of the internal CA into secret %s", name)
+	return nil
+}
+
+// certificateIssuer issues certificates signed by the internal CA and tracks when the earliest of them is renewed.
+type certificateIssuer struct {
+	ca          *pki.CA
+	now         time.Time
+	duration    time.Duration
+	renewBefore time.Duration
+	nextRenewal time.Time
+}
+
+// issue returns the existing certificate and key if they are still valid for the request, otherwise a new
+// certificate and key are issued.
+func (i *certificateIssuer) issue(certPEM, keyPEM []byte, request pki.Request) ([]byte, []byte, error) {
+	if cert, err := pki.ParseCertificate(certPEM); err == nil && isKeyPair(certPEM, keyPEM) && pki.IsIssuedFor(cert, i.ca, request) {
+		if renewal := pki.RenewalTime(cert, i.renewBefore); i.now.Before(renewal) {
+			i.trackRenewal(renewal)
+			return certPEM, keyPEM, nil
+		}
+	}
+	certPEM, keyPEM, err := i.ca.Issue(request, i.now, i.duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := pki.ParseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	i.trackRenewal(pki.RenewalTime(cert, i.renewBefore))
+	return certPEM, keyPEM, nil
+}
+
+func (i *certificateIssuer) trackRenewal(renewal time.Time) {
+	if i.nextRenewal.IsZero() || renewal.Before(i.nextRenewal) {
+		i.nextRenewal = renewal
+	}
+}
+
+// isKeyPair returns true if the private key matches the certificate.
+func isKeyPair(certPEM, keyPEM []byte) bool {
+	_, err := tls.X509KeyPair(certPEM, keyPEM)
+	return err == nil
+}
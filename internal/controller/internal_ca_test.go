@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/pki"
+)
+
+var _ = Describe("Internal CA", func() {
+	It("should keep certificates until they are due to renewal", func() {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		ca, err := pki.NewCA("test-ca", now, etcdaenixiov1alpha1.InternalCARootDuration)
+		Expect(err).NotTo(HaveOccurred())
+		request := factory.GetClientCertificateRequest()
+		issuer := &certificateIssuer{ca: ca, now: now, duration: 3 * time.Hour, renewBefore: time.Hour}
+
+		certPEM, keyPEM, err := issuer.issue(nil, nil, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issuer.nextRenewal).To(Equal(now.Add(2 * time.Hour)))
+
+		issuer = &certificateIssuer{ca: ca, now: now.Add(time.Hour), duration: 3 * time.Hour, renewBefore: time.Hour}
+		kept, _, err := issuer.issue(certPEM, keyPEM, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kept).To(Equal(certPEM))
+
+		issuer = &certificateIssuer{ca: ca, now: now.Add(2 * time.Hour), duration: 3 * time.Hour, renewBefore: time.Hour}
+		renewed, _, err := issuer.issue(certPEM, keyPEM, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renewed).NotTo(Equal(certPEM))
+		Expect(issuer.nextRenewal).To(Equal(now.Add(4 * time.Hour)))
+
+		// the key of another certificate is not kept
+		_, otherKeyPEM, err := issuer.issue(nil, nil, request)
+		Expect(err).NotTo(HaveOccurred())
+		reissued, _, err := issuer.issue(renewed, otherKeyPEM, request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reissued).NotTo(Equal(renewed))
+	})
+
+	It("should trust the next and the previous CA during rotation", func() {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		signing, err := pki.NewCA("test-ca", now, etcdaenixiov1alpha1.InternalCARootDuration)
+		Expect(err).NotTo(HaveOccurred())
+		next, err := pki.NewCA("test-ca", now, etcdaenixiov1alpha1.InternalCARootDuration)
+		Expect(err).NotTo(HaveOccurred())
+		signingPEM := pki.EncodeCertificate(signing.Certificate.Raw)
+		nextPEM := pki.EncodeCertificate(next.Certificate.Raw)
+
+		ca := &internalCA{signing: signing}
+		Expect(ca.trustBundle()).To(Equal(signingPEM))
+		ca.next = next
+		Expect(ca.trustBundle()).To(Equal(append(append([]byte{}, signingPEM...), nextPEM...)))
+		ca = &internalCA{signing: next, previous: signingPEM}
+		Expect(ca.trustBundle()).To(Equal(append(append([]byte{}, nextPEM...), signingPEM...)))
+	})
+
+	Context("with a cluster", func() {
+		var (
+			reconciler *EtcdClusterReconciler
+			clock      *clocktesting.FakePassiveClock
+			cluster    *etcdaenixiov1alpha1.EtcdCluster
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-internal-ca-"}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+
+			clock = clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+			reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Clock: clock}
+			cluster = &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name, UID: types.UID(uuid.NewString())},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas: ptr.To(int32(3)),
+					Security: &etcdaenixiov1alpha1.SecuritySpec{
+						TLS: etcdaenixiov1alpha1.TLSSpec{InternalCA: &etcdaenixiov1alpha1.InternalCASpec{}},
+					},
+				},
+			}
+			cluster.Default()
+		})
+
+		It("should issue certificates of members and the operator and renew them", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(Equal(etcdaenixiov1alpha1.DefaultInternalCADuration * 2 / 3))
+
+			caSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: "test-internal-ca"}, caSecret)).To(Succeed())
+			ca, err := pki.LoadCA(caSecret.Data[corev1.TLSCertKey], caSecret.Data[corev1.TLSPrivateKeyKey])
+			Expect(err).NotTo(HaveOccurred())
+
+			peer := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: "test-peer-tls"}, peer)).To(Succeed())
+			Expect(peer.Data).To(HaveKey(corev1.ServiceAccountRootCAKey))
+			for ordinal := range 3 {
+				podName := factory.GetMemberPodName(cluster, ordinal)
+				cert, err := pki.ParseCertificate(peer.Data[factory.GetMemberCertificateKey(podName)])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pki.IsIssuedFor(cert, ca, factory.GetMemberPeerCertificateRequest(cluster, podName))).To(BeTrue())
+				Expect(peer.Data).To(HaveKey(factory.GetMemberPrivateKeyKey(podName)))
+			}
+
+			server := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: "test-server-tls"}, server)).To(Succeed())
+			Expect(server.Type).To(Equal(corev1.SecretTypeTLS))
+			Expect(server.Data[corev1.ServiceAccountRootCAKey]).To(Equal(caSecret.Data[corev1.TLSCertKey]))
+
+			// certificates are kept until they are due to renewal
+			_, err = reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			kept := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(server), kept)).To(Succeed())
+			Expect(kept.ResourceVersion).To(Equal(server.ResourceVersion))
+
+			clock.SetTime(clock.Now().Add(requeueAfter))
+			_, err = reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			renewed := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(server), renewed)).To(Succeed())
+			Expect(renewed.Data[corev1.TLSCertKey]).NotTo(Equal(server.Data[corev1.TLSCertKey]))
+		})
+
+		It("should rotate the CA when requested", func(ctx SpecContext) {
+			// without members the rollout of the trust bundle is done at once
+			cluster.Spec.Replicas = ptr.To(int32(0))
+			_, err := reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			key := client.ObjectKey{Namespace: cluster.Namespace, Name: "test-internal-ca"}
+			caSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, key, caSecret)).To(Succeed())
+			retired := caSecret.Data[corev1.TLSCertKey]
+
+			cluster.Annotations = map[string]string{etcdaenixiov1alpha1.RotateInternalCAAnnotation: "1"}
+			_, err = reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, key, caSecret)).To(Succeed())
+			Expect(caSecret.Data[corev1.TLSCertKey]).To(Equal(retired))
+			Expect(caSecret.Data).To(HaveKey(nextCACertKey))
+			next := caSecret.Data[nextCACertKey]
+			server := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: "test-server-tls"}, server)).To(Succeed())
+			Expect(server.Data[corev1.ServiceAccountRootCAKey]).To(Equal(append(append([]byte{}, retired...), next...)))
+
+			// the next CA signs certificates once members trust it, the retired CA is trusted for the overlap
+			requeueAfter, err := reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(Equal(internalCATrustOverlap))
+			Expect(k8sClient.Get(ctx, key, caSecret)).To(Succeed())
+			Expect(caSecret.Data[corev1.TLSCertKey]).To(Equal(next))
+			Expect(caSecret.Data[previousCACertKey]).To(Equal(retired))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(server), server)).To(Succeed())
+			Expect(server.Data[corev1.ServiceAccountRootCAKey]).To(Equal(append(append([]byte{}, next...), retired...)))
+			ca, err := pki.LoadCA(caSecret.Data[corev1.TLSCertKey], caSecret.Data[corev1.TLSPrivateKeyKey])
+			Expect(err).NotTo(HaveOccurred())
+			cert, err := pki.ParseCertificate(server.Data[corev1.TLSCertKey])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pki.IsIssuedFor(cert, ca, factory.GetServerCertificateRequest(cluster, nil))).To(BeTrue())
+
+			clock.SetTime(clock.Now().Add(internalCATrustOverlap))
+			_, err = reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, key, caSecret)).To(Succeed())
+			Expect(caSecret.Data).NotTo(HaveKey(previousCACertKey))
+			Expect(caSecret.Data[corev1.ServiceAccountRootCAKey]).To(Equal(next))
+		})
+
+		It("should not issue certificates into secrets of others", func(ctx SpecContext) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: "test-server-tls"}}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			_, err := reconciler.ensureInternalCA(ctx, cluster)
+			Expect(err).To(MatchError(ContainSubstring("secret test-server-tls is not controlled by the cluster")))
+		})
+	})
+})
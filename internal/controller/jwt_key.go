@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	eventReasonJWTKeyCreated = "JWTKeyCreated"
+	eventReasonJWTKeyRotated = "JWTKeyRotated"
+)
+
+// ensureJWTKey generates the key pair JSON Web Tokens are signed with into the JWT key secret before members mount
+// it. A missing or invalid key, e.g. of another sign method, is replaced right away. A key rotated by annotation
+// is replaced only once all members are healthy and run with the current key, so members are restarted by
+// a single rollout at a time, each waiting for the previous one to be ready. Returns the duration after which
+// the rotation is checked again.
+func (r *EtcdClusterReconciler) ensureJWTKey(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+) (time.Duration, error) {
+	if !cluster.UsesJWT() {
+		return 0, nil
+	}
+	name := factory.GetJWTKeySecretName(cluster)
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, secret)
+	if client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("cannot get secret %s: %w", name, err)
+	}
+	found := err == nil
+	if found {
+		if owner := metav1.GetControllerOf(secret); owner == nil || owner.UID != cluster.UID {
+			return 0, fmt.Errorf("secret %s is not controlled by the cluster, the JWT key is not generated into it", name)
+		}
+	}
+	signMethod := cluster.Spec.Security.Auth.JWT.GetSignMethod()
+	requested := cluster.Annotations[etcdaenixiov1alpha1.RotateJWTKeyAnnotation]
+	valid := factory.IsJWTKeyValid(signMethod, secret.Data[factory.JWTPrivateKeyKey], secret.Data[factory.JWTPublicKeyKey])
+	if valid {
+		if requested == secret.Annotations[etcdaenixiov1alpha1.RotateJWTKeyAnnotation] {
+			return 0, nil
+		}
+		ready, err := r.isJWTKeyRotationReady(ctx, cluster, conn)
+		if err != nil {
+			return 0, err
+		}
+		if !ready {
+			recordSkipped(ctx, "rotation of JWT key: waiting for all members to be healthy with the current key")
+			return tlsRolloutRetryInterval, nil
+		}
+	}
+
+	privatePEM, publicPEM, err := factory.NewJWTKey(signMethod)
+	if err != nil {
+		return 0, err
+	}
+	// a generated key needs no rotation, the requested one is done
+	secret.Annotations = map[string]string{}
+	if requested != "" {
+		secret.Annotations[etcdaenixiov1alpha1.RotateJWTKeyAnnotation] = requested
+	}
+	secret.Data = map[string][]byte{factory.JWTPrivateKeyKey: privatePEM, factory.JWTPublicKeyKey: publicPEM}
+	if !found {
+		secret.ObjectMeta = metav1.ObjectMeta{
+			Namespace:   cluster.Namespace,
+			Name:        name,
+			Labels:      factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy(),
+			Annotations: secret.Annotations,
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		if err := ctrl.SetControllerReference(cluster, secret, r.Scheme); err != nil {
+			return 0, fmt.Errorf("cannot set controller reference: %w", err)
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return 0, fmt.Errorf("cannot create secret %s: %w", name, err)
+		}
+		recordAction(ctx, "generated JWT key in secret %s", name)
+		r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonJWTKeyCreated,
+			fmt.Sprintf("Generated the key JSON Web Tokens are signed with in secret %s", name))
+		return 0, nil
+	}
+	if err := r.Update(ctx, secret); err != nil {
+		return 0, fmt.Errorf("cannot update secret %s: %w", name, err)
+	}
+	if valid {
+		recordAction(ctx, "rotated JWT key in secret %s, members are restarted one at a time", name)
+	} else {
+		recordAction(ctx, "replaced invalid JWT key in secret %s", name)
+	}
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonJWTKeyRotated, fmt.Sprintf(
+		"Replaced the key JSON Web Tokens are signed with in secret %s, members are restarted one at a time "+
+			"and tokens signed by the previous key are rejected", name))
+	return 0, nil
+}
+
+// isJWTKeyRotationReady returns true if all members are ready, run with the key of the secret and are healthy
+// according to etcd, so a previous rotation is rolled out and restarts never cost the quorum.
+func (r *EtcdClusterReconciler) isJWTKeyRotationReady(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+) (bool, error) {
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	checksum, err := factory.GetJWTKeyChecksum(ctx, cluster, r.Client)
+	if err != nil {
+		return false, err
+	}
+	if !isJWTKeyRolledOut(pods, checksum, int(ptr.Deref(cluster.Spec.Replicas, 0))) {
+		return false, nil
+	}
+	return r.areAllMembersHealthy(ctx, cluster, conn, pods)
+}
+
+// isJWTKeyRolledOut returns true if the given number of members are ready and run with the key of the checksum.
+func isJWTKeyRolledOut(pods []corev1.Pod, checksum string, replicas int) bool {
+	rolledOut := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() && isPodReady(&pods[i]) &&
+			pods[i].Annotations[factory.JWTKeyChecksumAnnotation] == checksum {
+			rolledOut++
+		}
+	}
+	return rolledOut == replicas
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("JWT key rotation", func() {
+	newPod := func(checksum string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{factory.JWTKeyChecksumAnnotation: checksum}},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+
+	It("should wait for all members to be ready with the current key", func() {
+		pods := []corev1.Pod{newPod("a", true), newPod("a", true), newPod("b", true)}
+		Expect(isJWTKeyRolledOut(pods, "a", 3)).To(BeFalse())
+		pods[2] = newPod("a", false)
+		Expect(isJWTKeyRolledOut(pods, "a", 3)).To(BeFalse())
+		pods[2] = newPod("a", true)
+		Expect(isJWTKeyRolledOut(pods, "a", 3)).To(BeTrue())
+	})
+})
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// getMemberHTTP sends a GET request to an HTTP endpoint of an etcd member and passes the response to handle.
+// Members are requested rarely and with TLS settings of their cluster, so connections are not kept alive.
+func getMemberHTTP(ctx context.Context, tlsConfig *tls.Config, url string, handle func(*http.Response) error) error {
+	transport := &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}
+	defer transport.CloseIdleConnections()
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return handle(resp)
+}
+
+// getMemberMetric returns value of the metric without labels from the metrics endpoint of an etcd member.
+func getMemberMetric(ctx context.Context, tlsConfig *tls.Config, url, metric string) (float64, error) {
+	var value float64
+	err := getMemberHTTP(ctx, tlsConfig, url, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected metrics response status %s", resp.Status)
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			text, found := strings.CutPrefix(scanner.Text(), metric+" ")
+			if !found {
+				continue
+			}
+			var err error
+			if value, err = strconv.ParseFloat(strings.TrimSpace(text), 64); err != nil {
+				return fmt.Errorf("cannot parse %s metric: %w", metric, err)
+			}
+			return nil
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("metric %s not found", metric)
+	})
+	return value, err
+}
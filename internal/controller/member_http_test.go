@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Member metrics", func() {
+	var server *httptest.Server
+	var status int
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+			_, _ = fmt.Fprint(w, "# TYPE etcd_server_quota_backend_bytes gauge\n"+
+				"etcd_server_quota_backend_bytes 2.147483648e+09\n"+
+				"etcd_server_has_leader 1\n")
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	getMetric := func(metric string) (float64, error) {
+		tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+		return getMemberMetric(context.Background(), tlsConfig, server.URL+"/metrics", metric)
+	}
+
+	It("should parse the metric value", func() {
+		Expect(getMetric(quotaBackendBytesMetric)).To(BeEquivalentTo(2147483648))
+	})
+	It("should fail if the metric is missing", func() {
+		_, err := getMetric("etcd_server_is_leader")
+		Expect(err).To(MatchError("metric etcd_server_is_leader not found"))
+	})
+	It("should fail if metrics are not served", func() {
+		status = http.StatusServiceUnavailable
+		_, err := getMetric(quotaBackendBytesMetric)
+		Expect(err).To(MatchError(ContainSubstring("unexpected metrics response status 503")))
+	})
+})
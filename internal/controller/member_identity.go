@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const eventReasonForeignMemberData = "ForeignMemberData"
+
+// updateMemberIdentity records the etcd cluster ID once a quorum of members reports it, maps member pods to their
+// member IDs, annotates volume claims of members with IDs of their member and cluster and reports members whose
+// data belongs to another cluster. IDs of members must be observed by updateVersionStatus before. IDs are
+// forgotten during a storage migration, as the restored snapshot starts a cluster with new IDs.
+func (r *EtcdClusterReconciler) updateMemberIdentity(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	if cluster.Status.StorageMigration != nil {
+		cluster.Status.ClusterID = ""
+		cluster.Status.MemberIdentities = nil
+	}
+	if cluster.Status.ClusterID == "" && cluster.Status.StorageMigration == nil {
+		cluster.Status.ClusterID = getQuorumClusterID(cluster)
+	}
+	if cluster.Status.ClusterID == "" {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionForeignMemberData)
+		return nil
+	}
+	updateMemberIdentities(ctx, cluster)
+	var foreign []string
+	for _, member := range cluster.Status.Members {
+		if member.ClusterID == "" {
+			continue
+		}
+		if member.ClusterID != cluster.Status.ClusterID {
+			foreign = append(foreign, fmt.Sprintf("%s (cluster %s)", member.Name, member.ClusterID))
+			continue
+		}
+		if err := r.annotateMemberClaim(ctx, cluster, member); err != nil {
+			return err
+		}
+	}
+	if len(foreign) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionForeignMemberData)
+		return nil
+	}
+	message := fmt.Sprintf("Members %s keep data of another etcd cluster than %s, they are not reported ready; "+
+		"delete their volume claims to rejoin them with empty data", strings.Join(foreign, ", "), cluster.Status.ClusterID)
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionForeignMemberData) {
+		r.recordEvent(cluster, corev1.EventTypeWarning, eventReasonForeignMemberData, message)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionForeignMemberData).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeClusterIDMismatch)).
+		WithMessage(message).
+		Complete())
+	return nil
+}
+
+// updateMemberIdentities records IDs of members of the recorded cluster by their pods. Identities of pods which
+// do not exist are kept, unless their ordinal is beyond spec.replicas, so recreated pods are not taken for new members.
+func updateMemberIdentities(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	observed := make(map[string]bool, len(cluster.Status.Members))
+	for _, member := range cluster.Status.Members {
+		observed[member.Name] = true
+		if member.ID == "" || member.ClusterID != cluster.Status.ClusterID {
+			continue
+		}
+		setMemberIdentity(ctx, cluster, member.Name, member.ID)
+	}
+	cluster.Status.MemberIdentities = slices.DeleteFunc(cluster.Status.MemberIdentities,
+		func(identity etcdaenixiov1alpha1.MemberIdentity) bool {
+			return !observed[identity.Name] && identity.Ordinal >= ptr.Deref(cluster.Spec.Replicas, 0)
+		})
+}
+
+// setMemberIdentity maps the member pod to the member ID. The ID changes when the member is replaced.
+func setMemberIdentity(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster, podName, id string) {
+	ordinal, err := podOrdinal(podName)
+	if err != nil {
+		return
+	}
+	identities := cluster.Status.MemberIdentities
+	i := slices.IndexFunc(identities, func(identity etcdaenixiov1alpha1.MemberIdentity) bool {
+		return identity.Name == podName
+	})
+	if i >= 0 {
+		if identities[i].ID != id {
+			log.FromContext(ctx).Info("member pod runs another etcd member than before",
+				"member", podName, "previous_id", identities[i].ID, "id", id)
+			identities[i].ID = id
+		}
+		return
+	}
+	identities = append(identities, etcdaenixiov1alpha1.MemberIdentity{Name: podName, Ordinal: int32(ordinal), ID: id})
+	slices.SortFunc(identities, func(a, b etcdaenixiov1alpha1.MemberIdentity) int {
+		return cmp.Compare(a.Ordinal, b.Ordinal)
+	})
+	cluster.Status.MemberIdentities = identities
+}
+
+// getRecordedMemberID returns the ID of the etcd member the pod ran as of the last observation, empty if unknown.
+func getRecordedMemberID(cluster *etcdaenixiov1alpha1.EtcdCluster, podName string) string {
+	for _, identity := range cluster.Status.MemberIdentities {
+		if identity.Name == podName {
+			return identity.ID
+		}
+	}
+	return ""
+}
+
+// getQuorumClusterID returns the cluster ID reported by a quorum of members, or an empty string if there is no such ID.
+func getQuorumClusterID(cluster *etcdaenixiov1alpha1.EtcdCluster) string {
+	counts := map[string]int{}
+	for _, member := range cluster.Status.Members {
+		if member.ClusterID != "" {
+			counts[member.ClusterID]++
+		}
+	}
+	quorum := int(ptr.Deref(cluster.Spec.Replicas, 0))/2 + 1
+	for id, count := range counts {
+		if count >= quorum {
+			return id
+		}
+	}
+	return ""
+}
+
+// annotateMemberClaim sets IDs of the member and its cluster on the data volume claim of the member.
+func (r *EtcdClusterReconciler) annotateMemberClaim(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	member etcdaenixiov1alpha1.MemberStatus,
+) error {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	claim := &corev1.PersistentVolumeClaim{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetMemberPVCName(cluster, member.Name)}
+	if err := r.Get(ctx, key, claim); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if claim.Annotations[etcdaenixiov1alpha1.ClusterIDAnnotation] == member.ClusterID &&
+		claim.Annotations[etcdaenixiov1alpha1.MemberIDAnnotation] == member.ID {
+		return nil
+	}
+	patch := client.MergeFrom(claim.DeepCopy())
+	if claim.Annotations == nil {
+		claim.Annotations = map[string]string{}
+	}
+	claim.Annotations[etcdaenixiov1alpha1.ClusterIDAnnotation] = member.ClusterID
+	claim.Annotations[etcdaenixiov1alpha1.MemberIDAnnotation] = member.ID
+	if err := r.Patch(ctx, claim, patch); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot annotate volume claim of member %s: %w", member.Name, err)
+	}
+	return nil
+}
+
+// getForeignClaimClusterID returns the cluster ID the volume claim of the member is annotated with if it differs
+// from the given cluster ID, so the member would start with data of another cluster.
+func (r *EtcdClusterReconciler) getForeignClaimClusterID(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	podName string,
+	clusterID uint64,
+) (string, error) {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return "", nil
+	}
+	claim := &corev1.PersistentVolumeClaim{}
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetMemberPVCName(cluster, podName)}
+	if err := r.Get(ctx, key, claim); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if id, ok := claim.Annotations[etcdaenixiov1alpha1.ClusterIDAnnotation]; ok && id != strconv.FormatUint(clusterID, 16) {
+		return id, nil
+	}
+	return "", nil
+}
+
+// isForeignMember returns true if the member reports a cluster ID other than the one recorded in the status.
+func isForeignMember(cluster *etcdaenixiov1alpha1.EtcdCluster, status *clientv3.StatusResponse) bool {
+	return cluster.Status.ClusterID != "" && status.Header != nil &&
+		strconv.FormatUint(status.Header.ClusterId, 16) != cluster.Status.ClusterID
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Member identity", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				Storage: etcdaenixiov1alpha1.StorageSpec{
+					EmptyDir:  &corev1.EmptyDirVolumeSource{},
+					Ephemeral: true,
+				},
+			},
+		}
+	})
+
+	It("should record the cluster ID reported by a quorum of members", func() {
+		cluster.Status.Members = []etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", ClusterID: "a1"},
+			{Name: "test-1"},
+			{Name: "test-2", ClusterID: "b2"},
+		}
+		Expect(getQuorumClusterID(cluster)).To(BeEmpty())
+
+		cluster.Status.Members[1].ClusterID = "a1"
+		Expect(getQuorumClusterID(cluster)).To(Equal("a1"))
+	})
+
+	It("should report members with data of another cluster", func(ctx SpecContext) {
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &EtcdClusterReconciler{Recorder: recorder}
+		cluster.Status.Members = []etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", ID: "1", ClusterID: "a1"},
+			{Name: "test-1", ID: "2", ClusterID: "a1"},
+			{Name: "test-2", ID: "3", ClusterID: "b2"},
+		}
+
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.ClusterID).To(Equal("a1"))
+		cond := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionForeignMemberData)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeClusterIDMismatch)))
+		Expect(cond.Message).To(ContainSubstring("test-2 (cluster b2)"))
+		Expect(recorder.Events).To(HaveLen(1))
+
+		// the event is not repeated while members stay foreign
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(recorder.Events).To(HaveLen(1))
+
+		cluster.Status.Members[2].ClusterID = "a1"
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions,
+			etcdaenixiov1alpha1.EtcdConditionForeignMemberData)).To(BeNil())
+	})
+
+	It("should keep identities of members while their pods are recreated", func(ctx SpecContext) {
+		reconciler := &EtcdClusterReconciler{}
+		cluster.Status.Members = []etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", ID: "1", ClusterID: "a1"},
+			{Name: "test-1", ID: "2", ClusterID: "a1"},
+			{Name: "test-2", ID: "3", ClusterID: "b2"},
+			{Name: "test-3", ID: "4", ClusterID: "a1"},
+		}
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.MemberIdentities).To(Equal([]etcdaenixiov1alpha1.MemberIdentity{
+			{Name: "test-0", Ordinal: 0, ID: "1"},
+			{Name: "test-1", Ordinal: 1, ID: "2"},
+			{Name: "test-3", Ordinal: 3, ID: "4"},
+		}))
+
+		// pods of members are gone, members beyond replicas are scaled down
+		cluster.Status.Members = []etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", ID: "1", ClusterID: "a1"},
+			{Name: "test-2", ID: "3", ClusterID: "a1"},
+		}
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.MemberIdentities).To(Equal([]etcdaenixiov1alpha1.MemberIdentity{
+			{Name: "test-0", Ordinal: 0, ID: "1"},
+			{Name: "test-1", Ordinal: 1, ID: "2"},
+			{Name: "test-2", Ordinal: 2, ID: "3"},
+		}))
+		Expect(getRecordedMemberID(cluster, "test-1")).To(Equal("2"))
+
+		// the member of the recreated pod was replaced
+		cluster.Status.Members = append(cluster.Status.Members, etcdaenixiov1alpha1.MemberStatus{
+			Name: "test-1", ID: "5", ClusterID: "a1",
+		})
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(getRecordedMemberID(cluster, "test-1")).To(Equal("5"))
+	})
+
+	It("should forget the cluster ID during a storage migration", func(ctx SpecContext) {
+		reconciler := &EtcdClusterReconciler{}
+		cluster.Status.ClusterID = "a1"
+		cluster.Status.MemberIdentities = []etcdaenixiov1alpha1.MemberIdentity{{Name: "test-0", ID: "1"}}
+		cluster.Status.StorageMigration = &etcdaenixiov1alpha1.StorageMigrationStatus{}
+		cluster.Status.Members = []etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", ClusterID: "b2"},
+			{Name: "test-1", ClusterID: "a1"},
+			{Name: "test-2", ClusterID: "a1"},
+		}
+		Expect(reconciler.updateMemberIdentity(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.ClusterID).To(BeEmpty())
+		Expect(cluster.Status.MemberIdentities).To(BeEmpty())
+		Expect(cluster.Status.Conditions).To(BeEmpty())
+	})
+})
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	// LabelMemberID is set on member pods to the hex encoded etcd member ID.
+	LabelMemberID = "etcd.aenix.io/member-id"
+	// LabelMemberRole is set on member pods to leader, voter or learner as of the last observation.
+	LabelMemberRole = factory.LabelMemberRole
+	// LabelZone is set on member pods to the zone of their node.
+	LabelZone = corev1.LabelTopologyZone
+
+	memberRoleLeader  = factory.MemberRoleLeader
+	memberRoleVoter   = "voter"
+	memberRoleLearner = "learner"
+)
+
+const (
+	eventReasonLeaderChanged = "LeaderChanged"
+
+	// leaderPollInterval is the longest time a leader change is not reflected by the leader service.
+	leaderPollInterval = 30 * time.Second
+)
+
+// updateMemberLabels labels member pods with their etcd member ID, role and zone of their node.
+// Labels of members which can not be observed are kept as they are.
+func (r *EtcdClusterReconciler) updateMemberLabels(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) error {
+	desired := make(map[string]map[string]string, len(pods))
+	for i := range pods {
+		if pods[i].Spec.NodeName == "" || !pods[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		labels := map[string]string{}
+		node := &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: pods[i].Spec.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if zone, ok := node.Labels[corev1.LabelTopologyZone]; ok {
+			labels[LabelZone] = zone
+		}
+		desired[pods[i].Name] = labels
+	}
+
+	for name, memberLabels := range r.observeMembers(ctx, cluster, conn, pods) {
+		if labels, ok := desired[name]; ok {
+			for key, value := range memberLabels {
+				labels[key] = value
+			}
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		labels, ok := desired[pod.Name]
+		if !ok || !needsLabels(pod, labels) {
+			continue
+		}
+		// the leader service follows the label, so the change of the leader is reported with it
+		if previous, ok := pod.Labels[LabelMemberRole]; ok && previous != memberRoleLeader &&
+			labels[LabelMemberRole] == memberRoleLeader {
+			r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonLeaderChanged,
+				fmt.Sprintf("member %s became the leader", pod.Name))
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		for key, value := range labels {
+			pod.Labels[key] = value
+		}
+		if err := r.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeMembers returns member ID and role labels of members reported by etcd, keyed by member name.
+// Nothing is returned if etcd can not be reached.
+func (r *EtcdClusterReconciler) observeMembers(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) map[string]map[string]string {
+	logger := log.FromContext(ctx)
+	if len(getMemberEndpoints(cluster, pods, nil)) == 0 {
+		return nil
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		logger.Error(err, "cannot create etcd client")
+		return nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd members", "error", err.Error())
+		return nil
+	}
+	var leader uint64
+	for _, endpoint := range cli.Endpoints() {
+		status, err := cli.Status(reqCtx, endpoint)
+		if err == nil {
+			leader = status.Leader
+			break
+		}
+	}
+	return memberLabels(members, leader)
+}
+
+func memberLabels(members *clientv3.MemberListResponse, leader uint64) map[string]map[string]string {
+	result := make(map[string]map[string]string, len(members.Members))
+	for _, member := range members.Members {
+		// members added but not started yet have no name
+		if member.Name == "" {
+			continue
+		}
+		role := memberRoleVoter
+		switch {
+		case member.IsLearner:
+			role = memberRoleLearner
+		case member.ID == leader:
+			role = memberRoleLeader
+		}
+		result[member.Name] = map[string]string{
+			LabelMemberID:   strconv.FormatUint(member.ID, 16),
+			LabelMemberRole: role,
+		}
+	}
+	return result
+}
+
+func needsLabels(pod *corev1.Pod, labels map[string]string) bool {
+	for key, value := range labels {
+		if current, ok := pod.Labels[key]; !ok || current != value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Member labels", func() {
+	It("should label members with their ID and role", func() {
+		members := &clientv3.MemberListResponse{Members: []*etcdserverpb.Member{
+			{ID: 0x1a, Name: "test-0"},
+			{ID: 0x2b, Name: "test-1"},
+			{ID: 0x3c, Name: "test-2", IsLearner: true},
+			{ID: 0x4d},
+		}}
+		Expect(memberLabels(members, 0x2b)).To(Equal(map[string]map[string]string{
+			"test-0": {LabelMemberID: "1a", LabelMemberRole: memberRoleVoter},
+			"test-1": {LabelMemberID: "2b", LabelMemberRole: memberRoleLeader},
+			"test-2": {LabelMemberID: "3c", LabelMemberRole: memberRoleLearner},
+		}))
+	})
+
+	It("should detect outdated labels only", func() {
+		pod := &corev1.Pod{}
+		pod.Labels = map[string]string{LabelMemberID: "1a", LabelMemberRole: memberRoleLeader, "other": "value"}
+		Expect(needsLabels(pod, map[string]string{LabelMemberID: "1a"})).To(BeFalse())
+		Expect(needsLabels(pod, map[string]string{LabelMemberRole: memberRoleVoter})).To(BeTrue())
+		Expect(needsLabels(pod, map[string]string{LabelZone: "zone-a"})).To(BeTrue())
+	})
+})
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	// memberReadinessRetryInterval is how often members with the readiness gate not set are checked again,
+	// as a member becoming healthy does not change its pod.
+	memberReadinessRetryInterval = 5 * time.Second
+
+	reasonMemberHealthy   = "MemberHealthy"
+	reasonMemberUnhealthy = "MemberUnhealthy"
+)
+
+// updateMemberReadinessGates sets the readiness gate condition of member pods to the etcd health of members.
+// A member is healthy if it answers status requests with a known leader and no errors and belongs to the recorded
+// etcd cluster. Members which can not be queried individually through the configured operator connection keep
+// their condition. Returns the duration after which members with the gate not set are checked again.
+func (r *EtcdClusterReconciler) updateMemberReadinessGates(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) (time.Duration, error) {
+	if !cluster.HasMemberReadinessGate() {
+		return 0, nil
+	}
+	var gated []*corev1.Pod
+	for i := range pods {
+		if hasReadinessGate(&pods[i], factory.MemberHealthyCondition) && pods[i].DeletionTimestamp.IsZero() {
+			gated = append(gated, &pods[i])
+		}
+	}
+	if len(gated) == 0 {
+		return 0, nil
+	}
+
+	statuses := map[string]*clientv3.StatusResponse{}
+	if endpoints := getMemberEndpoints(cluster, pods, nil); len(endpoints) > 0 {
+		cli, err := conn.Client(ctx, pods)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "cannot create etcd client")
+			return memberReadinessRetryInterval, nil
+		}
+		statuses = getMemberStatuses(ctx, cli, cluster, pods)
+	}
+
+	var requeueAfter time.Duration
+	for _, pod := range gated {
+		status, found := statuses[pod.Name]
+		if !found && cluster.GetOperatorConnectionMode() != etcdaenixiov1alpha1.OperatorConnectionPodDNS {
+			requeueAfter = memberReadinessRetryInterval
+			continue
+		}
+		healthy := found && status.Leader != 0 && len(status.Errors) == 0 && !isForeignMember(cluster, status)
+		if !healthy {
+			requeueAfter = memberReadinessRetryInterval
+		}
+		if err := r.setMemberHealthyCondition(ctx, pod, healthy); err != nil {
+			return 0, err
+		}
+	}
+	return requeueAfter, nil
+}
+
+// setMemberHealthyCondition updates the readiness gate condition of the pod if it changed.
+func (r *EtcdClusterReconciler) setMemberHealthyCondition(ctx context.Context, pod *corev1.Pod, healthy bool) error {
+	condition := corev1.PodCondition{
+		Type:               factory.MemberHealthyCondition,
+		Status:             corev1.ConditionFalse,
+		Reason:             reasonMemberUnhealthy,
+		LastTransitionTime: metav1.NewTime(r.getClock().Now()),
+	}
+	if healthy {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = reasonMemberHealthy
+	}
+	patch := client.StrategicMergeFrom(pod.DeepCopy())
+	found := false
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type != condition.Type {
+			continue
+		}
+		if pod.Status.Conditions[i].Status == condition.Status {
+			return nil
+		}
+		pod.Status.Conditions[i] = condition
+		found = true
+	}
+	if !found {
+		pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	}
+	log.FromContext(ctx).V(2).Info("setting member readiness gate", "pod_name", pod.Name, "healthy", healthy)
+	return client.IgnoreNotFound(r.Status().Patch(ctx, pod, patch))
+}
+
+func hasReadinessGate(pod *corev1.Pod, conditionType corev1.PodConditionType) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == conditionType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Member readiness gate", func() {
+	It("should set the readiness gate condition of member pods", func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-readiness-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "test-0"},
+			Spec: corev1.PodSpec{
+				Containers:     []corev1.Container{{Name: "etcd", Image: "quay.io/coreos/etcd:v3.5.12"}},
+				ReadinessGates: []corev1.PodReadinessGate{{ConditionType: factory.MemberHealthyCondition}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+		Expect(hasReadinessGate(pod, factory.MemberHealthyCondition)).To(BeTrue())
+
+		reconciler := &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		Expect(reconciler.setMemberHealthyCondition(ctx, pod, false)).To(Succeed())
+		Expect(reconciler.setMemberHealthyCondition(ctx, pod, true)).To(Succeed())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+		Expect(pod.Status.Conditions).To(ConsistOf(And(
+			HaveField("Type", factory.MemberHealthyCondition),
+			HaveField("Status", corev1.ConditionTrue),
+			HaveField("Reason", reasonMemberHealthy),
+		)))
+	})
+})
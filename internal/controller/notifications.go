@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/notify"
+)
+
+const notificationTimeout = 10 * time.Second
+
+// clusterEvent is a critical event currently active in the cluster.
+type clusterEvent struct {
+	event   etcdaenixiov1alpha1.NotificationEvent
+	message string
+}
+
+// sendNotifications sends critical events of the cluster to its notification webhooks once per occurrence.
+// Failures are logged and retried on the next reconciliation, they never fail the reconciliation.
+func (r *EtcdClusterReconciler) sendNotifications(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) {
+	if r.Notifications == nil || cluster.Spec.Notifications == nil {
+		return
+	}
+	logger := log.FromContext(ctx)
+	events := r.getClusterEvents(ctx, cluster, pods)
+	for _, webhook := range cluster.Spec.Notifications.Webhooks {
+		messages := make(map[etcdaenixiov1alpha1.NotificationEvent]string, len(events))
+		var subscribed []etcdaenixiov1alpha1.NotificationEvent
+		for _, e := range events {
+			if webhook.Subscribes(e.event) {
+				subscribed = append(subscribed, e.event)
+				messages[e.event] = e.message
+			}
+		}
+		key := notify.WebhookKey(cluster.Namespace, cluster.Name, webhook.Name)
+		pending := r.Notifications.Observe(key, subscribed)
+		if len(pending) == 0 {
+			continue
+		}
+		url, err := r.getSecretValue(ctx, cluster.Namespace, webhook.URLSecretRef)
+		if err != nil {
+			logger.Error(err, "cannot get notification webhook URL", "webhook", webhook.Name)
+			continue
+		}
+		for _, event := range pending {
+			notification := notify.Notification{
+				Namespace: cluster.Namespace,
+				Cluster:   cluster.Name,
+				Event:     event,
+				Message:   messages[event],
+				Time:      r.getClock().Now().UTC(),
+			}
+			sendCtx, cancel := context.WithTimeout(ctx, notificationTimeout)
+			err := notify.Send(sendCtx, r.NotificationClient, url, webhook.Format, notification)
+			cancel()
+			if err != nil {
+				logger.Error(err, "cannot send notification", "webhook", webhook.Name, "event", event)
+				continue
+			}
+			logger.Info("notification sent", "webhook", webhook.Name, "event", event)
+			r.Notifications.MarkSent(key, event)
+		}
+	}
+}
+
+// getClusterEvents returns critical events currently active in the cluster.
+func (r *EtcdClusterReconciler) getClusterEvents(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) []clusterEvent {
+	var events []clusterEvent
+	if message, lost := getQuorumLoss(cluster, pods); lost {
+		events = append(events, clusterEvent{etcdaenixiov1alpha1.NotificationEventQuorumLost, message})
+	}
+	if cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionUpgradeFailed); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		events = append(events, clusterEvent{etcdaenixiov1alpha1.NotificationEventUpgradeFailed, cond.Message})
+	}
+	if cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionBackupNotConfigured); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		events = append(events, clusterEvent{etcdaenixiov1alpha1.NotificationEventBackupFailed, cond.Message})
+	}
+	if subscribesTo(cluster, etcdaenixiov1alpha1.NotificationEventDataCorruption) {
+		if message, corrupted := getDataCorruption(cluster); corrupted {
+			events = append(events, clusterEvent{etcdaenixiov1alpha1.NotificationEventDataCorruption, message})
+		}
+	}
+	return events
+}
+
+// getQuorumLoss reports initialized clusters with less than a quorum of ready members.
+func getQuorumLoss(cluster *etcdaenixiov1alpha1.EtcdCluster, pods []corev1.Pod) (string, bool) {
+	ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+	if ready == nil || ready.Reason == string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum) ||
+		ptr.Deref(cluster.Spec.Replicas, 0) == 0 {
+		return "", false
+	}
+	readyMembers := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() && isPodReady(&pods[i]) {
+			readyMembers++
+		}
+	}
+	quorum := cluster.CalculateQuorumSize()
+	if readyMembers >= quorum {
+		return "", false
+	}
+	return fmt.Sprintf("%d of %d members are ready, quorum requires %d",
+		readyMembers, ptr.Deref(cluster.Spec.Replicas, 0), quorum), true
+}
+
+// getDataCorruption reports members raising the CORRUPT alarm mirrored in cluster status.
+func getDataCorruption(cluster *etcdaenixiov1alpha1.EtcdCluster) (string, bool) {
+	members := alarmMembers(cluster.Status.Alarms, etcdaenixiov1alpha1.AlarmTypeCorrupt)
+	if len(members) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("members %s raised the CORRUPT alarm", strings.Join(members, ", ")), true
+}
+
+// subscribesTo returns true if any notification webhook of the cluster is notified about the event.
+func subscribesTo(cluster *etcdaenixiov1alpha1.EtcdCluster, event etcdaenixiov1alpha1.NotificationEvent) bool {
+	if cluster.Spec.Notifications == nil {
+		return false
+	}
+	for i := range cluster.Spec.Notifications.Webhooks {
+		if cluster.Spec.Notifications.Webhooks[i].Subscribes(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// getSecretValue returns value of the secret key in the namespace.
+func (r *EtcdClusterReconciler) getSecretValue(
+	ctx context.Context,
+	namespace string,
+	selector corev1.SecretKeySelector,
+) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, secret); err != nil {
+		return "", fmt.Errorf("cannot get secret %s: %w", selector.Name, err)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no %s field", selector.Name, selector.Key)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Notifications", func() {
+	readyPod := func(ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Pod{Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		}}
+	}
+	newCluster := func(reason etcdaenixiov1alpha1.EtcdCondType) *etcdaenixiov1alpha1.EtcdCluster {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{Spec: etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(3))}}
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).
+			WithStatus(false).
+			WithReason(string(reason)).
+			Complete())
+		return cluster
+	}
+
+	It("should detect quorum loss of initialized clusters", func() {
+		cluster := newCluster(etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady)
+		message, lost := getQuorumLoss(cluster, []corev1.Pod{readyPod(true), readyPod(false), readyPod(false)})
+		Expect(lost).To(BeTrue())
+		Expect(message).To(Equal("1 of 3 members are ready, quorum requires 2"))
+
+		_, lost = getQuorumLoss(cluster, []corev1.Pod{readyPod(true), readyPod(true), readyPod(false)})
+		Expect(lost).To(BeFalse())
+	})
+
+	It("should not report clusters waiting for first quorum", func() {
+		cluster := newCluster(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum)
+		_, lost := getQuorumLoss(cluster, nil)
+		Expect(lost).To(BeFalse())
+	})
+
+	It("should match webhooks subscribed to events", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		Expect(subscribesTo(cluster, etcdaenixiov1alpha1.NotificationEventDataCorruption)).To(BeFalse())
+		cluster.Spec.Notifications = &etcdaenixiov1alpha1.NotificationsSpec{
+			Webhooks: []etcdaenixiov1alpha1.NotificationWebhook{{
+				Name:   "pager",
+				Events: []etcdaenixiov1alpha1.NotificationEvent{etcdaenixiov1alpha1.NotificationEventQuorumLost},
+			}},
+		}
+		Expect(subscribesTo(cluster, etcdaenixiov1alpha1.NotificationEventQuorumLost)).To(BeTrue())
+		Expect(subscribesTo(cluster, etcdaenixiov1alpha1.NotificationEventDataCorruption)).To(BeFalse())
+		cluster.Spec.Notifications.Webhooks[0].Events = nil
+		Expect(subscribesTo(cluster, etcdaenixiov1alpha1.NotificationEventDataCorruption)).To(BeTrue())
+	})
+})
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// defaultObservePeriod is the health check interval of observed clusters without spec.reconcilePeriod.
+const defaultObservePeriod = time.Minute
+
+// observedHealth is the result of a health check of an observed cluster.
+type observedHealth struct {
+	members []etcdaenixiov1alpha1.MemberStatus
+	voters  int
+	healthy int
+	// alarms are raised by members, valid only if alarmsListed.
+	alarms       []etcdaenixiov1alpha1.AlarmStatus
+	alarmsListed bool
+	err          error
+}
+
+// reconcileObserved reports health of a cluster deployed outside of the operator. Only status is updated.
+func (r *EtcdClusterReconciler) reconcileObserved(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (ctrl.Result, error) {
+	if len(cluster.Status.Conditions) == 0 {
+		factory.FillConditions(cluster)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionInitialized).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeObserved)).
+		WithMessage(string(etcdaenixiov1alpha1.EtcdInitCondObservedMessage)).
+		Complete())
+
+	health := r.checkObservedHealth(ctx, cluster)
+	cluster.Status.Members = health.members
+	cluster.Status.CurrentVersion = lowestVersion(health.members)
+	cluster.Status.TargetVersion = ""
+	if health.alarmsListed {
+		setAlarmStatus(cluster, health.alarms)
+	}
+	setObservedReadyCondition(cluster, health)
+	// nothing is rolled out to observed clusters
+	setHealthConditions(cluster, true)
+
+	result, err := r.updateStatus(ctx, cluster)
+	if err == nil && !result.Requeue {
+		result.RequeueAfter = r.getReconcilePeriod(cluster)
+		if result.RequeueAfter == 0 {
+			result.RequeueAfter = defaultObservePeriod
+		}
+	}
+	return result, err
+}
+
+// checkObservedHealth lists members of the observed cluster and checks which of them respond on their client URLs.
+// Lease and watch requests are checked once a quorum responds.
+func (r *EtcdClusterReconciler) checkObservedHealth(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) observedHealth {
+	cli, err := r.newEtcdClient(ctx, cluster, cluster.Spec.ObservedEndpoints)
+	if err != nil {
+		return observedHealth{err: fmt.Errorf("cannot create etcd client: %w", err)}
+	}
+	defer func() { _ = cli.Close() }()
+
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	list, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return observedHealth{err: fmt.Errorf("cannot list members: %w", err)}
+	}
+
+	var health observedHealth
+	for _, member := range list.Members {
+		status := etcdaenixiov1alpha1.MemberStatus{Name: observedMemberName(member.Name, member.ID)}
+		if !member.IsLearner {
+			health.voters++
+		}
+		if version, ok := r.getObservedMemberVersion(ctx, cli, member.ClientURLs); ok {
+			status.Version = version
+			if !member.IsLearner {
+				health.healthy++
+			}
+		}
+		health.members = append(health.members, status)
+	}
+	if health.alarms, err = listAlarms(ctx, cli); err == nil {
+		health.alarmsListed = true
+	} else {
+		log.FromContext(ctx).V(2).Info("cannot list etcd alarms", "error", err.Error())
+	}
+	if health.healthy >= health.voters/2+1 {
+		if failure := checkLeaseAndWatch(ctx, cli); failure != nil {
+			health.err = failure
+		}
+	}
+	slices.SortFunc(health.members, func(a, b etcdaenixiov1alpha1.MemberStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return health
+}
+
+// getObservedMemberVersion returns etcd version reported by the first responding client URL of the member.
+func (r *EtcdClusterReconciler) getObservedMemberVersion(
+	ctx context.Context,
+	cli *clientv3.Client,
+	clientURLs []string,
+) (string, bool) {
+	for _, url := range clientURLs {
+		reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+		resp, err := cli.Status(reqCtx, url)
+		cancel()
+		if err == nil {
+			return resp.Version, true
+		}
+		log.FromContext(ctx).V(2).Info("cannot get member status", "endpoint", url, "error", err.Error())
+	}
+	return "", false
+}
+
+// observedMemberName returns name of the member, members which have not started yet are named by their ID.
+func observedMemberName(name string, id uint64) string {
+	if name != "" {
+		return name
+	}
+	return strconv.FormatUint(id, 16)
+}
+
+// setObservedReadyCondition sets Ready condition of the observed cluster to whether a quorum of voting members responds.
+func setObservedReadyCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, health observedHealth) {
+	quorum := health.voters/2 + 1
+	ready := health.err == nil && health.voters > 0 && health.healthy >= quorum
+	reason := etcdaenixiov1alpha1.EtcdCondTypeQuorumObserved
+	message := fmt.Sprintf("%d of %d voting members respond", health.healthy, health.voters)
+	switch {
+	case health.err != nil:
+		reason = etcdaenixiov1alpha1.EtcdCondTypeQuorumNotObserved
+		message = health.err.Error()
+		var failure *healthCheckError
+		if errors.As(health.err, &failure) {
+			reason = failure.reason
+		}
+	case !ready:
+		reason = etcdaenixiov1alpha1.EtcdCondTypeQuorumNotObserved
+		message = fmt.Sprintf("%s, quorum requires %d", message, quorum)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).
+		WithStatus(ready).
+		WithReason(string(reason)).
+		WithMessage(message).
+		Complete())
+}
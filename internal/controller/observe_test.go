@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Observed clusters", func() {
+	It("should be ready while a quorum of voting members responds", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setObservedReadyCondition(cluster, observedHealth{voters: 3, healthy: 2})
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeQuorumObserved)))
+		Expect(cond.Message).To(Equal("2 of 3 voting members respond"))
+
+		setObservedReadyCondition(cluster, observedHealth{voters: 3, healthy: 1})
+		cond = factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeQuorumNotObserved)))
+		Expect(cond.Message).To(Equal("1 of 3 voting members respond, quorum requires 2"))
+	})
+
+	It("should not be ready if members can not be listed", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setObservedReadyCondition(cluster, observedHealth{err: errors.New("cannot list members: context deadline exceeded")})
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Message).To(Equal("cannot list members: context deadline exceeded"))
+	})
+
+	It("should not be ready if lease or watch requests are not served", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setObservedReadyCondition(cluster, observedHealth{voters: 3, healthy: 3, err: &healthCheckError{
+			reason: etcdaenixiov1alpha1.EtcdCondTypeWatchCheckFailed,
+			err:    errors.New("cannot watch key /etcd-operator/health: context deadline exceeded"),
+		}})
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeWatchCheckFailed)))
+		Expect(cond.Message).To(Equal("cannot watch key /etcd-operator/health: context deadline exceeded"))
+	})
+
+	It("should name members which have not started by their ID", func() {
+		Expect(observedMemberName("etcd-a", 0x1a)).To(Equal("etcd-a"))
+		Expect(observedMemberName("", 0x1a)).To(Equal("1a"))
+	})
+})
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/images"
+)
+
+// operationOrder is the order in which simultaneously requested operations acquire the lock. Upgrade goes first,
+// so members added by a following scale-up start on the new version right away.
+var operationOrder = []etcdaenixiov1alpha1.OperationType{
+	etcdaenixiov1alpha1.OperationUpgrade,
+	etcdaenixiov1alpha1.OperationScale,
+}
+
+// lockOperation serializes operations requested by the spec of a cluster run by a StatefulSet. The operation
+// held in status.currentOperation is released once the StatefulSet settles, then the next requested operation
+// acquires the lock. Returns the cluster to render owned objects from, with changes of queued operations reverted
+// to the state of the StatefulSet.
+func (r *EtcdClusterReconciler) lockOperation(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (*etcdaenixiov1alpha1.EtcdCluster, error) {
+	if cluster.ManagesMemberPods() || isUpgradeRolledBack(cluster) || isImageRejected(cluster) {
+		cluster.Status.CurrentOperation = nil
+		setOperationQueuedCondition(cluster, nil)
+		return cluster, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("cannot get StatefulSet: %w", err)
+		}
+		// the StatefulSet is created from the spec at once
+		cluster.Status.CurrentOperation = nil
+		setOperationQueuedCondition(cluster, nil)
+		return cluster, nil
+	}
+
+	image := images.MirrorsFromContext(ctx).Rewrite(cluster.EtcdImage())
+	requested := getRequestedOperations(cluster, sts, image)
+	current := cluster.Status.CurrentOperation
+	if current != nil && !slices.Contains(requested, current.Type) && isStatefulSetSettled(sts) {
+		log.FromContext(ctx).Info("operation completed", "operation", current.Type)
+		recordAction(ctx, "completed %s operation", current.Type)
+		current = nil
+	}
+	if current == nil && len(requested) > 0 {
+		current = &etcdaenixiov1alpha1.OperationStatus{Type: requested[0], StartedAt: metav1.NewTime(r.getClock().Now())}
+		log.FromContext(ctx).Info("operation started", "operation", current.Type)
+		recordAction(ctx, "started %s operation", current.Type)
+	}
+	cluster.Status.CurrentOperation = current
+
+	var queued []etcdaenixiov1alpha1.OperationType
+	for _, operation := range requested {
+		if operation != current.Type {
+			queued = append(queued, operation)
+		}
+	}
+	setOperationQueuedCondition(cluster, queued)
+	if len(queued) == 0 {
+		return cluster, nil
+	}
+
+	desired := cluster.DeepCopy()
+	for _, operation := range queued {
+		recordSkipped(ctx, "%s: queued behind %s operation", operation, current.Type)
+		switch operation {
+		case etcdaenixiov1alpha1.OperationScale:
+			desired.Spec.Replicas = ptr.To(ptr.Deref(sts.Spec.Replicas, 0))
+		case etcdaenixiov1alpha1.OperationUpgrade:
+			setEtcdImage(desired, getContainerImage(sts.Spec.Template.Spec.Containers, "etcd"))
+		}
+	}
+	return desired, nil
+}
+
+// getRequestedOperations returns operations needed to bring the StatefulSet to the spec in the lock order.
+func getRequestedOperations(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	sts *appsv1.StatefulSet,
+	image string,
+) []etcdaenixiov1alpha1.OperationType {
+	var requested []etcdaenixiov1alpha1.OperationType
+	for _, operation := range operationOrder {
+		switch operation {
+		case etcdaenixiov1alpha1.OperationUpgrade:
+			if current := getContainerImage(sts.Spec.Template.Spec.Containers, "etcd"); current != "" && current != image {
+				requested = append(requested, operation)
+			}
+		case etcdaenixiov1alpha1.OperationScale:
+			if ptr.Deref(sts.Spec.Replicas, 0) != ptr.Deref(cluster.Spec.Replicas, 0) {
+				requested = append(requested, operation)
+			}
+		}
+	}
+	return requested
+}
+
+// isStatefulSetSettled returns true if the StatefulSet observed its spec and all its replicas are updated and ready.
+func isStatefulSetSettled(sts *appsv1.StatefulSet) bool {
+	replicas := ptr.Deref(sts.Spec.Replicas, 0)
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.UpdateRevision == sts.Status.CurrentRevision &&
+		sts.Status.Replicas == replicas &&
+		sts.Status.ReadyReplicas == replicas &&
+		sts.Status.UpdatedReplicas == replicas
+}
+
+// setOperationQueuedCondition reflects operations waiting for the operation lock.
+// Clusters have no such condition while no operation is queued.
+func setOperationQueuedCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, queued []etcdaenixiov1alpha1.OperationType) {
+	if len(queued) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionOperationQueued)
+		return
+	}
+	names := make([]string, 0, len(queued))
+	for _, operation := range queued {
+		names = append(names, string(operation))
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionOperationQueued).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForOperation)).
+		WithMessage(fmt.Sprintf("%s waits until %s completes",
+			strings.Join(names, ", "), cluster.Status.CurrentOperation.Type)).
+		Complete())
+}
+
+// getContainerImage returns image of the named container, empty if there is no such container.
+func getContainerImage(containers []corev1.Container, name string) string {
+	for _, c := range containers {
+		if c.Name == name {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+// setEtcdImage overrides image of the etcd container in the pod template of the cluster.
+func setEtcdImage(cluster *etcdaenixiov1alpha1.EtcdCluster, image string) {
+	containers := cluster.Spec.PodTemplate.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == "etcd" {
+			containers[i].Image = image
+			return
+		}
+	}
+	cluster.Spec.PodTemplate.Spec.Containers = append(containers, corev1.Container{Name: "etcd", Image: image})
+}
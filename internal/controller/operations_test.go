@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Operation lock", func() {
+	var (
+		cluster *etcdaenixiov1alpha1.EtcdCluster
+		sts     *appsv1.StatefulSet
+	)
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{}
+		cluster.Spec.Replicas = ptr.To(int32(5))
+		sts = &appsv1.StatefulSet{}
+		sts.Generation = 2
+		sts.Spec.Replicas = ptr.To(int32(3))
+		sts.Spec.Template.Spec.Containers = []corev1.Container{{Name: "etcd", Image: "quay.io/coreos/etcd:v3.5.11"}}
+		sts.Status = appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			ReadyReplicas:      3,
+			UpdatedReplicas:    3,
+			CurrentRevision:    "rev-1",
+			UpdateRevision:     "rev-1",
+		}
+	})
+
+	It("should order simultaneously requested operations", func() {
+		Expect(getRequestedOperations(cluster, sts, "quay.io/coreos/etcd:v3.5.12")).To(Equal(
+			[]etcdaenixiov1alpha1.OperationType{etcdaenixiov1alpha1.OperationUpgrade, etcdaenixiov1alpha1.OperationScale}))
+		Expect(getRequestedOperations(cluster, sts, "quay.io/coreos/etcd:v3.5.11")).To(Equal(
+			[]etcdaenixiov1alpha1.OperationType{etcdaenixiov1alpha1.OperationScale}))
+	})
+
+	It("should consider StatefulSet settled once all replicas are updated and ready", func() {
+		Expect(isStatefulSetSettled(sts)).To(BeTrue())
+		sts.Status.UpdateRevision = "rev-2"
+		Expect(isStatefulSetSettled(sts)).To(BeFalse())
+		sts.Status.UpdateRevision = "rev-1"
+		sts.Generation = 3
+		Expect(isStatefulSetSettled(sts)).To(BeFalse())
+	})
+
+	It("should reflect queued operations in condition", func() {
+		cluster.Status.CurrentOperation = &etcdaenixiov1alpha1.OperationStatus{
+			Type:      etcdaenixiov1alpha1.OperationUpgrade,
+			StartedAt: metav1.Now(),
+		}
+		setOperationQueuedCondition(cluster, []etcdaenixiov1alpha1.OperationType{etcdaenixiov1alpha1.OperationScale})
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionOperationQueued)
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Message).To(Equal("Scale waits until Upgrade completes"))
+
+		setOperationQueuedCondition(cluster, nil)
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionOperationQueued)).To(BeNil())
+	})
+
+	It("should keep image of queued upgrade", func() {
+		setEtcdImage(cluster, "quay.io/coreos/etcd:v3.5.11")
+		Expect(cluster.EtcdImage()).To(Equal("quay.io/coreos/etcd:v3.5.11"))
+		setEtcdImage(cluster, "quay.io/coreos/etcd:v3.5.10")
+		Expect(cluster.Spec.PodTemplate.Spec.Containers).To(HaveLen(1))
+		Expect(cluster.EtcdImage()).To(Equal("quay.io/coreos/etcd:v3.5.10"))
+	})
+})
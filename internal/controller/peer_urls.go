@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	// peerURLMigrationRetryInterval is how often stale peer URLs are checked while members are moved to new ones.
+	peerURLMigrationRetryInterval = 10 * time.Second
+
+	flagAdvertisePeerURLs   = "initial-advertise-peer-urls"
+	flagAdvertiseClientURLs = "advertise-client-urls"
+
+	reasonPeerURLsUpdated = "PeerURLsUpdated"
+)
+
+// migratePeerURLs updates peer URLs of members, whose pods advertise URLs different from the ones in the cluster
+// membership, e.g. after the headless service was renamed. etcd only reads advertised peer URLs on bootstrap, so
+// without the update other members keep dialing the stale URLs. Members are updated one per reconciliation once
+// their pod is running and the other members have quorum, so the next member is only updated after the previous
+// one rejoined. Members are reached by client URLs their pods advertise, as the URLs derived from the spec may not
+// resolve until pods are rolled. Returns the duration after which migration continues.
+func (r *EtcdClusterReconciler) migratePeerURLs(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+	running := make(map[string]*corev1.Pod, len(pods))
+	var endpoints []string
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		running[pod.Name] = pod
+		endpoints = append(endpoints, getPodAdvertisedURLs(pod, flagAdvertiseClientURLs)...)
+	}
+	if len(endpoints) == 0 || len(getMemberEndpoints(cluster, pods, nil)) == 0 {
+		return 0, nil
+	}
+
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		logger.Error(err, "cannot create etcd client")
+		return 0, nil
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		logger.V(2).Info("cannot list etcd members", "error", err.Error())
+		return 0, nil
+	}
+
+	var staleID uint64
+	var staleName string
+	var desired []string
+	voters := 0
+	for _, member := range members.Members {
+		if !member.IsLearner {
+			voters++
+		}
+		pod := running[member.Name]
+		if pod == nil || staleName != "" {
+			continue
+		}
+		urls := getPodAdvertisedURLs(pod, flagAdvertisePeerURLs)
+		if len(urls) > 0 && !sameURLs(member.PeerURLs, urls) {
+			staleID, staleName, desired = member.ID, member.Name, urls
+		}
+	}
+	if staleName == "" {
+		return 0, nil
+	}
+
+	// members are asked on the client URLs their pods advertise, which may differ from endpoints of the client
+	healthy := make(map[uint64]bool)
+	for _, endpoint := range endpoints {
+		status, err := cli.Status(reqCtx, endpoint)
+		if err != nil || status.IsLearner || status.Leader == 0 || len(status.Errors) > 0 {
+			continue
+		}
+		// the only member of a standalone cluster is its own quorum
+		if status.Header.MemberId != staleID || cluster.IsStandalone() {
+			healthy[status.Header.MemberId] = true
+		}
+	}
+	if len(healthy) < voters/2+1 {
+		logger.Info("waiting for quorum to update peer URLs of member", "member", staleName)
+		recordSkipped(ctx, "update of peer URLs of member %s: %d of %d voting members are healthy",
+			staleName, len(healthy), voters)
+		return peerURLMigrationRetryInterval, nil
+	}
+
+	if _, err := cli.MemberUpdate(reqCtx, staleID, desired); err != nil {
+		return 0, fmt.Errorf("cannot update peer URLs of member %s: %w", staleName, err)
+	}
+	message := fmt.Sprintf("Peer URLs of member %s updated to %s", staleName, strings.Join(desired, ","))
+	logger.Info("updated peer URLs of member", "member", staleName, "peer_urls", desired)
+	recordAction(ctx, "updated peer URLs of member %s to %s", staleName, strings.Join(desired, ","))
+	r.recordEvent(cluster, corev1.EventTypeNormal, reasonPeerURLsUpdated, message)
+	return peerURLMigrationRetryInterval, nil
+}
+
+// getPodAdvertisedURLs returns URLs passed by the flag to etcd container of the pod with pod name and namespace
+// variables expanded.
+func getPodAdvertisedURLs(pod *corev1.Pod, flag string) []string {
+	container := slices.IndexFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == "etcd" })
+	if container == -1 {
+		return nil
+	}
+	value := getFlagValues(pod.Spec.Containers[container].Args, []string{flag})[flag]
+	if value == "" {
+		return nil
+	}
+	value = strings.NewReplacer("$(POD_NAME)", pod.Name, "$(POD_NAMESPACE)", pod.Namespace).Replace(value)
+	return strings.Split(value, ",")
+}
+
+// sameURLs returns true if both lists contain the same URLs regardless of their order.
+func sameURLs(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
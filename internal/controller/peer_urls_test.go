@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Peer URL migration", func() {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-0", Namespace: "ns"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "etcd",
+			Args: []string{
+				"--name=$(POD_NAME)",
+				"--initial-advertise-peer-urls=https://$(POD_NAME).test-peers.$(POD_NAMESPACE).svc:2380",
+				"--advertise-client-urls=http://$(POD_NAME).test-peers.$(POD_NAMESPACE).svc:2379",
+			},
+		}}},
+	}
+
+	It("should expand URLs advertised by the pod", func() {
+		Expect(getPodAdvertisedURLs(pod, flagAdvertisePeerURLs)).To(Equal([]string{"https://test-0.test-peers.ns.svc:2380"}))
+		Expect(getPodAdvertisedURLs(pod, flagAdvertiseClientURLs)).To(Equal([]string{"http://test-0.test-peers.ns.svc:2379"}))
+		Expect(getPodAdvertisedURLs(pod, "listen-peer-urls")).To(BeEmpty())
+	})
+
+	It("should not find URLs of pods without etcd container", func() {
+		Expect(getPodAdvertisedURLs(&corev1.Pod{}, flagAdvertisePeerURLs)).To(BeEmpty())
+	})
+
+	It("should compare URLs regardless of their order", func() {
+		Expect(sameURLs([]string{"https://a:2380", "https://b:2380"}, []string{"https://b:2380", "https://a:2380"})).To(BeTrue())
+		Expect(sameURLs([]string{"https://a:2380"}, []string{"https://a.headless:2380"})).To(BeFalse())
+		Expect(sameURLs(nil, []string{"https://a:2380"})).To(BeFalse())
+	})
+})
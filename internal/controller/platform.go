@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// updateNodeCompatibilityCondition sets the NoCompatibleNodes condition if there are schedulable nodes, but none
+// of them runs Linux on an architecture of the etcd image, so member pods stay pending. Clusters have no such
+// condition otherwise, including clusters without schedulable nodes at all, which the scheduler reports anyway.
+func (r *EtcdClusterReconciler) updateNodeCompatibilityCondition(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) error {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return fmt.Errorf("cannot list nodes: %w", err)
+	}
+	setNodeCompatibilityCondition(cluster, nodes.Items)
+	return nil
+}
+
+// setNodeCompatibilityCondition reflects platforms of schedulable nodes in the NoCompatibleNodes condition,
+// if none of them is compatible with the etcd image.
+func setNodeCompatibilityCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, nodes []corev1.Node) {
+	platforms := map[string]int{}
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable {
+			continue
+		}
+		if factory.IsNodeCompatible(cluster, node) {
+			meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes)
+			return
+		}
+		platforms[node.Labels[corev1.LabelOSStable]+"/"+node.Labels[corev1.LabelArchStable]]++
+	}
+	if len(platforms) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes)
+		return
+	}
+	found := make([]string, 0, len(platforms))
+	for platform, count := range platforms {
+		found = append(found, fmt.Sprintf("%s (%d)", platform, count))
+	}
+	slices.Sort(found)
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeIncompatibleNodes)).
+		WithMessage(fmt.Sprintf("etcd image runs on linux/%s, but schedulable nodes are %s",
+			strings.Join(cluster.GetArchitectures(), ", linux/"), strings.Join(found, ", "))).
+		Complete())
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Node compatibility", func() {
+	node := func(os, arch string, unschedulable bool) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelOSStable: os, corev1.LabelArchStable: arch}},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		}
+	}
+
+	It("should report clusters whose schedulable nodes are all incompatible", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setNodeCompatibilityCondition(cluster, []corev1.Node{
+			node("windows", "amd64", false),
+			node("windows", "amd64", false),
+			node("linux", "amd64", true),
+		})
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeIncompatibleNodes)))
+		Expect(condition.Message).To(HaveSuffix("schedulable nodes are windows/amd64 (2)"))
+
+		setNodeCompatibilityCondition(cluster, []corev1.Node{node("windows", "amd64", false), node("linux", "arm64", false)})
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes)).To(BeNil())
+	})
+
+	It("should not report clusters without schedulable nodes", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setNodeCompatibilityCondition(cluster, []corev1.Node{node("linux", "amd64", true)})
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionNoCompatibleNodes)).To(BeNil())
+	})
+})
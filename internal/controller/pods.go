@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	podReasonEvicted          = "Evicted"
+	containerReasonCrashLoop  = "CrashLoopBackOff"
+	containerReasonOOMKilled  = "OOMKilled"
+	labelAppInstance          = "app.kubernetes.io/instance"
+	labelAppManagedBy         = "app.kubernetes.io/managed-by"
+	labelAppManagedByOperator = "etcd-operator"
+)
+
+// memberFailure describes a failing member pod and the reason of its failure.
+type memberFailure struct {
+	podName string
+	reason  etcdaenixiov1alpha1.EtcdCondType
+	message string
+}
+
+// listClusterPods returns member pods of the cluster.
+func (r *EtcdClusterReconciler) listClusterPods(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// areMemberPodsReady returns true if enough pods of members managed in the Pods member management mode are ready
+// according to the availability policy.
+func (r *EtcdClusterReconciler) areMemberPodsReady(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (bool, error) {
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	ready := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() && isPodReady(&pods[i]) {
+			ready++
+		}
+	}
+	return ready >= cluster.MinHealthyMembers(int(ptr.Deref(cluster.Spec.Replicas, 0))), nil
+}
+
+// getMemberFailures inspects pods and returns failures sorted by pod name.
+// Pods are considered failed if they were evicted or any of their containers is in CrashLoopBackOff or was OOMKilled.
+func getMemberFailures(pods []corev1.Pod) []memberFailure {
+	failures := make([]memberFailure, 0)
+	for i := range pods {
+		if failure, failed := getPodFailure(&pods[i]); failed {
+			failures = append(failures, failure)
+		}
+	}
+	slices.SortFunc(failures, func(a, b memberFailure) int {
+		return strings.Compare(a.podName, b.podName)
+	})
+	return failures
+}
+
+func getPodFailure(pod *corev1.Pod) (memberFailure, bool) {
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == podReasonEvicted {
+		return memberFailure{
+			podName: pod.Name,
+			reason:  etcdaenixiov1alpha1.EtcdCondTypeMemberEvicted,
+			message: fmt.Sprintf("pod %s was evicted: %s", pod.Name, pod.Status.Message),
+		}, true
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		oomKilled := status.State.Terminated != nil && status.State.Terminated.Reason == containerReasonOOMKilled
+		crashLooping := status.State.Waiting != nil && status.State.Waiting.Reason == containerReasonCrashLoop
+		if crashLooping && status.LastTerminationState.Terminated != nil &&
+			status.LastTerminationState.Terminated.Reason == containerReasonOOMKilled {
+			oomKilled = true
+		}
+		if oomKilled {
+			return memberFailure{
+				podName: pod.Name,
+				reason:  etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled,
+				message: fmt.Sprintf("container %s of pod %s was OOMKilled", status.Name, pod.Name),
+			}, true
+		}
+		if crashLooping {
+			return memberFailure{
+				podName: pod.Name,
+				reason:  etcdaenixiov1alpha1.EtcdCondTypeMemberCrashLoopBackOff,
+				message: fmt.Sprintf("container %s of pod %s is in CrashLoopBackOff", status.Name, pod.Name),
+			}, true
+		}
+	}
+	return memberFailure{}, false
+}
+
+// setMemberFailureCondition reflects failing member pods in the MemberFailure condition.
+// The reason of the first failing pod is used as condition reason, all failures are listed in the message.
+func setMemberFailureCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, failures []memberFailure) {
+	if len(failures) == 0 {
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionMemberFailure).
+			WithStatus(false).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeMembersHealthy)).
+			WithMessage(string(etcdaenixiov1alpha1.EtcdMemberFailureCondNegMessage)).
+			Complete())
+		return
+	}
+
+	messages := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		messages = append(messages, failure.message)
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionMemberFailure).
+		WithStatus(true).
+		WithReason(string(failures[0].reason)).
+		WithMessage(strings.Join(messages, "; ")).
+		Complete())
+}
+
+// mapPodToCluster maps events of pods managed by the operator to reconcile requests of their EtcdCluster.
+func mapPodToCluster(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels[labelAppManagedBy] != labelAppManagedByOperator || labels[labelAppInstance] == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: labels[labelAppInstance]}},
+	}
+}
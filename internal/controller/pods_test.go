@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Member pod failures", func() {
+	newPod := func(name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: statuses,
+			},
+		}
+	}
+
+	It("should not report healthy pods", func() {
+		pods := []corev1.Pod{
+			newPod("test-0", corev1.ContainerStatus{Name: "etcd", Ready: true}),
+		}
+		Expect(getMemberFailures(pods)).To(BeEmpty())
+	})
+
+	It("should detect evicted, crashlooping and OOMKilled pods", func() {
+		evicted := newPod("test-0")
+		evicted.Status.Phase = corev1.PodFailed
+		evicted.Status.Reason = "Evicted"
+		pods := []corev1.Pod{
+			newPod("test-2", corev1.ContainerStatus{
+				Name:  "etcd",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+				},
+			}),
+			newPod("test-1", corev1.ContainerStatus{
+				Name:  "etcd",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}),
+			evicted,
+		}
+
+		failures := getMemberFailures(pods)
+		Expect(failures).To(HaveLen(3))
+		Expect(failures[0].podName).To(Equal("test-0"))
+		Expect(failures[0].reason).To(Equal(etcdaenixiov1alpha1.EtcdCondTypeMemberEvicted))
+		Expect(failures[1].reason).To(Equal(etcdaenixiov1alpha1.EtcdCondTypeMemberCrashLoopBackOff))
+		Expect(failures[2].reason).To(Equal(etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled))
+	})
+
+	It("should reflect failures in MemberFailure condition", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setMemberFailureCondition(cluster, []memberFailure{
+			{podName: "test-1", reason: etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled, message: "container etcd of pod test-1 was OOMKilled"},
+		})
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionMemberFailure)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeMemberOOMKilled)))
+		Expect(cond.Message).To(ContainSubstring("test-1"))
+
+		setMemberFailureCondition(cluster, nil)
+		cond = factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionMemberFailure)
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should map operator managed pods to their cluster", func(ctx SpecContext) {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-0",
+			Labels:    factory.NewLabelsBuilder().WithName().WithInstance("test").WithManagedBy(),
+		}}
+		requests := mapPodToCluster(ctx, pod)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Name).To(Equal("test"))
+
+		pod.Labels = map[string]string{"app.kubernetes.io/instance": "test"}
+		Expect(mapPodToCluster(ctx, pod)).To(BeEmpty())
+	})
+})
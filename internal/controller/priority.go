@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// priorityControllerName names the controller reconciling unhealthy clusters from a queue of their own,
+// so incident recovery is not stuck behind routine resyncs of healthy clusters.
+const priorityControllerName = "etcdcluster-priority"
+
+// isClusterUnhealthy returns true if the cluster is degraded or has lost the quorum of ready members.
+func isClusterUnhealthy(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	if !cluster.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionDegraded); cond != nil &&
+		cond.Status == metav1.ConditionTrue {
+		return true
+	}
+	ready := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionReady)
+	return ready != nil && ready.Status == metav1.ConditionFalse &&
+		ready.Reason != string(etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum) &&
+		ptr.Deref(cluster.Spec.Replicas, 0) > 0
+}
+
+// isUnhealthyClusterObject filters events of the priority controller to unhealthy clusters.
+func isUnhealthyClusterObject(obj client.Object) bool {
+	cluster, ok := obj.(*etcdaenixiov1alpha1.EtcdCluster)
+	return ok && isClusterUnhealthy(cluster)
+}
+
+// mapPodToUnhealthyCluster enqueues the cluster of the member pod to the priority queue if the cluster is unhealthy.
+func (r *EtcdClusterReconciler) mapPodToUnhealthyCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := mapPodToCluster(ctx, obj)
+	if len(requests) == 0 {
+		return nil
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	if err := r.Get(ctx, requests[0].NamespacedName, cluster); err != nil || !isClusterUnhealthy(cluster) {
+		return nil
+	}
+	return requests
+}
+
+// reconcileUnhealthy reconciles the cluster from the priority queue. Once the cluster recovers,
+// it is no longer requeued there and is resynced by the regular queue only.
+func (r *EtcdClusterReconciler) reconcileUnhealthy(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !isClusterUnhealthy(cluster) {
+		log.FromContext(ctx).V(2).Info("cluster recovered, leaving priority queue")
+		return ctrl.Result{}, nil
+	}
+	return result, nil
+}
+
+// clusterLocks serializes reconciliations of the same cluster taken from different queues.
+type clusterLocks struct {
+	mu    sync.Mutex
+	locks map[types.NamespacedName]*clusterLock
+}
+
+type clusterLock struct {
+	sync.Mutex
+	holders int
+}
+
+// lock blocks until no other reconciliation holds the cluster and returns the function releasing it.
+func (l *clusterLocks) lock(key types.NamespacedName) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[types.NamespacedName]*clusterLock{}
+	}
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = &clusterLock{}
+		l.locks[key] = entry
+	}
+	entry.holders++
+	l.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		l.mu.Lock()
+		entry.holders--
+		if entry.holders == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
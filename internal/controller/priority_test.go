@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Priority reconciliation", func() {
+	var cluster *etcdaenixiov1alpha1.EtcdCluster
+
+	BeforeEach(func() {
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{Replicas: ptr.To(int32(3))},
+		}
+	})
+
+	setReady := func(ready bool, reason etcdaenixiov1alpha1.EtcdCondType) {
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionReady).
+			WithStatus(ready).WithReason(string(reason)).WithMessage("test").Complete())
+	}
+
+	It("should not prioritize healthy and bootstrapping clusters", func() {
+		Expect(isClusterUnhealthy(cluster)).To(BeFalse())
+		setReady(false, etcdaenixiov1alpha1.EtcdCondTypeWaitingForFirstQuorum)
+		Expect(isClusterUnhealthy(cluster)).To(BeFalse())
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		Expect(isClusterUnhealthy(cluster)).To(BeFalse())
+	})
+
+	It("should prioritize clusters which lost quorum", func() {
+		setReady(false, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetNotReady)
+		Expect(isClusterUnhealthy(cluster)).To(BeTrue())
+
+		cluster.Spec.Replicas = ptr.To(int32(0))
+		Expect(isClusterUnhealthy(cluster)).To(BeFalse())
+	})
+
+	It("should prioritize degraded clusters unless they are deleted", func() {
+		setReady(true, etcdaenixiov1alpha1.EtcdCondTypeStatefulSetReady)
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionDegraded).
+			WithStatus(true).WithReason(etcdaenixiov1alpha1.EtcdConditionMemberFailure).WithMessage("test").Complete())
+		Expect(isClusterUnhealthy(cluster)).To(BeTrue())
+
+		cluster.DeletionTimestamp = ptr.To(metav1.Now())
+		Expect(isClusterUnhealthy(cluster)).To(BeFalse())
+	})
+
+	It("should not reconcile the same cluster from both queues at once", func() {
+		var locks clusterLocks
+		key := types.NamespacedName{Namespace: "default", Name: "test"}
+		unlock := locks.lock(key)
+
+		// other clusters are not blocked
+		locks.lock(types.NamespacedName{Namespace: "default", Name: "other"})()
+
+		locked := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			locks.lock(key)()
+			close(locked)
+		}()
+		Consistently(locked, 100*time.Millisecond).ShouldNot(BeClosed())
+		unlock()
+		Eventually(locked).Should(BeClosed())
+		Expect(locks.locks).To(BeEmpty())
+	})
+})
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	flagMaxTxnOps       = "max-txn-ops"
+	flagMaxRequestBytes = "max-request-bytes"
+	// defaultMaxTxnOps and defaultMaxRequestBytes are limits of etcd members started without the flags.
+	defaultMaxTxnOps       = 128
+	defaultMaxRequestBytes = 1536 * 1024
+)
+
+// updateRequestLimitsStatus reflects the lowest request limits members were started with in the status,
+// so clients may rely on them while a change of spec.requestLimits is rolled out.
+func updateRequestLimitsStatus(cluster *etcdaenixiov1alpha1.EtcdCluster, pods []corev1.Pod) {
+	var limits *etcdaenixiov1alpha1.RequestLimitsStatus
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		container := slices.IndexFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == "etcd" })
+		if container == -1 {
+			continue
+		}
+		member := getRequestLimits(pod.Spec.Containers[container].Args)
+		if limits == nil {
+			limits = &member
+			continue
+		}
+		limits.MaxTxnOps = min(limits.MaxTxnOps, member.MaxTxnOps)
+		limits.MaxRequestBytes = min(limits.MaxRequestBytes, member.MaxRequestBytes)
+	}
+	cluster.Status.RequestLimits = limits
+}
+
+// getRequestLimits returns request limits of etcd started with the arguments. Values etcd would not parse
+// fall back to defaults, such members do not start anyway.
+func getRequestLimits(args []string) etcdaenixiov1alpha1.RequestLimitsStatus {
+	limits := etcdaenixiov1alpha1.RequestLimitsStatus{
+		MaxTxnOps:       defaultMaxTxnOps,
+		MaxRequestBytes: defaultMaxRequestBytes,
+	}
+	values := getFlagValues(args, []string{flagMaxTxnOps, flagMaxRequestBytes})
+	if value, err := strconv.ParseInt(values[flagMaxTxnOps], 10, 32); err == nil {
+		limits.MaxTxnOps = int32(value)
+	}
+	if value, err := strconv.ParseInt(values[flagMaxRequestBytes], 10, 64); err == nil {
+		limits.MaxRequestBytes = value
+	}
+	return limits
+}
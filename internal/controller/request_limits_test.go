@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Request limits", func() {
+	memberPod := func(name string, args ...string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "etcd", Args: args}},
+			},
+		}
+	}
+
+	It("should account members started without flags with defaults of etcd", func() {
+		Expect(getRequestLimits([]string{"--name=$(POD_NAME)"})).To(Equal(etcdaenixiov1alpha1.RequestLimitsStatus{
+			MaxTxnOps:       128,
+			MaxRequestBytes: 1572864,
+		}))
+	})
+	It("should report the lowest limits of members", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		updateRequestLimitsStatus(cluster, []corev1.Pod{
+			memberPod("test-0", "--max-txn-ops=1024", "--max-request-bytes=4194304"),
+			memberPod("test-1", "--max-txn-ops=512", "--max-request-bytes=8388608"),
+		})
+		Expect(cluster.Status.RequestLimits).To(Equal(&etcdaenixiov1alpha1.RequestLimitsStatus{
+			MaxTxnOps:       512,
+			MaxRequestBytes: 4194304,
+		}))
+
+		updateRequestLimitsStatus(cluster, nil)
+		Expect(cluster.Status.RequestLimits).To(BeNil())
+	})
+})
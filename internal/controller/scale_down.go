@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/internal/maintenance"
+)
+
+// scaleDownRetryInterval is the time after which deferred scale-down is checked again.
+const scaleDownRetryInterval = 30 * time.Second
+
+// scaleDownBlocker describes why members can not be removed now.
+type scaleDownBlocker struct {
+	reason  etcdaenixiov1alpha1.EtcdCondType
+	message string
+}
+
+// deferUnsafeScaleDown keeps members which would be removed by the scale-down while a learner catches up from one
+// of them or a maintenance operation runs on them. Returns the cluster to render owned objects from, with replicas
+// kept at the number of running members if the scale-down is deferred, and the duration after which it should be
+// checked again. The ScaleDownDeferred condition explains the deferral.
+func (r *EtcdClusterReconciler) deferUnsafeScaleDown(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	desired *etcdaenixiov1alpha1.EtcdCluster,
+) (*etcdaenixiov1alpha1.EtcdCluster, time.Duration, error) {
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot list Cluster pods: %w", err)
+	}
+	removed, running := getRemovedMembers(pods, int(ptr.Deref(desired.Spec.Replicas, 0)))
+	if len(removed) == 0 {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionScaleDownDeferred)
+		return desired, 0, nil
+	}
+
+	blocker := r.getMaintenanceBlocker(cluster, removed)
+	if blocker == nil {
+		blocker = r.getLearnerBlocker(ctx, cluster, conn, pods, removed)
+	}
+	if blocker == nil {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionScaleDownDeferred)
+		return desired, 0, nil
+	}
+
+	log.FromContext(ctx).Info("scale-down is deferred", "members", removed, "reason", blocker.reason)
+	recordSkipped(ctx, "scale-down: %s", blocker.message)
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionScaleDownDeferred).
+		WithStatus(true).
+		WithReason(string(blocker.reason)).
+		WithMessage(fmt.Sprintf("Removal of %s is deferred: %s", strings.Join(removed, ", "), blocker.message)).
+		Complete())
+	desired = desired.DeepCopy()
+	desired.Spec.Replicas = ptr.To(int32(running))
+	return desired, scaleDownRetryInterval, nil
+}
+
+// getRemovedMembers returns names of running member pods with ordinals beyond the replicas, sorted by name,
+// and the number of members which keeps all of them.
+func getRemovedMembers(pods []corev1.Pod, replicas int) ([]string, int) {
+	var removed []string
+	running := replicas
+	for i := range pods {
+		ordinal, err := podOrdinal(pods[i].Name)
+		if err != nil || ordinal < replicas || !pods[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		removed = append(removed, pods[i].Name)
+		running = max(running, ordinal+1)
+	}
+	slices.Sort(removed)
+	return removed, running
+}
+
+// getMaintenanceBlocker returns a blocker if a maintenance operation of the operator runs or is queued on any of
+// the removed members.
+func (r *EtcdClusterReconciler) getMaintenanceBlocker(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	removed []string,
+) *scaleDownBlocker {
+	clusterKey := maintenance.MemberKey(cluster.Namespace, cluster.Name, "")
+	for _, member := range removed {
+		if r.Maintenance != nil {
+			if ops := r.Maintenance.InFlight(maintenance.MemberKey(cluster.Namespace, cluster.Name, member)); len(ops) > 0 {
+				return &scaleDownBlocker{
+					reason:  etcdaenixiov1alpha1.EtcdCondTypeMaintenanceInProgress,
+					message: fmt.Sprintf("%s of member %s is in progress", ops[0], member),
+				}
+			}
+		}
+		if r.Bloat != nil && r.Bloat.IsPending(clusterKey, member) {
+			return &scaleDownBlocker{
+				reason:  etcdaenixiov1alpha1.EtcdCondTypeMaintenanceInProgress,
+				message: fmt.Sprintf("defragmentation of member %s is scheduled", member),
+			}
+		}
+	}
+	return nil
+}
+
+// getLearnerBlocker returns a blocker if a learner catches up from the leader which would be removed,
+// or if members of the cluster can not be listed to verify it.
+func (r *EtcdClusterReconciler) getLearnerBlocker(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+	removed []string,
+) *scaleDownBlocker {
+	unverified := func(err error) *scaleDownBlocker {
+		return &scaleDownBlocker{
+			reason:  etcdaenixiov1alpha1.EtcdCondTypeMembersUnverified,
+			message: fmt.Sprintf("cannot verify learners of the cluster: %s", err.Error()),
+		}
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		return unverified(err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return unverified(err)
+	}
+	var leader uint64
+	for _, endpoint := range cli.Endpoints() {
+		if status, err := cli.Status(reqCtx, endpoint); err == nil {
+			leader = status.Leader
+			break
+		}
+	}
+	if leader == 0 {
+		return unverified(fmt.Errorf("leader is unknown"))
+	}
+	return getLearnerCatchUpBlocker(members.Members, leader, removed)
+}
+
+// getLearnerCatchUpBlocker returns a blocker if the leader is removed while a learner, which receives data
+// from the leader only, is not promoted yet.
+func getLearnerCatchUpBlocker(members []*etcdserverpb.Member, leader uint64, removed []string) *scaleDownBlocker {
+	var leaderName string
+	var learners []string
+	for _, member := range members {
+		if member.ID == leader {
+			leaderName = member.Name
+		}
+		if member.IsLearner && !slices.Contains(removed, member.Name) {
+			learners = append(learners, member.Name)
+		}
+	}
+	if len(learners) == 0 || !slices.Contains(removed, leaderName) {
+		return nil
+	}
+	slices.Sort(learners)
+	return &scaleDownBlocker{
+		reason: etcdaenixiov1alpha1.EtcdCondTypeLearnerCatchingUp,
+		message: fmt.Sprintf("learner %s catches up from leader %s, move leadership or wait for promotion",
+			strings.Join(learners, ", "), leaderName),
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/maintenance"
+)
+
+var _ = Describe("Scale-down safety", func() {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+
+	It("should find members removed by the scale-down", func() {
+		pods := []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-4"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-3"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-2", DeletionTimestamp: ptr.To(metav1.Now())}},
+		}
+		removed, running := getRemovedMembers(pods, 2)
+		Expect(removed).To(Equal([]string{"test-3", "test-4"}))
+		Expect(running).To(Equal(5))
+
+		removed, running = getRemovedMembers(pods, 5)
+		Expect(removed).To(BeEmpty())
+		Expect(running).To(Equal(5))
+	})
+
+	It("should defer removal of members under maintenance", func() {
+		r := &EtcdClusterReconciler{
+			Maintenance: maintenance.NewLimiter(maintenance.DefaultLimits()),
+			Bloat:       maintenance.NewBloatDetector(),
+		}
+		Expect(r.getMaintenanceBlocker(cluster, []string{"test-2"})).To(BeNil())
+
+		release, _, ok := r.Maintenance.TryAcquire(maintenance.OperationSnapshot,
+			maintenance.MemberKey("default", "test", "test-2"))
+		Expect(ok).To(BeTrue())
+		blocker := r.getMaintenanceBlocker(cluster, []string{"test-2"})
+		Expect(blocker).NotTo(BeNil())
+		Expect(blocker.message).To(Equal("snapshot of member test-2 is in progress"))
+		release()
+
+		r.Bloat.Schedule(maintenance.MemberKey("default", "test", ""), []string{"test-2"})
+		Expect(r.getMaintenanceBlocker(cluster, []string{"test-2"})).NotTo(BeNil())
+		Expect((&EtcdClusterReconciler{}).getMaintenanceBlocker(cluster, []string{"test-2"})).To(BeNil())
+	})
+
+	It("should defer removal of the leader while a learner catches up", func() {
+		members := []*etcdserverpb.Member{
+			{ID: 1, Name: "test-0"},
+			{ID: 2, Name: "test-1", IsLearner: true},
+			{ID: 3, Name: "test-2"},
+		}
+		Expect(getLearnerCatchUpBlocker(members, 1, []string{"test-2"})).To(BeNil())
+		Expect(getLearnerCatchUpBlocker(members, 3, []string{"test-1", "test-2"})).To(BeNil())
+
+		blocker := getLearnerCatchUpBlocker(members, 3, []string{"test-2"})
+		Expect(blocker).NotTo(BeNil())
+		Expect(blocker.reason).To(Equal(etcdaenixiov1alpha1.EtcdCondTypeLearnerCatchingUp))
+	})
+})
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// mapSecretToClusters maps events of secrets to reconcile requests of EtcdClusters referencing them in TLS settings.
+func (r *EtcdClusterReconciler) mapSecretToClusters(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusters := &etcdaenixiov1alpha1.EtcdClusterList{}
+	if err := r.List(ctx, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list clusters for secret", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range clusters.Items {
+		if referencesSecret(&clusters.Items[i], obj.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: clusters.Items[i].Name},
+			})
+		}
+	}
+	return requests
+}
+
+// referencesSecret returns true if secret is used in TLS settings of the cluster.
+func referencesSecret(cluster *etcdaenixiov1alpha1.EtcdCluster, name string) bool {
+	if cluster.Spec.Security == nil {
+		return false
+	}
+	tls := cluster.Spec.Security.TLS
+	switch name {
+	case tls.PeerTrustedCASecret, tls.PeerSecret, tls.ServerSecret,
+		tls.ClientTrustedCASecret, tls.ClientSecret, tls.ClientCRLSecret:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// lastReconcileRefreshInterval is how often status.lastReconcile is refreshed while nothing else in the status
+// changes. Status is not written in between, so unchanged clusters do not load the API server.
+const lastReconcileRefreshInterval = 5 * time.Minute
+
+var statusUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "etcd_operator_status_updates_total",
+	Help: "Number of EtcdCluster status updates by result, skipped if the status did not change.",
+}, []string{"result"})
+
+func init() {
+	metrics.Registry.MustRegister(statusUpdates)
+}
+
+// observeStatus remembers the status of the cluster as read at the start of the reconciliation,
+// so it is written only if the reconciliation changes it.
+func observeStatus(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	if decisions := decisionLogFromContext(ctx); decisions != nil {
+		decisions.observed = cluster.Status.DeepCopy()
+	}
+}
+
+// isStatusChanged returns true if the status of the cluster differs from the status observed at the start of
+// the reconciliation. Time and duration of the last reconciliation are ignored unless they are older than
+// lastReconcileRefreshInterval. Status is always changed if it was not observed.
+func isStatusChanged(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	decisions := decisionLogFromContext(ctx)
+	if decisions == nil || decisions.observed == nil {
+		return true
+	}
+	return !isStatusEquivalent(decisions.observed, &cluster.Status, decisions.clock)
+}
+
+// isStatusEquivalent returns true if the statuses differ only in time and duration of a recent last reconciliation.
+func isStatusEquivalent(observed, current *etcdaenixiov1alpha1.EtcdClusterStatus, clk clock.PassiveClock) bool {
+	if (observed.LastReconcile == nil) != (current.LastReconcile == nil) {
+		return false
+	}
+	if observed.LastReconcile != nil && clk.Since(observed.LastReconcile.Time.Time) >= lastReconcileRefreshInterval {
+		return false
+	}
+	observed, current = observed.DeepCopy(), current.DeepCopy()
+	if observed.LastReconcile != nil {
+		observed.LastReconcile.Time, observed.LastReconcile.Duration = current.LastReconcile.Time, current.LastReconcile.Duration
+	}
+	return equality.Semantic.DeepEqual(observed, current)
+}
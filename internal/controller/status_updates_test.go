@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Status updates", func() {
+	var (
+		clock   *clocktesting.FakeClock
+		ctx     context.Context
+		cluster *etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	BeforeEach(func() {
+		clock = clocktesting.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		ctx = withDecisionLog(context.Background(), clock)
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			Status: etcdaenixiov1alpha1.EtcdClusterStatus{
+				CurrentVersion: "3.5.12",
+				LastReconcile:  &etcdaenixiov1alpha1.ReconcileSummary{Time: metav1.NewTime(clock.Now())},
+			},
+		}
+		observeStatus(ctx, cluster)
+		clock.Step(time.Minute)
+	})
+
+	It("should skip the update if only the time of the last reconciliation changed", func() {
+		setLastReconcile(ctx, cluster)
+		Expect(isStatusChanged(ctx, cluster)).To(BeFalse())
+	})
+
+	It("should update the status if the reconciliation changed it", func() {
+		cluster.Status.CurrentVersion = "3.5.13"
+		setLastReconcile(ctx, cluster)
+		Expect(isStatusChanged(ctx, cluster)).To(BeTrue())
+	})
+
+	It("should update the status if decisions of the reconciliation changed", func() {
+		recordSkipped(ctx, "rollout: etcd image signature is not verified")
+		setLastReconcile(ctx, cluster)
+		Expect(isStatusChanged(ctx, cluster)).To(BeTrue())
+	})
+
+	It("should refresh the last reconciliation once it is old", func() {
+		clock.Step(lastReconcileRefreshInterval)
+		setLastReconcile(ctx, cluster)
+		Expect(isStatusChanged(ctx, cluster)).To(BeTrue())
+	})
+
+	It("should update the status which was not observed", func() {
+		Expect(isStatusChanged(context.Background(), cluster)).To(BeTrue())
+	})
+})
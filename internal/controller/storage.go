@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// setEphemeralStorageCondition warns about clusters keeping data in emptyDir, in memory or on disk of nodes.
+// Other clusters have no such condition. It returns true if the condition was added.
+func setEphemeralStorageCondition(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	if cluster.Spec.Storage.EmptyDir == nil {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)
+		return false
+	}
+	reason := etcdaenixiov1alpha1.EtcdCondTypeEmptyDirStorage
+	message := etcdaenixiov1alpha1.EtcdEmptyDirStorageCondMessage
+	if cluster.Spec.Storage.IsMemory() {
+		reason = etcdaenixiov1alpha1.EtcdCondTypeMemoryStorage
+		message = etcdaenixiov1alpha1.EtcdEphemeralStorageCondMessage
+	}
+	added := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage) == nil
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionEphemeralStorage).
+		WithStatus(true).
+		WithReason(string(reason)).
+		WithMessage(string(message)).
+		Complete())
+	return added
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	eventReasonStorageMigrationStarted   = "StorageMigrationStarted"
+	eventReasonStorageMigrationCompleted = "StorageMigrationCompleted"
+	// storageMigrationPollInterval is how often the migration is checked, as crash looping members are waited for.
+	storageMigrationPollInterval = 10 * time.Second
+)
+
+// isStorageMigrationHoldingMembers returns true while the StatefulSet must not be updated from the spec:
+// members are kept on emptyDir until the snapshot is restored, then the StatefulSet is deleted with them.
+func isStorageMigrationHoldingMembers(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	return cluster.Status.StorageMigration != nil &&
+		cluster.Status.StorageMigration.Phase != etcdaenixiov1alpha1.StorageMigrationJoin
+}
+
+// migrateStorage moves data of members from emptyDir to volume claims once spec.storage.emptyDir is replaced by
+// spec.storage.volumeClaimTemplate. A snapshot of the cluster is restored as a single member cluster on the claim
+// of the first member, then the StatefulSet and its members are deleted and created with volume claims, and other
+// members are added to the restored one, one at a time. Progress is reported in status.storageMigration and
+// the StorageMigration condition. Returns the duration after which the migration should be checked again.
+func (r *EtcdClusterReconciler) migrateStorage(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+) (time.Duration, error) {
+	if cluster.Status.StorageMigration == nil {
+		started, err := r.startStorageMigration(ctx, cluster)
+		if err != nil || !started {
+			return 0, err
+		}
+	}
+	switch cluster.Status.StorageMigration.Phase {
+	case etcdaenixiov1alpha1.StorageMigrationSnapshot:
+		return r.restoreStorageMigrationSnapshot(ctx, cluster)
+	case etcdaenixiov1alpha1.StorageMigrationRecreate:
+		return r.recreateMembersOnClaims(ctx, cluster)
+	case etcdaenixiov1alpha1.StorageMigrationJoin:
+		return r.joinRestoredMember(ctx, cluster, conn)
+	}
+	return 0, nil
+}
+
+// startStorageMigration starts the migration if the StatefulSet keeps data in emptyDir, while the spec claims volumes.
+func (r *EtcdClusterReconciler) startStorageMigration(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (bool, error) {
+	if !cluster.Spec.Storage.HasVolumeClaims() || cluster.ManagesMemberPods() {
+		return false, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if !factory.IsEmptyDirPodTemplate(&sts.Spec.Template) {
+		return false, nil
+	}
+	cluster.Status.StorageMigration = &etcdaenixiov1alpha1.StorageMigrationStatus{
+		Phase:     etcdaenixiov1alpha1.StorageMigrationSnapshot,
+		StartTime: metav1.NewTime(r.getClock().Now()),
+	}
+	log.FromContext(ctx).Info("migrating member data from emptyDir to volume claims")
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonStorageMigrationStarted,
+		"Migration of member data from emptyDir to volume claims started")
+	recordAction(ctx, "started storage migration")
+	return true, nil
+}
+
+// restoreStorageMigrationSnapshot runs the storage migration pod, which restores a snapshot of the cluster
+// on the volume claim of the first member. Failed pods are kept for inspection, the snapshot is retried
+// once the pod is deleted.
+func (r *EtcdClusterReconciler) restoreStorageMigrationSnapshot(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (time.Duration, error) {
+	for _, claim := range factory.GetMemberVolumeClaims(cluster, factory.GetMemberPodName(cluster, 0)) {
+		if err := r.Create(ctx, &claim); client.IgnoreAlreadyExists(err) != nil {
+			return 0, fmt.Errorf("cannot create volume claim of the first member: %w", err)
+		}
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetStorageMigrationPodName(cluster)}, pod)
+	if errors.IsNotFound(err) {
+		pod = factory.GetStorageMigrationPod(ctx, cluster)
+		if err := ctrl.SetControllerReference(cluster, pod, r.Scheme); err != nil {
+			return 0, fmt.Errorf("cannot set controller reference: %w", err)
+		}
+		if err := r.Create(ctx, pod); err != nil {
+			return 0, fmt.Errorf("cannot create storage migration pod: %w", err)
+		}
+		recordAction(ctx, "started storage migration pod %s", pod.Name)
+	} else if err != nil {
+		return 0, fmt.Errorf("cannot get storage migration pod: %w", err)
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		log.FromContext(ctx).Info("snapshot restored on volume of the first member, recreating members")
+		cluster.Status.StorageMigration.Phase = etcdaenixiov1alpha1.StorageMigrationRecreate
+		return r.recreateMembersOnClaims(ctx, cluster)
+	case corev1.PodFailed:
+		setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeSnapshotFailed,
+			fmt.Sprintf("Storage migration pod %s failed, members are kept on emptyDir; "+
+				"delete the pod and the volume claim of the first member to retry", pod.Name))
+		return 0, nil
+	}
+	setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeTakingSnapshot,
+		fmt.Sprintf("Storage migration pod %s restores a snapshot of the cluster on volume of the first member", pod.Name))
+	return storageMigrationPollInterval, nil
+}
+
+// recreateMembersOnClaims deletes the StatefulSet keeping data in emptyDir together with its members. Once it is
+// gone, the StatefulSet is created with volume claims and the first member starts from the restored snapshot.
+func (r *EtcdClusterReconciler) recreateMembersOnClaims(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+) (time.Duration, error) {
+	sts := &appsv1.StatefulSet{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts)
+	if client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("cannot get statefulset: %w", err)
+	}
+	if errors.IsNotFound(err) || !factory.IsEmptyDirPodTemplate(&sts.Spec.Template) {
+		cluster.Status.StorageMigration.Phase = etcdaenixiov1alpha1.StorageMigrationJoin
+		setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeJoiningMembers,
+			fmt.Sprintf("Waiting for member %s restored from the snapshot to start", factory.GetMemberPodName(cluster, 0)))
+		return storageMigrationPollInterval, nil
+	}
+	if sts.DeletionTimestamp.IsZero() {
+		// foreground deletion keeps the statefulset until its members are gone, so new members do not clash with them
+		if err := r.Delete(ctx, sts, client.PropagationPolicy(metav1.DeletePropagationForeground)); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("cannot delete statefulset: %w", err)
+		}
+		recordAction(ctx, "deleted statefulset %s with members on emptyDir", sts.Name)
+	}
+	setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeRecreatingMembers,
+		"Members on emptyDir are replaced by members with volume claims")
+	return storageMigrationPollInterval, nil
+}
+
+// joinRestoredMember adds members to the cluster restored on the first member, one at a time. Members fail
+// to start until they are added, so the pod of the added member is deleted to restart it without backoff.
+// The migration completes once all members are started.
+func (r *EtcdClusterReconciler) joinRestoredMember(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+) (time.Duration, error) {
+	firstMember := factory.GetMemberPodName(cluster, 0)
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return 0, err
+	}
+	if len(getMemberEndpoints(cluster, pods, isPodReady)) == 0 {
+		setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeJoiningMembers,
+			fmt.Sprintf("Waiting for member %s restored from the snapshot to start", firstMember))
+		return storageMigrationPollInterval, nil
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		return 0, err
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot list etcd members: %w", err)
+	}
+
+	for ordinal := 1; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+		podName := factory.GetMemberPodName(cluster, ordinal)
+		peerURL := factory.GetMemberPeerURL(cluster, podName)
+		idx := slices.IndexFunc(members.Members, func(member *etcdserverpb.Member) bool {
+			return slices.Contains(member.PeerURLs, peerURL)
+		})
+		if idx != -1 && members.Members[idx].Name != "" {
+			continue
+		}
+		if idx == -1 {
+			foreignID, err := r.getForeignClaimClusterID(ctx, cluster, podName, members.Header.ClusterId)
+			if err != nil {
+				return 0, err
+			}
+			if foreignID != "" {
+				setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeJoiningMembers,
+					fmt.Sprintf("Volume claim of member %s keeps data of etcd cluster %s; delete it to join the member "+
+						"to the cluster restored from the snapshot", podName, foreignID))
+				return storageMigrationPollInterval, nil
+			}
+			if _, err := cli.MemberAdd(reqCtx, []string{peerURL}); err != nil {
+				return 0, fmt.Errorf("cannot add etcd member %s: %w", podName, err)
+			}
+			pod := &corev1.Pod{}
+			pod.Namespace, pod.Name = cluster.Namespace, podName
+			if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+				return 0, fmt.Errorf("cannot delete member pod: %w", err)
+			}
+			recordAction(ctx, "added member %s to the restored cluster", podName)
+		}
+		setStorageMigrationCondition(cluster, etcdaenixiov1alpha1.EtcdCondTypeJoiningMembers,
+			fmt.Sprintf("Waiting for member %s to join the cluster restored from the snapshot", podName))
+		return storageMigrationPollInterval, nil
+	}
+	return 0, r.completeStorageMigration(ctx, cluster)
+}
+
+// completeStorageMigration deletes the storage migration pod and removes the annotation requesting the migration.
+func (r *EtcdClusterReconciler) completeStorageMigration(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	pod := &corev1.Pod{}
+	pod.Namespace, pod.Name = cluster.Namespace, factory.GetStorageMigrationPodName(cluster)
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete storage migration pod: %w", err)
+	}
+	if cluster.IsStorageMigrationRequested() {
+		// the annotation is removed from a copy, so status changes made during the reconciliation are kept
+		patched := cluster.DeepCopy()
+		delete(patched.Annotations, etcdaenixiov1alpha1.StorageMigrationAnnotation)
+		if err := r.Patch(ctx, patched, client.MergeFrom(cluster)); err != nil {
+			return fmt.Errorf("cannot remove storage migration annotation: %w", err)
+		}
+		cluster.Annotations = patched.Annotations
+		cluster.ResourceVersion = patched.ResourceVersion
+	}
+	cluster.Status.StorageMigration = nil
+	meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionStorageMigration)
+	log.FromContext(ctx).Info("member data migrated from emptyDir to volume claims")
+	r.recordEvent(cluster, corev1.EventTypeNormal, eventReasonStorageMigrationCompleted,
+		"Member data was migrated from emptyDir to volume claims")
+	recordAction(ctx, "completed storage migration")
+	return nil
+}
+
+// setStorageMigrationCondition reports the current step of the storage migration.
+func setStorageMigrationCondition(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	reason etcdaenixiov1alpha1.EtcdCondType,
+	message string,
+) {
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionStorageMigration).
+		WithStatus(true).
+		WithReason(string(reason)).
+		WithMessage(message).
+		Complete())
+}
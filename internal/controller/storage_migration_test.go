@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Storage migration", func() {
+	var (
+		reconciler *EtcdClusterReconciler
+		cluster    *etcdaenixiov1alpha1.EtcdCluster
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-storage-migration-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		cluster = &etcdaenixiov1alpha1.EtcdCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   ns.Name,
+				Annotations: map[string]string{etcdaenixiov1alpha1.StorageMigrationAnnotation: "true"},
+			},
+			Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+				Replicas: ptr.To(int32(3)),
+				Storage:  etcdaenixiov1alpha1.StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cluster)).Should(Succeed())
+		Expect(factory.CreateOrUpdateStatefulSet(ctx, cluster, k8sClient)).Should(Succeed())
+
+		cluster.Spec.Storage = etcdaenixiov1alpha1.StorageSpec{
+			VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Update(ctx, cluster)).Should(Succeed())
+	})
+
+	It("should keep members on emptyDir until the snapshot is restored, then recreate them", func(ctx SpecContext) {
+		_, err := reconciler.migrateStorage(ctx, cluster, reconciler.newEtcdConnection(cluster))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Status.StorageMigration).To(HaveField("Phase", etcdaenixiov1alpha1.StorageMigrationSnapshot))
+		Expect(isStorageMigrationHoldingMembers(cluster)).To(BeTrue())
+		cond := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionStorageMigration)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeTakingSnapshot)))
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: "data-test-0"}, claim)).To(Succeed())
+		pod := &corev1.Pod{}
+		Expect(k8sClient.Get(ctx,
+			client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetStorageMigrationPodName(cluster)}, pod)).To(Succeed())
+
+		pod.Status.Phase = corev1.PodSucceeded
+		Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		_, err = reconciler.migrateStorage(ctx, cluster, reconciler.newEtcdConnection(cluster))
+		Expect(err).NotTo(HaveOccurred())
+		sts := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), sts)).To(Succeed())
+		Expect(sts.DeletionTimestamp).NotTo(BeNil())
+		Expect(cluster.Status.StorageMigration.Phase).To(Equal(etcdaenixiov1alpha1.StorageMigrationRecreate))
+		cond = meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionStorageMigration)
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeRecreatingMembers)))
+	})
+
+	It("should not migrate clusters which keep data in emptyDir", func(ctx SpecContext) {
+		cluster.Spec.Storage = etcdaenixiov1alpha1.StorageSpec{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		_, err := reconciler.migrateStorage(ctx, cluster, reconciler.newEtcdConnection(cluster))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Status.StorageMigration).To(BeNil())
+		Expect(isStorageMigrationHoldingMembers(cluster)).To(BeFalse())
+	})
+})
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Ephemeral storage condition", func() {
+	It("should warn about emptyDir storage until it is changed", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		cluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}
+		Expect(setEphemeralStorageCondition(cluster)).To(BeTrue())
+		condition := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeMemoryStorage)))
+
+		cluster.Spec.Storage.EmptyDir.Medium = corev1.StorageMediumDefault
+		Expect(setEphemeralStorageCondition(cluster)).To(BeFalse())
+		condition = factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeEmptyDirStorage)))
+
+		cluster.Spec.Storage.EmptyDir = nil
+		Expect(setEphemeralStorageCondition(cluster)).To(BeFalse())
+		Expect(factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionEphemeralStorage)).To(BeNil())
+	})
+})
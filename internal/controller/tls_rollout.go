@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// tlsRolloutRetryInterval is the time after which the health of members is checked again while restarts loading
+// changed TLS secrets are held.
+const tlsRolloutRetryInterval = 30 * time.Second
+
+// holdTLSRollout holds restarts of members loading changed TLS secrets until all members are healthy according
+// to etcd, so restarts never cost the quorum of a cluster already short of healthy members. Once any member runs
+// with the changed secrets, the rollout continues one member at a time, each waiting for the previous one to be
+// ready, which requires a quorum. Returns the context pod templates are rendered with and the duration after which
+// the health of members is checked again. Standalone clusters are never held, restarting their only member
+// is the only way to apply the secrets.
+func (r *EtcdClusterReconciler) holdTLSRollout(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+) (context.Context, time.Duration, error) {
+	if cluster.Spec.Security == nil || cluster.IsStandalone() {
+		return ctx, 0, nil
+	}
+	desired, err := factory.GetTLSChecksum(ctx, cluster, r.Client)
+	if err != nil {
+		return ctx, 0, err
+	}
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return ctx, 0, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	held, started := getTLSRolloutState(pods, desired)
+	if started {
+		return ctx, 0, nil
+	}
+	healthy, err := r.areAllMembersHealthy(ctx, cluster, conn, pods)
+	if err != nil || healthy {
+		return ctx, 0, err
+	}
+	log.FromContext(ctx).V(2).Info("restarts loading changed TLS secrets are held until all members are healthy")
+	recordSkipped(ctx, "rollout of changed TLS secrets: waiting for all members to be healthy")
+	return factory.WithHeldTLSChecksum(ctx, held), tlsRolloutRetryInterval, nil
+}
+
+// getTLSRolloutState returns the TLS checksum members run with and whether any member runs with the desired one.
+// Clusters without members are reported as started, there is nothing to hold.
+func getTLSRolloutState(pods []corev1.Pod, desired string) (string, bool) {
+	current, found := "", false
+	for i := range pods {
+		if !pods[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		checksum := pods[i].Annotations[factory.TLSChecksumAnnotation]
+		if checksum == desired {
+			return "", true
+		}
+		current, found = checksum, true
+	}
+	return current, !found
+}
+
+// isTLSRolledOut returns true if all members are ready and run with current TLS secrets.
+func (r *EtcdClusterReconciler) isTLSRolledOut(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) (bool, error) {
+	desired, err := factory.GetTLSChecksum(ctx, cluster, r.Client)
+	if err != nil {
+		return false, err
+	}
+	pods, err := r.listClusterPods(ctx, cluster)
+	if err != nil {
+		return false, fmt.Errorf("cannot list member pods: %w", err)
+	}
+	rolledOut := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() && isPodReady(&pods[i]) &&
+			pods[i].Annotations[factory.TLSChecksumAnnotation] == desired {
+			rolledOut++
+		}
+	}
+	return rolledOut == int(ptr.Deref(cluster.Spec.Replicas, 0)), nil
+}
+
+// areAllMembersHealthy returns true if every member of spec.replicas answers status requests with a known leader
+// and no errors and belongs to the recorded etcd cluster.
+func (r *EtcdClusterReconciler) areAllMembersHealthy(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) (bool, error) {
+	if len(getMemberEndpoints(cluster, pods, nil)) == 0 {
+		return false, nil
+	}
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "cannot create etcd client")
+		return false, nil
+	}
+
+	healthy := 0
+	for _, status := range getMemberStatuses(ctx, cli, cluster, pods) {
+		if status.Leader != 0 && len(status.Errors) == 0 && !isForeignMember(cluster, status) {
+			healthy++
+		}
+	}
+	return healthy >= int(ptr.Deref(cluster.Spec.Replicas, 0)), nil
+}
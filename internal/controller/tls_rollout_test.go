@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("TLS rollout", func() {
+	pod := func(checksum string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{factory.TLSChecksumAnnotation: checksum},
+		}}
+	}
+
+	It("should report the checksum members run with until any of them runs the desired one", func() {
+		current, started := getTLSRolloutState([]corev1.Pod{pod("old"), pod("old")}, "new")
+		Expect(started).To(BeFalse())
+		Expect(current).To(Equal("old"))
+
+		_, started = getTLSRolloutState([]corev1.Pod{pod("old"), pod("new")}, "new")
+		Expect(started).To(BeTrue())
+
+		// terminating members do not count
+		terminating := pod("new")
+		terminating.DeletionTimestamp = ptr.To(metav1.Now())
+		_, started = getTLSRolloutState([]corev1.Pod{pod("old"), terminating}, "new")
+		Expect(started).To(BeFalse())
+
+		_, started = getTLSRolloutState(nil, "new")
+		Expect(started).To(BeTrue())
+	})
+})
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// isUpgradeRolledBack returns true if failed upgrade of the current cluster spec was rolled back.
+// StatefulSet is not updated from the spec until the spec is changed.
+func isUpgradeRolledBack(cluster *etcdaenixiov1alpha1.EtcdCluster) bool {
+	cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionUpgradeFailed)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == cluster.Generation
+}
+
+// clearUpgradeRollback resets UpgradeFailed condition once the spec is changed after rollback,
+// so the new spec is rolled out.
+func clearUpgradeRollback(cluster *etcdaenixiov1alpha1.EtcdCluster) {
+	cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionUpgradeFailed)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.ObservedGeneration == cluster.Generation {
+		return
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionUpgradeFailed).
+		WithStatus(false).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeUpgradeSpecChanged)).
+		WithMessage(string(etcdaenixiov1alpha1.EtcdUpgradeFailedCondNegMessage)).
+		Complete())
+}
+
+// checkUpgrade looks for members updated to a new StatefulSet revision that stay not ready longer than UpgradeTimeout.
+// Upgrade is rolled back if such member is found, otherwise the duration until the nearest timeout is returned.
+// Cordoned members never trigger a rollback.
+func (r *EtcdClusterReconciler) checkUpgrade(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) (time.Duration, error) {
+	if r.UpgradeTimeout <= 0 || isUpgradeRolledBack(cluster) {
+		return 0, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cluster), sts); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	if sts.Status.CurrentRevision == "" || sts.Status.UpdateRevision == sts.Status.CurrentRevision {
+		return 0, nil
+	}
+
+	var requeueAfter time.Duration
+	now := r.getClock().Now()
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != sts.Status.UpdateRevision || isPodReady(pod) {
+			continue
+		}
+		if isPodCordoned(cluster, pod) {
+			continue
+		}
+		wait := podNotReadySince(pod).Add(r.UpgradeTimeout).Sub(now)
+		if wait > 0 {
+			if requeueAfter == 0 || wait < requeueAfter {
+				requeueAfter = wait
+			}
+			continue
+		}
+		return 0, r.rollbackUpgrade(ctx, cluster, sts, pod)
+	}
+	return requeueAfter, nil
+}
+
+// rollbackUpgrade restores pod template of the current StatefulSet revision. Members updated after the failing one
+// are rolled back, while the failing member is kept on the new revision by the partition for investigation.
+func (r *EtcdClusterReconciler) rollbackUpgrade(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	sts *appsv1.StatefulSet,
+	pod *corev1.Pod,
+) error {
+	ordinal, err := podOrdinal(pod.Name)
+	if err != nil {
+		return err
+	}
+	template, err := r.getRevisionTemplate(ctx, sts.Namespace, sts.Status.CurrentRevision)
+	if err != nil {
+		return err
+	}
+
+	failedRevision, currentRevision := sts.Status.UpdateRevision, sts.Status.CurrentRevision
+	log.FromContext(ctx).Info("rolling back failed upgrade", "pod", pod.Name,
+		"failed_revision", failedRevision, "revision", currentRevision)
+	sts.Spec.Template = *template
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type: appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+			Partition: ptr.To(int32(ordinal + 1)),
+		},
+	}
+	if err := r.Update(ctx, sts); err != nil {
+		return fmt.Errorf("cannot roll back StatefulSet: %w", err)
+	}
+	recordAction(ctx, "rolled back upgrade to revision %s, member %s is not ready", currentRevision, pod.Name)
+
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionUpgradeFailed).
+		WithStatus(true).
+		WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeUpgradeRolledBack)).
+		WithMessage(fmt.Sprintf("member pod %s of revision %s is not ready for more than %s, "+
+			"cluster is rolled back to revision %s and the member is quarantined; change the spec to retry",
+			pod.Name, failedRevision, r.UpgradeTimeout, currentRevision)).
+		Complete())
+	return nil
+}
+
+// getRevisionTemplate returns pod template stored in StatefulSet controller revision.
+func (r *EtcdClusterReconciler) getRevisionTemplate(ctx context.Context, namespace, name string) (*corev1.PodTemplateSpec, error) {
+	revision := &appsv1.ControllerRevision{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, revision); err != nil {
+		return nil, fmt.Errorf("cannot get controller revision %s: %w", name, err)
+	}
+	// revision data is a patch of StatefulSet replacing its pod template
+	patch := struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(revision.Data.Raw, &patch); err != nil {
+		return nil, fmt.Errorf("cannot decode controller revision %s: %w", name, err)
+	}
+	return &patch.Spec.Template, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func podNotReadySince(pod *corev1.Pod) time.Time {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && !cond.LastTransitionTime.IsZero() {
+			return cond.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
+
+func podOrdinal(podName string) (int, error) {
+	idx := strings.LastIndex(podName, "-")
+	ordinal, err := strconv.Atoi(podName[idx+1:])
+	if err != nil {
+		return 0, fmt.Errorf("cannot get ordinal of pod %s: %w", podName, err)
+	}
+	return ordinal, nil
+}
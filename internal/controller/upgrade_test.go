@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	. "sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Upgrade rollback", func() {
+	It("should keep rollback until the spec is changed", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+		factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionUpgradeFailed).
+			WithStatus(true).
+			WithReason(string(etcdaenixiov1alpha1.EtcdCondTypeUpgradeRolledBack)).
+			Complete())
+		clearUpgradeRollback(cluster)
+		Expect(isUpgradeRolledBack(cluster)).To(BeTrue())
+
+		cluster.Generation = 3
+		clearUpgradeRollback(cluster)
+		Expect(isUpgradeRolledBack(cluster)).To(BeFalse())
+		cond := factory.GetCondition(cluster, etcdaenixiov1alpha1.EtcdConditionUpgradeFailed)
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeUpgradeSpecChanged)))
+	})
+
+	It("should get pod ordinal", func() {
+		Expect(podOrdinal("test-etcd-2")).To(Equal(2))
+		_, err := podOrdinal("test")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should roll back StatefulSet to the current revision", func(ctx SpecContext) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ns)
+
+		template := func(image string) corev1.PodTemplateSpec {
+			return corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "etcd"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "etcd", Image: image}},
+				},
+			}
+		}
+		sts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "test"},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas: ptr.To(int32(3)),
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "etcd"}},
+				Template: template("quay.io/coreos/etcd:v3.5.13"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, sts)).Should(Succeed())
+
+		data, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{"template": template("quay.io/coreos/etcd:v3.5.12")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		revision := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: "test-old"},
+			Data:       runtime.RawExtension{Raw: data},
+			Revision:   1,
+		}
+		Expect(k8sClient.Create(ctx, revision)).Should(Succeed())
+		sts.Status.CurrentRevision = "test-old"
+		sts.Status.UpdateRevision = "test-new"
+
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-2"}}
+		reconciler := &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), UpgradeTimeout: time.Minute}
+		Expect(reconciler.rollbackUpgrade(ctx, cluster, sts, pod)).To(Succeed())
+
+		Eventually(Object(sts)).Should(HaveField("Spec.Template.Spec.Containers",
+			ContainElement(HaveField("Image", "quay.io/coreos/etcd:v3.5.12"))))
+		Expect(sts.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(ptr.To(int32(3))))
+		Expect(isUpgradeRolledBack(cluster)).To(BeTrue())
+	})
+})
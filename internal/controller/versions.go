@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// updateVersionStatus reflects etcd versions and IDs of members running on member pods and version of the spec
+// in cluster status. Members which can not be reached are listed without version and IDs.
+func (r *EtcdClusterReconciler) updateVersionStatus(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) {
+	logger := log.FromContext(ctx)
+	cluster.Status.TargetVersion = cluster.TargetVersion()
+
+	members := make([]etcdaenixiov1alpha1.MemberStatus, 0, len(pods))
+	for i := range pods {
+		members = append(members, etcdaenixiov1alpha1.MemberStatus{Name: pods[i].Name})
+	}
+	slices.SortFunc(members, func(a, b etcdaenixiov1alpha1.MemberStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if endpoints := getMemberEndpoints(cluster, pods, nil); len(endpoints) > 0 {
+		cli, err := conn.Client(ctx, pods)
+		if err != nil {
+			logger.Error(err, "cannot create etcd client")
+		} else {
+			statuses := getMemberStatuses(ctx, cli, cluster, pods)
+			for i := range members {
+				if status, ok := statuses[members[i].Name]; ok {
+					members[i].Version = status.Version
+					members[i].ID = strconv.FormatUint(status.Header.MemberId, 16)
+					members[i].ClusterID = strconv.FormatUint(status.Header.ClusterId, 16)
+				}
+			}
+		}
+	}
+
+	cluster.Status.Members = members
+	cluster.Status.CurrentVersion = lowestVersion(members)
+}
+
+// lowestVersion returns the lowest version among members, members with unknown versions are ignored.
+func lowestVersion(members []etcdaenixiov1alpha1.MemberStatus) string {
+	var lowest *utilversion.Version
+	result := ""
+	for _, member := range members {
+		version, err := utilversion.ParseGeneric(member.Version)
+		if err != nil {
+			continue
+		}
+		if lowest == nil || version.LessThan(lowest) {
+			lowest = version
+			result = member.Version
+		}
+	}
+	return result
+}
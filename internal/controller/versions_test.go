@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Member versions", func() {
+	It("should return the lowest known version", func() {
+		Expect(lowestVersion([]etcdaenixiov1alpha1.MemberStatus{
+			{Name: "test-0", Version: "3.5.13"},
+			{Name: "test-1"},
+			{Name: "test-2", Version: "3.5.9"},
+		})).To(Equal("3.5.9"))
+		Expect(lowestVersion([]etcdaenixiov1alpha1.MemberStatus{{Name: "test-0"}})).To(BeEmpty())
+	})
+
+	It("should list members not running yet without version", func(ctx SpecContext) {
+		reconciler := &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+		pods := []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}
+		reconciler.updateVersionStatus(ctx, cluster, reconciler.newEtcdConnection(cluster), pods)
+		Expect(cluster.Status.TargetVersion).To(Equal("3.5.12"))
+		Expect(cluster.Status.CurrentVersion).To(BeEmpty())
+		Expect(cluster.Status.Members).To(Equal([]etcdaenixiov1alpha1.MemberStatus{{Name: "test-0"}, {Name: "test-1"}}))
+	})
+})
@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	// volumeExpansionRequeueAfter is how often progress of volume expansion is checked.
+	volumeExpansionRequeueAfter = 30 * time.Second
+	// fileSystemResizeGracePeriod gives kubelet time to resize file systems of mounted volumes online,
+	// members are restarted only if their file system resize is still pending afterwards.
+	fileSystemResizeGracePeriod = 2 * time.Minute
+)
+
+// volumeExpansion collects member volume claims smaller than the spec requests.
+type volumeExpansion struct {
+	// resizing claims wait for their volumes to be expanded by the storage provider.
+	resizing []string
+	// pending claims wait for file systems of their expanded volumes to be resized on the node.
+	pending []string
+	// unsupported claims belong to storage classes which do not allow volume expansion.
+	unsupported []string
+	// restartable are members whose file system resize is pending longer than the grace period.
+	restartable []string
+	// capacity is the largest capacity provisioned for data volume claims of members.
+	capacity resource.Quantity
+}
+
+// expandVolumes grows volume claims of members to the storage requested by the spec. Claims of storage classes
+// not allowing volume expansion are left as they are. Members whose file systems are still not resized after
+// the grace period are restarted, so their volumes are resized while they are not mounted. One member is
+// restarted per reconciliation and only while all other members are ready.
+func (r *EtcdClusterReconciler) expandVolumes(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) (time.Duration, error) {
+	expansion := volumeExpansion{}
+	for ordinal := 0; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+		podName := factory.GetMemberPodName(cluster, ordinal)
+		for _, desired := range factory.GetMemberVolumeClaims(cluster, podName) {
+			if err := r.expandVolumeClaim(ctx, cluster, podName, &desired, &expansion); err != nil {
+				return 0, err
+			}
+		}
+	}
+	setVolumeExpansionCondition(cluster, expansion)
+	if !expansion.capacity.IsZero() {
+		cluster.Status.StorageCapacity = &expansion.capacity
+	}
+
+	if len(expansion.restartable) > 0 {
+		if err := r.restartResizePendingMember(ctx, cluster, expansion.restartable[0], pods); err != nil {
+			return 0, err
+		}
+	}
+	if len(expansion.resizing) > 0 || len(expansion.pending) > 0 {
+		return volumeExpansionRequeueAfter, nil
+	}
+	return 0, nil
+}
+
+// expandVolumeClaim requests storage of the desired claim for the existing claim of the member and records
+// the expansion progress and the provisioned capacity.
+func (r *EtcdClusterReconciler) expandVolumeClaim(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	podName string,
+	desired *corev1.PersistentVolumeClaim,
+	expansion *volumeExpansion,
+) error {
+	claim := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), claim); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !claim.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if capacity := claim.Status.Capacity.Storage(); claim.Name == factory.GetMemberPVCName(cluster, podName) &&
+		capacity.Cmp(expansion.capacity) > 0 {
+		expansion.capacity = capacity.DeepCopy()
+	}
+	request := desired.Spec.Resources.Requests.Storage()
+	if claim.Spec.Resources.Requests.Storage().Cmp(*request) < 0 {
+		expandable, err := r.isVolumeClaimExpandable(ctx, claim)
+		if err != nil {
+			return err
+		}
+		if !expandable {
+			recordSkipped(ctx, "expansion of volume claim %s: storage class does not allow volume expansion", claim.Name)
+			expansion.unsupported = append(expansion.unsupported, claim.Name)
+			return nil
+		}
+		patch := client.MergeFrom(claim.DeepCopy())
+		if claim.Spec.Resources.Requests == nil {
+			claim.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = *request
+		if err := r.Patch(ctx, claim, patch); err != nil {
+			return fmt.Errorf("cannot expand volume claim %s: %w", claim.Name, err)
+		}
+		log.FromContext(ctx).Info("expanding member volume claim", "pvc_name", claim.Name, "storage", request.String())
+		recordAction(ctx, "expanded volume claim %s to %s", claim.Name, request)
+		r.recordEvent(cluster, corev1.EventTypeNormal, "VolumeExpanding",
+			fmt.Sprintf("Expanding volume claim %s to %s", claim.Name, request))
+	}
+	if claim.Status.Capacity.Storage().Cmp(*request) >= 0 {
+		return nil
+	}
+	resizePending := getFileSystemResizePending(claim)
+	if resizePending == nil {
+		expansion.resizing = append(expansion.resizing, claim.Name)
+		return nil
+	}
+	expansion.pending = append(expansion.pending, claim.Name)
+	if r.getClock().Since(resizePending.LastTransitionTime.Time) >= fileSystemResizeGracePeriod {
+		expansion.restartable = append(expansion.restartable, podName)
+	}
+	return nil
+}
+
+// isVolumeClaimExpandable returns true if the storage class of the claim allows volume expansion.
+func (r *EtcdClusterReconciler) isVolumeClaimExpandable(ctx context.Context, claim *corev1.PersistentVolumeClaim) (bool, error) {
+	className := ptr.Deref(claim.Spec.StorageClassName, "")
+	if className == "" {
+		return false, nil
+	}
+	class := &storagev1.StorageClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: className}, class); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot get storage class %s: %w", className, err)
+	}
+	return ptr.Deref(class.AllowVolumeExpansion, false), nil
+}
+
+// getFileSystemResizePending returns the condition of the claim waiting for its file system to be resized.
+func getFileSystemResizePending(claim *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaimCondition {
+	for i := range claim.Status.Conditions {
+		condition := &claim.Status.Conditions[i]
+		if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// restartResizePendingMember deletes the member pod, so file systems of its volumes are resized before they are
+// mounted again. The member is not restarted unless all other members are ready.
+func (r *EtcdClusterReconciler) restartResizePendingMember(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	podName string,
+	pods []corev1.Pod,
+) error {
+	var pod *corev1.Pod
+	for i := range pods {
+		if pods[i].Name == podName {
+			pod = &pods[i]
+			continue
+		}
+		if !isPodReady(&pods[i]) {
+			recordSkipped(ctx, "restart of member %s for file system resize: member %s is not ready", podName, pods[i].Name)
+			return nil
+		}
+	}
+	if pod == nil || !pod.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	log.FromContext(ctx).Info("restarting member to resize file systems of its volumes", "member", podName)
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete member pod: %w", err)
+	}
+	recordAction(ctx, "restarted member %s to resize file systems of its volumes", podName)
+	r.recordEvent(cluster, corev1.EventTypeNormal, "VolumeResizeRestart",
+		fmt.Sprintf("Restarted member %s to resize file systems of its volumes", podName))
+	return nil
+}
+
+// setVolumeExpansionCondition reflects progress of volume expansion in the VolumeExpansion condition.
+// Clusters have no such condition while member volume claims have the requested storage.
+func setVolumeExpansionCondition(cluster *etcdaenixiov1alpha1.EtcdCluster, expansion volumeExpansion) {
+	var reason etcdaenixiov1alpha1.EtcdCondType
+	var claims []string
+	switch {
+	case len(expansion.unsupported) > 0:
+		reason, claims = etcdaenixiov1alpha1.EtcdCondTypeExpansionNotSupported, expansion.unsupported
+	case len(expansion.resizing) > 0:
+		reason, claims = etcdaenixiov1alpha1.EtcdCondTypeVolumeResizing, expansion.resizing
+	case len(expansion.pending) > 0:
+		reason, claims = etcdaenixiov1alpha1.EtcdCondTypeResizePending, expansion.pending
+	default:
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)
+		return
+	}
+	messages := map[etcdaenixiov1alpha1.EtcdCondType]string{
+		etcdaenixiov1alpha1.EtcdCondTypeExpansionNotSupported: "storage class does not allow volume expansion of claims",
+		etcdaenixiov1alpha1.EtcdCondTypeVolumeResizing:        "waiting for volumes of claims to be expanded",
+		etcdaenixiov1alpha1.EtcdCondTypeResizePending:         "waiting for file systems of claims to be resized",
+	}
+	factory.SetCondition(cluster, factory.NewCondition(etcdaenixiov1alpha1.EtcdConditionVolumeExpansion).
+		WithStatus(true).
+		WithReason(string(reason)).
+		WithMessage(messages[reason]+": "+strings.Join(claims, ", ")).
+		Complete())
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Volume expansion", func() {
+	It("should report the least advanced expansion", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		setVolumeExpansionCondition(cluster, volumeExpansion{pending: []string{"data-test-0"}, resizing: []string{"data-test-1"}})
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeVolumeResizing)))
+		Expect(condition.Message).To(HaveSuffix("data-test-1"))
+
+		setVolumeExpansionCondition(cluster, volumeExpansion{})
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)).To(BeNil())
+	})
+
+	Context("with member volume claims", func() {
+		var (
+			reconciler *EtcdClusterReconciler
+			clock      *clocktesting.FakePassiveClock
+			cluster    *etcdaenixiov1alpha1.EtcdCluster
+			claim      *corev1.PersistentVolumeClaim
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-volume-expansion-"}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+
+			class := &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{GenerateName: "expandable-"},
+				Provisioner:          "example.com/csi",
+				AllowVolumeExpansion: ptr.To(true),
+			}
+			Expect(k8sClient.Create(ctx, class)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, class)
+
+			clock = clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+			reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Clock: clock}
+			cluster = &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas: ptr.To(int32(1)),
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+							Spec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: ptr.To(class.Name),
+								AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+								Resources: corev1.VolumeResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			claims := factory.GetMemberVolumeClaims(cluster, factory.GetMemberPodName(cluster, 0))
+			Expect(claims).To(HaveLen(1))
+			claim = &claims[0]
+			Expect(k8sClient.Create(ctx, claim)).Should(Succeed())
+			// claims are resized only once bound to a volume
+			claim.Status.Phase = corev1.ClaimBound
+			claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+			Expect(k8sClient.Status().Update(ctx, claim)).Should(Succeed())
+		})
+
+		It("should request the storage of the spec and wait for the volume", func(ctx SpecContext) {
+			requeueAfter, err := reconciler.expandVolumes(ctx, cluster, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+			Expect(meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)).To(BeNil())
+			Expect(cluster.Status.StorageCapacity.String()).To(Equal("1Gi"))
+
+			cluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+			requeueAfter, err = reconciler.expandVolumes(ctx, cluster, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(Equal(volumeExpansionRequeueAfter))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)).To(Succeed())
+			Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("2Gi"))
+			condition := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeVolumeResizing)))
+
+			claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+			Expect(k8sClient.Status().Update(ctx, claim)).Should(Succeed())
+			requeueAfter, err = reconciler.expandVolumes(ctx, cluster, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+			Expect(meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)).To(BeNil())
+			Expect(cluster.Status.StorageCapacity.String()).To(Equal("2Gi"))
+		})
+
+		It("should restart the member whose file system resize is pending", func(ctx SpecContext) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: factory.GetMemberPodName(cluster, 0), Namespace: cluster.Namespace},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "etcd"}}},
+			}
+			Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+
+			cluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+			claim.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+				Type:               corev1.PersistentVolumeClaimFileSystemResizePending,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(clock.Now()),
+			}}
+			Expect(k8sClient.Status().Update(ctx, claim)).Should(Succeed())
+
+			_, err := reconciler.expandVolumes(ctx, cluster, []corev1.Pod{*pod})
+			Expect(err).NotTo(HaveOccurred())
+			condition := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionVolumeExpansion)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(string(etcdaenixiov1alpha1.EtcdCondTypeResizePending)))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			Expect(pod.DeletionTimestamp).To(BeNil())
+
+			clock.SetTime(clock.Now().Add(fileSystemResizeGracePeriod))
+			_, err = reconciler.expandVolumes(ctx, cluster, []corev1.Pod{*pod})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+				return err != nil || !pod.DeletionTimestamp.IsZero()
+			}).Should(BeTrue())
+		})
+	})
+})
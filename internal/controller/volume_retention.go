@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// reconcileVolumeRetention applies spec.storage.retentionPolicy to volume claims of members. Claims deleted with
+// the cluster are owned by it, so they are garbage collected, and claims of members removed by scale-down are
+// deleted once their pods are gone. Claims are left as they are by the Retain policy, except that ownership
+// added by the Delete policy is removed again.
+func (r *EtcdClusterReconciler) reconcileVolumeRetention(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	pods []corev1.Pod,
+) error {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return nil
+	}
+	claims := &corev1.PersistentVolumeClaimList{}
+	err := r.List(ctx, claims,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(factory.NewLabelsBuilder().WithName().WithInstance(cluster.Name).WithManagedBy()),
+	)
+	if err != nil {
+		return fmt.Errorf("cannot list member PVCs: %w", err)
+	}
+	replicas := int(ptr.Deref(cluster.Spec.Replicas, 0))
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		podName, ordinal, found := getClaimMember(cluster, claim.Name)
+		if !found || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if ordinal >= replicas && cluster.Spec.Storage.DeletesClaimsWhenScaled() {
+			if slices.ContainsFunc(pods, func(pod corev1.Pod) bool { return pod.Name == podName }) {
+				recordSkipped(ctx, "deletion of volume claim %s: member %s is not removed yet", claim.Name, podName)
+				continue
+			}
+			log.FromContext(ctx).Info("deleting volume claim of removed member", "pvc_name", claim.Name)
+			if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("cannot delete member PVC %s: %w", claim.Name, err)
+			}
+			recordAction(ctx, "deleted volume claim %s of removed member %s", claim.Name, podName)
+			continue
+		}
+		if err := r.updateClaimOwnership(ctx, cluster, claim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateClaimOwnership makes the cluster an owner of the member volume claim if claims are deleted together with
+// the cluster, and removes such ownership otherwise.
+func (r *EtcdClusterReconciler) updateClaimOwnership(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	claim *corev1.PersistentVolumeClaim,
+) error {
+	patch := client.MergeFrom(claim.DeepCopy())
+	owned := slices.ContainsFunc(claim.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.UID == cluster.UID
+	})
+	switch deleted := cluster.Spec.Storage.DeletesClaimsWhenDeleted(); {
+	case deleted && !owned:
+		if err := controllerutil.SetOwnerReference(cluster, claim, r.Scheme); err != nil {
+			return fmt.Errorf("cannot set owner reference: %w", err)
+		}
+	case !deleted && owned:
+		if err := controllerutil.RemoveOwnerReference(cluster, claim, r.Scheme); err != nil {
+			return fmt.Errorf("cannot remove owner reference: %w", err)
+		}
+	default:
+		return nil
+	}
+	if err := r.Patch(ctx, claim, patch); err != nil {
+		return fmt.Errorf("cannot update owner of member PVC %s: %w", claim.Name, err)
+	}
+	return nil
+}
+
+// getClaimMember returns name and ordinal of the member pod the data or write-ahead log volume claim belongs to.
+func getClaimMember(cluster *etcdaenixiov1alpha1.EtcdCluster, claimName string) (string, int, bool) {
+	for _, volume := range []string{factory.GetPVCName(cluster), factory.GetWALPVCName(cluster)} {
+		podName, found := strings.CutPrefix(claimName, volume+"-")
+		if !found {
+			continue
+		}
+		suffix, found := strings.CutPrefix(podName, cluster.Name+"-")
+		if !found {
+			continue
+		}
+		ordinal, err := strconv.Atoi(suffix)
+		if err != nil || ordinal < 0 || factory.GetMemberPodName(cluster, ordinal) != podName {
+			continue
+		}
+		return podName, ordinal, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+var _ = Describe("Volume retention", func() {
+	It("should find members of data and write-ahead log claims", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+		cluster.Spec.Storage.VolumeClaimTemplate.Name = "etcd"
+
+		podName, ordinal, found := getClaimMember(cluster, "etcd-test-3")
+		Expect(found).To(BeTrue())
+		Expect(podName).To(Equal("test-3"))
+		Expect(ordinal).To(Equal(3))
+		_, ordinal, found = getClaimMember(cluster, "wal-test-1")
+		Expect(found).To(BeTrue())
+		Expect(ordinal).To(Equal(1))
+
+		for _, name := range []string{"data-test-0", "etcd-test-a", "etcd-test-01", "etcd-other-0", "etcd-test--1"} {
+			_, _, found = getClaimMember(cluster, name)
+			Expect(found).To(BeFalse(), name)
+		}
+	})
+
+	Context("with member volume claims", func() {
+		var (
+			reconciler *EtcdClusterReconciler
+			cluster    *etcdaenixiov1alpha1.EtcdCluster
+			claims     []corev1.PersistentVolumeClaim
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-volume-retention-"}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+
+			reconciler = &EtcdClusterReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			cluster = &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas: ptr.To(int32(2)),
+					Storage: etcdaenixiov1alpha1.StorageSpec{
+						VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+							Spec: corev1.PersistentVolumeClaimSpec{
+								AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+								Resources: corev1.VolumeResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+								},
+							},
+						},
+						RetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+							WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+							WhenScaled:  appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, cluster)).Should(Succeed())
+
+			claims = nil
+			for ordinal := range 3 {
+				claim := factory.GetMemberVolumeClaims(cluster, factory.GetMemberPodName(cluster, ordinal))[0]
+				Expect(k8sClient.Create(ctx, &claim)).Should(Succeed())
+				claims = append(claims, claim)
+			}
+		})
+
+		It("should let claims be garbage collected with the cluster", func(ctx SpecContext) {
+			Expect(reconciler.reconcileVolumeRetention(ctx, cluster, nil)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&claims[0]), &claims[0])).To(Succeed())
+			Expect(claims[0].OwnerReferences).To(ContainElement(HaveField("UID", cluster.UID)))
+
+			cluster.Spec.Storage.RetentionPolicy.WhenDeleted = appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+			Expect(reconciler.reconcileVolumeRetention(ctx, cluster, nil)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&claims[0]), &claims[0])).To(Succeed())
+			Expect(claims[0].OwnerReferences).To(BeEmpty())
+		})
+
+		It("should delete claims of removed members once their pods are gone", func(ctx SpecContext) {
+			removed := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: factory.GetMemberPodName(cluster, 2)}}
+			Expect(reconciler.reconcileVolumeRetention(ctx, cluster, []corev1.Pod{removed})).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&claims[2]), &claims[2])).To(Succeed())
+			Expect(claims[2].DeletionTimestamp).To(BeNil())
+
+			Expect(reconciler.reconcileVolumeRetention(ctx, cluster, nil)).To(Succeed())
+			// claims may be kept by the protection finalizer, which is not removed without the controller manager
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&claims[2]), &claims[2])
+			Expect(apierrors.IsNotFound(err) || err == nil && !claims[2].DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&claims[1]), &claims[1])).To(Succeed())
+			Expect(claims[1].DeletionTimestamp).To(BeNil())
+		})
+	})
+})
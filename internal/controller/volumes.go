@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+const (
+	labelHostname = "kubernetes.io/hostname"
+	// defaultMemberReplacementGracePeriod tolerates node reboots and upgrades before members are replaced.
+	defaultMemberReplacementGracePeriod = 5 * time.Minute
+)
+
+// replaceLostMember recreates a member whose data volume is bound to a node which does not exist anymore,
+// e.g. a local PersistentVolume of a deleted node, or whose volume is gone, so its claim is Lost. The member is
+// removed from etcd and added back with the same peer URL, then its claim and pod are deleted, so the member starts
+// with a new volume elsewhere and joins the cluster. One member is replaced per reconciliation and only while other
+// members are reachable. Cordoned members are never replaced, other members are replaced once their pods are not
+// ready longer than the replacement grace period, otherwise the duration until the grace period ends is returned.
+func (r *EtcdClusterReconciler) replaceLostMember(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	pods []corev1.Pod,
+) (time.Duration, error) {
+	if !cluster.Spec.Storage.HasVolumeClaims() {
+		return 0, nil
+	}
+	var requeueAfter time.Duration
+	for ordinal := 0; ordinal < int(ptr.Deref(cluster.Spec.Replicas, 0)); ordinal++ {
+		if cluster.IsMemberCordoned(ordinal) {
+			continue
+		}
+		podName := factory.GetMemberPodName(cluster, ordinal)
+		claim := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetMemberPVCName(cluster, podName)}, claim)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return 0, err
+		}
+		if claim.Spec.VolumeName == "" || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// the volume of a lost claim was deleted, e.g. together with its node, so the pod can't start anywhere
+		lost := claim.Status.Phase == corev1.ClaimLost
+		if !lost {
+			if lost, err = r.isVolumeNodeLost(ctx, claim.Spec.VolumeName); err != nil {
+				return 0, err
+			}
+		}
+		if !lost {
+			continue
+		}
+		wait := getReplacementGraceRemaining(cluster, podName, pods, r.getClock().Now())
+		if wait > 0 {
+			log.FromContext(ctx).V(2).Info("member volume node is lost, waiting for the replacement grace period",
+				"member", podName, "wait", wait.String())
+			recordSkipped(ctx, "replacement of member %s: waiting %s for the grace period", podName, wait.Round(time.Second))
+			requeueAfter = earliestRequeue(requeueAfter, wait)
+			continue
+		}
+		return 0, r.replaceMember(ctx, cluster, conn, podName, claim, pods)
+	}
+	return requeueAfter, nil
+}
+
+// getReplacementGraceRemaining returns how long the member should stay unhealthy before it may be replaced,
+// counting from the moment its pod became not ready. Ready members and members without pods wait the whole period.
+func getReplacementGraceRemaining(
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	podName string,
+	pods []corev1.Pod,
+	now time.Time,
+) time.Duration {
+	gracePeriod := defaultMemberReplacementGracePeriod
+	if cluster.Spec.MemberReplacementGracePeriod != nil {
+		gracePeriod = cluster.Spec.MemberReplacementGracePeriod.Duration
+	}
+	for i := range pods {
+		if pods[i].Name != podName {
+			continue
+		}
+		if isPodReady(&pods[i]) {
+			return gracePeriod
+		}
+		return max(podNotReadySince(&pods[i]).Add(gracePeriod).Sub(now), 0)
+	}
+	return gracePeriod
+}
+
+// isVolumeNodeLost returns true if the volume is pinned to a single node by hostname and no such node exists.
+func (r *EtcdClusterReconciler) isVolumeNodeLost(ctx context.Context, volumeName string) (bool, error) {
+	volume := &corev1.PersistentVolume{}
+	if err := r.Get(ctx, client.ObjectKey{Name: volumeName}, volume); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	hostname := getVolumeHostname(volume)
+	if hostname == "" {
+		return false, nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes, client.MatchingLabels{labelHostname: hostname}); err != nil {
+		return false, err
+	}
+	return len(nodes.Items) == 0, nil
+}
+
+// getVolumeHostname returns hostname of the only node the volume is accessible from, as set on local volumes.
+func getVolumeHostname(volume *corev1.PersistentVolume) string {
+	if volume.Spec.NodeAffinity == nil || volume.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	terms := volume.Spec.NodeAffinity.Required.NodeSelectorTerms
+	if len(terms) != 1 {
+		return ""
+	}
+	for _, expr := range terms[0].MatchExpressions {
+		if expr.Key == labelHostname && expr.Operator == corev1.NodeSelectorOpIn && len(expr.Values) == 1 {
+			return expr.Values[0]
+		}
+	}
+	return ""
+}
+
+func (r *EtcdClusterReconciler) replaceMember(
+	ctx context.Context,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conn *etcdConnection,
+	podName string,
+	claim *corev1.PersistentVolumeClaim,
+	pods []corev1.Pod,
+) error {
+	logger := log.FromContext(ctx).WithValues("member", podName)
+	endpoints := getMemberEndpoints(cluster, pods, func(pod *corev1.Pod) bool {
+		return pod.Name != podName && isPodReady(pod)
+	})
+	if len(endpoints) == 0 {
+		logger.Info("member volume node is lost, but no other member is ready to replace it")
+		recordSkipped(ctx, "replacement of member %s: no other member is ready", podName)
+		return nil
+	}
+
+	cli, err := conn.Client(ctx, pods)
+	if err != nil {
+		return err
+	}
+
+	peerURL := factory.GetMemberPeerURL(cluster, podName)
+	reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return fmt.Errorf("cannot list etcd members: %w", err)
+	}
+	// members added but not started yet have no name, the recorded ID covers members moved to another peer URL
+	recordedID := getRecordedMemberID(cluster, podName)
+	for _, member := range members.Members {
+		if member.Name == podName || slices.Contains(member.PeerURLs, peerURL) ||
+			strconv.FormatUint(member.ID, 16) == recordedID {
+			if _, err := cli.MemberRemove(reqCtx, member.ID); err != nil {
+				return fmt.Errorf("cannot remove etcd member %s: %w", podName, err)
+			}
+		}
+	}
+	added, err := cli.MemberAdd(reqCtx, []string{peerURL})
+	if err != nil {
+		return fmt.Errorf("cannot add etcd member %s: %w", podName, err)
+	}
+	// the recreated pod runs the added member
+	setMemberIdentity(ctx, cluster, podName, strconv.FormatUint(added.Member.ID, 16))
+
+	logger.Info("member volume node is lost, recreating member with new volume", "pvc_name", claim.Name)
+	if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete member PVC: %w", err)
+	}
+	// the log of the removed member can't be replayed on the new data volume
+	walClaim := &corev1.PersistentVolumeClaim{}
+	walClaim.Namespace, walClaim.Name = cluster.Namespace, factory.GetMemberWALPVCName(cluster, podName)
+	if err := r.Delete(ctx, walClaim); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete member WAL PVC: %w", err)
+	}
+	pod := &corev1.Pod{}
+	pod.Namespace, pod.Name = cluster.Namespace, podName
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot delete member pod: %w", err)
+	}
+	recordAction(ctx, "replaced member %s whose volume node is lost", podName)
+	return nil
+}
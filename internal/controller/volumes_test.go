@@ -0,0 +1,242 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+)
+
+// fakeEtcdMembers serves membership requests of etcd from memory.
+type fakeEtcdMembers struct {
+	clientv3.Cluster
+	members []*etcdserverpb.Member
+	removed []uint64
+}
+
+func (f *fakeEtcdMembers) MemberList(context.Context) (*clientv3.MemberListResponse, error) {
+	return &clientv3.MemberListResponse{Members: slices.Clone(f.members)}, nil
+}
+
+func (f *fakeEtcdMembers) MemberAdd(_ context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	member := &etcdserverpb.Member{ID: uint64(len(f.members) + len(f.removed) + 1), PeerURLs: peerURLs}
+	f.members = append(f.members, member)
+	return &clientv3.MemberAddResponse{Member: member}, nil
+}
+
+func (f *fakeEtcdMembers) MemberRemove(_ context.Context, id uint64) (*clientv3.MemberRemoveResponse, error) {
+	f.members = slices.DeleteFunc(f.members, func(member *etcdserverpb.Member) bool { return member.ID == id })
+	f.removed = append(f.removed, id)
+	return &clientv3.MemberRemoveResponse{}, nil
+}
+
+var _ = Describe("Lost member volumes", func() {
+	volumeWithAffinity := func(terms ...corev1.NodeSelectorTerm) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{NodeSelectorTerms: terms}},
+		}}
+	}
+	hostnameTerm := func(values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: labelHostname, Operator: corev1.NodeSelectorOpIn, Values: values},
+		}}
+	}
+
+	It("should find node of local volumes", func() {
+		Expect(getVolumeHostname(volumeWithAffinity(hostnameTerm("node-a")))).To(Equal("node-a"))
+	})
+
+	It("should ignore volumes accessible from several nodes", func() {
+		Expect(getVolumeHostname(&corev1.PersistentVolume{})).To(BeEmpty())
+		Expect(getVolumeHostname(volumeWithAffinity(hostnameTerm("node-a", "node-b")))).To(BeEmpty())
+		Expect(getVolumeHostname(volumeWithAffinity(hostnameTerm("node-a"), hostnameTerm("node-b")))).To(BeEmpty())
+	})
+
+	It("should replace members not ready longer than the grace period", func() {
+		now := time.Now()
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		pods := []corev1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-0"},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Minute)),
+			}}},
+		}}
+		Expect(getReplacementGraceRemaining(cluster, "test-0", pods, now)).To(Equal(3 * time.Minute))
+		Expect(getReplacementGraceRemaining(cluster, "test-1", pods, now)).To(Equal(defaultMemberReplacementGracePeriod))
+
+		cluster.Spec.MemberReplacementGracePeriod = &metav1.Duration{Duration: time.Minute}
+		Expect(getReplacementGraceRemaining(cluster, "test-0", pods, now)).To(BeZero())
+	})
+
+	Context("when the node of a member is gone with its local volume", func() {
+		var (
+			reconciler *EtcdClusterReconciler
+			members    *fakeEtcdMembers
+			cluster    *etcdaenixiov1alpha1.EtcdCluster
+			pods       []corev1.Pod
+		)
+
+		createClaim := func(ctx context.Context, podName, volumeName string) *corev1.PersistentVolumeClaim {
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: cluster.Namespace,
+					Name:      factory.GetMemberPVCName(cluster, podName),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+					VolumeName: volumeName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			return claim
+		}
+
+		BeforeEach(func(ctx SpecContext) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-lost-node-"}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			DeferCleanup(k8sClient.Delete, ns)
+
+			members = &fakeEtcdMembers{}
+			reconciler = &EtcdClusterReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				EtcdClientFactory: EtcdClientFactoryFunc(func(clientv3.Config) (*clientv3.Client, error) {
+					cli := clientv3.NewCtxClient(ctx)
+					cli.Cluster = members
+					return cli, nil
+				}),
+			}
+			cluster = &etcdaenixiov1alpha1.EtcdCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns.Name, UID: types.UID(uuid.NewString())},
+				Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+					Replicas:                     ptr.To(int32(3)),
+					MemberReplacementGracePeriod: &metav1.Duration{},
+				},
+			}
+			pods = nil
+			for ordinal := 0; ordinal < 3; ordinal++ {
+				podName := factory.GetMemberPodName(cluster, ordinal)
+				members.members = append(members.members, &etcdserverpb.Member{
+					ID:       uint64(ordinal + 1),
+					Name:     podName,
+					PeerURLs: []string{factory.GetMemberPeerURL(cluster, podName)},
+				})
+				setMemberIdentity(ctx, cluster, podName, strconv.FormatUint(uint64(ordinal+1), 16))
+				pod := corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: podName},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "etcd", Image: "etcd"}}},
+				}
+				if ordinal > 0 {
+					pod.Status.PodIP = "10.0.0." + strconv.Itoa(ordinal)
+					pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+				}
+				pods = append(pods, pod)
+			}
+
+			// the pod of the first member is Pending, as its volume and the node it was pinned to were deleted
+			Expect(k8sClient.Create(ctx, &pods[0])).To(Succeed())
+			claim := createClaim(ctx, pods[0].Name, "local-pv-lost-node")
+			claim.Status.Phase = corev1.ClaimLost
+			Expect(k8sClient.Status().Update(ctx, claim)).To(Succeed())
+		})
+
+		It("should remove the member from etcd and let it rejoin on a new claim", func(ctx SpecContext) {
+			conn := reconciler.newEtcdConnection(cluster)
+			DeferCleanup(conn.Close)
+			requeueAfter, err := reconciler.replaceLostMember(ctx, cluster, conn, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeueAfter).To(BeZero())
+
+			// the member is added back with its peer URL, so the recreated pod joins as the new member
+			Expect(members.removed).To(Equal([]uint64{1}))
+			Expect(members.members).To(HaveLen(3))
+			added := members.members[2]
+			Expect(added.Name).To(BeEmpty())
+			Expect(added.PeerURLs).To(Equal([]string{factory.GetMemberPeerURL(cluster, "test-0")}))
+			Expect(getRecordedMemberID(cluster, "test-0")).To(Equal(strconv.FormatUint(added.ID, 16)))
+
+			claimKey := client.ObjectKey{Namespace: cluster.Namespace, Name: factory.GetMemberPVCName(cluster, "test-0")}
+			claim := &corev1.PersistentVolumeClaim{}
+			err = k8sClient.Get(ctx, claimKey, claim)
+			if err == nil {
+				// release the claim protected while it was in use
+				Expect(claim.DeletionTimestamp.IsZero()).To(BeFalse())
+				claim.Finalizers = nil
+				Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+				Eventually(func() error { return k8sClient.Get(ctx, claimKey, claim) }).Should(Satisfy(apierrors.IsNotFound))
+			} else {
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+			Expect(apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pods[0]), &corev1.Pod{}))).
+				To(BeTrue())
+
+			// the StatefulSet recreates the claim, which is bound to a volume of an existing node
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "node-",
+				Labels:       map[string]string{labelHostname: "node-" + uuid.NewString()},
+			}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, node)
+			volume := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "local-pv-"},
+				Spec: corev1.PersistentVolumeSpec{
+					Capacity:    corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						Local: &corev1.LocalVolumeSource{Path: "/mnt/disks/etcd"},
+					},
+					NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{hostnameTerm(node.Labels[labelHostname])},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, volume)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, volume)
+			createClaim(ctx, "test-0", volume.Name)
+
+			conn = reconciler.newEtcdConnection(cluster)
+			DeferCleanup(conn.Close)
+			_, err = reconciler.replaceLostMember(ctx, cluster, conn, pods)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members.removed).To(HaveLen(1))
+			Expect(members.members).To(ContainElement(added))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package defaults loads overrides of defaulting data embedded in the operator. The operator defaults clusters
+// without any registry lookups, overrides allow disconnected environments to point defaults to their mirrors
+// and to describe flags of etcd releases newer than the operator.
+package defaults
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"sigs.k8s.io/yaml"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// Overrides replace defaulting data embedded in the operator.
+type Overrides struct {
+	// EtcdImage is the etcd image of clusters without etcd image in the pod template.
+	EtcdImage string `json:"etcdImage,omitempty"`
+	// EtcdFlags adds or replaces flags validated in spec.options, keyed by etcd minor version and flag name.
+	EtcdFlags map[string]map[string]Flag `json:"etcdFlags,omitempty"`
+}
+
+// Flag describes values accepted by etcd flag.
+type Flag struct {
+	// Kind is one of string, bool, int, uint, duration or enum.
+	Kind string `json:"kind"`
+	// Values accepted by enum flags.
+	Values []string `json:"values,omitempty"`
+}
+
+// Load reads overrides from the YAML file and applies them. Unknown fields are rejected, so typos are not ignored.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read defaults file: %w", err)
+	}
+	overrides := Overrides{}
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return fmt.Errorf("cannot parse defaults file %s: %w", path, err)
+	}
+	return Apply(overrides)
+}
+
+// Apply replaces embedded defaulting data with the overrides.
+func Apply(overrides Overrides) error {
+	versions := make([]string, 0, len(overrides.EtcdFlags))
+	for version := range overrides.EtcdFlags {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	for _, version := range versions {
+		names := make([]string, 0, len(overrides.EtcdFlags[version]))
+		for name := range overrides.EtcdFlags[version] {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			flag := overrides.EtcdFlags[version][name]
+			if err := etcdaenixiov1alpha1.SetEtcdFlag(version, name, flag.Kind, flag.Values); err != nil {
+				return fmt.Errorf("invalid etcdFlags.%s.%s: %w", version, name, err)
+			}
+		}
+	}
+	if overrides.EtcdImage != "" {
+		etcdaenixiov1alpha1.DefaultEtcdImage = overrides.EtcdImage
+	}
+	return nil
+}
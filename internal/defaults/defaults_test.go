@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaults
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Defaults overrides", func() {
+	BeforeEach(func() {
+		image := etcdaenixiov1alpha1.DefaultEtcdImage
+		DeferCleanup(func() { etcdaenixiov1alpha1.DefaultEtcdImage = image })
+	})
+
+	It("should override default image and describe flags of newer etcd", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "defaults.yaml")
+		Expect(os.WriteFile(path, []byte(`
+etcdImage: registry.example.com/etcd:v3.5.12
+etcdFlags:
+  "3.7":
+    new-mode:
+      kind: enum
+      values: [fast, safe]
+`), 0o600)).To(Succeed())
+		Expect(Load(path)).To(Succeed())
+		Expect(etcdaenixiov1alpha1.DefaultEtcdImage).To(Equal("registry.example.com/etcd:v3.5.12"))
+
+		validator := &etcdaenixiov1alpha1.EtcdClusterValidator{}
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+		cluster.Spec.Replicas = ptr.To(int32(3))
+		cluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{{Name: "etcd", Image: "quay.io/coreos/etcd:v3.7.0"}}
+		cluster.Spec.Options = map[string]string{"new-mode": "safe", "snapshot-count": "1000"}
+		warnings, err := validator.ValidateCreate(context.Background(), cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		cluster.Spec.Options = map[string]string{"new-mode": "slow"}
+		_, err = validator.ValidateCreate(context.Background(), cluster)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown fields and invalid flags", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "defaults.yaml")
+		Expect(os.WriteFile(path, []byte("etcdImages: registry.example.com/etcd:v3.5.12\n"), 0o600)).To(Succeed())
+		Expect(Load(path)).NotTo(Succeed())
+
+		Expect(Apply(Overrides{EtcdFlags: map[string]map[string]Flag{
+			"3.6": {"new-mode": {Kind: "enum"}},
+		}})).To(MatchError(ContainSubstring("enum flag new-mode requires values")))
+		Expect(Apply(Overrides{EtcdFlags: map[string]map[string]Flag{
+			"v3.6.1": {"new-mode": {Kind: "string"}},
+		}})).To(MatchError(ContainSubstring("not an etcd minor version")))
+	})
+})
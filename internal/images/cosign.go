@@ -0,0 +1,349 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	// cosignSignatureAnnotation holds base64 encoded signature of the layer in cosign signature manifests.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// maxRegistryResponseSize limits size of manifests, signature payloads and token responses read from registries.
+	maxRegistryResponseSize = 4 << 20
+	dockerHubRegistry       = "registry-1.docker.io"
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ErrNoSignature is returned if the registry has no cosign signature of the image.
+var ErrNoSignature = goerrors.New("image has no cosign signature")
+
+// Verifier verifies signatures of images before they are rolled out.
+type Verifier interface {
+	Verify(ctx context.Context, image string) error
+}
+
+// CosignVerifier verifies cosign signatures made by a key pair. Signatures are looked up in the registry
+// of the image under the sha256-<hex>.sig tag, the layout used by cosign sign. Registries are accessed anonymously.
+// Digests of verified images are cached, images referenced by tag are resolved to digest on every verification.
+type CosignVerifier struct {
+	publicKey crypto.PublicKey
+	client    *http.Client
+
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+var _ Verifier = &CosignVerifier{}
+
+// NewCosignVerifier creates verifier of signatures made by the private key of the PEM encoded public key.
+// ECDSA, RSA and Ed25519 keys are supported. Nil client means http.DefaultClient.
+func NewCosignVerifier(publicKeyPEM []byte, client *http.Client) (*CosignVerifier, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("public key is not PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key: %w", err)
+	}
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &CosignVerifier{publicKey: publicKey, client: client, verified: map[string]bool{}}, nil
+}
+
+// Verify resolves the image to digest and checks that one of its cosign signatures is made by the key
+// and signs the same digest.
+func (v *CosignVerifier) Verify(ctx context.Context, image string) error {
+	registry, repository, digest := parseReference(image)
+	rc := &registryClient{client: v.client, registry: registry, repository: repository}
+	if digest == "" {
+		resolved, err := rc.resolveDigest(ctx, image)
+		if err != nil {
+			return err
+		}
+		digest = resolved
+	}
+
+	key := registry + "/" + repository + "@" + digest
+	v.mu.Lock()
+	verified := v.verified[key]
+	v.mu.Unlock()
+	if verified {
+		return nil
+	}
+
+	if err := v.verifyDigest(ctx, rc, digest); err != nil {
+		return fmt.Errorf("cannot verify signature of %s: %w", image, err)
+	}
+	v.mu.Lock()
+	v.verified[key] = true
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *CosignVerifier) verifyDigest(ctx context.Context, rc *registryClient, digest string) error {
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	body, _, err := rc.get(ctx, "manifests/"+signatureTag, manifestMediaTypes...)
+	if err != nil {
+		if goerrors.Is(err, errNotFound) {
+			return ErrNoSignature
+		}
+		return err
+	}
+	var manifest struct {
+		Layers []struct {
+			Digest      string            `json:"digest"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return fmt.Errorf("cannot decode signature manifest: %w", err)
+	}
+
+	var errs []error
+	for _, layer := range manifest.Layers {
+		encoded, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		err := v.verifyLayer(ctx, rc, digest, layer.Digest, encoded)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return ErrNoSignature
+	}
+	return goerrors.Join(errs...)
+}
+
+// verifyLayer checks signature of the simple signing payload stored in the layer.
+func (v *CosignVerifier) verifyLayer(ctx context.Context, rc *registryClient, imageDigest, layerDigest, encoded string) error {
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("cannot decode signature: %w", err)
+	}
+	payload, _, err := rc.get(ctx, "blobs/"+layerDigest)
+	if err != nil {
+		return err
+	}
+	if err := checkDigest(payload, layerDigest); err != nil {
+		return err
+	}
+	if err := verifySignature(v.publicKey, payload, signature); err != nil {
+		return err
+	}
+	var simpleSigning struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+		return fmt.Errorf("cannot decode signature payload: %w", err)
+	}
+	if signed := simpleSigning.Critical.Image.DockerManifestDigest; signed != imageDigest {
+		return fmt.Errorf("signature is made for digest %s", signed)
+	}
+	return nil
+}
+
+func verifySignature(publicKey crypto.PublicKey, payload, signature []byte) error {
+	hash := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hash[:], signature) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+			return fmt.Errorf("invalid RSA signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, signature) {
+			return fmt.Errorf("invalid Ed25519 signature")
+		}
+	}
+	return nil
+}
+
+// checkDigest compares sha256 digest of the content with the expected one.
+func checkDigest(content []byte, digest string) error {
+	hash := sha256.Sum256(content)
+	if actual := "sha256:" + hex.EncodeToString(hash[:]); actual != digest {
+		return fmt.Errorf("content digest %s does not match %s", actual, digest)
+	}
+	return nil
+}
+
+// parseReference splits image into registry host, repository path and digest, if the image is pinned.
+func parseReference(image string) (registry, repository, digest string) {
+	name, suffix := splitRepository(image)
+	if i := strings.Index(suffix, "@"); i >= 0 {
+		digest = suffix[i+1:]
+	}
+	registry, repository, _ = strings.Cut(normalizeRepository(name), "/")
+	if registry == defaultRegistry {
+		registry = dockerHubRegistry
+	}
+	return registry, repository, digest
+}
+
+var errNotFound = goerrors.New("not found")
+
+// registryClient reads from a repository of OCI distribution registry, obtaining anonymous bearer token if required.
+type registryClient struct {
+	client     *http.Client
+	registry   string
+	repository string
+	token      string
+}
+
+// resolveDigest returns digest of the manifest referenced by the image tag.
+func (c *registryClient) resolveDigest(ctx context.Context, image string) (string, error) {
+	_, suffix := splitRepository(image)
+	tag := strings.TrimPrefix(suffix, ":")
+	if tag == "" {
+		tag = "latest"
+	}
+	body, header, err := c.get(ctx, "manifests/"+tag, manifestMediaTypes...)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve digest of %s: %w", image, err)
+	}
+	if digest := header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	hash := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+func (c *registryClient) get(ctx context.Context, path string, accept ...string) ([]byte, http.Header, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			fmt.Sprintf("https://%s/v2/%s/%s", c.registry, c.repository, path), nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryResponseSize))
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return body, resp.Header, nil
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, nil, fmt.Errorf("%s: %w", path, errNotFound)
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if err := c.authorize(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, fmt.Errorf("registry %s responded %s: %s", c.registry, resp.Status, bytes.TrimSpace(body))
+		}
+	}
+}
+
+// authorize obtains anonymous pull token from the realm of the bearer challenge.
+func (c *registryClient) authorize(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("registry %s requires unsupported authentication %q", c.registry, scheme)
+	}
+	values := map[string]string{}
+	for _, param := range strings.Split(params, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		values[name] = strings.Trim(value, `"`)
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return fmt.Errorf("registry %s sent invalid bearer realm %q", c.registry, values["realm"])
+	}
+	query := realm.Query()
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", cmp.Or(values["scope"], "repository:"+c.repository+":pull"))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot get registry token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot get registry token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRegistryResponseSize)).Decode(&token); err != nil {
+		return fmt.Errorf("cannot decode registry token: %w", err)
+	}
+	c.token = cmp.Or(token.Token, token.AccessToken)
+	if c.token == "" {
+		return fmt.Errorf("registry %s returned empty token", c.registry)
+	}
+	return nil
+}
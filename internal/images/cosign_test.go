@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRegistry serves manifests and blobs of a single repository and requires anonymous bearer token.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer anonymous" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="https://`+r.Host+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	kind, reference, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/etcd/"), "/")
+	content, ok := map[string]map[string][]byte{"manifests": f.manifests, "blobs": f.blobs}[kind][reference]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+func digestOf(content []byte) string {
+	hash := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+var _ = Describe("Cosign verifier", func() {
+	var (
+		registry    *fakeRegistry
+		server      *httptest.Server
+		key         *ecdsa.PrivateKey
+		verifier    *CosignVerifier
+		image       string
+		imageDigest string
+	)
+
+	sign := func(key *ecdsa.PrivateKey, digest string) {
+		payload := []byte(`{"critical":{"identity":{"docker-reference":"etcd"},` +
+			`"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"}}`)
+		hash := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := json.Marshal(map[string]interface{}{
+			"layers": []map[string]interface{}{{
+				"digest":      digestOf(payload),
+				"annotations": map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		registry.blobs[digestOf(payload)] = payload
+		registry.manifests[strings.Replace(imageDigest, ":", "-", 1)+".sig"] = manifest
+	}
+
+	BeforeEach(func() {
+		manifest := []byte(`{"schemaVersion":2}`)
+		imageDigest = digestOf(manifest)
+		registry = &fakeRegistry{
+			manifests: map[string][]byte{"v3.5.12": manifest, imageDigest: manifest},
+			blobs:     map[string][]byte{},
+		}
+		server = httptest.NewTLSServer(registry)
+		DeferCleanup(server.Close)
+		image = strings.TrimPrefix(server.URL, "https://") + "/etcd:v3.5.12"
+
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		verifier, err = NewCosignVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), server.Client())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should verify image signed by the key", func(ctx context.Context) {
+		sign(key, imageDigest)
+		Expect(verifier.Verify(ctx, image)).To(Succeed())
+		Expect(verifier.Verify(ctx, strings.TrimSuffix(image, ":v3.5.12")+"@"+imageDigest)).To(Succeed())
+	})
+
+	It("should reject unsigned image", func(ctx context.Context) {
+		Expect(verifier.Verify(ctx, image)).To(MatchError(ErrNoSignature))
+	})
+
+	It("should reject image signed by another key", func(ctx context.Context) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		sign(otherKey, imageDigest)
+		Expect(verifier.Verify(ctx, image)).To(MatchError(ContainSubstring("invalid ECDSA signature")))
+	})
+
+	It("should reject signature made for another digest", func(ctx context.Context) {
+		sign(key, digestOf([]byte("other")))
+		Expect(verifier.Verify(ctx, image)).To(MatchError(ContainSubstring("signature is made for digest")))
+	})
+
+	It("should reject malformed public key", func() {
+		_, err := NewCosignVerifier([]byte("not a key"), nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
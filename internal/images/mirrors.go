@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images rewrites container image references according to the registry mirrors configured on the operator.
+package images
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultRegistry   = "docker.io"
+	officialNamespace = "library"
+)
+
+// Mirrors maps registries or repository prefixes to their mirrors, e.g. quay.io=registry.example.com/quay.
+// It implements flag.Value, so the operator can accept repeated source=target pairs.
+type Mirrors map[string]string
+
+// ParseMirrors parses comma separated source=target pairs.
+func ParseMirrors(value string) (Mirrors, error) {
+	mirrors := Mirrors{}
+	if err := mirrors.Set(value); err != nil {
+		return nil, err
+	}
+	return mirrors, nil
+}
+
+// String returns mirrors as comma separated source=target pairs sorted by source.
+func (m Mirrors) String() string {
+	pairs := make([]string, 0, len(m))
+	for source, target := range m {
+		pairs = append(pairs, source+"="+target)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set adds comma separated source=target pairs to the mirrors.
+func (m Mirrors) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		source, target, found := strings.Cut(pair, "=")
+		source = strings.TrimSuffix(strings.TrimSpace(source), "/")
+		target = strings.TrimSuffix(strings.TrimSpace(target), "/")
+		if !found || source == "" || target == "" {
+			return fmt.Errorf("invalid image mirror %q, expected source=target", pair)
+		}
+		if !isRepositoryPrefix(source) || !isRepositoryPrefix(target) {
+			return fmt.Errorf("invalid image mirror %q, tags and digests are not allowed", pair)
+		}
+		m[source] = target
+	}
+	return nil
+}
+
+// Rewrite replaces the longest matching source prefix of the image repository with its mirror.
+// Tags and digests are kept, so images pinned by digest stay pinned to the same content in the mirror.
+// Images without registry are matched as docker.io images, e.g. etcd:3.5 as docker.io/library/etcd:3.5.
+func (m Mirrors) Rewrite(image string) string {
+	if len(m) == 0 || image == "" {
+		return image
+	}
+	repository, suffix := splitRepository(image)
+	normalized := normalizeRepository(repository)
+	var matched string
+	for source := range m {
+		if len(source) <= len(matched) {
+			continue
+		}
+		if normalized == source || strings.HasPrefix(normalized, source+"/") {
+			matched = source
+		}
+	}
+	if matched == "" {
+		return image
+	}
+	return m[matched] + strings.TrimPrefix(normalized, matched) + suffix
+}
+
+// splitRepository splits image reference into repository and the tag and digest suffix.
+func splitRepository(image string) (repository, suffix string) {
+	repository = image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, suffix = repository[:i], repository[i:]
+	}
+	// colon after the last slash separates tag, colon before it belongs to the registry port
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, suffix = repository[:i], repository[i:]+suffix
+	}
+	return repository, suffix
+}
+
+// normalizeRepository adds implicit docker.io registry and library namespace to the repository.
+func normalizeRepository(repository string) string {
+	first, _, found := strings.Cut(repository, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return repository
+	}
+	if !found {
+		repository = officialNamespace + "/" + repository
+	}
+	return defaultRegistry + "/" + repository
+}
+
+// isRepositoryPrefix returns false if the value has a digest or a tag, colon is allowed only in the registry port.
+func isRepositoryPrefix(value string) bool {
+	if strings.Contains(value, "@") {
+		return false
+	}
+	i := strings.Index(value, ":")
+	return i < 0 || i < strings.Index(value+"/", "/") && strings.Count(value, ":") == 1
+}
+
+type mirrorsKey struct{}
+
+// WithMirrors returns a copy of the context carrying the mirrors.
+func WithMirrors(ctx context.Context, mirrors Mirrors) context.Context {
+	return context.WithValue(ctx, mirrorsKey{}, mirrors)
+}
+
+// MirrorsFromContext returns mirrors carried by the context, nil mirrors rewrite nothing.
+func MirrorsFromContext(ctx context.Context) Mirrors {
+	mirrors, _ := ctx.Value(mirrorsKey{}).(Mirrors)
+	return mirrors
+}
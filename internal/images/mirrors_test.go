@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Image mirrors", func() {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	It("should parse comma separated mirrors", func() {
+		mirrors, err := ParseMirrors("quay.io=registry.example.com/quay/, docker.io=localhost:5000")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mirrors).To(Equal(Mirrors{
+			"quay.io":   "registry.example.com/quay",
+			"docker.io": "localhost:5000",
+		}))
+		Expect(mirrors.String()).To(Equal("docker.io=localhost:5000,quay.io=registry.example.com/quay"))
+	})
+
+	It("should reject malformed mirrors", func() {
+		for _, value := range []string{"quay.io", "=mirror", "quay.io/coreos/etcd:v3.5.12=mirror", "quay.io=mirror@" + digest} {
+			_, err := ParseMirrors(value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+
+	It("should rewrite the longest matching prefix and keep tags and digests", func() {
+		mirrors := Mirrors{
+			"quay.io":             "registry.example.com/quay",
+			"quay.io/coreos/etcd": "registry.example.com/etcd",
+			"docker.io":           "registry.example.com:5000/dockerhub",
+		}
+		Expect(mirrors.Rewrite("quay.io/coreos/etcd:v3.5.12")).To(Equal("registry.example.com/etcd:v3.5.12"))
+		Expect(mirrors.Rewrite("quay.io/coreos/etcd@" + digest)).To(Equal("registry.example.com/etcd@" + digest))
+		Expect(mirrors.Rewrite("quay.io/coreos/etcd-backup:v1")).To(Equal("registry.example.com/quay/coreos/etcd-backup:v1"))
+		Expect(mirrors.Rewrite("busybox:1.36@" + digest)).
+			To(Equal("registry.example.com:5000/dockerhub/library/busybox:1.36@" + digest))
+		Expect(mirrors.Rewrite("bitnami/etcd")).To(Equal("registry.example.com:5000/dockerhub/bitnami/etcd"))
+		Expect(mirrors.Rewrite("gcr.io/etcd-development/etcd:v3.5.12")).To(Equal("gcr.io/etcd-development/etcd:v3.5.12"))
+		Expect(mirrors.Rewrite("localhost:5000/etcd:v3.5.12")).To(Equal("localhost:5000/etcd:v3.5.12"))
+	})
+
+	It("should carry mirrors in context", func() {
+		Expect(MirrorsFromContext(context.Background()).Rewrite("etcd")).To(Equal("etcd"))
+		ctx := WithMirrors(context.Background(), Mirrors{"docker.io": "mirror.local"})
+		Expect(MirrorsFromContext(ctx).Rewrite("etcd")).To(Equal("mirror.local/library/etcd"))
+	})
+})
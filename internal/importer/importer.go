@@ -0,0 +1,323 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer implements the import command of the operator. It inspects an etcd cluster deployed
+// outside of the operator and generates an EtcdCluster matching its size, version and TLS setup.
+package importer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+const (
+	etcdImageRepository = "quay.io/coreos/etcd"
+	// storageHeadroom is how many times the volume is larger than the current database.
+	storageHeadroom = 2
+)
+
+// minStorageSize matches the storage size defaulted by the webhook.
+var minStorageSize = resource.MustParse("4Gi")
+
+// Options configure inspection of the cluster and the generated EtcdCluster.
+type Options struct {
+	// Endpoints are client URLs of the inspected cluster.
+	Endpoints []string
+	// CAFile, CertFile and KeyFile configure TLS of client connections.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// Name and Namespace of the generated EtcdCluster.
+	Name      string
+	Namespace string
+	// Timeout of requests to the inspected cluster.
+	Timeout time.Duration
+}
+
+// ClusterInfo describes the inspected cluster.
+type ClusterInfo struct {
+	// Version is the etcd server version, e.g. 3.5.12.
+	Version string
+	// Voters and Learners are the numbers of members of each kind.
+	Voters   int
+	Learners int
+	// DBSize is the largest database size of members in bytes.
+	DBSize int64
+	// TLS is true if members serve clients over TLS.
+	TLS bool
+	// ClientCertAuth is true if a client certificate was used to connect.
+	ClientCertAuth bool
+}
+
+// Inspect connects to the cluster and collects its size, version and database size.
+func Inspect(ctx context.Context, opts Options) (*ClusterInfo, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   opts.Endpoints,
+		TLS:         tlsConfig,
+		DialTimeout: opts.Timeout,
+		Context:     ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to etcd: %w", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	reqCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	members, err := cli.MemberList(reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list etcd members: %w", err)
+	}
+	info := &ClusterInfo{TLS: tlsConfig != nil, ClientCertAuth: opts.CertFile != ""}
+	for _, member := range members.Members {
+		if member.IsLearner {
+			info.Learners++
+		} else {
+			info.Voters++
+		}
+		for _, url := range member.ClientURLs {
+			status, err := cli.Status(reqCtx, url)
+			if err != nil {
+				continue
+			}
+			info.Version = status.Version
+			info.DBSize = max(info.DBSize, status.DbSize)
+			break
+		}
+	}
+	if info.Version == "" {
+		return nil, errors.New("no member reported its status")
+	}
+	return info, nil
+}
+
+// Generate returns EtcdCluster matching the inspected cluster.
+func Generate(info *ClusterInfo, opts Options) *etcdaenixiov1alpha1.EtcdCluster {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: etcdaenixiov1alpha1.GroupVersion.String(),
+			Kind:       "EtcdCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Annotations: map[string]string{
+				etcdaenixiov1alpha1.ImportedFromAnnotation: strings.Join(opts.Endpoints, ","),
+			},
+		},
+		Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+			Replicas: ptr.To(int32(info.Voters)),
+			PodTemplate: etcdaenixiov1alpha1.PodTemplate{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "etcd",
+						Image: fmt.Sprintf("%s:v%s", etcdImageRepository, info.Version),
+					}},
+				},
+			},
+			Storage: etcdaenixiov1alpha1.StorageSpec{
+				VolumeClaimTemplate: etcdaenixiov1alpha1.EmbeddedPersistentVolumeClaim{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: storageSize(info.DBSize)},
+						},
+					},
+				},
+			},
+		},
+	}
+	if info.TLS {
+		cluster.Spec.Security = &etcdaenixiov1alpha1.SecuritySpec{
+			TLS: etcdaenixiov1alpha1.TLSSpec{ServerSecret: opts.Name + "-server-tls"},
+		}
+		if info.ClientCertAuth {
+			cluster.Spec.Security.TLS.ClientTrustedCASecret = opts.Name + "-client-ca"
+			cluster.Spec.Security.TLS.ClientSecret = opts.Name + "-client-tls"
+		}
+	}
+	return cluster
+}
+
+// storageSize returns volume size with headroom for the database rounded up to GiB, at least minStorageSize.
+func storageSize(dbSize int64) resource.Quantity {
+	const gib = 1 << 30
+	size := int64(math.Ceil(float64(dbSize*storageHeadroom)/gib)) * gib
+	if size <= minStorageSize.Value() {
+		return minStorageSize.DeepCopy()
+	}
+	return *resource.NewQuantity(size, resource.BinarySI)
+}
+
+// Manifest returns YAML manifest of the cluster without status.
+func Manifest(cluster *etcdaenixiov1alpha1.EtcdCluster) ([]byte, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cluster)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(obj, "status")
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj, "spec", "storage", "volumeClaimTemplate", "status")
+	manifest, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("---\n"), manifest...), nil
+}
+
+func newTLSConfig(opts Options) (*tls.Config, error) {
+	secure := opts.CAFile != "" || opts.CertFile != ""
+	for _, endpoint := range opts.Endpoints {
+		secure = secure || strings.HasPrefix(endpoint, "https://")
+	}
+	if !secure {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		caCert, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate in %s", opts.CAFile)
+		}
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Main runs the import command with the given arguments and returns its exit code.
+// The manifest is written to stdout, so it can be redirected to a file or piped to kubectl.
+// With --apply the EtcdCluster is created in the Kubernetes cluster instead. Data is not migrated:
+// restore a snapshot of the inspected cluster or move clients once the new cluster is ready.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var opts Options
+	var endpoints string
+	var apply bool
+	fs.StringVar(&endpoints, "endpoints", "http://127.0.0.1:2379", "Comma separated client URLs of the etcd cluster to import.")
+	fs.StringVar(&opts.CAFile, "cacert", "", "CA certificate verifying etcd server certificates.")
+	fs.StringVar(&opts.CertFile, "cert", "", "Client certificate used to connect to etcd.")
+	fs.StringVar(&opts.KeyFile, "key", "", "Key of the client certificate.")
+	fs.StringVar(&opts.Name, "name", "", "Name of the generated EtcdCluster.")
+	fs.StringVar(&opts.Namespace, "namespace", "default", "Namespace of the generated EtcdCluster.")
+	fs.DurationVar(&opts.Timeout, "timeout", 10*time.Second, "Timeout of requests to etcd.")
+	fs.BoolVar(&apply, "apply", false, "Create the EtcdCluster in the Kubernetes cluster instead of printing it.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	opts.Endpoints = strings.Split(endpoints, ",")
+	if opts.Name == "" {
+		_, _ = fmt.Fprintln(stderr, "--name is required")
+		return 2
+	}
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		_, _ = fmt.Fprintln(stderr, "--cert and --key must be set together")
+		return 2
+	}
+
+	info, err := Inspect(ctx, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cannot inspect etcd cluster: %v\n", err)
+		return 1
+	}
+	if info.Learners > 0 {
+		_, _ = fmt.Fprintf(stderr, "%d learner members are not imported\n", info.Learners)
+	}
+	cluster := Generate(info, opts)
+	if info.TLS {
+		_, _ = fmt.Fprintf(stderr, "create TLS secrets %s referenced in spec.security.tls before the cluster\n",
+			strings.Join(referencedSecrets(cluster), ", "))
+	}
+
+	if apply {
+		if err := create(ctx, cluster); err != nil {
+			_, _ = fmt.Fprintf(stderr, "cannot create EtcdCluster: %v\n", err)
+			return 1
+		}
+		_, _ = fmt.Fprintf(stdout, "etcdcluster %s/%s created\n", cluster.Namespace, cluster.Name)
+		return 0
+	}
+	manifest, err := Manifest(cluster)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cannot render manifest: %v\n", err)
+		return 1
+	}
+	_, _ = stdout.Write(manifest)
+	return 0
+}
+
+func referencedSecrets(cluster *etcdaenixiov1alpha1.EtcdCluster) []string {
+	tlsSpec := cluster.Spec.Security.TLS
+	var secrets []string
+	for _, name := range []string{tlsSpec.ServerSecret, tlsSpec.ClientTrustedCASecret, tlsSpec.ClientSecret} {
+		if name != "" {
+			secrets = append(secrets, name)
+		}
+	}
+	return secrets
+}
+
+// create creates the cluster using kubeconfig of the current context or in-cluster configuration.
+func create(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+	scheme := runtime.NewScheme()
+	utilruntime.Must(etcdaenixiov1alpha1.AddToScheme(scheme))
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	return c.Create(ctx, cluster)
+}
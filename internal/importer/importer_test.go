@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Import", func() {
+	opts := Options{Endpoints: []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"}, Name: "test", Namespace: "ns"}
+
+	It("should generate cluster matching the inspected one", func() {
+		cluster := Generate(&ClusterInfo{Version: "3.5.12", Voters: 3, DBSize: 3 << 30, TLS: true, ClientCertAuth: true}, opts)
+		Expect(*cluster.Spec.Replicas).To(BeEquivalentTo(3))
+		Expect(cluster.EtcdImage()).To(Equal("quay.io/coreos/etcd:v3.5.12"))
+		Expect(cluster.Annotations).To(HaveKeyWithValue(etcdaenixiov1alpha1.ImportedFromAnnotation,
+			"https://10.0.0.1:2379,https://10.0.0.2:2379"))
+		Expect(cluster.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests.Storage().String()).To(Equal("6Gi"))
+		Expect(cluster.Spec.Security.TLS).To(Equal(etcdaenixiov1alpha1.TLSSpec{
+			ServerSecret:          "test-server-tls",
+			ClientTrustedCASecret: "test-client-ca",
+			ClientSecret:          "test-client-tls",
+		}))
+	})
+
+	It("should not configure TLS for plain text clusters", func() {
+		cluster := Generate(&ClusterInfo{Version: "3.5.12", Voters: 1}, opts)
+		Expect(cluster.Spec.Security).To(BeNil())
+	})
+
+	It("should size storage with headroom", func() {
+		Expect(storageSize(0)).To(Equal(resource.MustParse("4Gi")))
+		Expect(storageSize(2 << 30)).To(Equal(resource.MustParse("4Gi")))
+		size := storageSize(5<<30 + 1)
+		Expect(size.Cmp(resource.MustParse("11Gi"))).To(Equal(0))
+	})
+
+	It("should render manifest without status", func() {
+		manifest, err := Manifest(Generate(&ClusterInfo{Version: "3.5.12", Voters: 3}, opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(manifest)).To(HavePrefix("---\napiVersion: etcd.aenix.io/v1alpha1\nkind: EtcdCluster\n"))
+		Expect(string(manifest)).NotTo(ContainSubstring("status:"))
+		Expect(string(manifest)).NotTo(ContainSubstring("creationTimestamp"))
+	})
+
+	It("should require cluster name", func() {
+		var stdout, stderr bytes.Buffer
+		Expect(Main(context.Background(), []string{"--endpoints", "http://127.0.0.1:2379"}, &stdout, &stderr)).To(Equal(2))
+		Expect(stderr.String()).To(ContainSubstring("--name is required"))
+		Expect(stdout.String()).To(BeEmpty())
+	})
+})
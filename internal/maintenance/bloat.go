@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// bloatMinDBShrinkPercent is the database shrink, relative to the key count drop, which is considered
+// a matching reduction of the database size, e.g. by a defragmentation made by someone else.
+const bloatMinDBShrinkPercent = 50
+
+// SizeSample is the size of a cluster observed at one point in time.
+type SizeSample struct {
+	// Keys is the number of keys in the keyspace.
+	Keys int64
+	// DBSize is the largest physical database size of members in bytes.
+	DBSize int64
+}
+
+// BloatDetector detects sharp drops of the key count without a matching drop of the database size,
+// typical for space held by deleted keys until compaction and defragmentation, and keeps the queue of members
+// to defragment one at a time. Clusters are identified by MemberKey with empty member name.
+type BloatDetector struct {
+	mu        sync.Mutex
+	baselines map[string]SizeSample
+	pending   map[string][]string
+}
+
+// NewBloatDetector returns a detector without history.
+func NewBloatDetector() *BloatDetector {
+	return &BloatDetector{
+		baselines: make(map[string]SizeSample),
+		pending:   make(map[string][]string),
+	}
+}
+
+// Observe records the sample of the cluster and returns true if the key count dropped by at least keyDropPercent
+// since the baseline, the highest key count observed, while the database did not shrink accordingly.
+// The sample becomes the new baseline after detection, so a drop is reported once.
+func (d *BloatDetector) Observe(cluster string, sample SizeSample, keyDropPercent int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	baseline, found := d.baselines[cluster]
+	if !found || sample.Keys >= baseline.Keys || baseline.Keys == 0 {
+		d.baselines[cluster] = sample
+		return false
+	}
+
+	keyDrop := (baseline.Keys - sample.Keys) * 100 / baseline.Keys
+	var dbShrink int64
+	if baseline.DBSize > 0 && sample.DBSize < baseline.DBSize {
+		dbShrink = (baseline.DBSize - sample.DBSize) * 100 / baseline.DBSize
+	}
+	if dbShrink*100 >= keyDrop*bloatMinDBShrinkPercent {
+		// space was already reclaimed
+		d.baselines[cluster] = sample
+		return false
+	}
+	if keyDrop < int64(keyDropPercent) {
+		return false
+	}
+	d.baselines[cluster] = sample
+	return true
+}
+
+// Schedule queues members of the cluster for defragmentation in the given order.
+func (d *BloatDetector) Schedule(cluster string, members []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[cluster] = append([]string(nil), members...)
+}
+
+// Next returns the next member of the cluster to defragment.
+func (d *BloatDetector) Next(cluster string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.pending[cluster]) == 0 {
+		return "", false
+	}
+	return d.pending[cluster][0], true
+}
+
+// IsPending returns true if the member of the cluster waits for defragmentation.
+func (d *BloatDetector) IsPending(cluster, member string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Contains(d.pending[cluster], member)
+}
+
+// Done removes the member from the defragmentation queue of the cluster.
+func (d *BloatDetector) Done(cluster, member string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pending := d.pending[cluster]
+	for i := range pending {
+		if pending[i] == member {
+			d.pending[cluster] = append(pending[:i:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(d.pending[cluster]) == 0 {
+		delete(d.pending, cluster)
+	}
+}
+
+// Forget drops history and queues of clusters with the given key prefix, e.g. for deleted clusters.
+func (d *BloatDetector) Forget(keyPrefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for cluster := range d.baselines {
+		if strings.HasPrefix(cluster, keyPrefix) {
+			delete(d.baselines, cluster)
+		}
+	}
+	for cluster := range d.pending {
+		if strings.HasPrefix(cluster, keyPrefix) {
+			delete(d.pending, cluster)
+		}
+	}
+}
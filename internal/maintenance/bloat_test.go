@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bloat detector", func() {
+	var (
+		detector *BloatDetector
+		cluster  string
+	)
+
+	BeforeEach(func() {
+		detector = NewBloatDetector()
+		cluster = MemberKey("default", "test", "")
+	})
+
+	It("should detect key count drop without database shrink once", func() {
+		Expect(detector.Observe(cluster, SizeSample{Keys: 1000, DBSize: 100 << 20}, 50)).To(BeFalse())
+		Expect(detector.Observe(cluster, SizeSample{Keys: 700, DBSize: 100 << 20}, 50)).To(BeFalse())
+		Expect(detector.Observe(cluster, SizeSample{Keys: 400, DBSize: 101 << 20}, 50)).To(BeTrue())
+		Expect(detector.Observe(cluster, SizeSample{Keys: 400, DBSize: 101 << 20}, 50)).To(BeFalse())
+	})
+
+	It("should ignore drops with reclaimed space", func() {
+		Expect(detector.Observe(cluster, SizeSample{Keys: 1000, DBSize: 100 << 20}, 50)).To(BeFalse())
+		Expect(detector.Observe(cluster, SizeSample{Keys: 300, DBSize: 40 << 20}, 50)).To(BeFalse())
+		Expect(detector.Observe(cluster, SizeSample{Keys: 250, DBSize: 40 << 20}, 50)).To(BeFalse())
+	})
+
+	It("should queue members and forget deleted clusters", func() {
+		detector.Schedule(cluster, []string{"test-1", "test-2", "test-0"})
+		member, ok := detector.Next(cluster)
+		Expect(ok).To(BeTrue())
+		Expect(member).To(Equal("test-1"))
+		Expect(detector.IsPending(cluster, "test-0")).To(BeTrue())
+
+		detector.Done(cluster, "test-1")
+		Expect(detector.IsPending(cluster, "test-1")).To(BeFalse())
+		member, _ = detector.Next(cluster)
+		Expect(member).To(Equal("test-2"))
+
+		detector.Forget(MemberKey("default", "test", ""))
+		_, ok = detector.Next(cluster)
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Operation is a kind of maintenance call made by the operator to etcd members.
+type Operation string
+
+const (
+	OperationDefragment Operation = "defragment"
+	OperationSnapshot   Operation = "snapshot"
+)
+
+// Limits configures how often and how many maintenance calls the operator may make.
+type Limits struct {
+	// MinInterval is the minimal interval between two calls of the operation on the same member.
+	MinInterval map[Operation]time.Duration
+	// MaxParallel is the maximal number of calls of the operation running at the same time across all clusters.
+	// Zero means no limit.
+	MaxParallel map[Operation]int
+}
+
+// DefaultLimits allows one defragmentation per member per hour and one snapshot at a time.
+func DefaultLimits() Limits {
+	return Limits{
+		MinInterval: map[Operation]time.Duration{
+			OperationDefragment: time.Hour,
+		},
+		MaxParallel: map[Operation]int{
+			OperationSnapshot: 1,
+		},
+	}
+}
+
+// Limiter enforces Limits for maintenance calls of all clusters managed by the operator.
+// Members are identified by a key unique across clusters, see MemberKey.
+type Limiter struct {
+	limits Limits
+	clock  clock.PassiveClock
+
+	mu       sync.Mutex
+	lastRun  map[Operation]map[string]time.Time
+	running  map[Operation]int
+	inFlight map[string]map[Operation]int
+}
+
+// NewLimiter returns a limiter enforcing the given limits.
+func NewLimiter(limits Limits) *Limiter {
+	return NewLimiterWithClock(limits, clock.RealClock{})
+}
+
+// NewLimiterWithClock returns a limiter enforcing the given limits by time of the clock.
+func NewLimiterWithClock(limits Limits, clk clock.PassiveClock) *Limiter {
+	return &Limiter{
+		limits:   limits,
+		clock:    clk,
+		lastRun:  make(map[Operation]map[string]time.Time),
+		running:  make(map[Operation]int),
+		inFlight: make(map[string]map[Operation]int),
+	}
+}
+
+// MemberKey identifies member of a cluster for the limiter.
+func MemberKey(namespace, cluster, member string) string {
+	return namespace + "/" + cluster + "/" + member
+}
+
+// TryAcquire reserves a call of the operation on the member. If the call is not allowed now, ok is false
+// and retryAfter is the duration after which the call may be allowed. Otherwise release must be called
+// once the call is finished.
+func (l *Limiter) TryAcquire(op Operation, member string) (release func(), retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if last, found := l.lastRun[op][member]; found {
+		if wait := last.Add(l.limits.MinInterval[op]).Sub(now); wait > 0 {
+			return nil, wait, false
+		}
+	}
+	if maxParallel := l.limits.MaxParallel[op]; maxParallel > 0 && l.running[op] >= maxParallel {
+		// there is no way to know when running calls finish, so suggest polling
+		return nil, time.Second * 10, false
+	}
+
+	if l.lastRun[op] == nil {
+		l.lastRun[op] = make(map[string]time.Time)
+	}
+	l.lastRun[op][member] = now
+	l.running[op]++
+	if l.inFlight[member] == nil {
+		l.inFlight[member] = make(map[Operation]int)
+	}
+	l.inFlight[member][op]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.running[op]--
+			l.inFlight[member][op]--
+			if l.inFlight[member][op] == 0 {
+				delete(l.inFlight[member], op)
+			}
+			if len(l.inFlight[member]) == 0 {
+				delete(l.inFlight, member)
+			}
+		})
+	}, 0, true
+}
+
+// InFlight returns operations currently running on the member, sorted by name.
+func (l *Limiter) InFlight(member string) []Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ops := make([]Operation, 0, len(l.inFlight[member]))
+	for op := range l.inFlight[member] {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+	return ops
+}
+
+// Forget drops history of calls on members with the given key prefix, e.g. for deleted clusters.
+func (l *Limiter) Forget(keyPrefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, members := range l.lastRun {
+		for member := range members {
+			if strings.HasPrefix(member, keyPrefix) {
+				delete(members, member)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("Maintenance limiter", func() {
+	var (
+		limiter *Limiter
+		clock   *clocktesting.FakePassiveClock
+	)
+
+	BeforeEach(func() {
+		clock = clocktesting.NewFakePassiveClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		limiter = NewLimiterWithClock(DefaultLimits(), clock)
+	})
+
+	It("should allow one defragmentation per member per interval", func() {
+		member := MemberKey("default", "test", "test-0")
+		release, _, ok := limiter.TryAcquire(OperationDefragment, member)
+		Expect(ok).To(BeTrue())
+		release()
+
+		clock.SetTime(clock.Now().Add(time.Minute * 20))
+		_, retryAfter, ok := limiter.TryAcquire(OperationDefragment, member)
+		Expect(ok).To(BeFalse())
+		Expect(retryAfter).To(Equal(time.Minute * 40))
+
+		By("allowing other members", func() {
+			release, _, ok := limiter.TryAcquire(OperationDefragment, MemberKey("default", "test", "test-1"))
+			Expect(ok).To(BeTrue())
+			release()
+		})
+
+		clock.SetTime(clock.Now().Add(time.Minute * 40))
+		_, _, ok = limiter.TryAcquire(OperationDefragment, member)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should limit parallel snapshots across clusters", func() {
+		release, _, ok := limiter.TryAcquire(OperationSnapshot, MemberKey("default", "first", "first-0"))
+		Expect(ok).To(BeTrue())
+
+		_, _, ok = limiter.TryAcquire(OperationSnapshot, MemberKey("other", "second", "second-0"))
+		Expect(ok).To(BeFalse())
+
+		release()
+		release()
+		_, _, ok = limiter.TryAcquire(OperationSnapshot, MemberKey("other", "second", "second-0"))
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should report operations in flight on members", func() {
+		member := MemberKey("default", "test", "test-0")
+		release, _, ok := limiter.TryAcquire(OperationSnapshot, member)
+		Expect(ok).To(BeTrue())
+		Expect(limiter.InFlight(member)).To(Equal([]Operation{OperationSnapshot}))
+		Expect(limiter.InFlight(MemberKey("default", "test", "test-1"))).To(BeEmpty())
+
+		release()
+		Expect(limiter.InFlight(member)).To(BeEmpty())
+	})
+
+	It("should forget members of deleted clusters", func() {
+		member := MemberKey("default", "test", "test-0")
+		release, _, ok := limiter.TryAcquire(OperationDefragment, member)
+		Expect(ok).To(BeTrue())
+		release()
+
+		limiter.Forget(MemberKey("default", "test", ""))
+		_, _, ok = limiter.TryAcquire(OperationDefragment, member)
+		Expect(ok).To(BeTrue())
+	})
+})
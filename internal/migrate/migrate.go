@@ -0,0 +1,423 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate implements the migrate command of the operator. It converts EtcdClusters of the CoreOS etcd
+// operator (etcd.database.coreos.com/v1beta2) into EtcdClusters observing the running members and adopts
+// their pods, services and volumes, so the CoreOS operator can be removed without disrupting the clusters.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/importer"
+)
+
+const (
+	coreosAPIVersion = "etcd.database.coreos.com/v1beta2"
+	coreosKind       = "EtcdCluster"
+	// coreosClusterLabel is set by the CoreOS operator on pods, services and volumes of the cluster.
+	coreosClusterLabel      = "etcd_cluster"
+	coreosDefaultRepository = "quay.io/coreos/etcd"
+	coreosClientPort        = 2379
+)
+
+var coreosListGVK = schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdClusterList"}
+
+// CoreOSCluster is the subset of the CoreOS EtcdCluster the conversion relies on.
+type CoreOSCluster struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CoreOSClusterSpec `json:"spec"`
+}
+
+// CoreOSClusterSpec is the spec of the CoreOS EtcdCluster.
+type CoreOSClusterSpec struct {
+	Size       int              `json:"size"`
+	Repository string           `json:"repository,omitempty"`
+	Version    string           `json:"version,omitempty"`
+	Paused     bool             `json:"paused,omitempty"`
+	Pod        *CoreOSPodPolicy `json:"pod,omitempty"`
+	TLS        *CoreOSTLSPolicy `json:"TLS,omitempty"`
+}
+
+// CoreOSPodPolicy defines member pods of the CoreOS EtcdCluster.
+type CoreOSPodPolicy struct {
+	Labels                    map[string]string                 `json:"labels,omitempty"`
+	Annotations               map[string]string                 `json:"annotations,omitempty"`
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	Resources                 corev1.ResourceRequirements       `json:"resources,omitempty"`
+	EtcdEnv                   []corev1.EnvVar                   `json:"etcdEnv,omitempty"`
+	SecurityContext           *corev1.PodSecurityContext        `json:"securityContext,omitempty"`
+	PersistentVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaimSpec,omitempty"`
+}
+
+// CoreOSTLSPolicy defines TLS of the CoreOS EtcdCluster, only static certificates are supported by it.
+type CoreOSTLSPolicy struct {
+	Static *CoreOSStaticTLS `json:"static,omitempty"`
+}
+
+// CoreOSStaticTLS references secrets with certificates of members and the CoreOS operator.
+type CoreOSStaticTLS struct {
+	Member         *CoreOSMemberSecret `json:"member,omitempty"`
+	OperatorSecret string              `json:"operatorSecret,omitempty"`
+}
+
+// CoreOSMemberSecret references secrets with peer and server certificates of members.
+type CoreOSMemberSecret struct {
+	PeerSecret   string `json:"peerSecret,omitempty"`
+	ServerSecret string `json:"serverSecret,omitempty"`
+}
+
+// SecretConversion copies a CoreOS certificate secret into a kubernetes.io/tls secret expected by the operator.
+// CoreOS secrets name files after their purpose, e.g. server.crt, server.key and server-ca.crt.
+type SecretConversion struct {
+	From string
+	To   string
+	// Prefix of the keys in the CoreOS secret.
+	Prefix string
+}
+
+// Convert returns EtcdCluster observing members of the CoreOS cluster through its client service, secrets to convert
+// for it and notes about settings which were not converted.
+func Convert(src *CoreOSCluster) (*etcdaenixiov1alpha1.EtcdCluster, []SecretConversion, []string) {
+	var notes []string
+	conversions := secretConversions(src)
+	scheme := "http"
+	if src.Spec.TLS != nil && src.Spec.TLS.Static != nil && src.Spec.TLS.Static.Member != nil &&
+		src.Spec.TLS.Static.Member.ServerSecret != "" {
+		scheme = "https"
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: etcdaenixiov1alpha1.GroupVersion.String(),
+			Kind:       "EtcdCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      src.Name,
+			Namespace: src.Namespace,
+			Annotations: map[string]string{
+				etcdaenixiov1alpha1.MigratedFromAnnotation: coreosAPIVersion,
+			},
+		},
+		Spec: etcdaenixiov1alpha1.EtcdClusterSpec{
+			Replicas:         ptr.To(int32(src.Spec.Size)),
+			ManagementPolicy: etcdaenixiov1alpha1.ManagementPolicyObserve,
+			ObservedEndpoints: []string{
+				fmt.Sprintf("%s://%s-client.%s.svc:%d", scheme, src.Name, src.Namespace, coreosClientPort),
+			},
+		},
+	}
+
+	container := corev1.Container{Name: "etcd"}
+	if src.Spec.Version != "" {
+		repository := src.Spec.Repository
+		if repository == "" {
+			repository = coreosDefaultRepository
+		}
+		container.Image = fmt.Sprintf("%s:v%s", repository, strings.TrimPrefix(src.Spec.Version, "v"))
+	} else {
+		notes = append(notes, "version is not set, the default etcd image of the operator is used")
+	}
+	if pod := src.Spec.Pod; pod != nil {
+		cluster.Spec.PodTemplate.Labels = pod.Labels
+		cluster.Spec.PodTemplate.Annotations = pod.Annotations
+		cluster.Spec.PodTemplate.Spec.NodeSelector = pod.NodeSelector
+		cluster.Spec.PodTemplate.Spec.Affinity = pod.Affinity
+		cluster.Spec.PodTemplate.Spec.Tolerations = pod.Tolerations
+		cluster.Spec.PodTemplate.Spec.SecurityContext = pod.SecurityContext
+		container.Resources = pod.Resources
+		container.Env = pod.EtcdEnv
+		if pod.PersistentVolumeClaimSpec != nil {
+			cluster.Spec.Storage.VolumeClaimTemplate.Spec = *pod.PersistentVolumeClaimSpec
+		}
+	}
+	cluster.Spec.PodTemplate.Spec.Containers = []corev1.Container{container}
+	// members of the CoreOS operator keep data in emptyDir volumes unless a claim is defined
+	if src.Spec.Pod == nil || src.Spec.Pod.PersistentVolumeClaimSpec == nil {
+		cluster.Spec.Storage.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		cluster.Spec.Storage.Ephemeral = true
+	}
+
+	for _, conversion := range conversions {
+		if cluster.Spec.Security == nil {
+			cluster.Spec.Security = &etcdaenixiov1alpha1.SecuritySpec{}
+		}
+		tlsSpec := &cluster.Spec.Security.TLS
+		switch conversion.Prefix {
+		case "peer":
+			tlsSpec.PeerSecret = conversion.To
+			tlsSpec.PeerTrustedCASecret = conversion.To
+		case "server":
+			// clients were verified by the CA of server certificates
+			tlsSpec.ServerSecret = conversion.To
+			tlsSpec.ClientTrustedCASecret = conversion.To
+		case "etcd-client":
+			tlsSpec.ClientSecret = conversion.To
+		}
+	}
+	return cluster, conversions, notes
+}
+
+// secretConversions lists certificate secrets of the CoreOS cluster with names of their converted copies.
+func secretConversions(src *CoreOSCluster) []SecretConversion {
+	if src.Spec.TLS == nil || src.Spec.TLS.Static == nil {
+		return nil
+	}
+	static := src.Spec.TLS.Static
+	var conversions []SecretConversion
+	if static.Member != nil && static.Member.PeerSecret != "" {
+		conversions = append(conversions, SecretConversion{From: static.Member.PeerSecret, To: src.Name + "-peer-tls", Prefix: "peer"})
+	}
+	if static.Member != nil && static.Member.ServerSecret != "" {
+		conversions = append(conversions, SecretConversion{From: static.Member.ServerSecret, To: src.Name + "-server-tls", Prefix: "server"})
+	}
+	if static.OperatorSecret != "" {
+		conversions = append(conversions, SecretConversion{From: static.OperatorSecret, To: src.Name + "-client-tls", Prefix: "etcd-client"})
+	}
+	return conversions
+}
+
+// ConvertSecret returns kubernetes.io/tls copy of the CoreOS certificate secret.
+func ConvertSecret(src *corev1.Secret, conversion SecretConversion) (*corev1.Secret, error) {
+	keys := map[string]string{
+		conversion.Prefix + ".crt":    corev1.TLSCertKey,
+		conversion.Prefix + ".key":    corev1.TLSPrivateKeyKey,
+		conversion.Prefix + "-ca.crt": corev1.ServiceAccountRootCAKey,
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: conversion.To, Namespace: src.Namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{},
+	}
+	for from, to := range keys {
+		data, ok := src.Data[from]
+		if !ok {
+			return nil, fmt.Errorf("secret %s has no %s field", src.Name, from)
+		}
+		secret.Data[to] = data
+	}
+	return secret, nil
+}
+
+// Adopt replaces owner reference to the CoreOS cluster with controller reference to the converted cluster, so
+// removal of the CoreOS cluster doesn't garbage collect its members. It returns false if there was nothing to replace.
+func Adopt(obj metav1.Object, owner *etcdaenixiov1alpha1.EtcdCluster) bool {
+	refs := obj.GetOwnerReferences()
+	adopted := false
+	for i, ref := range refs {
+		if ref.APIVersion == coreosAPIVersion && ref.Kind == coreosKind {
+			refs[i] = *metav1.NewControllerRef(owner, etcdaenixiov1alpha1.GroupVersion.WithKind("EtcdCluster"))
+			adopted = true
+		}
+	}
+	obj.SetOwnerReferences(refs)
+	return adopted
+}
+
+// Main runs the migrate command with the given arguments and returns its exit code.
+// Manifests of converted clusters are written to stdout. With --apply the CoreOS clusters are paused, so the CoreOS
+// operator leaves them alone, certificate secrets are converted, the EtcdClusters are created and adopt pods,
+// services and volumes of the CoreOS clusters. The members keep running and are observed by the operator:
+// restore a snapshot into a managed EtcdCluster and move clients to it to complete the migration.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var namespace, name string
+	var apply bool
+	fs.StringVar(&namespace, "namespace", "default", "Namespace of the CoreOS EtcdClusters to migrate.")
+	fs.StringVar(&name, "name", "", "Name of the CoreOS EtcdCluster to migrate, all clusters of the namespace if empty.")
+	fs.BoolVar(&apply, "apply", false, "Create the EtcdClusters and adopt workloads of the CoreOS clusters instead of printing manifests.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := newClient()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cannot create Kubernetes client: %v\n", err)
+		return 1
+	}
+	sources, err := listCoreOSClusters(ctx, c, namespace, name)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cannot list CoreOS EtcdClusters: %v\n", err)
+		return 1
+	}
+	if len(sources) == 0 {
+		_, _ = fmt.Fprintf(stderr, "no CoreOS EtcdClusters found in namespace %s\n", namespace)
+		return 1
+	}
+
+	code := 0
+	for i := range sources {
+		src := &sources[i]
+		cluster, conversions, notes := Convert(src)
+		for _, note := range notes {
+			_, _ = fmt.Fprintf(stderr, "%s/%s: %s\n", src.Namespace, src.Name, note)
+		}
+		if !apply {
+			manifest, err := importer.Manifest(cluster)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "cannot render manifest: %v\n", err)
+				return 1
+			}
+			_, _ = stdout.Write(manifest)
+			for _, conversion := range conversions {
+				_, _ = fmt.Fprintf(stderr, "%s/%s: secret %s is converted to %s with --apply\n",
+					src.Namespace, src.Name, conversion.From, conversion.To)
+			}
+			continue
+		}
+		adopted, err := migrate(ctx, c, src, cluster, conversions)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "cannot migrate %s/%s: %v\n", src.Namespace, src.Name, err)
+			code = 1
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "etcdcluster %s/%s created, %d objects adopted\n", cluster.Namespace, cluster.Name, adopted)
+	}
+	if apply && code == 0 {
+		_, _ = fmt.Fprintln(stderr, "CoreOS EtcdClusters are paused and can be deleted with the CoreOS operator once "+
+			"the EtcdClusters are Ready")
+	}
+	return code
+}
+
+// migrate pauses the CoreOS cluster, converts its secrets, creates the cluster and adopts workloads of the CoreOS
+// cluster. It is safe to run again after a failure. It returns the number of adopted objects.
+func migrate(
+	ctx context.Context,
+	c client.Client,
+	src *CoreOSCluster,
+	cluster *etcdaenixiov1alpha1.EtcdCluster,
+	conversions []SecretConversion,
+) (int, error) {
+	if !src.Spec.Paused {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(coreosAPIVersion)
+		obj.SetKind(coreosKind)
+		obj.SetNamespace(src.Namespace)
+		obj.SetName(src.Name)
+		patch := client.RawPatch(types.MergePatchType, []byte(`{"spec":{"paused":true}}`))
+		if err := c.Patch(ctx, obj, patch); err != nil {
+			return 0, fmt.Errorf("cannot pause CoreOS cluster: %w", err)
+		}
+	}
+
+	for _, conversion := range conversions {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: src.Namespace, Name: conversion.From}, secret); err != nil {
+			return 0, err
+		}
+		converted, err := ConvertSecret(secret, conversion)
+		if err != nil {
+			return 0, err
+		}
+		if err := c.Create(ctx, converted); err != nil && !apierrors.IsAlreadyExists(err) {
+			return 0, fmt.Errorf("cannot create secret %s: %w", converted.Name, err)
+		}
+	}
+
+	if err := c.Create(ctx, cluster); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return 0, err
+		}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(cluster), cluster); err != nil {
+			return 0, err
+		}
+		if cluster.Annotations[etcdaenixiov1alpha1.MigratedFromAnnotation] != coreosAPIVersion {
+			return 0, errors.New("EtcdCluster with the same name already exists")
+		}
+	}
+
+	selector := client.MatchingLabels{coreosClusterLabel: src.Name}
+	lists := []client.ObjectList{&corev1.PodList{}, &corev1.ServiceList{}, &corev1.PersistentVolumeClaimList{}}
+	adopted := 0
+	for _, list := range lists {
+		if err := c.List(ctx, list, client.InNamespace(src.Namespace), selector); err != nil {
+			return adopted, err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return adopted, err
+		}
+		for _, item := range objs {
+			obj := item.(client.Object)
+			base := obj.DeepCopyObject().(client.Object)
+			if !Adopt(obj, cluster) {
+				continue
+			}
+			if err := c.Patch(ctx, obj, client.MergeFrom(base)); err != nil {
+				return adopted, fmt.Errorf("cannot adopt %s: %w", obj.GetName(), err)
+			}
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+// listCoreOSClusters returns the named CoreOS cluster or all clusters of the namespace if name is empty.
+func listCoreOSClusters(ctx context.Context, c client.Client, namespace, name string) ([]CoreOSCluster, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(coreosListGVK)
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var clusters []CoreOSCluster
+	for _, item := range list.Items {
+		if name != "" && item.GetName() != name {
+			continue
+		}
+		var cluster CoreOSCluster
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &cluster); err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %w", item.GetName(), err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// newClient creates client using kubeconfig of the current context or in-cluster configuration.
+func newClient() (client.Client, error) {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(etcdaenixiov1alpha1.AddToScheme(scheme))
+	return client.New(config, client.Options{Scheme: scheme})
+}
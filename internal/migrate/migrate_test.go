@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Migrate", func() {
+	var src *CoreOSCluster
+
+	BeforeEach(func() {
+		src = &CoreOSCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Spec:       CoreOSClusterSpec{Size: 3, Version: "3.2.13"},
+		}
+	})
+
+	It("should observe members through the client service", func() {
+		cluster, conversions, notes := Convert(src)
+		Expect(conversions).To(BeEmpty())
+		Expect(notes).To(BeEmpty())
+		Expect(cluster.IsObserved()).To(BeTrue())
+		Expect(cluster.Spec.ObservedEndpoints).To(Equal([]string{"http://test-client.ns.svc:2379"}))
+		Expect(*cluster.Spec.Replicas).To(BeEquivalentTo(3))
+		Expect(cluster.EtcdImage()).To(Equal("quay.io/coreos/etcd:v3.2.13"))
+		Expect(cluster.Spec.Storage.EmptyDir).NotTo(BeNil())
+		Expect(cluster.Spec.Storage.Ephemeral).To(BeTrue())
+		Expect(cluster.Annotations).To(HaveKeyWithValue(etcdaenixiov1alpha1.MigratedFromAnnotation, coreosAPIVersion))
+	})
+
+	It("should convert pod policy", func() {
+		src.Spec.Repository = "registry.example.com/etcd"
+		src.Spec.Pod = &CoreOSPodPolicy{
+			NodeSelector: map[string]string{"role": "etcd"},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+			EtcdEnv: []corev1.EnvVar{{Name: "ETCD_AUTO_COMPACTION_RETENTION", Value: "1"}},
+			PersistentVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("fast"),
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("8Gi")},
+				},
+			},
+		}
+		cluster, _, _ := Convert(src)
+		Expect(cluster.EtcdImage()).To(Equal("registry.example.com/etcd:v3.2.13"))
+		Expect(cluster.Spec.PodTemplate.Spec.NodeSelector).To(HaveKeyWithValue("role", "etcd"))
+		etcd := cluster.Spec.PodTemplate.Spec.Containers[0]
+		Expect(etcd.Resources.Limits.Memory().String()).To(Equal("1Gi"))
+		Expect(etcd.Env).To(HaveLen(1))
+		Expect(cluster.Spec.Storage.EmptyDir).To(BeNil())
+		Expect(cluster.Spec.Storage.VolumeClaimTemplate.Spec.StorageClassName).To(Equal(ptr.To("fast")))
+	})
+
+	It("should note missing version", func() {
+		src.Spec.Version = ""
+		cluster, _, notes := Convert(src)
+		Expect(notes).To(HaveLen(1))
+		Expect(cluster.EtcdImage()).To(Equal(etcdaenixiov1alpha1.DefaultEtcdImage))
+	})
+
+	It("should convert static TLS", func() {
+		src.Spec.TLS = &CoreOSTLSPolicy{Static: &CoreOSStaticTLS{
+			Member:         &CoreOSMemberSecret{PeerSecret: "etcd-peer", ServerSecret: "etcd-server"},
+			OperatorSecret: "etcd-operator-client",
+		}}
+		cluster, conversions, _ := Convert(src)
+		Expect(cluster.Spec.ObservedEndpoints).To(Equal([]string{"https://test-client.ns.svc:2379"}))
+		Expect(conversions).To(ConsistOf(
+			SecretConversion{From: "etcd-peer", To: "test-peer-tls", Prefix: "peer"},
+			SecretConversion{From: "etcd-server", To: "test-server-tls", Prefix: "server"},
+			SecretConversion{From: "etcd-operator-client", To: "test-client-tls", Prefix: "etcd-client"},
+		))
+		Expect(cluster.Spec.Security.TLS).To(Equal(etcdaenixiov1alpha1.TLSSpec{
+			PeerTrustedCASecret:   "test-peer-tls",
+			PeerSecret:            "test-peer-tls",
+			ServerSecret:          "test-server-tls",
+			ClientTrustedCASecret: "test-server-tls",
+			ClientSecret:          "test-client-tls",
+		}))
+	})
+
+	It("should convert certificate secrets", func() {
+		src := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd-server", Namespace: "ns"},
+			Data: map[string][]byte{
+				"server.crt":    []byte("cert"),
+				"server.key":    []byte("key"),
+				"server-ca.crt": []byte("ca"),
+			},
+		}
+		secret, err := ConvertSecret(src, SecretConversion{From: "etcd-server", To: "test-server-tls", Prefix: "server"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Name).To(Equal("test-server-tls"))
+		Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+		Expect(secret.Data).To(Equal(map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key"), "ca.crt": []byte("ca")}))
+
+		delete(src.Data, "server-ca.crt")
+		_, err = ConvertSecret(src, SecretConversion{From: "etcd-server", To: "test-server-tls", Prefix: "server"})
+		Expect(err).To(MatchError(ContainSubstring("server-ca.crt")))
+	})
+
+	It("should replace owner reference to the CoreOS cluster", func() {
+		owner := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", UID: "uid"}}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "other"},
+			{APIVersion: coreosAPIVersion, Kind: coreosKind, Name: "test", Controller: ptr.To(true)},
+		}}}
+		Expect(Adopt(pod, owner)).To(BeTrue())
+		Expect(pod.OwnerReferences).To(HaveLen(2))
+		Expect(pod.OwnerReferences[1].APIVersion).To(Equal(etcdaenixiov1alpha1.GroupVersion.String()))
+		Expect(pod.OwnerReferences[1].UID).To(BeEquivalentTo("uid"))
+		Expect(Adopt(pod, owner)).To(BeFalse())
+	})
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notify sends critical cluster events to webhooks, e.g. Slack incoming webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// Notification describes an event of a cluster.
+type Notification struct {
+	Namespace string                                `json:"namespace"`
+	Cluster   string                                `json:"cluster"`
+	Event     etcdaenixiov1alpha1.NotificationEvent `json:"event"`
+	Message   string                                `json:"message"`
+	Time      time.Time                             `json:"time"`
+}
+
+// Text returns human readable description of the notification.
+func (n *Notification) Text() string {
+	return fmt.Sprintf("EtcdCluster %s/%s: %s: %s", n.Namespace, n.Cluster, n.Event, n.Message)
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Send posts the notification to the webhook URL in the given format.
+func Send(ctx context.Context, client *http.Client, url string, format etcdaenixiov1alpha1.NotificationFormat, n Notification) error {
+	var payload any = n
+	if format == etcdaenixiov1alpha1.NotificationFormatSlack {
+		payload = slackMessage{Text: n.Text()}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", redactURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send notification: %w", redactURL(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// redactURL strips the URL from errors, since webhook URLs embed credentials.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Send", func() {
+	var (
+		server   *httptest.Server
+		received map[string]any
+		status   int
+	)
+	notification := Notification{
+		Namespace: "ns",
+		Cluster:   "test",
+		Event:     etcdaenixiov1alpha1.NotificationEventQuorumLost,
+		Message:   "1 of 3 members are ready, quorum requires 2",
+		Time:      time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		received = nil
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should send generic JSON document", func() {
+		Expect(Send(context.Background(), nil, server.URL, etcdaenixiov1alpha1.NotificationFormatGeneric, notification)).
+			To(Succeed())
+		Expect(received).To(Equal(map[string]any{
+			"namespace": "ns",
+			"cluster":   "test",
+			"event":     "QuorumLost",
+			"message":   "1 of 3 members are ready, quorum requires 2",
+			"time":      "2024-05-01T00:00:00Z",
+		}))
+	})
+
+	It("should send Slack message", func() {
+		Expect(Send(context.Background(), nil, server.URL, etcdaenixiov1alpha1.NotificationFormatSlack, notification)).
+			To(Succeed())
+		Expect(received).To(Equal(map[string]any{
+			"text": "EtcdCluster ns/test: QuorumLost: 1 of 3 members are ready, quorum requires 2",
+		}))
+	})
+
+	It("should fail on error status without revealing the URL", func() {
+		status = http.StatusForbidden
+		err := Send(context.Background(), nil, server.URL+"/secret-token", "", notification)
+		Expect(err).To(MatchError(ContainSubstring("403")))
+
+		server.Close()
+		err = Send(context.Background(), nil, server.URL+"/secret-token", "", notification)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).NotTo(ContainSubstring("secret-token"))
+	})
+})
+
+var _ = Describe("Tracker", func() {
+	const (
+		quorumLost    = etcdaenixiov1alpha1.NotificationEventQuorumLost
+		upgradeFailed = etcdaenixiov1alpha1.NotificationEventUpgradeFailed
+	)
+
+	It("should return events once per occurrence", func() {
+		tracker := NewTracker()
+		key := WebhookKey("ns", "test", "slack")
+		Expect(tracker.Observe(key, []etcdaenixiov1alpha1.NotificationEvent{quorumLost})).To(ConsistOf(quorumLost))
+		// not sent yet, so still pending
+		Expect(tracker.Observe(key, []etcdaenixiov1alpha1.NotificationEvent{quorumLost})).To(ConsistOf(quorumLost))
+		tracker.MarkSent(key, quorumLost)
+		Expect(tracker.Observe(key, []etcdaenixiov1alpha1.NotificationEvent{quorumLost, upgradeFailed})).
+			To(ConsistOf(upgradeFailed))
+		tracker.MarkSent(key, upgradeFailed)
+
+		// resolved event is sent again when it occurs anew
+		Expect(tracker.Observe(key, []etcdaenixiov1alpha1.NotificationEvent{upgradeFailed})).To(BeEmpty())
+		Expect(tracker.Observe(key, []etcdaenixiov1alpha1.NotificationEvent{quorumLost, upgradeFailed})).
+			To(ConsistOf(quorumLost))
+	})
+
+	It("should forget webhooks of deleted clusters", func() {
+		tracker := NewTracker()
+		tracker.MarkSent(WebhookKey("ns", "test", "slack"), quorumLost)
+		tracker.MarkSent(WebhookKey("ns", "other", "slack"), quorumLost)
+		tracker.Forget(WebhookKey("ns", "test", ""))
+		Expect(tracker.Observe(WebhookKey("ns", "test", "slack"), []etcdaenixiov1alpha1.NotificationEvent{quorumLost})).
+			To(ConsistOf(quorumLost))
+		Expect(tracker.Observe(WebhookKey("ns", "other", "slack"), []etcdaenixiov1alpha1.NotificationEvent{quorumLost})).
+			To(BeEmpty())
+	})
+})
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"strings"
+	"sync"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+// Tracker remembers events already sent to webhooks, so an event is sent once per occurrence.
+// State is kept in memory, so active events are sent again after restart of the operator.
+type Tracker struct {
+	mu   sync.Mutex
+	sent map[string]map[string]bool
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{sent: make(map[string]map[string]bool)}
+}
+
+// WebhookKey identifies a webhook of a cluster for the tracker.
+func WebhookKey(namespace, cluster, webhook string) string {
+	return namespace + "/" + cluster + "/" + webhook
+}
+
+// Observe records events currently active for the webhook and returns those not sent to it yet.
+// Events no longer active are forgotten, so they are sent again when they occur anew.
+func (t *Tracker) Observe(
+	webhook string,
+	active []etcdaenixiov1alpha1.NotificationEvent,
+) []etcdaenixiov1alpha1.NotificationEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sent := make(map[string]bool, len(active))
+	var pending []etcdaenixiov1alpha1.NotificationEvent
+	for _, event := range active {
+		if t.sent[webhook][string(event)] {
+			sent[string(event)] = true
+		} else {
+			pending = append(pending, event)
+		}
+	}
+	t.sent[webhook] = sent
+	return pending
+}
+
+// MarkSent records the event as sent to the webhook.
+func (t *Tracker) MarkSent(webhook string, event etcdaenixiov1alpha1.NotificationEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sent[webhook] == nil {
+		t.sent[webhook] = make(map[string]bool)
+	}
+	t.sent[webhook][string(event)] = true
+}
+
+// Forget drops state of webhooks with the given key prefix, e.g. for deleted clusters.
+func (t *Tracker) Forget(keyPrefix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for webhook := range t.sent {
+		if strings.HasPrefix(webhook, keyPrefix) {
+			delete(t.sent, webhook)
+		}
+	}
+}
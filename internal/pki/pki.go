@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pki issues certificates of etcd members and clients signed by a CA generated by the operator.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"time"
+)
+
+// clockSkewAllowance backdates certificates, so members with clocks slightly behind accept them right away.
+const clockSkewAllowance = 5 * time.Minute
+
+// CA signs certificates with its key.
+type CA struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// Request describes a certificate to issue.
+type Request struct {
+	CommonName  string
+	DNSNames    []string
+	IPAddresses []net.IP
+	Usages      []x509.ExtKeyUsage
+}
+
+// NewCA generates a self-signed CA valid for the duration from now.
+func NewCA(commonName string, now time.Time, duration time.Duration) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate CA key: %w", err)
+	}
+	template, err := newTemplate(commonName, now, duration)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Certificate: cert, Key: key}, nil
+}
+
+// LoadCA parses the PEM encoded CA certificate and its key.
+func LoadCA(certPEM, keyPEM []byte) (*CA, error) {
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded CA key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse CA key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("CA key can not sign")
+	}
+	return &CA{Certificate: cert, Key: signer}, nil
+}
+
+// Issue generates a key and a certificate of the request signed by the CA, valid for the duration from now
+// but not longer than the CA. Returns the PEM encoded certificate and key.
+func (ca *CA) Issue(request Request, now time.Time, duration time.Duration) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot generate key: %w", err)
+	}
+	template, err := newTemplate(request.CommonName, now, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	if template.NotAfter.After(ca.Certificate.NotAfter) {
+		template.NotAfter = ca.Certificate.NotAfter
+	}
+	template.DNSNames = request.DNSNames
+	template.IPAddresses = request.IPAddresses
+	template.ExtKeyUsage = request.Usages
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, key.Public(), ca.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create certificate: %w", err)
+	}
+	keyPEM, err = EncodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return EncodeCertificate(der), keyPEM, nil
+}
+
+// EncodeCertificate returns the PEM encoded DER certificate.
+func EncodeCertificate(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// EncodeKey returns the PEM encoded PKCS #8 private key.
+func EncodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseCertificate returns the first certificate of the PEM encoded chain.
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// RenewalTime returns when the certificate valid for the duration is renewed: renewBefore its expiry.
+func RenewalTime(cert *x509.Certificate, renewBefore time.Duration) time.Time {
+	return cert.NotAfter.Add(-renewBefore)
+}
+
+// IsIssuedFor returns true if the certificate is signed by the CA and covers names and addresses of the request.
+func IsIssuedFor(cert *x509.Certificate, ca *CA, request Request) bool {
+	if cert.CheckSignatureFrom(ca.Certificate) != nil || cert.Subject.CommonName != request.CommonName {
+		return false
+	}
+	for _, name := range request.DNSNames {
+		if !slices.Contains(cert.DNSNames, name) {
+			return false
+		}
+	}
+	for _, ip := range request.IPAddresses {
+		if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+			return false
+		}
+	}
+	return true
+}
+
+func newTemplate(commonName string, now time.Time, duration time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate serial number: %w", err)
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-clockSkewAllowance),
+		NotAfter:     now.Add(duration),
+	}, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CA", func() {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	request := Request{
+		CommonName:  "test-0",
+		DNSNames:    []string{"test-0.test-headless.ns.svc", "*.test-headless.ns.svc"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		Usages:      []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	It("should issue certificates verified by the CA", func() {
+		ca, err := NewCA("test-ca", now, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		certPEM, keyPEM, err := ca.Issue(request, now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tls.X509KeyPair(certPEM, keyPEM)
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, err := ParseCertificate(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IsIssuedFor(cert, ca, request)).To(BeTrue())
+		Expect(RenewalTime(cert, 20*time.Minute)).To(Equal(now.Add(40 * time.Minute)))
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Certificate)
+		_, err = cert.Verify(x509.VerifyOptions{
+			DNSName:     "test-0.test-headless.ns.svc",
+			Roots:       roots,
+			CurrentTime: now,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should not issue certificates outliving the CA", func() {
+		ca, err := NewCA("test-ca", now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		certPEM, _, err := ca.Issue(request, now, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := ParseCertificate(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.NotAfter).To(Equal(ca.Certificate.NotAfter))
+	})
+
+	It("should load the encoded CA", func() {
+		ca, err := NewCA("test-ca", now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		keyPEM, err := EncodeKey(ca.Key)
+		Expect(err).NotTo(HaveOccurred())
+		loaded, err := LoadCA(EncodeCertificate(ca.Certificate.Raw), keyPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded.Certificate.Equal(ca.Certificate)).To(BeTrue())
+
+		certPEM, _, err := loaded.Issue(request, now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := ParseCertificate(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IsIssuedFor(cert, ca, request)).To(BeTrue())
+	})
+
+	It("should not take certificates of other CAs or names", func() {
+		ca, err := NewCA("test-ca", now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		other, err := NewCA("other-ca", now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		certPEM, _, err := other.Issue(request, now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := ParseCertificate(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IsIssuedFor(cert, ca, request)).To(BeFalse())
+
+		certPEM, _, err = ca.Issue(request, now, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err = ParseCertificate(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		request := request
+		request.DNSNames = append([]string{"test-1.test-headless.ns.svc"}, request.DNSNames...)
+		Expect(IsIssuedFor(cert, ca, request)).To(BeFalse())
+	})
+})
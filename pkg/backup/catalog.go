@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+const (
+	// CatalogKey is the key of the catalog object relative to the catalog prefix.
+	CatalogKey = "catalog.json"
+	// DefaultCatalogSize is the number of the latest backups kept in the catalog.
+	DefaultCatalogSize = 10
+)
+
+// CatalogEntry describes a backup in the catalog.
+type CatalogEntry struct {
+	// Key of the backup object in the storage.
+	Key string `json:"key"`
+	// Location is a provider specific reference to the object for humans, e.g. s3://bucket/key.
+	Location string `json:"location,omitempty"`
+	// Size of the backup in bytes.
+	Size int64 `json:"size"`
+	// Revision of etcd the backup was taken at.
+	Revision int64 `json:"revision"`
+	// Time the backup was taken at.
+	Time time.Time `json:"time"`
+}
+
+// Catalog lists the latest backups newest first, so a restore point can be chosen without browsing the storage.
+type Catalog struct {
+	Entries []CatalogEntry `json:"entries"`
+}
+
+// Latest returns the newest backup taken at or before the time, false if there is none.
+func (c *Catalog) Latest(before time.Time) (CatalogEntry, bool) {
+	for _, entry := range c.Entries {
+		if !entry.Time.After(before) {
+			return entry, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// ReadCatalog returns the catalog stored under the prefix, empty catalog if there is none.
+func ReadCatalog(ctx context.Context, storage Storage, prefix string) (*Catalog, error) {
+	catalog := &Catalog{}
+	r, err := storage.Get(ctx, prefix+CatalogKey, 0)
+	if errors.Is(err, ErrNotFound) {
+		return catalog, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read backup catalog: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read backup catalog: %w", err)
+	}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("cannot decode backup catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// AddToCatalog records the backup in the catalog stored under the prefix and keeps only size latest entries,
+// DefaultCatalogSize if size is zero. Entries of the same key are replaced. The catalog is read and written
+// without locking, so there must be a single writer per catalog.
+func AddToCatalog(ctx context.Context, storage Storage, prefix string, entry CatalogEntry, size int) (*Catalog, error) {
+	if size == 0 {
+		size = DefaultCatalogSize
+	}
+	catalog, err := ReadCatalog(ctx, storage, prefix)
+	if err != nil {
+		return nil, err
+	}
+	catalog.Entries = slices.DeleteFunc(catalog.Entries, func(e CatalogEntry) bool {
+		return e.Key == entry.Key
+	})
+	catalog.Entries = append(catalog.Entries, entry)
+	slices.SortStableFunc(catalog.Entries, func(a, b CatalogEntry) int {
+		return cmp.Or(b.Time.Compare(a.Time), cmp.Compare(b.Revision, a.Revision))
+	})
+	if len(catalog.Entries) > size {
+		catalog.Entries = catalog.Entries[:size]
+	}
+
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode backup catalog: %w", err)
+	}
+	if err := storage.Put(ctx, prefix+CatalogKey, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("cannot write backup catalog: %w", err)
+	}
+	return catalog, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// memoryStorage keeps objects in memory, it does not support listing.
+type memoryStorage struct {
+	Storage
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *memoryStorage) Put(_ context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStorage) Get(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:])), nil
+}
+
+var _ = Describe("Catalog", func() {
+	var (
+		storage *memoryStorage
+		start   = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+
+	BeforeEach(func() {
+		storage = &memoryStorage{objects: map[string][]byte{}}
+	})
+
+	entry := func(i int) CatalogEntry {
+		return CatalogEntry{
+			Key:      "snapshots/" + string(rune('a'+i)),
+			Size:     int64(i),
+			Revision: int64(i * 100),
+			Time:     start.Add(time.Duration(i) * time.Hour),
+		}
+	}
+
+	It("is empty without backups", func(ctx SpecContext) {
+		catalog, err := ReadCatalog(ctx, storage, "cluster/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(catalog.Entries).To(BeEmpty())
+	})
+
+	It("keeps the latest backups newest first", func(ctx SpecContext) {
+		for _, i := range []int{2, 0, 3, 1} {
+			_, err := AddToCatalog(ctx, storage, "cluster/", entry(i), 3)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := AddToCatalog(ctx, storage, "cluster/", entry(3), 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		catalog, err := ReadCatalog(ctx, storage, "cluster/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(catalog.Entries).To(HaveLen(3))
+		Expect(catalog.Entries[0].Key).To(Equal(entry(3).Key))
+		Expect(catalog.Entries[2].Key).To(Equal(entry(1).Key))
+		Expect(storage.objects).To(HaveKey("cluster/" + CatalogKey))
+	})
+
+	It("returns the latest backup before the time", func() {
+		catalog := &Catalog{Entries: []CatalogEntry{entry(2), entry(1), entry(0)}}
+		latest, ok := catalog.Latest(start.Add(90 * time.Minute))
+		Expect(ok).To(BeTrue())
+		Expect(latest.Revision).To(Equal(int64(100)))
+		_, ok = catalog.Latest(start.Add(-time.Hour))
+		Expect(ok).To(BeFalse())
+	})
+})
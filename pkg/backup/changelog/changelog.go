@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package changelog implements experimental continuous backup of etcd. Watch events are streamed from a revision
+// covered by a snapshot into segments of object storage, which can later be replayed on top of the restored snapshot.
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCompacted is returned when the requested revision was compacted and the changelog can not be continued
+// without a new snapshot.
+var ErrCompacted = errors.New("changelog revision has been compacted")
+
+// EventType is the type of key change.
+type EventType string
+
+const (
+	EventTypePut    EventType = "put"
+	EventTypeDelete EventType = "delete"
+)
+
+// Event is a single change of a key. Events of the same transaction share the revision.
+type Event struct {
+	Revision int64     `json:"revision"`
+	Type     EventType `json:"type"`
+	Key      []byte    `json:"key"`
+	Value    []byte    `json:"value,omitempty"`
+	// Time is when the event was observed by the writer, etcd does not record time of changes.
+	Time time.Time `json:"time"`
+}
+
+// Segment is an object of storage holding events of consecutive revisions, encoded as JSON lines.
+type Segment struct {
+	Key           string
+	FirstRevision int64
+	LastRevision  int64
+}
+
+const segmentSuffix = ".jsonl"
+
+// SegmentKey returns key of the segment holding revisions from first to last. Revisions are zero padded,
+// so segments are listed in the order of revisions.
+func SegmentKey(prefix string, first, last int64) string {
+	return fmt.Sprintf("%s%020d-%020d%s", prefix, first, last, segmentSuffix)
+}
+
+// ParseSegmentKey returns segment for the key created by SegmentKey.
+func ParseSegmentKey(prefix, key string) (Segment, error) {
+	segment := Segment{Key: key}
+	if !strings.HasPrefix(key, prefix) {
+		return segment, fmt.Errorf("key %q has no prefix %q", key, prefix)
+	}
+	first, last, found := strings.Cut(strings.TrimSuffix(key[len(prefix):], segmentSuffix), "-")
+	if !found || !strings.HasSuffix(key, segmentSuffix) {
+		return segment, fmt.Errorf("key %q is not a changelog segment", key)
+	}
+	var err error
+	if segment.FirstRevision, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return segment, fmt.Errorf("key %q is not a changelog segment: %w", key, err)
+	}
+	if segment.LastRevision, err = strconv.ParseInt(last, 10, 64); err != nil {
+		return segment, fmt.Errorf("key %q is not a changelog segment: %w", key, err)
+	}
+	if segment.FirstRevision > segment.LastRevision {
+		return segment, fmt.Errorf("key %q has invalid revision range", key)
+	}
+	return segment, nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseSegmentKey", func() {
+	It("parses keys created by SegmentKey", func() {
+		segment, err := ParseSegmentKey("changelog/", SegmentKey("changelog/", 10, 25))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(segment.FirstRevision).To(Equal(int64(10)))
+		Expect(segment.LastRevision).To(Equal(int64(25)))
+	})
+
+	DescribeTable("rejects other keys",
+		func(key string) {
+			_, err := ParseSegmentKey("changelog/", key)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("other prefix", "snapshots/00000000000000000010-00000000000000000025.jsonl"),
+		Entry("other suffix", "changelog/00000000000000000010-00000000000000000025.db"),
+		Entry("no range", "changelog/00000000000000000010.jsonl"),
+		Entry("invalid range", "changelog/00000000000000000025-00000000000000000010.jsonl"),
+	)
+})
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+// maxTxnOps is the default limit of operations in etcd transaction. Larger revisions are applied in several
+// transactions.
+const maxTxnOps = 128
+
+// Target is the point to recover to. Zero fields do not limit the replay.
+type Target struct {
+	// Revision is the last revision of the source cluster to apply.
+	Revision int64
+	// Time is the latest time of events to apply.
+	Time time.Time
+}
+
+func (t Target) includes(event Event) bool {
+	if t.Revision != 0 && event.Revision > t.Revision {
+		return false
+	}
+	if !t.Time.IsZero() && event.Time.After(t.Time) {
+		return false
+	}
+	return true
+}
+
+// ReplayResult describes the applied events.
+type ReplayResult struct {
+	// LastRevision is the last applied revision of the source cluster.
+	LastRevision int64
+	// LastTime is the time of the last applied event.
+	LastTime time.Time
+	Events   int
+}
+
+// Replay applies events after the snapshot revision up to the target onto the cluster restored from the snapshot.
+// Events of one revision are applied in one transaction, so changes of the source transactions stay atomic.
+// Revisions of the restored cluster differ from the source ones, as etcd assigns new revisions to the changes.
+func Replay(
+	ctx context.Context,
+	storage backup.Storage,
+	prefix string,
+	kv clientv3.KV,
+	snapshotRevision int64,
+	target Target,
+) (ReplayResult, error) {
+	result := ReplayResult{LastRevision: snapshotRevision}
+	segments, err := ListSegments(ctx, storage, prefix)
+	if err != nil {
+		return result, err
+	}
+
+	// the changelog is checked up front, so incomplete one leaves the restored cluster untouched
+	next := snapshotRevision + 1
+	for _, segment := range segments {
+		if segment.LastRevision < next {
+			continue
+		}
+		if segment.FirstRevision > next && (target.Revision == 0 || segment.FirstRevision <= target.Revision) {
+			return result, fmt.Errorf("changelog has no events of revisions %d to %d", next, segment.FirstRevision-1)
+		}
+		next = segment.LastRevision + 1
+	}
+
+	var ops []clientv3.Op
+	revision := int64(0)
+	apply := func() error {
+		for len(ops) > 0 {
+			batch := ops[:min(len(ops), maxTxnOps)]
+			if _, err := kv.Txn(ctx).Then(batch...).Commit(); err != nil {
+				return fmt.Errorf("cannot apply revision %d: %w", revision, err)
+			}
+			ops = ops[len(batch):]
+		}
+		return nil
+	}
+
+	next = snapshotRevision + 1
+	for _, segment := range segments {
+		if segment.LastRevision < next {
+			continue
+		}
+		done, err := replaySegment(ctx, storage, segment.Key, func(event Event) (bool, error) {
+			if event.Revision < next {
+				return true, nil
+			}
+			if !target.includes(event) {
+				return false, nil
+			}
+			if event.Revision != revision {
+				if err := apply(); err != nil {
+					return false, err
+				}
+				revision = event.Revision
+			}
+			if event.Type == EventTypeDelete {
+				ops = append(ops, clientv3.OpDelete(string(event.Key)))
+			} else {
+				ops = append(ops, clientv3.OpPut(string(event.Key), string(event.Value)))
+			}
+			result.LastRevision = event.Revision
+			result.LastTime = event.Time
+			result.Events++
+			return true, nil
+		})
+		if err != nil {
+			return result, err
+		}
+		if done {
+			break
+		}
+		next = segment.LastRevision + 1
+	}
+	return result, apply()
+}
+
+// replaySegment calls fn for events of the segment while it returns true. It returns true if the replay is done.
+func replaySegment(
+	ctx context.Context,
+	storage backup.Storage,
+	key string,
+	fn func(Event) (bool, error),
+) (bool, error) {
+	r, err := storage.Get(ctx, key, 0)
+	if err != nil {
+		return false, fmt.Errorf("cannot read changelog segment %s: %w", key, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	decoder := json.NewDecoder(r)
+	for {
+		event := Event{}
+		if err := decoder.Decode(&event); errors.Is(err, io.EOF) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("cannot decode changelog segment %s: %w", key, err)
+		}
+		more, err := fn(event)
+		if err != nil || !more {
+			return true, err
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup/filesystem"
+)
+
+type fakeKV struct {
+	clientv3.KV
+	txns [][]clientv3.Op
+}
+
+func (kv *fakeKV) Txn(_ context.Context) clientv3.Txn {
+	return &fakeTxn{kv: kv}
+}
+
+type fakeTxn struct {
+	kv  *fakeKV
+	ops []clientv3.Op
+}
+
+func (t *fakeTxn) If(_ ...clientv3.Cmp) clientv3.Txn {
+	return t
+}
+
+func (t *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.ops = append(t.ops, ops...)
+	return t
+}
+
+func (t *fakeTxn) Else(_ ...clientv3.Op) clientv3.Txn {
+	return t
+}
+
+func (t *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	t.kv.txns = append(t.kv.txns, t.ops)
+	return &clientv3.TxnResponse{}, nil
+}
+
+var _ = Describe("Replay", func() {
+	var (
+		storage *filesystem.Storage
+		kv      *fakeKV
+		start   = time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	)
+
+	putSegment := func(ctx context.Context, events ...Event) {
+		data := &bytes.Buffer{}
+		for _, event := range events {
+			Expect(json.NewEncoder(data).Encode(event)).To(Succeed())
+		}
+		key := SegmentKey("changelog/", events[0].Revision, events[len(events)-1].Revision)
+		Expect(storage.Put(ctx, key, data)).To(Succeed())
+	}
+	put := func(revision int64, key, value string) Event {
+		return Event{Revision: revision, Type: EventTypePut, Key: []byte(key), Value: []byte(value),
+			Time: start.Add(time.Duration(revision) * time.Second)}
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		storage, err = filesystem.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		kv = &fakeKV{}
+
+		putSegment(ctx, put(5, "a", "1"), put(6, "b", "1"), put(6, "c", "1"))
+		putSegment(ctx, put(7, "a", "2"), Event{Revision: 8, Type: EventTypeDelete, Key: []byte("b"),
+			Time: start.Add(8 * time.Second)})
+	})
+
+	It("applies events after the snapshot revision grouped by revision", func(ctx SpecContext) {
+		result, err := Replay(ctx, storage, "changelog/", kv, 5, Target{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.LastRevision).To(Equal(int64(8)))
+		Expect(result.Events).To(Equal(4))
+		Expect(kv.txns).To(HaveLen(3))
+		Expect(kv.txns[0]).To(HaveLen(2))
+		Expect(kv.txns[2][0].IsDelete()).To(BeTrue())
+		Expect(string(kv.txns[2][0].KeyBytes())).To(Equal("b"))
+	})
+
+	It("stops at the target revision", func(ctx SpecContext) {
+		result, err := Replay(ctx, storage, "changelog/", kv, 4, Target{Revision: 6})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.LastRevision).To(Equal(int64(6)))
+		Expect(kv.txns).To(HaveLen(2))
+	})
+
+	It("stops at the target time", func(ctx SpecContext) {
+		result, err := Replay(ctx, storage, "changelog/", kv, 4, Target{Time: start.Add(7 * time.Second)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.LastRevision).To(Equal(int64(7)))
+		Expect(result.LastTime).To(Equal(start.Add(7 * time.Second)))
+	})
+
+	It("fails on missing revisions", func(ctx SpecContext) {
+		_, err := Replay(ctx, storage, "changelog/", kv, 2, Target{})
+		Expect(err).To(MatchError(ContainSubstring("no events of revisions 3 to 4")))
+		Expect(kv.txns).To(BeEmpty())
+	})
+
+	It("does not apply anything if later revisions are missing", func(ctx SpecContext) {
+		putSegment(ctx, put(10, "a", "3"))
+		_, err := Replay(ctx, storage, "changelog/", kv, 4, Target{})
+		Expect(err).To(MatchError(ContainSubstring("no events of revisions 9 to 9")))
+		Expect(kv.txns).To(BeEmpty())
+
+		_, err = Replay(ctx, storage, "changelog/", kv, 4, Target{Revision: 8})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+const (
+	// DefaultMaxSegmentSize is the size of encoded events after which the segment is uploaded.
+	DefaultMaxSegmentSize = 8 << 20
+	// DefaultFlushInterval bounds the time buffered events wait for upload, so it is the recovery point objective.
+	DefaultFlushInterval = time.Minute
+)
+
+// Writer streams watch events of all keys into changelog segments.
+type Writer struct {
+	Storage backup.Storage
+	// Prefix is prepended to keys of segments.
+	Prefix  string
+	Watcher clientv3.Watcher
+	// MaxSegmentSize is DefaultMaxSegmentSize if zero.
+	MaxSegmentSize int
+	// FlushInterval is DefaultFlushInterval if zero.
+	FlushInterval time.Duration
+
+	now func() time.Time
+}
+
+// Run writes events starting from the revision until the context is cancelled or the watch fails.
+// Buffered events are uploaded before returning. ErrCompacted is returned if the revision is no longer available,
+// then a new snapshot has to be taken and the changelog continued from its revision.
+func (w *Writer) Run(ctx context.Context, fromRevision int64) error {
+	maxSize := w.MaxSegmentSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSegmentSize
+	}
+	interval := w.FlushInterval
+	if interval == 0 {
+		interval = DefaultFlushInterval
+	}
+	now := w.now
+	if now == nil {
+		now = time.Now
+	}
+
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	watchChan := w.Watcher.Watch(watchCtx, "", clientv3.WithPrefix(), clientv3.WithRev(fromRevision))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	buffer := &segmentBuffer{}
+	flush := func() error {
+		if buffer.len() == 0 {
+			return nil
+		}
+		// buffered events are uploaded even if the writer is being stopped
+		key := SegmentKey(w.Prefix, buffer.first, buffer.last)
+		if err := w.Storage.Put(context.WithoutCancel(ctx), key, bytes.NewReader(buffer.data.Bytes())); err != nil {
+			return fmt.Errorf("cannot upload changelog segment %s: %w", key, err)
+		}
+		buffer.reset()
+		return nil
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case resp, ok := <-watchChan:
+			if !ok {
+				if err := flush(); err != nil {
+					return err
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("changelog watch closed")
+			}
+			if resp.CompactRevision != 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+				return fmt.Errorf("%w: compacted at revision %d", ErrCompacted, resp.CompactRevision)
+			}
+			if err := resp.Err(); err != nil {
+				if flushErr := flush(); flushErr != nil {
+					return flushErr
+				}
+				return fmt.Errorf("changelog watch failed: %w", err)
+			}
+			// watch responses are not fragmented, so segments always hold complete revisions
+			observed := now()
+			for _, ev := range resp.Events {
+				if err := buffer.add(eventFromWatch(ev, observed)); err != nil {
+					return err
+				}
+			}
+			if buffer.data.Len() >= maxSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func eventFromWatch(ev *clientv3.Event, observed time.Time) Event {
+	event := Event{
+		Revision: ev.Kv.ModRevision,
+		Type:     EventTypePut,
+		Key:      ev.Kv.Key,
+		Value:    ev.Kv.Value,
+		Time:     observed,
+	}
+	if ev.Type == clientv3.EventTypeDelete {
+		event.Type = EventTypeDelete
+		event.Value = nil
+	}
+	return event
+}
+
+type segmentBuffer struct {
+	data   bytes.Buffer
+	events int
+	first  int64
+	last   int64
+}
+
+func (b *segmentBuffer) add(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cannot encode changelog event: %w", err)
+	}
+	if b.events == 0 {
+		b.first = event.Revision
+	}
+	b.last = event.Revision
+	b.events++
+	b.data.Write(line)
+	b.data.WriteByte('\n')
+	return nil
+}
+
+func (b *segmentBuffer) len() int {
+	return b.events
+}
+
+func (b *segmentBuffer) reset() {
+	b.data.Reset()
+	b.events = 0
+}
+
+// ListSegments returns changelog segments sorted by revisions. Objects with other keys under the prefix are ignored.
+func ListSegments(ctx context.Context, storage backup.Storage, prefix string) ([]Segment, error) {
+	objects, err := storage.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list changelog segments: %w", err)
+	}
+	segments := make([]Segment, 0, len(objects))
+	for _, object := range objects {
+		segment, err := ParseSegmentKey(prefix, object.Key)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	slices.SortFunc(segments, func(a, b Segment) int {
+		return cmp.Compare(a.FirstRevision, b.FirstRevision)
+	})
+	return segments, nil
+}
+
+// NextRevision returns the revision the changelog continues from, or zero if there are no segments yet.
+func NextRevision(ctx context.Context, storage backup.Storage, prefix string) (int64, error) {
+	segments, err := ListSegments(ctx, storage, prefix)
+	if err != nil || len(segments) == 0 {
+		return 0, err
+	}
+	return segments[len(segments)-1].LastRevision + 1, nil
+}
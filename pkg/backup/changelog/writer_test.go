@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changelog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+	"github.com/aenix-io/etcd-operator/pkg/backup/filesystem"
+)
+
+type fakeWatcher struct {
+	responses chan clientv3.WatchResponse
+	revision  int64
+}
+
+func (w *fakeWatcher) Watch(_ context.Context, _ string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet("", opts...)
+	w.revision = op.Rev()
+	return w.responses
+}
+
+func (w *fakeWatcher) RequestProgress(_ context.Context) error {
+	return nil
+}
+
+func (w *fakeWatcher) Close() error {
+	return nil
+}
+
+func readEvents(ctx context.Context, storage backup.Storage, key string) []Event {
+	r, err := storage.Get(ctx, key, 0)
+	Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = r.Close() }()
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		event := Event{}
+		Expect(json.Unmarshal(scanner.Bytes(), &event)).To(Succeed())
+		events = append(events, event)
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	return events
+}
+
+var _ = Describe("Writer", func() {
+	var (
+		storage *filesystem.Storage
+		watcher *fakeWatcher
+		writer  *Writer
+	)
+
+	BeforeEach(func() {
+		var err error
+		storage, err = filesystem.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		watcher = &fakeWatcher{responses: make(chan clientv3.WatchResponse, 2)}
+		writer = &Writer{
+			Storage: storage,
+			Prefix:  "changelog/",
+			Watcher: watcher,
+			now:     func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+		}
+	})
+
+	It("uploads buffered events when the watch ends", func(ctx SpecContext) {
+		watcher.responses <- clientv3.WatchResponse{Events: []*clientv3.Event{
+			{Type: clientv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("a"), Value: []byte("1"), ModRevision: 11}},
+			{Type: clientv3.EventTypeDelete, Kv: &mvccpb.KeyValue{Key: []byte("b"), ModRevision: 12}},
+		}}
+		close(watcher.responses)
+
+		Expect(writer.Run(ctx, 11)).To(MatchError("changelog watch closed"))
+		Expect(watcher.revision).To(Equal(int64(11)))
+
+		segments, err := ListSegments(ctx, storage, "changelog/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(segments).To(HaveLen(1))
+		Expect(segments[0].FirstRevision).To(Equal(int64(11)))
+		Expect(segments[0].LastRevision).To(Equal(int64(12)))
+
+		events := readEvents(ctx, storage, segments[0].Key)
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Type).To(Equal(EventTypePut))
+		Expect(events[0].Value).To(Equal([]byte("1")))
+		Expect(events[1].Type).To(Equal(EventTypeDelete))
+		Expect(events[1].Key).To(Equal([]byte("b")))
+
+		Expect(NextRevision(ctx, storage, "changelog/")).To(Equal(int64(13)))
+	})
+
+	It("reports compaction of the requested revision", func(ctx SpecContext) {
+		watcher.responses <- clientv3.WatchResponse{CompactRevision: 20}
+		Expect(writer.Run(ctx, 11)).To(MatchError(ErrCompacted))
+	})
+
+	It("starts without segments", func(ctx SpecContext) {
+		Expect(NextRevision(ctx, storage, "changelog/")).To(BeZero())
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultDownloadRetries is the number of retries of a download failing without progress.
+	DefaultDownloadRetries = 5
+	// DefaultDownloadRetryInterval is the delay before the first retry, it doubles with every retry.
+	DefaultDownloadRetryInterval = 5 * time.Second
+
+	partialSuffix = ".part"
+)
+
+// Progress of a download.
+type Progress struct {
+	// Bytes is the size of downloaded data, including data downloaded by previous attempts.
+	Bytes int64
+	// Total is the size of the object, zero if unknown.
+	Total int64
+}
+
+// Percent returns downloaded part of the object in percents, zero if its size is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) * 100 / float64(p.Total)
+}
+
+// DownloadOptions configure Download.
+type DownloadOptions struct {
+	// Retries is DefaultDownloadRetries if zero. Retries are counted from the last attempt that made progress.
+	Retries int
+	// RetryInterval is DefaultDownloadRetryInterval if zero.
+	RetryInterval time.Duration
+	// OnProgress is called after every chunk written to the file.
+	OnProgress func(Progress)
+}
+
+// Download fetches the object into the file at path. Data is written into the file with .part suffix, which is kept
+// when the download fails, so the next attempt or the next call continues where the previous one stopped instead of
+// downloading the completed part again. The file is renamed to path when the download completes.
+func Download(ctx context.Context, storage Storage, key, path string, opts DownloadOptions) error {
+	if opts.Retries == 0 {
+		opts.Retries = DefaultDownloadRetries
+	}
+	if opts.RetryInterval == 0 {
+		opts.RetryInterval = DefaultDownloadRetryInterval
+	}
+
+	total, err := objectSize(ctx, storage, key)
+	if err != nil {
+		return err
+	}
+	partial := path + partialSuffix
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	downloaded, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if downloaded > total {
+		// the object was replaced by a smaller one since the previous attempt
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		downloaded = 0
+	}
+
+	retries, interval := 0, opts.RetryInterval
+	for downloaded < total {
+		written, err := downloadFrom(ctx, storage, key, f, downloaded, total, opts.OnProgress)
+		downloaded += written
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrNotFound) || ctx.Err() != nil {
+			return err
+		}
+		if written > 0 {
+			retries, interval = 0, opts.RetryInterval
+		}
+		if retries >= opts.Retries {
+			return fmt.Errorf("cannot download %s after %d retries: %w", key, retries, err)
+		}
+		retries++
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, path)
+}
+
+func objectSize(ctx context.Context, storage Storage, key string) (int64, error) {
+	objects, err := storage.List(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	for _, object := range objects {
+		if object.Key == key {
+			return object.Size, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func downloadFrom(
+	ctx context.Context,
+	storage Storage,
+	key string,
+	w io.Writer,
+	offset, total int64,
+	onProgress func(Progress),
+) (int64, error) {
+	r, err := storage.Get(ctx, key, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = r.Close() }()
+	written, err := io.Copy(&progressWriter{w: w, offset: offset, total: total, onProgress: onProgress}, r)
+	if err == nil && offset+written < total {
+		err = io.ErrUnexpectedEOF
+	}
+	return written, err
+}
+
+type progressWriter struct {
+	w          io.Writer
+	offset     int64
+	total      int64
+	onProgress func(Progress)
+}
+
+func (p *progressWriter) Write(data []byte) (int, error) {
+	n, err := p.w.Write(data)
+	p.offset += int64(n)
+	if p.onProgress != nil {
+		p.onProgress(Progress{Bytes: p.offset, Total: p.total})
+	}
+	return n, err
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// flakyStorage serves a single object and breaks the first reads after failAfter bytes.
+type flakyStorage struct {
+	Storage
+	key       string
+	data      string
+	failAfter int
+	failures  int
+	offsets   []int64
+}
+
+func (s *flakyStorage) List(_ context.Context, _ string) ([]Object, error) {
+	return []Object{{Key: s.key, Size: int64(len(s.data))}}, nil
+}
+
+func (s *flakyStorage) Get(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	if key != s.key {
+		return nil, ErrNotFound
+	}
+	s.offsets = append(s.offsets, offset)
+	data := s.data[offset:]
+	if s.failures > 0 {
+		s.failures--
+		return io.NopCloser(io.MultiReader(strings.NewReader(data[:min(len(data), s.failAfter)]),
+			errorReader{})), nil
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+type errorReader struct{}
+
+func (errorReader) Read(_ []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+var _ = Describe("Download", func() {
+	var (
+		storage *flakyStorage
+		path    string
+	)
+
+	BeforeEach(func() {
+		storage = &flakyStorage{key: "snapshot.db", data: "0123456789", failAfter: 4}
+		path = filepath.Join(GinkgoT().TempDir(), "snapshot.db")
+	})
+
+	It("resumes interrupted downloads and reports progress", func(ctx SpecContext) {
+		storage.failures = 2
+		var progress []Progress
+		Expect(Download(ctx, storage, "snapshot.db", path, DownloadOptions{
+			RetryInterval: time.Millisecond,
+			OnProgress:    func(p Progress) { progress = append(progress, p) },
+		})).To(Succeed())
+
+		Expect(os.ReadFile(path)).To(Equal([]byte("0123456789")))
+		Expect(storage.offsets).To(Equal([]int64{0, 4, 8}))
+		Expect(progress[len(progress)-1]).To(Equal(Progress{Bytes: 10, Total: 10}))
+		Expect(progress[len(progress)-1].Percent()).To(Equal(float64(100)))
+		Expect(path + partialSuffix).NotTo(BeAnExistingFile())
+	})
+
+	It("continues partial file of the previous call", func(ctx SpecContext) {
+		Expect(os.WriteFile(path+partialSuffix, []byte("012345"), 0o600)).To(Succeed())
+		Expect(Download(ctx, storage, "snapshot.db", path, DownloadOptions{})).To(Succeed())
+		Expect(os.ReadFile(path)).To(Equal([]byte("0123456789")))
+		Expect(storage.offsets).To(Equal([]int64{6}))
+	})
+
+	It("gives up after retries without progress", func(ctx SpecContext) {
+		storage.failures = 10
+		storage.failAfter = 0
+		err := Download(ctx, storage, "snapshot.db", path, DownloadOptions{Retries: 2, RetryInterval: time.Millisecond})
+		Expect(err).To(MatchError(ContainSubstring("after 2 retries")))
+		Expect(storage.offsets).To(HaveLen(3))
+	})
+
+	It("fails on missing objects", func(ctx SpecContext) {
+		Expect(Download(ctx, storage, "missing.db", path, DownloadOptions{})).To(MatchError(ErrNotFound))
+	})
+})
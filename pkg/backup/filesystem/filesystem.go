@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filesystem implements backup storage in a local directory, e.g. a mounted PersistentVolumeClaim.
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+// ProviderName is the name the provider is registered with.
+const ProviderName = "filesystem"
+
+// tmpPrefix marks files being written, they are not listed.
+const tmpPrefix = ".tmp-"
+
+func init() {
+	backup.Register(ProviderName, func(_ context.Context, config map[string]string) (backup.Storage, error) {
+		return New(config["path"])
+	})
+}
+
+// Storage keeps backup objects as files under the root directory. Keys are slash separated paths.
+type Storage struct {
+	root string
+}
+
+var _ backup.Storage = &Storage{}
+
+// New returns Storage rooted at existing directory.
+func New(root string) (*Storage, error) {
+	if root == "" {
+		return nil, fmt.Errorf("filesystem storage: path is required")
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem storage: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("filesystem storage: %s is not a directory", root)
+	}
+	return &Storage{root: root}, nil
+}
+
+func (s *Storage) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) || strings.HasPrefix(filepath.Base(name), tmpPrefix) {
+		return "", fmt.Errorf("filesystem storage: invalid key %q", key)
+	}
+	return filepath.Join(s.root, name), nil
+}
+
+// Put writes content into a temporary file and renames it to the key, so readers never see partial objects.
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = io.Copy(f, &contextReader{ctx: ctx, r: r})
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *Storage) Get(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, backup.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *Storage) List(_ context.Context, prefix string) ([]backup.Object, error) {
+	objects := make([]backup.Object, 0)
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), tmpPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, backup.Object{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(objects, func(a, b backup.Object) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return objects, nil
+}
+
+func (s *Storage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// contextReader stops copying once the context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
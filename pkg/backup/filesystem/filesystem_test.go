@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+var _ = Describe("Filesystem storage", func() {
+	var storage *Storage
+
+	BeforeEach(func() {
+		var err error
+		storage, err = New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	read := func(ctx SpecContext, key string, offset int64) string {
+		r, err := storage.Get(ctx, key, offset)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = r.Close() }()
+		data, err := io.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	It("stores and reads objects from offset", func(ctx SpecContext) {
+		Expect(storage.Put(ctx, "cluster/snapshot.db", strings.NewReader("0123456789"))).To(Succeed())
+		Expect(read(ctx, "cluster/snapshot.db", 0)).To(Equal("0123456789"))
+		Expect(read(ctx, "cluster/snapshot.db", 4)).To(Equal("456789"))
+	})
+
+	It("lists objects by prefix", func(ctx SpecContext) {
+		Expect(storage.Put(ctx, "a/2", strings.NewReader("22"))).To(Succeed())
+		Expect(storage.Put(ctx, "a/1", strings.NewReader("1"))).To(Succeed())
+		Expect(storage.Put(ctx, "b/1", strings.NewReader("1"))).To(Succeed())
+
+		objects, err := storage.List(ctx, "a/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(HaveLen(2))
+		Expect(objects[0].Key).To(Equal("a/1"))
+		Expect(objects[1].Key).To(Equal("a/2"))
+		Expect(objects[1].Size).To(Equal(int64(2)))
+	})
+
+	It("deletes objects", func(ctx SpecContext) {
+		Expect(storage.Put(ctx, "snapshot.db", strings.NewReader("data"))).To(Succeed())
+		Expect(storage.Delete(ctx, "snapshot.db")).To(Succeed())
+		_, err := storage.Get(ctx, "snapshot.db", 0)
+		Expect(err).To(MatchError(backup.ErrNotFound))
+		Expect(storage.Delete(ctx, "snapshot.db")).To(Succeed())
+	})
+
+	It("rejects keys escaping the root", func(ctx SpecContext) {
+		Expect(storage.Put(ctx, "../snapshot.db", strings.NewReader("data"))).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,336 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package s3 implements backup storage in S3 compatible object storages. Google Cloud Storage is supported
+// through its XML API with HMAC keys and is registered as a separate provider with the matching endpoint.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+const (
+	// ProviderName is the name the S3 provider is registered with.
+	ProviderName = "s3"
+	// GCSProviderName is the name the Google Cloud Storage provider is registered with.
+	GCSProviderName = "gcs"
+
+	gcsEndpoint = "https://storage.googleapis.com"
+
+	// DefaultPartSize is the size of parts of multipart uploads. Objects smaller than a part are uploaded at once.
+	DefaultPartSize = 16 << 20
+	// minPartSize is the minimal size of all but the last part allowed by S3.
+	minPartSize = 5 << 20
+)
+
+func init() {
+	backup.Register(ProviderName, func(_ context.Context, config map[string]string) (backup.Storage, error) {
+		return New(configFromMap(config))
+	})
+	backup.Register(GCSProviderName, func(_ context.Context, config map[string]string) (backup.Storage, error) {
+		cfg := configFromMap(config)
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = gcsEndpoint
+		}
+		if cfg.Region == "" {
+			cfg.Region = "auto"
+		}
+		return New(cfg)
+	})
+}
+
+// Config configures S3 storage.
+type Config struct {
+	// Endpoint is the URL of S3 API, e.g. https://s3.eu-central-1.amazonaws.com.
+	Endpoint string
+	// Region is used to sign requests.
+	Region string
+	// Bucket to store objects in.
+	Bucket string
+	// Prefix is prepended to all keys.
+	Prefix string
+	// AccessKeyID and SecretAccessKey are the credentials used to sign requests.
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses bucket in the path instead of the host name, as required by most S3 compatible storages.
+	PathStyle bool
+	// PartSize is the size of multipart upload parts, DefaultPartSize if zero.
+	PartSize int64
+	// HTTPClient is used to make requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// configFromMap reads Config from provider configuration keys named after the Config fields in lower camel case.
+func configFromMap(config map[string]string) Config {
+	cfg := Config{
+		Endpoint:        config["endpoint"],
+		Region:          config["region"],
+		Bucket:          config["bucket"],
+		Prefix:          config["prefix"],
+		AccessKeyID:     config["accessKeyID"],
+		SecretAccessKey: config["secretAccessKey"],
+	}
+	cfg.PathStyle, _ = strconv.ParseBool(config["pathStyle"])
+	cfg.PartSize, _ = strconv.ParseInt(config["partSize"], 10, 64)
+	return cfg
+}
+
+// Storage keeps backup objects in S3 bucket.
+type Storage struct {
+	cfg      Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+var _ backup.Storage = &Storage{}
+
+// New returns Storage using the bucket.
+func New(cfg Config) (*Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("s3 storage: endpoint, region and bucket are required")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: invalid endpoint: %w", err)
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < minPartSize {
+		return nil, fmt.Errorf("s3 storage: part size must be at least %d bytes", minPartSize)
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &Storage{cfg: cfg, endpoint: endpoint, now: time.Now}, nil
+}
+
+// objectURL returns URL of the object, or of the bucket if key is empty.
+func (s *Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.cfg.PathStyle {
+		path += "/" + s.cfg.Bucket
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	path += "/"
+	if key != "" {
+		path += s.cfg.Prefix + key
+	}
+	u.Path = path
+	u.RawPath = canonicalURI(&u)
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+func (s *Storage) do(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = sha256Hex(body)
+	}
+	signRequest(req, s.cfg.AccessKeyID, s.cfg.SecretAccessKey, s.cfg.Region, payloadHash, s.now())
+	resp, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, backup.ErrNotFound
+		}
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+func (s *Storage) doAndClose(ctx context.Context, method string, u *url.URL, body []byte, header http.Header) (*http.Response, []byte, error) {
+	resp, err := s.do(ctx, method, u, body, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	return resp, data, err
+}
+
+// Put uploads objects smaller than a part with a single request, larger ones with multipart upload,
+// so only one part is kept in memory.
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader) error {
+	part := make([]byte, s.cfg.PartSize)
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		_, _, err = s.doAndClose(ctx, http.MethodPut, s.objectURL(key, nil), part[:n], nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, data, err := s.doAndClose(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploads": {""}}), nil, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create multipart upload: %w", err)
+	}
+	initiated := struct {
+		UploadID string `xml:"UploadId"`
+	}{}
+	if err := xml.Unmarshal(data, &initiated); err != nil {
+		return fmt.Errorf("cannot decode multipart upload: %w", err)
+	}
+
+	if err := s.uploadParts(ctx, key, initiated.UploadID, r, part[:n]); err != nil {
+		abortURL := s.objectURL(key, url.Values{"uploadId": {initiated.UploadID}})
+		if _, _, abortErr := s.doAndClose(context.WithoutCancel(ctx), http.MethodDelete, abortURL, nil, nil); abortErr != nil {
+			return fmt.Errorf("%w; cannot abort multipart upload: %s", err, abortErr.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *Storage) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, first []byte) error {
+	var parts []completedPart
+	part := first
+	for {
+		number := len(parts) + 1
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, _, err := s.doAndClose(ctx, http.MethodPut, s.objectURL(key, query), part, nil)
+		if err != nil {
+			return fmt.Errorf("cannot upload part %d: %w", number, err)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		buf := make([]byte, s.cfg.PartSize)
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part = buf[:n]
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	_, data, err := s.doAndClose(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploadId": {uploadID}}), complete, nil)
+	if err != nil {
+		return fmt.Errorf("cannot complete multipart upload: %w", err)
+	}
+	// S3 may report failure of the completion in the body of successful response
+	if bytes.Contains(data, []byte("<Error>")) {
+		return fmt.Errorf("cannot complete multipart upload: %s", string(data))
+	}
+	return nil
+}
+
+func (s *Storage) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key, nil), nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *Storage) List(ctx context.Context, prefix string) ([]backup.Object, error) {
+	objects := make([]backup.Object, 0)
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		_, data, err := s.doAndClose(ctx, http.MethodGet, s.objectURL("", query), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}{}
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("cannot decode object list: %w", err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, backup.Object{
+				Key:          strings.TrimPrefix(content.Key, s.cfg.Prefix),
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	_, _, err := s.doAndClose(ctx, http.MethodDelete, s.objectURL(key, nil), nil, nil)
+	if err == backup.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	apiErr := struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}{}
+	if xml.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+		return fmt.Errorf("s3 storage: %s: %s", apiErr.Code, apiErr.Message)
+	}
+	return fmt.Errorf("s3 storage: unexpected response status %s", resp.Status)
+}
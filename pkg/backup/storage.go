@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup defines the object storage interface etcd backups are written to and the registry of
+// storage providers. Providers register themselves in init, so custom providers are compiled in with a blank import:
+//
+//	import _ "example.com/etcd-backup-swift"
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Storage when the requested object does not exist.
+var ErrNotFound = errors.New("backup object not found")
+
+// Object describes an object in backup storage.
+type Object struct {
+	// Key is the name of the object relative to the storage root.
+	Key string
+	// Size of the object in bytes.
+	Size int64
+	// LastModified is the time the object was written.
+	LastModified time.Time
+}
+
+// Storage is an object storage for etcd backups. Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores content read from r under key, replacing the existing object. Content is streamed,
+	// so its size does not need to be known in advance. Partially written objects are never visible.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns content of the object starting at offset, so interrupted downloads can be resumed.
+	// Returns ErrNotFound if the object does not exist.
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	// List returns objects with keys starting with prefix sorted by key.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Provider creates Storage from provider specific configuration.
+type Provider func(ctx context.Context, config map[string]string) (Storage, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+// Register makes storage provider available by name. It panics if provider is nil or registered twice.
+func Register(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if provider == nil {
+		panic("backup: Register provider is nil")
+	}
+	if _, dup := providers[name]; dup {
+		panic("backup: Register called twice for provider " + name)
+	}
+	providers[name] = provider
+}
+
+// Open returns Storage of the registered provider.
+func Open(ctx context.Context, name string, config map[string]string) (Storage, error) {
+	providersMu.RLock()
+	provider, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("backup: unknown storage provider %q", name)
+	}
+	return provider(ctx, config)
+}
+
+// Providers returns sorted names of registered providers.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
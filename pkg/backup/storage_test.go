@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Register", func() {
+	provider := func(_ context.Context, _ map[string]string) (Storage, error) {
+		return nil, errors.New("test provider")
+	}
+
+	It("opens registered providers", func(ctx SpecContext) {
+		Register("test-open", provider)
+		Expect(Providers()).To(ContainElement("test-open"))
+		_, err := Open(ctx, "test-open", nil)
+		Expect(err).To(MatchError("test provider"))
+	})
+
+	It("fails to open unknown providers", func(ctx SpecContext) {
+		_, err := Open(ctx, "test-unknown", nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("panics on duplicate registration", func() {
+		Register("test-duplicate", provider)
+		Expect(func() { Register("test-duplicate", provider) }).To(Panic())
+	})
+
+	It("panics on nil provider", func() {
+		Expect(func() { Register("test-nil", nil) }).To(Panic())
+	})
+})
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client drives etcd-operator from Go programs. Platform automation creates clusters, waits for them,
+// backs them up and reads their connection secrets through it instead of copying internals of the operator.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+	"github.com/aenix-io/etcd-operator/internal/controller/factory"
+	"github.com/aenix-io/etcd-operator/pkg/backup"
+)
+
+const (
+	// DefaultPollInterval is the interval WaitReady checks the cluster at.
+	DefaultPollInterval = 5 * time.Second
+
+	etcdDialTimeout    = 5 * time.Second
+	etcdRequestTimeout = 5 * time.Second
+)
+
+// ErrNoConnectionSecret is returned by GetConnectionSecret until the operator publishes the admin access secret.
+var ErrNoConnectionSecret = errors.New("cluster has no connection secret yet")
+
+// Client manages EtcdClusters through the Kubernetes API.
+type Client struct {
+	client ctrlclient.Client
+	// PollInterval is DefaultPollInterval if zero.
+	PollInterval time.Duration
+}
+
+// New returns Client using the given Kubernetes client. Its scheme must include core and etcd.aenix.io types.
+func New(c ctrlclient.Client) *Client {
+	return &Client{client: c}
+}
+
+// NewForConfig returns Client connected to the Kubernetes API with the given config.
+func NewForConfig(config *rest.Config) (*Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(etcdaenixiov1alpha1.AddToScheme(scheme))
+	c, err := ctrlclient.New(config, ctrlclient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Kubernetes client: %w", err)
+	}
+	return New(c), nil
+}
+
+// CreateCluster creates the cluster. Omitted fields are defaulted by the operator webhook.
+func (c *Client) CreateCluster(ctx context.Context, cluster *etcdaenixiov1alpha1.EtcdCluster) error {
+	if err := c.client.Create(ctx, cluster); err != nil {
+		return fmt.Errorf("cannot create EtcdCluster: %w", err)
+	}
+	return nil
+}
+
+// WaitReady waits until the operator reports the cluster Ready for its current spec and returns the cluster.
+// Use a context with deadline to bound the wait.
+func (c *Client) WaitReady(ctx context.Context, key types.NamespacedName) (*etcdaenixiov1alpha1.EtcdCluster, error) {
+	interval := c.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	var message string
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		if err := c.client.Get(ctx, key, cluster); err != nil {
+			return false, fmt.Errorf("cannot get EtcdCluster: %w", err)
+		}
+		var ready bool
+		ready, message = IsReady(cluster)
+		return ready, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s is not ready: %s: %w", key, message, err)
+	}
+	return cluster, nil
+}
+
+// IsReady returns true if the Ready condition of the cluster is true for its current generation, otherwise
+// the reason the cluster is not ready.
+func IsReady(cluster *etcdaenixiov1alpha1.EtcdCluster) (bool, string) {
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, etcdaenixiov1alpha1.EtcdConditionReady)
+	if condition == nil {
+		return false, "cluster has not been reconciled yet"
+	}
+	if condition.ObservedGeneration != cluster.Generation {
+		return false, "latest spec has not been reconciled yet"
+	}
+	return condition.Status == metav1.ConditionTrue, condition.Message
+}
+
+// GetConnectionSecret returns the secret with etcdctl environment of the cluster, see AdminAccessStatus.
+// Returns ErrNoConnectionSecret if the operator has not published it yet.
+func (c *Client) GetConnectionSecret(ctx context.Context, key types.NamespacedName) (*corev1.Secret, error) {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	if err := c.client.Get(ctx, key, cluster); err != nil {
+		return nil, fmt.Errorf("cannot get EtcdCluster: %w", err)
+	}
+	if cluster.Status.AdminAccess == nil || cluster.Status.AdminAccess.SecretName == "" {
+		return nil, ErrNoConnectionSecret
+	}
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Namespace: key.Namespace, Name: cluster.Status.AdminAccess.SecretName}
+	if err := c.client.Get(ctx, secretKey, secret); err != nil {
+		return nil, fmt.Errorf("cannot get connection secret: %w", err)
+	}
+	return secret, nil
+}
+
+// BackupOptions configure TriggerBackup.
+type BackupOptions struct {
+	// Prefix of the snapshot and of the backup catalog in the storage.
+	Prefix string
+	// Endpoints override client URLs of members, e.g. when the program runs outside of the Kubernetes cluster.
+	Endpoints []string
+	// CatalogSize is backup.DefaultCatalogSize if zero.
+	CatalogSize int
+}
+
+// SnapshotKey returns the storage key of the snapshot taken at the revision.
+func SnapshotKey(prefix string, revision int64) string {
+	return fmt.Sprintf("%ssnapshot-%020d.db", prefix, revision)
+}
+
+// TriggerBackup takes a snapshot of the cluster, stores it and records it in the backup catalog under the prefix.
+// The operator has no backup schedule of its own, so the snapshot is streamed through the calling program.
+// Revision of the entry is read from the member right before the snapshot, which may include later changes.
+func (c *Client) TriggerBackup(
+	ctx context.Context,
+	key types.NamespacedName,
+	storage backup.Storage,
+	opts BackupOptions,
+) (backup.CatalogEntry, error) {
+	cluster := &etcdaenixiov1alpha1.EtcdCluster{}
+	if err := c.client.Get(ctx, key, cluster); err != nil {
+		return backup.CatalogEntry{}, fmt.Errorf("cannot get EtcdCluster: %w", err)
+	}
+	tlsConfig, err := factory.GetEtcdTLSConfig(ctx, cluster, c.client)
+	if err != nil {
+		return backup.CatalogEntry{}, err
+	}
+	username, password, err := factory.GetEtcdCredentials(ctx, cluster, c.client)
+	if err != nil {
+		return backup.CatalogEntry{}, err
+	}
+	endpoints := opts.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = factory.GetMemberClientEndpoints(cluster)
+	}
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		TLS:         tlsConfig,
+		Username:    username,
+		Password:    password,
+		DialTimeout: etcdDialTimeout,
+		Context:     ctx,
+	})
+	if err != nil {
+		return backup.CatalogEntry{}, fmt.Errorf("cannot connect to etcd: %w", err)
+	}
+	defer func() { _ = cli.Close() }()
+
+	endpoint, revision, err := getSnapshotMember(ctx, cli)
+	if err != nil {
+		return backup.CatalogEntry{}, err
+	}
+	// the snapshot is streamed from the member the revision was read from
+	cli.SetEndpoints(endpoint)
+	snapshot, err := cli.Snapshot(ctx)
+	if err != nil {
+		return backup.CatalogEntry{}, fmt.Errorf("cannot take snapshot: %w", err)
+	}
+	defer func() { _ = snapshot.Close() }()
+
+	entry := backup.CatalogEntry{
+		Key:      SnapshotKey(opts.Prefix, revision),
+		Revision: revision,
+		Time:     time.Now().UTC(),
+	}
+	r := &countingReader{r: snapshot}
+	if err := storage.Put(ctx, entry.Key, r); err != nil {
+		return backup.CatalogEntry{}, fmt.Errorf("cannot store snapshot: %w", err)
+	}
+	entry.Size = r.n
+	if _, err := backup.AddToCatalog(ctx, storage, opts.Prefix, entry, opts.CatalogSize); err != nil {
+		return backup.CatalogEntry{}, err
+	}
+	return entry, nil
+}
+
+// getSnapshotMember returns the first reachable member and its revision.
+func getSnapshotMember(ctx context.Context, cli *clientv3.Client) (string, int64, error) {
+	var errs []error
+	for _, endpoint := range cli.Endpoints() {
+		reqCtx, cancel := context.WithTimeout(ctx, etcdRequestTimeout)
+		resp, err := cli.Status(reqCtx, endpoint)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+			continue
+		}
+		return endpoint, resp.Header.Revision, nil
+	}
+	return "", 0, fmt.Errorf("no etcd member is reachable: %w", errors.Join(errs...))
+}
+
+// countingReader counts bytes read, as the snapshot size is not known in advance.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	etcdaenixiov1alpha1 "github.com/aenix-io/etcd-operator/api/v1alpha1"
+)
+
+var _ = Describe("Client", func() {
+	It("reports clusters ready for their current generation", func() {
+		cluster := &etcdaenixiov1alpha1.EtcdCluster{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+		ready, message := IsReady(cluster)
+		Expect(ready).To(BeFalse())
+		Expect(message).To(Equal("cluster has not been reconciled yet"))
+
+		cluster.Status.Conditions = []metav1.Condition{{
+			Type:               etcdaenixiov1alpha1.EtcdConditionReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: 1,
+		}}
+		ready, message = IsReady(cluster)
+		Expect(ready).To(BeFalse())
+		Expect(message).To(Equal("latest spec has not been reconciled yet"))
+
+		cluster.Status.Conditions[0].ObservedGeneration = 2
+		ready, _ = IsReady(cluster)
+		Expect(ready).To(BeTrue())
+
+		cluster.Status.Conditions[0].Status = metav1.ConditionFalse
+		cluster.Status.Conditions[0].Message = "Waiting for first quorum to be established"
+		ready, message = IsReady(cluster)
+		Expect(ready).To(BeFalse())
+		Expect(message).To(Equal("Waiting for first quorum to be established"))
+	})
+
+	It("orders snapshot keys by revision", func() {
+		Expect(SnapshotKey("prod/", 42)).To(Equal("prod/snapshot-00000000000000000042.db"))
+		Expect(SnapshotKey("", 9) < SnapshotKey("", 10)).To(BeTrue())
+	})
+})
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcdhealth checks health of etcd clusters. It is used by the operator to set the Ready condition and by
+// tests, so both evaluate health the same way.
+package etcdhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	// SentinelKey is watched by health checks, it is never written.
+	SentinelKey = "/etcd-operator/health"
+	// DefaultTimeout is the timeout of each health check request.
+	DefaultTimeout = 5 * time.Second
+
+	// leaseTTL is the TTL in seconds of the probe lease, it expires on its own if revocation fails.
+	leaseTTL    = 10
+	dialTimeout = 5 * time.Second
+)
+
+// Check is a subsystem of etcd checked for health.
+type Check string
+
+const (
+	CheckStatus Check = "Status"
+	CheckLease  Check = "Lease"
+	CheckWatch  Check = "Watch"
+)
+
+// Error is a failed health check.
+type Error struct {
+	// Check is the failing subsystem.
+	Check Check
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// EndpointResult is the result of a status request to an endpoint.
+type EndpointResult struct {
+	Endpoint string
+	// Status is nil if the endpoint did not respond.
+	Status *clientv3.StatusResponse
+	Err    error
+}
+
+// Options configure Check.
+type Options struct {
+	// MinHealthy is the number of endpoints which must respond to status requests, quorum of endpoints if zero.
+	MinHealthy int
+	// Timeout of each request, DefaultTimeout if zero.
+	Timeout time.Duration
+}
+
+// Quorum returns the number of voting members required for quorum.
+func Quorum(members int) int {
+	return members/2 + 1
+}
+
+// NewClient returns etcd client connected to the endpoints. TLS config is nil if etcd serves clients without TLS.
+func NewClient(ctx context.Context, endpoints []string, tlsConfig *tls.Config) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		TLS:         tlsConfig,
+		DialTimeout: dialTimeout,
+		Context:     ctx,
+	})
+}
+
+// CheckCluster checks that enough endpoints of the client respond to status requests and that lease and watch
+// requests are served, because a cluster can answer status requests while its lease or watch subsystem is wedged.
+// Results of all endpoints are returned even if the check fails. Returned errors are of type *Error.
+func CheckCluster(ctx context.Context, cli *clientv3.Client, opts Options) ([]EndpointResult, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	results := CheckEndpoints(ctx, cli, opts.Timeout)
+	minHealthy := opts.MinHealthy
+	if minHealthy == 0 {
+		minHealthy = Quorum(len(results))
+	}
+	responding := 0
+	for _, result := range results {
+		if result.Err == nil {
+			responding++
+		}
+	}
+	if responding == 0 || responding < minHealthy {
+		return results, &Error{
+			Check: CheckStatus,
+			Err:   fmt.Errorf("%d of %d endpoints respond to status requests", responding, len(results)),
+		}
+	}
+	return results, CheckLeaseAndWatch(ctx, cli, opts.Timeout)
+}
+
+// CheckEndpoints sends a status request to every endpoint of the client.
+func CheckEndpoints(ctx context.Context, cli *clientv3.Client, timeout time.Duration) []EndpointResult {
+	endpoints := cli.Endpoints()
+	results := make([]EndpointResult, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		resp, err := cli.Status(reqCtx, endpoint)
+		cancel()
+		results = append(results, EndpointResult{Endpoint: endpoint, Status: resp, Err: err})
+	}
+	return results
+}
+
+// CheckLeaseAndWatch grants and revokes a probe lease and opens and closes a watch on the sentinel key.
+// Returned errors are of type *Error.
+func CheckLeaseAndWatch(ctx context.Context, cli *clientv3.Client, timeout time.Duration) error {
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	lease, err := cli.Grant(reqCtx, leaseTTL)
+	if err != nil {
+		return &Error{Check: CheckLease, Err: fmt.Errorf("cannot grant lease: %w", err)}
+	}
+	if _, err := cli.Revoke(reqCtx, lease.ID); err != nil {
+		return &Error{Check: CheckLease, Err: fmt.Errorf("cannot revoke lease %x: %w", lease.ID, err)}
+	}
+
+	watchCtx, cancelWatch := context.WithTimeout(clientv3.WithRequireLeader(ctx), timeout)
+	defer cancelWatch()
+	watch := cli.Watch(watchCtx, SentinelKey, clientv3.WithCreatedNotify())
+	select {
+	case resp, ok := <-watch:
+		switch {
+		case !ok:
+			err = errors.New("watch closed")
+		case resp.Err() != nil:
+			err = resp.Err()
+		case !resp.Created:
+			err = errors.New("watch was not created")
+		}
+	case <-watchCtx.Done():
+		err = watchCtx.Err()
+	}
+	if err != nil {
+		return &Error{Check: CheckWatch, Err: fmt.Errorf("cannot watch key %s: %w", SentinelKey, err)}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The etcd-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcdhealth
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health check", func() {
+	It("should compute quorum of members", func() {
+		Expect(Quorum(1)).To(Equal(1))
+		Expect(Quorum(3)).To(Equal(2))
+		Expect(Quorum(4)).To(Equal(3))
+		Expect(Quorum(5)).To(Equal(3))
+	})
+
+	It("should fail the status check if endpoints do not respond", func(ctx SpecContext) {
+		cli, err := NewClient(ctx, []string{"http://127.0.0.1:1"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(cli.Close)
+
+		results, err := CheckCluster(ctx, cli, Options{Timeout: 100 * time.Millisecond})
+		var failure *Error
+		Expect(errors.As(err, &failure)).To(BeTrue())
+		Expect(failure.Check).To(Equal(CheckStatus))
+		Expect(failure).To(MatchError("0 of 1 endpoints respond to status requests"))
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Endpoint).To(Equal("http://127.0.0.1:1"))
+		Expect(results[0].Status).To(BeNil())
+		Expect(results[0].Err).To(HaveOccurred())
+	})
+})
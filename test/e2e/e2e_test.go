@@ -17,9 +17,11 @@ limitations under the License.
 package e2e
 
 import (
+	"context"
 	"os/exec"
 	"strconv"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -101,4 +103,81 @@ var _ = Describe("etcd-operator", Ordered, func() {
 			}
 		})
 	})
+
+	Context("Multiple clusters in a namespace", func() {
+		It("should isolate clusters with prefixed names", func() {
+			var err error
+			const namespace = "test-multiple-etcd-clusters"
+			clusters := []string{"test", "test-2"}
+
+			By("create namespace")
+			cmd := exec.Command("kubectl", "create", "namespace", namespace)
+			_, err = utils.Run(cmd)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+			By("apply multiple etcd clusters manifest")
+			dir, _ := utils.GetProjectDir()
+			cmd = exec.Command("kubectl", "apply",
+				"--filename", dir+"/examples/manifests/etcdcluster-multiple.yaml",
+				"--namespace", namespace,
+			)
+			_, err = utils.Run(cmd)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+			endpoints := make(map[string][]string, len(clusters))
+			for _, cluster := range clusters {
+				By("wait for statefulset " + cluster + " is ready")
+				cmd = exec.Command("kubectl", "wait",
+					"statefulset/"+cluster,
+					"--for", "jsonpath={.status.availableReplicas}=3",
+					"--namespace", namespace,
+					"--timeout", "5m",
+				)
+				_, err = utils.Run(cmd)
+				ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+				By("port-forward service " + cluster + " to localhost")
+				port, _ := utils.GetFreePort()
+				go func() {
+					defer GinkgoRecover()
+					cmd := exec.Command("kubectl", "port-forward",
+						"service/"+cluster, strconv.Itoa(port)+":2379",
+						"--namespace", namespace,
+					)
+					_, _ = utils.Run(cmd)
+				}()
+				endpoints[cluster] = []string{"localhost:" + strconv.Itoa(port)}
+				Eventually(func() bool { return utils.IsEtcdClusterHealthy(endpoints[cluster]) }, time.Minute).
+					Should(BeTrue())
+			}
+
+			By("check each cluster has only its own members")
+			for _, cluster := range clusters {
+				cli := utils.GetEtcdClient(endpoints[cluster])
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				members, err := cli.MemberList(ctx)
+				cancel()
+				_ = cli.Close()
+				Expect(err).NotTo(HaveOccurred())
+				names := make([]string, 0, len(members.Members))
+				for _, member := range members.Members {
+					names = append(names, member.Name)
+				}
+				Expect(names).To(ConsistOf(cluster+"-0", cluster+"-1", cluster+"-2"))
+			}
+
+			By("check data written to one cluster is not visible in the other")
+			first := utils.GetEtcdClient(endpoints[clusters[0]])
+			defer func() { _ = first.Close() }()
+			second := utils.GetEtcdClient(endpoints[clusters[1]])
+			defer func() { _ = second.Close() }()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, err = first.Put(ctx, "isolation", clusters[0])
+			Expect(err).NotTo(HaveOccurred())
+			resp, err := second.Get(ctx, "isolation")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Kvs).To(BeEmpty())
+		})
+	})
 })
@@ -30,6 +30,8 @@ import (
 	"time"
 
 	. "github.com/onsi/ginkgo/v2" //nolint:golint,revive
+
+	"github.com/aenix-io/etcd-operator/pkg/etcdhealth"
 )
 
 // Run executes the provided command within this context
@@ -112,11 +114,8 @@ func GetEtcdClient(endpoints []string) *clientv3.Client {
 	return cli
 }
 
-// IsEtcdClusterHealthy checks etcd cluster health.
+// IsEtcdClusterHealthy checks etcd cluster health the same way the operator does.
 func IsEtcdClusterHealthy(endpoints []string) bool {
-	// Should be changed when etcd is healthy
-	health := false
-
 	// Configure client
 	client := GetEtcdClient(endpoints)
 	defer func(client *clientv3.Client) {
@@ -126,24 +125,21 @@ func IsEtcdClusterHealthy(endpoints []string) bool {
 		}
 	}(client)
 
-	// Prepare the maintenance client
-	maint := clientv3.NewMaintenance(client)
-
-	// Context for the call
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	// Context for the check
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Perform the status call to check health
-	for i := range endpoints {
-		resp, err := maint.Status(ctx, endpoints[i])
-		if err != nil {
-			log.Fatalf("Failed to get endpoint health: %v", err)
-		} else {
-			if resp.Errors == nil {
-				fmt.Printf("Endpoint is healthy: %s\n", resp.Version)
-				health = true
-			}
+	results, err := etcdhealth.CheckCluster(ctx, client, etcdhealth.Options{Timeout: 2 * time.Second})
+	for _, result := range results {
+		if result.Err != nil {
+			_, _ = fmt.Fprintf(GinkgoWriter, "Endpoint %s is unhealthy: %v\n", result.Endpoint, result.Err)
+			continue
 		}
+		_, _ = fmt.Fprintf(GinkgoWriter, "Endpoint %s is healthy: %s\n", result.Endpoint, result.Status.Version)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(GinkgoWriter, "Cluster is unhealthy: %v\n", err)
+		return false
 	}
-	return health
+	return true
 }